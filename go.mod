@@ -5,9 +5,14 @@ go 1.24.0
 require (
 	github.com/briandowns/spinner v1.23.0
 	github.com/fatih/color v1.16.0
+	github.com/google/jsonschema-go v0.4.2
+	github.com/klauspost/compress v1.18.0
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/schollz/progressbar/v3 v3.14.2
 	github.com/urfave/cli/v2 v2.27.1
+	golang.org/x/term v0.17.0
+	golang.org/x/text v0.32.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -20,6 +25,4 @@ require (
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/xrash/smetrics v0.0.0-20201216005158-039620a65673 // indirect
 	golang.org/x/sys v0.17.0 // indirect
-	golang.org/x/term v0.17.0 // indirect
-	golang.org/x/text v0.32.0 // indirect
 )
@@ -0,0 +1,168 @@
+package config
+
+import "fmt"
+
+// ConfigBuilder собирает конфигурацию цепочкой вызовов вместо заполнения вложенных структур:
+//
+//	cfg, err := NewConfigBuilder().
+//		WithTimezone("UTC").
+//		OpenPort(443).
+//		AllowIP("10.0.0.0/8").
+//		EnableFail2ban().
+//		AddPackages("basic", "vim", "git").
+//		Build()
+//
+// Ошибки отдельных шагов и ValidateConfig возвращаются из Build.
+type ConfigBuilder struct {
+	cfg Config
+	err error
+}
+
+// NewConfigBuilder создает построитель пустой конфигурации.
+// Для изменения готовой конфигурации используйте NewConfigBuilderFrom.
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{}
+}
+
+// NewConfigBuilderFrom создает построитель на основе копии base, например DefaultConfig()
+func NewConfigBuilderFrom(base *Config) *ConfigBuilder {
+	b := &ConfigBuilder{}
+	if base != nil {
+		b.cfg = *base
+	}
+	return b
+}
+
+// fail запоминает первую ошибку шага; последующие шаги выполняются, но Build ее вернет
+func (b *ConfigBuilder) fail(err error) *ConfigBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// WithTimezone задает часовой пояс
+func (b *ConfigBuilder) WithTimezone(timezone string) *ConfigBuilder {
+	b.cfg.System.Timezone = timezone
+	return b
+}
+
+// WithHostname задает имя хоста
+func (b *ConfigBuilder) WithHostname(hostname string) *ConfigBuilder {
+	b.cfg.System.Hostname = hostname
+	return b
+}
+
+// WithSwapSize задает размер swap-файла (2G, 512M)
+func (b *ConfigBuilder) WithSwapSize(size string) *ConfigBuilder {
+	b.cfg.System.SwapSize = size
+	return b
+}
+
+// WithLocale задает язык и локаль системы
+func (b *ConfigBuilder) WithLocale(language, locale string) *ConfigBuilder {
+	b.cfg.System.Language = language
+	b.cfg.System.Locale = locale
+	return b
+}
+
+// WithSSHPort задает порт SSH
+func (b *ConfigBuilder) WithSSHPort(port int) *ConfigBuilder {
+	b.cfg.Security.SSHPort = port
+	return b
+}
+
+// OpenPort добавляет открываемые порты; уже добавленные порты не повторяются
+func (b *ConfigBuilder) OpenPort(ports ...int) *ConfigBuilder {
+	for _, port := range ports {
+		if !containsInt(b.cfg.Security.OpenPorts, port) {
+			b.cfg.Security.OpenPorts = append(b.cfg.Security.OpenPorts, port)
+		}
+	}
+	return b
+}
+
+// AllowIP добавляет разрешенные адреса или подсети (10.0.0.0/8)
+func (b *ConfigBuilder) AllowIP(ips ...string) *ConfigBuilder {
+	b.cfg.Security.AllowIPs = mergeStrings(b.cfg.Security.AllowIPs, ips)
+	return b
+}
+
+// EnableUFW включает настройку фаервола
+func (b *ConfigBuilder) EnableUFW() *ConfigBuilder {
+	b.cfg.Security.EnableUFW = Bool(true)
+	return b
+}
+
+// EnableFail2ban включает установку и настройку fail2ban
+func (b *ConfigBuilder) EnableFail2ban() *ConfigBuilder {
+	b.cfg.Security.EnableFail2ban = Bool(true)
+	return b
+}
+
+// AddFirewallRule добавляет правило фаервола
+func (b *ConfigBuilder) AddFirewallRule(rule FirewallRule) *ConfigBuilder {
+	b.cfg.Security.FirewallRules = append(b.cfg.Security.FirewallRules, rule)
+	return b
+}
+
+// WithSSHHardening задает блок настроек sshd; nil возвращает значения по умолчанию
+func (b *ConfigBuilder) WithSSHHardening(hardening *SSHHardening) *ConfigBuilder {
+	b.cfg.Security.SSHHardening = hardening
+	return b
+}
+
+// AddPackages добавляет пакеты в категорию; уже перечисленные пакеты не повторяются
+func (b *ConfigBuilder) AddPackages(category string, names ...string) *ConfigBuilder {
+	list := b.cfg.Packages.categoryList(category)
+	if list == nil {
+		return b.fail(fmt.Errorf("неизвестная категория пакетов: %s", category))
+	}
+	for _, name := range names {
+		if name == "" || !containsPackage(list.Names(), name) {
+			*list = append(*list, PackageEntry{Name: name})
+		}
+	}
+	return b
+}
+
+// ExcludePackages исключает пакеты из встроенной категории
+func (b *ConfigBuilder) ExcludePackages(category string, names ...string) *ConfigBuilder {
+	if b.cfg.Packages.categoryList(category) == nil {
+		return b.fail(fmt.Errorf("неизвестная категория пакетов: %s", category))
+	}
+	if b.cfg.Packages.Exclude == nil {
+		b.cfg.Packages.Exclude = make(map[string][]string)
+	}
+	b.cfg.Packages.Exclude[category] = mergeStrings(b.cfg.Packages.Exclude[category], names)
+	return b
+}
+
+// WithMaintenanceWindow ограничивает время рискованных операций
+func (b *ConfigBuilder) WithMaintenanceWindow(window *MaintenanceWindow) *ConfigBuilder {
+	b.cfg.Maintenance = window
+	return b
+}
+
+// Build проверяет собранную конфигурацию через ValidateConfig и возвращает ее.
+// Каждый вызов возвращает новую структуру; срезы и словари разделяются с построителем.
+func (b *ConfigBuilder) Build() (*Config, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	cfg := b.cfg
+	if err := ValidateConfig(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// containsInt проверяет наличие числа в срезе
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
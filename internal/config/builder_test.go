@@ -0,0 +1,94 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigBuilderMatchesHandBuiltConfig(t *testing.T) {
+	cfg, err := NewConfigBuilder().
+		WithTimezone("UTC").
+		OpenPort(443).
+		OpenPort(80, 443).
+		AllowIP("10.0.0.0/8").
+		EnableFail2ban().
+		AddPackages("basic", "vim", "git").
+		AddPackages("basic", "git").
+		AddPackages("web", "nginx").
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{}
+	want.System.Timezone = "UTC"
+	want.Security.OpenPorts = []int{443, 80}
+	want.Security.AllowIPs = []string{"10.0.0.0/8"}
+	want.Security.EnableFail2ban = Bool(true)
+	want.Packages.Basic = NewPackageList("vim", "git")
+	want.Packages.Web = NewPackageList("nginx")
+	if !reflect.DeepEqual(cfg, want) {
+		t.Fatalf("Build() = %+v\nожидалось %+v", cfg, want)
+	}
+}
+
+func TestConfigBuilderFromDefault(t *testing.T) {
+	base := DefaultConfig()
+	cfg, err := NewConfigBuilderFrom(base).WithSSHPort(2222).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Security.SSHPort != 2222 {
+		t.Errorf("SSHPort = %d, ожидалось 2222", cfg.Security.SSHPort)
+	}
+	if base.Security.SSHPort == 2222 {
+		t.Error("построитель изменил исходную конфигурацию")
+	}
+
+	want := DefaultConfig()
+	want.Security.SSHPort = 2222
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Build() = %+v\nожидалось %+v", cfg, want)
+	}
+}
+
+func TestConfigBuilderBuildErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *ConfigBuilder
+		want    string
+	}{
+		{"timezone", NewConfigBuilder().WithTimezone("Mars/Olympus_Mons"), "Mars/Olympus_Mons"},
+		{"empty timezone", NewConfigBuilder(), "часовой пояс"},
+		{"unknown category", NewConfigBuilder().WithTimezone("UTC").AddPackages("games", "nethack"), "games"},
+		{"unknown exclude category", NewConfigBuilder().WithTimezone("UTC").ExcludePackages("games", "nethack"), "games"},
+		{"unsafe package", NewConfigBuilder().WithTimezone("UTC").AddPackages("basic", "vim;reboot"), "vim;reboot"},
+		{"open port", NewConfigBuilder().WithTimezone("UTC").OpenPort(70000), "70000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := tt.builder.Build()
+			if err == nil {
+				t.Fatalf("Build() = %+v, ожидалась ошибка", cfg)
+			}
+			if cfg != nil {
+				t.Error("при ошибке Build должен возвращать nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ошибка %q должна содержать %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigBuilderKeepsFirstStepError(t *testing.T) {
+	_, err := NewConfigBuilder().
+		AddPackages("games", "nethack").
+		AddPackages("toys", "sl").
+		WithTimezone("UTC").
+		Build()
+	if err == nil || !strings.Contains(err.Error(), "games") {
+		t.Fatalf("ошибка %v, ожидалась ошибка первого шага", err)
+	}
+}
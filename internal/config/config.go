@@ -3,18 +3,57 @@
 package config
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 )
 
 // Config представляет основную конфигурацию утилиты
 type Config struct {
+	// Schema - ссылка на JSON Schema для автодополнения в редакторе (см. GenerateJSONSchema)
+	Schema string `json:"$schema,omitempty"`
+	// Include - файлы конфигурации, на которые накладывается этот файл (см. LoadConfig)
+	Include  []string       `json:"include,omitempty"`
 	System   SystemConfig   `json:"system"`
 	Security SecurityConfig `json:"security"`
 	Packages PackagesConfig `json:"packages"`
+	Clean    CleanConfig    `json:"clean"`
+	Hooks    HooksConfig    `json:"hooks"`
+	Files    FilesConfig    `json:"files,omitempty"`
+	Phases   PhasesConfig   `json:"phases,omitempty"`
+	Disk     DiskConfig     `json:"disk,omitempty"`
+	// Maintenance ограничивает время рискованных операций; nil - без ограничений
+	Maintenance *MaintenanceWindow `json:"maintenance_window,omitempty"`
+}
+
+// PhasesConfig определяет, какими областями системы управляет Apply.
+// Пустое значение (nil) означает true, чтобы старые конфигурации работали как раньше
+type PhasesConfig struct {
+	ManageFirewall *bool `json:"manage_firewall,omitempty"`
+	ManageSSH      *bool `json:"manage_ssh,omitempty"`
+	ManageSwap     *bool `json:"manage_swap,omitempty"`
+	ManagePackages *bool `json:"manage_packages,omitempty"`
+	ManageTimezone *bool `json:"manage_timezone,omitempty"`
+}
+
+// Manages сообщает, включен ли флаг фазы: nil означает true
+func Manages(flag *bool) bool {
+	return flag == nil || *flag
+}
+
+// Enabled сообщает, включен ли флаг, который по умолчанию выключен: nil означает false
+func Enabled(flag *bool) bool {
+	return flag != nil && *flag
+}
+
+// Bool возвращает указатель на значение флага
+func Bool(value bool) *bool {
+	return &value
 }
 
 // SystemConfig содержит настройки системы
@@ -26,14 +65,86 @@ type SystemConfig struct {
 	Locale   string `json:"locale"`
 }
 
-// SecurityConfig содержит настройки безопасности
+// FilesConfig задает права создаваемых файлов в восьмеричной записи ("0600").
+// Пустые поля означают безопасные значения по умолчанию
+type FilesConfig struct {
+	// ConfigMode - права файла конфигурации go-to-run (по умолчанию 0600)
+	ConfigMode string `json:"config_mode,omitempty"`
+	// SystemMode - права системных файлов: /etc/timezone, sysctl.d (по умолчанию 0644)
+	SystemMode string `json:"system_mode,omitempty"`
+	// SensitiveMode - права sshd_config и jail.local (по умолчанию 0600)
+	SensitiveMode string `json:"sensitive_mode,omitempty"`
+}
+
+// DefaultConfigFileMode - права файла конфигурации go-to-run по умолчанию
+const DefaultConfigFileMode os.FileMode = 0600
+
+// ParseFileMode разбирает права файла в восьмеричной записи.
+// Права с записью для всех и специальные биты (setuid, sticky) не допускаются
+func ParseFileMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0777 {
+		return 0, fmt.Errorf("некорректные права файла: %s", mode)
+	}
+	if value&0002 != 0 {
+		return 0, fmt.Errorf("права %s разрешают запись всем пользователям", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// DiskConfig задает, какие файловые системы показываются в отчетах о дисках.
+// По умолчанию скрываются snap (squashfs, loop-устройства, /snap) и overlay
+type DiskConfig struct {
+	// ShowAll отключает фильтры по умолчанию и показывает все файловые системы; nil - false
+	ShowAll *bool `json:"show_all,omitempty"`
+	// IncludeMounts - префиксы точек монтирования, которые показываются всегда
+	IncludeMounts []string `json:"include_mounts,omitempty"`
+	// ExcludeMounts - дополнительные префиксы скрываемых точек монтирования
+	ExcludeMounts []string `json:"exclude_mounts,omitempty"`
+	// ExcludeFSTypes - дополнительные скрываемые типы файловых систем
+	ExcludeFSTypes []string `json:"exclude_fs_types,omitempty"`
+}
+
+// SecurityConfig содержит настройки безопасности.
+// Флаги Enable* - указатели: при объединении конфигураций false отличается от незаданного (nil - false)
 type SecurityConfig struct {
 	SSHPort        int            `json:"ssh_port"`
 	OpenPorts      []int          `json:"open_ports"`
 	AllowIPs       []string       `json:"allow_ips"`
-	EnableUFW      bool           `json:"enable_ufw"`
-	EnableFail2ban bool           `json:"enable_fail2ban"`
+	EnableUFW      *bool          `json:"enable_ufw"`
+	EnableFail2ban *bool          `json:"enable_fail2ban"`
 	FirewallRules  []FirewallRule `json:"firewall_rules"`
+	// SSHBackupKeep - сколько последних бэкапов sshd_config хранить (0 - по умолчанию, 5)
+	SSHBackupKeep int `json:"ssh_backup_keep,omitempty"`
+	// SSHHardening задает блок рекомендуемых настроек sshd; nil - значения по умолчанию
+	SSHHardening *SSHHardening `json:"ssh_hardening,omitempty"`
+	// PermitRootLogin и PasswordAuth задают одноименные директивы sshd;
+	// nil оставляет текущие значения в sshd_config без изменений
+	PermitRootLogin *bool `json:"permit_root_login,omitempty"`
+	PasswordAuth    *bool `json:"password_auth,omitempty"`
+}
+
+// SSHHardening содержит рекомендуемые директивы sshd.
+// Нулевые и пустые поля не записываются в конфигурацию.
+type SSHHardening struct {
+	// Disabled отключает запись блока целиком
+	Disabled            bool   `json:"disabled,omitempty"`
+	ClientAliveInterval int    `json:"client_alive_interval,omitempty"`
+	ClientAliveCountMax int    `json:"client_alive_count_max,omitempty"`
+	MaxAuthTries        int    `json:"max_auth_tries,omitempty"`
+	MaxSessions         int    `json:"max_sessions,omitempty"`
+	X11Forwarding       string `json:"x11_forwarding,omitempty"`
+}
+
+// DefaultSSHHardening возвращает рекомендуемые настройки sshd
+func DefaultSSHHardening() *SSHHardening {
+	return &SSHHardening{
+		ClientAliveInterval: 300,
+		ClientAliveCountMax: 2,
+		MaxAuthTries:        3,
+		MaxSessions:         10,
+		X11Forwarding:       "no",
+	}
 }
 
 // FirewallRule представляет правило фаервола
@@ -42,21 +153,87 @@ type FirewallRule struct {
 	Protocol string `json:"protocol"`
 	Action   string `json:"action"`
 	Comment  string `json:"comment"`
+	// Tags группируют правила (dev, staging, prod) для выборочного применения
+	// через ApplyFirewallTags; правила без тегов применяются, только если фильтр не задан
+	Tags []string `json:"tags,omitempty"`
 }
 
 // PackagesConfig содержит настройки пакетов
 type PackagesConfig struct {
-	Basic       []string `json:"basic"`
-	Network     []string `json:"network"`
-	Monitoring  []string `json:"monitoring"`
-	Development []string `json:"development"`
-	Archive     []string `json:"archive"`
-	Security    []string `json:"security"`
-	System      []string `json:"system"`
-	Database    []string `json:"database"`
-	Web         []string `json:"web"`
+	Basic       PackageList `json:"basic"`
+	Network     PackageList `json:"network"`
+	Monitoring  PackageList `json:"monitoring"`
+	Development PackageList `json:"development"`
+	Archive     PackageList `json:"archive"`
+	Security    PackageList `json:"security"`
+	System      PackageList `json:"system"`
+	Database    PackageList `json:"database"`
+	Web         PackageList `json:"web"`
+	// Exclude содержит пакеты, исключаемые из встроенных категорий при установке
+	Exclude map[string][]string `json:"exclude,omitempty"`
+	// UnknownPolicy определяет реакцию на пакеты, отсутствующие в репозиториях:
+	// "warn" (по умолчанию) - пропустить с предупреждением, "error" - прервать установку
+	UnknownPolicy string `json:"unknown_policy,omitempty"`
+	// Manager переопределяет команды обнаруженного менеджера пакетов
+	Manager PackageManagerOverride `json:"manager,omitempty"`
+}
+
+// PackageManagerOverride переопределяет команды менеджера пакетов,
+// например для запуска через sudo, nice или apt-fast. Пустые поля сохраняют команды по умолчанию
+type PackageManagerOverride struct {
+	Update  string `json:"update,omitempty"`
+	Upgrade string `json:"upgrade,omitempty"`
+	Install string `json:"install,omitempty"`
+	Remove  string `json:"remove,omitempty"`
+	Clean   string `json:"clean,omitempty"`
+	Check   string `json:"check,omitempty"`
+}
+
+// shellMetaChars - символы, при которых команда не разбивается на аргументы по пробелам
+const shellMetaChars = ";&|<>$`'\"\\()\n"
+
+// Validate проверяет, что переопределенные команды разбиваются на аргументы без shell.
+// Clean выполняется через shell целиком и может объединять команды через &&
+func (o PackageManagerOverride) Validate() error {
+	for _, field := range []struct{ name, value string }{
+		{"update", o.Update}, {"upgrade", o.Upgrade}, {"install", o.Install},
+		{"remove", o.Remove}, {"check", o.Check},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if strings.TrimSpace(field.value) == "" {
+			return fmt.Errorf("пустая команда менеджера пакетов: %s", field.name)
+		}
+		if strings.ContainsAny(field.value, shellMetaChars) {
+			return fmt.Errorf("команда менеджера пакетов %s содержит спецсимволы shell: %q", field.name, field.value)
+		}
+	}
+	if o.Clean != "" && strings.TrimSpace(o.Clean) == "" {
+		return fmt.Errorf("пустая команда менеджера пакетов: clean")
+	}
+	return nil
+}
+
+// CleanConfig содержит настройки очистки системы
+type CleanConfig struct {
+	// JournalMaxAge - срок хранения журнала systemd в формате journalctl (3d, 2weeks)
+	JournalMaxAge string `json:"journal_max_age,omitempty"`
+	// JournalMaxSize - предельный размер журнала systemd (500M, 1G)
+	JournalMaxSize string `json:"journal_max_size,omitempty"`
 }
 
+// HooksConfig содержит настройки пользовательских хуков
+type HooksConfig struct {
+	// Dir - директория hooks.d с поддиректориями по фазам (pre-install, post-install, post-security)
+	Dir string `json:"dir,omitempty"`
+}
+
+var (
+	journalAgePattern  = regexp.MustCompile(`^[0-9]+(s|m|min|h|d|w|weeks?|months?|y|years?)?$`)
+	journalSizePattern = regexp.MustCompile(`^[0-9]+[KMGT]?$`)
+)
+
 // DefaultConfig возвращает конфигурацию по умолчанию
 func DefaultConfig() *Config {
 	return &Config{
@@ -71,92 +248,128 @@ func DefaultConfig() *Config {
 			SSHPort:        22,
 			OpenPorts:      []int{80, 443},
 			AllowIPs:       []string{"127.0.0.1"},
-			EnableUFW:      true,
-			EnableFail2ban: true,
+			EnableUFW:      Bool(true),
+			EnableFail2ban: Bool(true),
 			FirewallRules: []FirewallRule{
 				{Port: 22, Protocol: "tcp", Action: "allow", Comment: "SSH access"},
 				{Port: 80, Protocol: "tcp", Action: "allow", Comment: "HTTP"},
 				{Port: 443, Protocol: "tcp", Action: "allow", Comment: "HTTPS"},
 			},
+			SSHHardening: DefaultSSHHardening(),
 		},
 		Packages: PackagesConfig{
-			Basic: []string{
+			Basic: NewPackageList(
 				"nano", "vim", "micro",
 				"htop", "btop", "glances",
 				"git", "curl", "wget", "rsync",
 				"tree", "tmux", "screen", "zsh",
-			},
-			Archive: []string{
+			),
+			Archive: NewPackageList(
 				"gzip", "gunzip", "zip", "unzip",
 				"p7zip-full", "p7zip-rar", "unrar",
 				"bzip2", "xz-utils", "zstd",
 				"lz4", "tar", "cpio", "lzop",
-			},
-			Network: []string{
+			),
+			Network: NewPackageList(
 				"net-tools", "iproute2", "nmap",
 				"traceroute", "mtr-tiny", "tcpdump",
 				"openssh-client", "openssh-server",
 				"dnsutils", "whois", "netcat-openbsd",
-			},
-			Monitoring: []string{
+			),
+			Monitoring: NewPackageList(
 				"nmon", "iotop", "dstat", "vnstat",
 				"atop", "sar", "sysstat",
-			},
-			Development: []string{
+			),
+			Development: NewPackageList(
 				"build-essential", "gcc", "g++",
 				"python3", "python3-pip", "nodejs",
 				"golang-go", "make", "cmake",
-			},
-			Security: []string{
+			),
+			Security: NewPackageList(
 				"ufw", "fail2ban", "rkhunter",
 				"chkrootkit", "clamav",
-			},
-			System: []string{
+			),
+			System: NewPackageList(
 				"mc", "ncdu", "bat", "fzf",
 				"ripgrep", "jq", "yq",
-			},
+			),
+		},
+		Clean: CleanConfig{
+			JournalMaxAge: "3d",
+		},
+		Hooks: HooksConfig{
+			Dir: "/etc/go-to-run/hooks.d",
 		},
 	}
 }
 
-// LoadConfig загружает конфигурацию из файла
+// LoadConfig загружает конфигурацию из файла или из директории фрагментов (см. LoadConfigDir).
+// Файлы из "include" загружаются относительно включающего файла и объединяются
+// по порядку через MergeConfigs, значения самого файла применяются последними.
 func LoadConfig(filename string) (*Config, error) {
+	return loadConfig(filename, nil)
+}
+
+// loadConfig загружает конфигурацию; chain - цепочка включающих файлов для поиска циклов
+func loadConfig(filename string, chain []string) (*Config, error) {
 	// Проверка пути к файлу для предотвращения инъекций
 	if !filepath.IsAbs(filename) && filepath.Clean(filename) != filename {
 		return nil, fmt.Errorf("небезопасный путь к файлу: %s", filename)
 	}
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		return loadConfigDir(filename, chain)
+	}
 
 	data, err := os.ReadFile(filepath.Clean(filename))
 	if err != nil {
 		return nil, fmt.Errorf("ошибка чтения конфигурации: %w", err)
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("ошибка парсинга конфигурации: %w", err)
+	// Формат определяется по расширению, а при его отсутствии - по содержимому
+	format := formatFromExtension(filename)
+	if format == "" {
+		format = DetectFormat(data)
 	}
-
-	return &config, nil
+	config, err := decodeConfig(data, format)
+	if err != nil {
+		return nil, err
+	}
+	return resolveIncludes(filename, config, chain)
 }
 
-// SaveConfig сохраняет конфигурацию в файл
+// SaveConfig сохраняет конфигурацию в файл в формате по его расширению:
+// .yaml и .yml - YAML, остальные - JSON
 func SaveConfig(config *Config, filename string) error {
-	data, err := json.MarshalIndent(config, "", "  ")
+	format := formatFromExtension(filename)
+	if format == "" {
+		format = FormatJSON
+	}
+	data, err := encodeConfig(config, format)
 	if err != nil {
-		return fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+		return err
 	}
 
 	// Безопасные права доступа 0600 (только владелец может читать/писать)
-	if err := os.WriteFile(filepath.Clean(filename), data, 0600); err != nil {
+	mode := DefaultConfigFileMode
+	if config.Files.ConfigMode != "" {
+		if mode, err = ParseFileMode(config.Files.ConfigMode); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Clean(filename), data, mode); err != nil {
 		return fmt.Errorf("ошибка записи конфигурации: %w", err)
 	}
+	// WriteFile не меняет права существующего файла, а umask мог их сузить
+	if err := os.Chmod(filepath.Clean(filename), mode); err != nil {
+		return fmt.Errorf("ошибка установки прав конфигурации: %w", err)
+	}
 
 	return nil
 }
 
 // EnsureConfigDir создает директорию для конфигурации
 func EnsureConfigDir() (string, error) {
-	configDir := filepath.Join(os.Getenv("HOME"), ".config", "go-to-run")
+	configDir := userConfigDir()
 	// Безопасные права доступа 0750 (владелец может читать/писать/исполнять, группа только читать/исполнять)
 	if err := os.MkdirAll(configDir, 0750); err != nil {
 		return "", fmt.Errorf("ошибка создания директории конфигурации: %w", err)
@@ -164,31 +377,46 @@ func EnsureConfigDir() (string, error) {
 	return configDir, nil
 }
 
-// GetConfigPath возвращает путь к конфигурационному файлу
+// GetConfigPath возвращает путь к конфигурационному файлу.
+// Явный путь из SetConfigPathOverride возвращается без поиска.
+// На каждом уровне директория фрагментов go-to-run.d имеет приоритет над файлом,
+// а файл ищется как .json, .yaml и .yml (config.json, config.yaml...).
 func GetConfigPath() string {
+	if override := ConfigPathOverride(); override != "" {
+		return override
+	}
+
 	// 1. Текущая директория
-	if _, err := os.Stat("go-to-run.json"); err == nil {
-		return "go-to-run.json"
+	if hasConfigFragments(configDirName) {
+		return configDirName
+	}
+	if path, ok := findConfigFile(localConfigFile); ok {
+		return path
 	}
 
 	// 2. Пользовательская конфигурация
 	configDir, err := EnsureConfigDir()
 	if err == nil {
-		userConfig := filepath.Join(configDir, "config.json")
-		if _, err := os.Stat(userConfig); err == nil {
-			return userConfig
+		if dir := filepath.Join(configDir, configDirName); hasConfigFragments(dir) {
+			return dir
+		}
+		if path, ok := findConfigFile(filepath.Join(configDir, "config.json")); ok {
+			return path
 		}
 	}
 
 	// 3. Глобальная конфигурация
 	globalConfigs := []string{
-		"/etc/go-to-run/config.json",
+		systemConfigPath,
 		"/usr/local/etc/go-to-run/config.json",
 	}
 
 	for _, config := range globalConfigs {
-		if _, err := os.Stat(config); err == nil {
-			return config
+		if dir := filepath.Join(filepath.Dir(config), configDirName); hasConfigFragments(dir) {
+			return dir
+		}
+		if path, ok := findConfigFile(config); ok {
+			return path
 		}
 	}
 
@@ -196,6 +424,22 @@ func GetConfigPath() string {
 	return filepath.Join(configDir, "config.json")
 }
 
+// configExtensions - расширения файла конфигурации в порядке поиска:
+// JSON проверяется первым, как до появления YAML
+var configExtensions = []string{".json", ".yaml", ".yml"}
+
+// findConfigFile ищет файл path с одним из configExtensions вместо его расширения:
+// для config.json это config.json, config.yaml и config.yml
+func findConfigFile(path string) (string, bool) {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, ext := range configExtensions {
+		if _, err := os.Stat(base + ext); err == nil {
+			return base + ext, true
+		}
+	}
+	return "", false
+}
+
 // MergeConfigs объединяет две конфигурации
 func MergeConfigs(base, override *Config) *Config {
 	if base == nil {
@@ -217,6 +461,12 @@ func MergeConfigs(base, override *Config) *Config {
 	if override.System.SwapSize != "" {
 		merged.System.SwapSize = override.System.SwapSize
 	}
+	if override.System.Language != "" {
+		merged.System.Language = override.System.Language
+	}
+	if override.System.Locale != "" {
+		merged.System.Locale = override.System.Locale
+	}
 
 	// Объединение настроек безопасности
 	if override.Security.SSHPort != 0 {
@@ -228,33 +478,133 @@ func MergeConfigs(base, override *Config) *Config {
 	if len(override.Security.AllowIPs) > 0 {
 		merged.Security.AllowIPs = override.Security.AllowIPs
 	}
+	if override.Security.SSHBackupKeep != 0 {
+		merged.Security.SSHBackupKeep = override.Security.SSHBackupKeep
+	}
+	// Правило override для того же порта заменяет базовое, запреты идут перед разрешениями
+	merged.Security.FirewallRules = mergeFirewallRules(merged.Security.FirewallRules, override.Security.FirewallRules)
+	// Блок sshd заменяется целиком, чтобы пропущенные поля можно было исключить
+	if override.Security.SSHHardening != nil {
+		merged.Security.SSHHardening = override.Security.SSHHardening
+	}
+
+	// Объединение настроек очистки
+	if override.Clean.JournalMaxAge != "" {
+		merged.Clean.JournalMaxAge = override.Clean.JournalMaxAge
+	}
+	if override.Clean.JournalMaxSize != "" {
+		merged.Clean.JournalMaxSize = override.Clean.JournalMaxSize
+	}
+
+	if override.Hooks.Dir != "" {
+		merged.Hooks.Dir = override.Hooks.Dir
+	}
+
+	// Объединение флагов: заданный в override флаг, в том числе false, заменяет базовый
+	for _, field := range []struct {
+		dst **bool
+		src *bool
+	}{
+		{&merged.Phases.ManageFirewall, override.Phases.ManageFirewall},
+		{&merged.Phases.ManageSSH, override.Phases.ManageSSH},
+		{&merged.Phases.ManageSwap, override.Phases.ManageSwap},
+		{&merged.Phases.ManagePackages, override.Phases.ManagePackages},
+		{&merged.Phases.ManageTimezone, override.Phases.ManageTimezone},
+		{&merged.Security.EnableUFW, override.Security.EnableUFW},
+		{&merged.Security.EnableFail2ban, override.Security.EnableFail2ban},
+		{&merged.Security.PermitRootLogin, override.Security.PermitRootLogin},
+		{&merged.Security.PasswordAuth, override.Security.PasswordAuth},
+		{&merged.Disk.ShowAll, override.Disk.ShowAll},
+	} {
+		if field.src != nil {
+			*field.dst = field.src
+		}
+	}
+
+	// Объединение прав файлов
+	if override.Files.ConfigMode != "" {
+		merged.Files.ConfigMode = override.Files.ConfigMode
+	}
+	if override.Files.SystemMode != "" {
+		merged.Files.SystemMode = override.Files.SystemMode
+	}
+	if override.Files.SensitiveMode != "" {
+		merged.Files.SensitiveMode = override.Files.SensitiveMode
+	}
+
+	// Объединение фильтров дисков
+	merged.Disk.IncludeMounts = mergeStrings(merged.Disk.IncludeMounts, override.Disk.IncludeMounts)
+	merged.Disk.ExcludeMounts = mergeStrings(merged.Disk.ExcludeMounts, override.Disk.ExcludeMounts)
+	merged.Disk.ExcludeFSTypes = mergeStrings(merged.Disk.ExcludeFSTypes, override.Disk.ExcludeFSTypes)
+
+	// Окно обслуживания заменяется целиком
+	if override.Maintenance != nil {
+		merged.Maintenance = override.Maintenance
+	}
 
 	// Объединение пакетов
-	mergePackageLists := func(base, override []string) []string {
-		packageMap := make(map[string]bool)
-		for _, pkg := range base {
-			packageMap[pkg] = true
+	// Категории перечислены в порядке полей PackagesConfig
+	merged.Packages.Basic = mergePackageList(merged.Packages.Basic, override.Packages.Basic)
+	merged.Packages.Network = mergePackageList(merged.Packages.Network, override.Packages.Network)
+	merged.Packages.Monitoring = mergePackageList(merged.Packages.Monitoring, override.Packages.Monitoring)
+	merged.Packages.Development = mergePackageList(merged.Packages.Development, override.Packages.Development)
+	merged.Packages.Archive = mergePackageList(merged.Packages.Archive, override.Packages.Archive)
+	merged.Packages.Security = mergePackageList(merged.Packages.Security, override.Packages.Security)
+	merged.Packages.System = mergePackageList(merged.Packages.System, override.Packages.System)
+	merged.Packages.Database = mergePackageList(merged.Packages.Database, override.Packages.Database)
+	merged.Packages.Web = mergePackageList(merged.Packages.Web, override.Packages.Web)
+
+	if override.Packages.UnknownPolicy != "" {
+		merged.Packages.UnknownPolicy = override.Packages.UnknownPolicy
+	}
+
+	merged.Packages.Manager = mergeManagerOverride(merged.Packages.Manager, override.Packages.Manager)
+
+	if len(override.Packages.Exclude) > 0 {
+		exclude := make(map[string][]string, len(merged.Packages.Exclude)+len(override.Packages.Exclude))
+		for category, packages := range merged.Packages.Exclude {
+			exclude[category] = packages
 		}
-		for _, pkg := range override {
-			packageMap[pkg] = true
+		for category, packages := range override.Packages.Exclude {
+			exclude[category] = mergePackageList(NewPackageList(exclude[category]...), NewPackageList(packages...)).Names()
 		}
+		merged.Packages.Exclude = exclude
+	}
+
+	return &merged
+}
 
-		result := make([]string, 0, len(packageMap))
-		for pkg := range packageMap {
-			result = append(result, pkg)
+// mergeStrings дополняет base значениями override без повторов
+func mergeStrings(base, override []string) []string {
+	if len(override) == 0 {
+		return base
+	}
+	seen := make(map[string]bool, len(base)+len(override))
+	result := make([]string, 0, len(base)+len(override))
+	for _, v := range append(append([]string(nil), base...), override...) {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
 		}
-		return result
 	}
+	return result
+}
 
-	merged.Packages.Basic = mergePackageLists(merged.Packages.Basic, override.Packages.Basic)
-	merged.Packages.Archive = mergePackageLists(merged.Packages.Archive, override.Packages.Archive)
-	merged.Packages.Network = mergePackageLists(merged.Packages.Network, override.Packages.Network)
-	merged.Packages.Monitoring = mergePackageLists(merged.Packages.Monitoring, override.Packages.Monitoring)
-	merged.Packages.Development = mergePackageLists(merged.Packages.Development, override.Packages.Development)
-	merged.Packages.Security = mergePackageLists(merged.Packages.Security, override.Packages.Security)
-	merged.Packages.System = mergePackageLists(merged.Packages.System, override.Packages.System)
-
-	return &merged
+// mergeManagerOverride объединяет переопределения команд: непустые поля override заменяют base
+func mergeManagerOverride(base, override PackageManagerOverride) PackageManagerOverride {
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&base.Update, override.Update}, {&base.Upgrade, override.Upgrade},
+		{&base.Install, override.Install}, {&base.Remove, override.Remove},
+		{&base.Clean, override.Clean}, {&base.Check, override.Check},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	return base
 }
 
 // ValidateConfig проверяет конфигурацию на корректность
@@ -263,9 +613,12 @@ func ValidateConfig(config *Config) error {
 		return errors.New("конфигурация не может быть nil")
 	}
 
-	// Проверка часового пояса
-	if config.System.Timezone == "" {
-		return errors.New("часовой пояс не может быть пустым")
+	// Проверка часового пояса и локали
+	if err := validateTimezone(config.System.Timezone); err != nil {
+		return err
+	}
+	if err := validateLocale(config.System.Locale); err != nil {
+		return err
 	}
 
 	// Проверка портов
@@ -275,6 +628,50 @@ func ValidateConfig(config *Config) error {
 		}
 	}
 
+	// Проверка разрешенных IP-адресов
+	if _, err := NormalizeAllowIPs(config.Security.AllowIPs); err != nil {
+		return err
+	}
+
+	// Проверка имен пакетов
+	for _, list := range []PackageList{
+		config.Packages.Basic, config.Packages.Network, config.Packages.Monitoring,
+		config.Packages.Development, config.Packages.Archive, config.Packages.Security,
+		config.Packages.System, config.Packages.Database, config.Packages.Web,
+	} {
+		for _, entry := range list {
+			if err := ValidatePackageName(entry.Name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, names := range config.Packages.Exclude {
+		for _, name := range names {
+			if err := ValidatePackageName(name); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch config.Packages.UnknownPolicy {
+	case "", "warn", "error":
+	default:
+		return fmt.Errorf("некорректная политика unknown_policy: %s", config.Packages.UnknownPolicy)
+	}
+	if err := config.Packages.Manager.Validate(); err != nil {
+		return err
+	}
+
+	// Проверка прав файлов
+	for _, mode := range []string{config.Files.ConfigMode, config.Files.SystemMode, config.Files.SensitiveMode} {
+		if mode == "" {
+			continue
+		}
+		if _, err := ParseFileMode(mode); err != nil {
+			return err
+		}
+	}
+
 	// Проверка правил фаервола
 	for _, rule := range config.Security.FirewallRules {
 		if rule.Port < 1 || rule.Port > 65535 {
@@ -287,6 +684,72 @@ func ValidateConfig(config *Config) error {
 			return fmt.Errorf("некорректное действие в правиле: %s", rule.Action)
 		}
 	}
+	if err := validateFirewallConflicts(config.Security); err != nil {
+		return err
+	}
+
+	// Проверка настроек sshd
+	if config.Security.SSHBackupKeep < 0 {
+		return errors.New("ssh_backup_keep не может быть отрицательным")
+	}
+	if h := config.Security.SSHHardening; h != nil {
+		if h.ClientAliveInterval < 0 || h.ClientAliveCountMax < 0 || h.MaxAuthTries < 0 || h.MaxSessions < 0 {
+			return errors.New("параметры ssh_hardening не могут быть отрицательными")
+		}
+		if h.X11Forwarding != "" && h.X11Forwarding != "yes" && h.X11Forwarding != "no" {
+			return fmt.Errorf("некорректное значение x11_forwarding: %s", h.X11Forwarding)
+		}
+	}
+
+	if err := config.Maintenance.Validate(); err != nil {
+		return err
+	}
+
+	// Проверка настроек очистки журнала
+	if config.Clean.JournalMaxAge != "" && !journalAgePattern.MatchString(config.Clean.JournalMaxAge) {
+		return fmt.Errorf("некорректный срок хранения журнала: %s", config.Clean.JournalMaxAge)
+	}
+	if config.Clean.JournalMaxSize != "" && !journalSizePattern.MatchString(config.Clean.JournalMaxSize) {
+		return fmt.Errorf("некорректный размер журнала: %s", config.Clean.JournalMaxSize)
+	}
 
 	return nil
 }
+
+// NormalizeAllowIPs приводит адреса к каноническому виду CIDR и удаляет дубликаты.
+// Одиночные адреса дополняются маской /32 (IPv4) или /128 (IPv6), порядок сохраняется.
+func NormalizeAllowIPs(ips []string) ([]string, error) {
+	seen := make(map[string]bool, len(ips))
+	result := make([]string, 0, len(ips))
+
+	for _, raw := range ips {
+		entry := strings.TrimSpace(raw)
+
+		var normalized string
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("некорректная подсеть в allow_ips: %q", raw)
+			}
+			normalized = network.String()
+		} else {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("некорректный IP-адрес в allow_ips: %q", raw)
+			}
+			if ip.To4() != nil {
+				normalized = ip.String() + "/32"
+			} else {
+				normalized = ip.String() + "/128"
+			}
+		}
+
+		if seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		result = append(result, normalized)
+	}
+
+	return result, nil
+}
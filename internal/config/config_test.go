@@ -0,0 +1,268 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// writeConfigFile записывает файл конфигурации во временную директорию dir
+func writeConfigFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMergeConfigsPackageCategories(t *testing.T) {
+	base := DefaultConfig()
+	override := &Config{}
+	override.Packages.Database = NewPackageList("postgresql", "redis")
+
+	merged := MergeConfigs(base, override)
+	for _, category := range CategoryNames {
+		got, _ := merged.Packages.Category(category)
+		want, _ := base.Packages.Category(category)
+		if category == "database" {
+			want = mergePackageList(want, override.Packages.Database)
+		}
+		if (len(got) > 0 || len(want) > 0) && !reflect.DeepEqual(got, want) {
+			t.Errorf("категория %s: %v, ожидалось %v", category, got.Names(), want.Names())
+		}
+	}
+}
+
+func TestMergeConfigsWebAndDatabase(t *testing.T) {
+	base := &Config{}
+	base.Packages.Web = NewPackageList("nginx")
+	base.Packages.Database = NewPackageList("sqlite3")
+	override := &Config{}
+	override.Packages.Web = PackageList{{Name: "nginx", Reason: "прокси"}, {Name: "certbot"}}
+
+	merged := MergeConfigs(base, override)
+	if want := (PackageList{{Name: "nginx", Reason: "прокси"}, {Name: "certbot"}}); !reflect.DeepEqual(merged.Packages.Web, want) {
+		t.Errorf("web = %v, ожидалось %v", merged.Packages.Web, want)
+	}
+	if got := merged.Packages.Database.Names(); !reflect.DeepEqual(got, []string{"sqlite3"}) {
+		t.Errorf("database = %v", got)
+	}
+}
+
+func TestMergeConfigsBoolFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     *bool
+		override *bool
+		want     bool
+	}{
+		{"override выключает", Bool(true), Bool(false), false},
+		{"override включает", Bool(false), Bool(true), true},
+		{"не задано в override", Bool(true), nil, true},
+		{"не задано нигде", nil, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, override := &Config{}, &Config{}
+			base.Security.EnableUFW, override.Security.EnableUFW = tt.base, tt.override
+			base.Security.EnableFail2ban, override.Security.EnableFail2ban = tt.base, tt.override
+			base.Security.PermitRootLogin, override.Security.PermitRootLogin = tt.base, tt.override
+			base.Security.PasswordAuth, override.Security.PasswordAuth = tt.base, tt.override
+			base.Disk.ShowAll, override.Disk.ShowAll = tt.base, tt.override
+
+			merged := MergeConfigs(base, override)
+			for name, flag := range map[string]*bool{
+				"enable_ufw":        merged.Security.EnableUFW,
+				"enable_fail2ban":   merged.Security.EnableFail2ban,
+				"permit_root_login": merged.Security.PermitRootLogin,
+				"password_auth":     merged.Security.PasswordAuth,
+				"show_all":          merged.Disk.ShowAll,
+			} {
+				if Enabled(flag) != tt.want {
+					t.Errorf("%s = %v, ожидалось %v", name, Enabled(flag), tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigFalseFlagOverridesDefault(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "config.json", `{"security": {"enable_ufw": false}}`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Security.EnableUFW == nil {
+		t.Fatal("явное false разобрано как незаданное значение")
+	}
+	merged := MergeConfigs(DefaultConfig(), cfg)
+	if Enabled(merged.Security.EnableUFW) {
+		t.Error("enable_ufw: false не переопределил значение по умолчанию")
+	}
+	if !Enabled(merged.Security.EnableFail2ban) {
+		t.Error("незаданный enable_fail2ban сбросил значение по умолчанию")
+	}
+}
+
+func TestNormalizeAllowIPs(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []string
+		want []string
+	}{
+		{"одиночный IPv4", []string{"192.168.1.5"}, []string{"192.168.1.5/32"}},
+		{"подсеть IPv4", []string{"10.0.0.0/8"}, []string{"10.0.0.0/8"}},
+		{"адрес хоста в подсети", []string{"10.1.2.3/8"}, []string{"10.0.0.0/8"}},
+		{"одиночный IPv6", []string{"2001:DB8::1"}, []string{"2001:db8::1/128"}},
+		{"подсеть IPv6", []string{"2001:db8::/32"}, []string{"2001:db8::/32"}},
+		{"пробелы", []string{" 127.0.0.1 "}, []string{"127.0.0.1/32"}},
+		{"дубликаты с сохранением порядка",
+			[]string{"10.0.0.1", "192.168.0.0/16", "10.0.0.1/32", "192.168.1.1/16", "::1"},
+			[]string{"10.0.0.1/32", "192.168.0.0/16", "::1/128"}},
+		{"пустой список", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeAllowIPs(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeAllowIPs(%q) = %q, ожидалось %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAllowIPsInvalid(t *testing.T) {
+	for _, entry := range []string{"192.168.1.300", "10.0.0.0/33", "host.example", "2001:db8::/129", ""} {
+		_, err := NormalizeAllowIPs([]string{"10.0.0.1", entry})
+		if err == nil {
+			t.Errorf("%q: ожидалась ошибка", entry)
+			continue
+		}
+		if !strings.Contains(err.Error(), fmt.Sprintf("%q", entry)) {
+			t.Errorf("%q: ошибка не называет значение: %v", entry, err)
+		}
+	}
+}
+
+func TestValidateConfigRejectsInvalidAllowIP(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Security.AllowIPs = []string{"127.0.0.1", "10.0.0.256"}
+	if err := ValidateConfig(cfg); err == nil || !strings.Contains(err.Error(), "10.0.0.256") {
+		t.Fatalf("ValidateConfig = %v, ожидалась ошибка с некорректным адресом", err)
+	}
+}
+
+func TestSaveConfigFileMode(t *testing.T) {
+	// Разрешающий umask не должен расширять права файла конфигурации
+	old := syscall.Umask(0)
+	t.Cleanup(func() { syscall.Umask(old) })
+
+	dir := t.TempDir()
+	existing := writeConfigFile(t, dir, "existing.json", "{}")
+	if err := os.Chmod(existing, 0644); err != nil {
+		t.Fatal(err)
+	}
+	custom := DefaultConfig()
+	custom.Files.ConfigMode = "0640"
+
+	tests := []struct {
+		name string
+		path string
+		cfg  *Config
+		want os.FileMode
+	}{
+		{"новый файл", filepath.Join(dir, "config.json"), DefaultConfig(), 0600},
+		{"существующий файл 0644", existing, DefaultConfig(), 0600},
+		{"yaml", filepath.Join(dir, "config.yaml"), DefaultConfig(), 0600},
+		{"config_mode", filepath.Join(dir, "custom.json"), custom, 0640},
+	}
+	for _, tt := range tests {
+		if err := SaveConfig(tt.cfg, tt.path); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		info, err := os.Stat(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != tt.want {
+			t.Errorf("%s: права %o, ожидалось %o", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{"0600", 0600, false},
+		{"644", 0644, false},
+		{"0666", 0, true},
+		{"1777", 0, true},
+		{"rw-r--r--", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFileMode(tt.mode)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseFileMode(%q) = %o, %v", tt.mode, got, err)
+		}
+	}
+}
+
+func TestConfigPathOverride(t *testing.T) {
+	clearEnvOverrides(t)
+	// Локальная конфигурация нашлась бы поиском, если бы путь не был задан явно
+	local := t.TempDir()
+	writeConfigFile(t, local, localConfigFile, `{"system": {"hostname": "local"}}`)
+	t.Chdir(local)
+	explicit := writeConfigFile(t, t.TempDir(), "ci.json", `{"system": {"hostname": "ci"}}`)
+
+	SetConfigPathOverride(explicit)
+	t.Cleanup(func() { SetConfigPathOverride("") })
+	if path := GetConfigPath(); path != explicit {
+		t.Errorf("GetConfigPath() = %q, ожидался явный путь %q", path, explicit)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.Hostname != "ci" {
+		t.Errorf("hostname = %q, загружен не явно указанный файл", cfg.System.Hostname)
+	}
+
+	SetConfigPathOverride("")
+	if path := GetConfigPath(); path != localConfigFile {
+		t.Errorf("без явного пути GetConfigPath() = %q", path)
+	}
+}
+
+func TestConfigPathOverrideMissing(t *testing.T) {
+	clearEnvOverrides(t)
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	SetConfigPathOverride(missing)
+	t.Cleanup(func() { SetConfigPathOverride("") })
+
+	cfg, err := Load()
+	if !errors.Is(err, ErrConfigNotFound) {
+		t.Fatalf("ожидалась ErrConfigNotFound, получено %v", err)
+	}
+	if cfg != nil {
+		t.Error("вместо ошибки возвращена конфигурация по умолчанию")
+	}
+	if !strings.Contains(err.Error(), missing) {
+		t.Errorf("ошибка не называет путь: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// configDirName - имя директории фрагментов конфигурации рядом с config.json
+const configDirName = "go-to-run.d"
+
+// ErrNoConfigFragments возвращается, если в директории нет фрагментов конфигурации
+var ErrNoConfigFragments = errors.New("в директории нет фрагментов конфигурации")
+
+// LoadConfigDir загружает фрагменты конфигурации (*.json, *.yaml, *.yml) из директории
+// в лексическом порядке имен и объединяет их через MergeConfigs: последующие фрагменты
+// переопределяют предыдущие.
+func LoadConfigDir(dir string) (*Config, error) {
+	return loadConfigDir(dir, nil)
+}
+
+func loadConfigDir(dir string, chain []string) (*Config, error) {
+	fragments, err := configFragments(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(fragments) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoConfigFragments, dir)
+	}
+
+	var merged *Config
+	for _, path := range fragments {
+		fragment, err := loadConfig(path, chain)
+		if err != nil {
+			return nil, fmt.Errorf("фрагмент %s: %w", path, err)
+		}
+		merged = MergeConfigs(merged, fragment)
+	}
+	return merged, nil
+}
+
+// configFragments возвращает отсортированные пути фрагментов конфигурации в директории
+func configFragments(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения директории конфигурации: %w", err)
+	}
+
+	var fragments []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		switch formatFromExtension(entry.Name()) {
+		case FormatJSON, FormatYAML:
+			fragments = append(fragments, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(fragments)
+	return fragments, nil
+}
+
+// hasConfigFragments проверяет, что директория существует и содержит фрагменты
+func hasConfigFragments(dir string) bool {
+	fragments, err := configFragments(dir)
+	return err == nil && len(fragments) > 0
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigDirFragmentOrder(t *testing.T) {
+	dir := t.TempDir()
+	// Фрагменты применяются в лексическом порядке имен, а не в порядке создания
+	writeConfigFile(t, dir, "20-host.yaml", "system:\n  hostname: web01\nsecurity:\n  enable_ufw: false\n")
+	writeConfigFile(t, dir, "10-base.json", `{
+		"system": {"timezone": "Europe/Moscow", "hostname": "base"},
+		"security": {"ssh_port": 22, "enable_ufw": true, "enable_fail2ban": true},
+		"disk": {"show_all": true}
+	}`)
+	writeConfigFile(t, dir, "30-disk.json", `{"disk": {"show_all": false, "exclude_mounts": ["/mnt"]}}`)
+	writeConfigFile(t, dir, "notes.txt", "не фрагмент")
+
+	cfg, err := LoadConfigDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.Hostname != "web01" || cfg.System.Timezone != "Europe/Moscow" {
+		t.Errorf("system = %+v", cfg.System)
+	}
+	if Enabled(cfg.Security.EnableUFW) {
+		t.Error("фрагмент 20-host не выключил enable_ufw")
+	}
+	if !Enabled(cfg.Security.EnableFail2ban) {
+		t.Error("enable_fail2ban из 10-base потерян")
+	}
+	if Enabled(cfg.Disk.ShowAll) {
+		t.Error("фрагмент 30-disk не выключил show_all")
+	}
+	if !reflect.DeepEqual(cfg.Disk.ExcludeMounts, []string{"/mnt"}) {
+		t.Errorf("exclude_mounts = %v", cfg.Disk.ExcludeMounts)
+	}
+}
+
+func TestLoadConfigDirEmpty(t *testing.T) {
+	if _, err := LoadConfigDir(t.TempDir()); !errors.Is(err, ErrNoConfigFragments) {
+		t.Fatalf("ошибка %v, ожидался ErrNoConfigFragments", err)
+	}
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// ErrConfigNotFound возвращается, если явно указанный файл конфигурации не существует
+var ErrConfigNotFound = errors.New("файл конфигурации не найден")
+
+var (
+	configPathMu       sync.RWMutex
+	configPathOverride string
+)
+
+// SetConfigPathOverride задает явный путь к конфигурации (флаг --config).
+// Непустой путь отменяет поиск GetConfigPath; пустая строка возвращает поиск по умолчанию.
+func SetConfigPathOverride(path string) {
+	configPathMu.Lock()
+	defer configPathMu.Unlock()
+	configPathOverride = path
+}
+
+// ConfigPathOverride возвращает явный путь к конфигурации или пустую строку
+func ConfigPathOverride() string {
+	configPathMu.RLock()
+	defer configPathMu.RUnlock()
+	return configPathOverride
+}
+
+// Load загружает конфигурацию по пути из GetConfigPath и применяет ApplyEnvOverrides.
+// Явный путь (SetConfigPathOverride) обязан существовать, иначе возвращается ErrConfigNotFound.
+// Если путь не задан и конфигурация не найдена, за основу берется конфигурация по умолчанию.
+func Load() (*Config, error) {
+	cfg, err := loadFile()
+	if err != nil {
+		return nil, err
+	}
+	if err := ApplyEnvOverrides(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile загружает конфигурацию без переопределений из окружения
+func loadFile() (*Config, error) {
+	if override := ConfigPathOverride(); override != "" {
+		if _, err := os.Stat(override); err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, override)
+			}
+			return nil, fmt.Errorf("ошибка чтения конфигурации: %w", err)
+		}
+		return LoadConfig(override)
+	}
+
+	path := GetConfigPath()
+	if _, err := os.Stat(path); err != nil {
+		return DefaultConfig(), nil
+	}
+	return LoadConfig(path)
+}
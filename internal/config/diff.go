@@ -0,0 +1,171 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DiffConfig показывает, что изменит override при объединении с base через MergeConfigs.
+// Каждая строка описывает одно поле по его json-пути:
+//
+//	system.timezone: "Europe/Moscow" -> "UTC"
+//	security.open_ports: +8080, -21
+//
+// Списки (порты, пакеты категорий, правила фаервола) показываются как добавленные (+)
+// и удаленные (-) элементы. Пустой результат означает, что override ничего не меняет.
+func DiffConfig(base, override *Config) []string {
+	if base == nil {
+		base = &Config{}
+	}
+	merged := MergeConfigs(base, override)
+	if merged == nil {
+		return nil
+	}
+	var lines []string
+	diffValue("", reflect.ValueOf(*base), reflect.ValueOf(*merged), &lines)
+	return lines
+}
+
+// diffValue сравнивает значения одного типа и дописывает различия в lines
+func diffValue(path string, before, after reflect.Value, lines *[]string) {
+	switch before.Kind() {
+	case reflect.Pointer:
+		if before.IsNil() && after.IsNil() {
+			return
+		}
+		// Незаданный блок сравнивается как пустой, незаданный флаг - как "не задано"
+		if before.Type().Elem().Kind() == reflect.Struct {
+			diffValue(path, derefOrZero(before), derefOrZero(after), lines)
+			return
+		}
+		if before.IsNil() || after.IsNil() || !reflect.DeepEqual(before.Elem().Interface(), after.Elem().Interface()) {
+			*lines = append(*lines, fmt.Sprintf("%s: %s -> %s", path, formatDiffValue(before), formatDiffValue(after)))
+		}
+	case reflect.Struct:
+		for i := 0; i < before.NumField(); i++ {
+			field := before.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _ := jsonFieldName(field)
+			if name == "-" {
+				continue
+			}
+			diffValue(joinDiffPath(path, name), before.Field(i), after.Field(i), lines)
+		}
+	case reflect.Slice, reflect.Array:
+		if line := diffList(before, after); line != "" {
+			*lines = append(*lines, fmt.Sprintf("%s: %s", path, line))
+		}
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, m := range []reflect.Value{before, after} {
+			for _, key := range m.MapKeys() {
+				keys[fmt.Sprint(key.Interface())] = key
+			}
+		}
+		names := make([]string, 0, len(keys))
+		for name := range keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			key := keys[name]
+			diffValue(joinDiffPath(path, name), mapValueOrZero(before, key), mapValueOrZero(after, key), lines)
+		}
+	default:
+		if !reflect.DeepEqual(before.Interface(), after.Interface()) {
+			*lines = append(*lines, fmt.Sprintf("%s: %s -> %s", path, formatDiffValue(before), formatDiffValue(after)))
+		}
+	}
+}
+
+// diffList описывает изменение списка как "+добавленные, -удаленные". Если состав
+// не изменился, но изменился порядок (правила фаервола применяются по порядку),
+// об этом сообщается отдельно
+func diffList(before, after reflect.Value) string {
+	beforeKeys, afterKeys := listKeys(before), listKeys(after)
+	inBefore := make(map[string]bool, len(beforeKeys))
+	for _, key := range beforeKeys {
+		inBefore[key] = true
+	}
+	inAfter := make(map[string]bool, len(afterKeys))
+	for _, key := range afterKeys {
+		inAfter[key] = true
+	}
+
+	var changes []string
+	for _, key := range afterKeys {
+		if !inBefore[key] {
+			changes = append(changes, "+"+key)
+		}
+	}
+	for _, key := range beforeKeys {
+		if !inAfter[key] {
+			changes = append(changes, "-"+key)
+		}
+	}
+	if len(changes) > 0 {
+		return strings.Join(changes, ", ")
+	}
+	if strings.Join(beforeKeys, "\x00") != strings.Join(afterKeys, "\x00") {
+		return "изменен порядок: " + strings.Join(afterKeys, ", ")
+	}
+	return ""
+}
+
+// listKeys возвращает элементы списка в виде строк для сравнения:
+// пакет - по имени, правило фаервола - в виде "allow 80/tcp"
+func listKeys(list reflect.Value) []string {
+	keys := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		switch item := list.Index(i).Interface().(type) {
+		case PackageEntry:
+			keys = append(keys, item.Name)
+		case FirewallRule:
+			keys = append(keys, item.key())
+		default:
+			keys = append(keys, fmt.Sprint(item))
+		}
+	}
+	return keys
+}
+
+// formatDiffValue форматирует значение поля: строки в кавычках, nil как "не задано"
+func formatDiffValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "не задано"
+		}
+		v = v.Elem()
+	}
+	if v.Kind() == reflect.String {
+		return fmt.Sprintf("%q", v.String())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// derefOrZero возвращает значение указателя или нулевое значение его типа для nil
+func derefOrZero(v reflect.Value) reflect.Value {
+	if v.IsNil() {
+		return reflect.Zero(v.Type().Elem())
+	}
+	return v.Elem()
+}
+
+// mapValueOrZero возвращает значение по ключу или нулевое значение, если ключа нет
+func mapValueOrZero(m reflect.Value, key reflect.Value) reflect.Value {
+	if value := m.MapIndex(key); value.IsValid() {
+		return value
+	}
+	return reflect.Zero(m.Type().Elem())
+}
+
+func joinDiffPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envPrefixes - префиксы переменных окружения, переопределяющих значения конфигурации
+// (GOTORUN_SSH_PORT, GOTORUN_TIMEZONE и т.д.), в порядке приоритета. GO_TO_RUN_
+// совпадает с остальными переменными программы и принимается как синоним.
+var envPrefixes = []string{"GOTORUN_", "GO_TO_RUN_"}
+
+// envOverrides сопоставляет переменные окружения (без префикса) с полями конфигурации
+var envOverrides = []struct {
+	name  string
+	apply func(cfg *Config, value string) error
+}{
+	{"TIMEZONE", setString(func(cfg *Config) *string { return &cfg.System.Timezone })},
+	{"HOSTNAME", setString(func(cfg *Config) *string { return &cfg.System.Hostname })},
+	{"SWAP_SIZE", setString(func(cfg *Config) *string { return &cfg.System.SwapSize })},
+	{"LOCALE", setString(func(cfg *Config) *string { return &cfg.System.Locale })},
+	{"SSH_PORT", func(cfg *Config, value string) error {
+		port, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("ожидается номер порта: %w", err)
+		}
+		cfg.Security.SSHPort = port
+		return nil
+	}},
+	{"OPEN_PORTS", func(cfg *Config, value string) error {
+		var ports []int
+		for _, field := range splitList(value) {
+			port, err := strconv.Atoi(field)
+			if err != nil {
+				return fmt.Errorf("ожидается список портов через запятую: %w", err)
+			}
+			ports = append(ports, port)
+		}
+		cfg.Security.OpenPorts = ports
+		return nil
+	}},
+	{"ALLOW_IPS", func(cfg *Config, value string) error {
+		cfg.Security.AllowIPs = splitList(value)
+		return nil
+	}},
+}
+
+// setString возвращает переопределение строкового поля конфигурации
+func setString(field func(cfg *Config) *string) func(cfg *Config, value string) error {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+// ApplyEnvOverrides переопределяет значения конфигурации переменными GOTORUN_<ПОЛЕ> (см. README)
+func ApplyEnvOverrides(cfg *Config) error {
+	for _, override := range envOverrides {
+		name, value, ok := lookupEnvOverride(override.name)
+		if !ok {
+			continue
+		}
+		if err := override.apply(cfg, value); err != nil {
+			return fmt.Errorf("некорректное значение %s=%q: %w", name, value, err)
+		}
+	}
+	return nil
+}
+
+// lookupEnvOverride возвращает первую непустую переменную поля с учетом приоритета префиксов
+func lookupEnvOverride(field string) (string, string, bool) {
+	for _, prefix := range envPrefixes {
+		if value := os.Getenv(prefix + field); value != "" {
+			return prefix + field, value, true
+		}
+	}
+	return "", "", false
+}
+
+// splitList разбирает список через запятую, пропуская пустые элементы
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
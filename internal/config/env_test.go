@@ -0,0 +1,93 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// clearEnvOverrides сбрасывает переменные окружения, которые могли остаться от окружения теста
+func clearEnvOverrides(t *testing.T) {
+	t.Helper()
+	for _, prefix := range envPrefixes {
+		for _, override := range envOverrides {
+			t.Setenv(prefix+override.name, "")
+		}
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	clearEnvOverrides(t)
+	path := writeConfigFile(t, t.TempDir(), "go-to-run.json",
+		`{"system": {"timezone": "Europe/Moscow", "hostname": "from-file"}, "security": {"ssh_port": 2222}}`)
+	SetConfigPathOverride(path)
+	t.Cleanup(func() { SetConfigPathOverride("") })
+
+	t.Setenv("GOTORUN_TIMEZONE", "UTC")
+	t.Setenv("GOTORUN_OPEN_PORTS", "80, 443,")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.Timezone != "UTC" {
+		t.Errorf("timezone = %q, окружение должно перекрывать файл", cfg.System.Timezone)
+	}
+	if cfg.System.Hostname != "from-file" || cfg.Security.SSHPort != 2222 {
+		t.Errorf("значения файла потеряны: %q, %d", cfg.System.Hostname, cfg.Security.SSHPort)
+	}
+	if !reflect.DeepEqual(cfg.Security.OpenPorts, []int{80, 443}) {
+		t.Errorf("open_ports = %v", cfg.Security.OpenPorts)
+	}
+}
+
+func TestApplyEnvOverridesPrefixes(t *testing.T) {
+	clearEnvOverrides(t)
+	t.Setenv("GO_TO_RUN_HOSTNAME", "legacy")
+	t.Setenv("GO_TO_RUN_SSH_PORT", "2200")
+	t.Setenv("GOTORUN_SSH_PORT", "2201")
+
+	cfg := DefaultConfig()
+	if err := ApplyEnvOverrides(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.Hostname != "legacy" {
+		t.Errorf("hostname = %q, префикс GO_TO_RUN_ должен приниматься", cfg.System.Hostname)
+	}
+	if cfg.Security.SSHPort != 2201 {
+		t.Errorf("ssh_port = %d, GOTORUN_ должен иметь приоритет", cfg.Security.SSHPort)
+	}
+}
+
+func TestApplyEnvOverridesInvalidValues(t *testing.T) {
+	tests := []struct {
+		name, value string
+	}{
+		{"GOTORUN_SSH_PORT", "ssh"},
+		{"GOTORUN_OPEN_PORTS", "80,http"},
+		{"GO_TO_RUN_SSH_PORT", "22x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnvOverrides(t)
+			t.Setenv(tt.name, tt.value)
+			cfg := DefaultConfig()
+			err := ApplyEnvOverrides(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.name) {
+				t.Fatalf("ошибка %v должна называть переменную %s", err, tt.name)
+			}
+		})
+	}
+}
+
+func TestApplyEnvOverridesIgnoresEmpty(t *testing.T) {
+	clearEnvOverrides(t)
+	cfg := DefaultConfig()
+	want := *cfg
+	if err := ApplyEnvOverrides(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*cfg, want) {
+		t.Error("пустые переменные изменили конфигурацию")
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ShadowedRule - правило фаервола, которое никогда не сработает: раньше в списке
+// стоит правило для того же порта и протокола, а ufw применяет первое совпавшее
+type ShadowedRule struct {
+	Rule  FirewallRule
+	Index int
+	// By - более раннее правило, перехватывающее трафик
+	By      FirewallRule
+	ByIndex int
+}
+
+// String описывает затенение для предупреждений
+func (s ShadowedRule) String() string {
+	if s.Rule.Action == s.By.Action {
+		return fmt.Sprintf("правило %s firewall_rules[%d] повторяет firewall_rules[%d]", s.Rule.key(), s.Index, s.ByIndex)
+	}
+	return fmt.Sprintf("правило %s firewall_rules[%d] не сработает: раньше стоит %s firewall_rules[%d]",
+		s.Rule.key(), s.Index, s.By.key(), s.ByIndex)
+}
+
+// key возвращает правило в виде "allow 80/tcp"
+func (r FirewallRule) key() string {
+	return fmt.Sprintf("%s %s", r.Action, r.target())
+}
+
+// target возвращает порт и протокол правила: "80/tcp"
+func (r FirewallRule) target() string {
+	return fmt.Sprintf("%d/%s", r.Port, r.Protocol)
+}
+
+// FilterFirewallRules возвращает правила, у которых есть хотя бы один из тегов tags.
+// Пустой фильтр возвращает все правила. Правила для порта sshPort сохраняются всегда,
+// чтобы выборочное применение не закрыло доступ к серверу.
+func FilterFirewallRules(rules []FirewallRule, tags []string, sshPort int) []FirewallRule {
+	if len(tags) == 0 {
+		return rules
+	}
+	var filtered []FirewallRule
+	for _, rule := range rules {
+		if (sshPort > 0 && rule.Port == sshPort) || rule.HasAnyTag(tags) {
+			filtered = append(filtered, rule)
+		}
+	}
+	return filtered
+}
+
+// HasAnyTag проверяет, отмечено ли правило хотя бы одним из тегов
+func (r FirewallRule) HasAnyTag(tags []string) bool {
+	for _, tag := range r.Tags {
+		for _, want := range tags {
+			if tag == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ShadowedFirewallRules находит правила, перед которыми в списке уже есть правило
+// для того же порта и протокола. Такое правило либо бесполезно (то же действие),
+// либо молча не работает (allow после deny или наоборот).
+func ShadowedFirewallRules(rules []FirewallRule) []ShadowedRule {
+	var shadowed []ShadowedRule
+	first := make(map[string]int, len(rules))
+	for i, rule := range rules {
+		j, ok := first[rule.target()]
+		if !ok {
+			first[rule.target()] = i
+			continue
+		}
+		shadowed = append(shadowed, ShadowedRule{Rule: rule, Index: i, By: rules[j], ByIndex: j})
+	}
+	return shadowed
+}
+
+// validateFirewallConflicts проверяет, что open_ports не повторяются, а один и тот же
+// порт и протокол не разрешен и не запрещен одновременно. Порты open_ports
+// открываются по tcp и считаются разрешающими правилами.
+func validateFirewallConflicts(sec SecurityConfig) error {
+	seen := make(map[int]bool, len(sec.OpenPorts))
+	for _, port := range sec.OpenPorts {
+		if seen[port] {
+			return fmt.Errorf("порт %d указан в open_ports несколько раз", port)
+		}
+		seen[port] = true
+	}
+
+	denied := make(map[string]int)
+	for i, rule := range sec.FirewallRules {
+		if rule.Action == "deny" {
+			if _, ok := denied[rule.target()]; !ok {
+				denied[rule.target()] = i
+			}
+		}
+	}
+	for i, rule := range sec.FirewallRules {
+		if rule.Action != "allow" {
+			continue
+		}
+		if j, ok := denied[rule.target()]; ok {
+			return fmt.Errorf("конфликт правил фаервола для порта %s: firewall_rules[%d] разрешает, firewall_rules[%d] запрещает",
+				rule.target(), i, j)
+		}
+	}
+	for _, port := range sec.OpenPorts {
+		target := fmt.Sprintf("%d/tcp", port)
+		if j, ok := denied[target]; ok {
+			return fmt.Errorf("конфликт правил фаервола для порта %s: порт открыт в open_ports, firewall_rules[%d] запрещает",
+				target, j)
+		}
+	}
+	return nil
+}
+
+// mergeFirewallRules объединяет правила: правило override для того же порта и протокола
+// заменяет базовое на его месте, новые правила добавляются в конец. Затем запрещающие
+// правила ставятся перед разрешающими с сохранением порядка внутри групп, чтобы
+// добавленный allow не обошел запрет, заданный в базовой конфигурации.
+func mergeFirewallRules(base, override []FirewallRule) []FirewallRule {
+	if len(override) == 0 {
+		return base
+	}
+
+	merged := append([]FirewallRule(nil), base...)
+	index := make(map[string]int, len(merged))
+	for i, rule := range merged {
+		if _, ok := index[rule.target()]; !ok {
+			index[rule.target()] = i
+		}
+	}
+	for _, rule := range override {
+		if i, ok := index[rule.target()]; ok {
+			merged[i] = rule
+			continue
+		}
+		index[rule.target()] = len(merged)
+		merged = append(merged, rule)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Action == "deny" && merged[j].Action != "deny"
+	})
+	return merged
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeConfigsFirewallRulesDenyFirst(t *testing.T) {
+	base := DefaultConfig()
+	base.Security.FirewallRules = []FirewallRule{
+		{Port: 22, Protocol: "tcp", Action: "allow", Comment: "ssh"},
+		{Port: 0, Protocol: "tcp", Action: "deny", Comment: "deny all"},
+		{Port: 25, Protocol: "tcp", Action: "deny", Comment: "smtp"},
+	}
+	override := &Config{}
+	override.Security.FirewallRules = []FirewallRule{
+		{Port: 443, Protocol: "tcp", Action: "allow", Comment: "https"},
+		{Port: 22, Protocol: "tcp", Action: "allow", Comment: "ssh from override", Tags: []string{"prod"}},
+		{Port: 3306, Protocol: "tcp", Action: "deny", Comment: "mysql"},
+	}
+
+	merged := MergeConfigs(base, override)
+	want := []FirewallRule{
+		{Port: 0, Protocol: "tcp", Action: "deny", Comment: "deny all"},
+		{Port: 25, Protocol: "tcp", Action: "deny", Comment: "smtp"},
+		{Port: 3306, Protocol: "tcp", Action: "deny", Comment: "mysql"},
+		// Правило override для того же порта заменяет базовое, а не добавляется вторым
+		{Port: 22, Protocol: "tcp", Action: "allow", Comment: "ssh from override", Tags: []string{"prod"}},
+		{Port: 443, Protocol: "tcp", Action: "allow", Comment: "https"},
+	}
+	if got := merged.Security.FirewallRules; !reflect.DeepEqual(got, want) {
+		t.Fatalf("правила после слияния:\n%+v\nожидалось:\n%+v", got, want)
+	}
+	if shadowed := ShadowedFirewallRules(merged.Security.FirewallRules); len(shadowed) != 0 {
+		t.Errorf("после слияния остались затененные правила: %v", shadowed)
+	}
+}
+
+func TestMergeConfigsWithoutOverrideRulesKeepsOrder(t *testing.T) {
+	base := DefaultConfig()
+	base.Security.FirewallRules = []FirewallRule{
+		{Port: 80, Protocol: "tcp", Action: "allow"},
+		{Port: 25, Protocol: "tcp", Action: "deny"},
+	}
+	merged := MergeConfigs(base, &Config{})
+	if !reflect.DeepEqual(merged.Security.FirewallRules, base.Security.FirewallRules) {
+		t.Errorf("без правил override порядок изменен: %+v", merged.Security.FirewallRules)
+	}
+}
+
+func TestShadowedFirewallRules(t *testing.T) {
+	rules := []FirewallRule{
+		{Port: 80, Protocol: "tcp", Action: "deny"},
+		{Port: 443, Protocol: "tcp", Action: "allow"},
+		{Port: 80, Protocol: "tcp", Action: "allow"},
+		{Port: 80, Protocol: "udp", Action: "allow"},
+		{Port: 443, Protocol: "tcp", Action: "allow"},
+	}
+	shadowed := ShadowedFirewallRules(rules)
+	if len(shadowed) != 2 {
+		t.Fatalf("затененные правила %v, ожидалось 2", shadowed)
+	}
+
+	if s := shadowed[0]; s.Index != 2 || s.ByIndex != 0 {
+		t.Errorf("первое затенение %+v, ожидалось firewall_rules[2] из-за firewall_rules[0]", s)
+	}
+	if msg := shadowed[0].String(); !strings.Contains(msg, "allow 80/tcp") || !strings.Contains(msg, "не сработает") ||
+		!strings.Contains(msg, "deny 80/tcp") {
+		t.Errorf("описание allow после deny: %q", msg)
+	}
+
+	if s := shadowed[1]; s.Index != 4 || s.ByIndex != 1 {
+		t.Errorf("второе затенение %+v, ожидалось firewall_rules[4] из-за firewall_rules[1]", s)
+	}
+	if msg := shadowed[1].String(); !strings.Contains(msg, "повторяет firewall_rules[1]") {
+		t.Errorf("описание повторяющегося правила: %q", msg)
+	}
+}
+
+func TestFilterFirewallRules(t *testing.T) {
+	rules := []FirewallRule{
+		{Port: 22, Protocol: "tcp", Action: "allow"},
+		{Port: 8080, Protocol: "tcp", Action: "allow", Tags: []string{"dev"}},
+		{Port: 443, Protocol: "tcp", Action: "allow", Tags: []string{"prod", "staging"}},
+		{Port: 5432, Protocol: "tcp", Action: "allow"},
+		{Port: 22, Protocol: "udp", Action: "deny", Tags: []string{"dev"}},
+	}
+
+	tests := []struct {
+		tags []string
+		want []int
+	}{
+		// Без фильтра применяются все правила, в том числе без тегов
+		{nil, []int{0, 1, 2, 3, 4}},
+		{[]string{"prod"}, []int{0, 2, 4}},
+		{[]string{"dev", "staging"}, []int{0, 1, 2, 4}},
+		{[]string{"qa"}, []int{0, 4}},
+	}
+	for _, tt := range tests {
+		var want []FirewallRule
+		for _, i := range tt.want {
+			want = append(want, rules[i])
+		}
+		// Правила для порта SSH сохраняются при любом фильтре, независимо от протокола
+		if got := FilterFirewallRules(rules, tt.tags, 22); !reflect.DeepEqual(got, want) {
+			t.Errorf("теги %q: %+v, ожидалось %+v", tt.tags, got, want)
+		}
+	}
+
+	// Без известного порта SSH сохраняются только отмеченные правила
+	if got := FilterFirewallRules(rules, []string{"prod"}, 0); !reflect.DeepEqual(got, []FirewallRule{rules[2]}) {
+		t.Errorf("без порта SSH: %+v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Форматы файлов конфигурации
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+var (
+	// yamlKeyPattern - строка вида "key:" или "key: value"
+	yamlKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*:(\s|$)`)
+	// sectionPattern - заголовок секции TOML/INI: [section] или [[table]]
+	sectionPattern = regexp.MustCompile(`^\[{1,2}[A-Za-z0-9_. -]+\]{1,2}$`)
+)
+
+// formatFromExtension определяет формат по расширению файла; пустая строка - расширение неизвестно
+func formatFromExtension(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return FormatJSON
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml", ".ini":
+		return FormatTOML
+	default:
+		return ""
+	}
+}
+
+// DetectFormat определяет формат конфигурации по содержимому:
+// "{" - JSON, строка "key:" - YAML, "[section]" - TOML/INI. По умолчанию - JSON.
+func DetectFormat(data []byte) string {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) == 0 || trimmed[0] == '{' {
+		return FormatJSON
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		switch {
+		case line == "---" || yamlKeyPattern.MatchString(line):
+			return FormatYAML
+		case sectionPattern.MatchString(line):
+			return FormatTOML
+		}
+		// Решение принимается по первой значимой строке
+		break
+	}
+	return FormatJSON
+}
+
+// ParseConfig разбирает конфигурацию, определяя формат по содержимому
+func ParseConfig(data []byte) (*Config, error) {
+	return decodeConfig(data, DetectFormat(data))
+}
+
+// LoadConfigFromReader читает конфигурацию из потока, например stdin
+func LoadConfigFromReader(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения конфигурации: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// decodeConfig разбирает конфигурацию в заданном формате
+func decodeConfig(data []byte, format string) (*Config, error) {
+	var config Config
+	switch format {
+	case FormatJSON:
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("ошибка парсинга конфигурации (%s): %w", format, err)
+		}
+	case FormatYAML:
+		// YAML приводится к JSON, чтобы ключи и проверки полей оставались общими
+		// с JSON-конфигурацией: у структур есть только json-теги
+		var document any
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("ошибка парсинга конфигурации (%s): %w", format, err)
+		}
+		converted, err := json.Marshal(document)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка парсинга конфигурации (%s): %w", format, err)
+		}
+		if err := json.Unmarshal(converted, &config); err != nil {
+			return nil, fmt.Errorf("ошибка парсинга конфигурации (%s): %w", format, err)
+		}
+	default:
+		return nil, fmt.Errorf("формат конфигурации %s не поддерживается", format)
+	}
+	return &config, nil
+}
+
+// encodeConfig сериализует конфигурацию в заданном формате. YAML строится из JSON,
+// поэтому ключи и их порядок совпадают с JSON-конфигурацией
+func encodeConfig(config *Config, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+		}
+		return data, nil
+	case FormatYAML:
+		data, err := json.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+		}
+		// JSON - корректный YAML: узлы сохраняют порядок ключей, остается сменить стиль
+		var node yaml.Node
+		if err := yaml.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+		}
+		resetYAMLStyle(&node)
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&node); err != nil {
+			return nil, fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, fmt.Errorf("ошибка сериализации конфигурации: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("сохранение конфигурации в формате %s не поддерживается", format)
+	}
+}
+
+// resetYAMLStyle заменяет унаследованный от JSON стиль ({...}, [...], "...") на блочный;
+// строки, которые без кавычек прочитались бы как числа или bool, кодировщик экранирует сам
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
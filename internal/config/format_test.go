@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"json", "\n  {\"system\": {}}", FormatJSON},
+		{"json с BOM", "\ufeff{}", FormatJSON},
+		{"пустой файл", "  \n", FormatJSON},
+		{"yaml", "system:\n  timezone: UTC\n", FormatYAML},
+		{"yaml с комментарием", "# настройки\n\nsecurity:\n  ssh_port: 2222\n", FormatYAML},
+		{"yaml с началом документа", "---\nsystem: {}\n", FormatYAML},
+		{"toml", "[system]\ntimezone = \"UTC\"\n", FormatTOML},
+		{"toml с массивом таблиц", "[[firewall_rules]]\nport = 80\n", FormatTOML},
+		{"ini", "; настройки\n[security]\nssh_port=2222\n", FormatTOML},
+		{"неизвестное содержимое", "timezone = UTC\n", FormatJSON},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat([]byte(tt.content)); got != tt.want {
+			t.Errorf("%s: DetectFormat() = %q, ожидалось %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigExtensionless(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"config-json": `{"system": {"timezone": "Europe/Moscow"}, "security": {"ssh_port": 2222}}`,
+		"config-yaml": "system:\n  timezone: Europe/Moscow\nsecurity:\n  ssh_port: 2222\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := LoadConfig(writeConfigFile(t, dir, name, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.System.Timezone != "Europe/Moscow" || cfg.Security.SSHPort != 2222 {
+				t.Errorf("разобрано timezone=%q ssh_port=%d", cfg.System.Timezone, cfg.Security.SSHPort)
+			}
+		})
+	}
+}
+
+func TestLoadConfigExtensionlessErrorsNameFormat(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		format  string
+	}{
+		{"broken-yaml", "system:\n  timezone: [UTC\n", FormatYAML},
+		{"broken-json", "{\"system\": ", FormatJSON},
+		{"settings-toml", "[system]\ntimezone = \"UTC\"\n", FormatTOML},
+		{"settings-ini", "[security]\nssh_port=2222\n", FormatTOML},
+	}
+	for _, tt := range tests {
+		_, err := LoadConfig(writeConfigFile(t, dir, tt.name, tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.format) {
+			t.Errorf("%s: ошибка %v, ожидалось упоминание формата %s", tt.name, err, tt.format)
+		}
+	}
+}
+
+func TestLoadConfigFromReader(t *testing.T) {
+	cfg, err := LoadConfigFromReader(strings.NewReader("system:\n  hostname: web-01\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.Hostname != "web-01" {
+		t.Errorf("hostname = %q, ожидалось web-01", cfg.System.Hostname)
+	}
+}
+
+func TestLoadConfigExtensionWinsOverContent(t *testing.T) {
+	// Содержимое похоже на YAML, но расширение .json задает формат явно
+	path := writeConfigFile(t, t.TempDir(), "config.json", "system:\n  timezone: UTC\n")
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), FormatJSON) {
+		t.Errorf("ошибка %v, ожидалась ошибка разбора JSON", err)
+	}
+}
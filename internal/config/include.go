@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrIncludeCycle возвращается, если файлы конфигурации включают друг друга по кругу
+var ErrIncludeCycle = errors.New("циклическое включение конфигурации")
+
+// resolveIncludes накладывает config на файлы из config.Include. Пути включений
+// отсчитываются от директории filename; один файл может включаться из разных мест,
+// но не из самого себя через цепочку включений.
+func resolveIncludes(filename string, config *Config, chain []string) (*Config, error) {
+	if len(config.Include) == 0 {
+		return config, nil
+	}
+
+	path, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка определения пути %s: %w", filename, err)
+	}
+	chain = append(chain[:len(chain):len(chain)], path)
+
+	var merged *Config
+	for _, include := range config.Include {
+		if include == "" {
+			return nil, fmt.Errorf("пустой путь в include файла %s", filename)
+		}
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		include = filepath.Clean(include)
+		for i, visited := range chain {
+			if visited == include {
+				cycle := append(append([]string(nil), chain[i:]...), include)
+				return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(cycle, " -> "))
+			}
+		}
+
+		included, err := loadConfig(include, chain)
+		if err != nil {
+			if errors.Is(err, ErrIncludeCycle) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("ошибка загрузки %s из include файла %s: %w", include, filename, err)
+		}
+		merged = MergeConfigs(merged, included)
+	}
+
+	own := *config
+	own.Include = nil
+	merged = MergeConfigs(merged, &own)
+	// Включения уже применены: при сохранении конфигурация записывается целиком
+	result := *merged
+	result.Include = nil
+	return &result, nil
+}
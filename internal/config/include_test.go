@@ -0,0 +1,110 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigIncludeOrderAndPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "base.json", `{
+		"system": {"timezone": "Europe/Moscow", "hostname": "base", "swap_size": "2G"},
+		"security": {"ssh_port": 22, "open_ports": [80], "enable_ufw": true, "enable_fail2ban": true},
+		"packages": {"basic": ["vim"]}
+	}`)
+	writeConfigFile(t, dir, "hosts/web01.yaml", `
+system:
+  hostname: web01
+security:
+  open_ports: [80, 443]
+packages:
+  web: [nginx]
+`)
+	top := writeConfigFile(t, dir, "top.json", `{
+		"include": ["base.json", "hosts/web01.yaml"],
+		"system": {"timezone": "UTC"},
+		"security": {"enable_ufw": false}
+	}`)
+
+	cfg, err := LoadConfig(top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.Timezone != "UTC" {
+		t.Errorf("timezone = %q: значения самого файла должны применяться последними", cfg.System.Timezone)
+	}
+	if cfg.System.Hostname != "web01" || cfg.System.SwapSize != "2G" {
+		t.Errorf("hostname = %q, swap_size = %q", cfg.System.Hostname, cfg.System.SwapSize)
+	}
+	if !reflect.DeepEqual(cfg.Security.OpenPorts, []int{80, 443}) {
+		t.Errorf("open_ports = %v", cfg.Security.OpenPorts)
+	}
+	if Enabled(cfg.Security.EnableUFW) {
+		t.Error("enable_ufw: false файла верхнего уровня потерян")
+	}
+	if !Enabled(cfg.Security.EnableFail2ban) {
+		t.Error("enable_fail2ban из base.json потерян")
+	}
+	if got := cfg.Packages.Basic.Names(); !reflect.DeepEqual(got, []string{"vim"}) {
+		t.Errorf("basic = %v", got)
+	}
+	if got := cfg.Packages.Web.Names(); !reflect.DeepEqual(got, []string{"nginx"}) {
+		t.Errorf("web = %v", got)
+	}
+	if cfg.Include != nil {
+		t.Errorf("include = %v, ожидалось nil после объединения", cfg.Include)
+	}
+}
+
+func TestLoadConfigIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	a := writeConfigFile(t, dir, "a.json", `{"include": ["b.json"]}`)
+	writeConfigFile(t, dir, "b.json", `{"include": ["hosts/c.json"]}`)
+	writeConfigFile(t, dir, "hosts/c.json", `{"include": ["../a.json"]}`)
+
+	_, err := LoadConfig(a)
+	if !errors.Is(err, ErrIncludeCycle) {
+		t.Fatalf("ошибка %v, ожидался ErrIncludeCycle", err)
+	}
+	chain := strings.Join([]string{
+		filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"),
+		filepath.Join(dir, "hosts", "c.json"), filepath.Join(dir, "a.json"),
+	}, " -> ")
+	if !strings.Contains(err.Error(), chain) {
+		t.Errorf("ошибка %q не называет цикл %s", err, chain)
+	}
+}
+
+func TestLoadConfigIncludeSelf(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "self.json", `{"include": ["self.json"]}`)
+	if _, err := LoadConfig(path); !errors.Is(err, ErrIncludeCycle) {
+		t.Fatalf("ошибка %v, ожидался ErrIncludeCycle", err)
+	}
+}
+
+func TestLoadConfigIncludeSharedFile(t *testing.T) {
+	// Один файл из двух веток включений - не цикл
+	dir := t.TempDir()
+	writeConfigFile(t, dir, "common.json", `{"system": {"swap_size": "4G"}}`)
+	writeConfigFile(t, dir, "a.json", `{"include": ["common.json"], "system": {"hostname": "a"}}`)
+	top := writeConfigFile(t, dir, "top.json", `{"include": ["common.json", "a.json"]}`)
+
+	cfg, err := LoadConfig(top)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.System.SwapSize != "4G" || cfg.System.Hostname != "a" {
+		t.Errorf("system = %+v", cfg.System)
+	}
+}
+
+func TestLoadConfigIncludeMissingFile(t *testing.T) {
+	path := writeConfigFile(t, t.TempDir(), "top.json", `{"include": ["missing.json"]}`)
+	_, err := LoadConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "missing.json") {
+		t.Fatalf("ошибка %v не называет отсутствующий файл", err)
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrOutsideMaintenanceWindow возвращается при попытке выполнить рискованную операцию
+// вне окна обслуживания
+var ErrOutsideMaintenanceWindow = errors.New("вне окна обслуживания")
+
+// MaintenanceWindow задает время, в которое разрешены рискованные операции:
+// обновление пакетов, перезапуск SSH, включение фаервола, перезагрузка.
+// Если End меньше Start, окно переходит через полночь (22:00-04:00);
+// день недели в этом случае относится к началу окна.
+type MaintenanceWindow struct {
+	// Days - дни недели: mon, tue, wed, thu, fri, sat, sun; пустой список означает любой день
+	Days []string `json:"days,omitempty"`
+	// Start и End - границы окна в формате ЧЧ:ММ; End не входит в окно
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// Timezone - часовой пояс окна; пустое значение означает локальное время
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// weekdayNames сопоставляет сокращенные названия дням недели
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parseClock разбирает время ЧЧ:ММ в минуты от начала суток
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("некорректное время окна обслуживания: %s", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Validate проверяет дни, время и часовой пояс окна обслуживания
+func (w *MaintenanceWindow) Validate() error {
+	if w == nil {
+		return nil
+	}
+	for _, day := range w.Days {
+		if _, ok := weekdayNames[strings.ToLower(day)]; !ok {
+			return fmt.Errorf("некорректный день окна обслуживания: %s", day)
+		}
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.New("начало и конец окна обслуживания совпадают")
+	}
+	if _, err := w.location(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// location возвращает часовой пояс окна
+func (w *MaintenanceWindow) location() (*time.Location, error) {
+	if w.Timezone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный часовой пояс окна обслуживания: %s", w.Timezone)
+	}
+	return loc, nil
+}
+
+// allowsDay сообщает, входит ли день недели в окно
+func (w *MaintenanceWindow) allowsDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, name := range w.Days {
+		if weekday, ok := weekdayNames[strings.ToLower(name)]; ok && weekday == day {
+			return true
+		}
+	}
+	return false
+}
+
+// WithinMaintenanceWindow сообщает, попадает ли момент now в окно обслуживания.
+// Отсутствующее окно (nil) не ограничивает операции; некорректное окно не пропускает ничего.
+func (w *MaintenanceWindow) WithinMaintenanceWindow(now time.Time) bool {
+	if w == nil {
+		return true
+	}
+	if w.Validate() != nil {
+		return false
+	}
+	loc, _ := w.location()
+	start, _ := parseClock(w.Start)
+	end, _ := parseClock(w.End)
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end && w.allowsDay(local.Weekday())
+	}
+	// Окно через полночь: вечерняя часть относится к текущему дню, утренняя - к предыдущему
+	if minute >= start {
+		return w.allowsDay(local.Weekday())
+	}
+	if minute < end {
+		return w.allowsDay(local.AddDate(0, 0, -1).Weekday())
+	}
+	return false
+}
+
+// Check возвращает ErrOutsideMaintenanceWindow, если момент now вне окна обслуживания
+func (w *MaintenanceWindow) Check(now time.Time) error {
+	if w.WithinMaintenanceWindow(now) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrOutsideMaintenanceWindow, w)
+}
+
+// String описывает окно обслуживания: "mon,tue 22:00-04:00 Europe/Moscow"
+func (w *MaintenanceWindow) String() string {
+	if w == nil {
+		return "без ограничений"
+	}
+	days := "ежедневно"
+	if len(w.Days) > 0 {
+		days = strings.Join(w.Days, ",")
+	}
+	tz := w.Timezone
+	if tz == "" {
+		tz = "локальное время"
+	}
+	return fmt.Sprintf("%s %s-%s %s", days, w.Start, w.End, tz)
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWithinMaintenanceWindowOvernight(t *testing.T) {
+	// Окно в ночь с субботы на воскресенье по Москве (UTC+3)
+	w := &MaintenanceWindow{Days: []string{"sat"}, Start: "22:00", End: "04:00", Timezone: "Europe/Moscow"}
+	tests := []struct {
+		utc  string
+		want bool
+	}{
+		{"2024-01-06T18:59:00Z", false}, // сб 21:59
+		{"2024-01-06T19:00:00Z", true},  // сб 22:00 - начало входит в окно
+		{"2024-01-06T21:00:00Z", true},  // вс 00:00
+		{"2024-01-07T00:59:00Z", true},  // вс 03:59 относится к субботнему окну
+		{"2024-01-07T01:00:00Z", false}, // вс 04:00 - конец не входит в окно
+		{"2024-01-07T19:00:00Z", false}, // вс 22:00 - воскресенье не указано
+		{"2024-01-06T00:30:00Z", false}, // сб 03:30 относится к пятнице
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.utc)
+		if got := w.WithinMaintenanceWindow(now); got != tt.want {
+			t.Errorf("%s (%s): %v, ожидалось %v", tt.utc, now.In(mustLocation(t, "Europe/Moscow")).Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestWithinMaintenanceWindowTimezones(t *testing.T) {
+	// Ежедневное окно 02:00-05:00 по Нью-Йорку: зимой UTC-5, летом UTC-4
+	w := &MaintenanceWindow{Start: "02:00", End: "05:00", Timezone: "America/New_York"}
+	tests := []struct {
+		utc  string
+		want bool
+	}{
+		{"2024-01-15T06:59:00Z", false},
+		{"2024-01-15T07:00:00Z", true},
+		{"2024-01-15T09:59:00Z", true},
+		{"2024-01-15T10:00:00Z", false},
+		{"2024-07-15T06:00:00Z", true},
+		{"2024-07-15T09:00:00Z", false},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.RFC3339, tt.utc)
+		if got := w.WithinMaintenanceWindow(now); got != tt.want {
+			t.Errorf("%s: %v, ожидалось %v", tt.utc, got, tt.want)
+		}
+	}
+
+	// Тот же момент в другом часовом поясе окна
+	utc := &MaintenanceWindow{Start: "02:00", End: "05:00", Timezone: "UTC"}
+	now, _ := time.Parse(time.RFC3339, "2024-01-15T07:00:00Z")
+	if utc.WithinMaintenanceWindow(now) {
+		t.Error("07:00 UTC вне окна 02:00-05:00 UTC")
+	}
+}
+
+func TestMaintenanceWindowCheck(t *testing.T) {
+	var none *MaintenanceWindow
+	if err := none.Check(time.Now()); err != nil {
+		t.Errorf("без окна операции не ограничиваются: %v", err)
+	}
+
+	w := &MaintenanceWindow{Days: []string{"Sun"}, Start: "01:00", End: "03:00", Timezone: "UTC"}
+	monday := time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)
+	err := w.Check(monday)
+	if !errors.Is(err, ErrOutsideMaintenanceWindow) {
+		t.Fatalf("ожидалась ErrOutsideMaintenanceWindow, получено %v", err)
+	}
+	if want := "вне окна обслуживания: Sun 01:00-03:00 UTC"; err.Error() != want {
+		t.Errorf("ошибка %q, ожидалось %q", err, want)
+	}
+	if err := w.Check(monday.AddDate(0, 0, -1)); err != nil {
+		t.Errorf("воскресенье 02:00: %v", err)
+	}
+}
+
+func TestMaintenanceWindowValidate(t *testing.T) {
+	invalid := []*MaintenanceWindow{
+		{Days: []string{"monday"}, Start: "01:00", End: "02:00"},
+		{Start: "25:00", End: "02:00"},
+		{Start: "01:00", End: ""},
+		{Start: "01:00", End: "01:00"},
+		{Start: "01:00", End: "02:00", Timezone: "Mars/Olympus"},
+	}
+	now := time.Date(2024, 1, 1, 1, 30, 0, 0, time.UTC)
+	for _, w := range invalid {
+		if err := w.Validate(); err == nil {
+			t.Errorf("%s: ожидалась ошибка", w)
+		}
+		// Некорректное окно ничего не пропускает
+		if w.WithinMaintenanceWindow(now) {
+			t.Errorf("%s: некорректное окно пропустило операцию", w)
+		}
+	}
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
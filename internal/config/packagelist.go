@@ -0,0 +1,87 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultPackageListCategory - категория пакетов, перечисленных в файле до первого заголовка
+const DefaultPackageListCategory = "basic"
+
+// LoadPackageList читает список пакетов в стиле requirements.txt: по одному пакету в строке,
+// комментарии после "#", пустые строки и заголовки категорий вида "web:".
+// Пакеты до первого заголовка относятся к категории basic.
+func LoadPackageList(path string) (map[string][]string, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения списка пакетов: %w", err)
+	}
+	defer file.Close()
+	return ParsePackageList(file)
+}
+
+// ParsePackageList разбирает список пакетов (см. LoadPackageList).
+// Повторы внутри категории отбрасываются, порядок первого упоминания сохраняется.
+func ParsePackageList(r io.Reader) (map[string][]string, error) {
+	lists := make(map[string][]string)
+	seen := make(map[string]map[string]bool)
+	category := DefaultPackageListCategory
+
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if name, ok := strings.CutSuffix(line, ":"); ok {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if _, known := (&PackagesConfig{}).Category(name); !known {
+				return nil, fmt.Errorf("строка %d: неизвестная категория %s", lineNo, name)
+			}
+			category = name
+			continue
+		}
+		if strings.ContainsAny(line, " \t") {
+			return nil, fmt.Errorf("строка %d: ожидается одно имя пакета: %q", lineNo, line)
+		}
+		if err := ValidatePackageName(line); err != nil {
+			return nil, fmt.Errorf("строка %d: %w", lineNo, err)
+		}
+
+		if seen[category] == nil {
+			seen[category] = make(map[string]bool)
+		}
+		if seen[category][line] {
+			continue
+		}
+		seen[category][line] = true
+		lists[category] = append(lists[category], line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения списка пакетов: %w", err)
+	}
+	return lists, nil
+}
+
+// MergePackageList добавляет пакеты из списка (см. LoadPackageList) в категории конфигурации
+// без дубликатов; пакеты, уже описанные в конфигурации, сохраняют свои метаданные
+func (p *PackagesConfig) MergePackageList(lists map[string][]string) error {
+	for category, names := range lists {
+		list := p.categoryList(category)
+		if list == nil {
+			return fmt.Errorf("неизвестная категория пакетов: %s", category)
+		}
+		for _, name := range names {
+			if !containsPackage(list.Names(), name) {
+				*list = append(*list, PackageEntry{Name: name})
+			}
+		}
+	}
+	return nil
+}
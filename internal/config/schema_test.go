@@ -0,0 +1,71 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// resolvedSchema разбирает сгенерированную схему библиотекой валидации JSON Schema
+func resolvedSchema(t *testing.T) *jsonschema.Resolved {
+	t.Helper()
+	data, err := GenerateJSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema jsonschema.Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("схема не разбирается: %v", err)
+	}
+	resolved, err := schema.Resolve(nil)
+	if err != nil {
+		t.Fatalf("схема некорректна: %v", err)
+	}
+	return resolved
+}
+
+// configInstance сериализует конфигурацию так же, как она сохраняется в файл
+func configInstance(t *testing.T, cfg *Config) any {
+	t.Helper()
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var instance any
+	if err := json.Unmarshal(data, &instance); err != nil {
+		t.Fatal(err)
+	}
+	return instance
+}
+
+func TestSchemaValidatesDefaultConfig(t *testing.T) {
+	resolved := resolvedSchema(t)
+	if err := resolved.Validate(configInstance(t, DefaultConfig())); err != nil {
+		t.Errorf("конфигурация по умолчанию не проходит схему: %v", err)
+	}
+}
+
+func TestSchemaRejectsInvalidConfig(t *testing.T) {
+	resolved := resolvedSchema(t)
+	tests := map[string]func(cfg *Config){
+		"ssh port":  func(cfg *Config) { cfg.Security.SSHPort = 70000 },
+		"open port": func(cfg *Config) { cfg.Security.OpenPorts = []int{0} },
+		"file mode": func(cfg *Config) { cfg.Files.ConfigMode = "0666" },
+	}
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			mutate(cfg)
+			if err := resolved.Validate(configInstance(t, cfg)); err == nil {
+				t.Error("ожидалась ошибка валидации")
+			}
+		})
+	}
+
+	instance := configInstance(t, DefaultConfig()).(map[string]any)
+	instance["unknown_section"] = true
+	if err := resolved.Validate(instance); err == nil {
+		t.Error("неизвестное поле должно отклоняться схемой")
+	}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ConfigScope определяет, где хранится конфигурация
+type ConfigScope int
+
+const (
+	// ScopeLocal - файл go-to-run.json в текущей директории
+	ScopeLocal ConfigScope = iota
+	// ScopeUser - пользовательская конфигурация в XDG_CONFIG_HOME
+	ScopeUser
+	// ScopeSystem - системная конфигурация в /etc, запись требует прав root
+	ScopeSystem
+)
+
+// localConfigFile - имя конфигурации в текущей директории
+const localConfigFile = "go-to-run.json"
+
+// systemConfigPath - путь к системной конфигурации
+const systemConfigPath = "/etc/go-to-run/config.json"
+
+// ErrRootRequired возвращается при записи системной конфигурации без прав root
+var ErrRootRequired = errors.New("для записи системной конфигурации требуются права root")
+
+// geteuid возвращает эффективный UID процесса; подменяется в тестах
+var geteuid = os.Geteuid
+
+// String возвращает название области конфигурации
+func (s ConfigScope) String() string {
+	switch s {
+	case ScopeLocal:
+		return "local"
+	case ScopeUser:
+		return "user"
+	case ScopeSystem:
+		return "system"
+	default:
+		return fmt.Sprintf("scope(%d)", int(s))
+	}
+}
+
+// ConfigPathForScope возвращает путь к конфигурации для заданной области
+func ConfigPathForScope(scope ConfigScope) string {
+	switch scope {
+	case ScopeSystem:
+		return systemConfigPath
+	case ScopeUser:
+		return filepath.Join(userConfigDir(), "config.json")
+	default:
+		return localConfigFile
+	}
+}
+
+// SaveConfigToScope сохраняет конфигурацию в файл заданной области и возвращает итоговый путь
+func SaveConfigToScope(config *Config, scope ConfigScope) (string, error) {
+	if scope == ScopeSystem && geteuid() != 0 {
+		return "", ErrRootRequired
+	}
+
+	path := ConfigPathForScope(scope)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return "", fmt.Errorf("ошибка создания директории конфигурации: %w", err)
+	}
+	if err := SaveConfig(config, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// userConfigDir возвращает директорию пользовательской конфигурации с учетом XDG_CONFIG_HOME
+func userConfigDir() string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "go-to-run")
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config", "go-to-run")
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useEUID подменяет эффективный UID процесса на время теста
+func useEUID(t *testing.T, uid int) {
+	t.Helper()
+	prev := geteuid
+	geteuid = func() int { return uid }
+	t.Cleanup(func() { geteuid = prev })
+}
+
+func TestConfigPathForScope(t *testing.T) {
+	t.Setenv("HOME", "/home/admin")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	tests := map[ConfigScope]string{
+		ScopeLocal:  "go-to-run.json",
+		ScopeUser:   "/home/admin/.config/go-to-run/config.json",
+		ScopeSystem: "/etc/go-to-run/config.json",
+	}
+	for scope, want := range tests {
+		if got := ConfigPathForScope(scope); got != want {
+			t.Errorf("%s: %s, ожидалось %s", scope, got, want)
+		}
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "/srv/xdg")
+	if got := ConfigPathForScope(ScopeUser); got != "/srv/xdg/go-to-run/config.json" {
+		t.Errorf("user с XDG_CONFIG_HOME: %s", got)
+	}
+}
+
+func TestSaveConfigToScopeUser(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	path, err := SaveConfigToScope(DefaultConfig(), ScopeUser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(xdg, "go-to-run", "config.json"); path != want {
+		t.Errorf("сохранено в %s, ожидалось %s", path, want)
+	}
+	if _, err := LoadConfig(path); err != nil {
+		t.Errorf("сохраненная конфигурация не читается: %v", err)
+	}
+}
+
+func TestSaveConfigToScopeLocal(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path, err := SaveConfigToScope(DefaultConfig(), ScopeLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "go-to-run.json" {
+		t.Errorf("сохранено в %s", path)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go-to-run.json")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveConfigToScopeSystemRequiresRoot(t *testing.T) {
+	useEUID(t, 1000)
+	path, err := SaveConfigToScope(DefaultConfig(), ScopeSystem)
+	if !errors.Is(err, ErrRootRequired) {
+		t.Fatalf("SaveConfigToScope = %q, %v, ожидалась ErrRootRequired", path, err)
+	}
+	if path != "" {
+		t.Errorf("без прав root возвращен путь %q", path)
+	}
+}
@@ -3,32 +3,90 @@
 package dashboard
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/system"
 	"github.com/fatih/color"
 )
 
 // Dashboard управляет отображением информационной панели
 type Dashboard struct {
 	config *config.Config
+	// WidgetTimeout ограничивает время каждой проверки виджета; ноль - defaultWidgetTimeout
+	WidgetTimeout time.Duration
+	// Runner запускает команды виджетов; nil - runner.Default
+	Runner runner.CommandRunner
+	// Throughput включает таблицу скорости дисков и сети для режима наблюдения (monitor).
+	// Замер занимает throughputSampleInterval, поэтому в MOTD таблица не выводится
+	Throughput bool
+}
+
+// defaultWidgetTimeout - время ожидания проверки по умолчанию: MOTD должен появляться быстро
+const defaultWidgetTimeout = 2 * time.Second
+
+// errProbeTimeout возвращается проверкой, не уложившейся в WidgetTimeout
+var errProbeTimeout = errors.New("превышено время ожидания проверки")
+
+// timeoutLabel - отображение проверки, прерванной по таймауту
+const timeoutLabel = "n/a (timeout)"
+
+func (d *Dashboard) widgetTimeout() time.Duration {
+	if d.WidgetTimeout > 0 {
+		return d.WidgetTimeout
+	}
+	return defaultWidgetTimeout
+}
+
+// probe выполняет проверку без поддержки context с таймаутом виджета.
+// Зависшая проверка продолжает работу в фоне, но ее результат отбрасывается.
+func probe[T any](ctx context.Context, d *Dashboard, check func() (T, error)) (T, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.widgetTimeout())
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := check()
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, errProbeTimeout
+	}
+}
+
+// orTimeout возвращает value или timeoutLabel, если проверка прервана по таймауту
+func orTimeout(value string, err error) string {
+	if errors.Is(err, errProbeTimeout) {
+		return timeoutLabel
+	}
+	return value
 }
 
 // NewDashboard создает новый экземпляр дашборда
 func NewDashboard() (*Dashboard, error) {
 	// Загружаем конфигурацию (как это делает main.go)
-	cfgPath := config.GetConfigPath()
-	var cfg *config.Config
-
-	if _, err := os.Stat(cfgPath); err == nil {
-		cfg, _ = config.LoadConfig(cfgPath)
+	cfg, err := config.Load()
+	if errors.Is(err, config.ErrConfigNotFound) {
+		// Явно указанный путь не подменяется конфигурацией по умолчанию
+		return nil, err
 	}
-
 	if cfg == nil {
 		cfg = config.DefaultConfig()
 	}
@@ -38,10 +96,15 @@ func NewDashboard() (*Dashboard, error) {
 	}, nil
 }
 
-// runCommand выполняет команду и возвращает вывод
-func (d *Dashboard) runCommand(cmd string, args ...string) (string, error) {
-	command := exec.Command(cmd, args...)
-	output, err := command.Output()
+// runCommand выполняет команду с таймаутом виджета и возвращает вывод
+func (d *Dashboard) runCommand(ctx context.Context, cmd string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.widgetTimeout())
+	defer cancel()
+
+	output, err := runner.Or(d.Runner).OutputContext(ctx, cmd, args...)
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", errProbeTimeout
+	}
 	if err != nil {
 		return "", err
 	}
@@ -49,17 +112,48 @@ func (d *Dashboard) runCommand(cmd string, args ...string) (string, error) {
 }
 
 // runShell выполняет shell-команду
-func (d *Dashboard) runShell(cmd string) (string, error) {
-	return d.runCommand("sh", "-c", cmd)
+func (d *Dashboard) runShell(ctx context.Context, cmd string) (string, error) {
+	return d.runCommand(ctx, "sh", "-c", cmd)
+}
+
+// requiresSudo - отображение виджета, недоступного без прав root
+const requiresSudo = "🔒 requires sudo"
+
+// runPrivilegedShell выполняет shell-команду, требующую прав root.
+// Без прав root команда не запускается и возвращается system.ErrRequiresRoot.
+func (d *Dashboard) runPrivilegedShell(ctx context.Context, cmd string) (string, error) {
+	if err := system.RequireRoot(); err != nil {
+		return "", err
+	}
+	return d.runShell(ctx, cmd)
 }
 
 // Render отображает дашборд в терминале
 func (d *Dashboard) Render() error {
+	return d.RenderContext(context.Background())
+}
+
+// RenderContext отображает дашборд, ограничивая каждую проверку таймаутом WidgetTimeout.
+// Таблица скорости дисков и сети выводится только с Throughput.
+// Зависшая проверка отображается как "n/a (timeout)"; при отмене ctx вывод прекращается
+// между секциями и возвращается ошибка контекста.
+func (d *Dashboard) RenderContext(ctx context.Context) error {
 	d.renderHeader()
-	d.renderSystemInfo()
-	d.renderSecurityInfo()
-	d.renderConfigInfo()
-	d.renderUpdatesInfo()
+	renders := []func(context.Context){d.renderSystemInfo}
+	if d.Throughput {
+		renders = append(renders, d.renderThroughput)
+	}
+	renders = append(renders,
+		d.renderSecurityInfo,
+		func(context.Context) { d.renderConfigInfo() },
+		d.renderUpdatesInfo,
+	)
+	for _, render := range renders {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		render(ctx)
+	}
 	d.renderQuickActions()
 	return nil
 }
@@ -82,18 +176,16 @@ func (d *Dashboard) renderHeader() {
 }
 
 // renderSystemInfo отображает информацию о системе
-func (d *Dashboard) renderSystemInfo() {
+func (d *Dashboard) renderSystemInfo(ctx context.Context) {
 	green := color.New(color.FgGreen, color.Bold)
 	green.Println("📊 SYSTEM INFORMATION")
 
 	// Получаем системную информацию
 	hostname, _ := os.Hostname()
-	uptime, _ := d.runShell("uptime -p | sed 's/up //'")
-	load, _ := d.runShell("cat /proc/loadavg | awk '{print $1, $2, $3}'")
-	memory, _ := d.runShell("free -m | awk 'NR==2{printf \"%.1f/%.1fGB (%.0f%%)\", $3/1024,$2/1024,$3*100/$2 }'")
-	osInfo, _ := d.runShell("grep PRETTY_NAME /etc/os-release 2>/dev/null | cut -d='\"' -f2 || echo 'Unknown'")
-	kernel, _ := d.runCommand("uname", "-r")
-	processes, _ := d.runShell("ps -e --no-headers | wc -l")
+	uptime := orTimeout(d.runShell(ctx, "uptime -p | sed 's/up //'"))
+	osInfo := orTimeout(d.runShell(ctx, "grep PRETTY_NAME /etc/os-release 2>/dev/null | cut -d='\"' -f2 || echo 'Unknown'"))
+	kernel := orTimeout(d.runCommand(ctx, "uname", "-r"))
+	processes := orTimeout(d.runShell(ctx, "ps -e --no-headers | wc -l"))
 
 	fmt.Printf("├─ Hostname: %s\n", hostname)
 	fmt.Printf("├─ OS: %s\n", osInfo)
@@ -101,11 +193,46 @@ func (d *Dashboard) renderSystemInfo() {
 	if uptime != "" {
 		fmt.Printf("├─ Uptime: %s\n", uptime)
 	}
-	if load != "" {
-		fmt.Printf("├─ Load: %s\n", load)
+	if load, err := probe(ctx, d, (&system.SystemUtils{}).GetLoadAverage); errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ Load: %s\n", timeoutLabel)
+	} else if err == nil {
+		fmt.Printf("├─ Load: %s\n", loadColor(load.Level()).Sprintf("%.2f %.2f %.2f (%.2f per core, %d cores)",
+			load.One, load.Five, load.Fifteen, load.PerCore, load.Cores))
 	}
-	if memory != "" {
-		fmt.Printf("├─ Memory: %s\n", memory)
+	// Память с учетом лимита cgroup: в контейнере /proc/meminfo показывает память хоста
+	memory, err := probe(ctx, d, (&system.SystemUtils{}).GetMemoryInfo)
+	if errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ Memory: %s\n", timeoutLabel)
+	} else if err == nil {
+		limited := ""
+		if memory.Limited {
+			limited = " [limited]"
+		}
+		fmt.Printf("├─ Memory: %.1f/%.1fGB (%.0f%%)%s\n",
+			float64(memory.Used)/(1<<30), float64(memory.Total)/(1<<30), memory.UsedPercent(), limited)
+	}
+	// Занятость дисков; inode показываются, когда их заметно больше, чем занятого места
+	su := &system.SystemUtils{}
+	if d.config != nil {
+		su.Disk = d.config.Disk
+	}
+	if usages, err := probe(ctx, d, func() ([]system.DiskUsage, error) {
+		return su.GetDiskUsage()
+	}); errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ Disk: %s\n", timeoutLabel)
+	} else if err == nil {
+		for _, usage := range usages {
+			fmt.Printf("├─ Disk %s: %.1f/%.1fGB (%.0f%%)", usage.Mount,
+				float64(usage.Used)/(1<<30), float64(usage.Total)/(1<<30), usage.UsedPercent())
+			if usage.InodePercent()-usage.UsedPercent() >= inodeDisplayMargin {
+				fmt.Printf(" ⚠️  inodes %.0f%%", usage.InodePercent())
+			}
+			fmt.Println()
+		}
+	}
+	// Предупреждаем о нехватке энтропии: генерация ключей SSH/TLS может зависнуть
+	if entropy, err := probe(ctx, d, (&system.SystemUtils{}).CheckEntropy); err == nil && entropy < system.LowEntropyThreshold {
+		fmt.Printf("├─ Entropy: ⚠️  %d (low, install haveged)\n", entropy)
 	}
 	if processes != "" {
 		fmt.Printf("└─ Processes: %s\n", processes)
@@ -113,13 +240,77 @@ func (d *Dashboard) renderSystemInfo() {
 	fmt.Println()
 }
 
+// loadColor выбирает цвет загрузки по ее доле на одно ядро
+func loadColor(level system.LoadLevel) *color.Color {
+	switch level {
+	case system.LoadOverloaded:
+		return color.New(color.FgRed)
+	case system.LoadElevated:
+		return color.New(color.FgYellow)
+	default:
+		return color.New(color.FgGreen)
+	}
+}
+
+// inodeDisplayMargin - на сколько процентов занятость inode должна превышать
+// занятость места, чтобы показать ее в дашборде
+const inodeDisplayMargin = 20.0
+
+// throughputSampleInterval - интервал между снимками счетчиков дисков и сети
+const throughputSampleInterval = 500 * time.Millisecond
+
+// renderThroughput отображает текущую скорость дисков и сетевых интерфейсов
+func (d *Dashboard) renderThroughput(ctx context.Context) {
+	su := &system.SystemUtils{}
+
+	// Снимки дисков и сети снимаются одновременно, чтобы не удваивать задержку
+	var (
+		disks []system.DiskIOStats
+		nets  []system.NetIOStats
+		wg    sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		disks, _ = probe(ctx, d, func() ([]system.DiskIOStats, error) {
+			return su.GetIOStats(throughputSampleInterval)
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		nets, _ = probe(ctx, d, func() ([]system.NetIOStats, error) {
+			return su.GetNetStats(throughputSampleInterval)
+		})
+	}()
+	wg.Wait()
+
+	if len(disks)+len(nets) == 0 {
+		return
+	}
+
+	cyan := color.New(color.FgCyan, color.Bold)
+	cyan.Println("📈 THROUGHPUT")
+	fmt.Printf("├─ %-12s %12s %12s\n", "Device", "In KB/s", "Out KB/s")
+	for _, disk := range disks {
+		fmt.Printf("├─ %-12s %12.1f %12.1f\n", disk.Device, disk.ReadKBps, disk.WriteKBps)
+	}
+	for i, iface := range nets {
+		prefix := "├─"
+		if i == len(nets)-1 {
+			prefix = "└─"
+		}
+		fmt.Printf("%s %-12s %12.1f %12.1f\n", prefix, iface.Interface, iface.RxKBps, iface.TxKBps)
+	}
+	fmt.Println()
+}
+
 // renderSecurityInfo отображает информацию о безопасности
-func (d *Dashboard) renderSecurityInfo() {
+func (d *Dashboard) renderSecurityInfo(ctx context.Context) {
 	magenta := color.New(color.FgMagenta, color.Bold)
 	magenta.Println("🛡️  SECURITY STATUS")
 
 	// SSH статус
-	sshStatus, _ := d.runShell("systemctl is-active ssh 2>/dev/null || systemctl is-active sshd 2>/dev/null || echo 'unknown'")
+	sshStatus := orTimeout(d.runShell(ctx, "systemctl is-active ssh 2>/dev/null || systemctl is-active sshd 2>/dev/null || echo 'unknown'"))
 	sshIcon := "✅"
 	if sshStatus != "active" {
 		sshIcon = "⚠️ "
@@ -133,21 +324,80 @@ func (d *Dashboard) renderSecurityInfo() {
 	}
 	fmt.Printf("├─ SSH Port: %s\n", sshPort)
 
-	// UFW статус
-	ufwStatus, _ := d.runShell("which ufw >/dev/null 2>&1 && ufw status | grep -q 'Status: active' && echo 'active' || echo 'inactive'")
-	ufwIcon := "✅"
-	if ufwStatus != "active" {
-		ufwIcon = "❌"
+	// Действующие настройки входа (sshd -T читает ключи хоста и работает только от root)
+	if system.RequireRoot() != nil {
+		fmt.Printf("├─ SSH Login: %s\n", requiresSudo)
+	} else if findings, err := probe(ctx, d, (&system.SecurityManager{}).AuditSSH); errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ SSH Login: %s\n", timeoutLabel)
+	} else if err == nil && len(findings) > 0 {
+		risks := make([]string, len(findings))
+		for i, f := range findings {
+			risks[i] = f.Directive + " " + f.Value
+		}
+		fmt.Printf("├─ SSH Login: ⚠️  %s\n", strings.Join(risks, ", "))
+	} else if err == nil {
+		fmt.Printf("├─ SSH Login: ✅ keys only\n")
+	}
+
+	// Статус фаервола (ufw, firewalld или nftables; запросы работают только от root)
+	if info, err := probe(ctx, d, (&system.SecurityManager{}).FirewallStatus); errors.Is(err, system.ErrRequiresRoot) {
+		fmt.Printf("├─ Firewall: %s\n", requiresSudo)
+	} else if errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ Firewall: %s\n", timeoutLabel)
+	} else if err != nil {
+		fmt.Printf("├─ Firewall: ❌ %v\n", err)
+	} else {
+		firewallIcon := "✅"
+		if !info.Active {
+			firewallIcon = "❌"
+		}
+		fmt.Printf("├─ Firewall: %s %s\n", firewallIcon, system.FormatFirewallInfo(info))
 	}
-	fmt.Printf("├─ UFW: %s %s\n", ufwIcon, ufwStatus)
 
-	// Fail2Ban статус
-	fail2banStatus, _ := d.runShell("which fail2ban-client >/dev/null 2>&1 && fail2ban-client status 2>/dev/null | grep -q 'Status' && echo 'active' || echo 'not installed'")
-	fail2banIcon := "✅"
-	if fail2banStatus != "active" {
-		fail2banIcon = "⚠️ "
+	// Новые версии конфигураций, оставленные менеджером пакетов при обновлении
+	if conflicts, err := probe(ctx, d, system.FindPendingConfigMerges); errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ Config merges: %s\n", timeoutLabel)
+	} else if len(conflicts) > 0 {
+		fmt.Printf("├─ Config merges: ⚠️  %d pending (.dpkg-dist/.rpmnew)\n", len(conflicts))
+	}
+
+	// Последний вход и неудачные попытки (btmp читается только root)
+	if logins, err := probe(ctx, d, system.GetLoginSummary); errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ Last login: %s\n", timeoutLabel)
+	} else if err == nil {
+		if len(logins.Recent) > 0 {
+			last := logins.Recent[0]
+			from := last.Host
+			if from == "" {
+				from = last.TTY
+			}
+			fmt.Printf("├─ Last login: %s from %s at %s\n", last.User, from, last.Time.Format("2006-01-02 15:04"))
+		}
+		switch {
+		case logins.FailedUnavailable && system.RequireRoot() != nil:
+			fmt.Printf("├─ Failed logins: %s\n", requiresSudo)
+		case logins.FailedUnavailable:
+			fmt.Printf("├─ Failed logins: n/a\n")
+		case logins.FailedCount >= system.FailedLoginWarnThreshold:
+			fmt.Printf("├─ Failed logins (24h): ⚠️  %d\n", logins.FailedCount)
+		default:
+			fmt.Printf("├─ Failed logins (24h): ✅ %d\n", logins.FailedCount)
+		}
+	}
+
+	// Fail2Ban статус (сокет fail2ban доступен только root)
+	fail2banStatus, err := d.runPrivilegedShell(ctx, "which fail2ban-client >/dev/null 2>&1 && fail2ban-client status 2>/dev/null | grep -q 'Status' && echo 'active' || echo 'not installed'")
+	if errors.Is(err, system.ErrRequiresRoot) {
+		fmt.Printf("└─ Fail2Ban: %s\n", requiresSudo)
+	} else if errors.Is(err, errProbeTimeout) {
+		fmt.Printf("└─ Fail2Ban: %s\n", timeoutLabel)
+	} else {
+		fail2banIcon := "✅"
+		if fail2banStatus != "active" {
+			fail2banIcon = "⚠️ "
+		}
+		fmt.Printf("└─ Fail2Ban: %s %s\n", fail2banIcon, fail2banStatus)
 	}
-	fmt.Printf("└─ Fail2Ban: %s %s\n", fail2banIcon, fail2banStatus)
 
 	fmt.Println()
 }
@@ -201,7 +451,7 @@ func (d *Dashboard) renderConfigInfo() {
 
 	// Показываем количество пакетов по категориям
 	fmt.Println("└─ Package Categories:")
-	categories := map[string][]string{
+	categories := map[string]config.PackageList{
 		"Basic":       d.config.Packages.Basic,
 		"Network":     d.config.Packages.Network,
 		"Development": d.config.Packages.Development,
@@ -222,43 +472,34 @@ func (d *Dashboard) renderConfigInfo() {
 }
 
 // renderUpdatesInfo отображает информацию об обновлениях
-func (d *Dashboard) renderUpdatesInfo() {
+func (d *Dashboard) renderUpdatesInfo(ctx context.Context) {
 	yellow := color.New(color.FgYellow, color.Bold)
 	yellow.Println("📦 AVAILABLE UPDATES")
 
-	// Проверяем разные менеджеры пакетов
-	updateCount := 0
-
-	// APT (Debian/Ubuntu)
-	if aptUpdates, err := d.runShell("which apt >/dev/null 2>&1 && apt list --upgradable 2>/dev/null | wc -l"); err == nil && aptUpdates != "" {
-		if count, err := strconv.Atoi(aptUpdates); err == nil && count > 1 {
-			updateCount = count - 1
-			fmt.Printf("├─ APT: %d updates available\n", updateCount)
-		}
-	}
-
-	// DNF (Fedora/RHEL)
-	if dnfUpdates, err := d.runShell("which dnf >/dev/null 2>&1 && dnf check-update --quiet 2>/dev/null | wc -l"); err == nil && dnfUpdates != "" {
-		if count, err := strconv.Atoi(dnfUpdates); err == nil && count > 0 {
-			updateCount = count
-			fmt.Printf("├─ DNF: %d updates available\n", updateCount)
-		}
-	}
-
-	// YUM (CentOS/RHEL)
-	if yumUpdates, err := d.runShell("which yum >/dev/null 2>&1 && yum check-update --quiet 2>/dev/null | wc -l"); err == nil && yumUpdates != "" {
-		if count, err := strconv.Atoi(yumUpdates); err == nil && count > 0 {
-			updateCount = count
-			fmt.Printf("├─ YUM: %d updates available\n", updateCount)
-		}
-	}
-
-	if updateCount == 0 {
+	// Используем тот же менеджер пакетов и разбор вывода, что и остальной код
+	pm, err := system.DetectPackageManager()
+	if errors.Is(err, system.ErrNoPackageManager) {
+		fmt.Println("├─ Package manager: unsupported")
+	} else if err != nil {
+		fmt.Println("├─ Package manager: n/a")
+	} else if summary, err := probe(ctx, d, func() (*system.UpdateSummary, error) {
+		return system.CheckCachedUpdates(pm)
+	}); errors.Is(err, errProbeTimeout) {
+		fmt.Printf("├─ %s: %s\n", strings.ToUpper(pm.Name), timeoutLabel)
+	} else if err != nil {
+		fmt.Printf("├─ %s: n/a\n", strings.ToUpper(pm.Name))
+	} else if summary.Available == 0 {
 		fmt.Println("├─ ✅ System is up to date")
+	} else {
+		fmt.Printf("├─ %s: %d updates available", strings.ToUpper(pm.Name), summary.Available)
+		if summary.Security > 0 {
+			fmt.Printf(" (%d security)", summary.Security)
+		}
+		fmt.Println()
 	}
 
 	// Время последнего обновления
-	if lastUpdate, err := d.runShell("stat -c %y /var/lib/apt/periodic/update-success-stamp 2>/dev/null || echo 'Never'"); err == nil {
+	if lastUpdate, err := d.runShell(ctx, "stat -c %y /var/lib/apt/periodic/update-success-stamp 2>/dev/null || echo 'Never'"); err == nil {
 		if lastUpdate != "Never" {
 			lastUpdateTime, err := time.Parse("2006-01-02 15:04:05.000000000 -0700", lastUpdate)
 			if err == nil {
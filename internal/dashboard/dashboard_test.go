@@ -0,0 +1,253 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/system"
+	"github.com/fatih/color"
+)
+
+// captureStdout возвращает вывод render в stdout
+func captureStdout(t *testing.T, render func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = w, w
+	defer func() { os.Stdout, color.Output = stdout, colorOutput }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	render()
+	_ = w.Close()
+	return <-done
+}
+
+// onlyManager возвращает FakeRunner, в PATH которого из менеджеров пакетов есть только name
+func onlyManager(name string) *runner.FakeRunner {
+	fake := runner.NewFakeRunner()
+	for _, manager := range []string{"apt", "dnf", "yum", "pacman", "zypper", "apk"} {
+		fake.Missing[manager] = manager != name
+	}
+	return fake
+}
+
+func TestLoadColor(t *testing.T) {
+	tests := []struct {
+		perCore float64
+		want    color.Attribute
+	}{
+		{0.0, color.FgGreen},
+		{0.69, color.FgGreen},
+		{0.7, color.FgYellow},
+		{0.99, color.FgYellow},
+		{1.0, color.FgRed},
+		{4.0, color.FgRed},
+	}
+	for _, tt := range tests {
+		load := &system.LoadInfo{PerCore: tt.perCore}
+		if got := loadColor(load.Level()); !got.Equals(color.New(tt.want)) {
+			t.Errorf("загрузка %.2f на ядро: цвет уровня %s, ожидался %v", tt.perCore, load.Level(), tt.want)
+		}
+	}
+}
+
+func TestRenderUpdatesUsesDetectedManager(t *testing.T) {
+	// Код 100 у dnf check-update означает, что обновления есть
+	exit100 := exec.Command("sh", "-c", "exit 100").Run()
+	fake := onlyManager("dnf").
+		On("sh -c dnf check-update", `Last metadata expiration check: 0:05:00 ago on Mon 01 Jan 2024 10:00:00 AM UTC.
+
+kernel.x86_64          6.6.8-200.fc39      updates
+openssl-libs.x86_64    1:3.1.1-4.fc39      updates
+`, exit100).
+		On("sh -c dnf -q updateinfo list --security", "FEDORA-2024-1a2b3c4d5e Important/Sec. openssl-libs-1:3.1.1-4.fc39.x86_64\n", nil).
+		On("sh -c yum check-update", "vim.x86_64 2:9.0 updates\ncurl.x86_64 8.0 updates\n", nil).
+		On("sh -c apt list --upgradable", "vim/jammy 2:8.2 amd64 [upgradable from: 2:8.1]\n", nil)
+	t.Cleanup(system.SetCommandRunner(fake))
+
+	d := &Dashboard{Runner: fake}
+	output := captureStdout(t, func() { d.renderUpdatesInfo(context.Background()) })
+	if !strings.Contains(output, "DNF: 2 updates available (1 security)") {
+		t.Errorf("вывод виджета:\n%s", output)
+	}
+	if strings.Contains(output, "YUM") || strings.Contains(output, "APT") {
+		t.Errorf("виджет учитывает не обнаруженные менеджеры:\n%s", output)
+	}
+	for _, command := range fake.Commands() {
+		if strings.Contains(command, "yum ") || strings.Contains(command, "apt ") {
+			t.Errorf("запущена проверка другого менеджера: %q", command)
+		}
+	}
+}
+
+func TestRenderUpdatesCountsDnfPackagesOnly(t *testing.T) {
+	// Заголовок, пустые строки и заменяемые пакеты не считаются обновлениями,
+	// а пакет из секции Obsoleting Packages считается
+	exit100 := exec.Command("sh", "-c", "exit 100").Run()
+	fake := onlyManager("dnf").
+		On("sh -c dnf check-update", `Last metadata expiration check: 0:05:00 ago on Mon 01 Jan 2024 10:00:00 AM UTC.
+
+
+kernel.x86_64          6.6.8-200.fc39      updates
+
+openssl-libs.x86_64    1:3.1.1-4.fc39      updates
+Obsoleting Packages
+grub2-tools.x86_64     1:2.06-110.fc39     updates
+    grub2-tools.x86_64 1:2.06-100.fc39     @updates
+
+`, exit100).
+		On("sh -c dnf -q updateinfo list --security", "", nil)
+	t.Cleanup(system.SetCommandRunner(fake))
+
+	d := &Dashboard{Runner: fake}
+	output := captureStdout(t, func() { d.renderUpdatesInfo(context.Background()) })
+	if !strings.Contains(output, "DNF: 3 updates available\n") {
+		t.Errorf("ожидалось 3 обновления без исправлений безопасности, вывод виджета:\n%s", output)
+	}
+	for _, command := range fake.Commands() {
+		if strings.Contains(command, "wc -l") {
+			t.Errorf("обновления подсчитаны через wc: %q", command)
+		}
+	}
+}
+
+func TestRenderUpdatesWithoutPackageManager(t *testing.T) {
+	fake := onlyManager("")
+	t.Cleanup(system.SetCommandRunner(fake))
+
+	d := &Dashboard{Runner: fake}
+	output := captureStdout(t, func() { d.renderUpdatesInfo(context.Background()) })
+	if !strings.Contains(output, "Package manager: unsupported") {
+		t.Errorf("виджет не показывает отсутствие менеджера пакетов:\n%s", output)
+	}
+}
+
+// slowRunner - FakeRunner, у которого команды, содержащие slow, зависают до отмены
+// контекста или до завершения теста
+type slowRunner struct {
+	*runner.FakeRunner
+	slow    string
+	release chan struct{}
+}
+
+func newSlowRunner(t *testing.T, fake *runner.FakeRunner, slow string) *slowRunner {
+	r := &slowRunner{FakeRunner: fake, slow: slow, release: make(chan struct{})}
+	t.Cleanup(func() { close(r.release) })
+	return r
+}
+
+func (r *slowRunner) hang(ctx context.Context, name string, args []string) {
+	if strings.Contains(runner.Call{Name: name, Args: args}.String(), r.slow) {
+		select {
+		case <-ctx.Done():
+		case <-r.release:
+		}
+	}
+}
+
+func (r *slowRunner) Output(name string, args ...string) ([]byte, error) {
+	r.hang(context.Background(), name, args)
+	return r.FakeRunner.Output(name, args...)
+}
+
+func (r *slowRunner) OutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	r.hang(ctx, name, args)
+	return r.FakeRunner.OutputContext(ctx, name, args...)
+}
+
+func TestRunShellTimesOut(t *testing.T) {
+	fake := runner.NewFakeRunner().On("sh -c ufw status", "Status: active\n", nil)
+	d := &Dashboard{Runner: newSlowRunner(t, fake, "ufw status"), WidgetTimeout: 20 * time.Millisecond}
+
+	start := time.Now()
+	if _, err := d.runShell(context.Background(), "ufw status"); !errors.Is(err, errProbeTimeout) {
+		t.Errorf("runShell() error = %v, ожидается errProbeTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("проверка прервана через %s", elapsed)
+	}
+	if got := orTimeout("active", errProbeTimeout); got != timeoutLabel {
+		t.Errorf("orTimeout() = %q", got)
+	}
+}
+
+func TestRenderCompletesWithSlowProbe(t *testing.T) {
+	fake := onlyManager("apt")
+	slow := newSlowRunner(t, fake, "apt")
+	t.Cleanup(system.SetCommandRunner(slow))
+	d := &Dashboard{config: config.DefaultConfig(), Runner: slow, WidgetTimeout: 50 * time.Millisecond}
+
+	done := make(chan string, 1)
+	go func() {
+		done <- captureStdout(t, func() {
+			if err := d.Render(); err != nil {
+				t.Error(err)
+			}
+		})
+	}()
+	select {
+	case output := <-done:
+		if !strings.Contains(output, "APT: "+timeoutLabel) {
+			t.Errorf("зависшая проверка обновлений не отмечена таймаутом:\n%s", output)
+		}
+		if !strings.Contains(output, "QUICK ACTIONS") {
+			t.Errorf("дашборд отрисован не полностью:\n%s", output)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Render завис на медленной проверке")
+	}
+}
+
+func TestRenderThroughputOptIn(t *testing.T) {
+	fake := onlyManager("apt")
+	t.Cleanup(system.SetCommandRunner(fake))
+
+	// По умолчанию (MOTD) скорость дисков и сети не замеряется
+	d := &Dashboard{config: config.DefaultConfig(), Runner: fake}
+	output := captureStdout(t, func() {
+		if err := d.Render(); err != nil {
+			t.Error(err)
+		}
+	})
+	if strings.Contains(output, "THROUGHPUT") {
+		t.Errorf("таблица скорости выведена без Throughput:\n%s", output)
+	}
+
+	if _, err := os.Stat("/proc/net/dev"); err != nil {
+		t.Skip("нет /proc/net/dev")
+	}
+	d.Throughput = true
+	output = captureStdout(t, func() {
+		if err := d.Render(); err != nil {
+			t.Error(err)
+		}
+	})
+	if !strings.Contains(output, "THROUGHPUT") {
+		t.Errorf("таблица скорости не выведена с Throughput:\n%s", output)
+	}
+}
+
+func TestNewDashboardMissingConfigOverride(t *testing.T) {
+	config.SetConfigPathOverride(filepath.Join(t.TempDir(), "missing.json"))
+	t.Cleanup(func() { config.SetConfigPathOverride("") })
+
+	if _, err := NewDashboard(); !errors.Is(err, config.ErrConfigNotFound) {
+		t.Errorf("NewDashboard() error = %v, ожидалась ErrConfigNotFound", err)
+	}
+}
@@ -0,0 +1,371 @@
+// Package orchestrator выполняет полную настройку системы по конфигурации:
+// системные параметры, установку пакетов и настройку безопасности с пользовательскими хуками.
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/system"
+)
+
+// StepResult содержит результат шага настройки
+type StepResult struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// HookResult содержит результат выполнения хука
+type HookResult struct {
+	Phase    Phase
+	Name     string
+	Output   string
+	Err      error
+	Duration time.Duration
+}
+
+// Report содержит результаты шагов и хуков Apply
+type Report struct {
+	Steps []StepResult
+	Hooks []HookResult
+	// Skipped - фазы, отключенные флагами Manage* в конфигурации
+	Skipped []string
+}
+
+// skippedPhases возвращает фазы, отключенные в конфигурации
+func skippedPhases(cfg *config.Config) []string {
+	var skipped []string
+	for _, phase := range []struct {
+		name string
+		flag *bool
+	}{
+		{"timezone", cfg.Phases.ManageTimezone},
+		{"swap", cfg.Phases.ManageSwap},
+		{"packages", cfg.Phases.ManagePackages},
+		{"firewall", cfg.Phases.ManageFirewall},
+		{"ssh", cfg.Phases.ManageSSH},
+	} {
+		if !config.Manages(phase.flag) {
+			skipped = append(skipped, phase.name)
+		}
+	}
+	return skipped
+}
+
+// step описывает шаг Apply: встроенное действие или вызов хуков фазы
+type step struct {
+	name  string
+	phase Phase
+	run   func(ctx context.Context, cfg *config.Config) error
+}
+
+// steps возвращает шаги полной настройки в порядке выполнения
+func steps() []step {
+	return []step{
+		{name: "system", run: applySystem},
+		{phase: PhasePreInstall},
+		{name: "packages", run: applyPackages},
+		{phase: PhasePostInstall},
+		{name: "security", run: applySecurity},
+		{phase: PhasePostSecurity},
+	}
+}
+
+// ApplyOptions задает параметры выполнения Apply
+type ApplyOptions struct {
+	// Force разрешает рискованные фазы вне окна обслуживания
+	Force bool
+	// Now возвращает текущее время для проверки окна обслуживания; nil означает time.Now
+	Now func() time.Time
+}
+
+// disruptivePhases возвращает фазы конфигурации, способные нарушить работу сервиса:
+// установку пакетов, включение фаервола и перезапуск SSH
+func disruptivePhases(cfg *config.Config) []string {
+	var phases []string
+	if config.Manages(cfg.Phases.ManagePackages) {
+		for _, category := range config.CategoryNames {
+			if list, _ := cfg.Packages.Category(category); len(without(list.Names(), cfg.Packages.Exclude[category])) > 0 {
+				phases = append(phases, "packages")
+				break
+			}
+		}
+	}
+	if config.Enabled(cfg.Security.EnableUFW) && config.Manages(cfg.Phases.ManageFirewall) {
+		phases = append(phases, "firewall")
+	}
+	if cfg.Security.SSHPort > 0 && config.Manages(cfg.Phases.ManageSSH) {
+		phases = append(phases, "ssh")
+	}
+	return phases
+}
+
+// checkMaintenanceWindow отказывает в запуске рискованных фаз вне окна обслуживания
+func checkMaintenanceWindow(cfg *config.Config, opts ApplyOptions) error {
+	if opts.Force || cfg.Maintenance == nil {
+		return nil
+	}
+	phases := disruptivePhases(cfg)
+	if len(phases) == 0 {
+		return nil
+	}
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+	if err := cfg.Maintenance.Check(now()); err != nil {
+		return fmt.Errorf("%w; фазы %s требуют окна обслуживания или принудительного запуска",
+			err, strings.Join(phases, ", "))
+	}
+	return nil
+}
+
+// Apply выполняет полную настройку системы и вызывает хуки на соответствующих фазах.
+// Выполнение останавливается на первой ошибке шага или хука; отчет содержит все выполненные шаги.
+func Apply(ctx context.Context, cfg *config.Config) (*Report, error) {
+	return ApplyWithOptions(ctx, cfg, ApplyOptions{})
+}
+
+// ApplyWithOptions выполняет Apply с параметрами. Если в конфигурации задано окно обслуживания,
+// а рискованные фазы запускаются вне его без Force, настройка не начинается.
+func ApplyWithOptions(ctx context.Context, cfg *config.Config, opts ApplyOptions) (*Report, error) {
+	if err := config.ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
+	if err := checkMaintenanceWindow(cfg, opts); err != nil {
+		return nil, err
+	}
+	// Права создаваемых файлов не должны зависеть от umask окружения
+	system.SecureUmask()
+	if err := system.SetFileModes(cfg.Files); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Skipped: skippedPhases(cfg)}
+	for _, st := range steps() {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		if st.phase != "" {
+			if err := runHooks(ctx, cfg, st.phase, report); err != nil {
+				return report, err
+			}
+			continue
+		}
+
+		start := time.Now()
+		err := st.run(ctx, cfg)
+		report.Steps = append(report.Steps, StepResult{Name: st.name, Err: err, Duration: time.Since(start)})
+		if err != nil {
+			return report, fmt.Errorf("ошибка шага %s: %w", st.name, err)
+		}
+	}
+	return report, nil
+}
+
+// runHooks выполняет зарегистрированные хуки фазы, затем скрипты из hooks.d
+func runHooks(ctx context.Context, cfg *config.Config, phase Phase, report *Report) error {
+	scripts, err := LoadScriptHooks(cfg.Hooks.Dir, phase)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range append(registeredHooks(phase), scripts...) {
+		start := time.Now()
+		var output string
+		if oh, ok := hook.(OutputHook); ok {
+			output, err = oh.RunWithOutput(ctx, cfg)
+		} else {
+			err = hook.Run(ctx, cfg)
+		}
+		report.Hooks = append(report.Hooks, HookResult{
+			Phase:    phase,
+			Name:     hook.Name(),
+			Output:   output,
+			Err:      err,
+			Duration: time.Since(start),
+		})
+		if err != nil {
+			return fmt.Errorf("ошибка хука %s (%s): %w", hook.Name(), phase, err)
+		}
+	}
+	return nil
+}
+
+func applySystem(_ context.Context, cfg *config.Config) error {
+	su := &system.SystemUtils{}
+	if config.Manages(cfg.Phases.ManageTimezone) {
+		if err := su.SetupTimezone(cfg.System.Timezone); err != nil {
+			return err
+		}
+	}
+	if cfg.System.Locale != "" {
+		if err := su.SetupLocale(cfg.System.Locale); err != nil {
+			return err
+		}
+	}
+	if cfg.System.SwapSize != "" && config.Manages(cfg.Phases.ManageSwap) {
+		return su.SetupSwap(cfg.System.SwapSize)
+	}
+	return nil
+}
+
+func applyPackages(ctx context.Context, cfg *config.Config) error {
+	if !config.Manages(cfg.Phases.ManagePackages) {
+		return nil
+	}
+	pm, err := system.DetectPackageManager()
+	if err != nil {
+		return err
+	}
+	if pm, err = system.ApplyPackageManagerOverride(pm, cfg.Packages.Manager); err != nil {
+		return err
+	}
+	// Пакет проверяется при фильтрации, установке и повторной попытке - кэшируем на время шага
+	pm.State = system.NewPackageStateCache()
+
+	type categoryPackages struct {
+		name               string
+		required, optional []string
+	}
+	var (
+		categories []categoryPackages
+		all        []string
+	)
+	for _, category := range config.CategoryNames {
+		list, _ := cfg.Packages.Category(category)
+		required, optional := list.Split()
+		exclude := cfg.Packages.Exclude[category]
+		required, optional = without(required, exclude), without(optional, exclude)
+		if len(required)+len(optional) == 0 {
+			continue
+		}
+		categories = append(categories, categoryPackages{name: category, required: required, optional: optional})
+		all = append(all, required...)
+		all = append(all, optional...)
+	}
+
+	// Проверяем все пакеты до установки, чтобы показать полный список ненайденных
+	_, unknown, err := system.ResolveAndValidate(pm, all)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		if cfg.Packages.UnknownPolicy == "error" {
+			return fmt.Errorf("%w: %s", system.ErrUnknownPackages, strings.Join(unknown, ", "))
+		}
+		fmt.Printf("⚠️  Пакеты не найдены в репозиториях и будут пропущены: %s\n", strings.Join(unknown, ", "))
+	}
+
+	for _, category := range categories {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		required := resolveNames(pm, without(category.required, unknown))
+		optional := resolveNames(pm, without(category.optional, unknown))
+		if len(required)+len(optional) == 0 {
+			continue
+		}
+		if err := system.InstallPackagesWithOptional(pm, required, optional, true); err != nil {
+			return fmt.Errorf("категория %s: %w", category.name, err)
+		}
+	}
+	return nil
+}
+
+// resolveNames переводит имена пакетов для менеджера pm
+func resolveNames(pm *system.PackageManager, packages []string) []string {
+	result := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		result = append(result, system.ResolvePackageName(pm, pkg))
+	}
+	return result
+}
+
+func applySecurity(_ context.Context, cfg *config.Config) error {
+	sec := cfg.Security
+	sm := &system.SecurityManager{SSHBackupKeep: sec.SSHBackupKeep}
+
+	if config.Enabled(sec.EnableUFW) && config.Manages(cfg.Phases.ManageFirewall) {
+		if err := sm.SetupFirewall(firewallConfig(sec, sec.FirewallRules)); err != nil {
+			return err
+		}
+	}
+
+	if config.Enabled(sec.EnableFail2ban) {
+		if err := sm.SetupFail2ban(); err != nil {
+			return err
+		}
+	}
+
+	if sec.SSHPort > 0 && config.Manages(cfg.Phases.ManageSSH) {
+		// Вход root и по паролю меняются только явно заданными в конфигурации значениями:
+		// иначе можно потерять доступ к хосту, где ключи настроены только для root
+		hardening := sec.SSHHardening
+		if hardening == nil {
+			hardening = config.DefaultSSHHardening()
+		}
+		return sm.SetupSSHWithHardening(sec.SSHPort, sec.PermitRootLogin, sec.PasswordAuth, hardening)
+	}
+	return nil
+}
+
+// ApplyFirewallTags настраивает фаервол только с правилами, отмеченными одним из тегов tags
+// (см. config.FilterFirewallRules). Правило для порта SSH и разрешение самого SSH
+// применяются всегда; open_ports и allow_ips не имеют тегов и применяются как обычно.
+// Одна конфигурация может так описывать наборы правил dev, staging и prod.
+// К активному фаерволу правила добавляются без сброса, ранее добавленные правила остаются.
+func ApplyFirewallTags(cfg *config.Config, tags []string) error {
+	sec := cfg.Security
+	if !config.Enabled(sec.EnableUFW) {
+		return fmt.Errorf("фаервол отключен в конфигурации (enable_ufw)")
+	}
+	if !config.Manages(cfg.Phases.ManageFirewall) {
+		return fmt.Errorf("фаервол исключен из управления (phases.manage_firewall)")
+	}
+	sm := &system.SecurityManager{SSHBackupKeep: sec.SSHBackupKeep}
+	return sm.ApplyFirewallRules(firewallConfig(sec, config.FilterFirewallRules(sec.FirewallRules, tags, sec.SSHPort)))
+}
+
+// firewallConfig возвращает настройки фаервола с правилами rules вместо sec.FirewallRules
+func firewallConfig(sec config.SecurityConfig, rules []config.FirewallRule) *system.FirewallConfig {
+	converted := make([]system.FirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		converted = append(converted, system.FirewallRule{
+			Port:     rule.Port,
+			Protocol: rule.Protocol,
+			Action:   rule.Action,
+			Comment:  rule.Comment,
+		})
+	}
+	return &system.FirewallConfig{
+		Enabled:   true,
+		SSHPort:   sec.SSHPort,
+		OpenPorts: sec.OpenPorts,
+		AllowIPs:  sec.AllowIPs,
+		Rules:     converted,
+	}
+}
+
+// without возвращает пакеты, не входящие в exclude
+func without(packages, exclude []string) []string {
+	if len(exclude) == 0 {
+		return packages
+	}
+	skip := make(map[string]bool, len(exclude))
+	for _, pkg := range exclude {
+		skip[pkg] = true
+	}
+	var result []string
+	for _, pkg := range packages {
+		if !skip[pkg] {
+			result = append(result, pkg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package orchestrator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/config"
+)
+
+// taggedFirewallConfig возвращает конфигурацию с правилами для разных окружений
+func taggedFirewallConfig() *config.Config {
+	cfg := quietConfig()
+	cfg.Phases.ManageFirewall = config.Bool(true)
+	cfg.Security.EnableUFW = config.Bool(true)
+	cfg.Security.SSHPort = 2222
+	cfg.Security.OpenPorts = nil
+	cfg.Security.AllowIPs = nil
+	cfg.Security.FirewallRules = []config.FirewallRule{
+		{Port: 2222, Protocol: "tcp", Action: "allow", Comment: "bastion"},
+		{Port: 8080, Protocol: "tcp", Action: "allow", Tags: []string{"dev"}},
+		{Port: 443, Protocol: "tcp", Action: "allow", Tags: []string{"prod", "staging"}},
+		{Port: 5432, Protocol: "tcp", Action: "allow"},
+	}
+	return cfg
+}
+
+// ufwRules возвращает команды добавления правил ufw
+func ufwRules(commands []string) []string {
+	var rules []string
+	for _, command := range commands {
+		if strings.HasPrefix(command, "sh -c ufw allow ") || strings.HasPrefix(command, "sh -c ufw deny ") {
+			rules = append(rules, strings.TrimPrefix(command, "sh -c "))
+		}
+	}
+	return rules
+}
+
+func TestApplyFirewallTags(t *testing.T) {
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{
+			tags: []string{"prod"},
+			// Правило для порта SSH применяется, хотя у него нет тега prod
+			want: []string{"ufw allow 2222/tcp comment 'SSH access'", "ufw allow 2222/tcp comment 'bastion'", "ufw allow 443/tcp"},
+		},
+		{
+			tags: []string{"dev"},
+			want: []string{"ufw allow 2222/tcp comment 'SSH access'", "ufw allow 2222/tcp comment 'bastion'", "ufw allow 8080/tcp"},
+		},
+		{
+			tags: []string{"qa"},
+			want: []string{"ufw allow 2222/tcp comment 'SSH access'", "ufw allow 2222/tcp comment 'bastion'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.tags, ","), func(t *testing.T) {
+			fake := useAptOnly(t)
+			fake.On("ufw status", "Status: inactive\n", nil)
+
+			if err := ApplyFirewallTags(taggedFirewallConfig(), tt.tags); err != nil {
+				t.Fatal(err)
+			}
+			if rules := ufwRules(fake.Commands()); !reflect.DeepEqual(rules, tt.want) {
+				t.Errorf("правила:\n%q\nожидалось:\n%q", rules, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyFirewallTagsActiveFirewall(t *testing.T) {
+	fake := useAptOnly(t)
+	fake.On("ufw status", "Status: active\n", nil)
+
+	if err := ApplyFirewallTags(taggedFirewallConfig(), []string{"prod"}); err != nil {
+		t.Fatal(err)
+	}
+	commands := fake.Commands()
+	want := []string{"ufw allow 2222/tcp comment 'SSH access'", "ufw allow 2222/tcp comment 'bastion'", "ufw allow 443/tcp"}
+	if rules := ufwRules(commands); !reflect.DeepEqual(rules, want) {
+		t.Errorf("правила:\n%q\nожидалось:\n%q", rules, want)
+	}
+	// Действующий фаервол не сбрасывается и не включается заново
+	for _, command := range commands {
+		if strings.Contains(command, "reset") || strings.Contains(command, "ufw enable") || strings.Contains(command, "ufw default") {
+			t.Errorf("на активном фаерволе выполнена команда %q", command)
+		}
+	}
+}
+
+func TestApplyFirewallTagsActiveFirewallRollsBack(t *testing.T) {
+	fake := useAptOnly(t)
+	fake.On("ufw status", "Status: active\n", nil)
+	fake.On("sh -c ufw allow 443/tcp", "", errors.New("exit status 1"))
+
+	if err := ApplyFirewallTags(taggedFirewallConfig(), []string{"prod"}); err == nil {
+		t.Fatal("ожидалась ошибка применения правила")
+	}
+	commands := fake.Commands()
+	if last := commands[len(commands)-1]; last != "ufw reload" {
+		t.Errorf("после ошибки правила не восстановлены: %q", commands)
+	}
+}
+
+func TestApplyFirewallTagsWithoutFilterAppliesAll(t *testing.T) {
+	fake := useAptOnly(t)
+	fake.On("ufw status", "Status: inactive\n", nil)
+
+	if err := ApplyFirewallTags(taggedFirewallConfig(), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"ufw allow 2222/tcp comment 'SSH access'", "ufw allow 2222/tcp comment 'bastion'",
+		"ufw allow 8080/tcp", "ufw allow 443/tcp", "ufw allow 5432/tcp",
+	}
+	if rules := ufwRules(fake.Commands()); !reflect.DeepEqual(rules, want) {
+		t.Errorf("правила:\n%q\nожидалось:\n%q", rules, want)
+	}
+}
+
+func TestApplyFirewallTagsDisabledFirewall(t *testing.T) {
+	fake := useAptOnly(t)
+
+	cfg := taggedFirewallConfig()
+	cfg.Security.EnableUFW = config.Bool(false)
+	if err := ApplyFirewallTags(cfg, []string{"prod"}); err == nil || !strings.Contains(err.Error(), "enable_ufw") {
+		t.Errorf("ошибка %v, ожидался отказ при отключенном фаерволе", err)
+	}
+
+	cfg = taggedFirewallConfig()
+	cfg.Phases.ManageFirewall = config.Bool(false)
+	if err := ApplyFirewallTags(cfg, []string{"prod"}); err == nil || !strings.Contains(err.Error(), "manage_firewall") {
+		t.Errorf("ошибка %v, ожидался отказ при исключенной фазе", err)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("при отказе выполнены команды %q", commands)
+	}
+}
@@ -0,0 +1,265 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/13winged/go-to-run/internal/system"
+	"github.com/13winged/go-to-run/pkg/archive"
+)
+
+// ErrDoctorProblems возвращается, если диагностика нашла проблемы уровня error
+var ErrDoctorProblems = errors.New("диагностика нашла проблемы окружения")
+
+// DoctorFinding - проблема окружения и способ ее исправить
+type DoctorFinding struct {
+	Severity Severity
+	// Module - часть окружения: packages, archive, ssh, filesystem, disk, firewall, privileges
+	Module  string
+	Problem string
+	// Fix - конкретное действие: команда или пакет для установки
+	Fix string
+}
+
+// DoctorReport содержит найденные диагностикой проблемы
+type DoctorReport struct {
+	Findings []DoctorFinding
+}
+
+// BySeverity возвращает проблемы указанной важности
+func (r *DoctorReport) BySeverity(severity Severity) []DoctorFinding {
+	var found []DoctorFinding
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			found = append(found, f)
+		}
+	}
+	return found
+}
+
+// doctorChecks перечисляет проверки окружения; все проверки только читают состояние
+var doctorChecks = []func(pm *system.PackageManager) []DoctorFinding{
+	doctorPrivileges,
+	doctorPackageManager,
+	doctorArchiveTools,
+	doctorSSHService,
+	doctorWritableEtc,
+	doctorDiskSpace,
+	doctorFirewall,
+}
+
+// Пороги занятости корневой файловой системы в процентах
+const (
+	doctorDiskWarning = 90.0
+	doctorDiskError   = 97.0
+)
+
+// Doctor проверяет окружение и для каждой проблемы предлагает исправление: какой пакет
+// установить или какую команду выполнить. В отличие от SelfTest (работает ли утилита)
+// и LintConfig (применима ли конфигурация), Doctor ищет исправимые проблемы хоста.
+// При проблемах уровня error вместе с полным отчетом возвращается ErrDoctorProblems.
+func Doctor() (*DoctorReport, error) {
+	// Без менеджера пакетов проверки предлагают общие подсказки вместо команд установки
+	pm, _ := system.DetectPackageManager()
+
+	report := &DoctorReport{}
+	for _, check := range doctorChecks {
+		report.Findings = append(report.Findings, check(pm)...)
+	}
+
+	if errs := report.BySeverity(SeverityError); len(errs) > 0 {
+		return report, fmt.Errorf("%w: %d", ErrDoctorProblems, len(errs))
+	}
+	return report, nil
+}
+
+// FormatDoctor форматирует отчет, группируя проблемы по важности
+func FormatDoctor(report *DoctorReport) string {
+	if len(report.Findings) == 0 {
+		return "✅ Проблем окружения не найдено\n"
+	}
+
+	var b strings.Builder
+	for _, group := range []struct {
+		severity Severity
+		title    string
+	}{
+		{SeverityError, "❌ Ошибки"},
+		{SeverityWarning, "⚠️  Предупреждения"},
+		{SeverityInfo, "ℹ️  Замечания"},
+	} {
+		findings := report.BySeverity(group.severity)
+		if len(findings) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s (%d):\n", group.title, len(findings))
+		for _, f := range findings {
+			fmt.Fprintf(&b, "  [%s] %s\n", f.Module, f.Problem)
+			if f.Fix != "" {
+				fmt.Fprintf(&b, "      исправление: %s\n", f.Fix)
+			}
+		}
+	}
+	return b.String()
+}
+
+// installFix возвращает команду установки пакета или общую подсказку без менеджера пакетов
+func installFix(pm *system.PackageManager, pkg string) string {
+	if pm == nil {
+		return "установите пакет " + pkg
+	}
+	return pm.Install + " " + system.ResolvePackageName(pm, pkg)
+}
+
+func doctorPrivileges(_ *system.PackageManager) []DoctorFinding {
+	if os.Geteuid() == 0 {
+		return nil
+	}
+	return []DoctorFinding{{
+		Severity: SeverityInfo, Module: "privileges",
+		Problem: "запуск без прав root: изменения системы и часть проверок недоступны",
+		Fix:     "запустите через sudo",
+	}}
+}
+
+func doctorPackageManager(pm *system.PackageManager) []DoctorFinding {
+	if pm != nil {
+		return nil
+	}
+	return []DoctorFinding{{
+		Severity: SeverityError, Module: "packages",
+		Problem: "менеджер пакетов не найден",
+		Fix:     "поддерживаются apt, dnf, yum, pacman, zypper и apk; для другого менеджера задайте команды в packages.manager",
+	}}
+}
+
+// archiveToolPackages сопоставляет утилиты архивов с пакетами, которые их устанавливают
+var archiveToolPackages = map[string]string{
+	"tar":    "tar",
+	"gzip":   "gzip",
+	"gunzip": "gzip",
+	"bzip2":  "bzip2",
+	"xz":     "xz-utils",
+	"unzip":  "unzip",
+	"unrar":  "unrar",
+	"7z":     "p7zip-full",
+	"lz4":    "lz4",
+	"zstd":   "zstd",
+	"lzop":   "lzop",
+	"cpio":   "cpio",
+}
+
+// doctorArchiveTools предлагает установить утилиты для форматов, которые без них не извлекаются
+func doctorArchiveTools(pm *system.PackageManager) []DoctorFinding {
+	tools := (&archive.ExtractManager{}).CheckTools()
+	var missing []string
+	for name, ok := range tools {
+		if !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+
+	var findings []DoctorFinding
+	for _, name := range missing {
+		severity := SeverityWarning
+		if name == "tar" {
+			severity = SeverityError
+		}
+		pkg, ok := archiveToolPackages[name]
+		if !ok {
+			pkg = name
+		}
+		findings = append(findings, DoctorFinding{
+			Severity: severity, Module: "archive",
+			Problem: fmt.Sprintf("утилита %s не найдена: архивы этого формата не извлекаются", name),
+			Fix:     installFix(pm, pkg),
+		})
+	}
+	return findings
+}
+
+// doctorSSHService проверяет, что сервер SSH установлен
+func doctorSSHService(pm *system.PackageManager) []DoctorFinding {
+	if system.Facts().InitSystem != "systemd" {
+		return nil
+	}
+	units, err := system.SSHServiceUnits()
+	if err != nil {
+		return []DoctorFinding{{Severity: SeverityWarning, Module: "ssh", Problem: err.Error()}}
+	}
+
+	if len(units) == 0 {
+		return []DoctorFinding{{
+			Severity: SeverityWarning, Module: "ssh",
+			Problem: "служба SSH не найдена: настройка SSH не применится",
+			Fix:     installFix(pm, "openssh-server"),
+		}}
+	}
+	return nil
+}
+
+// doctorWritableEtc проверяет, что конфигурацию в /etc можно изменить.
+// Без прав root запись недоступна всегда, об этом сообщает doctorPrivileges.
+func doctorWritableEtc(_ *system.PackageManager) []DoctorFinding {
+	if os.Geteuid() != 0 {
+		return nil
+	}
+	err := system.CheckWritable("/etc")
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, system.ErrReadOnlyFilesystem):
+		return []DoctorFinding{{
+			Severity: SeverityError, Module: "filesystem",
+			Problem: "/etc доступна только для чтения",
+			Fix:     system.ReadOnlyHint(),
+		}}
+	default:
+		return []DoctorFinding{{Severity: SeverityError, Module: "filesystem", Problem: err.Error()}}
+	}
+}
+
+// doctorDiskSpace проверяет место и inode на корневой файловой системе
+func doctorDiskSpace(pm *system.PackageManager) []DoctorFinding {
+	su := &system.SystemUtils{}
+	usages, err := su.GetDiskUsage("/")
+	if err != nil || len(usages) == 0 {
+		return nil
+	}
+
+	fix := "найдите крупные директории: du -xh --max-depth=2 / | sort -h | tail; сократите журнал: journalctl --vacuum-size=500M"
+	if pm != nil && pm.Clean != "" {
+		fix = pm.Clean + "; " + fix
+	}
+
+	var findings []DoctorFinding
+	used := usages[0].UsedPercent()
+	switch {
+	case used >= doctorDiskError:
+		findings = append(findings, DoctorFinding{Severity: SeverityError, Module: "disk",
+			Problem: fmt.Sprintf("корневая файловая система заполнена на %.0f%%", used), Fix: fix})
+	case used >= doctorDiskWarning:
+		findings = append(findings, DoctorFinding{Severity: SeverityWarning, Module: "disk",
+			Problem: fmt.Sprintf("корневая файловая система заполнена на %.0f%%", used), Fix: fix})
+	}
+	for _, warning := range su.InodeWarnings(usages) {
+		findings = append(findings, DoctorFinding{Severity: SeverityWarning, Module: "disk", Problem: warning,
+			Fix: "найдите директории с множеством файлов: du -x --inodes --max-depth=2 / | sort -n | tail"})
+	}
+	return findings
+}
+
+func doctorFirewall(pm *system.PackageManager) []DoctorFinding {
+	if system.Facts().FirewallBackend != "" {
+		return nil
+	}
+	return []DoctorFinding{{
+		Severity: SeverityWarning, Module: "firewall",
+		Problem: "фаервол не установлен (ufw, firewalld, nftables)",
+		Fix:     installFix(pm, "ufw"),
+	}}
+}
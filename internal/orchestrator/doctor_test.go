@@ -0,0 +1,191 @@
+package orchestrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/system"
+)
+
+// useArchiveRunner подменяет запуск команд по умолчанию, которым пользуется ExtractManager
+// без заданного Runner, и возвращает FakeRunner, в PATH которого нет утилит missing
+func useArchiveRunner(t *testing.T, missing ...string) *runner.FakeRunner {
+	t.Helper()
+	fake := runner.NewFakeRunner()
+	for _, name := range missing {
+		fake.Missing[name] = true
+	}
+	prev := runner.Default
+	runner.Default = fake
+	t.Cleanup(func() { runner.Default = prev })
+	return fake
+}
+
+// findingFor возвращает первую проблему модуля module, в описании которой есть substr
+func findingFor(findings []DoctorFinding, module, substr string) (DoctorFinding, bool) {
+	for _, f := range findings {
+		if f.Module == module && strings.Contains(f.Problem, substr) {
+			return f, true
+		}
+	}
+	return DoctorFinding{}, false
+}
+
+func TestDoctorSuggestsMissingArchiveTools(t *testing.T) {
+	useAptOnly(t)
+	useArchiveRunner(t, "unrar", "7z", "tar")
+	pm, err := system.DetectPackageManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := doctorArchiveTools(pm)
+	tests := []struct {
+		tool     string
+		severity Severity
+		fix      string
+	}{
+		{"unrar", SeverityWarning, "apt install -y unrar"},
+		{"7z", SeverityWarning, "apt install -y p7zip-full"},
+		// Без tar не извлекается большинство архивов
+		{"tar", SeverityError, "apt install -y tar"},
+	}
+	for _, tt := range tests {
+		f, ok := findingFor(findings, "archive", "утилита "+tt.tool+" ")
+		if !ok {
+			t.Errorf("нет замечания об отсутствии %s: %+v", tt.tool, findings)
+			continue
+		}
+		if f.Severity != tt.severity || f.Fix != tt.fix {
+			t.Errorf("%s: важность %s, исправление %q, ожидалось %s и %q", tt.tool, f.Severity, f.Fix, tt.severity, tt.fix)
+		}
+	}
+	if len(findings) != len(tests) {
+		t.Errorf("замечания об утилитах, которые установлены: %+v", findings)
+	}
+}
+
+func TestDoctorResolvesPackageNamesForManager(t *testing.T) {
+	useArchiveRunner(t, "7z")
+	pm := &system.PackageManager{Name: "dnf", Family: "rhel", Install: "dnf install -y"}
+
+	f, ok := findingFor(doctorArchiveTools(pm), "archive", "7z")
+	if !ok || f.Fix != "dnf install -y p7zip" {
+		t.Errorf("исправление для dnf: %+v", f)
+	}
+}
+
+func TestDoctorWithoutPackageManager(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	for _, manager := range []string{"apt", "dnf", "yum", "pacman", "zypper", "apk", "ufw", "firewall-cmd", "nft"} {
+		fake.Missing[manager] = true
+	}
+	t.Cleanup(system.SetCommandRunner(fake))
+	useArchiveRunner(t, "unrar")
+
+	report, err := Doctor()
+	if !errors.Is(err, ErrDoctorProblems) {
+		t.Fatalf("Doctor() error = %v, ожидалась ErrDoctorProblems", err)
+	}
+
+	packages, ok := findingFor(report.Findings, "packages", "менеджер пакетов не найден")
+	if !ok || packages.Severity != SeverityError || !strings.Contains(packages.Fix, "packages.manager") {
+		t.Errorf("замечание о менеджере пакетов: %+v", packages)
+	}
+	// Без менеджера пакетов предлагается общая подсказка вместо команды установки
+	if f, ok := findingFor(report.Findings, "archive", "unrar"); !ok || f.Fix != "установите пакет unrar" {
+		t.Errorf("исправление для unrar: %+v", f)
+	}
+	if f, ok := findingFor(report.Findings, "firewall", "не установлен"); !ok || f.Fix != "установите пакет ufw" {
+		t.Errorf("исправление для фаервола: %+v", f)
+	}
+	for _, command := range fake.Commands() {
+		if strings.Contains(command, "install") {
+			t.Errorf("диагностика не должна изменять систему, выполнено %q", command)
+		}
+	}
+}
+
+func TestDoctorFirewallInstallsUFW(t *testing.T) {
+	fake := useAptOnly(t)
+	for _, binary := range []string{"ufw", "firewall-cmd", "nft"} {
+		fake.Missing[binary] = true
+	}
+	pm, err := system.DetectPackageManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := doctorFirewall(pm)
+	if len(findings) != 1 || findings[0].Fix != "apt install -y ufw" {
+		t.Errorf("замечания фаервола: %+v", findings)
+	}
+
+	delete(fake.Missing, "nft")
+	system.ResetFacts()
+	if findings := doctorFirewall(pm); len(findings) != 0 {
+		t.Errorf("при установленном nftables: %+v", findings)
+	}
+}
+
+func TestDoctorSSHService(t *testing.T) {
+	if system.Facts().InitSystem != "systemd" {
+		t.Skip("проверка службы SSH выполняется только под systemd")
+	}
+	tests := []struct {
+		units string
+		fix   string
+	}{
+		// Перезапуск после настройки использует установленный юнит, замечаний нет
+		{"sshd.service enabled enabled\n", ""},
+		{"", "apt install -y openssh-server"},
+		{"ssh.service enabled enabled\n", ""},
+	}
+	for _, tt := range tests {
+		fake := useAptOnly(t)
+		fake.On("systemctl", tt.units, nil)
+		pm, err := system.DetectPackageManager()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		findings := doctorSSHService(pm)
+		if tt.fix == "" {
+			if len(findings) != 0 {
+				t.Errorf("%q: %+v, ожидалось без замечаний", tt.units, findings)
+			}
+			continue
+		}
+		if len(findings) != 1 || !strings.Contains(findings[0].Fix, tt.fix) {
+			t.Errorf("%q: %+v, ожидалось исправление %q", tt.units, findings, tt.fix)
+		}
+	}
+}
+
+func TestFormatDoctorGroupsBySeverity(t *testing.T) {
+	report := &DoctorReport{Findings: []DoctorFinding{
+		{Severity: SeverityWarning, Module: "archive", Problem: "утилита unrar не найдена", Fix: "apt install -y unrar"},
+		{Severity: SeverityInfo, Module: "privileges", Problem: "запуск без прав root"},
+		{Severity: SeverityError, Module: "packages", Problem: "менеджер пакетов не найден", Fix: "задайте packages.manager"},
+	}}
+	out := FormatDoctor(report)
+
+	errorsAt := strings.Index(out, "❌ Ошибки (1)")
+	warningsAt := strings.Index(out, "Предупреждения (1)")
+	infoAt := strings.Index(out, "Замечания (1)")
+	if errorsAt < 0 || warningsAt < errorsAt || infoAt < warningsAt {
+		t.Fatalf("группы не упорядочены по важности:\n%s", out)
+	}
+	if !strings.Contains(out, "[archive] утилита unrar не найдена\n      исправление: apt install -y unrar") {
+		t.Errorf("исправление не выведено под проблемой:\n%s", out)
+	}
+	if strings.Count(out, "исправление:") != 2 {
+		t.Errorf("исправление выведено для проблемы без Fix:\n%s", out)
+	}
+
+	if out := FormatDoctor(&DoctorReport{}); !strings.Contains(out, "Проблем окружения не найдено") {
+		t.Errorf("пустой отчет: %q", out)
+	}
+}
@@ -0,0 +1,200 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/system"
+)
+
+// ExplainPhases - фазы в порядке выполнения, для которых Explain описывает действия
+var ExplainPhases = []string{"system", "packages", "security", "clean"}
+
+// Explain возвращает по фазам список конкретных действий, которые выполнит настройка
+// по конфигурации cfg. В отличие от LintConfig ничего не запускается и не читается с хоста:
+// описание строится только по конфигурации и отражает поведение Apply и очистки.
+func Explain(cfg *config.Config) map[string][]string {
+	return map[string][]string{
+		"system":   explainSystem(cfg),
+		"packages": explainPackages(cfg),
+		"security": explainSecurity(cfg),
+		"clean":    explainClean(cfg),
+	}
+}
+
+// FormatExplanation отображает результат Explain деревом в порядке фаз
+func FormatExplanation(explanation map[string][]string) string {
+	var b strings.Builder
+	for _, phase := range ExplainPhases {
+		actions := explanation[phase]
+		b.WriteString(phase + "\n")
+		if len(actions) == 0 {
+			b.WriteString("└─ нет действий\n")
+			continue
+		}
+		for i, action := range actions {
+			branch := "├─ "
+			if i == len(actions)-1 {
+				branch = "└─ "
+			}
+			b.WriteString(branch + action + "\n")
+		}
+	}
+	return b.String()
+}
+
+func explainSystem(cfg *config.Config) []string {
+	var actions []string
+	if !config.Manages(cfg.Phases.ManageTimezone) {
+		actions = append(actions, "часовой пояс не изменяется (phases.manage_timezone = false)")
+	} else if cfg.System.Timezone != "" {
+		actions = append(actions, "установить часовой пояс "+cfg.System.Timezone)
+	}
+	if cfg.System.Locale != "" {
+		actions = append(actions, "сгенерировать и установить локаль "+cfg.System.Locale)
+	}
+	switch {
+	case !config.Manages(cfg.Phases.ManageSwap):
+		actions = append(actions, "swap не изменяется (phases.manage_swap = false)")
+	case cfg.System.SwapSize != "":
+		actions = append(actions,
+			fmt.Sprintf("создать swap-файл %s в %s, если он еще не активен", cfg.System.SwapSize, system.ManagedSwapFile),
+			fmt.Sprintf("добавить %s в /etc/fstab", system.ManagedSwapFile))
+	}
+	return actions
+}
+
+func explainPackages(cfg *config.Config) []string {
+	if !config.Manages(cfg.Phases.ManagePackages) {
+		return []string{"пакеты не устанавливаются (phases.manage_packages = false)"}
+	}
+
+	var actions []string
+	for _, category := range config.CategoryNames {
+		list, _ := cfg.Packages.Category(category)
+		exclude := cfg.Packages.Exclude[category]
+		required, optional := list.Split()
+		required, optional = without(required, exclude), without(optional, exclude)
+		if len(required) > 0 {
+			actions = append(actions, fmt.Sprintf("установить %s (%d): %s",
+				category, len(required), strings.Join(required, ", ")))
+		}
+		if len(optional) > 0 {
+			actions = append(actions, fmt.Sprintf("попытаться установить необязательные пакеты %s: %s",
+				category, strings.Join(optional, ", ")))
+		}
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+
+	if cfg.Packages.UnknownPolicy == "error" {
+		actions = append(actions, "прервать установку, если пакета нет в репозиториях")
+	} else {
+		actions = append(actions, "пропустить с предупреждением пакеты, которых нет в репозиториях")
+	}
+	if cmd := cfg.Packages.Manager.Install; cmd != "" {
+		actions = append(actions, "устанавливать командой "+cmd)
+	}
+	return actions
+}
+
+func explainSecurity(cfg *config.Config) []string {
+	sec := cfg.Security
+	var actions []string
+
+	switch {
+	case !config.Enabled(sec.EnableUFW):
+	case !config.Manages(cfg.Phases.ManageFirewall):
+		actions = append(actions, "фаервол не изменяется (phases.manage_firewall = false)")
+	default:
+		actions = append(actions,
+			"если UFW неактивен: сбросить правила, запретить входящие и разрешить исходящие соединения")
+		seen := make(map[int]bool)
+		if sec.SSHPort > 0 {
+			actions = append(actions, fmt.Sprintf("открыть порт %d/tcp (SSH)", sec.SSHPort))
+			seen[sec.SSHPort] = true
+		}
+		for _, port := range sec.OpenPorts {
+			if port <= 0 || port > 65535 || seen[port] {
+				continue
+			}
+			seen[port] = true
+			actions = append(actions, fmt.Sprintf("открыть порт %d/tcp", port))
+		}
+		for _, rule := range sec.FirewallRules {
+			action := fmt.Sprintf("%s %d/%s", rule.Action, rule.Port, rule.Protocol)
+			if rule.Comment != "" {
+				action += " (" + rule.Comment + ")"
+			}
+			actions = append(actions, "добавить правило "+action)
+		}
+		for _, ip := range sec.AllowIPs {
+			actions = append(actions, "разрешить весь трафик с "+ip)
+		}
+		actions = append(actions, "включить журналирование и UFW")
+	}
+
+	if config.Enabled(sec.EnableFail2ban) {
+		actions = append(actions, "установить fail2ban при отсутствии, записать /etc/fail2ban/jail.local и перезапустить службу")
+	}
+
+	switch {
+	case sec.SSHPort <= 0:
+	case !config.Manages(cfg.Phases.ManageSSH):
+		actions = append(actions, "SSH не изменяется (phases.manage_ssh = false)")
+	default:
+		hardening := sec.SSHHardening
+		if hardening == nil {
+			hardening = config.DefaultSSHHardening()
+		}
+		// Те же значения, что передает applySecurity
+		actions = append(actions,
+			"сохранить резервную копию /etc/ssh/sshd_config",
+			fmt.Sprintf("установить Port %d", sec.SSHPort))
+		if sec.PermitRootLogin != nil {
+			actions = append(actions, "установить PermitRootLogin "+yesNo(*sec.PermitRootLogin))
+		}
+		if sec.PasswordAuth != nil {
+			actions = append(actions, "установить PasswordAuthentication "+yesNo(*sec.PasswordAuth))
+		}
+		for _, directive := range system.SSHHardeningDirectives(hardening) {
+			actions = append(actions, "установить "+directive)
+		}
+		actions = append(actions, "перезапустить SSH")
+	}
+	return actions
+}
+
+func explainClean(cfg *config.Config) []string {
+	actions := []string{
+		"удалить все файлы из /tmp и /var/tmp",
+	}
+	if cmd := cfg.Packages.Manager.Clean; cmd != "" {
+		actions = append(actions, "очистить кеш пакетов командой "+cmd)
+	} else {
+		actions = append(actions, "удалить ненужные пакеты и очистить кеш менеджера пакетов")
+	}
+	actions = append(actions, "удалить ротированные логи в /var/log (*.gz, *.1)")
+
+	var limits []string
+	if cfg.Clean.JournalMaxAge != "" {
+		limits = append(limits, "старше "+cfg.Clean.JournalMaxAge)
+	}
+	if cfg.Clean.JournalMaxSize != "" {
+		limits = append(limits, "сверх "+cfg.Clean.JournalMaxSize)
+	}
+	if len(limits) > 0 {
+		actions = append(actions, "удалить записи журнала systemd "+strings.Join(limits, " и "))
+	}
+	return actions
+}
+
+// yesNo возвращает значение директивы sshd для флага
+func yesNo(flag bool) string {
+	if flag {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/config"
+)
+
+// explainConfig - пример конфигурации с портами и swap
+func explainConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	cfg.System.SwapSize = "2G"
+	cfg.Security.EnableUFW = config.Bool(true)
+	cfg.Security.SSHPort = 2222
+	cfg.Security.OpenPorts = []int{80, 443, 2222}
+	return cfg
+}
+
+// countContaining возвращает число действий, содержащих substr
+func countContaining(actions []string, substr string) int {
+	n := 0
+	for _, action := range actions {
+		if strings.Contains(action, substr) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestExplainMentionsPortsAndSwap(t *testing.T) {
+	fake := useHooks(t)
+	explanation := Explain(explainConfig())
+
+	system := explanation["system"]
+	if countContaining(system, "swap-файл 2G в /swapfile") != 1 {
+		t.Errorf("system не упоминает swap 2G: %q", system)
+	}
+	security := explanation["security"]
+	for _, port := range []string{"80/tcp", "443/tcp"} {
+		if countContaining(security, "открыть порт "+port) != 1 {
+			t.Errorf("security не упоминает порт %s: %q", port, security)
+		}
+	}
+	// Порт SSH из open_ports не открывается повторно
+	if n := countContaining(security, "2222/tcp"); n != 1 {
+		t.Errorf("порт 2222 упомянут %d раз: %q", n, security)
+	}
+	if countContaining(security, "Port 2222") != 1 {
+		t.Errorf("security не описывает настройку SSH: %q", security)
+	}
+	// Без permit_root_login и password_auth эти директивы не меняются
+	if countContaining(security, "PermitRootLogin") != 0 || countContaining(security, "PasswordAuthentication") != 0 {
+		t.Errorf("security меняет незаданные директивы SSH: %q", security)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("Explain выполнил команды %q", commands)
+	}
+}
+
+func TestExplainSSHAccessSettings(t *testing.T) {
+	cfg := explainConfig()
+	cfg.Security.PermitRootLogin = config.Bool(false)
+	cfg.Security.PasswordAuth = config.Bool(true)
+	security := Explain(cfg)["security"]
+	if countContaining(security, "PermitRootLogin no") != 1 || countContaining(security, "PasswordAuthentication yes") != 1 {
+		t.Errorf("security не описывает заданные директивы SSH: %q", security)
+	}
+}
+
+func TestExplainDisabledPhases(t *testing.T) {
+	cfg := explainConfig()
+	cfg.Phases.ManageSwap = config.Bool(false)
+	cfg.Phases.ManageFirewall = config.Bool(false)
+	explanation := Explain(cfg)
+
+	if system := explanation["system"]; countContaining(system, "2G") != 0 || countContaining(system, "manage_swap = false") != 1 {
+		t.Errorf("system: %q", system)
+	}
+	if security := explanation["security"]; countContaining(security, "открыть порт") != 0 || countContaining(security, "manage_firewall = false") != 1 {
+		t.Errorf("security: %q", security)
+	}
+}
+
+func TestFormatExplanation(t *testing.T) {
+	got := FormatExplanation(map[string][]string{
+		"system":   {"установить часовой пояс UTC", "создать swap-файл 1G"},
+		"security": {"открыть порт 22/tcp (SSH)"},
+	})
+	want := `system
+├─ установить часовой пояс UTC
+└─ создать swap-файл 1G
+packages
+└─ нет действий
+security
+└─ открыть порт 22/tcp (SSH)
+clean
+└─ нет действий
+`
+	if got != want {
+		t.Errorf("FormatExplanation():\n%s\nожидалось:\n%s", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/13winged/go-to-run/internal/config"
+)
+
+// Phase определяет момент полной настройки, в который вызываются хуки
+type Phase string
+
+const (
+	// PhasePreInstall - перед установкой пакетов
+	PhasePreInstall Phase = "pre-install"
+	// PhasePostInstall - после установки пакетов
+	PhasePostInstall Phase = "post-install"
+	// PhasePostSecurity - после настройки фаервола, Fail2ban и SSH
+	PhasePostSecurity Phase = "post-security"
+)
+
+// Phases возвращает фазы хуков в порядке выполнения
+func Phases() []Phase {
+	return []Phase{PhasePreInstall, PhasePostInstall, PhasePostSecurity}
+}
+
+// Hook - пользовательский шаг, выполняемый в рамках Apply
+type Hook interface {
+	Name() string
+	Run(ctx context.Context, cfg *config.Config) error
+}
+
+// OutputHook - хук, вывод которого сохраняется в отчете Apply
+type OutputHook interface {
+	Hook
+	RunWithOutput(ctx context.Context, cfg *config.Config) (string, error)
+}
+
+var (
+	hooksMu sync.Mutex
+	hooks   = make(map[Phase][]Hook)
+)
+
+// RegisterHook регистрирует хук для указанной фазы.
+// Хуки одной фазы выполняются в порядке регистрации, до скриптов из hooks.d.
+func RegisterHook(phase Phase, hook Hook) error {
+	if hook == nil {
+		return errors.New("хук не может быть nil")
+	}
+	if !validPhase(phase) {
+		return fmt.Errorf("неизвестная фаза хука: %s", phase)
+	}
+
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks[phase] = append(hooks[phase], hook)
+	return nil
+}
+
+// registeredHooks возвращает копию зарегистрированных хуков фазы
+func registeredHooks(phase Phase) []Hook {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	return append([]Hook(nil), hooks[phase]...)
+}
+
+func validPhase(phase Phase) bool {
+	for _, p := range Phases() {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// ScriptHook запускает исполняемый скрипт из hooks.d
+type ScriptHook struct {
+	Path  string
+	Phase Phase
+}
+
+// Name возвращает имя файла скрипта
+func (h *ScriptHook) Name() string {
+	return filepath.Base(h.Path)
+}
+
+// Run запускает скрипт
+func (h *ScriptHook) Run(ctx context.Context, cfg *config.Config) error {
+	_, err := h.RunWithOutput(ctx, cfg)
+	return err
+}
+
+// RunWithOutput запускает скрипт и возвращает его объединенный stdout и stderr.
+// Фаза передается скрипту в переменной окружения GO_TO_RUN_PHASE.
+func (h *ScriptHook) RunWithOutput(ctx context.Context, _ *config.Config) (string, error) {
+	cmd := exec.CommandContext(ctx, h.Path)
+	cmd.Env = append(os.Environ(), "GO_TO_RUN_PHASE="+string(h.Phase))
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return string(output), fmt.Errorf("ошибка выполнения скрипта %s: %w", h.Path, err)
+	}
+	return string(output), nil
+}
+
+// LoadScriptHooks находит исполняемые скрипты фазы в поддиректории dir/<phase>.
+// Скрипты возвращаются в лексическом порядке имен; скрытые и неисполняемые файлы пропускаются.
+func LoadScriptHooks(dir string, phase Phase) ([]Hook, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	phaseDir := filepath.Join(dir, string(phase))
+	entries, err := os.ReadDir(phaseDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения директории хуков: %w", err)
+	}
+
+	var result []Hook
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		result = append(result, &ScriptHook{Path: filepath.Join(phaseDir, entry.Name()), Phase: phase})
+	}
+	return result, nil
+}
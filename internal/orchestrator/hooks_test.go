@@ -0,0 +1,277 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/system"
+)
+
+// quietConfig возвращает конфигурацию, при которой Apply не меняет систему:
+// все фазы отключены, и выполняются только хуки
+func quietConfig() *config.Config {
+	cfg := config.DefaultConfig()
+	off := config.Bool(false)
+	cfg.Phases = config.PhasesConfig{
+		ManageFirewall: off, ManageSSH: off, ManageSwap: off, ManagePackages: off, ManageTimezone: off,
+	}
+	cfg.System.Locale = ""
+	cfg.Security.EnableUFW = off
+	cfg.Security.EnableFail2ban = off
+	return cfg
+}
+
+// useHooks очищает реестр хуков на время теста и подменяет запуск команд
+func useHooks(t *testing.T) *runner.FakeRunner {
+	t.Helper()
+	hooksMu.Lock()
+	prev := hooks
+	hooks = make(map[Phase][]Hook)
+	hooksMu.Unlock()
+	t.Cleanup(func() {
+		hooksMu.Lock()
+		hooks = prev
+		hooksMu.Unlock()
+	})
+
+	fake := runner.NewFakeRunner()
+	t.Cleanup(system.SetCommandRunner(fake))
+	return fake
+}
+
+// fakeHook записывает свои вызовы в общий журнал
+type fakeHook struct {
+	name string
+	err  error
+	mu   *sync.Mutex
+	log  *[]string
+}
+
+func (h *fakeHook) Name() string { return h.name }
+
+func (h *fakeHook) Run(_ context.Context, cfg *config.Config) error {
+	if cfg == nil {
+		return errors.New("хук получил nil вместо конфигурации")
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	*h.log = append(*h.log, h.name)
+	return h.err
+}
+
+func TestApplyRunsHooksAtTheirPhase(t *testing.T) {
+	fake := useHooks(t)
+	var mu sync.Mutex
+	var log []string
+	for _, phase := range []Phase{PhasePostSecurity, PhasePreInstall, PhasePostInstall} {
+		if err := RegisterHook(phase, &fakeHook{name: string(phase), mu: &mu, log: &log}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := Apply(context.Background(), quietConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"pre-install", "post-install", "post-security"}; !reflect.DeepEqual(log, want) {
+		t.Errorf("порядок хуков %q, ожидалось %q", log, want)
+	}
+	var phases []Phase
+	for _, hook := range report.Hooks {
+		if hook.Name != string(hook.Phase) || hook.Err != nil {
+			t.Errorf("результат хука %+v", hook)
+		}
+		phases = append(phases, hook.Phase)
+	}
+	if !reflect.DeepEqual(phases, Phases()) {
+		t.Errorf("фазы в отчете %q", phases)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("при отключенных фазах выполнены команды %q", commands)
+	}
+}
+
+func TestApplySurfacesHookError(t *testing.T) {
+	useHooks(t)
+	var mu sync.Mutex
+	var log []string
+	hookErr := errors.New("приложение не установлено")
+	_ = RegisterHook(PhasePreInstall, &fakeHook{name: "install-app", err: hookErr, mu: &mu, log: &log})
+	_ = RegisterHook(PhasePostInstall, &fakeHook{name: "never", mu: &mu, log: &log})
+
+	report, err := Apply(context.Background(), quietConfig())
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("ошибка хука не возвращена: %v", err)
+	}
+	if !strings.Contains(err.Error(), "install-app") || !strings.Contains(err.Error(), string(PhasePreInstall)) {
+		t.Errorf("ошибка не называет хук и фазу: %v", err)
+	}
+	if !reflect.DeepEqual(log, []string{"install-app"}) {
+		t.Errorf("после ошибки выполнены хуки %q", log)
+	}
+	// Хук pre-install выполняется после шага system и до установки пакетов
+	var steps []string
+	for _, step := range report.Steps {
+		steps = append(steps, step.Name)
+	}
+	if !reflect.DeepEqual(steps, []string{"system"}) {
+		t.Errorf("выполнены шаги %q, ожидался только system", steps)
+	}
+	if n := len(report.Hooks); n != 1 || !errors.Is(report.Hooks[0].Err, hookErr) {
+		t.Errorf("отчет о хуках %+v", report.Hooks)
+	}
+}
+
+func TestApplyRunsScriptHooksInOrder(t *testing.T) {
+	useHooks(t)
+	dir := t.TempDir()
+	phaseDir := filepath.Join(dir, string(PhasePostInstall))
+	scripts := map[string]string{
+		"20-second.sh": "#!/bin/sh\necho second $GO_TO_RUN_PHASE\n",
+		"10-first.sh":  "#!/bin/sh\necho first\n",
+		".hidden.sh":   "#!/bin/sh\necho hidden\n",
+	}
+	if err := os.MkdirAll(phaseDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range scripts {
+		if err := os.WriteFile(filepath.Join(phaseDir, name), []byte(content), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(phaseDir, "30-not-executable.sh"), []byte("#!/bin/sh\necho no\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := quietConfig()
+	cfg.Hooks.Dir = dir
+	report, err := Apply(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, hook := range report.Hooks {
+		got = append(got, hook.Name+": "+strings.TrimSpace(hook.Output))
+	}
+	if want := []string{"10-first.sh: first", "20-second.sh: second post-install"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("хуки %q, ожидалось %q", got, want)
+	}
+}
+
+func TestApplyScriptHookFailure(t *testing.T) {
+	useHooks(t)
+	dir := t.TempDir()
+	phaseDir := filepath.Join(dir, string(PhasePostSecurity))
+	if err := os.MkdirAll(phaseDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(phaseDir, "fail.sh"), []byte("#!/bin/sh\necho broken >&2\nexit 3\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := quietConfig()
+	cfg.Hooks.Dir = dir
+	report, err := Apply(context.Background(), cfg)
+	if err == nil || !strings.Contains(err.Error(), "fail.sh") {
+		t.Fatalf("ошибка скрипта не возвращена: %v", err)
+	}
+	if n := len(report.Hooks); n != 1 || !strings.Contains(report.Hooks[0].Output, "broken") {
+		t.Errorf("вывод скрипта не сохранен в отчете: %+v", report.Hooks)
+	}
+}
+
+func TestRegisterHookValidation(t *testing.T) {
+	useHooks(t)
+	if err := RegisterHook(PhasePreInstall, nil); err == nil {
+		t.Error("nil-хук: ожидалась ошибка")
+	}
+	if err := RegisterHook("post-reboot", &fakeHook{name: "x"}); err == nil {
+		t.Error("неизвестная фаза: ожидалась ошибка")
+	}
+}
+
+func TestApplySkipsDisabledPhases(t *testing.T) {
+	fake := useHooks(t)
+	cfg := quietConfig()
+	// Часовой пояс по умолчанию управляется, swap отключен явно
+	cfg.Phases.ManageTimezone = nil
+	cfg.System.Timezone = "Europe/Moscow"
+	cfg.System.SwapSize = "2G"
+
+	report, err := Apply(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"swap", "packages", "firewall", "ssh"}; !reflect.DeepEqual(report.Skipped, want) {
+		t.Errorf("пропущенные фазы %q, ожидалось %q", report.Skipped, want)
+	}
+	// Отключенная фаза swap не запускает команд
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, []string{"timedatectl set-timezone Europe/Moscow"}) {
+		t.Errorf("выполнены команды %q, ожидалась только настройка часового пояса", commands)
+	}
+}
+
+func TestSkippedPhasesDefaults(t *testing.T) {
+	if skipped := skippedPhases(config.DefaultConfig()); len(skipped) != 0 {
+		t.Errorf("по умолчанию управляются все фазы, пропущены %q", skipped)
+	}
+	want := []string{"timezone", "swap", "packages", "firewall", "ssh"}
+	if skipped := skippedPhases(quietConfig()); !reflect.DeepEqual(skipped, want) {
+		t.Errorf("пропущены %q, ожидалось %q", skipped, want)
+	}
+}
+
+func TestCheckMaintenanceWindow(t *testing.T) {
+	cfg := quietConfig()
+	cfg.Maintenance = &config.MaintenanceWindow{Start: "02:00", End: "04:00", Timezone: "UTC"}
+	noon := ApplyOptions{Now: func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) }}
+
+	// Без рискованных фаз окно не проверяется
+	if err := checkMaintenanceWindow(cfg, noon); err != nil {
+		t.Errorf("отключенные фазы: %v", err)
+	}
+
+	cfg.Phases.ManageFirewall = nil
+	cfg.Security.EnableUFW = config.Bool(true)
+	err := checkMaintenanceWindow(cfg, noon)
+	if !errors.Is(err, config.ErrOutsideMaintenanceWindow) || !strings.Contains(err.Error(), "firewall") {
+		t.Errorf("вне окна: %v", err)
+	}
+
+	night := ApplyOptions{Now: func() time.Time { return time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC) }}
+	if err := checkMaintenanceWindow(cfg, night); err != nil {
+		t.Errorf("в окне: %v", err)
+	}
+	noon.Force = true
+	if err := checkMaintenanceWindow(cfg, noon); err != nil {
+		t.Errorf("с Force: %v", err)
+	}
+}
+
+func TestApplyRefusesOutsideMaintenanceWindow(t *testing.T) {
+	fake := useHooks(t)
+	cfg := quietConfig()
+	cfg.Phases.ManageTimezone = nil
+	cfg.Phases.ManageSSH = nil
+	cfg.Maintenance = &config.MaintenanceWindow{Start: "02:00", End: "04:00", Timezone: "UTC"}
+
+	_, err := ApplyWithOptions(context.Background(), cfg, ApplyOptions{
+		Now: func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC) },
+	})
+	if !errors.Is(err, config.ErrOutsideMaintenanceWindow) {
+		t.Fatalf("ожидалась ErrOutsideMaintenanceWindow, получено %v", err)
+	}
+	// Настройка не начинается: даже безопасные шаги не выполнены
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("выполнены команды %q", commands)
+	}
+}
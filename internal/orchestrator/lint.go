@@ -0,0 +1,235 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/system"
+)
+
+// Severity - важность замечания проверки конфигурации
+type Severity string
+
+// Уровни важности замечаний
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// LintFinding - замечание проверки конфигурации
+type LintFinding struct {
+	Severity Severity
+	// Check - название проверки: config, packages, timezone, locale, firewall, ssh, swap
+	Check   string
+	Message string
+}
+
+// swapRoot - файловая система, на которой создается swap файл
+const swapRoot = "/"
+
+// LintConfig проверяет, применится ли конфигурация на этом хосте, ничего не изменяя:
+// корректность конфигурации, наличие пакетов в репозиториях, повторы пакетов
+// в категориях, часовой пояс и локаль, конфликты правил фаервола, риск потерять SSH-сессию
+// и место под swap.
+func LintConfig(cfg *config.Config) []LintFinding {
+	if err := config.ValidateConfig(cfg); err != nil {
+		// Остальные проверки на некорректной конфигурации дают ложные замечания
+		return []LintFinding{{Severity: SeverityError, Check: "config", Message: err.Error()}}
+	}
+
+	var findings []LintFinding
+	for _, check := range []func(*config.Config) []LintFinding{
+		lintPackages, lintDuplicatePackages, lintTimezone, lintLocale, lintFirewall, lintSSH, lintSwap,
+	} {
+		findings = append(findings, check(cfg)...)
+	}
+	return findings
+}
+
+// lintPackages проверяет, что пакеты есть в репозиториях обнаруженного менеджера
+func lintPackages(cfg *config.Config) []LintFinding {
+	if !config.Manages(cfg.Phases.ManagePackages) {
+		return nil
+	}
+	pm, err := system.DetectPackageManager()
+	if err != nil {
+		return []LintFinding{{Severity: SeverityError, Check: "packages", Message: err.Error()}}
+	}
+
+	var all []string
+	for _, category := range config.CategoryNames {
+		list, _ := cfg.Packages.Category(category)
+		all = append(all, without(list.Names(), cfg.Packages.Exclude[category])...)
+	}
+	if len(all) == 0 {
+		return nil
+	}
+
+	_, unknown, err := system.ResolveAndValidate(pm, all)
+	if err != nil {
+		return []LintFinding{{Severity: SeverityWarning, Check: "packages",
+			Message: fmt.Sprintf("не удалось проверить пакеты: %v", err)}}
+	}
+	severity := SeverityWarning
+	if cfg.Packages.UnknownPolicy == "error" {
+		severity = SeverityError
+	}
+	findings := make([]LintFinding, 0, len(unknown))
+	for _, pkg := range unknown {
+		findings = append(findings, LintFinding{Severity: severity, Check: "packages",
+			Message: fmt.Sprintf("пакет %s не найден в репозиториях %s", pkg, pm.Name)})
+	}
+	return findings
+}
+
+// lintDuplicatePackages сообщает о пакетах, перечисленных сразу в нескольких категориях
+func lintDuplicatePackages(cfg *config.Config) []LintFinding {
+	var findings []LintFinding
+	seen := make(map[string]bool)
+	for _, category := range config.CategoryNames {
+		list, _ := cfg.Packages.Category(category)
+		for _, name := range list.Names() {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if categories := cfg.Packages.CategoriesForPackage(name); len(categories) > 1 {
+				findings = append(findings, LintFinding{Severity: SeverityWarning, Check: "packages",
+					Message: fmt.Sprintf("пакет %s указан в нескольких категориях: %s", name, strings.Join(categories, ", "))})
+			}
+		}
+	}
+	return findings
+}
+
+// lintTimezone проверяет, что часовой пояс есть в базе часовых поясов хоста
+func lintTimezone(cfg *config.Config) []LintFinding {
+	tz := cfg.System.Timezone
+	if tz == "" || !config.Manages(cfg.Phases.ManageTimezone) {
+		return nil
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return []LintFinding{{Severity: SeverityError, Check: "timezone",
+			Message: fmt.Sprintf("часовой пояс %s не найден на хосте", tz)}}
+	}
+	return nil
+}
+
+// lintLocale проверяет, что локаль сгенерирована на хосте (locale -a)
+func lintLocale(cfg *config.Config) []LintFinding {
+	locale := cfg.System.Locale
+	if locale == "" {
+		return nil
+	}
+	locales, err := system.AvailableLocales()
+	if err != nil {
+		return []LintFinding{{Severity: SeverityInfo, Check: "locale",
+			Message: "не удалось получить список локалей, проверка пропущена"}}
+	}
+	want := normalizeLocale(locale)
+	for _, available := range locales {
+		if normalizeLocale(available) == want {
+			return nil
+		}
+	}
+	return []LintFinding{{Severity: SeverityWarning, Check: "locale",
+		Message: fmt.Sprintf("локаль %s не сгенерирована на хосте", locale)}}
+}
+
+// normalizeLocale приводит имя локали к виду locale -a: en_US.UTF-8 и en_US.utf8 совпадают
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(locale), "-", "")
+}
+
+// lintFirewall ищет порты, которые одновременно разрешены и запрещены, и затененные правила
+func lintFirewall(cfg *config.Config) []LintFinding {
+	sec := cfg.Security
+	if !config.Enabled(sec.EnableUFW) || !config.Manages(cfg.Phases.ManageFirewall) {
+		return nil
+	}
+
+	// Порт SSH и open_ports открываются по tcp до пользовательских правил
+	allowed := map[string]string{}
+	if sec.SSHPort > 0 {
+		allowed[fmt.Sprintf("%d/tcp", sec.SSHPort)] = "ssh_port"
+	}
+	for _, port := range sec.OpenPorts {
+		allowed[fmt.Sprintf("%d/tcp", port)] = "open_ports"
+	}
+	for _, rule := range sec.FirewallRules {
+		key := fmt.Sprintf("%d/%s", rule.Port, rule.Protocol)
+		if _, ok := allowed[key]; !ok && rule.Action == "allow" {
+			allowed[key] = "firewall_rules"
+		}
+	}
+
+	var findings []LintFinding
+	for _, rule := range sec.FirewallRules {
+		key := fmt.Sprintf("%d/%s", rule.Port, rule.Protocol)
+		source, ok := allowed[key]
+		if rule.Action != "deny" || !ok {
+			continue
+		}
+		severity := SeverityWarning
+		if source == "ssh_port" {
+			severity = SeverityError
+		}
+		findings = append(findings, LintFinding{Severity: severity, Check: "firewall",
+			Message: fmt.Sprintf("порт %s запрещен правилом и разрешен в %s", key, source)})
+	}
+
+	// ufw применяет первое совпавшее правило: следующие правила для того же порта не сработают
+	for _, shadow := range config.ShadowedFirewallRules(sec.FirewallRules) {
+		findings = append(findings, LintFinding{Severity: SeverityWarning, Check: "firewall", Message: shadow.String()})
+	}
+	return findings
+}
+
+// lintSSH проверяет, не потеряет ли доступ текущая SSH-сессия
+func lintSSH(cfg *config.Config) []LintFinding {
+	// SSH_CONNECTION: "client_ip client_port server_ip server_port"
+	fields := strings.Fields(os.Getenv("SSH_CONNECTION"))
+	if len(fields) != 4 || cfg.Security.SSHPort == 0 || !config.Manages(cfg.Phases.ManageSSH) {
+		return nil
+	}
+	currentPort, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil
+	}
+
+	if currentPort == cfg.Security.SSHPort {
+		return nil
+	}
+	return []LintFinding{{Severity: SeverityWarning, Check: "ssh",
+		Message: fmt.Sprintf("текущая сессия подключена к порту %d, SSH будет перенесен на %d: "+
+			"проверьте доступ к новому порту до закрытия сессии", currentPort, cfg.Security.SSHPort)}}
+}
+
+// lintSwap проверяет, что swap файл помещается на диск
+func lintSwap(cfg *config.Config) []LintFinding {
+	size := cfg.System.SwapSize
+	if size == "" || !config.Manages(cfg.Phases.ManageSwap) {
+		return nil
+	}
+	need, err := system.ParseSwapSize(size)
+	if err != nil {
+		return []LintFinding{{Severity: SeverityError, Check: "swap", Message: err.Error()}}
+	}
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(swapRoot, &st); err != nil {
+		return nil
+	}
+	free := st.Bavail * uint64(st.Bsize)
+	if need > free {
+		return []LintFinding{{Severity: SeverityError, Check: "swap",
+			Message: fmt.Sprintf("swap %s не помещается на %s: свободно %dM", size, swapRoot, free>>20)}}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package orchestrator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/system"
+)
+
+// findingsOf возвращает замечания проверки check
+func findingsOf(findings []LintFinding, check string) []LintFinding {
+	var result []LintFinding
+	for _, f := range findings {
+		if f.Check == check {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// useAptOnly подменяет запуск команд: из менеджеров пакетов в PATH есть только apt
+func useAptOnly(t *testing.T) *runner.FakeRunner {
+	t.Helper()
+	fake := runner.NewFakeRunner()
+	for _, manager := range []string{"dnf", "yum", "pacman", "zypper", "apk"} {
+		fake.Missing[manager] = true
+	}
+	t.Cleanup(system.SetCommandRunner(fake))
+	return fake
+}
+
+func TestLintConfigNonexistentPackage(t *testing.T) {
+	fake := useAptOnly(t)
+	fake.On("env", "curl:\n  Installed: (none)\n  Candidate: 8.5.0-2ubuntu10\n", nil)
+
+	cfg := quietConfig()
+	cfg.Phases.ManagePackages = config.Bool(true)
+	cfg.Packages = config.PackagesConfig{Basic: config.NewPackageList("curl", "no-such-package")}
+
+	findings := findingsOf(LintConfig(cfg), "packages")
+	if len(findings) != 1 {
+		t.Fatalf("замечания о пакетах: %+v, ожидалось одно", findings)
+	}
+	if f := findings[0]; f.Severity != SeverityWarning || !strings.Contains(f.Message, "no-such-package") {
+		t.Errorf("замечание %+v, ожидалось предупреждение о no-such-package", f)
+	}
+
+	cfg.Packages.UnknownPolicy = "error"
+	if findings := findingsOf(LintConfig(cfg), "packages"); len(findings) != 1 || findings[0].Severity != SeverityError {
+		t.Errorf("при unknown_policy=error получено %+v", findings)
+	}
+}
+
+func TestLintConfigInvalidTimezone(t *testing.T) {
+	useAptOnly(t)
+	cfg := quietConfig()
+	cfg.Phases.ManageTimezone = config.Bool(true)
+	cfg.System.Timezone = "Mars/Olympus_Mons"
+
+	findings := LintConfig(cfg)
+	if len(findings) == 0 {
+		t.Fatal("для несуществующего часового пояса нет замечаний")
+	}
+	f := findings[0]
+	if f.Severity != SeverityError || !strings.Contains(f.Message, "Mars/Olympus_Mons") {
+		t.Errorf("замечание %+v, ожидалась ошибка с названием часового пояса", f)
+	}
+}
+
+func TestLintConfigCleanConfig(t *testing.T) {
+	useAptOnly(t)
+	cfg := quietConfig()
+	cfg.Phases.ManageTimezone = config.Bool(true)
+	cfg.System.Timezone = "UTC"
+
+	if findings := LintConfig(cfg); len(findings) != 0 {
+		t.Errorf("для корректной конфигурации получены замечания: %+v", findings)
+	}
+}
+
+func TestLintFirewallConflicts(t *testing.T) {
+	cfg := quietConfig()
+	cfg.Phases.ManageFirewall = config.Bool(true)
+	cfg.Security.EnableUFW = config.Bool(true)
+	cfg.Security.SSHPort = 22
+	cfg.Security.OpenPorts = []int{80}
+	cfg.Security.FirewallRules = []config.FirewallRule{
+		{Port: 22, Protocol: "tcp", Action: "deny"},
+		{Port: 80, Protocol: "tcp", Action: "deny"},
+		{Port: 53, Protocol: "udp", Action: "deny"},
+	}
+
+	findings := findingsOf(lintFirewall(cfg), "firewall")
+	severities := map[string]Severity{}
+	for _, f := range findings {
+		for _, port := range []string{"22/tcp", "80/tcp", "53/udp"} {
+			if strings.Contains(f.Message, "порт "+port) {
+				severities[port] = f.Severity
+			}
+		}
+	}
+	if severities["22/tcp"] != SeverityError || severities["80/tcp"] != SeverityWarning {
+		t.Errorf("замечания фаервола: %+v", findings)
+	}
+	if _, ok := severities["53/udp"]; ok {
+		t.Errorf("запрет неразрешенного порта считается конфликтом: %+v", findings)
+	}
+}
+
+func TestLintShadowedFirewallRules(t *testing.T) {
+	cfg := quietConfig()
+	cfg.Phases.ManageFirewall = config.Bool(true)
+	cfg.Security.EnableUFW = config.Bool(true)
+	cfg.Security.FirewallRules = []config.FirewallRule{
+		{Port: 8080, Protocol: "tcp", Action: "allow"},
+		{Port: 8080, Protocol: "tcp", Action: "allow"},
+		{Port: 9000, Protocol: "udp", Action: "allow"},
+	}
+
+	var shadowed []LintFinding
+	for _, f := range findingsOf(lintFirewall(cfg), "firewall") {
+		if strings.Contains(f.Message, "повторяет") {
+			shadowed = append(shadowed, f)
+		}
+	}
+	if len(shadowed) != 1 || shadowed[0].Severity != SeverityWarning ||
+		!strings.Contains(shadowed[0].Message, "firewall_rules[1]") {
+		t.Errorf("затененные правила: %+v", shadowed)
+	}
+}
+
+func TestLintSSHPortChange(t *testing.T) {
+	cfg := quietConfig()
+	cfg.Phases.ManageSSH = config.Bool(true)
+	cfg.Security.SSHPort = 2222
+
+	t.Setenv("SSH_CONNECTION", "10.0.0.5 51234 10.0.0.1 22")
+	if findings := lintSSH(cfg); len(findings) != 1 || !strings.Contains(findings[0].Message, "2222") {
+		t.Errorf("смена порта SSH в текущей сессии: %+v", findings)
+	}
+	t.Setenv("SSH_CONNECTION", "10.0.0.5 51234 10.0.0.1 2222")
+	if findings := lintSSH(cfg); len(findings) != 0 {
+		t.Errorf("порт не меняется, но получены замечания: %+v", findings)
+	}
+}
+
+func TestLintDuplicatePackages(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Packages = config.PackagesConfig{
+		Basic:      config.PackageList{{Name: "nginx"}, {Name: "curl"}},
+		Web:        config.PackageList{{Name: "nginx"}},
+		Monitoring: config.PackageList{{Name: "htop"}},
+	}
+
+	findings := lintDuplicatePackages(cfg)
+	if len(findings) != 1 {
+		t.Fatalf("находки %+v, ожидалась одна", findings)
+	}
+	if f := findings[0]; f.Severity != SeverityWarning || !strings.Contains(f.Message, "nginx") || !strings.Contains(f.Message, "basic, web") {
+		t.Errorf("находка %+v", f)
+	}
+}
@@ -0,0 +1,156 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/13winged/go-to-run/internal/system"
+	"github.com/13winged/go-to-run/pkg/archive"
+)
+
+// ErrSelfTestFailed возвращается, если хотя бы одна проверка самотестирования не прошла
+var ErrSelfTestFailed = errors.New("самотестирование выявило проблемы")
+
+// SelfTestCheck - результат одной проверки самотестирования
+type SelfTestCheck struct {
+	// Module - проверяемая часть утилиты: packages, firewall, archive, distro, system
+	Module string
+	Passed bool
+	// Detail - найденное значение при успехе или причина ошибки
+	Detail string
+}
+
+// SelfTestReport содержит результаты всех проверок самотестирования
+type SelfTestReport struct {
+	Checks []SelfTestCheck
+}
+
+// Failed возвращает непройденные проверки
+func (r *SelfTestReport) Failed() []SelfTestCheck {
+	var failed []SelfTestCheck
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// selfTestProbe - проверка одного модуля: описание найденного или ошибка
+type selfTestProbe struct {
+	module string
+	probe  func() (string, error)
+}
+
+// selfTestProbes перечисляет проверки в порядке вывода; все проверки только читают состояние
+var selfTestProbes = []selfTestProbe{
+	{"packages", probePackageManager},
+	{"firewall", probeFirewall},
+	{"archive", probeArchiveTools},
+	{"distro", probeOSRelease},
+	{"system", probeSystemInfo},
+}
+
+// SelfTest проверяет, сможет ли утилита работать на этом хосте: определяет менеджер пакетов,
+// фаервол и дистрибутив, ищет утилиты архивов и читает сведения о системе. Ничего не изменяет.
+// При непройденных проверках вместе с полным отчетом возвращается ErrSelfTestFailed.
+func SelfTest() (*SelfTestReport, error) {
+	report := &SelfTestReport{}
+	for _, p := range selfTestProbes {
+		detail, err := p.probe()
+		check := SelfTestCheck{Module: p.module, Passed: err == nil, Detail: detail}
+		if err != nil {
+			check.Detail = err.Error()
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return report, fmt.Errorf("%w: %d из %d проверок", ErrSelfTestFailed, len(failed), len(report.Checks))
+	}
+	return report, nil
+}
+
+// FormatSelfTest форматирует отчет самотестирования для вывода
+func FormatSelfTest(report *SelfTestReport) string {
+	var b strings.Builder
+	for _, check := range report.Checks {
+		mark := "✅"
+		if !check.Passed {
+			mark = "❌"
+		}
+		fmt.Fprintf(&b, "%s %-10s %s\n", mark, check.Module, check.Detail)
+	}
+	return b.String()
+}
+
+func probePackageManager() (string, error) {
+	pm, err := (&system.PackageManagerDetector{}).Detect()
+	if err != nil {
+		return "", err
+	}
+	if pm.Family != "" {
+		return fmt.Sprintf("%s (семейство %s)", pm.Name, pm.Family), nil
+	}
+	return pm.Name, nil
+}
+
+func probeFirewall() (string, error) {
+	info, err := (&system.SecurityManager{}).FirewallStatus()
+	if err != nil {
+		return "", err
+	}
+	state := "неактивен"
+	if info.Active {
+		state = "активен"
+	}
+	return fmt.Sprintf("%s, %s", info.Backend, state), nil
+}
+
+// probeArchiveTools проверяет наличие tar: без него доступны только встроенные форматы
+func probeArchiveTools() (string, error) {
+	tools := (&archive.ExtractManager{}).CheckTools()
+	var available, missing []string
+	for name, ok := range tools {
+		if ok {
+			available = append(available, name)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(available)
+	sort.Strings(missing)
+
+	detail := "доступны: " + strings.Join(available, ", ")
+	if len(missing) > 0 {
+		detail += "; нет: " + strings.Join(missing, ", ")
+	}
+	if !tools["tar"] {
+		return "", fmt.Errorf("tar не найден; %s", detail)
+	}
+	return detail, nil
+}
+
+func probeOSRelease() (string, error) {
+	release, err := system.ParseOSRelease()
+	if err != nil {
+		return "", err
+	}
+	if release.PrettyName != "" {
+		return release.PrettyName, nil
+	}
+	return strings.TrimSpace(release.Name + " " + release.VersionID), nil
+}
+
+func probeSystemInfo() (string, error) {
+	info, err := (&system.SystemUtils{}).GetSystemInfo()
+	if err != nil {
+		return "", err
+	}
+	if info.Kernel == "" {
+		return "", errors.New("не удалось получить версию ядра")
+	}
+	return "ядро " + info.Kernel, nil
+}
@@ -0,0 +1,112 @@
+package orchestrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/system"
+)
+
+// useSelfTestProbes подменяет проверки самотестирования на время теста
+func useSelfTestProbes(t *testing.T, probes []selfTestProbe) {
+	t.Helper()
+	prev := selfTestProbes
+	selfTestProbes = probes
+	t.Cleanup(func() { selfTestProbes = prev })
+}
+
+func TestSelfTestReportsEveryModule(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	for _, manager := range []string{"apt", "dnf", "yum", "pacman", "zypper", "apk"} {
+		fake.Missing[manager] = true
+	}
+	t.Cleanup(system.SetCommandRunner(fake))
+
+	report, err := SelfTest()
+	if !errors.Is(err, ErrSelfTestFailed) {
+		t.Fatalf("SelfTest() error = %v, ожидается ErrSelfTestFailed", err)
+	}
+	if report == nil {
+		t.Fatal("при непройденных проверках отчет должен возвращаться")
+	}
+
+	checks := make(map[string]SelfTestCheck)
+	for _, check := range report.Checks {
+		checks[check.Module] = check
+	}
+	for _, module := range []string{"packages", "firewall", "archive", "distro", "system"} {
+		if _, ok := checks[module]; !ok {
+			t.Errorf("в отчете нет проверки модуля %s: %+v", module, report.Checks)
+		}
+	}
+
+	packages := checks["packages"]
+	if packages.Passed {
+		t.Error("проверка packages должна провалиться без менеджера пакетов")
+	}
+	if !strings.Contains(packages.Detail, system.ErrNoPackageManager.Error()) {
+		t.Errorf("Detail = %q, ожидается причина %q", packages.Detail, system.ErrNoPackageManager)
+	}
+
+	failed := report.Failed()
+	if len(failed) == 0 || failed[0].Module != "packages" {
+		t.Errorf("Failed() = %+v, ожидается проверка packages", failed)
+	}
+	for _, call := range fake.Commands() {
+		if strings.Contains(call, "install") || strings.Contains(call, "enable") {
+			t.Errorf("самотестирование не должно изменять систему, выполнено %q", call)
+		}
+	}
+}
+
+func TestSelfTestMarksFailures(t *testing.T) {
+	useSelfTestProbes(t, []selfTestProbe{
+		{"packages", func() (string, error) { return "apt (семейство debian)", nil }},
+		{"firewall", func() (string, error) { return "", errors.New("фаервол не найден") }},
+		{"distro", func() (string, error) { return "Ubuntu 24.04 LTS", nil }},
+	})
+
+	report, err := SelfTest()
+	if !errors.Is(err, ErrSelfTestFailed) {
+		t.Fatalf("SelfTest() error = %v, ожидается ErrSelfTestFailed", err)
+	}
+	if !strings.Contains(err.Error(), "1 из 3") {
+		t.Errorf("error = %q, ожидается число непройденных проверок", err)
+	}
+
+	want := []SelfTestCheck{
+		{Module: "packages", Passed: true, Detail: "apt (семейство debian)"},
+		{Module: "firewall", Passed: false, Detail: "фаервол не найден"},
+		{Module: "distro", Passed: true, Detail: "Ubuntu 24.04 LTS"},
+	}
+	if len(report.Checks) != len(want) {
+		t.Fatalf("Checks = %+v, ожидается %+v", report.Checks, want)
+	}
+	for i := range want {
+		if report.Checks[i] != want[i] {
+			t.Errorf("Checks[%d] = %+v, ожидается %+v", i, report.Checks[i], want[i])
+		}
+	}
+
+	out := FormatSelfTest(report)
+	if !strings.Contains(out, "❌ firewall") || !strings.Contains(out, "✅ packages") {
+		t.Errorf("FormatSelfTest() = %q", out)
+	}
+}
+
+func TestSelfTestAllPassed(t *testing.T) {
+	useSelfTestProbes(t, []selfTestProbe{
+		{"packages", func() (string, error) { return "apk", nil }},
+		{"system", func() (string, error) { return "ядро 6.8.0", nil }},
+	})
+
+	report, err := SelfTest()
+	if err != nil {
+		t.Fatalf("SelfTest() error = %v", err)
+	}
+	if failed := report.Failed(); len(failed) != 0 {
+		t.Errorf("Failed() = %+v, ожидается пусто", failed)
+	}
+}
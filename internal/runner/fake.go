@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Call - записанный вызов FakeRunner
+type Call struct {
+	Name string
+	Args []string
+	// Stdin - ввод, переданный команде через RunIO
+	Stdin string
+}
+
+// String возвращает вызов в виде командной строки
+func (c Call) String() string {
+	return strings.Join(append([]string{c.Name}, c.Args...), " ")
+}
+
+// FakeResponse - заранее заданный результат команды
+type FakeResponse struct {
+	Output []byte
+	Err    error
+}
+
+// FakeRunner записывает вызовы и возвращает заданные результаты вместо запуска процессов.
+// Ответы ищутся по полной командной строке ("ufw status"), затем по имени команды;
+// для остальных команд возвращается пустой вывод без ошибки.
+type FakeRunner struct {
+	mu sync.Mutex
+	// Responses задает результаты по командной строке или имени команды.
+	// Несколько ответов на один ключ возвращаются по очереди, последний повторяется
+	Responses map[string][]FakeResponse
+	// Missing - команды, которых "нет" в PATH
+	Missing map[string]bool
+	// Calls содержит вызовы в порядке выполнения
+	Calls []Call
+}
+
+// NewFakeRunner создает FakeRunner без заданных ответов
+func NewFakeRunner() *FakeRunner {
+	return &FakeRunner{
+		Responses: make(map[string][]FakeResponse),
+		Missing:   make(map[string]bool),
+	}
+}
+
+// On добавляет ответ на команду key (командная строка или имя команды)
+func (f *FakeRunner) On(key string, output string, err error) *FakeRunner {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Responses == nil {
+		f.Responses = make(map[string][]FakeResponse)
+	}
+	f.Responses[key] = append(f.Responses[key], FakeResponse{Output: []byte(output), Err: err})
+	return f
+}
+
+// Commands возвращает записанные вызовы в виде командных строк
+func (f *FakeRunner) Commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	commands := make([]string, len(f.Calls))
+	for i, call := range f.Calls {
+		commands[i] = call.String()
+	}
+	return commands
+}
+
+func (f *FakeRunner) call(name string, args []string) ([]byte, error) {
+	return f.respond(Call{Name: name, Args: append([]string(nil), args...)})
+}
+
+// respond записывает вызов и возвращает ответ на него
+func (f *FakeRunner) respond(call Call) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Calls = append(f.Calls, call)
+	name := call.Name
+
+	for _, key := range []string{call.String(), name} {
+		responses := f.Responses[key]
+		if len(responses) == 0 {
+			continue
+		}
+		response := responses[0]
+		if len(responses) > 1 {
+			f.Responses[key] = responses[1:]
+		}
+		return response.Output, response.Err
+	}
+	return nil, nil
+}
+
+// Run записывает вызов и возвращает заданную ошибку
+func (f *FakeRunner) Run(name string, args ...string) error {
+	_, err := f.call(name, args)
+	return err
+}
+
+// Output записывает вызов и возвращает заданный вывод
+func (f *FakeRunner) Output(name string, args ...string) ([]byte, error) {
+	return f.call(name, args)
+}
+
+// CombinedOutput записывает вызов и возвращает заданный вывод
+func (f *FakeRunner) CombinedOutput(name string, args ...string) ([]byte, error) {
+	return f.call(name, args)
+}
+
+// RunContext записывает вызов; отмененный ctx возвращает свою ошибку
+func (f *FakeRunner) RunContext(ctx context.Context, name string, args ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Run(name, args...)
+}
+
+// OutputContext записывает вызов; отмененный ctx возвращает свою ошибку
+func (f *FakeRunner) OutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.Output(name, args...)
+}
+
+// RunIO записывает вызов вместе с прочитанным Stdin и пишет заданный вывод в Stdout
+func (f *FakeRunner) RunIO(ctx context.Context, streams Streams, name string, args ...string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	call := Call{Name: name, Args: append([]string(nil), args...)}
+	if streams.Stdin != nil {
+		// Процесс прочитал бы весь ввод: иначе пишущая в канал сторона заблокируется
+		var stdin strings.Builder
+		_, _ = io.Copy(&stdin, streams.Stdin)
+		call.Stdin = stdin.String()
+	}
+	output, err := f.respond(call)
+	if streams.Stdout != nil && len(output) > 0 {
+		if _, werr := streams.Stdout.Write(output); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return err
+}
+
+// LookPath находит любую команду, кроме перечисленных в Missing
+func (f *FakeRunner) LookPath(name string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Missing[name] {
+		return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return fmt.Sprintf("/usr/bin/%s", name), nil
+}
@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func ExampleFakeRunner() {
+	fake := NewFakeRunner().
+		On("ufw status", "Status: inactive\n", nil).
+		On("systemctl", "", errors.New("exit status 1"))
+
+	output, _ := fake.Output("ufw", "status")
+	err := fake.Run("systemctl", "restart", "ssh")
+	fmt.Print(string(output))
+	fmt.Println(err)
+	fmt.Println(fake.Commands())
+	// Output:
+	// Status: inactive
+	// exit status 1
+	// [ufw status systemctl restart ssh]
+}
+
+func TestFakeRunnerResponsesInOrder(t *testing.T) {
+	fake := NewFakeRunner().
+		On("systemctl restart ssh", "", errors.New("сбой")).
+		On("systemctl restart ssh", "", nil)
+
+	if err := fake.Run("systemctl", "restart", "ssh"); err == nil {
+		t.Fatal("первый вызов должен вернуть ошибку")
+	}
+	for i := 0; i < 2; i++ {
+		if err := fake.Run("systemctl", "restart", "ssh"); err != nil {
+			t.Fatalf("повторный вызов %d: %v", i, err)
+		}
+	}
+}
+
+func TestFakeRunnerRunIO(t *testing.T) {
+	fake := NewFakeRunner().On("cpio -t", "a\nb\n", nil)
+	var stdout bytes.Buffer
+	stdin := strings.NewReader("архив")
+	err := fake.RunIO(context.Background(), Streams{Dir: "/tmp", Stdin: stdin, Stdout: &stdout}, "cpio", "-t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "a\nb\n" {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+	if stdin.Len() != 0 {
+		t.Error("stdin не прочитан")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := fake.RunIO(ctx, Streams{}, "cpio", "-t"); !errors.Is(err, context.Canceled) {
+		t.Errorf("отмененный ctx: %v", err)
+	}
+}
+
+func TestFakeRunnerLookPath(t *testing.T) {
+	fake := NewFakeRunner()
+	fake.Missing["pigz"] = true
+	if _, err := fake.LookPath("pigz"); err == nil {
+		t.Error("pigz найден, хотя отмечен отсутствующим")
+	}
+	if path, err := fake.LookPath("gzip"); err != nil || path != "/usr/bin/gzip" {
+		t.Errorf("LookPath(gzip) = %q, %v", path, err)
+	}
+}
+
+func TestSwappableConcurrentSwap(t *testing.T) {
+	first, second := NewFakeRunner(), NewFakeRunner()
+	s := NewSwappable(first)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = s.Run("true")
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		prev := s.Swap(second)
+		s.Swap(prev)
+	}
+	wg.Wait()
+
+	if got := len(first.Commands()) + len(second.Commands()); got != 800 {
+		t.Fatalf("выполнено %d вызовов, ожидалось 800", got)
+	}
+	if prev := s.Swap(nil); prev != first {
+		t.Fatal("Swap вернул не прежний исполнитель")
+	}
+	if !reflect.DeepEqual(s.current(), Default) {
+		t.Fatal("Swap(nil) должен вернуть Default")
+	}
+}
@@ -0,0 +1,100 @@
+// Package runner предоставляет абстракцию запуска внешних команд.
+// Модули выполняют команды через CommandRunner, чтобы в тестах его можно было
+// заменить на FakeRunner и проверять логику без живой системы.
+package runner
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// CommandRunner запускает внешние команды
+type CommandRunner interface {
+	// Run выполняет команду без захвата вывода
+	Run(name string, args ...string) error
+	// Output выполняет команду и возвращает stdout; stderr сохраняется в *exec.ExitError
+	Output(name string, args ...string) ([]byte, error)
+	// CombinedOutput выполняет команду и возвращает stdout и stderr вместе
+	CombinedOutput(name string, args ...string) ([]byte, error)
+	// RunContext выполняет команду, прерывая ее при отмене ctx
+	RunContext(ctx context.Context, name string, args ...string) error
+	// OutputContext выполняет команду с ctx и возвращает stdout
+	OutputContext(ctx context.Context, name string, args ...string) ([]byte, error)
+	// RunIO выполняет команду в директории и с потоками из streams, прерывая ее при отмене ctx
+	RunIO(ctx context.Context, streams Streams, name string, args ...string) error
+	// LookPath ищет исполняемый файл в PATH
+	LookPath(name string) (string, error)
+}
+
+// Streams задает рабочую директорию и потоки команды для RunIO; пустые поля - как в os/exec
+type Streams struct {
+	Dir    string
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Default - реализация по умолчанию, запускающая реальные процессы
+var Default CommandRunner = Exec{}
+
+// waitDelay - сколько ждать закрытия вывода после отмены контекста:
+// дочерние процессы sh могут держать stdout открытым после остановки оболочки
+const waitDelay = 100 * time.Millisecond
+
+// Exec запускает команды через os/exec
+type Exec struct{}
+
+// Run выполняет команду без захвата вывода
+func (Exec) Run(name string, args ...string) error {
+	return exec.Command(name, args...).Run()
+}
+
+// Output выполняет команду и возвращает stdout
+func (Exec) Output(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+// CombinedOutput выполняет команду и возвращает stdout и stderr вместе
+func (Exec) CombinedOutput(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// RunContext выполняет команду, прерывая ее при отмене ctx
+func (Exec) RunContext(ctx context.Context, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	return cmd.Run()
+}
+
+// OutputContext выполняет команду с ctx и возвращает stdout
+func (Exec) OutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = waitDelay
+	return cmd.Output()
+}
+
+// RunIO выполняет команду с заданными директорией и потоками
+func (Exec) RunIO(ctx context.Context, streams Streams, name string, args ...string) error {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = streams.Dir
+	cmd.Stdin = streams.Stdin
+	cmd.Stdout = streams.Stdout
+	cmd.Stderr = streams.Stderr
+	cmd.WaitDelay = waitDelay
+	return cmd.Run()
+}
+
+// LookPath ищет исполняемый файл в PATH
+func (Exec) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// Or возвращает r или Default, если r не задан
+func Or(r CommandRunner) CommandRunner {
+	if r == nil {
+		return Default
+	}
+	return r
+}
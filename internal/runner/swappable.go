@@ -0,0 +1,70 @@
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+// Swappable передает вызовы исполнителю, который можно заменить во время работы.
+// Замена и вызовы синхронизированы, поэтому тесты могут подменять исполнитель пакета
+// без гонок с уже запущенными горутинами.
+type Swappable struct {
+	mu sync.RWMutex
+	r  CommandRunner
+}
+
+// NewSwappable создает Swappable с исполнителем r (nil - Default)
+func NewSwappable(r CommandRunner) *Swappable {
+	return &Swappable{r: Or(r)}
+}
+
+// Swap заменяет исполнитель (nil - Default) и возвращает прежний
+func (s *Swappable) Swap(r CommandRunner) CommandRunner {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.r
+	s.r = Or(r)
+	return prev
+}
+
+// current возвращает текущий исполнитель
+func (s *Swappable) current() CommandRunner {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.r
+}
+
+// Run выполняет команду текущим исполнителем
+func (s *Swappable) Run(name string, args ...string) error {
+	return s.current().Run(name, args...)
+}
+
+// Output выполняет команду текущим исполнителем и возвращает stdout
+func (s *Swappable) Output(name string, args ...string) ([]byte, error) {
+	return s.current().Output(name, args...)
+}
+
+// CombinedOutput выполняет команду текущим исполнителем и возвращает stdout и stderr
+func (s *Swappable) CombinedOutput(name string, args ...string) ([]byte, error) {
+	return s.current().CombinedOutput(name, args...)
+}
+
+// RunContext выполняет команду текущим исполнителем с ctx
+func (s *Swappable) RunContext(ctx context.Context, name string, args ...string) error {
+	return s.current().RunContext(ctx, name, args...)
+}
+
+// OutputContext выполняет команду текущим исполнителем с ctx и возвращает stdout
+func (s *Swappable) OutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return s.current().OutputContext(ctx, name, args...)
+}
+
+// RunIO выполняет команду текущим исполнителем с заданными потоками
+func (s *Swappable) RunIO(ctx context.Context, streams Streams, name string, args ...string) error {
+	return s.current().RunIO(ctx, streams, name, args...)
+}
+
+// LookPath ищет исполняемый файл текущим исполнителем
+func (s *Swappable) LookPath(name string) (string, error) {
+	return s.current().LookPath(name)
+}
@@ -0,0 +1,84 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// configMergeRoot - директория, в которой менеджеры пакетов оставляют новые версии конфигураций
+const configMergeRoot = "/etc"
+
+// pendingConfigSuffixes - суффиксы файлов, оставленных dpkg, ucf и rpm при обновлении
+// измененной администратором конфигурации
+var pendingConfigSuffixes = []string{
+	".dpkg-dist", ".dpkg-new", ".dpkg-old",
+	".ucf-dist", ".ucf-new", ".ucf-old",
+	".rpmnew", ".rpmsave",
+}
+
+// ConfigConflict описывает необработанную версию конфигурационного файла
+type ConfigConflict struct {
+	// Base - текущий файл конфигурации
+	Base string
+	// Pending - версия, оставленная менеджером пакетов
+	Pending string
+	// Kind - суффикс без точки: dpkg-dist, rpmnew и т.д.
+	Kind string
+}
+
+// FindPendingConfigMerges ищет в /etc версии конфигураций, оставленные менеджером пакетов
+// при обновлении и не объединенные с текущими файлами
+func FindPendingConfigMerges() ([]ConfigConflict, error) {
+	return findPendingConfigMerges(configMergeRoot)
+}
+
+func findPendingConfigMerges(root string) ([]ConfigConflict, error) {
+	var conflicts []ConfigConflict
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		for _, suffix := range pendingConfigSuffixes {
+			if strings.HasSuffix(path, suffix) {
+				conflicts = append(conflicts, ConfigConflict{
+					Base:    strings.TrimSuffix(path, suffix),
+					Pending: path,
+					Kind:    strings.TrimPrefix(suffix, "."),
+				})
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return conflicts, fmt.Errorf("ошибка обхода %s: %w", root, err)
+	}
+	return conflicts, nil
+}
+
+// Diff возвращает унифицированный diff между текущим файлом и оставленной версией
+func (c ConfigConflict) Diff() (string, error) {
+	output, err := cmdRunner.Output("diff", "-u", "--", c.Base, c.Pending)
+	// diff завершается с кодом 1, если файлы различаются
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return string(output), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка сравнения %s и %s: %w", c.Base, c.Pending, err)
+	}
+	return string(output), nil
+}
@@ -0,0 +1,69 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+func TestFindPendingConfigMerges(t *testing.T) {
+	root := t.TempDir()
+	writeFixtures(t, root, map[string]string{
+		"ssh/sshd_config":                    "Port 22\n",
+		"ssh/sshd_config.dpkg-dist":          "Port 22\nUsePAM yes\n",
+		"dnf/dnf.conf":                       "[main]\n",
+		"dnf/dnf.conf.rpmnew":                "[main]\ngpgcheck=1\n",
+		"logrotate.d/rsyslog.rpmsave":        "weekly\n",
+		"default/grub.ucf-dist":              "GRUB_TIMEOUT=5\n",
+		"nginx/sites-available/default":      "server {}\n",
+		"nginx/sites-available/default.orig": "server {}\n",
+	})
+	// Ссылка с подходящим суффиксом не является оставленной версией
+	if err := os.Symlink("sshd_config", filepath.Join(root, "ssh", "ssh_config.rpmnew")); err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := findPendingConfigMerges(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Pending < conflicts[j].Pending })
+	want := []ConfigConflict{
+		{Base: filepath.Join(root, "default/grub"), Pending: filepath.Join(root, "default/grub.ucf-dist"), Kind: "ucf-dist"},
+		{Base: filepath.Join(root, "dnf/dnf.conf"), Pending: filepath.Join(root, "dnf/dnf.conf.rpmnew"), Kind: "rpmnew"},
+		{Base: filepath.Join(root, "logrotate.d/rsyslog"), Pending: filepath.Join(root, "logrotate.d/rsyslog.rpmsave"), Kind: "rpmsave"},
+		{Base: filepath.Join(root, "ssh/sshd_config"), Pending: filepath.Join(root, "ssh/sshd_config.dpkg-dist"), Kind: "dpkg-dist"},
+	}
+	if !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("найдено:\n%+v\nожидалось:\n%+v", conflicts, want)
+	}
+}
+
+func TestFindPendingConfigMergesMissingRoot(t *testing.T) {
+	if _, err := findPendingConfigMerges(filepath.Join(t.TempDir(), "etc")); err == nil {
+		t.Error("отсутствующая директория должна давать ошибку")
+	}
+}
+
+func TestConfigConflictDiff(t *testing.T) {
+	conflict := ConfigConflict{Base: "/etc/dnf/dnf.conf", Pending: "/etc/dnf/dnf.conf.rpmnew", Kind: "rpmnew"}
+	const diff = "--- /etc/dnf/dnf.conf\n+++ /etc/dnf/dnf.conf.rpmnew\n@@ -1 +1,2 @@\n [main]\n+gpgcheck=1\n"
+
+	// Код 1 означает, что файлы различаются, а не ошибку; код 2 - ошибка diff
+	fake := runner.NewFakeRunner().
+		On("diff -u -- /etc/dnf/dnf.conf /etc/dnf/dnf.conf.rpmnew", diff, exitStatus(t, "1")).
+		On("diff -u -- /etc/dnf/dnf.conf /etc/dnf/dnf.conf.rpmnew", "", exitStatus(t, "2"))
+	t.Cleanup(SetCommandRunner(fake))
+	got, err := conflict.Diff()
+	if err != nil || got != diff {
+		t.Errorf("Diff() = %q, %v", got, err)
+	}
+
+	if _, err := conflict.Diff(); err == nil {
+		t.Error("код 2 должен давать ошибку")
+	}
+}
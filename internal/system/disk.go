@@ -0,0 +1,199 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+)
+
+// procMountsPath - список смонтированных файловых систем
+const procMountsPath = "/proc/mounts"
+
+// DefaultInodeThreshold - доля занятых inode в процентах, выше которой выдается предупреждение
+const DefaultInodeThreshold = 90.0
+
+// DiskUsage содержит занятость файловой системы по байтам и inode
+type DiskUsage struct {
+	Mount      string
+	Total      uint64
+	Used       uint64
+	Inodes     uint64
+	InodesUsed uint64
+}
+
+// UsedPercent возвращает долю занятого места в процентах
+func (d DiskUsage) UsedPercent() float64 {
+	if d.Total == 0 {
+		return 0
+	}
+	return float64(d.Used) * 100 / float64(d.Total)
+}
+
+// InodePercent возвращает долю занятых inode в процентах.
+// Файловые системы без фиксированного числа inode (btrfs) дают 0.
+func (d DiskUsage) InodePercent() float64 {
+	if d.Inodes == 0 {
+		return 0
+	}
+	return float64(d.InodesUsed) * 100 / float64(d.Inodes)
+}
+
+// GetDiskUsage возвращает занятость файловых систем по байтам и inode.
+// Без аргументов проверяются файловые системы на блочных устройствах
+// с учетом фильтров su.Disk (snap и overlay по умолчанию скрыты).
+func (su *SystemUtils) GetDiskUsage(mounts ...string) ([]DiskUsage, error) {
+	explicit := len(mounts) > 0
+	if !explicit {
+		table, err := readMounts(procMountsPath)
+		if err != nil {
+			return nil, err
+		}
+		mounts = filterMounts(table, su.Disk)
+	}
+
+	usages := make([]DiskUsage, 0, len(mounts))
+	for _, mount := range mounts {
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(mount, &st); err != nil {
+			// Недоступные точки из таблицы монтирования (отключенный NFS) пропускаются
+			if !explicit {
+				continue
+			}
+			return usages, fmt.Errorf("ошибка получения данных о %s: %w", mount, err)
+		}
+		usages = append(usages, diskUsageFromStatfs(mount, &st))
+	}
+	return usages, nil
+}
+
+// InodeWarnings возвращает предупреждения о файловых системах, в которых занято
+// больше InodeThreshold процентов inode (по умолчанию DefaultInodeThreshold).
+// Нехватка inode не видна по занятому месту: диск "заполнен" при свободных байтах.
+func (su *SystemUtils) InodeWarnings(usages []DiskUsage) []string {
+	threshold := su.InodeThreshold
+	if threshold <= 0 {
+		threshold = DefaultInodeThreshold
+	}
+	var warnings []string
+	for _, usage := range usages {
+		if percent := usage.InodePercent(); percent > threshold {
+			warnings = append(warnings, fmt.Sprintf("%s: занято %.0f%% inode (место: %.0f%%)",
+				usage.Mount, percent, usage.UsedPercent()))
+		}
+	}
+	return warnings
+}
+
+// diskUsageFromStatfs переводит данные statfs в DiskUsage.
+// Занятое место считается как Blocks-Bfree, как в df
+func diskUsageFromStatfs(mount string, st *syscall.Statfs_t) DiskUsage {
+	blockSize := uint64(st.Bsize)
+	usage := DiskUsage{
+		Mount:  mount,
+		Total:  st.Blocks * blockSize,
+		Used:   (st.Blocks - st.Bfree) * blockSize,
+		Inodes: st.Files,
+	}
+	if st.Files >= st.Ffree {
+		usage.InodesUsed = st.Files - st.Ffree
+	}
+	return usage
+}
+
+// mountEntry - запись таблицы монтирования
+type mountEntry struct {
+	Source string
+	Mount  string
+	FSType string
+}
+
+// Фильтры дисков по умолчанию: snap-пакеты монтируются как squashfs с loop-устройств
+// в /snap, а overlay - слои контейнеров; в отчетах о дисках они только мешают
+var (
+	defaultExcludeFSTypes = []string{"squashfs", "overlay"}
+	defaultExcludeMounts  = []string{"/snap", "/var/snap", "/var/lib/docker"}
+)
+
+// readMounts читает таблицу монтирования в формате /proc/mounts
+func readMounts(path string) ([]mountEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var mounts []mountEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// /dev/sda1 / ext4 rw,relatime 0 0
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 {
+			continue
+		}
+		// Пробелы в путях экранируются как \040
+		mounts = append(mounts, mountEntry{
+			Source: fields[0],
+			Mount:  strings.ReplaceAll(fields[1], `\040`, " "),
+			FSType: fields[2],
+		})
+	}
+	return mounts, scanner.Err()
+}
+
+// filterMounts оставляет файловые системы, которые показываются в отчетах:
+// на блочных устройствах (кроме loop) и не скрытые фильтрами cfg.
+// С cfg.ShowAll возвращаются все записи, кроме повторов и явно исключенных.
+func filterMounts(mounts []mountEntry, cfg appconfig.DiskConfig) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, m := range mounts {
+		if seen[m.Mount] || !showMount(m, cfg) {
+			continue
+		}
+		seen[m.Mount] = true
+		result = append(result, m.Mount)
+	}
+	return result
+}
+
+// showMount применяет фильтры дисков к одной файловой системе
+func showMount(m mountEntry, cfg appconfig.DiskConfig) bool {
+	if hasMountPrefix(m.Mount, cfg.IncludeMounts) {
+		return true
+	}
+	if hasMountPrefix(m.Mount, cfg.ExcludeMounts) || containsString(cfg.ExcludeFSTypes, m.FSType) {
+		return false
+	}
+	if appconfig.Enabled(cfg.ShowAll) {
+		return true
+	}
+	if !strings.HasPrefix(m.Source, "/dev/") || strings.HasPrefix(m.Source, "/dev/loop") {
+		return false
+	}
+	return !hasMountPrefix(m.Mount, defaultExcludeMounts) && !containsString(defaultExcludeFSTypes, m.FSType)
+}
+
+// hasMountPrefix проверяет, лежит ли mount в одной из директорий prefixes
+func hasMountPrefix(mount string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if mount == prefix || strings.HasPrefix(mount, prefix+"/") || prefix == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// containsString проверяет наличие значения в списке
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
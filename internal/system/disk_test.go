@@ -0,0 +1,140 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+)
+
+func TestInodeWarningsFromStatfs(t *testing.T) {
+	// Место занято на 30%, inode - на 95%: много мелких файлов
+	full := diskUsageFromStatfs("/var", &syscall.Statfs_t{
+		Bsize: 4096, Blocks: 1000, Bfree: 700,
+		Files: 10000, Ffree: 500,
+	})
+	if full.Total != 4096000 || full.Used != 1228800 || full.Inodes != 10000 || full.InodesUsed != 9500 {
+		t.Fatalf("diskUsageFromStatfs() = %+v", full)
+	}
+	// btrfs не сообщает число inode
+	btrfs := diskUsageFromStatfs("/home", &syscall.Statfs_t{Bsize: 4096, Blocks: 1000, Bfree: 10})
+	normal := diskUsageFromStatfs("/", &syscall.Statfs_t{
+		Bsize: 4096, Blocks: 1000, Bfree: 500,
+		Files: 10000, Ffree: 8000,
+	})
+
+	su := &SystemUtils{}
+	warnings := su.InodeWarnings([]DiskUsage{full, btrfs, normal})
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "/var: занято 95% inode") {
+		t.Errorf("предупреждения %q", warnings)
+	}
+
+	su.InodeThreshold = 15
+	if warnings := su.InodeWarnings([]DiskUsage{full, btrfs, normal}); len(warnings) != 2 {
+		t.Errorf("порог 15%%: предупреждения %q", warnings)
+	}
+}
+
+func TestDiskUsagePercentNoData(t *testing.T) {
+	var usage DiskUsage
+	if usage.UsedPercent() != 0 || usage.InodePercent() != 0 {
+		t.Errorf("пустые данные: %v, %v", usage.UsedPercent(), usage.InodePercent())
+	}
+	// Ffree больше Files бывает у сетевых файловых систем
+	odd := diskUsageFromStatfs("/mnt/nfs", &syscall.Statfs_t{Bsize: 512, Blocks: 10, Files: 5, Ffree: 7})
+	if odd.InodesUsed != 0 {
+		t.Errorf("InodesUsed = %d", odd.InodesUsed)
+	}
+}
+
+func TestGetDiskUsageExplicitMounts(t *testing.T) {
+	su := &SystemUtils{}
+	dir := t.TempDir()
+	usages, err := su.GetDiskUsage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 || usages[0].Mount != dir || usages[0].Total == 0 {
+		t.Errorf("GetDiskUsage(%s) = %+v", dir, usages)
+	}
+	if _, err := su.GetDiskUsage(filepath.Join(dir, "missing")); err == nil {
+		t.Error("явно указанная отсутствующая точка должна давать ошибку")
+	}
+}
+
+// mountsFixture - /proc/mounts Ubuntu с snap-пакетами и контейнерами
+const mountsFixture = `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda2 / ext4 rw,relatime 0 0
+/dev/sda1 /boot/efi vfat rw,relatime 0 0
+tmpfs /run tmpfs rw,nosuid,nodev,noexec,relatime 0 0
+/dev/loop0 /snap/core22/1122 squashfs ro,nodev,relatime 0 0
+/dev/loop1 /snap/lxd/27037 squashfs ro,nodev,relatime 0 0
+tmpfs /run/snapd/ns tmpfs rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda2 /var/snap/lxd/common/lxd ext4 rw,relatime 0 0
+overlay /var/lib/docker/overlay2/abc/merged overlay rw,relatime 0 0
+/dev/sdb1 /mnt/My\040Data ext4 rw,relatime 0 0
+/dev/sda2 / ext4 rw,relatime 0 0
+`
+
+func readMountsFixture(t *testing.T) []mountEntry {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mounts")
+	if err := os.WriteFile(path, []byte(mountsFixture), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mounts, err := readMounts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mounts
+}
+
+func TestFilterMountsHidesSnapsByDefault(t *testing.T) {
+	mounts := readMountsFixture(t)
+	want := []string{"/", "/boot/efi", "/mnt/My Data"}
+	if got := filterMounts(mounts, appconfig.DiskConfig{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterMounts() = %q, ожидалось %q", got, want)
+	}
+}
+
+func TestFilterMountsConfig(t *testing.T) {
+	mounts := readMountsFixture(t)
+	tests := []struct {
+		name string
+		cfg  appconfig.DiskConfig
+		want []string
+	}{
+		{
+			name: "include",
+			cfg:  appconfig.DiskConfig{IncludeMounts: []string{"/snap/lxd", "/run"}},
+			want: []string{"/", "/boot/efi", "/run", "/snap/lxd/27037", "/run/snapd/ns", "/mnt/My Data"},
+		},
+		{
+			name: "exclude",
+			cfg:  appconfig.DiskConfig{ExcludeMounts: []string{"/mnt/"}, ExcludeFSTypes: []string{"vfat"}},
+			want: []string{"/"},
+		},
+		{
+			name: "show_all",
+			cfg:  appconfig.DiskConfig{ShowAll: appconfig.Bool(true), ExcludeFSTypes: []string{"proc", "sysfs", "tmpfs"}},
+			want: []string{"/", "/boot/efi", "/snap/core22/1122", "/snap/lxd/27037",
+				"/var/snap/lxd/common/lxd", "/var/lib/docker/overlay2/abc/merged", "/mnt/My Data"},
+		},
+	}
+	for _, tt := range tests {
+		if got := filterMounts(mounts, tt.cfg); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: filterMounts() = %q, ожидалось %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadMountsMissing(t *testing.T) {
+	if _, err := readMounts(filepath.Join(t.TempDir(), "mounts")); err == nil {
+		t.Error("отсутствующая таблица монтирования должна давать ошибку")
+	}
+}
@@ -0,0 +1,72 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/13winged/go-to-run/internal/ui"
+)
+
+// entropyAvailPath - файл ядра с текущим объемом пула энтропии
+const entropyAvailPath = "/proc/sys/kernel/random/entropy_avail"
+
+// LowEntropyThreshold - объем энтропии, ниже которого генерация ключей может блокироваться
+const LowEntropyThreshold = 256
+
+// CheckEntropy возвращает доступный объем энтропии в битах
+func (su *SystemUtils) CheckEntropy() (int, error) {
+	return readEntropy(entropyAvailPath)
+}
+
+func readEntropy(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения энтропии: %w", err)
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка разбора энтропии: %w", err)
+	}
+	return value, nil
+}
+
+// SetupEntropy устанавливает и включает haveged (или rng-tools), если энтропии мало
+func (su *SystemUtils) SetupEntropy() error {
+	entropy, err := su.CheckEntropy()
+	if err != nil {
+		return err
+	}
+	if entropy >= LowEntropyThreshold {
+		fmt.Printf("Энтропии достаточно: %d\n", entropy)
+		return nil
+	}
+
+	s := ui.NewSpinner(fmt.Sprintf("Мало энтропии (%d), настройка генератора...", entropy))
+	s.Start()
+	defer s.Stop()
+
+	pm, err := DetectPackageManager()
+	if err != nil {
+		return fmt.Errorf("ошибка определения менеджера пакетов: %w", err)
+	}
+
+	// haveged есть в большинстве дистрибутивов, rng-tools - запасной вариант
+	daemons := []struct{ pkg, service string }{
+		{"haveged", "haveged"},
+		{"rng-tools", "rngd"},
+	}
+	var lastErr error
+	for _, d := range daemons {
+		if err := shell(pm.Install + " " + d.pkg); err != nil {
+			lastErr = fmt.Errorf("ошибка установки %s: %w", d.pkg, err)
+			continue
+		}
+		if err := cmdRunner.Run("systemctl", "enable", "--now", d.service); err != nil {
+			return fmt.Errorf("ошибка запуска %s: %w", d.service, err)
+		}
+		return nil
+	}
+	return lastErr
+}
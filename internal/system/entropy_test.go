@@ -0,0 +1,49 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadEntropy(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+		low     bool
+	}{
+		{"3842\n", 3842, false},
+		{"256\n", 256, false},
+		{"  128  \n", 128, true},
+		{"0\n", 0, true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "entropy_avail")
+		if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := readEntropy(path)
+		if err != nil {
+			t.Fatalf("%q: %v", tt.content, err)
+		}
+		if got != tt.want {
+			t.Errorf("%q: энтропия %d, ожидалось %d", tt.content, got, tt.want)
+		}
+		if low := got < LowEntropyThreshold; low != tt.low {
+			t.Errorf("%q: низкая энтропия = %v, ожидалось %v", tt.content, low, tt.low)
+		}
+	}
+}
+
+func TestReadEntropyErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entropy_avail")
+	if err := os.WriteFile(path, []byte("много\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readEntropy(path); err == nil {
+		t.Error("нечисловое значение: ожидалась ошибка")
+	}
+	if _, err := readEntropy(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("отсутствующий файл: ожидалась ошибка")
+	}
+}
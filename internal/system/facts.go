@@ -0,0 +1,95 @@
+package system
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// SystemFacts содержит сведения о системе, которые не меняются в течение запуска:
+// менеджер пакетов, дистрибутив, фаервол и система инициализации
+type SystemFacts struct {
+	// PackageManager - обнаруженный менеджер пакетов; nil вместе с PackageManagerErr
+	PackageManager    *PackageManager
+	PackageManagerErr error
+	// OSRelease - содержимое os-release; nil, если файл не найден
+	OSRelease *OSRelease
+	// FirewallBackend - первый установленный фаервол (ufw, firewalld, nftables) или пусто
+	FirewallBackend string
+	// InitSystem - systemd, openrc или имя процесса с PID 1
+	InitSystem string
+}
+
+var (
+	factsMu sync.Mutex
+	facts   *SystemFacts
+)
+
+// Facts возвращает сведения о системе, собранные при первом вызове.
+// Повторные вызовы в рамках запуска не запускают определение заново.
+func Facts() *SystemFacts {
+	factsMu.Lock()
+	defer factsMu.Unlock()
+	if facts == nil {
+		facts = collectFacts()
+	}
+	return facts
+}
+
+// ResetFacts сбрасывает сохраненные сведения, например после установки фаервола
+func ResetFacts() {
+	factsMu.Lock()
+	facts = nil
+	factsMu.Unlock()
+}
+
+// DetectPackageManager возвращает менеджер пакетов из Facts.
+// Каждый вызов получает свою копию, чтобы настройка State одной операции не влияла на другие.
+func DetectPackageManager() (*PackageManager, error) {
+	f := Facts()
+	if f.PackageManagerErr != nil {
+		return nil, f.PackageManagerErr
+	}
+	pm := *f.PackageManager
+	return &pm, nil
+}
+
+func collectFacts() *SystemFacts {
+	f := &SystemFacts{InitSystem: detectInitSystem()}
+	if release, err := ParseOSRelease(); err == nil {
+		f.OSRelease = release
+	}
+	f.PackageManager, f.PackageManagerErr = detectPackageManager(f.OSRelease)
+	for _, backend := range firewallBackends {
+		if commandExists(backend.binary) {
+			f.FirewallBackend = backend.name
+			break
+		}
+	}
+	return f
+}
+
+// initSystemMarkers - признаки систем инициализации по порядку проверки
+var initSystemMarkers = []struct {
+	name string
+	path string
+}{
+	{"systemd", "/run/systemd/system"},
+	{"openrc", "/run/openrc"},
+}
+
+// procOneComm - имя процесса с PID 1, если ни один признак не найден
+var procOneComm = "/proc/1/comm"
+
+// detectInitSystem определяет систему инициализации по ее служебным директориям
+func detectInitSystem() string {
+	for _, marker := range initSystemMarkers {
+		if _, err := os.Stat(marker.path); err == nil {
+			return marker.name
+		}
+	}
+	if data, err := os.ReadFile(procOneComm); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return "unknown"
+}
@@ -0,0 +1,163 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// lookPathCounter - FakeRunner, считающий поиски команд в PATH
+type lookPathCounter struct {
+	*runner.FakeRunner
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+func newLookPathCounter(present string) *lookPathCounter {
+	fake := runner.NewFakeRunner()
+	for _, manager := range packageManagerOrder {
+		fake.Missing[manager] = manager != present
+	}
+	return &lookPathCounter{FakeRunner: fake, lookups: make(map[string]int)}
+}
+
+func (c *lookPathCounter) LookPath(name string) (string, error) {
+	c.mu.Lock()
+	c.lookups[name]++
+	c.mu.Unlock()
+	return c.FakeRunner.LookPath(name)
+}
+
+func (c *lookPathCounter) total() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, count := range c.lookups {
+		n += count
+	}
+	return n
+}
+
+func TestFactsProbeOnce(t *testing.T) {
+	counter := newLookPathCounter("dnf")
+	t.Cleanup(SetCommandRunner(counter))
+
+	pm, err := DetectPackageManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.Name != "dnf" {
+		t.Fatalf("менеджер %s, ожидался dnf", pm.Name)
+	}
+	probes := counter.total()
+	if probes == 0 {
+		t.Fatal("определение не проверило ни одной команды")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = DetectPackageManager()
+			_ = Facts()
+		}()
+	}
+	wg.Wait()
+	if _, err := DetectPackageManager(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := counter.total(); got != probes {
+		t.Errorf("повторные вызовы выполнили %d поисков в PATH, ожидалось 0", got-probes)
+	}
+}
+
+func TestResetFactsProbesAgain(t *testing.T) {
+	counter := newLookPathCounter("apk")
+	t.Cleanup(SetCommandRunner(counter))
+
+	first := Facts()
+	if Facts() != first {
+		t.Fatal("повторный вызов Facts вернул новые сведения")
+	}
+	probes := counter.total()
+
+	ResetFacts()
+	if Facts() == first {
+		t.Error("после ResetFacts сведения не собраны заново")
+	}
+	if counter.total() != 2*probes {
+		t.Errorf("после ResetFacts выполнено %d поисков, ожидалось %d", counter.total()-probes, probes)
+	}
+}
+
+func TestDetectPackageManagerReturnsCopy(t *testing.T) {
+	t.Cleanup(SetCommandRunner(newLookPathCounter("pacman")))
+
+	first, err := DetectPackageManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Install = "changed"
+	second, err := DetectPackageManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Install == "changed" {
+		t.Error("изменение одной копии менеджера видно в другой")
+	}
+}
+
+func TestDetectPackageManagerCachesError(t *testing.T) {
+	counter := newLookPathCounter("")
+	t.Cleanup(SetCommandRunner(counter))
+
+	if _, err := DetectPackageManager(); !errors.Is(err, ErrNoPackageManager) {
+		t.Fatalf("ошибка %v, ожидалась ErrNoPackageManager", err)
+	}
+	probes := counter.total()
+	for i := 0; i < 3; i++ {
+		if _, err := DetectPackageManager(); !errors.Is(err, ErrNoPackageManager) {
+			t.Fatalf("ошибка %v, ожидалась ErrNoPackageManager", err)
+		}
+	}
+	if got := counter.total(); got != probes {
+		t.Errorf("повторные вызовы выполнили %d поисков в PATH, ожидалось 0", got-probes)
+	}
+}
+
+func TestDetectInitSystem(t *testing.T) {
+	dir := t.TempDir()
+	prevMarkers, prevComm := initSystemMarkers, procOneComm
+	t.Cleanup(func() { initSystemMarkers, procOneComm = prevMarkers, prevComm })
+
+	initSystemMarkers = []struct {
+		name string
+		path string
+	}{
+		{"systemd", filepath.Join(dir, "systemd")},
+		{"openrc", filepath.Join(dir, "openrc")},
+	}
+	procOneComm = filepath.Join(dir, "comm")
+
+	if got := detectInitSystem(); got != "unknown" {
+		t.Errorf("без признаков: %q, ожидалось unknown", got)
+	}
+	if err := os.WriteFile(procOneComm, []byte("runit\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := detectInitSystem(); got != "runit" {
+		t.Errorf("по /proc/1/comm: %q, ожидалось runit", got)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "openrc"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if got := detectInitSystem(); got != "openrc" {
+		t.Errorf("с /run/openrc: %q, ожидалось openrc", got)
+	}
+}
@@ -0,0 +1,60 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+)
+
+// secureUmaskBits - биты, которые всегда должны быть в umask процесса:
+// создаваемые файлы не получают права записи для группы и остальных
+const secureUmaskBits = 0022
+
+var (
+	// systemFileMode - права системных файлов (/etc/timezone, sysctl.d, fstab)
+	systemFileMode os.FileMode = 0644
+	// sensitiveFileMode - права файлов с настройками доступа (sshd_config, jail.local)
+	sensitiveFileMode os.FileMode = 0600
+)
+
+// SetFileModes задает права системных и чувствительных файлов из конфигурации.
+// Пустые поля сохраняют значения по умолчанию.
+func SetFileModes(files appconfig.FilesConfig) error {
+	for _, field := range []struct {
+		value string
+		dst   *os.FileMode
+	}{
+		{files.SystemMode, &systemFileMode},
+		{files.SensitiveMode, &sensitiveFileMode},
+	} {
+		if field.value == "" {
+			continue
+		}
+		mode, err := appconfig.ParseFileMode(field.value)
+		if err != nil {
+			return err
+		}
+		*field.dst = mode
+	}
+	return nil
+}
+
+// SecureUmask добавляет к umask процесса запрет записи для группы и остальных
+// и возвращает предыдущее значение
+func SecureUmask() int {
+	old := syscall.Umask(secureUmaskBits)
+	syscall.Umask(old | secureUmaskBits)
+	return old
+}
+
+// writeFileMode записывает файл и явно устанавливает права: os.WriteFile не меняет
+// права существующего файла, а umask может сузить их при создании
+func writeFileMode(path string, data []byte, mode os.FileMode) error {
+	path = filepath.Clean(path)
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return err
+	}
+	return os.Chmod(path, mode)
+}
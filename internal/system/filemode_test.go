@@ -0,0 +1,73 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+)
+
+func TestSecureUmask(t *testing.T) {
+	old := syscall.Umask(0)
+	t.Cleanup(func() { syscall.Umask(old) })
+
+	if prev := SecureUmask(); prev != 0 {
+		t.Errorf("SecureUmask() вернула %o, ожидался прежний umask 0", prev)
+	}
+	path := filepath.Join(t.TempDir(), "created")
+	if err := os.WriteFile(path, nil, 0666); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0644 {
+		t.Errorf("права созданного файла %o, ожидалось 0644", got)
+	}
+
+	// Более строгий umask сохраняется
+	syscall.Umask(0077)
+	SecureUmask()
+	if current := syscall.Umask(0077); current != 0077 {
+		t.Errorf("umask 0077 изменен на %o", current)
+	}
+}
+
+func TestWriteFileModeTightensExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jail.local")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileMode(path, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0600 {
+		t.Errorf("права %o, ожидалось 0600", got)
+	}
+}
+
+func TestSetFileModes(t *testing.T) {
+	prevSystem, prevSensitive := systemFileMode, sensitiveFileMode
+	t.Cleanup(func() { systemFileMode, sensitiveFileMode = prevSystem, prevSensitive })
+
+	if err := SetFileModes(appconfig.FilesConfig{SensitiveMode: "0640"}); err != nil {
+		t.Fatal(err)
+	}
+	if systemFileMode != 0644 || sensitiveFileMode != 0640 {
+		t.Errorf("права: системные %o, чувствительные %o", systemFileMode, sensitiveFileMode)
+	}
+	if err := SetFileModes(appconfig.FilesConfig{SystemMode: "0666"}); err == nil {
+		t.Error("права с записью для всех приняты")
+	}
+}
@@ -0,0 +1,205 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// FirewallInfo описывает текущее состояние фаервола
+type FirewallInfo struct {
+	// Backend - ufw, firewalld или nftables
+	Backend   string
+	Active    bool
+	RuleCount int
+	// DefaultIncoming - политика для входящих соединений (deny, reject, allow, drop)
+	DefaultIncoming string
+}
+
+// ErrNoFirewall возвращается, если в системе нет ни одного поддерживаемого фаервола
+var ErrNoFirewall = errors.New("фаервол не найден (ufw, firewalld, nftables)")
+
+// firewallBackends перечисляет фаерволы в порядке проверки.
+// ufw и firewalld работают поверх nftables, поэтому nft проверяется последним.
+var firewallBackends = []struct {
+	name   string
+	binary string
+	query  func() (*FirewallInfo, error)
+}{
+	{"ufw", "ufw", queryUFW},
+	{"firewalld", "firewall-cmd", queryFirewalld},
+	{"nftables", "nft", queryNftables},
+}
+
+// FirewallStatus определяет установленный фаервол и возвращает его состояние.
+// Если установлено несколько фаерволов, возвращается первый активный,
+// а при отсутствии активных - первый найденный. Без прав root возвращается ErrRequiresRoot.
+func (sm *SecurityManager) FirewallStatus() (*FirewallInfo, error) {
+	if err := RequireRoot(); err != nil {
+		return nil, err
+	}
+	var inactive *FirewallInfo
+	for _, backend := range firewallBackends {
+		if !commandExists(backend.binary) {
+			continue
+		}
+		info, err := backend.query()
+		if err != nil {
+			return nil, err
+		}
+		if info.Active {
+			return info, nil
+		}
+		if inactive == nil {
+			inactive = info
+		}
+	}
+	if inactive == nil {
+		return nil, ErrNoFirewall
+	}
+	return inactive, nil
+}
+
+func queryUFW() (*FirewallInfo, error) {
+	output, err := cmdRunner.Output("ufw", "status", "verbose")
+	if err != nil {
+		return nil, firewallQueryError("ufw", err)
+	}
+	return parseUFWStatus(string(output)), nil
+}
+
+func queryFirewalld() (*FirewallInfo, error) {
+	// firewall-cmd --state завершается с кодом 252, если служба не запущена
+	state, _ := cmdRunner.Output("firewall-cmd", "--state")
+	if strings.TrimSpace(string(state)) != "running" {
+		return &FirewallInfo{Backend: "firewalld"}, nil
+	}
+	output, err := cmdRunner.Output("firewall-cmd", "--list-all")
+	if err != nil {
+		return nil, firewallQueryError("firewalld", err)
+	}
+	return parseFirewalldList(string(output)), nil
+}
+
+func queryNftables() (*FirewallInfo, error) {
+	output, err := cmdRunner.Output("nft", "list", "ruleset")
+	if err != nil {
+		return nil, firewallQueryError("nftables", err)
+	}
+	return parseNftRuleset(string(output)), nil
+}
+
+func firewallQueryError(backend string, err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return fmt.Errorf("ошибка получения статуса %s: %w: %s", backend, err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return fmt.Errorf("ошибка получения статуса %s: %w", backend, err)
+}
+
+// parseUFWStatus разбирает вывод "ufw status verbose"
+func parseUFWStatus(output string) *FirewallInfo {
+	info := &FirewallInfo{Backend: "ufw"}
+	inRules := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Status:"):
+			info.Active = strings.TrimSpace(strings.TrimPrefix(line, "Status:")) == "active"
+		case strings.HasPrefix(line, "Default:"):
+			// Default: deny (incoming), allow (outgoing), disabled (routed)
+			for _, part := range strings.Split(strings.TrimPrefix(line, "Default:"), ",") {
+				if strings.Contains(part, "(incoming)") {
+					info.DefaultIncoming = strings.Fields(part)[0]
+				}
+			}
+		case strings.HasPrefix(line, "--"):
+			inRules = true
+		case inRules && line != "":
+			info.RuleCount++
+		}
+	}
+	return info
+}
+
+// parseFirewalldList разбирает вывод "firewall-cmd --list-all" для зоны по умолчанию
+func parseFirewalldList(output string) *FirewallInfo {
+	info := &FirewallInfo{Backend: "firewalld", Active: true}
+	inRichRules := false
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		key, value, found := strings.Cut(trimmed, ":")
+		if inRichRules && (!found || strings.HasPrefix(trimmed, "rule ")) {
+			if trimmed != "" {
+				info.RuleCount++
+			}
+			continue
+		}
+		inRichRules = false
+
+		switch key {
+		case "target":
+			info.DefaultIncoming = firewalldTarget(strings.TrimSpace(value))
+		case "services", "ports", "source-ports", "protocols", "forward-ports":
+			info.RuleCount += len(strings.Fields(value))
+		case "rich rules":
+			inRichRules = true
+		}
+	}
+	return info
+}
+
+// firewalldTarget приводит target зоны firewalld к политике входящих соединений
+func firewalldTarget(target string) string {
+	switch target {
+	case "default", "%%REJECT%%", "REJECT":
+		return "reject"
+	case "DROP":
+		return "drop"
+	case "ACCEPT":
+		return "allow"
+	default:
+		return strings.ToLower(target)
+	}
+}
+
+// parseNftRuleset разбирает вывод "nft list ruleset".
+// Политикой входящих считается policy цепочки с hook input.
+func parseNftRuleset(output string) *FirewallInfo {
+	info := &FirewallInfo{Backend: "nftables"}
+	inChain := false
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "chain "):
+			inChain = true
+		case trimmed == "}":
+			inChain = false
+		case !inChain || trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "type "):
+			if strings.Contains(trimmed, "hook input") {
+				if _, policy, ok := strings.Cut(trimmed, "policy "); ok {
+					info.DefaultIncoming = strings.TrimSuffix(strings.TrimSpace(policy), ";")
+				}
+			}
+		default:
+			info.RuleCount++
+		}
+	}
+	info.Active = info.RuleCount > 0 || (info.DefaultIncoming != "" && info.DefaultIncoming != "accept")
+	return info
+}
+
+// FormatFirewallInfo возвращает краткое описание состояния фаервола для вывода
+func FormatFirewallInfo(info *FirewallInfo) string {
+	state := "inactive"
+	if info.Active {
+		state = "active"
+	}
+	result := fmt.Sprintf("%s %s, правил: %d", info.Backend, state, info.RuleCount)
+	if info.DefaultIncoming != "" {
+		result += ", входящие по умолчанию: " + info.DefaultIncoming
+	}
+	return result
+}
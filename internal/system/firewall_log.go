@@ -0,0 +1,274 @@
+package system
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FirewallLogEntry - запись журнала фаервола о сетевом пакете
+type FirewallLogEntry struct {
+	Time time.Time
+	// Action - действие из префикса записи: BLOCK, ALLOW, AUDIT для UFW или префикс правила nftables
+	Action  string
+	SrcIP   string
+	DstPort int
+	// Proto - протокол в нижнем регистре: tcp, udp, icmp, icmpv6
+	Proto string
+}
+
+// FirewallLogCount - число заблокированных пакетов для адреса или порта
+type FirewallLogCount struct {
+	Key   string
+	Count int
+}
+
+// FirewallLogSummary содержит самые частые источники и цели заблокированных пакетов
+type FirewallLogSummary struct {
+	Blocked    int
+	TopSources []FirewallLogCount
+	TopPorts   []FirewallLogCount
+}
+
+// ufwLogPath - журнал UFW; ротированные копии лежат рядом с суффиксами .1, .2.gz и т.д.
+var ufwLogPath = "/var/log/ufw.log"
+
+// firewallLogRotations - сколько ротированных копий журнала просматривается
+const firewallLogRotations = 5
+
+// defaultFirewallLogLines - количество записей, если lines не задано
+const defaultFirewallLogLines = 100
+
+var (
+	// ufwActionPattern находит префикс UFW: "[UFW BLOCK]", "[UFW LIMIT BLOCK]"
+	ufwActionPattern = regexp.MustCompile(`\[UFW ([A-Z ]+)\]`)
+	// kernelPrefixPattern находит префикс правила между "kernel:" (и временем ядра) и полем IN=
+	kernelPrefixPattern = regexp.MustCompile(`kernel:\s*(?:\[\s*[0-9.]+\]\s*)?(.*?)\s*IN=`)
+	// logFieldPattern находит поля вида SRC=1.2.3.4
+	logFieldPattern = regexp.MustCompile(`\b([A-Z]+)=(\S*)`)
+)
+
+// GetFirewallLog возвращает последние lines записей журнала фаервола в хронологическом порядке.
+// Читает /var/log/ufw.log и его ротированные копии (в том числе .gz); если журнала UFW нет,
+// записи берутся из журнала ядра через journalctl (nftables).
+func (sm *SecurityManager) GetFirewallLog(lines int) ([]FirewallLogEntry, error) {
+	if lines <= 0 {
+		lines = defaultFirewallLogLines
+	}
+	if _, err := os.Stat(ufwLogPath); err == nil {
+		return readFirewallLogFiles(ufwLogPath, lines)
+	}
+	if !commandExists("journalctl") {
+		return nil, errors.New("журнал фаервола не найден")
+	}
+	output, err := cmdRunner.Output("journalctl", "-k", "--no-pager", "-o", "short-iso",
+		"-g", "SRC=", "-n", strconv.Itoa(lines))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала ядра: %w", err)
+	}
+	entries, err := parseFirewallLog(strings.NewReader(string(output)), lines, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала ядра: %w", err)
+	}
+	return entries, nil
+}
+
+// readFirewallLogFiles читает журнал и ротированные копии от новых к старым,
+// пока не наберется lines записей
+func readFirewallLogFiles(base string, lines int) ([]FirewallLogEntry, error) {
+	candidates := []string{base}
+	for i := 1; i <= firewallLogRotations; i++ {
+		candidates = append(candidates, fmt.Sprintf("%s.%d", base, i), fmt.Sprintf("%s.%d.gz", base, i))
+	}
+
+	var result []FirewallLogEntry
+	for _, path := range candidates {
+		if len(result) >= lines {
+			break
+		}
+		entries, err := readFirewallLogFile(path, lines-len(result))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(entries, result...)
+	}
+	return result, nil
+}
+
+// readFirewallLogFile возвращает последние limit записей файла журнала
+func readFirewallLogFile(path string, limit int) ([]FirewallLogEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	// Год в записях syslog не указан - берем его из времени изменения файла
+	entries, err := parseFirewallLog(r, limit, info.ModTime())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// parseFirewallLog разбирает строки журнала и оставляет последние limit записей.
+// Строки без SRC= (не относящиеся к пакетам) пропускаются. Для времени без года
+// используется год из reference.
+func parseFirewallLog(r io.Reader, limit int, reference time.Time) ([]FirewallLogEntry, error) {
+	var entries []FirewallLogEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry, ok := parseFirewallLogLine(scanner.Text(), reference)
+		if !ok {
+			continue
+		}
+		entries = append(entries, entry)
+		if limit > 0 && len(entries) > 2*limit {
+			entries = append(entries[:0], entries[len(entries)-limit:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries, nil
+}
+
+// parseFirewallLogLine разбирает запись UFW или правила nftables с LOG:
+// "Oct 16 10:12:01 host kernel: [123.4] [UFW BLOCK] IN=eth0 ... SRC=1.2.3.4 ... PROTO=TCP SPT=5555 DPT=22"
+func parseFirewallLogLine(line string, reference time.Time) (FirewallLogEntry, bool) {
+	fields := make(map[string]string)
+	for _, match := range logFieldPattern.FindAllStringSubmatch(line, -1) {
+		if _, ok := fields[match[1]]; !ok {
+			fields[match[1]] = match[2]
+		}
+	}
+	if fields["SRC"] == "" {
+		return FirewallLogEntry{}, false
+	}
+
+	entry := FirewallLogEntry{
+		Time:  parseLogTime(line, reference),
+		SrcIP: fields["SRC"],
+		Proto: strings.ToLower(fields["PROTO"]),
+	}
+	// Ядро пишет IPv6 адреса полностью (2001:0db8:0000:...) - приводим к краткой форме
+	if addr, err := netip.ParseAddr(entry.SrcIP); err == nil {
+		entry.SrcIP = addr.String()
+	}
+	if port, err := strconv.Atoi(fields["DPT"]); err == nil {
+		entry.DstPort = port
+	}
+	if match := ufwActionPattern.FindStringSubmatch(line); match != nil {
+		entry.Action = strings.TrimSpace(match[1])
+	} else if match := kernelPrefixPattern.FindStringSubmatch(line); match != nil {
+		entry.Action = strings.Trim(match[1], "[]: ")
+	}
+	return entry, true
+}
+
+// parseLogTime разбирает время в начале записи: ISO 8601 (rsyslog, journalctl -o short-iso)
+// или традиционный формат syslog без года
+func parseLogTime(line string, reference time.Time) time.Time {
+	first, _, _ := strings.Cut(line, " ")
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05-0700", "2006-01-02T15:04:05.999999-0700"} {
+		if t, err := time.Parse(layout, first); err == nil {
+			return t
+		}
+	}
+
+	parts := strings.Fields(line)
+	if len(parts) < 3 {
+		return time.Time{}
+	}
+	t, err := time.ParseInLocation("Jan 2 15:04:05", strings.Join(parts[:3], " "), time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	t = t.AddDate(reference.Year(), 0, 0)
+	// Записи декабря в журнале, измененном в январе, относятся к прошлому году
+	if t.After(reference.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// isBlockedAction сообщает, означает ли действие записи отброшенный пакет
+func isBlockedAction(action string) bool {
+	action = strings.ToUpper(action)
+	for _, word := range []string{"BLOCK", "DROP", "REJECT", "DENY"} {
+		if strings.Contains(action, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// SummarizeFirewallLog подсчитывает заблокированные пакеты и возвращает top самых частых
+// адресов источников и портов назначения
+func SummarizeFirewallLog(entries []FirewallLogEntry, top int) FirewallLogSummary {
+	sources := make(map[string]int)
+	ports := make(map[string]int)
+	var summary FirewallLogSummary
+	for _, entry := range entries {
+		if !isBlockedAction(entry.Action) {
+			continue
+		}
+		summary.Blocked++
+		sources[entry.SrcIP]++
+		if entry.DstPort > 0 {
+			ports[fmt.Sprintf("%d/%s", entry.DstPort, entry.Proto)]++
+		}
+	}
+	summary.TopSources = topCounts(sources, top)
+	summary.TopPorts = topCounts(ports, top)
+	return summary
+}
+
+// topCounts возвращает top ключей с наибольшим счетчиком; при равенстве - по алфавиту
+func topCounts(counts map[string]int, top int) []FirewallLogCount {
+	result := make([]FirewallLogCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, FirewallLogCount{Key: key, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	if top > 0 && len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
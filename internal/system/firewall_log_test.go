@@ -0,0 +1,123 @@
+package system
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ufwLogSample - записи /var/log/ufw.log, в том числе IPv6 с полной формой адресов
+const ufwLogSample = `Oct 16 10:12:01 web1 kernel: [12345.678901] [UFW BLOCK] IN=eth0 OUT= MAC=52:54:00:12:34:56:52:54:00:65:43:21:08:00 SRC=203.0.113.5 DST=198.51.100.10 LEN=60 TOS=0x00 PREC=0x00 TTL=52 ID=4242 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0
+Oct 16 10:12:05 web1 kernel: [12349.100000] [UFW BLOCK] IN=eth0 OUT= MAC=52:54:00:12:34:56:52:54:00:65:43:21:86:dd SRC=2001:0db8:0000:0000:0000:0000:0000:0001 DST=2001:0db8:0000:0000:0000:0000:0000:00ff LEN=80 TC=0 HOPLIMIT=57 FLOWLBL=0 PROTO=TCP SPT=40000 DPT=443 WINDOW=64800 RES=0x00 SYN URGP=0
+Oct 16 10:12:09 web1 kernel: [12353.000000] [UFW BLOCK] IN=eth0 OUT= MAC=33:33:00:00:00:01:52:54:00:65:43:21:86:dd SRC=fe80:0000:0000:0000:0216:3eff:fe00:0001 DST=ff02:0000:0000:0000:0000:0000:0000:0001 LEN=64 TC=0 HOPLIMIT=255 FLOWLBL=0 PROTO=ICMPv6 TYPE=134 CODE=0
+Oct 16 10:12:30 web1 kernel: [12374.000000] [UFW LIMIT BLOCK] IN=eth0 OUT= MAC=52:54:00:12:34:56:52:54:00:65:43:21:08:00 SRC=203.0.113.5 DST=198.51.100.10 LEN=60 TOS=0x00 PREC=0x00 TTL=52 ID=4243 DF PROTO=TCP SPT=51240 DPT=22 WINDOW=64240 RES=0x00 SYN URGP=0
+Oct 16 10:12:31 web1 kernel: [12375.000000] [UFW] some unrelated message
+2024-10-16T10:13:00.123456+03:00 web1 kernel: [UFW ALLOW] IN=eth0 OUT= MAC=52:54:00:12:34:56:52:54:00:65:43:21:08:00 SRC=192.0.2.7 DST=198.51.100.10 LEN=72 PROTO=UDP SPT=5353 DPT=53 LEN=52
+2024-10-16T10:15:00+0300 web1 kernel: nft-drop: IN=eth0 OUT= SRC=198.51.100.77 DST=198.51.100.10 LEN=52 PROTO=TCP SPT=61000 DPT=3389
+`
+
+func TestParseFirewallLog(t *testing.T) {
+	reference := time.Date(2024, 10, 20, 0, 0, 0, 0, time.Local)
+	entries, err := parseFirewallLog(strings.NewReader(ufwLogSample), 0, reference)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msk := time.FixedZone("", 3*60*60)
+	want := []FirewallLogEntry{
+		{Time: time.Date(2024, 10, 16, 10, 12, 1, 0, time.Local), Action: "BLOCK", SrcIP: "203.0.113.5", DstPort: 22, Proto: "tcp"},
+		{Time: time.Date(2024, 10, 16, 10, 12, 5, 0, time.Local), Action: "BLOCK", SrcIP: "2001:db8::1", DstPort: 443, Proto: "tcp"},
+		{Time: time.Date(2024, 10, 16, 10, 12, 9, 0, time.Local), Action: "BLOCK", SrcIP: "fe80::216:3eff:fe00:1", Proto: "icmpv6"},
+		{Time: time.Date(2024, 10, 16, 10, 12, 30, 0, time.Local), Action: "LIMIT BLOCK", SrcIP: "203.0.113.5", DstPort: 22, Proto: "tcp"},
+		{Time: time.Date(2024, 10, 16, 10, 13, 0, 123456000, msk), Action: "ALLOW", SrcIP: "192.0.2.7", DstPort: 53, Proto: "udp"},
+		{Time: time.Date(2024, 10, 16, 10, 15, 0, 0, msk), Action: "nft-drop", SrcIP: "198.51.100.77", DstPort: 3389, Proto: "tcp"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("разобрано %d записей, ожидалось %d: %+v", len(entries), len(want), entries)
+	}
+	for i := range want {
+		got := entries[i]
+		if !got.Time.Equal(want[i].Time) {
+			t.Errorf("запись %d: время %v, ожидалось %v", i, got.Time, want[i].Time)
+		}
+		got.Time = want[i].Time
+		if got != want[i] {
+			t.Errorf("запись %d: %+v, ожидалось %+v", i, got, want[i])
+		}
+	}
+
+	// limit оставляет последние записи
+	last, _ := parseFirewallLog(strings.NewReader(ufwLogSample), 2, reference)
+	if len(last) != 2 || last[1].SrcIP != "198.51.100.77" {
+		t.Errorf("последние записи: %+v", last)
+	}
+}
+
+func TestParseLogTimeYearRollover(t *testing.T) {
+	// Журнал изменен в январе: декабрьские записи относятся к прошлому году
+	reference := time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local)
+	got := parseLogTime("Dec 31 23:59:59 web1 kernel: [UFW BLOCK] SRC=1.2.3.4", reference)
+	if want := time.Date(2024, 12, 31, 23, 59, 59, 0, time.Local); !got.Equal(want) {
+		t.Errorf("parseLogTime() = %v, ожидалось %v", got, want)
+	}
+}
+
+func TestSummarizeFirewallLog(t *testing.T) {
+	entries, _ := parseFirewallLog(strings.NewReader(ufwLogSample), 0, time.Now())
+	summary := SummarizeFirewallLog(entries, 2)
+	// ALLOW не считается заблокированным
+	if summary.Blocked != 5 {
+		t.Errorf("заблокировано %d", summary.Blocked)
+	}
+	if want := []FirewallLogCount{{"203.0.113.5", 2}, {"198.51.100.77", 1}}; !reflect.DeepEqual(summary.TopSources, want) {
+		t.Errorf("источники %+v, ожидалось %+v", summary.TopSources, want)
+	}
+	if want := []FirewallLogCount{{"22/tcp", 2}, {"3389/tcp", 1}}; !reflect.DeepEqual(summary.TopPorts, want) {
+		t.Errorf("порты %+v, ожидалось %+v", summary.TopPorts, want)
+	}
+}
+
+func TestGetFirewallLogReadsRotations(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "ufw.log")
+	line := func(day, src string) string {
+		return "Oct " + day + " 10:00:00 web1 kernel: [UFW BLOCK] IN=eth0 SRC=" + src + " PROTO=TCP DPT=22\n"
+	}
+	writeFixtures(t, dir, map[string]string{
+		"ufw.log":   line("16", "192.0.2.3") + line("16", "192.0.2.4"),
+		"ufw.log.1": line("15", "192.0.2.2"),
+	})
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	_, _ = zw.Write([]byte(line("14", "192.0.2.1")))
+	_ = zw.Close()
+	if err := os.WriteFile(base+".2.gz", gz.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	prev := ufwLogPath
+	ufwLogPath = base
+	t.Cleanup(func() { ufwLogPath = prev })
+
+	sm := &SecurityManager{}
+	sources := func(lines int) []string {
+		entries, err := sm.GetFirewallLog(lines)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var result []string
+		for _, e := range entries {
+			result = append(result, e.SrcIP)
+		}
+		return result
+	}
+	if got, want := sources(3), []string{"192.0.2.2", "192.0.2.3", "192.0.2.4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("3 записи: %q, ожидалось %q", got, want)
+	}
+	if got, want := sources(0), []string{"192.0.2.1", "192.0.2.2", "192.0.2.3", "192.0.2.4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("все записи: %q, ожидалось %q", got, want)
+	}
+}
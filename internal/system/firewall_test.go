@@ -0,0 +1,172 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+const ufwStatusVerbose = `Status: active
+Logging: on (low)
+Default: deny (incoming), allow (outgoing), disabled (routed)
+New profiles: skip
+
+To                         Action      From
+--                         ------      ----
+22/tcp                     ALLOW IN    Anywhere
+80,443/tcp                 ALLOW IN    Anywhere
+22/tcp (v6)                ALLOW IN    Anywhere (v6)
+`
+
+const firewalldListAll = `public (active)
+  target: default
+  icmp-block-inversion: no
+  interfaces: eth0
+  sources:
+  services: dhcpv6-client ssh
+  ports: 8080/tcp 53/udp
+  protocols:
+  forward: yes
+  masquerade: no
+  forward-ports:
+  source-ports:
+  icmp-blocks:
+  rich rules:
+	rule family="ipv4" source address="10.0.0.1" accept
+`
+
+const nftRuleset = `table inet filter {
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ct state established,related accept
+		iif "lo" accept
+		tcp dport 22 accept
+	}
+	chain output {
+		type filter hook output priority filter; policy accept;
+	}
+}
+`
+
+// useFirewallBackends оставляет в PATH только перечисленные фаерволы
+func useFirewallBackends(t *testing.T, fake *runner.FakeRunner, binaries ...string) {
+	t.Helper()
+	useEUID(t, 0)
+	for _, backend := range firewallBackends {
+		fake.Missing[backend.binary] = true
+	}
+	for _, binary := range binaries {
+		delete(fake.Missing, binary)
+	}
+	t.Cleanup(SetCommandRunner(fake))
+}
+
+func TestFirewallStatusBackends(t *testing.T) {
+	tests := []struct {
+		name   string
+		binary string
+		stubs  map[string]string
+		want   FirewallInfo
+	}{
+		{
+			name:   "ufw",
+			binary: "ufw",
+			stubs:  map[string]string{"ufw status verbose": ufwStatusVerbose},
+			want:   FirewallInfo{Backend: "ufw", Active: true, RuleCount: 3, DefaultIncoming: "deny"},
+		},
+		{
+			name:   "ufw inactive",
+			binary: "ufw",
+			stubs:  map[string]string{"ufw status verbose": "Status: inactive\n"},
+			want:   FirewallInfo{Backend: "ufw"},
+		},
+		{
+			name:   "firewalld",
+			binary: "firewall-cmd",
+			stubs: map[string]string{
+				"firewall-cmd --state":    "running\n",
+				"firewall-cmd --list-all": firewalldListAll,
+			},
+			want: FirewallInfo{Backend: "firewalld", Active: true, RuleCount: 5, DefaultIncoming: "reject"},
+		},
+		{
+			name:   "firewalld not running",
+			binary: "firewall-cmd",
+			stubs:  map[string]string{"firewall-cmd --state": "not running\n"},
+			want:   FirewallInfo{Backend: "firewalld"},
+		},
+		{
+			name:   "nftables",
+			binary: "nft",
+			stubs:  map[string]string{"nft list ruleset": nftRuleset},
+			want:   FirewallInfo{Backend: "nftables", Active: true, RuleCount: 3, DefaultIncoming: "drop"},
+		},
+		{
+			name:   "nftables empty",
+			binary: "nft",
+			stubs:  map[string]string{"nft list ruleset": ""},
+			want:   FirewallInfo{Backend: "nftables"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := runner.NewFakeRunner()
+			for key, output := range tt.stubs {
+				fake.On(key, output, nil)
+			}
+			useFirewallBackends(t, fake, tt.binary)
+
+			info, err := (&SecurityManager{}).FirewallStatus()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*info, tt.want) {
+				t.Errorf("FirewallStatus() = %+v, ожидалось %+v", *info, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirewallStatusPrefersActiveBackend(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("ufw status verbose", "Status: inactive\n", nil).
+		On("nft list ruleset", nftRuleset, nil)
+	useFirewallBackends(t, fake, "ufw", "nft")
+
+	info, err := (&SecurityManager{}).FirewallStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Backend != "nftables" || !info.Active {
+		t.Errorf("FirewallStatus() = %+v, ожидался активный nftables", *info)
+	}
+}
+
+func TestFirewallStatusErrors(t *testing.T) {
+	t.Run("нет фаервола", func(t *testing.T) {
+		useFirewallBackends(t, runner.NewFakeRunner())
+		if _, err := (&SecurityManager{}).FirewallStatus(); !errors.Is(err, ErrNoFirewall) {
+			t.Errorf("ошибка %v, ожидалась ErrNoFirewall", err)
+		}
+	})
+	t.Run("без root", func(t *testing.T) {
+		fake := runner.NewFakeRunner()
+		useFirewallBackends(t, fake, "ufw")
+		useEUID(t, 1000)
+		if _, err := (&SecurityManager{}).FirewallStatus(); !errors.Is(err, ErrRequiresRoot) {
+			t.Errorf("ошибка %v, ожидалась ErrRequiresRoot", err)
+		}
+		if len(fake.Commands()) != 0 {
+			t.Errorf("без root выполнены команды: %q", fake.Commands())
+		}
+	})
+	t.Run("ошибка запроса", func(t *testing.T) {
+		fake := runner.NewFakeRunner().On("ufw status verbose", "", errors.New("exit status 1"))
+		useFirewallBackends(t, fake, "ufw")
+		if _, err := (&SecurityManager{}).FirewallStatus(); err == nil {
+			t.Error("ожидалась ошибка получения статуса ufw")
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+)
+
+// vacuumFreedPattern находит итоговую строку journalctl:
+// "Vacuuming done, freed 1.2G of archived journals from /var/log/journal."
+var vacuumFreedPattern = regexp.MustCompile(`freed ([0-9.]+)([KMGTP]?)B? of archived journals`)
+
+// VacuumJournal сокращает журнал systemd по сроку хранения и/или размеру.
+// Возвращает количество освобожденных байт.
+func (su *SystemUtils) VacuumJournal(cfg appconfig.CleanConfig) (int64, error) {
+	if !commandExists("journalctl") {
+		return 0, nil
+	}
+	if cfg.JournalMaxAge == "" && cfg.JournalMaxSize == "" {
+		return 0, errors.New("не задан ни срок хранения, ни размер журнала")
+	}
+
+	args := []string{}
+	if cfg.JournalMaxAge != "" {
+		args = append(args, "--vacuum-time="+cfg.JournalMaxAge)
+	}
+	if cfg.JournalMaxSize != "" {
+		args = append(args, "--vacuum-size="+cfg.JournalMaxSize)
+	}
+
+	// journalctl пишет отчет об очистке в stderr
+	output, err := cmdRunner.CombinedOutput("journalctl", args...)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка очистки журнала: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return parseVacuumFreed(string(output)), nil
+}
+
+// parseVacuumFreed суммирует освобожденный объем по всем директориям журнала
+func parseVacuumFreed(output string) int64 {
+	var total int64
+	for _, match := range vacuumFreedPattern.FindAllStringSubmatch(output, -1) {
+		value, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			continue
+		}
+		total += int64(value * float64(sizeMultiplier(match[2])))
+	}
+	return total
+}
+
+// sizeMultiplier возвращает множитель для суффикса размера в формате systemd (степени 1024)
+func sizeMultiplier(suffix string) int64 {
+	switch suffix {
+	case "K":
+		return 1 << 10
+	case "M":
+		return 1 << 20
+	case "G":
+		return 1 << 30
+	case "T":
+		return 1 << 40
+	case "P":
+		return 1 << 50
+	default:
+		return 1
+	}
+}
+
+// formatSize форматирует размер в байтах для вывода
+func formatSize(bytes int64) string {
+	units := []string{"B", "K", "M", "G", "T"}
+	value := float64(bytes)
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d%s", bytes, units[0])
+	}
+	return fmt.Sprintf("%.1f%s", value, units[i])
+}
@@ -0,0 +1,83 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+const journalVacuumOutput = `Deleted archived journal /var/log/journal/3f2c/system@0005f1a2-0001.journal (128.0M).
+Deleted archived journal /var/log/journal/3f2c/user-1000@0005f1a2-0002.journal (8.0M).
+Vacuuming done, freed 136.0M of archived journals from /var/log/journal/3f2c.
+Vacuuming done, freed 0B of archived journals from /var/log/journal.
+Vacuuming done, freed 1.5G of archived journals from /run/log/journal.
+`
+
+func TestParseVacuumFreed(t *testing.T) {
+	tests := []struct {
+		output string
+		want   int64
+	}{
+		{journalVacuumOutput, 136<<20 + 3<<29},
+		{"Vacuuming done, freed 512K of archived journals from /var/log/journal.\n", 512 << 10},
+		{"Vacuuming done, freed 4096B of archived journals from /var/log/journal.\n", 4096},
+		{"Vacuuming done, freed 0B of archived journals from /var/log/journal.\n", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseVacuumFreed(tt.output); got != tt.want {
+			t.Errorf("parseVacuumFreed(%q) = %d, ожидалось %d", tt.output, got, tt.want)
+		}
+	}
+}
+
+func TestVacuumJournal(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  appconfig.CleanConfig
+		want string
+	}{
+		{"по сроку", appconfig.CleanConfig{JournalMaxAge: "3d"}, "journalctl --vacuum-time=3d"},
+		{"по размеру", appconfig.CleanConfig{JournalMaxSize: "500M"}, "journalctl --vacuum-size=500M"},
+		{"оба условия", appconfig.CleanConfig{JournalMaxAge: "2weeks", JournalMaxSize: "1G"},
+			"journalctl --vacuum-time=2weeks --vacuum-size=1G"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := runner.NewFakeRunner().On("journalctl", journalVacuumOutput, nil)
+			t.Cleanup(SetCommandRunner(fake))
+
+			freed, err := (&SystemUtils{}).VacuumJournal(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if freed != 136<<20+3<<29 {
+				t.Errorf("освобождено %d байт", freed)
+			}
+			if commands := fake.Commands(); !reflect.DeepEqual(commands, []string{tt.want}) {
+				t.Errorf("команды %q, ожидалось %q", commands, tt.want)
+			}
+		})
+	}
+}
+
+func TestVacuumJournalErrors(t *testing.T) {
+	fake := runner.NewFakeRunner().On("journalctl", "Failed to open journal directory\n", errors.New("exit status 1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	su := &SystemUtils{}
+	if _, err := su.VacuumJournal(appconfig.CleanConfig{}); err == nil {
+		t.Error("без срока и размера: ожидалась ошибка")
+	}
+	if _, err := su.VacuumJournal(appconfig.CleanConfig{JournalMaxAge: "3d"}); err == nil {
+		t.Error("ошибка journalctl не возвращена")
+	}
+
+	fake.Missing["journalctl"] = true
+	if freed, err := su.VacuumJournal(appconfig.CleanConfig{JournalMaxAge: "3d"}); freed != 0 || err != nil {
+		t.Errorf("без journalctl: %d, %v", freed, err)
+	}
+}
@@ -0,0 +1,89 @@
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// procLoadavgPath - источник средней загрузки
+const procLoadavgPath = "/proc/loadavg"
+
+// Пороги загрузки на одно ядро: ниже LoadPerCoreElevated процессор справляется
+// с запасом, от LoadPerCoreOverloaded процессы ждут своей очереди
+const (
+	LoadPerCoreElevated   = 0.7
+	LoadPerCoreOverloaded = 1.0
+)
+
+// LoadLevel - оценка загрузки относительно числа ядер
+type LoadLevel string
+
+// Уровни загрузки
+const (
+	LoadNormal     LoadLevel = "normal"
+	LoadElevated   LoadLevel = "elevated"
+	LoadOverloaded LoadLevel = "overloaded"
+)
+
+// LoadInfo содержит среднюю загрузку за 1, 5 и 15 минут и ее долю на одно ядро
+type LoadInfo struct {
+	One     float64
+	Five    float64
+	Fifteen float64
+	// Cores - число ядер, доступных процессу
+	Cores int
+	// PerCore - загрузка за минуту на одно ядро: 8.0 на 16 ядрах - это 0.5
+	PerCore float64
+}
+
+// Level оценивает загрузку за минуту на одно ядро
+func (l *LoadInfo) Level() LoadLevel {
+	switch {
+	case l.PerCore >= LoadPerCoreOverloaded:
+		return LoadOverloaded
+	case l.PerCore >= LoadPerCoreElevated:
+		return LoadElevated
+	default:
+		return LoadNormal
+	}
+}
+
+// GetLoadAverage читает среднюю загрузку из /proc/loadavg и делит ее на число ядер,
+// доступных процессу: сама по себе загрузка 8.0 ничего не говорит без числа ядер
+func (su *SystemUtils) GetLoadAverage() (*LoadInfo, error) {
+	return readLoadAverage(procLoadavgPath, runtime.NumCPU())
+}
+
+// readLoadAverage разбирает файл формата /proc/loadavg: "0.52 0.40 0.35 1/234 5678"
+func readLoadAverage(path string, cores int) (*LoadInfo, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("неожиданный формат %s: %q", path, strings.TrimSpace(string(data)))
+	}
+
+	var values [3]float64
+	for i := range values {
+		if values[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, fmt.Errorf("неожиданный формат %s: %w", path, err)
+		}
+	}
+
+	if cores < 1 {
+		cores = 1
+	}
+	return &LoadInfo{
+		One:     values[0],
+		Five:    values[1],
+		Fifteen: values[2],
+		Cores:   cores,
+		PerCore: values[0] / float64(cores),
+	}, nil
+}
@@ -0,0 +1,72 @@
+package system
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeLoadavg записывает фикстуру /proc/loadavg во временную директорию
+func writeLoadavg(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "loadavg")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadLoadAverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		cores   int
+		perCore float64
+		level   LoadLevel
+	}{
+		{"высокая загрузка на многих ядрах", "8.00 6.50 4.25 3/812 41234\n", 16, 0.5, LoadNormal},
+		{"та же загрузка на двух ядрах", "8.00 6.50 4.25 3/812 41234\n", 2, 4.0, LoadOverloaded},
+		{"повышенная", "2.80 2.10 1.90 1/200 100\n", 4, 0.7, LoadElevated},
+		{"на границе перегрузки", "4.00 3.00 2.00 1/200 100\n", 4, 1.0, LoadOverloaded},
+		{"чуть ниже повышенной", "0.69 0.50 0.40 1/200 100\n", 1, 0.69, LoadNormal},
+		{"неизвестное число ядер", "1.50 1.00 0.50 1/200 100\n", 0, 1.5, LoadOverloaded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			load, err := readLoadAverage(writeLoadavg(t, tt.content), tt.cores)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(load.PerCore-tt.perCore) > 1e-9 {
+				t.Errorf("PerCore = %v, ожидалось %v", load.PerCore, tt.perCore)
+			}
+			if level := load.Level(); level != tt.level {
+				t.Errorf("Level = %s, ожидалось %s", level, tt.level)
+			}
+		})
+	}
+}
+
+func TestReadLoadAverageFields(t *testing.T) {
+	load, err := readLoadAverage(writeLoadavg(t, "0.52 0.40 0.35 1/234 5678\n"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := LoadInfo{One: 0.52, Five: 0.40, Fifteen: 0.35, Cores: 4, PerCore: 0.13}
+	if load.One != want.One || load.Five != want.Five || load.Fifteen != want.Fifteen || load.Cores != want.Cores ||
+		math.Abs(load.PerCore-want.PerCore) > 1e-9 {
+		t.Errorf("readLoadAverage = %+v, ожидалось %+v", *load, want)
+	}
+}
+
+func TestReadLoadAverageErrors(t *testing.T) {
+	for _, content := range []string{"", "0.52 0.40\n", "0.52 abc 0.35 1/234 5678\n"} {
+		if _, err := readLoadAverage(writeLoadavg(t, content), 4); err == nil {
+			t.Errorf("%q: ожидалась ошибка", content)
+		}
+	}
+	if _, err := readLoadAverage(filepath.Join(t.TempDir(), "missing"), 4); err == nil {
+		t.Error("отсутствующий файл: ожидалась ошибка")
+	}
+}
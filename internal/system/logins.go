@@ -0,0 +1,134 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginRecord - одна запись wtmp или btmp
+type LoginRecord struct {
+	User string
+	TTY  string
+	// Host - адрес или имя удаленного хоста; пусто для локального входа
+	Host string
+	Time time.Time
+}
+
+// LoginSummary содержит последние входы и число неудачных попыток
+type LoginSummary struct {
+	// Recent - последние успешные входы, новые первыми
+	Recent []LoginRecord
+	// FailedCount - неудачные попытки за FailedWindow
+	FailedCount  int
+	FailedWindow time.Duration
+	// FailedUnavailable - btmp не прочитан: нет прав root, файла или утилиты lastb
+	FailedUnavailable bool
+}
+
+// FailedLoginWarnThreshold - число неудачных входов за окно, после которого стоит насторожиться
+const FailedLoginWarnThreshold = 20
+
+var (
+	// recentLoginCount - сколько последних входов показывать
+	recentLoginCount = 5
+	// failedLoginWindow - за какой период считать неудачные попытки
+	failedLoginWindow = 24 * time.Hour
+)
+
+// GetLoginSummary читает последние успешные входы (last, /var/log/wtmp) и считает неудачные
+// попытки за сутки (lastb, /var/log/btmp). btmp доступен только root: без прав
+// или без файла FailedUnavailable выставляется вместо ошибки.
+func GetLoginSummary() (*LoginSummary, error) {
+	// Запрашиваем с запасом: записи перезагрузок отбрасываются при разборе
+	output, err := cmdRunner.Output("env", cLocale("last", "-F", "-w", "-n", fmt.Sprint(recentLoginCount*4))...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения журнала входов: %w", err)
+	}
+	summary := &LoginSummary{FailedWindow: failedLoginWindow}
+	summary.Recent = parseLastOutput(string(output))
+	if len(summary.Recent) > recentLoginCount {
+		summary.Recent = summary.Recent[:recentLoginCount]
+	}
+
+	if RequireRoot() != nil {
+		summary.FailedUnavailable = true
+		return summary, nil
+	}
+	since := time.Now().Add(-failedLoginWindow)
+	output, err = cmdRunner.Output("env", cLocale("lastb", "-F", "-w", "--since", since.Format("2006-01-02 15:04:05"))...)
+	if err != nil {
+		summary.FailedUnavailable = true
+		return summary, nil
+	}
+	summary.FailedCount = countFailedLogins(parseLastOutput(string(output)), since)
+	return summary, nil
+}
+
+// parseLastOutput разбирает вывод last -F -w и lastb -F -w:
+//
+//	alice    pts/1        10.0.0.5         Tue Oct 13 18:01:02 2026 - Tue Oct 13 19:30:44 2026  (01:29)
+//	root     tty1                          Wed Oct 14 09:12:33 2026   still logged in
+//	reboot   system boot  6.1.0-13-amd64   Tue Oct 13 08:00:01 2026   still running
+//
+// Колонка хоста пуста для локального входа, поэтому время ищется по дню недели.
+// Записи перезагрузок и выключений, а также строка "wtmp begins" пропускаются.
+func parseLastOutput(output string) []LoginRecord {
+	var records []LoginRecord
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		switch fields[0] {
+		case "reboot", "shutdown", "runlevel":
+			continue
+		}
+		if strings.HasSuffix(fields[0], "tmp") && fields[1] == "begins" {
+			continue
+		}
+
+		at := loginTimeIndex(fields)
+		if at < 0 {
+			continue
+		}
+		when, err := time.ParseInLocation("Mon Jan 2 15:04:05 2006", strings.Join(fields[at:at+5], " "), time.Local)
+		if err != nil {
+			continue
+		}
+		record := LoginRecord{User: fields[0], Time: when}
+		if at > 1 {
+			record.TTY = fields[1]
+		}
+		if at > 2 {
+			record.Host = fields[2]
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// loginTimeIndex возвращает индекс начала времени входа (день недели и месяц) или -1
+func loginTimeIndex(fields []string) int {
+	for i := 1; i+5 <= len(fields); i++ {
+		if _, err := time.Parse("Mon", fields[i]); err != nil {
+			continue
+		}
+		if _, err := time.Parse("Jan", fields[i+1]); err == nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// countFailedLogins считает попытки не раньше since; время проверяется и после lastb --since,
+// чтобы граница окна не зависела от разбора времени утилитой
+func countFailedLogins(records []LoginRecord, since time.Time) int {
+	count := 0
+	for _, record := range records {
+		if !record.Time.Before(since) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,155 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+const lastOutput = `alice    pts/1        10.0.0.5         Tue Oct 13 18:01:02 2026 - Tue Oct 13 19:30:44 2026  (01:29)
+root     tty1                          Wed Oct 14 09:12:33 2026   still logged in
+reboot   system boot  6.1.0-13-amd64   Tue Oct 13 08:00:01 2026   still running
+bob      pts/0        2001:db8::1      Mon Oct 12 22:45:10 2026 - crash                     (09:14)
+shutdown system down  6.1.0-13-amd64   Mon Oct 12 07:59:58 2026 - Tue Oct 13 08:00:01 2026  (1+00:00)
+
+wtmp begins Thu Oct  1 00:00:01 2026
+`
+
+const lastbOutput = `admin    ssh:notty    203.0.113.9      Wed Oct 14 03:12:09 2026 - Wed Oct 14 03:12:09 2026  (00:00)
+root     ssh:notty    203.0.113.9      Wed Oct 14 03:12:05 2026 - Wed Oct 14 03:12:05 2026  (00:00)
+
+btmp begins Thu Oct  1 00:00:04 2026
+`
+
+// localTime возвращает время в местном часовом поясе, как его печатают last и lastb
+func localTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	when, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return when
+}
+
+func TestParseLastOutput(t *testing.T) {
+	want := []LoginRecord{
+		{User: "alice", TTY: "pts/1", Host: "10.0.0.5", Time: localTime(t, "2026-10-13 18:01:02")},
+		{User: "root", TTY: "tty1", Time: localTime(t, "2026-10-14 09:12:33")},
+		{User: "bob", TTY: "pts/0", Host: "2001:db8::1", Time: localTime(t, "2026-10-12 22:45:10")},
+	}
+	if got := parseLastOutput(lastOutput); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseLastOutput:\n%+v\nожидалось:\n%+v", got, want)
+	}
+}
+
+func TestParseLastbOutput(t *testing.T) {
+	want := []LoginRecord{
+		{User: "admin", TTY: "ssh:notty", Host: "203.0.113.9", Time: localTime(t, "2026-10-14 03:12:09")},
+		{User: "root", TTY: "ssh:notty", Host: "203.0.113.9", Time: localTime(t, "2026-10-14 03:12:05")},
+	}
+	if got := parseLastOutput(lastbOutput); !reflect.DeepEqual(got, want) {
+		t.Fatalf("parseLastOutput:\n%+v\nожидалось:\n%+v", got, want)
+	}
+}
+
+func TestCountFailedLogins(t *testing.T) {
+	records := parseLastOutput(lastbOutput)
+	tests := map[string]int{
+		"2026-10-14 03:00:00": 2,
+		// Граница окна включается
+		"2026-10-14 03:12:09": 1,
+		"2026-10-14 04:00:00": 0,
+	}
+	for since, want := range tests {
+		if got := countFailedLogins(records, localTime(t, since)); got != want {
+			t.Errorf("с %s: %d попыток, ожидалось %d", since, got, want)
+		}
+	}
+}
+
+// lastLine форматирует строку lastb -F -w для попытки входа в момент when
+func lastLine(user string, when time.Time) string {
+	stamp := when.Format("Mon Jan 2 15:04:05 2006")
+	return fmt.Sprintf("%-8s ssh:notty    198.51.100.7     %s - %s  (00:00)\n", user, stamp, stamp)
+}
+
+func TestGetLoginSummary(t *testing.T) {
+	useEUID(t, 0)
+	now := time.Now()
+	failed := lastLine("admin", now.Add(-time.Hour)) +
+		lastLine("test", now.Add(-2*time.Hour)) +
+		lastLine("oracle", now.Add(-48*time.Hour))
+
+	var recent strings.Builder
+	for i := 0; i < recentLoginCount+2; i++ {
+		fmt.Fprintf(&recent, "user%d    pts/%d        10.0.0.%d         Tue Oct 13 18:0%d:00 2026   still logged in\n", i, i, i, i)
+	}
+	fake := runner.NewFakeRunner().
+		On(fmt.Sprintf("env LC_ALL=C last -F -w -n %d", recentLoginCount*4), recent.String(), nil).
+		On("env", failed, nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	summary, err := GetLoginSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Recent) != recentLoginCount || summary.Recent[0].User != "user0" {
+		t.Errorf("Recent = %+v, ожидалось %d последних входов", summary.Recent, recentLoginCount)
+	}
+	if summary.FailedUnavailable || summary.FailedCount != 2 {
+		t.Errorf("FailedCount = %d, FailedUnavailable = %v, ожидалось 2 попытки за сутки",
+			summary.FailedCount, summary.FailedUnavailable)
+	}
+}
+
+func TestGetLoginSummaryWithoutBtmp(t *testing.T) {
+	tests := []struct {
+		name string
+		uid  int
+		err  error
+	}{
+		{"non-root", 1000, nil},
+		{"btmp missing", 0, errors.New("lastb: cannot open /var/log/btmp: No such file or directory")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useEUID(t, tt.uid)
+			fake := runner.NewFakeRunner().
+				On(fmt.Sprintf("env LC_ALL=C last -F -w -n %d", recentLoginCount*4), lastOutput, nil).
+				On("env", "", tt.err)
+			t.Cleanup(SetCommandRunner(fake))
+
+			summary, err := GetLoginSummary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !summary.FailedUnavailable || summary.FailedCount != 0 {
+				t.Errorf("summary = %+v, ожидалось FailedUnavailable", summary)
+			}
+			if len(summary.Recent) != 3 {
+				t.Errorf("Recent = %+v, ожидалось 3 входа", summary.Recent)
+			}
+			if tt.uid != 0 {
+				for _, command := range fake.Commands() {
+					if strings.Contains(command, "lastb") {
+						t.Errorf("без root запущен %q", command)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGetLoginSummaryLastFails(t *testing.T) {
+	fake := runner.NewFakeRunner().On("env", "", errors.New("last: cannot open /var/log/wtmp"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	if _, err := GetLoginSummary(); err == nil {
+		t.Fatal("ожидалась ошибка чтения wtmp")
+	}
+}
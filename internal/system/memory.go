@@ -0,0 +1,146 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Пути к источникам информации о памяти
+const (
+	procMeminfoPath = "/proc/meminfo"
+	cgroupRootPath  = "/sys/fs/cgroup"
+)
+
+// MemoryInfo содержит сведения о доступной процессу памяти
+type MemoryInfo struct {
+	Total uint64
+	Used  uint64
+	// Limited - объем ограничен cgroup (контейнер) и меньше памяти хоста
+	Limited bool
+	// Source - источник данных: host, cgroup v1 или cgroup v2
+	Source string
+}
+
+// UsedPercent возвращает долю занятой памяти в процентах
+func (m *MemoryInfo) UsedPercent() float64 {
+	if m.Total == 0 {
+		return 0
+	}
+	return float64(m.Used) * 100 / float64(m.Total)
+}
+
+// GetMemoryInfo возвращает объем памяти с учетом ограничений cgroup.
+// В контейнере /proc/meminfo показывает память хоста, поэтому при лимите cgroup
+// ниже памяти хоста используются лимит и потребление cgroup.
+func (su *SystemUtils) GetMemoryInfo() (*MemoryInfo, error) {
+	return readMemoryInfo(procMeminfoPath, cgroupRootPath)
+}
+
+func readMemoryInfo(meminfoPath, cgroupRoot string) (*MemoryInfo, error) {
+	host, err := readHostMemory(meminfoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, usage, source, ok := readCgroupMemory(cgroupRoot)
+	if !ok || limit >= host.Total {
+		return host, nil
+	}
+	return &MemoryInfo{Total: limit, Used: usage, Limited: true, Source: source}, nil
+}
+
+// readHostMemory читает MemTotal и MemAvailable из /proc/meminfo
+func readHostMemory(path string) (*MemoryInfo, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// MemTotal:       16318708 kB
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) == 3 && fields[2] == "kB" {
+			value *= 1024
+		}
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+
+	total, ok := values["MemTotal"]
+	if !ok {
+		return nil, fmt.Errorf("в %s нет MemTotal", path)
+	}
+	available, ok := values["MemAvailable"]
+	if !ok {
+		available = values["MemFree"] + values["Buffers"] + values["Cached"]
+	}
+	used := uint64(0)
+	if available < total {
+		used = total - available
+	}
+	return &MemoryInfo{Total: total, Used: used, Source: "host"}, nil
+}
+
+// readCgroupMemory читает лимит и потребление памяти cgroup v2 или v1.
+// Отсутствие лимита ("max" в v2) дает ok == false.
+func readCgroupMemory(root string) (limit, usage uint64, source string, ok bool) {
+	// cgroup v2: единая иерархия
+	if raw, err := readCgroupValue(filepath.Join(root, "memory.max")); err == nil {
+		if raw == "max" {
+			return 0, 0, "", false
+		}
+		limit, err = strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return 0, 0, "", false
+		}
+		usage = readCgroupUint(filepath.Join(root, "memory.current"))
+		return limit, usage, "cgroup v2", true
+	}
+
+	// cgroup v1: контроллер памяти в отдельной иерархии.
+	// Без лимита v1 сообщает огромное число, которое отсеивается сравнением с памятью хоста.
+	v1 := filepath.Join(root, "memory")
+	raw, err := readCgroupValue(filepath.Join(v1, "memory.limit_in_bytes"))
+	if err != nil {
+		return 0, 0, "", false
+	}
+	limit, err = strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, 0, "", false
+	}
+	usage = readCgroupUint(filepath.Join(v1, "memory.usage_in_bytes"))
+	return limit, usage, "cgroup v1", true
+}
+
+func readCgroupValue(path string) (string, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readCgroupUint(path string) uint64 {
+	raw, err := readCgroupValue(path)
+	if err != nil {
+		return 0
+	}
+	value, _ := strconv.ParseUint(raw, 10, 64)
+	return value
+}
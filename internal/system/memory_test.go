@@ -0,0 +1,122 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// hostMeminfo - /proc/meminfo хоста с 16 ГБ памяти, из которых доступно 12 ГБ
+const hostMeminfo = `MemTotal:       16777216 kB
+MemFree:         4194304 kB
+MemAvailable:   12582912 kB
+Buffers:          524288 kB
+Cached:          6291456 kB
+`
+
+// writeFixtures создает файлы относительно dir
+func writeFixtures(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestReadMemoryInfo(t *testing.T) {
+	const gib = 1 << 30
+	host := &MemoryInfo{Total: 16 * gib, Used: 4 * gib, Source: "host"}
+
+	tests := []struct {
+		name   string
+		cgroup map[string]string
+		want   *MemoryInfo
+	}{
+		{
+			name:   "cgroup v2 с лимитом",
+			cgroup: map[string]string{"memory.max": "2147483648\n", "memory.current": "536870912\n"},
+			want:   &MemoryInfo{Total: 2 * gib, Used: gib / 2, Limited: true, Source: "cgroup v2"},
+		},
+		{
+			name:   "cgroup v2 без лимита",
+			cgroup: map[string]string{"memory.max": "max\n", "memory.current": "536870912\n"},
+			want:   host,
+		},
+		{
+			name:   "cgroup v2 с лимитом выше памяти хоста",
+			cgroup: map[string]string{"memory.max": "34359738368\n", "memory.current": "536870912\n"},
+			want:   host,
+		},
+		{
+			name: "cgroup v1 с лимитом",
+			cgroup: map[string]string{
+				"memory/memory.limit_in_bytes": "1073741824\n",
+				"memory/memory.usage_in_bytes": "268435456\n",
+			},
+			want: &MemoryInfo{Total: gib, Used: gib / 4, Limited: true, Source: "cgroup v1"},
+		},
+		{
+			name: "cgroup v1 без лимита",
+			cgroup: map[string]string{
+				"memory/memory.limit_in_bytes": "9223372036854771712\n",
+				"memory/memory.usage_in_bytes": "268435456\n",
+			},
+			want: host,
+		},
+		{
+			name:   "без cgroup",
+			cgroup: nil,
+			want:   host,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFixtures(t, dir, map[string]string{"meminfo": hostMeminfo})
+			cgroupRoot := filepath.Join(dir, "cgroup")
+			writeFixtures(t, cgroupRoot, tt.cgroup)
+
+			got, err := readMemoryInfo(filepath.Join(dir, "meminfo"), cgroupRoot)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readMemoryInfo() = %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadHostMemoryWithoutMemAvailable(t *testing.T) {
+	dir := t.TempDir()
+	writeFixtures(t, dir, map[string]string{"meminfo": "MemTotal: 1000 kB\nMemFree: 200 kB\nBuffers: 100 kB\nCached: 300 kB\n"})
+
+	got, err := readHostMemory(filepath.Join(dir, "meminfo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Total != 1000*1024 || got.Used != 400*1024 {
+		t.Errorf("readHostMemory() = %+v, ожидалось 1000 kB всего и 400 kB занято", got)
+	}
+	if got.UsedPercent() != 40 {
+		t.Errorf("UsedPercent() = %v, ожидалось 40", got.UsedPercent())
+	}
+}
+
+func TestReadHostMemoryErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFixtures(t, dir, map[string]string{"meminfo": "MemFree: 200 kB\n"})
+
+	if _, err := readHostMemory(filepath.Join(dir, "meminfo")); err == nil {
+		t.Error("ожидалась ошибка без MemTotal")
+	}
+	if _, err := readHostMemory(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ожидалась ошибка для отсутствующего файла")
+	}
+}
@@ -0,0 +1,147 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OrphanReport содержит пакеты, которые менеджер удалит как неиспользуемые
+type OrphanReport struct {
+	Manager  string
+	Packages []string
+	// FreedBytes - оценка освобождаемого места, 0 если менеджер ее не сообщает
+	FreedBytes int64
+}
+
+// orphanChecks содержит команды, перечисляющие кандидатов на удаление без удаления
+var orphanChecks = map[string]string{
+	"apt":    "LC_ALL=C apt-get autoremove --dry-run",
+	"dnf":    "LC_ALL=C dnf autoremove --assumeno",
+	"yum":    "LC_ALL=C yum autoremove --assumeno",
+	"pacman": "pacman -Qdtq",
+	"zypper": "LC_ALL=C zypper --non-interactive packages --unneeded",
+}
+
+var (
+	aptFreedPattern = regexp.MustCompile(`After this operation, ([0-9.,]+) ([kMG]?B) disk space will be freed`)
+	dnfFreedPattern = regexp.MustCompile(`Freed space: ([0-9.]+) ?([kMG]?)`)
+)
+
+// GetOrphanedPackages возвращает пакеты, которые будут удалены как неиспользуемые зависимости
+func GetOrphanedPackages(pm *PackageManager) ([]string, error) {
+	report, err := GetOrphanReport(pm)
+	if err != nil {
+		return nil, err
+	}
+	return report.Packages, nil
+}
+
+// GetOrphanReport возвращает кандидатов на удаление и оценку освобождаемого места, ничего не удаляя
+func GetOrphanReport(pm *PackageManager) (*OrphanReport, error) {
+	cmd, ok := orphanChecks[pm.Name]
+	if !ok {
+		return nil, fmt.Errorf("поиск неиспользуемых пакетов не поддерживается для %s", pm.Name)
+	}
+
+	output, err := shellOutput(cmd)
+	var exitErr *exec.ExitError
+	// dnf/yum с --assumeno завершаются с ошибкой отмены, pacman - если неиспользуемых пакетов нет
+	if err != nil && !(errors.As(err, &exitErr) && pm.Name != "apt" && pm.Name != "zypper") {
+		return nil, fmt.Errorf("ошибка поиска неиспользуемых пакетов: %w", err)
+	}
+
+	return parseOrphans(pm.Name, string(output)), nil
+}
+
+// parseOrphans разбирает вывод пробного удаления конкретного менеджера
+func parseOrphans(manager, output string) *OrphanReport {
+	report := &OrphanReport{Manager: manager}
+
+	switch manager {
+	case "apt":
+		// Remv libfoo1 [1.2-3]
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "Remv" {
+				report.Packages = append(report.Packages, fields[1])
+			}
+		}
+		if m := aptFreedPattern.FindStringSubmatch(output); m != nil {
+			report.FreedBytes = parseDecimalSize(strings.ReplaceAll(m[1], ",", ""), strings.TrimSuffix(m[2], "B"), 1000)
+		}
+	case "dnf", "yum":
+		report.Packages = parseDnfRemoving(output)
+		if m := dnfFreedPattern.FindStringSubmatch(output); m != nil {
+			report.FreedBytes = parseDecimalSize(m[1], m[2], 1024)
+		}
+	case "pacman":
+		report.Packages = strings.Fields(output)
+	case "zypper":
+		// i | repo | name | version | arch
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Split(line, "|")
+			if len(fields) < 5 || !strings.HasPrefix(strings.TrimSpace(fields[0]), "i") {
+				continue
+			}
+			report.Packages = append(report.Packages, strings.TrimSpace(fields[2]))
+		}
+	}
+
+	return report
+}
+
+// parseDnfRemoving извлекает имена пакетов из секций "Removing..." транзакции dnf/yum.
+// Длинные имена dnf переносит на отдельную строку, остальные колонки идут следующей строкой.
+func parseDnfRemoving(output string) []string {
+	var (
+		packages []string
+		inside   bool
+		pending  string
+	)
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "Transaction Summary"):
+			return packages
+		case strings.HasPrefix(trimmed, "Removing"):
+			inside = true
+			continue
+		case !inside || trimmed == "" || strings.HasSuffix(trimmed, ":"):
+			continue
+		}
+
+		fields := strings.Fields(trimmed)
+		switch {
+		case len(fields) == 1:
+			pending = fields[0]
+		case pending != "":
+			packages = append(packages, pending)
+			pending = ""
+		case len(fields) >= 5:
+			packages = append(packages, fields[0])
+		}
+	}
+	return packages
+}
+
+// parseDecimalSize переводит размер с суффиксом k/M/G в байты с заданным основанием
+func parseDecimalSize(value, suffix string, base int64) int64 {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	multiplier := int64(1)
+	switch strings.ToUpper(suffix) {
+	case "K":
+		multiplier = base
+	case "M":
+		multiplier = base * base
+	case "G":
+		multiplier = base * base * base
+	}
+	return int64(number * float64(multiplier))
+}
@@ -0,0 +1,106 @@
+package system
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+const aptAutoremoveDryRun = `Reading package lists...
+Building dependency tree...
+Reading state information...
+The following packages will be REMOVED:
+  libfoo1 linux-headers-5.15.0-88
+0 upgraded, 0 newly installed, 2 to remove and 0 not upgraded.
+After this operation, 1,234 kB disk space will be freed.
+Remv libfoo1 [1.2-3]
+Remv linux-headers-5.15.0-88 [5.15.0-88.98]
+`
+
+const dnfAutoremoveAssumeno = `Last metadata expiration check: 0:10:13 ago on Mon 01 Jan 2024 10:00:00 AM UTC.
+Dependencies resolved.
+================================================================================
+ Package                          Arch     Version          Repository     Size
+================================================================================
+Removing:
+ libfoo                           x86_64   1.2-3.fc39       @fedora       120 k
+ python3-very-long-package-name-that-wraps
+                                  noarch   4.5-6.fc39       @updates      2.1 M
+Removing unused dependencies:
+ libbar                           x86_64   0.9-1.fc39       @fedora        45 k
+
+Transaction Summary
+================================================================================
+Remove  3 Packages
+
+Freed space: 2.3 M
+Operation aborted.
+`
+
+const zypperUnneeded = `Loading repository data...
+Reading installed packages...
+S  | Repository | Name    | Version | Arch
+---+------------+---------+---------+-------
+i  | @System    | libfoo1 | 1.2-3.1 | x86_64
+i+ | @System    | oldtool | 0.1-1.1 | noarch
+`
+
+func TestParseOrphans(t *testing.T) {
+	dnfFreed := 2.3
+	tests := []struct {
+		manager string
+		output  string
+		want    *OrphanReport
+	}{
+		{"apt", aptAutoremoveDryRun, &OrphanReport{
+			Manager: "apt", Packages: []string{"libfoo1", "linux-headers-5.15.0-88"}, FreedBytes: 1234000}},
+		{"apt", "0 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.\n", &OrphanReport{Manager: "apt"}},
+		{"dnf", dnfAutoremoveAssumeno, &OrphanReport{
+			Manager:    "dnf",
+			Packages:   []string{"libfoo", "python3-very-long-package-name-that-wraps", "libbar"},
+			FreedBytes: int64(dnfFreed * (1 << 20)),
+		}},
+		{"yum", "No packages marked for removal.\n", &OrphanReport{Manager: "yum"}},
+		{"pacman", "libfoo\nlibbar\n", &OrphanReport{Manager: "pacman", Packages: []string{"libfoo", "libbar"}}},
+		{"pacman", "", &OrphanReport{Manager: "pacman"}},
+		{"zypper", zypperUnneeded, &OrphanReport{Manager: "zypper", Packages: []string{"libfoo1", "oldtool"}}},
+	}
+	for _, tt := range tests {
+		got := parseOrphans(tt.manager, tt.output)
+		if len(got.Packages) == 0 {
+			// Пустой список и nil равнозначны
+			got.Packages = nil
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %+v, ожидалось %+v", tt.manager, got, tt.want)
+		}
+	}
+}
+
+func TestGetOrphanReportDnfAssumeno(t *testing.T) {
+	// dnf с --assumeno завершается с кодом 1 после отмены транзакции
+	fake := runner.NewFakeRunner().
+		On("sh -c LC_ALL=C dnf autoremove --assumeno", dnfAutoremoveAssumeno, exitStatus(t, "1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	packages, err := GetOrphanedPackages(&PackageManager{Name: "dnf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"libfoo", "python3-very-long-package-name-that-wraps", "libbar"}; !reflect.DeepEqual(packages, want) {
+		t.Errorf("пакеты %q, ожидалось %q", packages, want)
+	}
+}
+
+func TestGetOrphanReportErrors(t *testing.T) {
+	fake := runner.NewFakeRunner().On("sh -c LC_ALL=C apt-get autoremove --dry-run", "", exitStatus(t, "100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	if _, err := GetOrphanReport(&PackageManager{Name: "apt"}); err == nil {
+		t.Error("ошибка apt-get не возвращена")
+	}
+	if _, err := GetOrphanReport(&PackageManager{Name: "apk"}); err == nil {
+		t.Error("неподдерживаемый менеджер: ожидалась ошибка")
+	}
+}
@@ -0,0 +1,130 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// osReleasePaths - расположение os-release по порядку проверки (os-release(5))
+var osReleasePaths = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+// OSRelease содержит поля /etc/os-release
+type OSRelease struct {
+	ID              string
+	IDLike          []string
+	Name            string
+	PrettyName      string
+	Version         string
+	VersionID       string
+	VersionCodename string
+	HomeURL         string
+	// Fields содержит все пары ключ-значение, включая перечисленные выше
+	Fields map[string]string
+}
+
+// familyManagers сопоставляет ID дистрибутива или его родителя из ID_LIKE с менеджером пакетов
+var familyManagers = map[string]string{
+	"debian":   "apt",
+	"ubuntu":   "apt",
+	"fedora":   "dnf",
+	"rhel":     "dnf",
+	"centos":   "dnf",
+	"arch":     "pacman",
+	"suse":     "zypper",
+	"opensuse": "zypper",
+	"alpine":   "apk",
+}
+
+// ParseOSRelease читает и разбирает /etc/os-release (или /usr/lib/os-release)
+func ParseOSRelease() (*OSRelease, error) {
+	for _, path := range osReleasePaths {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения %s: %w", path, err)
+		}
+		defer f.Close()
+		return parseOSRelease(f)
+	}
+	return nil, fmt.Errorf("файл os-release не найден")
+}
+
+// parseOSRelease разбирает строки KEY=value; значения могут быть в кавычках
+// и содержать экранированные символы
+func parseOSRelease(r io.Reader) (*OSRelease, error) {
+	release := &OSRelease{Fields: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		release.Fields[key] = unquoteOSReleaseValue(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения os-release: %w", err)
+	}
+
+	f := release.Fields
+	release.ID = strings.ToLower(f["ID"])
+	release.IDLike = strings.Fields(strings.ToLower(f["ID_LIKE"]))
+	release.Name = f["NAME"]
+	release.PrettyName = f["PRETTY_NAME"]
+	release.Version = f["VERSION"]
+	release.VersionID = f["VERSION_ID"]
+	release.VersionCodename = f["VERSION_CODENAME"]
+	release.HomeURL = f["HOME_URL"]
+	return release, nil
+}
+
+// unquoteOSReleaseValue снимает кавычки и экранирование в стиле shell
+func unquoteOSReleaseValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		quote := value[0]
+		value = value[1 : len(value)-1]
+		if quote == '\'' {
+			return value
+		}
+	}
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		if r == '\\' && !escaped {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Lineage возвращает ID дистрибутива и его родителей из ID_LIKE по порядку
+func (r *OSRelease) Lineage() []string {
+	return uniqueStrings(append([]string{r.ID}, r.IDLike...))
+}
+
+// Family возвращает ближайший известный дистрибутив из ID и ID_LIKE:
+// для Linux Mint это ubuntu, для Rocky - rhel. Пустая строка - семейство неизвестно.
+func (r *OSRelease) Family() string {
+	for _, id := range r.Lineage() {
+		if _, ok := familyManagers[id]; ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// PackageManagerName возвращает менеджер пакетов, принятый в семействе дистрибутива
+func (r *OSRelease) PackageManagerName() string {
+	return familyManagers[r.Family()]
+}
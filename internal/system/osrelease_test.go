@@ -0,0 +1,135 @@
+package system
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// osReleaseFixtures - /etc/os-release производных дистрибутивов
+var osReleaseFixtures = map[string]string{
+	"linuxmint": `NAME="Linux Mint"
+VERSION="21.2 (Victoria)"
+ID=linuxmint
+ID_LIKE="ubuntu debian"
+PRETTY_NAME="Linux Mint 21.2"
+VERSION_ID="21.2"
+HOME_URL="https://www.linuxmint.com/"
+VERSION_CODENAME=victoria
+UBUNTU_CODENAME=jammy
+`,
+	"pop": `NAME="Pop!_OS"
+VERSION="22.04 LTS"
+ID=pop
+ID_LIKE="ubuntu debian"
+PRETTY_NAME="Pop!_OS 22.04 LTS"
+VERSION_ID="22.04"
+VERSION_CODENAME=jammy
+`,
+	"rocky": `NAME="Rocky Linux"
+VERSION="9.3 (Blue Onyx)"
+ID="rocky"
+ID_LIKE="rhel centos fedora"
+VERSION_ID="9.3"
+PRETTY_NAME="Rocky Linux 9.3 (Blue Onyx)"
+`,
+	"manjaro": `NAME="Manjaro Linux"
+ID=manjaro
+ID_LIKE=arch
+PRETTY_NAME="Manjaro Linux"
+`,
+	"opensuse-tumbleweed": `NAME="openSUSE Tumbleweed"
+ID="opensuse-tumbleweed"
+ID_LIKE="opensuse suse"
+VERSION_ID="20240101"
+`,
+}
+
+func TestOSReleaseFamily(t *testing.T) {
+	tests := []struct {
+		fixture, family, manager string
+	}{
+		{"linuxmint", "ubuntu", "apt"},
+		{"pop", "ubuntu", "apt"},
+		{"rocky", "rhel", "dnf"},
+		{"manjaro", "arch", "pacman"},
+		{"opensuse-tumbleweed", "opensuse", "zypper"},
+	}
+	for _, tt := range tests {
+		release, err := parseOSRelease(strings.NewReader(osReleaseFixtures[tt.fixture]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if release.ID != tt.fixture {
+			t.Errorf("%s: ID = %q", tt.fixture, release.ID)
+		}
+		if family := release.Family(); family != tt.family {
+			t.Errorf("%s: Family() = %q, ожидалось %q", tt.fixture, family, tt.family)
+		}
+		if manager := release.PackageManagerName(); manager != tt.manager {
+			t.Errorf("%s: PackageManagerName() = %q, ожидалось %q", tt.fixture, manager, tt.manager)
+		}
+	}
+
+	unknown, _ := parseOSRelease(strings.NewReader("ID=nixos\n"))
+	if unknown.Family() != "" || unknown.PackageManagerName() != "" {
+		t.Errorf("неизвестный дистрибутив: %q, %q", unknown.Family(), unknown.PackageManagerName())
+	}
+}
+
+func TestParseOSReleaseFields(t *testing.T) {
+	release, err := parseOSRelease(strings.NewReader(osReleaseFixtures["linuxmint"] + `# комментарий
+ANSI_COLOR='0;32'
+BUG_REPORT_URL="https://example.org/\"bugs\""
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &OSRelease{
+		ID:              "linuxmint",
+		IDLike:          []string{"ubuntu", "debian"},
+		Name:            "Linux Mint",
+		PrettyName:      "Linux Mint 21.2",
+		Version:         "21.2 (Victoria)",
+		VersionID:       "21.2",
+		VersionCodename: "victoria",
+		HomeURL:         "https://www.linuxmint.com/",
+	}
+	fields := release.Fields
+	release.Fields = nil
+	if !reflect.DeepEqual(release, want) {
+		t.Errorf("parseOSRelease() = %+v, ожидалось %+v", release, want)
+	}
+	// Поля без отдельного свойства тоже сохраняются
+	if fields["UBUNTU_CODENAME"] != "jammy" || fields["ANSI_COLOR"] != "0;32" || fields["BUG_REPORT_URL"] != `https://example.org/"bugs"` {
+		t.Errorf("Fields = %q", fields)
+	}
+	if lineage := (&OSRelease{ID: "ubuntu", IDLike: []string{"debian", "ubuntu"}}).Lineage(); !reflect.DeepEqual(lineage, []string{"ubuntu", "debian"}) {
+		t.Errorf("Lineage() = %q", lineage)
+	}
+}
+
+func TestDetectPackageManagerUsesFamily(t *testing.T) {
+	// В системе есть и apt, и pacman: Manjaro должен получить менеджер семейства arch
+	fake := runner.NewFakeRunner()
+	for _, manager := range packageManagerOrder {
+		fake.Missing[manager] = manager != "apt" && manager != "pacman"
+	}
+	t.Cleanup(SetCommandRunner(fake))
+
+	release, _ := parseOSRelease(strings.NewReader(osReleaseFixtures["manjaro"]))
+	pm, err := detectPackageManager(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.Name != "pacman" || pm.Family != "arch" {
+		t.Errorf("менеджер %s, семейство %q", pm.Name, pm.Family)
+	}
+
+	// Без os-release выбирается первый менеджер по порядку
+	if pm, _ := detectPackageManager(nil); pm.Name != "apt" || pm.Family != "" {
+		t.Errorf("без os-release: %s, %q", pm.Name, pm.Family)
+	}
+}
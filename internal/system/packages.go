@@ -4,13 +4,13 @@ package system
 import (
 	"errors"
 	"fmt"
-	"os/exec"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/briandowns/spinner"
-	"github.com/schollz/progressbar/v3"
+	appconfig "github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/ui"
 )
 
 // PackageManager представляет менеджер пакетов
@@ -22,6 +22,14 @@ type PackageManager struct {
 	Remove  string
 	Clean   string
 	Check   string
+	// Family - семейство дистрибутива из os-release (debian, ubuntu, rhel...); пусто, если не определено
+	Family string
+	// State кэширует проверки установленных пакетов в рамках запуска; nil отключает кэш
+	State *PackageStateCache
+	// RefreshAttempts - число попыток обновления списка пакетов; ноль означает 3
+	RefreshAttempts int
+	// RefreshBackoff - пауза перед повтором обновления, удваивается с каждой попыткой; ноль означает 2с
+	RefreshBackoff time.Duration
 }
 
 // PackageCategory представляет категорию пакетов
@@ -118,50 +126,123 @@ var (
 	}
 )
 
-// Detect определяет менеджер пакетов системы
+// packageManagerOrder задает порядок проверки менеджеров пакетов,
+// чтобы на системах с несколькими менеджерами (dnf и yum) выбор был однозначным
+var packageManagerOrder = []string{"apt", "dnf", "yum", "pacman", "zypper", "apk"}
+
+// Detect определяет менеджер пакетов системы.
+// Сначала проверяется менеджер семейства дистрибутива по ID и ID_LIKE из os-release,
+// чтобы производные (Pop!_OS, Linux Mint, Rocky) использовали менеджер родителя,
+// затем - все известные менеджеры по порядку.
+// Каждый вызов заново проверяет систему; в рамках запуска используйте DetectPackageManager.
 func (d *PackageManagerDetector) Detect() (*PackageManager, error) {
-	for cmd, pm := range packageManagers {
+	release, _ := ParseOSRelease()
+	return detectPackageManager(release)
+}
+
+// detectPackageManager ищет менеджер пакетов с учетом семейства дистрибутива; release может быть nil
+func detectPackageManager(release *OSRelease) (*PackageManager, error) {
+	var family string
+	order := packageManagerOrder
+	if release != nil {
+		family = release.Family()
+		if name := release.PackageManagerName(); name != "" {
+			order = append([]string{name}, packageManagerOrder...)
+		}
+	}
+
+	for _, cmd := range order {
 		if commandExists(cmd) {
+			pm := packageManagers[cmd]
+			pm.Family = family
 			return &pm, nil
 		}
 	}
-	return nil, errors.New("не найден поддерживаемый менеджер пакетов")
+	return nil, ErrNoPackageManager
 }
 
-// IsPackageInstalled проверяет установлен ли пакет
+// ErrNoPackageManager возвращается Detect, если в системе нет поддерживаемого менеджера пакетов
+var ErrNoPackageManager = errors.New("пакетный менеджер не поддерживается")
+
+// noPackageManagerNotice гарантирует, что предупреждение выводится один раз за запуск
+var noPackageManagerNotice sync.Once
+
+// notifyNoPackageManager выводит предупреждение об отсутствии менеджера пакетов
+// для функций, которые без него пропускаются, а не завершаются ошибкой
+func notifyNoPackageManager() {
+	noPackageManagerNotice.Do(func() {
+		fmt.Printf("⚠️  %s: операции с пакетами пропущены\n", ErrNoPackageManager)
+	})
+}
+
+// IsPackageInstalled проверяет установлен ли пакет.
+// При заданном pm.State повторные проверки берутся из кэша.
 func IsPackageInstalled(pm *PackageManager, pkg string) (bool, error) {
+	if pm.State != nil {
+		return pm.State.installed(pkg, func() (bool, error) {
+			return queryPackageInstalled(pm, pkg)
+		})
+	}
+	return queryPackageInstalled(pm, pkg)
+}
+
+// queryPackageInstalled запрашивает у менеджера пакетов, установлен ли пакет.
+// Имя передается отдельным аргументом, без оболочки
+func queryPackageInstalled(pm *PackageManager, pkg string) (bool, error) {
 	switch pm.Name {
 	case "apt":
-		cmd := "dpkg-query -W -f='${Status}' " + pkg + " 2>/dev/null | grep -q 'install ok installed'"
-		_, err := exec.Command("sh", "-c", cmd).Output()
-		return err == nil, nil
-	case "dnf", "yum":
-		cmd := "rpm -q " + pkg
-		_, err := exec.Command("sh", "-c", cmd).Output()
+		output, err := cmdRunner.Output("dpkg-query", "-W", "-f=${Status}", "--", pkg)
+		return err == nil && strings.Contains(string(output), "install ok installed"), nil
+	case "dnf", "yum", "zypper":
+		_, err := cmdRunner.Output("rpm", "-q", "--", pkg)
 		return err == nil, nil
 	case "pacman":
-		cmd := "pacman -Qs ^" + pkg + "$"
-		output, err := exec.Command("sh", "-c", cmd).Output()
+		output, err := cmdRunner.Output("pacman", "-Qs", "^"+pkg+"$")
 		return err == nil && strings.Contains(string(output), pkg), nil
 	case "apk":
-		cmd := "apk info -e " + pkg
-		_, err := exec.Command("sh", "-c", cmd).Output()
-		return err == nil, nil
-	case "zypper":
-		cmd := "rpm -q " + pkg
-		_, err := exec.Command("sh", "-c", cmd).Output()
+		_, err := cmdRunner.Output("apk", "info", "-e", pkg)
 		return err == nil, nil
 	default:
 		return false, fmt.Errorf("неподдерживаемый менеджер пакетов: %s", pm.Name)
 	}
 }
 
+// InstallPackagesFromFile устанавливает пакеты из списка в стиле requirements.txt
+// (см. config.LoadPackageList) по категориям в порядке config.CategoryNames
+func InstallPackagesFromFile(pm *PackageManager, path string, showProgress bool) error {
+	lists, err := appconfig.LoadPackageList(path)
+	if err != nil {
+		return err
+	}
+	for _, category := range appconfig.CategoryNames {
+		packages := lists[category]
+		if len(packages) == 0 {
+			continue
+		}
+		resolved := make([]string, 0, len(packages))
+		for _, pkg := range packages {
+			resolved = append(resolved, ResolvePackageName(pm, pkg))
+		}
+		if err := InstallPackages(pm, resolved, showProgress); err != nil {
+			return fmt.Errorf("категория %s: %w", category, err)
+		}
+	}
+	return nil
+}
+
 // InstallPackages устанавливает пакеты
 func InstallPackages(pm *PackageManager, packages []string, showProgress bool) error {
 	if len(packages) == 0 {
 		return nil
 	}
 
+	// Имена попадают в команду оболочки pm.Install: метасимволы в них недопустимы
+	for _, pkg := range packages {
+		if err := appconfig.ValidatePackageName(pkg); err != nil {
+			return err
+		}
+	}
+
 	// Фильтруем уже установленные пакеты
 	var toInstall []string
 	for _, pkg := range packages {
@@ -178,63 +259,58 @@ func InstallPackages(pm *PackageManager, packages []string, showProgress bool) e
 		return nil
 	}
 
+	// На ostree-системах корень только для чтения, пакеты ставятся через rpm-ostree
+	if isOSTree() {
+		return ostreeInstallError(toInstall)
+	}
+
+	// Состояние пакетов меняется и при частично неудачной установке
+	defer pm.State.Invalidate(toInstall...)
+
 	if showProgress {
 		return installWithProgress(pm, toInstall)
 	}
 	return installWithoutProgress(pm, toInstall)
 }
 
+// InstallPackagesWithOptional устанавливает обязательные пакеты, а затем необязательные.
+// Ошибка установки необязательного пакета выводится как предупреждение и не прерывает работу.
+func InstallPackagesWithOptional(pm *PackageManager, required, optional []string, showProgress bool) error {
+	if err := InstallPackages(pm, required, showProgress); err != nil {
+		return err
+	}
+
+	for _, pkg := range optional {
+		if err := InstallPackages(pm, []string{pkg}, false); err != nil {
+			fmt.Printf("⚠️  Необязательный пакет %s не установлен: %v\n", pkg, err)
+		}
+	}
+	return nil
+}
+
 func installWithProgress(pm *PackageManager, packages []string) error {
-	bar := progressbar.NewOptions(len(packages),
-		progressbar.OptionSetDescription("Установка пакетов"),
-		progressbar.OptionSetWidth(40),
-		progressbar.OptionShowCount(),
-		progressbar.OptionClearOnFinish(),
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[green]=[reset]",
-			SaucerHead:    "[green]>[reset]",
-			SaucerPadding: " ",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}))
-
-	// Для некоторых менеджеров устанавливаем все сразу
-	if pm.Name == "apt" || pm.Name == "dnf" || pm.Name == "yum" {
-		installCmd := pm.Install + " " + strings.Join(packages, " ")
-		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-		s.Suffix = " Установка пакетов..."
-		s.Start()
+	bar := ui.NewProgressBar(len(packages), "Установка пакетов")
 
-		cmd := exec.Command("sh", "-c", installCmd)
-		if err := cmd.Run(); err != nil {
-			s.Stop()
-			// Пробуем установить по одному
-			for _, pkg := range packages {
-				cmdStr := pm.Install + " " + pkg
-				cmd := exec.Command("sh", "-c", cmdStr)
-				if err := cmd.Run(); err != nil {
-					return fmt.Errorf("ошибка установки %s: %w", pkg, err)
-				}
-				// Игнорируем ошибки прогресс-бара
-				_ = bar.Add(1)
-			}
-		} else {
-			s.Stop()
+	if canBatchInstall(pm, packages) {
+		s := ui.NewSpinner("Установка пакетов...")
+		s.Start()
+		err := shell(batchInstallCommand(pm, packages))
+		s.Stop()
+		if err == nil {
 			// Игнорируем ошибки прогресс-бара
 			_ = bar.Add(len(packages))
+			_ = bar.Finish()
+			return nil
 		}
-	} else {
-		// Для других менеджеров устанавливаем по одному
-		for _, pkg := range packages {
-			cmdStr := pm.Install + " " + pkg
-			cmd := exec.Command("sh", "-c", cmdStr)
-			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("ошибка установки %s: %w", pkg, err)
-			}
-			// Игнорируем ошибки прогресс-бара
-			_ = bar.Add(1)
+	}
+
+	// Пробуем установить по одному
+	for _, pkg := range packages {
+		if err := shell(pm.Install + " " + pkg); err != nil {
+			return fmt.Errorf("ошибка установки %s: %w", pkg, err)
 		}
+		// Игнорируем ошибки прогресс-бара
+		_ = bar.Add(1)
 	}
 
 	// Игнорируем ошибки завершения
@@ -243,76 +319,61 @@ func installWithProgress(pm *PackageManager, packages []string) error {
 }
 
 func installWithoutProgress(pm *PackageManager, packages []string) error {
-	if pm.Name == "apt" || pm.Name == "dnf" || pm.Name == "yum" {
-		cmdStr := pm.Install + " " + strings.Join(packages, " ")
-		cmd := exec.Command("sh", "-c", cmdStr)
-		return cmd.Run()
+	if canBatchInstall(pm, packages) && shell(batchInstallCommand(pm, packages)) == nil {
+		return nil
 	}
 
-	// Для других менеджеров устанавливаем по одному
+	// Пакетная установка не удалась: устанавливаем по одному, чтобы найти проблемный пакет
 	for _, pkg := range packages {
-		cmdStr := pm.Install + " " + pkg
-		cmd := exec.Command("sh", "-c", cmdStr)
-		if err := cmd.Run(); err != nil {
+		if err := shell(pm.Install + " " + pkg); err != nil {
 			return fmt.Errorf("ошибка установки %s: %w", pkg, err)
 		}
 	}
 	return nil
 }
 
-// UpdateSystem обновляет систему
-func UpdateSystem(pm *PackageManager) error {
-	// Обновляем список пакетов
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Обновление списка пакетов..."
-	s.Start()
-
-	updateCmd := exec.Command("sh", "-c", pm.Update)
-	if err := updateCmd.Run(); err != nil {
-		s.Stop()
-		return fmt.Errorf("ошибка обновления списка пакетов: %w", err)
-	}
-	s.Stop()
+// batchInstallManagers - менеджеры, которые устанавливают несколько пакетов одной командой.
+// apk, pacman и zypper к тому же ставят группу атомарно и быстрее, чем по одному.
+var batchInstallManagers = map[string]bool{
+	"apt":    true,
+	"dnf":    true,
+	"yum":    true,
+	"apk":    true,
+	"pacman": true,
+	"zypper": true,
+}
 
-	// Обновляем пакеты
-	s = spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Обновление пакетов..."
-	s.Start()
+// canBatchInstall проверяет, есть ли смысл ставить пакеты одной командой
+func canBatchInstall(pm *PackageManager, packages []string) bool {
+	return len(packages) > 1 && batchInstallManagers[pm.Name]
+}
 
-	upgradeCmd := exec.Command("sh", "-c", pm.Upgrade)
-	if err := upgradeCmd.Run(); err != nil {
-		s.Stop()
-		return fmt.Errorf("ошибка обновления пакетов: %w", err)
-	}
-	s.Stop()
+// batchInstallCommand возвращает команду установки всех пакетов сразу
+func batchInstallCommand(pm *PackageManager, packages []string) string {
+	return pm.Install + " " + strings.Join(packages, " ")
+}
 
-	return nil
+// UpdateSystem обновляет систему
+func UpdateSystem(pm *PackageManager) error {
+	_, err := UpdateSystemWithResult(pm)
+	return err
 }
 
 // CleanSystem очищает систему
 func CleanSystem(pm *PackageManager) error {
-	cleanCmd := exec.Command("sh", "-c", pm.Clean)
-	return cleanCmd.Run()
+	// autoremove удаляет пакеты, поэтому сохраненное состояние больше не актуально
+	defer pm.State.InvalidateAll()
+	return shell(pm.Clean)
 }
 
-// GetAvailableUpdates возвращает список доступных обновлений
+// GetAvailableUpdates возвращает имена пакетов, для которых доступны обновления.
+// Вывод разбирается так же, как в CheckUpdates: код 100 dnf/yum и заголовки не считаются ошибкой и пакетами
 func GetAvailableUpdates(pm *PackageManager) ([]string, error) {
-	checkCmd := exec.Command("sh", "-c", pm.Check)
-	output, err := checkCmd.Output()
+	output, err := runCheckCommand(pm.Check)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения обновлений: %w", err)
 	}
-
-	var updates []string
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line != "" && !strings.Contains(line, "Listing...") {
-			updates = append(updates, line)
-		}
-	}
-
-	return updates, nil
+	return parseUpdateList(pm.Name, output).Packages, nil
 }
 
 // GetPackageCategories возвращает список категорий пакетов
@@ -360,15 +421,63 @@ func FilterInstalledPackages(pm *PackageManager, packages []string) ([]string, [
 
 // InstallCategory устанавливает все пакеты из категории
 func InstallCategory(pm *PackageManager, category string, showProgress bool) error {
-	packages, err := GetPackagesByCategory(category)
+	return InstallCategoryWithOptions(pm, category, CategoryInstallOptions{ShowProgress: showProgress})
+}
+
+// CategoryInstallOptions задает параметры установки категории пакетов
+type CategoryInstallOptions struct {
+	// Exclude содержит пакеты категории, которые не нужно устанавливать
+	Exclude      []string
+	ShowProgress bool
+}
+
+// InstallCategoryWithOptions устанавливает пакеты категории, кроме исключенных
+func InstallCategoryWithOptions(pm *PackageManager, category string, opts CategoryInstallOptions) error {
+	packages, excluded, err := GetPackagesByCategoryExcluding(category, opts.Exclude)
 	if err != nil {
 		return err
 	}
-	return InstallPackages(pm, packages, showProgress)
+	if len(excluded) > 0 {
+		fmt.Printf("Исключены из категории %s: %s\n", category, strings.Join(excluded, ", "))
+	}
+	return InstallPackages(pm, packages, opts.ShowProgress)
+}
+
+// GetPackagesByCategoryExcluding возвращает пакеты категории без исключенных
+// и список фактически исключенных пакетов
+func GetPackagesByCategoryExcluding(category string, exclude []string) ([]string, []string, error) {
+	packages, err := GetPackagesByCategory(category)
+	if err != nil {
+		return nil, nil, err
+	}
+	kept, excluded := filterExcluded(packages, exclude)
+	return kept, excluded, nil
+}
+
+// filterExcluded разделяет пакеты на оставшиеся и исключенные
+func filterExcluded(packages, exclude []string) ([]string, []string) {
+	if len(exclude) == 0 {
+		return packages, nil
+	}
+
+	skip := make(map[string]bool, len(exclude))
+	for _, pkg := range exclude {
+		skip[pkg] = true
+	}
+
+	var kept, excluded []string
+	for _, pkg := range packages {
+		if skip[pkg] {
+			excluded = append(excluded, pkg)
+			continue
+		}
+		kept = append(kept, pkg)
+	}
+	return kept, excluded
 }
 
 // commandExists проверяет существование команды
 func commandExists(cmd string) bool {
-	_, err := exec.LookPath(cmd)
+	_, err := cmdRunner.LookPath(cmd)
 	return err == nil
 }
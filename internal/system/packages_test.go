@@ -0,0 +1,253 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// aptManager - apt с командами как у DetectPackageManager
+func aptManager() *PackageManager {
+	return &PackageManager{Name: "apt", Install: "apt install -y"}
+}
+
+func TestInstallPackagesRejectsShellMetacharacters(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	t.Cleanup(SetCommandRunner(fake))
+
+	err := InstallPackages(aptManager(), []string{"vim", "curl${IFS}evil|sh"}, false)
+	if err == nil {
+		t.Fatal("InstallPackages принял имя пакета с метасимволами")
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Fatalf("до проверки имен выполнены команды: %q", commands)
+	}
+}
+
+func TestIsPackageInstalledPassesNameAsArgument(t *testing.T) {
+	fake := runner.NewFakeRunner().On("dpkg-query", "install ok installed", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	installed, err := IsPackageInstalled(aptManager(), "vim")
+	if err != nil || !installed {
+		t.Fatalf("IsPackageInstalled = %v, %v", installed, err)
+	}
+	want := []string{"dpkg-query -W -f=${Status} -- vim"}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, want) {
+		t.Fatalf("команды %q, ожидалось %q", commands, want)
+	}
+}
+
+func TestInstallPackagesBatchSkipsInstalled(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("dpkg-query -W -f=${Status} -- vim", "install ok installed", nil).
+		On("dpkg-query", "", errors.New("exit status 1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	if err := InstallPackages(aptManager(), []string{"vim", "curl", "git"}, false); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"dpkg-query -W -f=${Status} -- vim",
+		"dpkg-query -W -f=${Status} -- curl",
+		"dpkg-query -W -f=${Status} -- git",
+		"sh -c apt install -y curl git",
+	}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, want) {
+		t.Fatalf("команды:\n%q\nожидалось:\n%q", commands, want)
+	}
+}
+
+func TestInstallPackagesFallsBackToSingleInstall(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("dpkg-query", "", errors.New("exit status 1")).
+		On("sh -c apt install -y curl broken", "", errors.New("exit status 100")).
+		On("sh -c apt install -y broken", "", errors.New("exit status 100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	err := InstallPackages(aptManager(), []string{"curl", "broken"}, false)
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("ошибка %v должна называть пакет broken", err)
+	}
+	want := []string{
+		"sh -c apt install -y curl broken",
+		"sh -c apt install -y curl",
+		"sh -c apt install -y broken",
+	}
+	if installs := installCommands(fake); !reflect.DeepEqual(installs, want) {
+		t.Fatalf("установка:\n%q\nожидалось:\n%q", installs, want)
+	}
+}
+
+// installCommands возвращает запущенные через оболочку команды установки
+func installCommands(fake *runner.FakeRunner) []string {
+	var installs []string
+	for _, command := range fake.Commands() {
+		if strings.HasPrefix(command, "sh -c ") {
+			installs = append(installs, command)
+		}
+	}
+	return installs
+}
+
+func TestInstallPackagesBatchesOtherManagers(t *testing.T) {
+	// Команда проверки установленного пакета у каждого менеджера своя
+	tests := []struct {
+		manager string
+		query   string
+		want    string
+	}{
+		{"apk", "apk", "sh -c apk add vim curl git"},
+		{"pacman", "pacman", "sh -c pacman -S --noconfirm vim curl git"},
+		{"zypper", "rpm", "sh -c zypper install -y vim curl git"},
+	}
+	for _, tt := range tests {
+		for _, showProgress := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/progress=%v", tt.manager, showProgress), func(t *testing.T) {
+				fake := runner.NewFakeRunner().On(tt.query, "", errors.New("exit status 1"))
+				t.Cleanup(SetCommandRunner(fake))
+
+				pm := packageManagers[tt.manager]
+				if err := InstallPackages(&pm, []string{"vim", "curl", "git"}, showProgress); err != nil {
+					t.Fatal(err)
+				}
+				if installs := installCommands(fake); !reflect.DeepEqual(installs, []string{tt.want}) {
+					t.Fatalf("установка %q, ожидалась одна команда %q", installs, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestInstallPackagesApkFallsBackToSingleInstall(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("apk", "", errors.New("exit status 1")).
+		On("sh -c apk add curl broken", "", errors.New("exit status 1")).
+		On("sh -c apk add broken", "", errors.New("exit status 1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	pm := packageManagers["apk"]
+	err := InstallPackages(&pm, []string{"curl", "broken"}, false)
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Fatalf("ошибка %v должна называть пакет broken", err)
+	}
+	want := []string{"sh -c apk add curl broken", "sh -c apk add curl", "sh -c apk add broken"}
+	if installs := installCommands(fake); !reflect.DeepEqual(installs, want) {
+		t.Fatalf("установка:\n%q\nожидалось:\n%q", installs, want)
+	}
+}
+
+func TestInstallPackagesWithOptionalContinuesOnFailure(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("dpkg-query", "", errors.New("exit status 1")).
+		On("sh -c apt install -y mosh", "", errors.New("exit status 100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	err := InstallPackagesWithOptional(aptManager(), []string{"vim"}, []string{"mosh", "htop"}, false)
+	if err != nil {
+		t.Fatalf("ошибка необязательного пакета прервала установку: %v", err)
+	}
+	var installs []string
+	for _, command := range fake.Commands() {
+		if strings.HasPrefix(command, "sh -c ") {
+			installs = append(installs, command)
+		}
+	}
+	want := []string{
+		"sh -c apt install -y vim",
+		"sh -c apt install -y mosh",
+		"sh -c apt install -y htop",
+	}
+	if !reflect.DeepEqual(installs, want) {
+		t.Fatalf("установка:\n%q\nожидалось:\n%q", installs, want)
+	}
+}
+
+func TestInstallPackagesWithOptionalRequiredFailureAborts(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("dpkg-query", "", errors.New("exit status 1")).
+		On("sh -c apt install -y vim", "", errors.New("exit status 100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	err := InstallPackagesWithOptional(aptManager(), []string{"vim"}, []string{"mosh"}, false)
+	if err == nil || !strings.Contains(err.Error(), "vim") {
+		t.Fatalf("ошибка %v должна называть обязательный пакет vim", err)
+	}
+	for _, command := range fake.Commands() {
+		if strings.Contains(command, "mosh") {
+			t.Fatalf("необязательный пакет обработан после ошибки обязательного: %q", fake.Commands())
+		}
+	}
+}
+
+func TestInstallCategoryExcludesPackages(t *testing.T) {
+	packages, err := GetPackagesByCategory("basic")
+	if err != nil || len(packages) < 3 {
+		t.Fatalf("категория basic: %v, %v", packages, err)
+	}
+	exclude := []string{packages[0], packages[2], "not-in-category"}
+
+	fake := runner.NewFakeRunner().On("dpkg-query", "", errors.New("exit status 1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	err = InstallCategoryWithOptions(aptManager(), "basic", CategoryInstallOptions{Exclude: exclude})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var install string
+	for _, command := range fake.Commands() {
+		if strings.HasPrefix(command, "sh -c apt install -y ") {
+			install = command
+		}
+	}
+	installed := strings.Fields(strings.TrimPrefix(install, "sh -c apt install -y "))
+	want := append([]string{packages[1]}, packages[3:]...)
+	if !reflect.DeepEqual(installed, want) {
+		t.Fatalf("установлены %q, ожидалось %q", installed, want)
+	}
+	for _, command := range fake.Commands() {
+		for _, pkg := range exclude[:2] {
+			if strings.HasSuffix(command, " "+pkg) || strings.Contains(command, " "+pkg+" ") {
+				t.Errorf("исключенный пакет %s передан команде %q", pkg, command)
+			}
+		}
+	}
+}
+
+func TestGetPackagesByCategoryExcludingReportsExcluded(t *testing.T) {
+	packages, _ := GetPackagesByCategory("basic")
+	kept, excluded, err := GetPackagesByCategoryExcluding("basic", []string{packages[0], "not-in-category"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(excluded, []string{packages[0]}) {
+		t.Errorf("исключены %q, ожидалось только %q", excluded, packages[0])
+	}
+	if !reflect.DeepEqual(kept, packages[1:]) {
+		t.Errorf("оставлены %q, ожидалось %q", kept, packages[1:])
+	}
+	if _, _, err := GetPackagesByCategoryExcluding("games", nil); err == nil {
+		t.Error("неизвестная категория: ожидалась ошибка")
+	}
+}
+
+func TestDetectPackageManagerUnsupported(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	for _, manager := range packageManagerOrder {
+		fake.Missing[manager] = true
+	}
+	t.Cleanup(SetCommandRunner(fake))
+
+	if _, err := detectPackageManager(nil); !errors.Is(err, ErrNoPackageManager) {
+		t.Errorf("detectPackageManager() error = %v, ожидается ErrNoPackageManager", err)
+	}
+	// Отсутствие менеджера не считается ошибкой проверки обновлений
+	sm := &SecurityManager{}
+	if err := sm.checkSecurityUpdates(); err != nil {
+		t.Errorf("checkSecurityUpdates() error = %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package system
+
+import "sync"
+
+// PackageStateCache запоминает результаты проверки установленных пакетов в рамках одного запуска,
+// чтобы повторные проверки одного пакета не запускали менеджер пакетов снова.
+// Безопасен для одновременного использования; установка пакетов сбрасывает их записи.
+type PackageStateCache struct {
+	mu      sync.Mutex
+	entries map[string]*packageState
+}
+
+// packageState - результат проверки пакета; once гарантирует единственный запрос
+// даже при одновременных проверках одного пакета
+type packageState struct {
+	once      sync.Once
+	installed bool
+	err       error
+}
+
+// NewPackageStateCache создает пустой кэш состояния пакетов
+func NewPackageStateCache() *PackageStateCache {
+	return &PackageStateCache{entries: make(map[string]*packageState)}
+}
+
+// installed возвращает сохраненный результат для pkg или выполняет query.
+// Ошибки не кэшируются: следующая проверка повторит запрос.
+func (c *PackageStateCache) installed(pkg string, query func() (bool, error)) (bool, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[pkg]
+	if !ok {
+		entry = &packageState{}
+		c.entries[pkg] = entry
+	}
+	c.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.installed, entry.err = query()
+	})
+	if entry.err != nil {
+		c.mu.Lock()
+		if c.entries[pkg] == entry {
+			delete(c.entries, pkg)
+		}
+		c.mu.Unlock()
+	}
+	return entry.installed, entry.err
+}
+
+// Invalidate сбрасывает сохраненное состояние пакетов после их установки или удаления
+func (c *PackageStateCache) Invalidate(packages ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, pkg := range packages {
+		delete(c.entries, pkg)
+	}
+}
+
+// InvalidateAll сбрасывает состояние всех пакетов, например после autoremove
+func (c *PackageStateCache) InvalidateAll() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*packageState)
+}
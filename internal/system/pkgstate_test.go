@@ -0,0 +1,125 @@
+package system
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// countCommands считает выполненные команды command
+func countCommands(fake *runner.FakeRunner, command string) int {
+	n := 0
+	for _, c := range fake.Commands() {
+		if c == command {
+			n++
+		}
+	}
+	return n
+}
+
+func TestPackageStateCacheQueriesOnce(t *testing.T) {
+	fake := runner.NewFakeRunner().On("dpkg-query", "install ok installed", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	pm := aptManager()
+	pm.State = NewPackageStateCache()
+	for i := 0; i < 3; i++ {
+		installed, err := IsPackageInstalled(pm, "vim")
+		if err != nil || !installed {
+			t.Fatalf("IsPackageInstalled = %v, %v", installed, err)
+		}
+	}
+	if n := countCommands(fake, "dpkg-query -W -f=${Status} -- vim"); n != 1 {
+		t.Fatalf("запрос выполнен %d раз, ожидался 1: %q", n, fake.Commands())
+	}
+}
+
+func TestPackageStateCacheInvalidatedByInstall(t *testing.T) {
+	const query = "dpkg-query -W -f=${Status} -- curl"
+	fake := runner.NewFakeRunner().
+		On(query, "", errors.New("exit status 1")).
+		On(query, "install ok installed", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	pm := aptManager()
+	pm.State = NewPackageStateCache()
+	if err := InstallPackages(pm, []string{"curl"}, false); err != nil {
+		t.Fatal(err)
+	}
+	installed, err := IsPackageInstalled(pm, "curl")
+	if err != nil || !installed {
+		t.Fatalf("после установки IsPackageInstalled = %v, %v", installed, err)
+	}
+	if _, err := IsPackageInstalled(pm, "curl"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countCommands(fake, query); n != 2 {
+		t.Fatalf("запрос выполнен %d раз, ожидалось 2 (до и после установки): %q", n, fake.Commands())
+	}
+}
+
+func TestPackageStateCacheConcurrentLookups(t *testing.T) {
+	cache := NewPackageStateCache()
+	var queries atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			installed, err := cache.installed("vim", func() (bool, error) {
+				queries.Add(1)
+				return true, nil
+			})
+			if err != nil || !installed {
+				t.Errorf("installed = %v, %v", installed, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := queries.Load(); n != 1 {
+		t.Fatalf("запрос выполнен %d раз, ожидался 1", n)
+	}
+}
+
+func TestPackageStateCacheDoesNotCacheErrors(t *testing.T) {
+	cache := NewPackageStateCache()
+	calls := 0
+	query := func() (bool, error) {
+		calls++
+		if calls == 1 {
+			return false, errors.New("менеджер пакетов занят")
+		}
+		return true, nil
+	}
+	if _, err := cache.installed("vim", query); err == nil {
+		t.Fatal("ожидалась ошибка первого запроса")
+	}
+	installed, err := cache.installed("vim", query)
+	if err != nil || !installed || calls != 2 {
+		t.Fatalf("installed = %v, %v после %d запросов, ожидался повтор запроса", installed, err, calls)
+	}
+}
+
+func TestPackageStateCacheInvalidateAll(t *testing.T) {
+	cache := NewPackageStateCache()
+	calls := 0
+	query := func() (bool, error) {
+		calls++
+		return true, nil
+	}
+	for _, pkg := range []string{"vim", "curl", "vim"} {
+		_, _ = cache.installed(pkg, query)
+	}
+	cache.InvalidateAll()
+	_, _ = cache.installed("vim", query)
+	if calls != 3 {
+		t.Fatalf("запросов %d, ожидалось 3", calls)
+	}
+
+	var nilCache *PackageStateCache
+	nilCache.Invalidate("vim")
+	nilCache.InvalidateAll()
+}
@@ -0,0 +1,404 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/13winged/go-to-run/internal/ui"
+)
+
+// ErrPlanDeclined возвращается, если изменение пакетов не подтверждено
+var ErrPlanDeclined = errors.New("изменение пакетов не подтверждено")
+
+// InstallPlan описывает изменения пакетов, которые выполнит установка или обновление.
+// Размеры в байтах; ноль означает, что менеджер их не сообщил (apt-get -s, apk).
+type InstallPlan struct {
+	Manager   string
+	ToInstall []string
+	ToUpgrade []string
+	ToRemove  []string
+	// DownloadSize - объем загрузки
+	DownloadSize int64
+	// InstalledSize - изменение занятого места; отрицательное значение - место освобождается
+	InstalledSize int64
+}
+
+// Empty сообщает, что операция не изменит ни одного пакета
+func (p *InstallPlan) Empty() bool {
+	return len(p.ToInstall)+len(p.ToUpgrade)+len(p.ToRemove) == 0
+}
+
+// Display выводит план таблицей вместе с размерами загрузки и установки
+func (p *InstallPlan) Display() {
+	(&ui.TableManager{}).DisplayPackageChanges(p.ToInstall, p.ToUpgrade, p.ToRemove)
+	if p.DownloadSize > 0 {
+		fmt.Printf("Будет загружено: %s\n", formatSize(p.DownloadSize))
+	}
+	switch {
+	case p.InstalledSize > 0:
+		fmt.Printf("Будет занято: %s\n", formatSize(p.InstalledSize))
+	case p.InstalledSize < 0:
+		fmt.Printf("Будет освобождено: %s\n", formatSize(-p.InstalledSize))
+	}
+}
+
+// ConfirmFunc решает, выполнять ли операцию по ее плану
+type ConfirmFunc func(plan *InstallPlan) bool
+
+// ConfirmInteractive показывает план и спрашивает подтверждение в терминале.
+// Без терминала на stdin операция не подтверждается.
+func ConfirmInteractive(plan *InstallPlan) bool {
+	plan.Display()
+	return ui.Confirm("Продолжить?")
+}
+
+// PlanInstall показывает, что изменит установка пакетов, не устанавливая их.
+// Уже установленные пакеты отбрасываются так же, как в InstallPackages.
+func PlanInstall(pm *PackageManager, packages []string) (*InstallPlan, error) {
+	var toInstall []string
+	for _, pkg := range packages {
+		installed, err := IsPackageInstalled(pm, pkg)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка проверки пакета %s: %w", pkg, err)
+		}
+		if !installed {
+			toInstall = append(toInstall, pkg)
+		}
+	}
+	if len(toInstall) == 0 {
+		return &InstallPlan{Manager: pm.Name}, nil
+	}
+
+	var args []string
+	switch pm.Name {
+	case "apt":
+		args = append([]string{"apt-get", "-s", "install"}, toInstall...)
+	case "dnf", "yum":
+		args = append([]string{pm.Name, "install", "--assumeno"}, toInstall...)
+	case "pacman":
+		args = append([]string{"pacman", "-Sp", "--print-format", "%n %s"}, toInstall...)
+	case "apk":
+		args = append([]string{"apk", "add", "--simulate"}, toInstall...)
+	case "zypper":
+		args = append([]string{"zypper", "--non-interactive", "install", "--dry-run"}, toInstall...)
+	default:
+		return nil, fmt.Errorf("%w: предварительный просмотр для %s", ErrNoPackageManager, pm.Name)
+	}
+	return runPlan(pm.Name, false, args)
+}
+
+// PlanUpgrade показывает, что изменит обновление системы, не обновляя ее.
+// Списки пакетов не обновляются: план строится по текущим метаданным.
+func PlanUpgrade(pm *PackageManager) (*InstallPlan, error) {
+	var args []string
+	switch pm.Name {
+	case "apt":
+		args = []string{"apt-get", "-s", "upgrade"}
+	case "dnf", "yum":
+		args = []string{pm.Name, "update", "--assumeno"}
+	case "pacman":
+		args = []string{"pacman", "-Sup", "--print-format", "%n %s"}
+	case "apk":
+		args = []string{"apk", "upgrade", "--simulate"}
+	case "zypper":
+		args = []string{"zypper", "--non-interactive", "update", "--dry-run"}
+	default:
+		return nil, fmt.Errorf("%w: предварительный просмотр для %s", ErrNoPackageManager, pm.Name)
+	}
+	return runPlan(pm.Name, true, args)
+}
+
+// InstallPackagesConfirmed строит план установки и устанавливает пакеты только после
+// подтверждения. nil confirm означает ConfirmInteractive. Пустой план ничего не спрашивает.
+func InstallPackagesConfirmed(pm *PackageManager, packages []string, showProgress bool, confirm ConfirmFunc) error {
+	plan, err := PlanInstall(pm, packages)
+	if err != nil {
+		return err
+	}
+	if plan.Empty() {
+		return nil
+	}
+	if confirm == nil {
+		confirm = ConfirmInteractive
+	}
+	if !confirm(plan) {
+		return ErrPlanDeclined
+	}
+	return InstallPackages(pm, packages, showProgress)
+}
+
+// UpdateSystemConfirmed строит план обновления и обновляет систему только после подтверждения.
+// nil confirm означает ConfirmInteractive. Если обновлять нечего, возвращается пустой отчет.
+func UpdateSystemConfirmed(pm *PackageManager, confirm ConfirmFunc) (*UpdateReport, error) {
+	plan, err := PlanUpgrade(pm)
+	if err != nil {
+		return nil, err
+	}
+	if plan.Empty() {
+		return &UpdateReport{}, nil
+	}
+	if confirm == nil {
+		confirm = ConfirmInteractive
+	}
+	if !confirm(plan) {
+		return nil, ErrPlanDeclined
+	}
+	return UpdateSystemWithResult(pm)
+}
+
+// runPlan выполняет симуляцию и разбирает ее вывод.
+// dnf и yum с --assumeno завершаются с ошибкой даже при успешном разрешении зависимостей.
+func runPlan(manager string, upgrade bool, args []string) (*InstallPlan, error) {
+	// Вывод разбирается по английским сообщениям менеджеров
+	output, err := cmdRunner.CombinedOutput("env", cLocale(args[0], args[1:]...)...)
+	text := string(output)
+	if err != nil && !((manager == "dnf" || manager == "yum") && dnfPlanResolved(text)) {
+		return nil, fmt.Errorf("ошибка симуляции %s: %w: %s", args[0], err, strings.TrimSpace(text))
+	}
+	return parsePlan(manager, upgrade, text), nil
+}
+
+// dnfPlanResolved проверяет, что dnf разрешил транзакцию и лишь отказался ее выполнять
+func dnfPlanResolved(output string) bool {
+	return strings.Contains(output, "Transaction Summary") || strings.Contains(output, "Nothing to do")
+}
+
+// parsePlan разбирает вывод симуляции менеджера. upgrade указывает, что это обновление
+// системы: pacman -Sup печатает только имена, и вид изменения определяется командой.
+func parsePlan(manager string, upgrade bool, output string) *InstallPlan {
+	plan := &InstallPlan{Manager: manager}
+	switch manager {
+	case "apt":
+		parseAptPlan(plan, output)
+	case "dnf", "yum":
+		parseDnfPlan(plan, output)
+	case "pacman":
+		parsePacmanPlan(plan, upgrade, output)
+	case "apk":
+		parseApkPlan(plan, output)
+	case "zypper":
+		parseZypperPlan(plan, output)
+	}
+	return plan
+}
+
+// parseAptPlan разбирает вывод apt-get -s:
+//
+//	Inst nginx (1.22.1-9 Debian:12/stable [amd64])
+//	Inst libc6 [2.36-9] (2.36-9+deb12u4 Debian:12/stable-security [amd64])
+//	Remv oldpkg [1.0-1]
+//
+// Версия в квадратных скобках после имени - установленная, то есть пакет обновляется.
+// Размеры apt-get -s не печатает, но строки apt install --assume-no тоже разбираются.
+func parseAptPlan(plan *InstallPlan, output string) {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) >= 2 && fields[0] == "Inst":
+			if len(fields) >= 3 && strings.HasPrefix(fields[2], "[") {
+				plan.ToUpgrade = append(plan.ToUpgrade, fields[1])
+			} else {
+				plan.ToInstall = append(plan.ToInstall, fields[1])
+			}
+		case len(fields) >= 2 && fields[0] == "Remv":
+			plan.ToRemove = append(plan.ToRemove, fields[1])
+		case strings.HasPrefix(line, "Need to get "):
+			// Need to get 0 B/1,234 kB of archives. - загружается только первая часть
+			value := strings.TrimPrefix(line, "Need to get ")
+			value, _, _ = strings.Cut(value, " of archives")
+			value, _, _ = strings.Cut(value, "/")
+			plan.DownloadSize = parsePlanSize(value)
+		case strings.HasPrefix(line, "After this operation, "):
+			// After this operation, 3,456 kB of additional disk space will be used.
+			value := strings.TrimPrefix(line, "After this operation, ")
+			value, rest, _ := strings.Cut(value, " disk space")
+			value = strings.TrimSuffix(value, " of additional")
+			plan.InstalledSize = parsePlanSize(value)
+			if strings.Contains(rest, "freed") {
+				plan.InstalledSize = -plan.InstalledSize
+			}
+		}
+	}
+}
+
+// parseDnfPlan разбирает таблицу транзакции dnf/yum с --assumeno:
+//
+//	Installing:
+//	 nginx          x86_64   1:1.24.0-1.fc39   updates   34 k
+//	Installing dependencies:
+//	 nginx-core     x86_64   1:1.24.0-1.fc39   updates   590 k
+//	Transaction Summary
+//	Total download size: 624 k
+//	Installed size: 1.9 M
+func parseDnfPlan(plan *InstallPlan, output string) {
+	var section *[]string
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Transaction Summary") {
+			section = nil
+			continue
+		}
+
+		// Заголовки секций идут без отступа и заканчиваются двоеточием
+		if raw == strings.TrimLeft(raw, " \t") {
+			switch {
+			case strings.HasPrefix(line, "Total download size:"):
+				plan.DownloadSize = parsePlanSize(strings.TrimPrefix(line, "Total download size:"))
+			case strings.HasPrefix(line, "Installed size:"):
+				plan.InstalledSize = parsePlanSize(strings.TrimPrefix(line, "Installed size:"))
+			case strings.HasPrefix(line, "Freed space:"):
+				plan.InstalledSize = -parsePlanSize(strings.TrimPrefix(line, "Freed space:"))
+			case !strings.HasSuffix(line, ":"):
+				section = nil
+			case strings.HasPrefix(line, "Installing"):
+				section = &plan.ToInstall
+			case strings.HasPrefix(line, "Upgrading"), strings.HasPrefix(line, "Updating"),
+				strings.HasPrefix(line, "Downgrading"):
+				section = &plan.ToUpgrade
+			case strings.HasPrefix(line, "Removing"):
+				section = &plan.ToRemove
+			default:
+				section = nil
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		// Строки "replacing  foo.x86_64 1.0" относятся к пакету выше
+		if section == nil || len(fields) < 3 || fields[0] == "replacing" {
+			continue
+		}
+		*section = append(*section, fields[0])
+	}
+}
+
+// parsePacmanPlan разбирает вывод pacman -Sp --print-format "%n %s": имя и размер
+// загрузки в байтах. Удаления (конфликты) pacman в этом режиме не показывает.
+func parsePacmanPlan(plan *InstallPlan, upgrade bool, output string) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if upgrade {
+			plan.ToUpgrade = append(plan.ToUpgrade, fields[0])
+		} else {
+			plan.ToInstall = append(plan.ToInstall, fields[0])
+		}
+		plan.DownloadSize += size
+	}
+}
+
+// parseApkPlan разбирает вывод apk --simulate:
+//
+//	(1/2) Installing nginx (1.24.0-r6)
+//	(2/2) Upgrading busybox (1.36.0-r0 -> 1.36.1-r0)
+func parseApkPlan(plan *InstallPlan, output string) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasPrefix(fields[0], "(") {
+			continue
+		}
+		switch fields[1] {
+		case "Installing":
+			plan.ToInstall = append(plan.ToInstall, fields[2])
+		case "Upgrading", "Downgrading", "Replacing":
+			plan.ToUpgrade = append(plan.ToUpgrade, fields[2])
+		case "Purging", "Deleting":
+			plan.ToRemove = append(plan.ToRemove, fields[2])
+		}
+	}
+}
+
+// Итоговая строка zypper:
+// Overall download size: 1.2 MiB. Already cached: 0 B. After the operation, additional 3.4 MiB will be used.
+var (
+	zypperDownloadPattern  = regexp.MustCompile(`Overall download size: ([\d.,]+ \S+?)\.`)
+	zypperInstalledPattern = regexp.MustCompile(`After the operation, (?:additional )?([\d.,]+ \S+) will be (used|freed)`)
+)
+
+// parseZypperPlan разбирает вывод zypper --dry-run: заголовки вида
+// "The following 2 NEW packages are going to be installed:" и имена на строках с отступом
+func parseZypperPlan(plan *InstallPlan, output string) {
+	var section *[]string
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "":
+			section = nil
+		case strings.HasPrefix(line, "The following "):
+			switch {
+			case strings.HasSuffix(line, "installed:"):
+				section = &plan.ToInstall
+			case strings.HasSuffix(line, "upgraded:"), strings.HasSuffix(line, "downgraded:"):
+				section = &plan.ToUpgrade
+			case strings.HasSuffix(line, "REMOVED:"), strings.HasSuffix(line, "removed:"):
+				section = &plan.ToRemove
+			default:
+				section = nil
+			}
+		case strings.HasPrefix(line, "Overall download size:"):
+			if m := zypperDownloadPattern.FindStringSubmatch(line); m != nil {
+				plan.DownloadSize = parsePlanSize(m[1])
+			}
+			if m := zypperInstalledPattern.FindStringSubmatch(line); m != nil {
+				plan.InstalledSize = parsePlanSize(m[1])
+				if m[2] == "freed" {
+					plan.InstalledSize = -plan.InstalledSize
+				}
+			}
+		case section != nil && raw != strings.TrimLeft(raw, " \t"):
+			*section = append(*section, strings.Fields(line)...)
+		default:
+			section = nil
+		}
+	}
+}
+
+// planSizeUnits - множители единиц размера: apt использует десятичные kB/MB,
+// dnf - двоичные k/M, zypper - KiB/MiB
+var planSizeUnits = map[string]float64{
+	"B":   1,
+	"kB":  1e3,
+	"MB":  1e6,
+	"GB":  1e9,
+	"k":   1 << 10,
+	"K":   1 << 10,
+	"KiB": 1 << 10,
+	"M":   1 << 20,
+	"MiB": 1 << 20,
+	"G":   1 << 30,
+	"GiB": 1 << 30,
+}
+
+// parsePlanSize переводит размер вида "1,234 kB" или "1.9 M" в байты; нераспознанный размер - ноль
+func parsePlanSize(value string) int64 {
+	fields := strings.Fields(strings.TrimSuffix(strings.TrimSpace(value), "."))
+	if len(fields) == 0 || len(fields) > 2 {
+		return 0
+	}
+	number, err := strconv.ParseFloat(strings.ReplaceAll(fields[0], ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	multiplier := 1.0
+	if len(fields) == 2 {
+		m, ok := planSizeUnits[fields[1]]
+		if !ok {
+			return 0
+		}
+		multiplier = m
+	}
+	return int64(number * multiplier)
+}
@@ -0,0 +1,192 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// aptSimulation - вывод apt-get -s install с установкой, обновлением и удалением
+const aptSimulation = `NOTE: This is only a simulation!
+      apt-get needs root privileges for real execution.
+      Keep also in mind that locking is deactivated,
+      so don't depend on the relevance to the real current situation!
+Reading package lists... Done
+Building dependency tree... Done
+Reading state information... Done
+The following additional packages will be installed:
+  libnginx-mod-http-geoip nginx-common
+The following packages will be REMOVED:
+  apache2
+The following NEW packages will be installed:
+  libnginx-mod-http-geoip nginx nginx-common
+The following packages will be upgraded:
+  libc6
+1 upgraded, 3 newly installed, 1 to remove and 12 not upgraded.
+Remv apache2 [2.4.57-2]
+Inst libc6 [2.36-9] (2.36-9+deb12u4 Debian:12/stable-security [amd64])
+Inst nginx-common (1.22.1-9 Debian:12/stable [all])
+Inst libnginx-mod-http-geoip (1.22.1-9 Debian:12/stable [amd64])
+Inst nginx (1.22.1-9 Debian:12/stable [amd64])
+Conf libc6 (2.36-9+deb12u4 Debian:12/stable-security [amd64])
+Conf nginx-common (1.22.1-9 Debian:12/stable [all])
+Conf libnginx-mod-http-geoip (1.22.1-9 Debian:12/stable [amd64])
+Conf nginx (1.22.1-9 Debian:12/stable [amd64])
+`
+
+func TestParseAptPlan(t *testing.T) {
+	plan := parsePlan("apt", false, aptSimulation)
+	want := &InstallPlan{
+		Manager:   "apt",
+		ToInstall: []string{"nginx-common", "libnginx-mod-http-geoip", "nginx"},
+		ToUpgrade: []string{"libc6"},
+		ToRemove:  []string{"apache2"},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("parsePlan = %+v, ожидалось %+v", plan, want)
+	}
+}
+
+func TestParseAptPlanSizes(t *testing.T) {
+	tests := []struct {
+		output        string
+		download      int64
+		installedSize int64
+	}{
+		{"Need to get 1,234 kB of archives.\nAfter this operation, 3,456 kB of additional disk space will be used.\n",
+			1234000, 3456000},
+		// Часть архивов уже загружена: учитывается только оставшийся объем
+		{"Need to get 512 kB/1,024 kB of archives.\n", 512000, 0},
+		{"After this operation, 2.5 MB disk space will be freed.\n", 0, -2500000},
+	}
+	for _, tt := range tests {
+		plan := parsePlan("apt", false, tt.output)
+		if plan.DownloadSize != tt.download || plan.InstalledSize != tt.installedSize {
+			t.Errorf("%q: загрузка %d, установка %d, ожидалось %d и %d",
+				tt.output, plan.DownloadSize, plan.InstalledSize, tt.download, tt.installedSize)
+		}
+	}
+}
+
+func TestParseAptPlanNothingToDo(t *testing.T) {
+	output := `Reading package lists... Done
+Building dependency tree... Done
+Reading state information... Done
+Calculating upgrade... Done
+0 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.
+`
+	if plan := parsePlan("apt", true, output); !plan.Empty() {
+		t.Fatalf("план %+v, ожидался пустой", plan)
+	}
+}
+
+func TestParsePlanSize(t *testing.T) {
+	tests := map[string]int64{
+		"1,234 kB":   1234000,
+		"1.9 M":      1992294,
+		"624 k":      638976,
+		"3.4 MiB":    3565158,
+		"0 B":        0,
+		"17":         17,
+		"12 parsecs": 0,
+		"":           0,
+	}
+	for value, want := range tests {
+		if got := parsePlanSize(value); got != want {
+			t.Errorf("parsePlanSize(%q) = %d, ожидалось %d", value, got, want)
+		}
+	}
+}
+
+func TestPlanInstallSkipsInstalledPackages(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("dpkg-query -W -f=${Status} -- vim", "install ok installed", nil).
+		On("dpkg-query", "", exitStatus(t, "1")).
+		On("env", aptSimulation, nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	plan, err := PlanInstall(aptManager(), []string{"vim", "nginx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.ToInstall) != 3 {
+		t.Errorf("план %+v", plan)
+	}
+	commands := fake.Commands()
+	if last := commands[len(commands)-1]; last != "env LC_ALL=C apt-get -s install nginx" {
+		t.Errorf("симуляция %q, ожидалась установка только nginx", last)
+	}
+}
+
+func TestPlanUpgradeDnfAssumeNo(t *testing.T) {
+	output := `Dependencies resolved.
+================================================================================
+ Package            Arch       Version               Repository           Size
+================================================================================
+Upgrading:
+ kernel             x86_64     6.6.8-200.fc39        updates             140 k
+ openssl-libs       x86_64     1:3.1.1-4.fc39        updates             2.2 M
+Removing:
+ kernel-core-old    x86_64     6.5.6-300.fc39        @updates             65 M
+
+Transaction Summary
+================================================================================
+Upgrade  2 Packages
+Remove   1 Package
+
+Total download size: 2.3 M
+Freed space: 60 M
+Operation aborted.
+`
+	// dnf с --assumeno завершается с кодом 1, хотя транзакция разрешена
+	fake := runner.NewFakeRunner().On("env", output, exitStatus(t, "1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	plan, err := PlanUpgrade(&PackageManager{Name: "dnf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &InstallPlan{
+		Manager:       "dnf",
+		ToUpgrade:     []string{"kernel", "openssl-libs"},
+		ToRemove:      []string{"kernel-core-old"},
+		DownloadSize:  parsePlanSize("2.3 M"),
+		InstalledSize: -60 << 20,
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Fatalf("PlanUpgrade = %+v, ожидалось %+v", plan, want)
+	}
+
+	fake = runner.NewFakeRunner().On("env", "Error: Unable to find a match: nosuchpkg\n", exitStatus(t, "1"))
+	t.Cleanup(SetCommandRunner(fake))
+	if _, err := PlanUpgrade(&PackageManager{Name: "dnf"}); err == nil || !strings.Contains(err.Error(), "nosuchpkg") {
+		t.Errorf("ожидалась ошибка симуляции с выводом dnf, получено %v", err)
+	}
+}
+
+func TestInstallPackagesConfirmedDeclined(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("dpkg-query", "", exitStatus(t, "1")).
+		On("env", aptSimulation, nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	var shown *InstallPlan
+	err := InstallPackagesConfirmed(aptManager(), []string{"nginx"}, false, func(plan *InstallPlan) bool {
+		shown = plan
+		return false
+	})
+	if !errors.Is(err, ErrPlanDeclined) {
+		t.Fatalf("ошибка %v, ожидалась ErrPlanDeclined", err)
+	}
+	if shown == nil || len(shown.ToRemove) != 1 {
+		t.Errorf("подтверждению передан план %+v", shown)
+	}
+	for _, command := range fake.Commands() {
+		if strings.Contains(command, "apt install") {
+			t.Errorf("без подтверждения выполнена установка: %q", command)
+		}
+	}
+}
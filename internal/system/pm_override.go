@@ -0,0 +1,53 @@
+package system
+
+import (
+	"fmt"
+	"os"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+)
+
+// pmOverrideEnvPrefix - префикс переменных окружения, переопределяющих команды
+// менеджера пакетов: GO_TO_RUN_PM_INSTALL, GO_TO_RUN_PM_UPDATE и т.д.
+const pmOverrideEnvPrefix = "GO_TO_RUN_PM_"
+
+// ApplyPackageManagerOverride возвращает копию pm с командами из override.
+// Переменные окружения GO_TO_RUN_PM_<ПОЛЕ> имеют приоритет над конфигурацией.
+func ApplyPackageManagerOverride(pm *PackageManager, override appconfig.PackageManagerOverride) (*PackageManager, error) {
+	override = packageManagerOverrideFromEnv(override)
+	if err := override.Validate(); err != nil {
+		return nil, fmt.Errorf("некорректное переопределение менеджера пакетов: %w", err)
+	}
+
+	result := *pm
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&result.Update, override.Update}, {&result.Upgrade, override.Upgrade},
+		{&result.Install, override.Install}, {&result.Remove, override.Remove},
+		{&result.Clean, override.Clean}, {&result.Check, override.Check},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+	return &result, nil
+}
+
+// packageManagerOverrideFromEnv дополняет override значениями из переменных окружения
+func packageManagerOverrideFromEnv(override appconfig.PackageManagerOverride) appconfig.PackageManagerOverride {
+	for _, field := range []struct {
+		name string
+		dst  *string
+	}{
+		{"UPDATE", &override.Update}, {"UPGRADE", &override.Upgrade},
+		{"INSTALL", &override.Install}, {"REMOVE", &override.Remove},
+		{"CLEAN", &override.Clean}, {"CHECK", &override.Check},
+	} {
+		if value, ok := os.LookupEnv(pmOverrideEnvPrefix + field.name); ok && value != "" {
+			*field.dst = value
+		}
+	}
+	return override
+}
@@ -0,0 +1,64 @@
+package system
+
+import (
+	"errors"
+	"testing"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+func TestPackageManagerOverrideChangesInstallCommand(t *testing.T) {
+	fake := runner.NewFakeRunner().On("dpkg-query", "", errors.New("exit status 1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	base := aptManager()
+	pm, err := ApplyPackageManagerOverride(base, appconfig.PackageManagerOverride{Install: "nice -n19 apt-fast install -y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := InstallPackages(pm, []string{"curl", "git"}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := fake.Commands()
+	if last := commands[len(commands)-1]; last != "sh -c nice -n19 apt-fast install -y curl git" {
+		t.Errorf("команда установки %q, ожидалась переопределенная", last)
+	}
+	if base.Install != "apt install -y" {
+		t.Errorf("исходный менеджер изменен: %q", base.Install)
+	}
+	if pm.Name != "apt" {
+		t.Errorf("переопределение сбросило имя менеджера: %q", pm.Name)
+	}
+}
+
+func TestPackageManagerOverrideEnvTakesPrecedence(t *testing.T) {
+	t.Setenv(pmOverrideEnvPrefix+"INSTALL", "sudo apt install -y")
+	t.Setenv(pmOverrideEnvPrefix+"CLEAN", "apt autoremove -y && apt clean")
+
+	pm, err := ApplyPackageManagerOverride(aptManager(), appconfig.PackageManagerOverride{Install: "apt-fast install -y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.Install != "sudo apt install -y" {
+		t.Errorf("Install = %q, ожидалось значение из окружения", pm.Install)
+	}
+	if pm.Clean != "apt autoremove -y && apt clean" {
+		t.Errorf("Clean = %q, ожидалось значение из окружения", pm.Clean)
+	}
+}
+
+func TestPackageManagerOverrideRejectsShellSyntax(t *testing.T) {
+	for _, override := range []appconfig.PackageManagerOverride{
+		{Install: "apt install -y; rm -rf /"},
+		{Update: "apt update | tee log"},
+		{Remove: "$(which apt) remove -y"},
+		{Check: "   "},
+		{Clean: " "},
+	} {
+		if _, err := ApplyPackageManagerOverride(aptManager(), override); err == nil {
+			t.Errorf("переопределение %+v принято", override)
+		}
+	}
+}
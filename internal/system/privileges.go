@@ -0,0 +1,20 @@
+package system
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrRequiresRoot возвращается проверками, которые без прав root дают только ошибки доступа
+var ErrRequiresRoot = errors.New("требуются права root")
+
+// geteuid возвращает эффективный UID процесса; подменяется в тестах
+var geteuid = os.Geteuid
+
+// RequireRoot возвращает ErrRequiresRoot, если процесс запущен не от root
+func RequireRoot() error {
+	if geteuid() != 0 {
+		return ErrRequiresRoot
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ErrReadOnlyFilesystem возвращается, если изменяемый файл находится на файловой системе только для чтения
+var ErrReadOnlyFilesystem = errors.New("файловая система доступна только для чтения")
+
+// ostreeMarker присутствует на системах, загруженных через ostree (Fedora Silverblue, CoreOS)
+const ostreeMarker = "/run/ostree-booted"
+
+// isWritable проверяет, можно ли изменить файл или создать его в указанной директории
+func isWritable(path string) bool {
+	return probeWrite(path) == nil
+}
+
+// ensureWritable проверяет путь перед изменением и возвращает ErrReadOnlyFilesystem с подсказкой
+func ensureWritable(path string) error {
+	if isWritable(path) {
+		return nil
+	}
+	err := probeWrite(path)
+	if errors.Is(err, syscall.EROFS) {
+		return fmt.Errorf("%w: %s (%s)", ErrReadOnlyFilesystem, path, ReadOnlyHint())
+	}
+	return fmt.Errorf("нет доступа на запись к %s: %w", path, err)
+}
+
+// CheckWritable проверяет, можно ли изменить path (или создать его в родительской директории).
+// Для файловой системы только для чтения возвращается ErrReadOnlyFilesystem с подсказкой.
+func CheckWritable(path string) error {
+	return ensureWritable(path)
+}
+
+// probeWrite пробует открыть файл на запись без изменения содержимого.
+// Для отсутствующих файлов и симлинков проверяется родительская директория.
+func probeWrite(path string) error {
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil && info.Mode().IsRegular():
+		f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	case err == nil && info.IsDir():
+		return probeDir(path)
+	default:
+		return probeDir(filepath.Dir(path))
+	}
+}
+
+func probeDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".go-to-run-probe-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// isOSTree определяет неизменяемую систему на базе ostree
+func isOSTree() bool {
+	_, err := os.Stat(ostreeMarker)
+	return err == nil
+}
+
+// ReadOnlyHint подсказывает, как изменить файлы на файловой системе только для чтения
+func ReadOnlyHint() string {
+	if isOSTree() {
+		return "система на базе ostree: используйте rpm-ostree или измените конфигурацию в /etc через оверлей"
+	}
+	return "перемонтируйте файловую систему на запись: mount -o remount,rw /"
+}
+
+// ostreeInstallError возвращает понятный отказ от установки пакетов на ostree-системе
+func ostreeInstallError(packages []string) error {
+	return fmt.Errorf("%w: установка пакетов на ostree-системе не поддерживается, используйте: rpm-ostree install %s",
+		ErrReadOnlyFilesystem, strings.Join(packages, " "))
+}
@@ -0,0 +1,89 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// readOnlyMount монтирует tmpfs с файлом sshd_config и перемонтирует его только для чтения.
+// Требует прав root, иначе тест пропускается.
+func readOnlyMount(t *testing.T) string {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("монтирование требует прав root")
+	}
+	dir := t.TempDir()
+	if output, err := exec.Command("mount", "-t", "tmpfs", "-o", "size=1m", "tmpfs", dir).CombinedOutput(); err != nil {
+		t.Skipf("не удалось смонтировать tmpfs: %v: %s", err, output)
+	}
+	t.Cleanup(func() { _ = exec.Command("umount", dir).Run() })
+
+	if err := os.WriteFile(filepath.Join(dir, "sshd_config"), []byte("Port 22\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if output, err := exec.Command("mount", "-o", "remount,ro", dir).CombinedOutput(); err != nil {
+		t.Fatalf("не удалось перемонтировать только для чтения: %v: %s", err, output)
+	}
+	return dir
+}
+
+func TestCheckWritableReadOnlyMount(t *testing.T) {
+	dir := readOnlyMount(t)
+	for _, path := range []string{filepath.Join(dir, "sshd_config"), filepath.Join(dir, "missing.conf"), dir} {
+		err := CheckWritable(path)
+		if !errors.Is(err, ErrReadOnlyFilesystem) {
+			t.Errorf("%s: ожидалась ErrReadOnlyFilesystem, получено %v", path, err)
+		}
+		if isWritable(path) {
+			t.Errorf("%s: isWritable = true на файловой системе только для чтения", path)
+		}
+	}
+}
+
+func TestCheckWritableLeavesNoTrace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "existing.conf")
+	if err := os.WriteFile(path, []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{path, filepath.Join(dir, "new.conf"), dir} {
+		if err := CheckWritable(p); err != nil {
+			t.Errorf("%s: %v", p, err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("проверка оставила файлы: %v", entries)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep" {
+		t.Errorf("проверка изменила файл: %q", data)
+	}
+}
+
+func TestSetupSSHRefusesReadOnlyConfig(t *testing.T) {
+	dir := readOnlyMount(t)
+	prev := sshConfigPath
+	sshConfigPath = filepath.Join(dir, "sshd_config")
+	t.Cleanup(func() { sshConfigPath = prev })
+	fake := runner.NewFakeRunner()
+	t.Cleanup(SetCommandRunner(fake))
+
+	err := (&SecurityManager{}).SetupSSH(2222, false, false)
+	if !errors.Is(err, ErrReadOnlyFilesystem) {
+		t.Fatalf("ожидалась ErrReadOnlyFilesystem, получено %v", err)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Fatalf("на файловой системе только для чтения выполнены команды: %q", commands)
+	}
+	if data, _ := os.ReadFile(sshConfigPath); string(data) != "Port 22\n" {
+		t.Fatalf("sshd_config изменен: %q", data)
+	}
+}
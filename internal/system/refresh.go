@@ -0,0 +1,193 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRefreshAttempts - число попыток обновления списка пакетов по умолчанию
+	defaultRefreshAttempts = 3
+	// defaultRefreshBackoff - пауза перед второй попыткой; дальше она удваивается
+	defaultRefreshBackoff = 2 * time.Second
+)
+
+// mirrorFallbackArgs - опции, с которыми менеджер повторно опрашивает зеркала
+// или выбирает другое. Добавляются только к стандартной команде обновления.
+var mirrorFallbackArgs = map[string]string{
+	"apt": "-o Acquire::Retries=3",
+	"dnf": "--setopt=fastestmirror=True",
+	"yum": "--setopt=fastestmirror=1",
+}
+
+// transientRefreshErrors - признаки временных сетевых ошибок, после которых
+// имеет смысл повторить обновление
+var transientRefreshErrors = []string{
+	"temporary failure resolving",
+	"could not resolve",
+	"connection timed out",
+	"operation timed out",
+	"connection refused",
+	"connection reset",
+	"network is unreachable",
+	"failed to fetch",
+	"curl error",
+	"cannot download",
+	"failed to download metadata",
+	"503 service unavailable",
+	"502 bad gateway",
+	"hash sum mismatch",
+}
+
+// mirrorURLPattern находит адреса репозиториев в выводе менеджера
+var mirrorURLPattern = regexp.MustCompile(`(?:https?|ftp)://[^\s'"]+`)
+
+// dnfRepoPattern находит имя репозитория в ошибках dnf/yum
+var dnfRepoPattern = regexp.MustCompile(`for repo(?:sitory)? '([^']+)'`)
+
+// runRefreshCommand выполняет команду обновления списка пакетов.
+// Ошибки разбираются по английским сообщениям менеджеров.
+func runRefreshCommand(command string) ([]byte, error) {
+	return cmdRunner.CombinedOutput("env", cLocale("sh", "-c", command)...)
+}
+
+// refreshSleep - пауза между попытками; подменяется в тестах
+var refreshSleep = time.Sleep
+
+// RefreshError описывает неудачное обновление списка пакетов
+type RefreshError struct {
+	Attempts int
+	// Mirrors - зеркала и репозитории, упомянутые в ошибках
+	Mirrors []string
+	Err     error
+}
+
+func (e *RefreshError) Error() string {
+	msg := fmt.Sprintf("ошибка обновления списка пакетов (попыток: %d)", e.Attempts)
+	if len(e.Mirrors) > 0 {
+		msg += ", недоступны: " + strings.Join(e.Mirrors, ", ")
+	}
+	return msg + ": " + e.Err.Error()
+}
+
+func (e *RefreshError) Unwrap() error {
+	return e.Err
+}
+
+// refreshPackageLists обновляет список пакетов, повторяя попытку при временных
+// сетевых ошибках. Со второй попытки менеджер просится сменить зеркало.
+func refreshPackageLists(pm *PackageManager) error {
+	attempts := pm.RefreshAttempts
+	if attempts <= 0 {
+		attempts = defaultRefreshAttempts
+	}
+	backoff := pm.RefreshBackoff
+	if backoff <= 0 {
+		backoff = defaultRefreshBackoff
+	}
+
+	var mirrors []string
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		command := pm.Update
+		if attempt > 1 {
+			command = withMirrorFallback(pm)
+		}
+
+		output, err := runRefreshCommand(command)
+		if err == nil || refreshSucceeded(pm.Name, err) {
+			return nil
+		}
+
+		lastErr = err
+		mirrors = appendUnique(mirrors, failedMirrors(string(output))...)
+		if !isTransientRefreshError(string(output)) || attempt == attempts {
+			return &RefreshError{Attempts: attempt, Mirrors: mirrors, Err: lastErr}
+		}
+
+		if len(mirrors) > 0 {
+			fmt.Printf("⚠️  Не удалось обновить список пакетов (%s), повтор через %s\n",
+				strings.Join(mirrors, ", "), backoff)
+		} else {
+			fmt.Printf("⚠️  Не удалось обновить список пакетов, повтор через %s\n", backoff)
+		}
+		refreshSleep(backoff)
+		backoff *= 2
+	}
+	return &RefreshError{Attempts: attempts, Mirrors: mirrors, Err: lastErr}
+}
+
+// refreshSucceeded учитывает коды выхода, которые не означают ошибку:
+// dnf/yum check-update возвращают 100, если есть доступные обновления
+func refreshSucceeded(manager string, err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	return (manager == "dnf" || manager == "yum") && exitErr.ExitCode() == 100
+}
+
+// withMirrorFallback возвращает команду обновления с опциями смены зеркала.
+// Переопределенные пользователем команды не изменяются.
+func withMirrorFallback(pm *PackageManager) string {
+	args, ok := mirrorFallbackArgs[pm.Name]
+	if !ok || pm.Update != packageManagers[pm.Name].Update {
+		return pm.Update
+	}
+	return pm.Update + " " + args
+}
+
+// isTransientRefreshError проверяет, похожа ли ошибка на временный сбой сети или зеркала
+func isTransientRefreshError(output string) bool {
+	lower := strings.ToLower(output)
+	for _, marker := range transientRefreshErrors {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// failedMirrors извлекает из вывода менеджера хосты и репозитории, обновить которые не удалось
+func failedMirrors(output string) []string {
+	var mirrors []string
+	for _, line := range strings.Split(output, "\n") {
+		lower := strings.ToLower(line)
+		if !strings.HasPrefix(line, "Err:") && !strings.HasPrefix(line, "E:") &&
+			!strings.HasPrefix(line, "W:") && !strings.Contains(lower, "error") &&
+			!strings.Contains(lower, "cannot download") {
+			continue
+		}
+		for _, raw := range mirrorURLPattern.FindAllString(line, -1) {
+			if u, err := url.Parse(raw); err == nil && u.Host != "" {
+				mirrors = appendUnique(mirrors, u.Host)
+			}
+		}
+		for _, m := range dnfRepoPattern.FindAllStringSubmatch(line, -1) {
+			mirrors = appendUnique(mirrors, m[1])
+		}
+	}
+	return mirrors
+}
+
+// appendUnique добавляет в список значения, которых в нем еще нет
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		found := false
+		for _, existing := range list {
+			if existing == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,134 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// aptMirrorDown - вывод apt update при недоступном зеркале
+const aptMirrorDown = `Hit:1 http://security.ubuntu.com/ubuntu jammy-security InRelease
+Err:2 http://mirror.example.org/ubuntu jammy InRelease
+  Temporary failure resolving 'mirror.example.org'
+W: Failed to fetch http://mirror.example.org/ubuntu/dists/jammy/InRelease  Temporary failure resolving 'mirror.example.org'
+W: Some index files failed to download. They have been ignored, or old ones used instead.
+`
+
+// recordRefreshSleeps заменяет паузы между попытками записью их длительности
+func recordRefreshSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var sleeps []time.Duration
+	prev := refreshSleep
+	refreshSleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	t.Cleanup(func() { refreshSleep = prev })
+	return &sleeps
+}
+
+func TestRefreshRetriesTransientFailure(t *testing.T) {
+	sleeps := recordRefreshSleeps(t)
+	fake := runner.NewFakeRunner().
+		On("env LC_ALL=C sh -c apt update", aptMirrorDown, exitStatus(t, "100")).
+		On("env LC_ALL=C sh -c apt update -o Acquire::Retries=3", "Hit:1 http://mirror.example.org/ubuntu jammy InRelease\n", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	pm := packageManagers["apt"]
+	if err := refreshPackageLists(&pm); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"env LC_ALL=C sh -c apt update", "env LC_ALL=C sh -c apt update -o Acquire::Retries=3"}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, want) {
+		t.Errorf("команды %q, ожидалось %q", commands, want)
+	}
+	if !reflect.DeepEqual(*sleeps, []time.Duration{defaultRefreshBackoff}) {
+		t.Errorf("паузы %v", *sleeps)
+	}
+}
+
+func TestRefreshGivesUpAfterAttempts(t *testing.T) {
+	sleeps := recordRefreshSleeps(t)
+	fake := runner.NewFakeRunner().On("env", aptMirrorDown, exitStatus(t, "100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	pm := packageManagers["apt"]
+	pm.RefreshBackoff = time.Second
+	err := refreshPackageLists(&pm)
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) {
+		t.Fatalf("ожидалась RefreshError, получено %v", err)
+	}
+	if refreshErr.Attempts != defaultRefreshAttempts || !reflect.DeepEqual(refreshErr.Mirrors, []string{"mirror.example.org"}) {
+		t.Errorf("RefreshError = %+v", refreshErr)
+	}
+	if n := len(fake.Commands()); n != defaultRefreshAttempts {
+		t.Errorf("выполнено %d попыток", n)
+	}
+	// Пауза удваивается с каждой попыткой
+	if !reflect.DeepEqual(*sleeps, []time.Duration{time.Second, 2 * time.Second}) {
+		t.Errorf("паузы %v", *sleeps)
+	}
+}
+
+func TestRefreshDoesNotRetryPermanentFailure(t *testing.T) {
+	sleeps := recordRefreshSleeps(t)
+	fake := runner.NewFakeRunner().On("env", "E: The repository 'http://ppa.example.org/x jammy Release' does not have a Release file.\n", exitStatus(t, "100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	pm := packageManagers["apt"]
+	err := refreshPackageLists(&pm)
+	var refreshErr *RefreshError
+	if !errors.As(err, &refreshErr) || refreshErr.Attempts != 1 {
+		t.Fatalf("ошибка %v", err)
+	}
+	if !reflect.DeepEqual(refreshErr.Mirrors, []string{"ppa.example.org"}) {
+		t.Errorf("зеркала %q", refreshErr.Mirrors)
+	}
+	if len(*sleeps) != 0 || len(fake.Commands()) != 1 {
+		t.Errorf("постоянная ошибка повторена: %q", fake.Commands())
+	}
+}
+
+func TestRefreshDnfUpdatesAvailable(t *testing.T) {
+	recordRefreshSleeps(t)
+	// dnf check-update завершается с кодом 100, если есть обновления
+	fake := runner.NewFakeRunner().On("env", dnfCheckUpdate, exitStatus(t, "100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	pm := packageManagers["dnf"]
+	if err := refreshPackageLists(&pm); err != nil {
+		t.Errorf("код 100 у dnf не является ошибкой: %v", err)
+	}
+}
+
+func TestFailedMirrorsDnf(t *testing.T) {
+	output := `Errors during downloading metadata for repository 'updates':
+  - Curl error (28): Timeout was reached for https://mirrors.example.com/fedora/updates/39/repodata/repomd.xml
+Error: Failed to download metadata for repo 'updates': Cannot download repomd.xml
+`
+	want := []string{"updates", "mirrors.example.com"}
+	if got := failedMirrors(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("failedMirrors() = %q, ожидалось %q", got, want)
+	}
+	if !isTransientRefreshError(output) {
+		t.Error("ошибка загрузки метаданных должна считаться временной")
+	}
+}
+
+func TestWithMirrorFallback(t *testing.T) {
+	dnf := packageManagers["dnf"]
+	if got := withMirrorFallback(&dnf); got != "dnf check-update --setopt=fastestmirror=True" {
+		t.Errorf("dnf: %q", got)
+	}
+	pacman := packageManagers["pacman"]
+	if got := withMirrorFallback(&pacman); got != "pacman -Sy" {
+		t.Errorf("pacman: %q", got)
+	}
+	// Переопределенная пользователем команда не изменяется
+	custom := packageManagers["apt"]
+	custom.Update = "apt-fast update"
+	if got := withMirrorFallback(&custom); got != "apt-fast update" {
+		t.Errorf("переопределенная команда: %q", got)
+	}
+}
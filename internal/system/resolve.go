@@ -0,0 +1,189 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnknownPackages возвращается, если часть пакетов отсутствует в репозиториях
+var ErrUnknownPackages = errors.New("пакеты не найдены в репозиториях")
+
+// packageAliases сопоставляет имена пакетов Debian/Ubuntu, используемые в категориях,
+// с именами в других дистрибутивах. Ключ - менеджер пакетов или семейство дистрибутива
+// (PackageManager.Family); имя для семейства имеет приоритет над именем для менеджера
+var packageAliases = map[string]map[string]string{
+	"xz-utils": {
+		"dnf": "xz", "yum": "xz", "pacman": "xz", "zypper": "xz", "apk": "xz",
+	},
+	"p7zip-full": {
+		"dnf": "p7zip", "yum": "p7zip", "pacman": "p7zip", "zypper": "p7zip-full", "apk": "7zip",
+	},
+	"dnsutils": {
+		"dnf": "bind-utils", "yum": "bind-utils", "pacman": "bind", "zypper": "bind-utils", "apk": "bind-tools",
+	},
+	"netcat-openbsd": {
+		"dnf": "nmap-ncat", "yum": "nmap-ncat", "pacman": "openbsd-netcat", "zypper": "netcat-openbsd",
+	},
+	"golang-go": {
+		"dnf": "golang", "yum": "golang", "pacman": "go", "zypper": "go", "apk": "go",
+	},
+	"python3-pip": {
+		"pacman": "python-pip", "apk": "py3-pip",
+	},
+	"mtr-tiny": {
+		"dnf": "mtr", "yum": "mtr", "pacman": "mtr", "zypper": "mtr", "apk": "mtr",
+	},
+	"openssh-client": {
+		"dnf": "openssh-clients", "yum": "openssh-clients", "pacman": "openssh", "zypper": "openssh-clients",
+	},
+	"openssh-server": {
+		"pacman": "openssh",
+	},
+	"build-essential": {
+		"pacman": "base-devel", "apk": "build-base",
+	},
+}
+
+// ResolvePackageName возвращает имя пакета для менеджера pm с учетом различий дистрибутивов
+func ResolvePackageName(pm *PackageManager, name string) string {
+	if alias, ok := packageAliases[name][pm.Family]; ok && pm.Family != "" {
+		return alias
+	}
+	if alias, ok := packageAliases[name][pm.Name]; ok {
+		return alias
+	}
+	return name
+}
+
+// ResolveAndValidate переводит имена пакетов для менеджера pm и одним запросом проверяет
+// их наличие в репозиториях. resolved содержит найденные пакеты в исходном порядке
+// (уже переведенные имена), unknown - исходные имена, которые не удалось найти.
+func ResolveAndValidate(pm *PackageManager, packages []string) (resolved []string, unknown []string, err error) {
+	if len(packages) == 0 {
+		return nil, nil, nil
+	}
+
+	names := make([]string, len(packages))
+	for i, pkg := range packages {
+		names[i] = ResolvePackageName(pm, pkg)
+	}
+
+	available, err := availablePackages(pm, uniqueStrings(names))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, name := range names {
+		if available[name] {
+			resolved = append(resolved, name)
+		} else {
+			unknown = append(unknown, packages[i])
+		}
+	}
+	return resolved, unknown, nil
+}
+
+// availablePackages возвращает множество пакетов из names, известных репозиториям
+func availablePackages(pm *PackageManager, names []string) (map[string]bool, error) {
+	var args []string
+	switch pm.Name {
+	case "apt":
+		args = append([]string{"apt-cache", "policy"}, names...)
+	case "dnf", "yum":
+		args = append([]string{pm.Name, "-q", "list", "--available", "--installed"}, names...)
+	case "pacman":
+		args = append([]string{"pacman", "-Si"}, names...)
+	case "zypper":
+		args = append([]string{"zypper", "--non-interactive", "--quiet", "search", "--match-exact", "-t", "package"}, names...)
+	case "apk":
+		args = append([]string{"apk", "search", "--exact"}, names...)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый менеджер пакетов: %s", pm.Name)
+	}
+
+	// Менеджеры завершаются с ошибкой, если часть пакетов не найдена, - разбираем вывод в любом случае
+	output, err := cmdRunner.CombinedOutput("env", cLocale(args[0], args[1:]...)...)
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("ошибка проверки пакетов: %w", err)
+	}
+
+	return parseAvailablePackages(pm.Name, string(output), names), nil
+}
+
+// parseAvailablePackages разбирает вывод проверки наличия пакетов
+func parseAvailablePackages(manager, output string, names []string) map[string]bool {
+	requested := make(map[string]bool, len(names))
+	for _, name := range names {
+		requested[name] = true
+	}
+	found := make(map[string]bool, len(names))
+	mark := func(name string) {
+		if requested[name] {
+			found[name] = true
+		}
+	}
+
+	lines := strings.Split(output, "\n")
+	switch manager {
+	case "apt":
+		// curl:
+		//   Installed: 7.81.0
+		//   Candidate: 7.81.0
+		for i, line := range lines {
+			if line == "" || line[0] == ' ' || !strings.HasSuffix(line, ":") {
+				continue
+			}
+			name := strings.TrimSuffix(line, ":")
+			// Пакет без кандидата и без установленной версии установить нельзя
+			if i+2 < len(lines) && strings.Contains(lines[i+1], "(none)") && strings.Contains(lines[i+2], "(none)") {
+				continue
+			}
+			mark(name)
+		}
+	case "dnf", "yum":
+		// curl.x86_64   7.85.0-1.fc39   @fedora
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) < 3 || !strings.Contains(fields[0], ".") {
+				continue
+			}
+			mark(fields[0][:strings.LastIndex(fields[0], ".")])
+		}
+	case "pacman":
+		// Name            : curl
+		for _, line := range lines {
+			if key, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(key) == "Name" {
+				mark(strings.TrimSpace(value))
+			}
+		}
+	case "zypper":
+		// i | curl | Утилита ... | package
+		for _, line := range lines {
+			fields := strings.Split(line, "|")
+			if len(fields) >= 3 {
+				mark(strings.TrimSpace(fields[1]))
+			}
+		}
+	case "apk":
+		// curl-8.5.0-r0
+		for _, line := range lines {
+			mark(rpmName(strings.TrimSpace(line)))
+		}
+	}
+	return found
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+const aptCachePolicy = `curl:
+  Installed: 7.81.0-1ubuntu1.16
+  Candidate: 7.81.0-1ubuntu1.16
+  Version table:
+ *** 7.81.0-1ubuntu1.16 500
+        500 http://archive.ubuntu.com/ubuntu jammy-updates/main amd64 Packages
+dnsutils:
+  Installed: (none)
+  Candidate: 1:9.18.18-0ubuntu0.22.04.2
+  Version table:
+virtual-only:
+  Installed: (none)
+  Candidate: (none)
+  Version table:
+N: Unable to locate package no-such-package
+`
+
+func TestResolvePackageName(t *testing.T) {
+	tests := []struct {
+		pm   *PackageManager
+		name string
+		want string
+	}{
+		{&PackageManager{Name: "apt"}, "dnsutils", "dnsutils"},
+		{&PackageManager{Name: "dnf"}, "dnsutils", "bind-utils"},
+		{&PackageManager{Name: "apk"}, "build-essential", "build-base"},
+		{&PackageManager{Name: "pacman"}, "curl", "curl"},
+		{&PackageManager{Name: "zypper"}, "golang-go", "go"},
+	}
+	for _, tt := range tests {
+		if got := ResolvePackageName(tt.pm, tt.name); got != tt.want {
+			t.Errorf("ResolvePackageName(%s, %q) = %q, ожидалось %q", tt.pm.Name, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAndValidateApt(t *testing.T) {
+	fake := runner.NewFakeRunner().On("env", aptCachePolicy, exitStatus(t, "100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	packages := []string{"curl", "no-such-package", "dnsutils", "virtual-only", "curl"}
+	resolved, unknown, err := ResolveAndValidate(&PackageManager{Name: "apt"}, packages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"curl", "dnsutils", "curl"}; !reflect.DeepEqual(resolved, want) {
+		t.Errorf("найдены %q, ожидалось %q", resolved, want)
+	}
+	if want := []string{"no-such-package", "virtual-only"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("не найдены %q, ожидалось %q", unknown, want)
+	}
+	// Все имена проверяются одним запросом, повторы не передаются
+	want := "env LC_ALL=C apt-cache policy curl no-such-package dnsutils virtual-only"
+	if commands := fake.Commands(); len(commands) != 1 || commands[0] != want {
+		t.Errorf("команды %q, ожидалась одна %q", commands, want)
+	}
+}
+
+func TestResolveAndValidateReportsOriginalNames(t *testing.T) {
+	output := "bind-utils.x86_64    32:9.18.24-1.fc39    updates\ncurl.x86_64    8.2.1-4.fc39    @fedora\n"
+	fake := runner.NewFakeRunner().On("env", output, nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	resolved, unknown, err := ResolveAndValidate(&PackageManager{Name: "dnf"}, []string{"dnsutils", "curl", "golang-go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"bind-utils", "curl"}; !reflect.DeepEqual(resolved, want) {
+		t.Errorf("найдены %q, ожидалось %q", resolved, want)
+	}
+	if want := []string{"golang-go"}; !reflect.DeepEqual(unknown, want) {
+		t.Errorf("не найдены %q, ожидались исходные имена %q", unknown, want)
+	}
+	if command := fake.Commands()[0]; !strings.Contains(command, "bind-utils curl golang") {
+		t.Errorf("проверка запрошена не для переведенных имен: %q", command)
+	}
+}
+
+func TestResolveAndValidateErrors(t *testing.T) {
+	fake := runner.NewFakeRunner().On("env", "", errors.New("exec: \"env\": executable file not found"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	if _, _, err := ResolveAndValidate(&PackageManager{Name: "apt"}, []string{"curl"}); err == nil {
+		t.Error("ожидалась ошибка запуска проверки")
+	}
+	if _, _, err := ResolveAndValidate(&PackageManager{Name: "emerge"}, []string{"curl"}); err == nil {
+		t.Error("ожидалась ошибка для неподдерживаемого менеджера")
+	}
+	if resolved, unknown, err := ResolveAndValidate(&PackageManager{Name: "apt"}, nil); resolved != nil || unknown != nil || err != nil {
+		t.Errorf("для пустого списка получено %q, %q, %v", resolved, unknown, err)
+	}
+}
+
+func TestParseAvailablePackages(t *testing.T) {
+	names := []string{"curl", "vim", "htop"}
+	tests := []struct {
+		manager string
+		output  string
+	}{
+		{"pacman", "Repository      : core\nName            : curl\nVersion         : 8.7.1-1\n\nRepository      : extra\nName            : vim\n"},
+		{"zypper", "S | Name | Summary          | Type\n--+------+------------------+--------\n  | curl | A tool           | package\ni | vim  | Vi IMproved      | package\n"},
+		{"apk", "curl-8.5.0-r0\nvim-9.0.2127-r0\n"},
+	}
+	want := map[string]bool{"curl": true, "vim": true}
+	for _, tt := range tests {
+		if got := parseAvailablePackages(tt.manager, tt.output, names); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: найдены %v, ожидалось %v", tt.manager, got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package system
+
+import "fmt"
+
+// Rollback накапливает действия отмены для уже выполненных шагов многошаговой операции.
+// При ошибке на очередном шаге Run отменяет предыдущие шаги в обратном порядке.
+type Rollback struct {
+	steps []rollbackStep
+}
+
+type rollbackStep struct {
+	name string
+	undo func() error
+}
+
+// Add регистрирует действие отмены успешно выполненного шага
+func (r *Rollback) Add(name string, undo func() error) {
+	r.steps = append(r.steps, rollbackStep{name: name, undo: undo})
+}
+
+// Run выполняет действия отмены в обратном порядке и очищает стек.
+// Ошибки отката выводятся, но не прерывают откат остальных шагов.
+func (r *Rollback) Run() {
+	for i := len(r.steps) - 1; i >= 0; i-- {
+		step := r.steps[i]
+		fmt.Printf("↩ Откат: %s\n", step.name)
+		if err := step.undo(); err != nil {
+			fmt.Printf("Ошибка отката (%s): %v\n", step.name, err)
+		}
+	}
+	r.steps = nil
+}
@@ -0,0 +1,66 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRollbackUndoesCompletedStepsInReverseOrder(t *testing.T) {
+	var undone []string
+	steps := []struct {
+		name string
+		err  error
+	}{
+		{"шаг 1", nil},
+		{"шаг 2", nil},
+		{"шаг 3", errors.New("сбой шага 3")},
+		{"шаг 4", nil},
+	}
+
+	rb := &Rollback{}
+	var failed string
+	for _, step := range steps {
+		if step.err != nil {
+			failed = step.name
+			rb.Run()
+			break
+		}
+		name := step.name
+		rb.Add(name, func() error {
+			undone = append(undone, name)
+			return nil
+		})
+	}
+
+	if failed != "шаг 3" {
+		t.Fatalf("ошибка на шаге %q, ожидался шаг 3", failed)
+	}
+	if want := []string{"шаг 2", "шаг 1"}; !reflect.DeepEqual(undone, want) {
+		t.Fatalf("откат: %v, ожидалось %v", undone, want)
+	}
+}
+
+func TestRollbackContinuesAfterUndoError(t *testing.T) {
+	var undone []string
+	rb := &Rollback{}
+	rb.Add("первый", func() error {
+		undone = append(undone, "первый")
+		return nil
+	})
+	rb.Add("второй", func() error {
+		undone = append(undone, "второй")
+		return errors.New("сбой")
+	})
+	rb.Run()
+	if want := []string{"второй", "первый"}; !reflect.DeepEqual(undone, want) {
+		t.Fatalf("откат: %v, ожидалось %v", undone, want)
+	}
+
+	// Стек очищается: повторный Run ничего не делает
+	undone = nil
+	rb.Run()
+	if len(undone) != 0 {
+		t.Fatalf("повторный откат выполнил %v", undone)
+	}
+}
@@ -0,0 +1,34 @@
+package system
+
+import "github.com/13winged/go-to-run/internal/runner"
+
+// cmdRunner запускает внешние команды пакета; в тестах заменяется через SetCommandRunner
+var cmdRunner = runner.NewSwappable(runner.Default)
+
+// SetCommandRunner заменяет запуск команд пакета, например на runner.FakeRunner.
+// nil возвращает реализацию по умолчанию. Сбрасывает Facts. Возвращает функцию восстановления прежнего значения.
+func SetCommandRunner(r runner.CommandRunner) (restore func()) {
+	prev := cmdRunner.Swap(r)
+	// Сведения о системе собраны прежним исполнителем
+	ResetFacts()
+	return func() {
+		cmdRunner.Swap(prev)
+		ResetFacts()
+	}
+}
+
+// shell выполняет команду через sh -c
+func shell(cmd string) error {
+	return cmdRunner.Run("sh", "-c", cmd)
+}
+
+// shellOutput выполняет команду через sh -c и возвращает stdout
+func shellOutput(cmd string) ([]byte, error) {
+	return cmdRunner.Output("sh", "-c", cmd)
+}
+
+// cLocale возвращает аргументы env для запуска команды с LC_ALL=C:
+// вывод менеджеров разбирается по английским сообщениям
+func cLocale(name string, args ...string) []string {
+	return append([]string{"LC_ALL=C", name}, args...)
+}
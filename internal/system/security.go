@@ -1,17 +1,23 @@
 package system
 
 import (
+	"errors"
 	"fmt"
 	"os" // Добавить эту строку
-	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time" // Добавить эту строку
 
-	"github.com/briandowns/spinner"
+	appconfig "github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/ui"
 )
 
 // SecurityManager управляет настройками безопасности
-type SecurityManager struct{}
+type SecurityManager struct {
+	// SSHBackupKeep - сколько последних бэкапов sshd_config хранить; 0 означает значение по умолчанию
+	SSHBackupKeep int
+}
 
 // FirewallConfig содержит настройки фаервола
 type FirewallConfig struct {
@@ -45,8 +51,7 @@ func (sm *SecurityManager) SetupFirewall(config *FirewallConfig) error {
 		}
 	}
 
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Настройка фаервола..."
+	s := ui.NewSpinner("Настройка фаервола...")
 	s.Start()
 	defer s.Stop()
 
@@ -65,27 +70,45 @@ func (sm *SecurityManager) SetupFirewall(config *FirewallConfig) error {
 
 	// Сбрасываем правила если фаервол отключен
 	if strings.Contains(status, "Status: inactive") {
+		// Правила и политики сохраняются до сброса, чтобы ошибка на любом следующем шаге
+		// вернула UFW в прежнее состояние, а не оставила его пустым
+		backupDir, saved, err := sm.backupUFWState()
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения правил UFW: %w", err)
+		}
+		defer os.RemoveAll(backupDir)
+		rb := &Rollback{}
+		rb.Add("восстановление правил и политик UFW", func() error {
+			return sm.restoreUFWState(saved)
+		})
+
 		if err := sm.resetUFW(); err != nil {
+			rb.Run()
 			return fmt.Errorf("ошибка сброса UFW: %w", err)
 		}
 
 		// Настраиваем политики по умолчанию
 		if err := sm.setDefaultPolicies(); err != nil {
+			rb.Run()
 			return fmt.Errorf("ошибка настройки политик: %w", err)
 		}
 
 		// Применяем правила
 		if err := sm.applyRules(config); err != nil {
+			rb.Run()
 			return fmt.Errorf("ошибка применения правил: %w", err)
 		}
 
 		// Включаем логирование
 		if err := sm.enableLogging(); err != nil {
+			rb.Run()
 			return fmt.Errorf("ошибка включения логирования: %w", err)
 		}
+		rb.Add("отключение логирования UFW", sm.disableLogging)
 
 		// Включаем фаервол
 		if err := sm.enableUFW(); err != nil {
+			rb.Run()
 			return fmt.Errorf("ошибка включения UFW: %w", err)
 		}
 	}
@@ -95,10 +118,52 @@ func (sm *SecurityManager) SetupFirewall(config *FirewallConfig) error {
 	return nil
 }
 
+// ApplyFirewallRules добавляет правила config к уже активному UFW без сброса: правила,
+// добавленные ранее, сохраняются, повторное добавление существующего правила ufw пропускает.
+// Неактивный или не установленный UFW настраивается целиком через SetupFirewall.
+func (sm *SecurityManager) ApplyFirewallRules(config *FirewallConfig) error {
+	if !config.Enabled || !sm.isUFWInstalled() {
+		return sm.SetupFirewall(config)
+	}
+	status, err := sm.getUFWStatus()
+	if err != nil {
+		return fmt.Errorf("ошибка получения статуса UFW: %w", err)
+	}
+	if !strings.Contains(status, "Status: active") {
+		return sm.SetupFirewall(config)
+	}
+
+	s := ui.NewSpinner("Применение правил фаервола...")
+	s.Start()
+	defer s.Stop()
+
+	// Ошибка на любом правиле возвращает набор правил, действовавший до вызова
+	backupDir, saved, err := sm.backupUFWState()
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения правил UFW: %w", err)
+	}
+	defer os.RemoveAll(backupDir)
+	rb := &Rollback{}
+	rb.Add("восстановление правил и политик UFW", func() error {
+		if err := sm.restoreUFWState(saved); err != nil {
+			return err
+		}
+		return cmdRunner.Run("ufw", "reload")
+	})
+
+	if err := sm.applyRules(config); err != nil {
+		rb.Run()
+		return fmt.Errorf("ошибка применения правил: %w", err)
+	}
+
+	fmt.Println("Правила фаервола применены")
+	sm.showUFWStatus()
+	return nil
+}
+
 // SetupFail2ban настраивает Fail2ban
 func (sm *SecurityManager) SetupFail2ban() error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Настройка Fail2ban..."
+	s := ui.NewSpinner("Настройка Fail2ban...")
 	s.Start()
 	defer s.Stop()
 
@@ -123,25 +188,50 @@ func (sm *SecurityManager) SetupFail2ban() error {
 	return nil
 }
 
-// SetupSSH настраивает SSH
+// SetupSSH настраивает SSH с рекомендуемыми настройками по умолчанию
 func (sm *SecurityManager) SetupSSH(port int, allowRoot bool, passwordAuth bool) error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Настройка SSH..."
+	return sm.SetupSSHWithHardening(port, &allowRoot, &passwordAuth, appconfig.DefaultSSHHardening())
+}
+
+// SetupSSHWithHardening настраивает SSH и записывает заданный блок рекомендуемых настроек.
+// nil в allowRoot или passwordAuth оставляет директиву PermitRootLogin или
+// PasswordAuthentication без изменений; nil или Disabled в hardening удаляют ранее записанный блок.
+func (sm *SecurityManager) SetupSSHWithHardening(port int, allowRoot, passwordAuth *bool, hardening *appconfig.SSHHardening) error {
+	s := ui.NewSpinner("Настройка SSH...")
 	s.Start()
 	defer s.Stop()
 
+	// Проверяем возможность записи до создания бэкапа
+	if err := ensureWritable(sshConfigPath); err != nil {
+		return err
+	}
+
 	// Создаем резервную копию конфигурации
-	if err := sm.backupSSHConfig(); err != nil {
+	backupPath, err := sm.backupSSHConfig()
+	if err != nil {
 		return fmt.Errorf("ошибка создания бэкапа SSH: %w", err)
 	}
 
+	// Любая ошибка дальше восстанавливает конфигурацию из бэкапа
+	rb := &Rollback{}
+	rb.Add("восстановление sshd_config из "+backupPath, func() error {
+		return sm.restoreSSHBackup(backupPath)
+	})
+
 	// Настраиваем SSH
-	if err := sm.configureSSH(port, allowRoot, passwordAuth); err != nil {
+	if err := sm.configureSSH(port, allowRoot, passwordAuth, hardening); err != nil {
+		rb.Run()
 		return fmt.Errorf("ошибка настройки SSH: %w", err)
 	}
 
 	// Перезапускаем службу SSH
 	if err := sm.restartSSH(); err != nil {
+		// Сначала восстанавливается бэкап, затем sshd перезапускается уже с прежней конфигурацией
+		rb.Run()
+		fmt.Println("↩ Откат: перезапуск SSH с прежней конфигурацией")
+		if restartErr := sm.restartSSH(); restartErr != nil {
+			fmt.Printf("Ошибка отката (перезапуск SSH): %v\n", restartErr)
+		}
 		return fmt.Errorf("ошибка перезапуска SSH: %w", err)
 	}
 
@@ -152,21 +242,26 @@ func (sm *SecurityManager) SetupSSH(port int, allowRoot bool, passwordAuth bool)
 // Helper методы
 
 func (sm *SecurityManager) isUFWInstalled() bool {
-	_, err := exec.LookPath("ufw")
+	_, err := cmdRunner.LookPath("ufw")
 	return err == nil
 }
 
 func (sm *SecurityManager) installUFW() error {
-	pm, err := (&PackageManagerDetector{}).Detect()
+	pm, err := DetectPackageManager()
 	if err != nil {
 		return fmt.Errorf("ошибка определения менеджера пакетов: %w", err)
 	}
 	cmd := fmt.Sprintf("%s ufw", pm.Install)
-	return exec.Command("sh", "-c", cmd).Run()
+	if err := shell(cmd); err != nil {
+		return err
+	}
+	// Фаервол в Facts определялся до установки ufw
+	ResetFacts()
+	return nil
 }
 
 func (sm *SecurityManager) getUFWStatus() (string, error) {
-	output, err := exec.Command("ufw", "status").Output()
+	output, err := cmdRunner.Output("ufw", "status")
 	if err != nil {
 		return "", fmt.Errorf("ошибка получения статуса UFW: %w", err)
 	}
@@ -174,16 +269,56 @@ func (sm *SecurityManager) getUFWStatus() (string, error) {
 }
 
 func (sm *SecurityManager) resetUFW() error {
-	return exec.Command("ufw", "--force", "reset").Run()
+	return cmdRunner.Run("ufw", "--force", "reset")
+}
+
+// ufwStateFiles - файлы с правилами, политиками и настройками UFW
+var ufwStateFiles = []string{"/etc/ufw/user.rules", "/etc/ufw/user6.rules", "/etc/ufw/ufw.conf", "/etc/default/ufw"}
+
+// backupUFWState копирует существующие файлы ufwStateFiles во временную директорию dir.
+// saved сопоставляет исходный путь с копией
+func (sm *SecurityManager) backupUFWState() (dir string, saved map[string]string, err error) {
+	dir, err = os.MkdirTemp("", "go-to-run-ufw-")
+	if err != nil {
+		return "", nil, fmt.Errorf("ошибка создания временной директории: %w", err)
+	}
+	saved = make(map[string]string)
+	for i, path := range ufwStateFiles {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		copyPath := filepath.Join(dir, strconv.Itoa(i))
+		if err := cmdRunner.Run("cp", "-p", path, copyPath); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("ошибка копирования %s: %w", path, err)
+		}
+		saved[path] = copyPath
+	}
+	return dir, saved, nil
+}
+
+// restoreUFWState возвращает файлы, сохраненные backupUFWState, на место
+func (sm *SecurityManager) restoreUFWState(saved map[string]string) error {
+	var errs []error
+	for _, path := range ufwStateFiles {
+		copyPath, ok := saved[path]
+		if !ok {
+			continue
+		}
+		if err := cmdRunner.Run("cp", "-p", copyPath, path); err != nil {
+			errs = append(errs, fmt.Errorf("ошибка восстановления %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
 func (sm *SecurityManager) setDefaultPolicies() error {
 	// Отключаем входящие соединения по умолчанию
-	if err := exec.Command("ufw", "default", "deny", "incoming").Run(); err != nil {
+	if err := cmdRunner.Run("ufw", "default", "deny", "incoming"); err != nil {
 		return fmt.Errorf("ошибка установки политики для входящих соединений: %w", err)
 	}
 	// Разрешаем исходящие соединения по умолчанию
-	if err := exec.Command("ufw", "default", "allow", "outgoing").Run(); err != nil {
+	if err := cmdRunner.Run("ufw", "default", "allow", "outgoing"); err != nil {
 		return fmt.Errorf("ошибка установки политики для исходящих соединений: %w", err)
 	}
 	return nil
@@ -218,8 +353,12 @@ func (sm *SecurityManager) applyRules(config *FirewallConfig) error {
 		}
 	}
 
-	// Разрешаем указанные IP-адреса
-	for _, ip := range config.AllowIPs {
+	// Разрешаем указанные IP-адреса в каноническом виде и без дубликатов
+	allowIPs, err := appconfig.NormalizeAllowIPs(config.AllowIPs)
+	if err != nil {
+		return err
+	}
+	for _, ip := range allowIPs {
 		if err := sm.allowIP(ip); err != nil {
 			return err
 		}
@@ -230,7 +369,7 @@ func (sm *SecurityManager) applyRules(config *FirewallConfig) error {
 
 func (sm *SecurityManager) addPortRule(port int, protocol, comment string) error {
 	cmd := fmt.Sprintf("ufw allow %d/%s comment '%s'", port, protocol, comment)
-	return exec.Command("sh", "-c", cmd).Run()
+	return shell(cmd)
 }
 
 func (sm *SecurityManager) addCustomRule(rule FirewallRule) error {
@@ -248,47 +387,51 @@ func (sm *SecurityManager) addCustomRule(rule FirewallRule) error {
 		cmd += fmt.Sprintf(" comment '%s'", rule.Comment)
 	}
 
-	return exec.Command("sh", "-c", cmd).Run()
+	return shell(cmd)
 }
 
 func (sm *SecurityManager) allowIP(ip string) error {
-	return exec.Command("ufw", "allow", "from", ip).Run()
+	return cmdRunner.Run("ufw", "allow", "from", ip)
 }
 
 func (sm *SecurityManager) enableLogging() error {
-	return exec.Command("ufw", "logging", "on").Run()
+	return cmdRunner.Run("ufw", "logging", "on")
+}
+
+func (sm *SecurityManager) disableLogging() error {
+	return cmdRunner.Run("ufw", "logging", "off")
 }
 
 func (sm *SecurityManager) enableUFW() error {
-	return exec.Command("sh", "-c", "yes | ufw enable").Run()
+	return shell("yes | ufw enable")
 }
 
 func (sm *SecurityManager) showUFWStatus() {
-	output, err := exec.Command("ufw", "status", "verbose").Output()
+	output, err := cmdRunner.Output("ufw", "status", "verbose")
 	if err == nil {
 		fmt.Println(string(output))
 	}
 }
 
 func (sm *SecurityManager) showUFWRules() {
-	output, err := exec.Command("ufw", "status", "numbered").Output()
+	output, err := cmdRunner.Output("ufw", "status", "numbered")
 	if err == nil {
 		fmt.Println(string(output))
 	}
 }
 
 func (sm *SecurityManager) isFail2banInstalled() bool {
-	_, err := exec.LookPath("fail2ban-client")
+	_, err := cmdRunner.LookPath("fail2ban-client")
 	return err == nil
 }
 
 func (sm *SecurityManager) installFail2ban() error {
-	pm, err := (&PackageManagerDetector{}).Detect()
+	pm, err := DetectPackageManager()
 	if err != nil {
 		return fmt.Errorf("ошибка определения менеджера пакетов: %w", err)
 	}
 	cmd := fmt.Sprintf("%s fail2ban", pm.Install)
-	return exec.Command("sh", "-c", cmd).Run()
+	return shell(cmd)
 }
 
 func (sm *SecurityManager) createFail2banConfig() error {
@@ -306,7 +449,7 @@ backend = %(sshd_backend)s
 `
 
 	configPath := "/etc/fail2ban/jail.local"
-	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+	if err := writeFileMode(configPath, []byte(config), sensitiveFileMode); err != nil {
 		return fmt.Errorf("ошибка записи конфигурации Fail2ban: %w", err)
 	}
 	return nil
@@ -314,26 +457,37 @@ backend = %(sshd_backend)s
 
 func (sm *SecurityManager) restartFail2ban() error {
 	// Включаем автозагрузку
-	if err := exec.Command("systemctl", "enable", "fail2ban").Run(); err != nil {
+	if err := cmdRunner.Run("systemctl", "enable", "fail2ban"); err != nil {
 		return fmt.Errorf("ошибка включения автозагрузки Fail2ban: %w", err)
 	}
 	// Перезапускаем службу
-	if err := exec.Command("systemctl", "restart", "fail2ban").Run(); err != nil {
+	if err := cmdRunner.Run("systemctl", "restart", "fail2ban"); err != nil {
 		return fmt.Errorf("ошибка перезапуска Fail2ban: %w", err)
 	}
 	return nil
 }
 
-func (sm *SecurityManager) backupSSHConfig() error {
-	backupCmd := "cp /etc/ssh/sshd_config /etc/ssh/sshd_config.backup.$(date +%Y%m%d%H%M%S)"
-	if err := exec.Command("sh", "-c", backupCmd).Run(); err != nil {
-		return fmt.Errorf("ошибка создания бэкапа SSH конфигурации: %w", err)
+func (sm *SecurityManager) backupSSHConfig() (string, error) {
+	backupPath := sshBackupPrefix() + time.Now().Format(sshBackupTimeFormat)
+	if err := cmdRunner.Run("cp", "-p", sshConfigPath, backupPath); err != nil {
+		return "", fmt.Errorf("ошибка создания бэкапа SSH конфигурации: %w", err)
+	}
+	// Ошибка удаления старых бэкапов не мешает настройке
+	if err := pruneSSHBackups(sshBackupPrefix(), sm.backupKeep()); err != nil {
+		fmt.Printf("⚠️  Не удалось удалить старые бэкапы SSH: %v\n", err)
+	}
+	return backupPath, nil
+}
+
+func (sm *SecurityManager) restoreSSHBackup(backupPath string) error {
+	if err := cmdRunner.Run("cp", "-p", backupPath, sshConfigPath); err != nil {
+		return fmt.Errorf("ошибка восстановления SSH конфигурации: %w", err)
 	}
 	return nil
 }
 
-func (sm *SecurityManager) configureSSH(port int, allowRoot, passwordAuth bool) error {
-	configPath := "/etc/ssh/sshd_config"
+func (sm *SecurityManager) configureSSH(port int, allowRoot, passwordAuth *bool, hardening *appconfig.SSHHardening) error {
+	configPath := sshConfigPath
 	config, err := os.ReadFile(configPath)
 	if err != nil {
 		return fmt.Errorf("ошибка чтения SSH конфигурации: %w", err)
@@ -355,52 +509,79 @@ func (sm *SecurityManager) configureSSH(port int, allowRoot, passwordAuth bool)
 		switch {
 		case strings.HasPrefix(trimmed, "Port "):
 			newLines = append(newLines, fmt.Sprintf("Port %d", port))
-		case strings.HasPrefix(trimmed, "PermitRootLogin "):
-			value := "no"
-			if allowRoot {
-				value = "yes"
-			}
-			newLines = append(newLines, fmt.Sprintf("PermitRootLogin %s", value))
-		case strings.HasPrefix(trimmed, "PasswordAuthentication "):
-			value := "no"
-			if passwordAuth {
-				value = "yes"
-			}
-			newLines = append(newLines, fmt.Sprintf("PasswordAuthentication %s", value))
+		case strings.HasPrefix(trimmed, "PermitRootLogin ") && allowRoot != nil:
+			newLines = append(newLines, "PermitRootLogin "+sshYesNo(*allowRoot))
+		case strings.HasPrefix(trimmed, "PasswordAuthentication ") && passwordAuth != nil:
+			newLines = append(newLines, "PasswordAuthentication "+sshYesNo(*passwordAuth))
 		default:
 			newLines = append(newLines, line)
 		}
 	}
 
-	// Добавляем рекомендуемые настройки
-	recommendedSettings := []string{
-		"",
-		"# Additional security settings",
-		"Protocol 2",
-		"ClientAliveInterval 300",
-		"ClientAliveCountMax 2",
-		"MaxAuthTries 3",
-		"MaxSessions 10",
-		"X11Forwarding no",
-	}
+	// Записываем блок рекомендуемых настроек
+	newLines = applySSHHardening(newLines, hardening)
 
-	newLines = append(newLines, recommendedSettings...)
-
-	if err := os.WriteFile(configPath, []byte(strings.Join(newLines, "\n")), 0644); err != nil {
+	if err := writeFileMode(configPath, []byte(strings.Join(newLines, "\n")), sensitiveFileMode); err != nil {
 		return fmt.Errorf("ошибка записи SSH конфигурации: %w", err)
 	}
 	return nil
 }
 
+// sshYesNo возвращает значение директивы sshd для флага
+func sshYesNo(flag bool) string {
+	if flag {
+		return "yes"
+	}
+	return "no"
+}
+
+// SSHServiceUnits возвращает установленные юниты службы SSH: ssh.service (Debian, Ubuntu)
+// и/или sshd.service (RHEL, Arch, SUSE). Пустой список - сервер SSH не установлен.
+func SSHServiceUnits() ([]string, error) {
+	output, err := cmdRunner.Output("systemctl", "list-unit-files", "--no-legend", "--plain", "ssh.service", "sshd.service")
+	// Без совпадений новые версии systemctl завершаются с ошибкой и пустым выводом
+	if err != nil && len(strings.TrimSpace(string(output))) > 0 {
+		return nil, fmt.Errorf("ошибка получения юнитов SSH: %w", err)
+	}
+	var units []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && strings.HasSuffix(fields[0], ".service") {
+			units = append(units, fields[0])
+		}
+	}
+	return units, nil
+}
+
+// sshServiceUnit возвращает юнит службы SSH для перезапуска: ssh (Debian, Ubuntu), если он
+// установлен, иначе найденный sshd (RHEL, Arch, SUSE). Без найденных юнитов используется ssh
+func sshServiceUnit() string {
+	units, err := SSHServiceUnits()
+	if err != nil || len(units) == 0 || containsString(units, "ssh.service") {
+		return "ssh"
+	}
+	return strings.TrimSuffix(units[0], ".service")
+}
+
 func (sm *SecurityManager) restartSSH() error {
-	if err := exec.Command("systemctl", "restart", "ssh").Run(); err != nil {
+	if err := cmdRunner.Run("systemctl", "restart", sshServiceUnit()); err != nil {
 		return fmt.Errorf("ошибка перезапуска SSH службы: %w", err)
 	}
 	return nil
 }
 
+// SecurityCheckOptions задает параметры проверки безопасности
+type SecurityCheckOptions struct {
+	// FixWorldWritable снимает бит записи для остальных с найденных файлов
+	FixWorldWritable bool
+}
+
 // CheckSecurity проверяет безопасность системы
 func (sm *SecurityManager) CheckSecurity() error {
+	return sm.CheckSecurityWithOptions(SecurityCheckOptions{})
+}
+
+// CheckSecurityWithOptions проверяет безопасность системы и при необходимости исправляет найденное
+func (sm *SecurityManager) CheckSecurityWithOptions(opts SecurityCheckOptions) error {
 	fmt.Println("Проверка безопасности системы...")
 
 	// Проверяем открытые порты
@@ -423,12 +604,102 @@ func (sm *SecurityManager) CheckSecurity() error {
 	fmt.Println("\n4. Проверка Fail2ban:")
 	sm.checkFail2ban()
 
+	// Проверяем файлы, доступные на запись всем
+	fmt.Println("\n5. Проверка файлов, доступных на запись всем:")
+	if err := sm.checkWorldWritable(opts.FixWorldWritable); err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+	}
+
+	// Проверяем конфигурации, не объединенные после обновления пакетов
+	fmt.Println("\n6. Проверка необъединенных конфигураций:")
+	if err := sm.checkConfigMerges(); err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+	}
+
+	// Проверяем действующие настройки входа по SSH
+	fmt.Println("\n7. Проверка настроек SSH:")
+	if err := sm.checkSSHConfig(); err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+	}
+
+	// Проверяем, кто чаще всего упирается в фаервол
+	fmt.Println("\n8. Журнал фаервола:")
+	if err := sm.checkFirewallLog(); err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+	}
+
+	return nil
+}
+
+// firewallAuditLines - сколько последних записей журнала фаервола учитывается в проверке
+const firewallAuditLines = 1000
+
+func (sm *SecurityManager) checkFirewallLog() error {
+	entries, err := sm.GetFirewallLog(firewallAuditLines)
+	if err != nil {
+		return err
+	}
+	summary := SummarizeFirewallLog(entries, 5)
+	if summary.Blocked == 0 {
+		fmt.Println("Заблокированных соединений в журнале нет")
+		return nil
+	}
+	fmt.Printf("Заблокировано пакетов: %d (последние %d записей)\n", summary.Blocked, len(entries))
+	fmt.Println("Частые источники:")
+	for _, c := range summary.TopSources {
+		fmt.Printf("  %-40s %d\n", c.Key, c.Count)
+	}
+	fmt.Println("Частые порты:")
+	for _, c := range summary.TopPorts {
+		fmt.Printf("  %-40s %d\n", c.Key, c.Count)
+	}
+	return nil
+}
+
+func (sm *SecurityManager) checkConfigMerges() error {
+	conflicts, err := FindPendingConfigMerges()
+	if err != nil {
+		return err
+	}
+	if len(conflicts) == 0 {
+		fmt.Println("Необъединенных конфигураций не найдено")
+		return nil
+	}
+
+	fmt.Printf("⚠️  Найдено %d необъединенных конфигураций:\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Printf("  %s (%s)\n", c.Base, c.Kind)
+	}
+	return nil
+}
+
+func (sm *SecurityManager) checkWorldWritable(fix bool) error {
+	found, err := scanWorldWritable(worldWritableRoots())
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		fmt.Println("Файлов, доступных на запись всем, не найдено")
+		return nil
+	}
+
+	fmt.Printf("Найдено %d файлов, доступных на запись всем:\n", len(found))
+	for _, path := range found {
+		fmt.Printf("  %s\n", path)
+	}
+	if !fix {
+		return nil
+	}
+	if err := fixWorldWritable(found); err != nil {
+		return err
+	}
+	fmt.Println("Бит записи для остальных снят")
 	return nil
 }
 
 func (sm *SecurityManager) checkOpenPorts() error {
 	cmd := "ss -tulpn | grep LISTEN"
-	output, err := exec.Command("sh", "-c", cmd).Output()
+	output, err := shellOutput(cmd)
 	if err != nil {
 		return fmt.Errorf("ошибка проверки открытых портов: %w", err)
 	}
@@ -445,7 +716,11 @@ func (sm *SecurityManager) checkOpenPorts() error {
 }
 
 func (sm *SecurityManager) checkSecurityUpdates() error {
-	pm, err := (&PackageManagerDetector{}).Detect()
+	pm, err := DetectPackageManager()
+	if errors.Is(err, ErrNoPackageManager) {
+		notifyNoPackageManager()
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка определения менеджера пакетов: %w", err)
 	}
@@ -469,19 +744,17 @@ func (sm *SecurityManager) checkSecurityUpdates() error {
 }
 
 func (sm *SecurityManager) checkUFW() {
-	if sm.isUFWInstalled() {
-		status, err := sm.getUFWStatus()
-		if err == nil {
-			fmt.Printf("UFW статус: %s", status)
-		}
-	} else {
-		fmt.Println("UFW не установлен")
+	info, err := sm.FirewallStatus()
+	if err != nil {
+		fmt.Printf("Фаервол: %v\n", err)
+		return
 	}
+	fmt.Println(FormatFirewallInfo(info))
 }
 
 func (sm *SecurityManager) checkFail2ban() {
 	if sm.isFail2banInstalled() {
-		output, err := exec.Command("fail2ban-client", "status").Output()
+		output, err := cmdRunner.Output("fail2ban-client", "status")
 		if err == nil {
 			fmt.Printf("Fail2ban статус:\n%s", string(output))
 		}
@@ -0,0 +1,280 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// useSSHConfig подменяет sshd_config временным файлом на время теста
+func useSSHConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "sshd_config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	prev := sshConfigPath
+	sshConfigPath = path
+	t.Cleanup(func() { sshConfigPath = prev })
+	return path
+}
+
+// listSSHUnits - запрос установленных юнитов SSH перед перезапуском службы
+const listSSHUnits = "systemctl list-unit-files --no-legend --plain ssh.service sshd.service"
+
+func TestRestartSSHUsesInstalledUnit(t *testing.T) {
+	tests := []struct {
+		units string
+		want  string
+	}{
+		{"ssh.service enabled enabled\n", "systemctl restart ssh"},
+		{"sshd.service enabled disabled\n", "systemctl restart sshd"},
+		// В Debian sshd.service - псевдоним ssh.service
+		{"ssh.service enabled enabled\nsshd.service alias -\n", "systemctl restart ssh"},
+		{"", "systemctl restart ssh"},
+	}
+	for _, tt := range tests {
+		fake := runner.NewFakeRunner().On(listSSHUnits, tt.units, nil)
+		restore := SetCommandRunner(fake)
+		err := (&SecurityManager{}).restartSSH()
+		restore()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if commands := fake.Commands(); commands[len(commands)-1] != tt.want {
+			t.Errorf("%q: команды %q, ожидался перезапуск %q", tt.units, commands, tt.want)
+		}
+	}
+}
+
+func TestSetupSSHRestoresBackupBeforeRestart(t *testing.T) {
+	configPath := useSSHConfig(t, "Port 22\nPermitRootLogin yes\n")
+	fake := runner.NewFakeRunner().
+		On("systemctl restart ssh", "", errors.New("exit status 1")).
+		On("systemctl restart ssh", "", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	sm := &SecurityManager{}
+	if err := sm.SetupSSH(2222, false, false); err == nil {
+		t.Fatal("ожидалась ошибка перезапуска SSH")
+	}
+
+	commands := fake.Commands()
+	if len(commands) != 6 {
+		t.Fatalf("команды: %q", commands)
+	}
+	backup := strings.Fields(commands[0])[3]
+	want := []string{
+		"cp -p " + configPath + " " + backup,
+		listSSHUnits,
+		"systemctl restart ssh",
+		"cp -p " + backup + " " + configPath,
+		listSSHUnits,
+		"systemctl restart ssh",
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Fatalf("команды:\n%q\nожидалось:\n%q", commands, want)
+	}
+}
+
+func TestSetupSSHWritesConfig(t *testing.T) {
+	configPath := useSSHConfig(t, "Port 22\nPasswordAuthentication yes\n")
+	fake := runner.NewFakeRunner()
+	t.Cleanup(SetCommandRunner(fake))
+
+	sm := &SecurityManager{}
+	if err := sm.SetupSSHWithHardening(2222, appconfig.Bool(false), appconfig.Bool(false), nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Port 2222", "PasswordAuthentication no"} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("в sshd_config нет %q:\n%s", line, data)
+		}
+	}
+	if last := fake.Commands()[len(fake.Commands())-1]; last != "systemctl restart ssh" {
+		t.Errorf("последняя команда %q, ожидался перезапуск SSH", last)
+	}
+}
+
+func TestSetupSSHKeepsUnsetAccessDirectives(t *testing.T) {
+	configPath := useSSHConfig(t, "Port 22\nPermitRootLogin prohibit-password\nPasswordAuthentication no\n")
+	t.Cleanup(SetCommandRunner(runner.NewFakeRunner()))
+
+	if err := (&SecurityManager{}).SetupSSHWithHardening(2222, nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Port 2222", "PermitRootLogin prohibit-password", "PasswordAuthentication no"} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("в sshd_config нет %q:\n%s", line, data)
+		}
+	}
+}
+
+// useUFWState подменяет файлы состояния UFW временными копиями
+func useUFWState(t *testing.T) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"user.rules", "user6.rules"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("### tuple ### allow tcp 8080\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	prev := ufwStateFiles
+	ufwStateFiles = files
+	t.Cleanup(func() { ufwStateFiles = prev })
+	return files
+}
+
+func TestSetupFirewallAppliesRules(t *testing.T) {
+	useUFWState(t)
+	fake := runner.NewFakeRunner().On("ufw status", "Status: inactive\n", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	sm := &SecurityManager{}
+	err := sm.SetupFirewall(&FirewallConfig{
+		Enabled:   true,
+		SSHPort:   22,
+		OpenPorts: []int{80, 22},
+		AllowIPs:  []string{"10.0.0.1"},
+		Rules:     []FirewallRule{{Port: 53, Protocol: "udp", Action: "deny"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ufw []string
+	for _, command := range fake.Commands() {
+		if strings.HasPrefix(command, "ufw ") || strings.HasPrefix(command, "sh -c ") {
+			ufw = append(ufw, command)
+		}
+	}
+	want := []string{
+		"ufw status",
+		"ufw --force reset",
+		"ufw default deny incoming",
+		"ufw default allow outgoing",
+		"sh -c ufw allow 22/tcp comment 'SSH access'",
+		"sh -c ufw allow 80/tcp comment 'Port 80'",
+		"sh -c ufw deny 53/udp",
+		"ufw allow from 10.0.0.1/32",
+		"ufw logging on",
+		"sh -c yes | ufw enable",
+		"ufw status verbose",
+	}
+	if !reflect.DeepEqual(ufw, want) {
+		t.Fatalf("команды:\n%q\nожидалось:\n%q", ufw, want)
+	}
+}
+
+func TestSetupFirewallRestoresPreviousRulesOnFailure(t *testing.T) {
+	files := useUFWState(t)
+	fake := runner.NewFakeRunner().
+		On("ufw status", "Status: inactive\n", nil).
+		On("ufw allow from 10.0.0.1/32", "", errors.New("exit status 1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	sm := &SecurityManager{}
+	err := sm.SetupFirewall(&FirewallConfig{Enabled: true, SSHPort: 22, AllowIPs: []string{"10.0.0.1"}})
+	if err == nil {
+		t.Fatal("ожидалась ошибка применения правил")
+	}
+
+	commands := fake.Commands()
+	var backups, restores []string
+	reset := -1
+	for i, command := range commands {
+		fields := strings.Fields(command)
+		switch {
+		case command == "ufw --force reset":
+			reset = i
+		case fields[0] == "cp" && reset < 0:
+			backups = append(backups, fields[2])
+		case fields[0] == "cp":
+			restores = append(restores, fields[3])
+		}
+	}
+	if reset < 0 {
+		t.Fatalf("UFW не сбрасывался: %q", commands)
+	}
+	if !reflect.DeepEqual(backups, files) || !reflect.DeepEqual(restores, files) {
+		t.Fatalf("сохранены %q, восстановлены %q, ожидалось %q", backups, restores, files)
+	}
+	if last := commands[len(commands)-1]; !strings.HasPrefix(last, "cp -p ") {
+		t.Fatalf("откат должен завершаться восстановлением файлов, последняя команда %q", last)
+	}
+}
+
+func TestSetupFirewallKeepsActiveFirewall(t *testing.T) {
+	fake := runner.NewFakeRunner().On("ufw status", "Status: active\n", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	sm := &SecurityManager{}
+	if err := sm.SetupFirewall(&FirewallConfig{Enabled: true, SSHPort: 22}); err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range fake.Commands() {
+		if strings.Contains(command, "reset") || strings.Contains(command, "allow") {
+			t.Fatalf("активный фаервол изменен: %q", fake.Commands())
+		}
+	}
+}
+
+func TestSetupFirewallNormalizesAllowIPs(t *testing.T) {
+	useUFWState(t)
+	fake := runner.NewFakeRunner().On("ufw status", "Status: inactive\n", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	sm := &SecurityManager{}
+	err := sm.SetupFirewall(&FirewallConfig{
+		Enabled:  true,
+		SSHPort:  22,
+		AllowIPs: []string{"10.0.0.1", "2001:db8::1", "10.0.0.1/32", "192.168.1.7/24"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var allowed []string
+	for _, command := range fake.Commands() {
+		if strings.HasPrefix(command, "ufw allow from ") {
+			allowed = append(allowed, strings.TrimPrefix(command, "ufw allow from "))
+		}
+	}
+	if want := []string{"10.0.0.1/32", "2001:db8::1/128", "192.168.1.0/24"}; !reflect.DeepEqual(allowed, want) {
+		t.Fatalf("разрешены %q, ожидалось %q", allowed, want)
+	}
+}
+
+func TestSetupFirewallRejectsInvalidAllowIP(t *testing.T) {
+	useUFWState(t)
+	fake := runner.NewFakeRunner().On("ufw status", "Status: inactive\n", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	sm := &SecurityManager{}
+	err := sm.SetupFirewall(&FirewallConfig{Enabled: true, SSHPort: 22, AllowIPs: []string{"10.0.0.1", "not-an-ip"}})
+	if err == nil || !strings.Contains(err.Error(), "not-an-ip") {
+		t.Fatalf("ошибка = %v, ожидалось упоминание некорректного адреса", err)
+	}
+	for _, command := range fake.Commands() {
+		if strings.HasPrefix(command, "ufw allow from ") {
+			t.Fatalf("адрес разрешен несмотря на ошибку: %q", command)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// sshdFallbackPath - расположение sshd, если /usr/sbin нет в PATH (обычный пользователь)
+const sshdFallbackPath = "/usr/sbin/sshd"
+
+// SSHFinding описывает опасную настройку SSH в действующей конфигурации sshd
+type SSHFinding struct {
+	// Severity - серьезность: "high" для доступа, открывающего подбор паролей и root
+	Severity  string
+	Directive string
+	Value     string
+	Message   string
+}
+
+// sshRisks - опасные значения директив sshd -T (имена в нижнем регистре)
+var sshRisks = []struct {
+	directive string
+	value     string
+	message   string
+}{
+	{"permitrootlogin", "yes", "разрешен вход root, в том числе по паролю"},
+	{"passwordauthentication", "yes", "разрешена аутентификация по паролю"},
+	{"permitemptypasswords", "yes", "разрешен вход с пустым паролем"},
+}
+
+// AuditSSH проверяет действующую конфигурацию sshd, полученную через sshd -T.
+// В отличие от поиска по sshd_config, sshd -T учитывает Include и значения по умолчанию.
+// Требует прав root: sshd читает ключи хоста.
+func (sm *SecurityManager) AuditSSH() ([]SSHFinding, error) {
+	sshd := "sshd"
+	if _, err := cmdRunner.LookPath(sshd); err != nil {
+		sshd = sshdFallbackPath
+	}
+	output, err := cmdRunner.Output(sshd, "-T")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения конфигурации sshd: %w", err)
+	}
+	return auditSSHConfig(parseSSHDEffective(string(output))), nil
+}
+
+// parseSSHDEffective разбирает вывод sshd -T: по одной директиве "имя значение" на строку.
+// Повторяющиеся директивы (hostkey, listenaddress) объединяются через пробел.
+func parseSSHDEffective(output string) map[string]string {
+	settings := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		if key == "" {
+			continue
+		}
+		key = strings.ToLower(key)
+		value = strings.TrimSpace(value)
+		if prev, ok := settings[key]; ok {
+			value = prev + " " + value
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// auditSSHConfig возвращает опасные настройки из действующей конфигурации sshd
+func auditSSHConfig(settings map[string]string) []SSHFinding {
+	var findings []SSHFinding
+	for _, risk := range sshRisks {
+		if value, ok := settings[risk.directive]; ok && strings.EqualFold(value, risk.value) {
+			findings = append(findings, SSHFinding{
+				Severity:  "high",
+				Directive: risk.directive,
+				Value:     value,
+				Message:   risk.message,
+			})
+		}
+	}
+	return findings
+}
+
+// checkSSHConfig выводит опасные настройки SSH для проверки безопасности
+func (sm *SecurityManager) checkSSHConfig() error {
+	findings, err := sm.AuditSSH()
+	if err != nil {
+		return err
+	}
+	if len(findings) == 0 {
+		fmt.Println("Вход root и аутентификация по паролю отключены")
+		return nil
+	}
+	for _, f := range findings {
+		fmt.Printf("⚠️  [%s] %s %s: %s\n", f.Severity, f.Directive, f.Value, f.Message)
+	}
+	return nil
+}
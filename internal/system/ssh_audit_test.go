@@ -0,0 +1,92 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// sshdHardened - фрагмент вывода sshd -T защищенного сервера
+const sshdHardened = `port 2222
+addressfamily any
+listenaddress [::]:2222
+listenaddress 0.0.0.0:2222
+permitrootlogin without-password
+passwordauthentication no
+permitemptypasswords no
+pubkeyauthentication yes
+hostkey /etc/ssh/ssh_host_ecdsa_key
+hostkey /etc/ssh/ssh_host_ed25519_key
+`
+
+// sshdDefaults - вывод sshd -T с разрешенным входом root и паролями
+// (например, из файла, подключенного через Include)
+const sshdDefaults = `port 22
+permitrootlogin yes
+passwordauthentication yes
+permitemptypasswords no
+PermitTTY yes
+`
+
+func TestParseSSHDEffective(t *testing.T) {
+	settings := parseSSHDEffective(sshdHardened + "\n   \n")
+	tests := map[string]string{
+		"port":                   "2222",
+		"permitrootlogin":        "without-password",
+		"passwordauthentication": "no",
+		"listenaddress":          "[::]:2222 0.0.0.0:2222",
+		"hostkey":                "/etc/ssh/ssh_host_ecdsa_key /etc/ssh/ssh_host_ed25519_key",
+	}
+	for key, want := range tests {
+		if got := settings[key]; got != want {
+			t.Errorf("%s = %q, ожидалось %q", key, got, want)
+		}
+	}
+	if got := parseSSHDEffective(sshdDefaults)["permittty"]; got != "yes" {
+		t.Errorf("имена директив приводятся к нижнему регистру: %q", got)
+	}
+}
+
+func TestAuditSSHFindings(t *testing.T) {
+	if findings := auditSSHConfig(parseSSHDEffective(sshdHardened)); len(findings) != 0 {
+		t.Errorf("защищенная конфигурация: %+v", findings)
+	}
+
+	findings := auditSSHConfig(parseSSHDEffective(sshdDefaults))
+	var directives []string
+	for _, f := range findings {
+		if f.Severity != "high" || f.Value != "yes" || f.Message == "" {
+			t.Errorf("находка %+v", f)
+		}
+		directives = append(directives, f.Directive)
+	}
+	if want := []string{"permitrootlogin", "passwordauthentication"}; !reflect.DeepEqual(directives, want) {
+		t.Errorf("директивы %q, ожидалось %q", directives, want)
+	}
+}
+
+func TestAuditSSHRunsSSHDT(t *testing.T) {
+	fake := runner.NewFakeRunner().On("/usr/sbin/sshd -T", sshdDefaults, nil)
+	// Без /usr/sbin в PATH используется полный путь
+	fake.Missing["sshd"] = true
+	t.Cleanup(SetCommandRunner(fake))
+
+	findings, err := (&SecurityManager{}).AuditSSH()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Errorf("находки %+v", findings)
+	}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, []string{"/usr/sbin/sshd -T"}) {
+		t.Errorf("команды %q", commands)
+	}
+
+	failure := errors.New("no hostkeys available")
+	t.Cleanup(SetCommandRunner(runner.NewFakeRunner().On("sshd", "", failure)))
+	if _, err := (&SecurityManager{}).AuditSSH(); !errors.Is(err, failure) {
+		t.Errorf("ошибка sshd -T не возвращена: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// sshConfigPath - конфигурация sshd; в тестах заменяется временным файлом
+var sshConfigPath = "/etc/ssh/sshd_config"
+
+const (
+	sshBackupTimeFormat = "20060102150405"
+	// defaultSSHBackupKeep - число хранимых бэкапов по умолчанию
+	defaultSSHBackupKeep = 5
+)
+
+// ErrNoSSHBackup возвращается, если подходящий бэкап sshd_config не найден
+var ErrNoSSHBackup = errors.New("бэкап SSH конфигурации не найден")
+
+func (sm *SecurityManager) backupKeep() int {
+	if sm.SSHBackupKeep > 0 {
+		return sm.SSHBackupKeep
+	}
+	return defaultSSHBackupKeep
+}
+
+// ListSSHBackups возвращает бэкапы sshd_config от новых к старым
+func (sm *SecurityManager) ListSSHBackups() ([]string, error) {
+	return listSSHBackups(sshBackupPrefix())
+}
+
+// sshBackupPrefix возвращает префикс бэкапов; к нему добавляется время создания
+func sshBackupPrefix() string {
+	return sshConfigPath + ".backup."
+}
+
+// RestoreSSHConfig восстанавливает sshd_config из бэкапа и перезапускает SSH.
+// backupName - имя файла или полный путь из ListSSHBackups; пустое имя выбирает последний бэкап.
+func (sm *SecurityManager) RestoreSSHConfig(backupName string) error {
+	backups, err := sm.ListSSHBackups()
+	if err != nil {
+		return err
+	}
+
+	backup := ""
+	for _, path := range backups {
+		if backupName == "" || path == backupName || filepath.Base(path) == backupName {
+			backup = path
+			break
+		}
+	}
+	if backup == "" {
+		if backupName == "" {
+			return ErrNoSSHBackup
+		}
+		return fmt.Errorf("%w: %s", ErrNoSSHBackup, backupName)
+	}
+
+	if err := ensureWritable(sshConfigPath); err != nil {
+		return err
+	}
+	if err := sm.restoreSSHBackup(backup); err != nil {
+		return err
+	}
+	fmt.Printf("SSH конфигурация восстановлена из %s\n", backup)
+	return sm.restartSSH()
+}
+
+// listSSHBackups находит бэкапы с заданным префиксом и сортирует их от новых к старым.
+// Время в имени имеет фиксированную ширину, поэтому лексический порядок совпадает с хронологическим.
+func listSSHBackups(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(prefix))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения директории бэкапов: %w", err)
+	}
+
+	base := filepath.Base(prefix)
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, base) {
+			continue
+		}
+		if len(strings.TrimPrefix(name, base)) != len(sshBackupTimeFormat) {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(prefix), name))
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// pruneSSHBackups удаляет бэкапы сверх keep последних
+func pruneSSHBackups(prefix string, keep int) error {
+	backups, err := listSSHBackups(prefix)
+	if err != nil {
+		return err
+	}
+	if len(backups) <= keep {
+		return nil
+	}
+
+	var errs []error
+	for _, path := range backups[keep:] {
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// plantSSHBackups создает бэкапы sshd_config с заданными отметками времени
+func plantSSHBackups(t *testing.T, stamps ...string) []string {
+	t.Helper()
+	var paths []string
+	for _, stamp := range stamps {
+		path := sshBackupPrefix() + stamp
+		if err := os.WriteFile(path, []byte("Port "+stamp+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestBackupSSHConfigPrunesToRetention(t *testing.T) {
+	useSSHConfig(t, "Port 22\n")
+	// Бэкап копируется настоящим cp, чтобы новый файл участвовал в очистке
+	t.Cleanup(SetCommandRunner(runner.Exec{}))
+	planted := plantSSHBackups(t,
+		"20240101000000", "20240102000000", "20240103000000", "20240104000000",
+		"20240105000000", "20240106000000")
+	unrelated := sshBackupPrefix() + "manual"
+	if err := os.WriteFile(unrelated, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sm := &SecurityManager{SSHBackupKeep: 3}
+	backup, err := sm.backupSSHConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := sm.ListSSHBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{backup, planted[5], planted[4]}
+	if !reflect.DeepEqual(backups, want) {
+		t.Errorf("бэкапы:\n%q\nожидалось:\n%q", backups, want)
+	}
+	if _, err := os.Stat(planted[0]); err == nil {
+		t.Error("старый бэкап не удален")
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("удален файл, не являющийся бэкапом: %v", err)
+	}
+}
+
+func TestBackupSSHConfigDefaultRetention(t *testing.T) {
+	useSSHConfig(t, "Port 22\n")
+	t.Cleanup(SetCommandRunner(runner.NewFakeRunner()))
+	plantSSHBackups(t,
+		"20240101000000", "20240102000000", "20240103000000", "20240104000000",
+		"20240105000000", "20240106000000", "20240107000000")
+
+	sm := &SecurityManager{}
+	if _, err := sm.backupSSHConfig(); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := sm.ListSSHBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != defaultSSHBackupKeep {
+		t.Errorf("осталось %d бэкапов, ожидалось %d: %q", len(backups), defaultSSHBackupKeep, backups)
+	}
+}
+
+func TestRestoreSSHConfig(t *testing.T) {
+	configPath := useSSHConfig(t, "Port 2222\n")
+	fake := runner.NewFakeRunner()
+	t.Cleanup(SetCommandRunner(fake))
+	planted := plantSSHBackups(t, "20240101000000", "20240102000000")
+	sm := &SecurityManager{}
+
+	if err := sm.RestoreSSHConfig(""); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.RestoreSSHConfig(filepath.Base(planted[0])); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"cp -p " + planted[1] + " " + configPath,
+		listSSHUnits,
+		"systemctl restart ssh",
+		"cp -p " + planted[0] + " " + configPath,
+		listSSHUnits,
+		"systemctl restart ssh",
+	}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, want) {
+		t.Errorf("команды:\n%q\nожидалось:\n%q", commands, want)
+	}
+
+	if err := sm.RestoreSSHConfig("sshd_config.backup.19990101000000"); !errors.Is(err, ErrNoSSHBackup) {
+		t.Errorf("ошибка %v, ожидалась ErrNoSSHBackup", err)
+	}
+}
@@ -0,0 +1,114 @@
+package system
+
+import (
+	"fmt"
+	"strings"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+)
+
+// Маркеры блока рекомендуемых настроек в sshd_config
+const (
+	sshHardeningBegin = "# BEGIN go-to-run hardening"
+	sshHardeningEnd   = "# END go-to-run hardening"
+)
+
+// legacyHardeningHeader - заголовок блока, который добавляли прежние версии без маркеров
+const legacyHardeningHeader = "# Additional security settings"
+
+// legacyHardeningLines - директивы прежнего блока без маркеров.
+// Protocol 2 больше не записывается: современный sshd считает директиву устаревшей.
+var legacyHardeningLines = map[string]bool{
+	"Protocol 2":              true,
+	"ClientAliveInterval 300": true,
+	"ClientAliveCountMax 2":   true,
+	"MaxAuthTries 3":          true,
+	"MaxSessions 10":          true,
+	"X11Forwarding no":        true,
+}
+
+// SSHHardeningDirectives возвращает директивы sshd, которые запишет блок настроек h.
+// nil и Disabled означают, что блок не записывается
+func SSHHardeningDirectives(h *appconfig.SSHHardening) []string {
+	if h == nil || h.Disabled {
+		return nil
+	}
+	return renderSSHHardening(h)
+}
+
+// renderSSHHardening возвращает директивы блока без маркеров
+func renderSSHHardening(h *appconfig.SSHHardening) []string {
+	var lines []string
+	if h.ClientAliveInterval > 0 {
+		lines = append(lines, fmt.Sprintf("ClientAliveInterval %d", h.ClientAliveInterval))
+	}
+	if h.ClientAliveCountMax > 0 {
+		lines = append(lines, fmt.Sprintf("ClientAliveCountMax %d", h.ClientAliveCountMax))
+	}
+	if h.MaxAuthTries > 0 {
+		lines = append(lines, fmt.Sprintf("MaxAuthTries %d", h.MaxAuthTries))
+	}
+	if h.MaxSessions > 0 {
+		lines = append(lines, fmt.Sprintf("MaxSessions %d", h.MaxSessions))
+	}
+	if h.X11Forwarding != "" {
+		lines = append(lines, "X11Forwarding "+h.X11Forwarding)
+	}
+	return lines
+}
+
+// applySSHHardening удаляет прежний блок настроек и вставляет новый в начало конфигурации.
+// sshd применяет первое вхождение директивы, а директивы после Match относятся к нему,
+// поэтому блок размещается до остальных настроек. Повторный вызов дает тот же результат.
+func applySSHHardening(lines []string, h *appconfig.SSHHardening) []string {
+	lines = stripSSHHardening(lines)
+	if h == nil || h.Disabled {
+		return lines
+	}
+	directives := renderSSHHardening(h)
+	if len(directives) == 0 {
+		return lines
+	}
+
+	block := make([]string, 0, len(directives)+3+len(lines))
+	block = append(block, sshHardeningBegin)
+	block = append(block, directives...)
+	block = append(block, sshHardeningEnd, "")
+	return append(block, lines...)
+}
+
+// stripSSHHardening удаляет блок между маркерами и блок прежних версий без маркеров
+func stripSSHHardening(lines []string) []string {
+	result := make([]string, 0, len(lines))
+	inBlock, inLegacy, skipBlank := false, false, false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == sshHardeningBegin:
+			inBlock = true
+			continue
+		case inBlock:
+			if trimmed == sshHardeningEnd {
+				inBlock = false
+				// Пустая строка, отделяющая блок от остальной конфигурации
+				skipBlank = true
+			}
+			continue
+		case skipBlank && trimmed == "":
+			skipBlank = false
+			continue
+		case trimmed == legacyHardeningHeader:
+			inLegacy = true
+			// Удаляем пустую строку, которую прежние версии ставили перед заголовком
+			if n := len(result); n > 0 && strings.TrimSpace(result[n-1]) == "" {
+				result = result[:n-1]
+			}
+			continue
+		case inLegacy && legacyHardeningLines[trimmed]:
+			continue
+		}
+		inLegacy, skipBlank = false, false
+		result = append(result, line)
+	}
+	return result
+}
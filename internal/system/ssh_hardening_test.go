@@ -0,0 +1,101 @@
+package system
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	appconfig "github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+func TestSSHHardeningDirectivesOverride(t *testing.T) {
+	h := appconfig.DefaultSSHHardening()
+	h.X11Forwarding = "yes"
+	h.MaxAuthTries = 6
+	h.MaxSessions = 0
+
+	got := SSHHardeningDirectives(h)
+	want := []string{"ClientAliveInterval 300", "ClientAliveCountMax 2", "MaxAuthTries 6", "X11Forwarding yes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("директивы %q, ожидалось %q", got, want)
+	}
+}
+
+func TestSSHHardeningDirectivesDisabled(t *testing.T) {
+	if got := SSHHardeningDirectives(nil); got != nil {
+		t.Errorf("для nil получено %q", got)
+	}
+	h := appconfig.DefaultSSHHardening()
+	h.Disabled = true
+	if got := SSHHardeningDirectives(h); got != nil {
+		t.Errorf("для Disabled получено %q", got)
+	}
+}
+
+func TestApplySSHHardeningIdempotent(t *testing.T) {
+	lines := []string{"Port 22", "Match User backup", "  PasswordAuthentication yes"}
+	h := &appconfig.SSHHardening{MaxAuthTries: 4, X11Forwarding: "no"}
+
+	once := applySSHHardening(lines, h)
+	want := []string{
+		sshHardeningBegin, "MaxAuthTries 4", "X11Forwarding no", sshHardeningEnd, "",
+		"Port 22", "Match User backup", "  PasswordAuthentication yes",
+	}
+	if !reflect.DeepEqual(once, want) {
+		t.Fatalf("блок:\n%q\nожидалось:\n%q", once, want)
+	}
+	if twice := applySSHHardening(once, h); !reflect.DeepEqual(twice, once) {
+		t.Errorf("повторное применение изменило конфигурацию:\n%q", twice)
+	}
+
+	h.MaxAuthTries = 0
+	updated := applySSHHardening(once, h)
+	if strings.Contains(strings.Join(updated, "\n"), "MaxAuthTries") {
+		t.Errorf("опущенная директива осталась в блоке:\n%q", updated)
+	}
+	if removed := applySSHHardening(once, nil); !reflect.DeepEqual(removed, lines) {
+		t.Errorf("блок не удален:\n%q", removed)
+	}
+}
+
+func TestApplySSHHardeningReplacesLegacyBlock(t *testing.T) {
+	lines := []string{
+		"Port 22", "",
+		legacyHardeningHeader, "Protocol 2", "ClientAliveInterval 300", "ClientAliveCountMax 2",
+		"MaxAuthTries 3", "MaxSessions 10", "X11Forwarding no",
+		"UsePAM yes",
+	}
+	got := applySSHHardening(lines, &appconfig.SSHHardening{X11Forwarding: "yes"})
+	want := []string{sshHardeningBegin, "X11Forwarding yes", sshHardeningEnd, "", "Port 22", "UsePAM yes"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("блок:\n%q\nожидалось:\n%q", got, want)
+	}
+}
+
+func TestSetupSSHWithHardeningWritesOverrides(t *testing.T) {
+	configPath := useSSHConfig(t, "Port 22\n")
+	t.Cleanup(SetCommandRunner(runner.NewFakeRunner()))
+
+	h := appconfig.DefaultSSHHardening()
+	h.X11Forwarding = "yes"
+	h.ClientAliveInterval = 0
+	if err := (&SecurityManager{}).SetupSSHWithHardening(22, nil, nil, h); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := string(data)
+	if !strings.Contains(config, "X11Forwarding yes") {
+		t.Errorf("нет переопределенной директивы X11Forwarding yes:\n%s", config)
+	}
+	if strings.Contains(config, "ClientAliveInterval") {
+		t.Errorf("опущенная директива ClientAliveInterval записана:\n%s", config)
+	}
+	if strings.Count(config, sshHardeningBegin) != 1 {
+		t.Errorf("блок настроек записан не один раз:\n%s", config)
+	}
+}
@@ -0,0 +1,105 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// swapAllocator - способ выделения места под swap файл
+type swapAllocator struct {
+	name     string
+	allocate func(path string, size int64) error
+}
+
+// swapAllocators перечисляет способы выделения места в порядке предпочтения:
+// системный вызов fallocate без запуска процессов, утилита fallocate, заполнение нулями через dd.
+// Разреженный файл (truncate) не используется: ядро отказывается подключать swap с "дырами".
+var swapAllocators = []swapAllocator{
+	{"fallocate(2)", fallocateNative},
+	{"fallocate", fallocateCommand},
+	{"dd", ddZeroFill},
+}
+
+// errSparseSwap возвращается, если после выделения файл остался разреженным
+var errSparseSwap = errors.New("файл разреженный, ядро не подключит его как swap")
+
+// ParseSwapSize разбирает размер swap в формате fallocate: число с суффиксом K, M, G или T
+func ParseSwapSize(size string) (uint64, error) {
+	if size == "" {
+		return 0, errors.New("размер swap не задан")
+	}
+	units := map[byte]uint64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30, 'T': 1 << 40}
+	number, multiplier := size, uint64(1)
+	if unit, ok := units[size[len(size)-1]]; ok {
+		number, multiplier = size[:len(size)-1], unit
+	}
+	value, err := strconv.ParseUint(number, 10, 64)
+	if err != nil || value == 0 {
+		return 0, fmt.Errorf("некорректный размер swap: %s", size)
+	}
+	return value * multiplier, nil
+}
+
+// allocateSwapFile создает файл размером size байт, перебирая swapAllocators.
+// Способ, оставивший разреженный файл, считается неудачным.
+func allocateSwapFile(path string, size int64) error {
+	var errs []error
+	for _, allocator := range swapAllocators {
+		_ = os.Remove(path)
+		err := allocator.allocate(path, size)
+		if err == nil {
+			err = checkNotSparse(path, size)
+		}
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("⚠️  Не удалось выделить swap через %s: %v\n", allocator.name, err)
+		errs = append(errs, fmt.Errorf("%s: %w", allocator.name, err))
+	}
+	_ = os.Remove(path)
+	return fmt.Errorf("ошибка создания swap файла: %w", errors.Join(errs...))
+}
+
+// fallocateNative выделяет место системным вызовом fallocate
+func fallocateNative(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	err = syscall.Fallocate(int(f.Fd()), 0, 0, size)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// fallocateCommand выделяет место утилитой fallocate
+func fallocateCommand(path string, size int64) error {
+	return cmdRunner.Run("fallocate", "-l", strconv.FormatInt(size, 10), path)
+}
+
+// ddZeroFill заполняет файл нулями блоками по 1M: медленно, но работает на любой файловой системе
+func ddZeroFill(path string, size int64) error {
+	count := (size + (1 << 20) - 1) >> 20
+	return cmdRunner.Run("dd", "if=/dev/zero", "of="+path, "bs=1M", "count="+strconv.FormatInt(count, 10), "status=none")
+}
+
+// checkNotSparse проверяет, что под файл действительно выделено не меньше size байт
+func checkNotSparse(path string, size int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	// st_blocks считается в 512-байтных блоках независимо от размера блока файловой системы
+	if info.Size() < size || st.Blocks*512 < size {
+		return errSparseSwap
+	}
+	return nil
+}
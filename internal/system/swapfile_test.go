@@ -0,0 +1,169 @@
+package system
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// allocatorStub - поведение подмененного способа выделения swap
+type allocatorStub int
+
+const (
+	allocOK     allocatorStub = iota // файл полностью выделен
+	allocFail                        // способ завершился ошибкой
+	allocSparse                      // способ оставил разреженный файл
+)
+
+// useSwapAllocators подменяет swapAllocators заглушками с теми же именами и
+// возвращает журнал попыток
+func useSwapAllocators(t *testing.T, behavior map[string]allocatorStub) *[]string {
+	t.Helper()
+	var attempts []string
+	prev := swapAllocators
+	stubs := make([]swapAllocator, 0, len(prev))
+	for _, allocator := range prev {
+		name, stub := allocator.name, behavior[allocator.name]
+		stubs = append(stubs, swapAllocator{name: name, allocate: func(path string, size int64) error {
+			attempts = append(attempts, name)
+			switch stub {
+			case allocFail:
+				return errors.New("operation not supported")
+			case allocSparse:
+				f, err := os.Create(path)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				return f.Truncate(size)
+			}
+			return os.WriteFile(path, make([]byte, size), 0600)
+		}})
+	}
+	swapAllocators = stubs
+	t.Cleanup(func() { swapAllocators = prev })
+	return &attempts
+}
+
+func TestAllocateSwapFileFallbackChain(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior map[string]allocatorStub
+		want     []string
+	}{
+		{"fallocate(2)", nil, []string{"fallocate(2)"}},
+		{"утилита fallocate", map[string]allocatorStub{"fallocate(2)": allocFail},
+			[]string{"fallocate(2)", "fallocate"}},
+		{"dd", map[string]allocatorStub{"fallocate(2)": allocFail, "fallocate": allocFail},
+			[]string{"fallocate(2)", "fallocate", "dd"}},
+		// Разреженный файл не подходит для swap - пробуется следующий способ
+		{"разреженный файл", map[string]allocatorStub{"fallocate(2)": allocSparse},
+			[]string{"fallocate(2)", "fallocate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := useSwapAllocators(t, tt.behavior)
+			path := filepath.Join(t.TempDir(), "swapfile")
+			if err := allocateSwapFile(path, 64<<10); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*attempts, tt.want) {
+				t.Errorf("попытки %q, ожидалось %q", *attempts, tt.want)
+			}
+			if info, err := os.Stat(path); err != nil || info.Size() != 64<<10 {
+				t.Errorf("swap файл: %v, %v", info, err)
+			}
+		})
+	}
+}
+
+func TestAllocateSwapFileAllFail(t *testing.T) {
+	attempts := useSwapAllocators(t, map[string]allocatorStub{
+		"fallocate(2)": allocFail, "fallocate": allocSparse, "dd": allocFail,
+	})
+	path := filepath.Join(t.TempDir(), "swapfile")
+	err := allocateSwapFile(path, 64<<10)
+	if err == nil {
+		t.Fatal("ожидалась ошибка")
+	}
+	if !errors.Is(err, errSparseSwap) {
+		t.Errorf("ошибка не сообщает о разреженном файле: %v", err)
+	}
+	for _, name := range []string{"fallocate(2)", "fallocate", "dd"} {
+		if !strings.Contains(err.Error(), name+":") {
+			t.Errorf("ошибка не называет способ %s: %v", name, err)
+		}
+	}
+	if len(*attempts) != 3 {
+		t.Errorf("попытки %q", *attempts)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("после неудачи остался файл: %v", err)
+	}
+}
+
+func TestSwapAllocatorCommands(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	t.Cleanup(SetCommandRunner(fake))
+
+	if err := fallocateCommand("/swapfile", 2<<30); err != nil {
+		t.Fatal(err)
+	}
+	// dd округляет размер вверх до целого числа мегабайт
+	if err := ddZeroFill("/swapfile", 3<<20+1); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"fallocate -l 2147483648 /swapfile",
+		"dd if=/dev/zero of=/swapfile bs=1M count=4 status=none",
+	}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, want) {
+		t.Errorf("команды %q, ожидалось %q", commands, want)
+	}
+}
+
+func TestCheckNotSparse(t *testing.T) {
+	dir := t.TempDir()
+	sparse := filepath.Join(dir, "sparse")
+	f, err := os.Create(sparse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if err := checkNotSparse(sparse, 1<<20); !errors.Is(err, errSparseSwap) {
+		t.Errorf("разреженный файл: %v", err)
+	}
+
+	full := filepath.Join(dir, "full")
+	if err := os.WriteFile(full, make([]byte, 1<<20), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkNotSparse(full, 1<<20); err != nil {
+		t.Errorf("заполненный файл: %v", err)
+	}
+	if err := checkNotSparse(full, 2<<20); !errors.Is(err, errSparseSwap) {
+		t.Errorf("файл меньше нужного размера: %v", err)
+	}
+}
+
+func TestParseSwapSize(t *testing.T) {
+	valid := map[string]uint64{"512M": 512 << 20, "2G": 2 << 30, "1T": 1 << 40, "4096": 4096, "8K": 8 << 10}
+	for size, want := range valid {
+		if got, err := ParseSwapSize(size); err != nil || got != want {
+			t.Errorf("ParseSwapSize(%q) = %d, %v, ожидалось %d", size, got, err, want)
+		}
+	}
+	for _, size := range []string{"", "0G", "2GB", "-1G", "G", "1.5G"} {
+		if _, err := ParseSwapSize(size); err == nil {
+			t.Errorf("ParseSwapSize(%q): ожидалась ошибка", size)
+		}
+	}
+}
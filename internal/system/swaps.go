@@ -0,0 +1,51 @@
+package system
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ManagedSwapFile - swap файл, которым управляет go-to-run
+const ManagedSwapFile = "/swapfile"
+
+// procSwapsPath - список активных swap устройств ядра; в тестах заменяется временным файлом
+var procSwapsPath = "/proc/swaps"
+
+// activeSwaps возвращает пути активных swap устройств и файлов из /proc/swaps
+func activeSwaps(procSwaps string) ([]string, error) {
+	f, err := os.Open(procSwaps)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var swaps []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Первая строка - заголовок "Filename Type Size Used Priority"
+		if len(fields) == 0 || fields[0] == "Filename" {
+			continue
+		}
+		// Ядро экранирует пробелы в путях как \040
+		swaps = append(swaps, strings.ReplaceAll(fields[0], `\040`, " "))
+	}
+	return swaps, scanner.Err()
+}
+
+// isActiveSwap проверяет, подключен ли указанный путь как swap
+func isActiveSwap(procSwaps, target string) (bool, error) {
+	swaps, err := activeSwaps(procSwaps)
+	if err != nil {
+		return false, err
+	}
+	target = filepath.Clean(target)
+	for _, swap := range swaps {
+		if filepath.Clean(swap) == target {
+			return true, nil
+		}
+	}
+	return false, nil
+}
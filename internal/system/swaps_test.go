@@ -0,0 +1,81 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// useProcSwaps подменяет /proc/swaps временным файлом на время теста
+func useProcSwaps(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "swaps")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	prev := procSwapsPath
+	procSwapsPath = path
+	t.Cleanup(func() { procSwapsPath = prev })
+	return path
+}
+
+const procSwapsOther = `Filename				Type		Size		Used		Priority
+/dev/zram0                              partition	2097148		10240		100
+/var/lib/swap\040file                   file		524284		0		-2
+`
+
+func TestActiveSwaps(t *testing.T) {
+	path := useProcSwaps(t, procSwapsOther)
+	swaps, err := activeSwaps(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/dev/zram0", "/var/lib/swap file"}; !reflect.DeepEqual(swaps, want) {
+		t.Errorf("activeSwaps() = %q, ожидалось %q", swaps, want)
+	}
+}
+
+func TestIsActiveSwapOtherDevicePresent(t *testing.T) {
+	path := useProcSwaps(t, procSwapsOther)
+
+	// Другой swap не мешает настройке управляемого файла
+	active, err := isActiveSwap(path, ManagedSwapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active {
+		t.Errorf("%s считается подключенным, хотя в /proc/swaps только другие устройства", ManagedSwapFile)
+	}
+	if active, _ := isActiveSwap(path, "/var/lib/swap file"); !active {
+		t.Error("путь с пробелом не найден среди подключенных")
+	}
+	if active, _ := isActiveSwap(path, "/dev/./zram0"); !active {
+		t.Error("путь не приводится к каноническому виду перед сравнением")
+	}
+}
+
+func TestIsActiveSwapEmptyAndMissing(t *testing.T) {
+	path := useProcSwaps(t, "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n")
+	if active, err := isActiveSwap(path, ManagedSwapFile); err != nil || active {
+		t.Errorf("без swap получено %v, %v", active, err)
+	}
+	if _, err := isActiveSwap(filepath.Join(t.TempDir(), "missing"), ManagedSwapFile); err == nil {
+		t.Error("ожидалась ошибка чтения отсутствующего файла")
+	}
+}
+
+func TestSetupSwapManagedFileAlreadyActive(t *testing.T) {
+	useProcSwaps(t, procSwapsOther+ManagedSwapFile+"                               file\t\t2097148\t\t0\t\t-3\n")
+	fake := runner.NewFakeRunner()
+	t.Cleanup(SetCommandRunner(fake))
+
+	if err := (&SystemUtils{}).SetupSwap("1G"); err != nil {
+		t.Fatal(err)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("подключенный swap настраивается повторно: %q", commands)
+	}
+}
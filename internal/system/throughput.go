@@ -0,0 +1,200 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Источники счетчиков ввода-вывода
+const (
+	procDiskstatsPath = "/proc/diskstats"
+	procNetDevPath    = "/proc/net/dev"
+	sysClassBlockPath = "/sys/class/block"
+	// diskSectorSize - размер сектора в /proc/diskstats независимо от устройства
+	diskSectorSize = 512
+)
+
+// DiskIOStats содержит скорость чтения и записи устройства
+type DiskIOStats struct {
+	Device    string
+	ReadKBps  float64
+	WriteKBps float64
+}
+
+// NetIOStats содержит скорость приема и передачи сетевого интерфейса
+type NetIOStats struct {
+	Interface string
+	RxKBps    float64
+	TxKBps    float64
+}
+
+// ioCounters - накопительные счетчики байтов устройства или интерфейса
+type ioCounters struct {
+	in, out uint64
+}
+
+// GetIOStats измеряет скорость чтения и записи дисков по двум снимкам /proc/diskstats
+// с интервалом interval. Разделы, loop- и ram-устройства не учитываются.
+func (su *SystemUtils) GetIOStats(interval time.Duration) ([]DiskIOStats, error) {
+	before, after, elapsed, err := sampleCounters(procDiskstatsPath, readDiskstats, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []DiskIOStats
+	for _, rate := range counterRates(before, after, elapsed) {
+		if isPartition(sysClassBlockPath, rate.name) {
+			continue
+		}
+		stats = append(stats, DiskIOStats{Device: rate.name, ReadKBps: rate.in, WriteKBps: rate.out})
+	}
+	return stats, nil
+}
+
+// GetNetStats измеряет скорость приема и передачи интерфейсов по двум снимкам
+// /proc/net/dev с интервалом interval. Loopback не учитывается.
+func (su *SystemUtils) GetNetStats(interval time.Duration) ([]NetIOStats, error) {
+	before, after, elapsed, err := sampleCounters(procNetDevPath, readNetDev, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []NetIOStats
+	for _, rate := range counterRates(before, after, elapsed) {
+		stats = append(stats, NetIOStats{Interface: rate.name, RxKBps: rate.in, TxKBps: rate.out})
+	}
+	return stats, nil
+}
+
+// sampleCounters читает два снимка счетчиков с интервалом interval
+func sampleCounters(path string, read func(string) (map[string]ioCounters, error),
+	interval time.Duration) (before, after map[string]ioCounters, elapsed time.Duration, err error) {
+	start := time.Now()
+	if before, err = read(path); err != nil {
+		return nil, nil, 0, err
+	}
+	time.Sleep(interval)
+	if after, err = read(path); err != nil {
+		return nil, nil, 0, err
+	}
+	return before, after, time.Since(start), nil
+}
+
+// readDiskstats читает байты чтения и записи устройств из /proc/diskstats:
+// "major minor name reads merged sectors_read ms writes merged sectors_written ..."
+func readDiskstats(path string) (map[string]ioCounters, error) {
+	counters := make(map[string]ioCounters)
+	err := scanProcFile(path, func(line string) {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			return
+		}
+		name := fields[2]
+		if strings.HasPrefix(name, "loop") || strings.HasPrefix(name, "ram") {
+			return
+		}
+		read, err1 := strconv.ParseUint(fields[5], 10, 64)
+		written, err2 := strconv.ParseUint(fields[9], 10, 64)
+		if err1 != nil || err2 != nil {
+			return
+		}
+		counters[name] = ioCounters{in: read * diskSectorSize, out: written * diskSectorSize}
+	})
+	return counters, err
+}
+
+// readNetDev читает принятые и переданные байты интерфейсов из /proc/net/dev:
+// "iface: rx_bytes rx_packets ... (8 полей приема) tx_bytes ..."
+func readNetDev(path string) (map[string]ioCounters, error) {
+	counters := make(map[string]ioCounters)
+	err := scanProcFile(path, func(line string) {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			return
+		}
+		name = strings.TrimSpace(name)
+		fields := strings.Fields(rest)
+		if name == "lo" || len(fields) < 9 {
+			return
+		}
+		rx, err1 := strconv.ParseUint(fields[0], 10, 64)
+		tx, err2 := strconv.ParseUint(fields[8], 10, 64)
+		if err1 != nil || err2 != nil {
+			return
+		}
+		counters[name] = ioCounters{in: rx, out: tx}
+	})
+	return counters, err
+}
+
+func scanProcFile(path string, handle func(line string)) error {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		handle(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	return nil
+}
+
+// counterRate - скорость по двум снимкам счетчиков в КБ/с
+type counterRate struct {
+	name    string
+	in, out float64
+}
+
+// counterRates вычисляет скорости для имен, присутствующих в обоих снимках, в порядке имен
+func counterRates(before, after map[string]ioCounters, elapsed time.Duration) []counterRate {
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		return nil
+	}
+
+	rates := make([]counterRate, 0, len(after))
+	for name, cur := range after {
+		prev, ok := before[name]
+		if !ok {
+			continue
+		}
+		rates = append(rates, counterRate{
+			name: name,
+			in:   float64(counterDelta(prev.in, cur.in)) / 1024 / seconds,
+			out:  float64(counterDelta(prev.out, cur.out)) / 1024 / seconds,
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].name < rates[j].name })
+	return rates
+}
+
+// counterDelta возвращает прирост счетчика с учетом переполнения.
+// Часть счетчиков ядра 32-битные, поэтому при значении до переполнения,
+// умещающемся в 32 бита, считается переход через 2^32.
+func counterDelta(prev, cur uint64) uint64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	if prev <= math.MaxUint32 {
+		return cur + (math.MaxUint32 - prev) + 1
+	}
+	return cur + (math.MaxUint64 - prev) + 1
+}
+
+// isPartition проверяет по sysfs, является ли блочное устройство разделом диска
+func isPartition(sysBlock, name string) bool {
+	_, err := os.Stat(filepath.Join(sysBlock, name, "partition"))
+	return err == nil
+}
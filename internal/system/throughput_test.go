@@ -0,0 +1,124 @@
+package system
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const diskstatsBefore = `   7       0 loop0 120 0 2400 10 0 0 0 0 0 20 10 0 0 0 0 0 0
+   8       0 sda 5000 100 200000 3000 8000 400 100000 9000 0 7000 12000 0 0 0 0 0 0
+   8       1 sda1 4900 100 199000 2900 7900 400 99000 8900 0 6900 11800 0 0 0 0 0 0
+ 259       0 nvme0n1 100 0 4096 50 200 0 8192 80 0 100 130 0 0 0 0 0 0
+`
+
+// За 2 секунды sda прочитал 4096 секторов (2 МБ) и записал 2048 (1 МБ)
+const diskstatsAfter = `   7       0 loop0 130 0 2600 10 0 0 0 0 0 20 10 0 0 0 0 0 0
+   8       0 sda 5100 100 204096 3050 8100 400 102048 9100 0 7100 12150 0 0 0 0 0 0
+   8       1 sda1 5000 100 203096 2950 8000 400 101048 9000 0 7000 11950 0 0 0 0 0 0
+ 259       0 nvme0n1 100 0 4096 50 200 0 8192 80 0 100 130 0 0 0 0 0 0
+`
+
+const netDevBefore = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:  9000000    1000    0    0    0     0          0         0  9000000    1000    0    0    0     0       0          0
+  eth0: 1048576     800    0    0    0     0          0         0   524288     400    0    0    0     0       0          0
+ wlan0: 4294967000    50    0    0    0     0          0         0      100      10    0    0    0     0       0          0
+`
+
+// eth0 принял 4 МБ и передал 1 МБ; 32-битный счетчик wlan0 переполнился
+const netDevAfter = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 19000000    2000    0    0    0     0          0         0 19000000    2000    0    0    0     0       0          0
+  eth0: 5242880    3800    0    0    0     0          0         0  1572864    900    0    0    0     0       0          0
+ wlan0:     1752      60    0    0    0     0          0         0     2148      12    0    0    0     0       0          0
+`
+
+// readSnapshots читает два снимка счетчиков из временных файлов
+func readSnapshots(t *testing.T, read func(string) (map[string]ioCounters, error), before, after string) (map[string]ioCounters, map[string]ioCounters) {
+	t.Helper()
+	dir := t.TempDir()
+	writeFixtures(t, dir, map[string]string{"before": before, "after": after})
+	b, err := read(filepath.Join(dir, "before"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := read(filepath.Join(dir, "after"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, a
+}
+
+func TestDiskstatsRates(t *testing.T) {
+	before, after := readSnapshots(t, readDiskstats, diskstatsBefore, diskstatsAfter)
+	if _, ok := after["loop0"]; ok {
+		t.Error("loop-устройство не отброшено")
+	}
+
+	got := counterRates(before, after, 2*time.Second)
+	want := []counterRate{
+		{name: "nvme0n1", in: 0, out: 0},
+		{name: "sda", in: 1024, out: 512},
+		{name: "sda1", in: 1024, out: 512},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("скорости:\n%+v\nожидалось:\n%+v", got, want)
+	}
+}
+
+func TestIsPartition(t *testing.T) {
+	sysBlock := t.TempDir()
+	writeFixtures(t, sysBlock, map[string]string{"sda1/partition": "1\n", "sda/size": "1000\n"})
+	if !isPartition(sysBlock, "sda1") {
+		t.Error("sda1 не распознан как раздел")
+	}
+	if isPartition(sysBlock, "sda") {
+		t.Error("sda распознан как раздел")
+	}
+}
+
+func TestNetDevRates(t *testing.T) {
+	before, after := readSnapshots(t, readNetDev, netDevBefore, netDevAfter)
+	if _, ok := after["lo"]; ok {
+		t.Error("loopback не отброшен")
+	}
+
+	got := counterRates(before, after, 2*time.Second)
+	want := []counterRate{
+		{name: "eth0", in: 2048, out: 512},
+		{name: "wlan0", in: 1, out: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("скорости:\n%+v\nожидалось:\n%+v", got, want)
+	}
+}
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		prev, cur, want uint64
+	}{
+		{100, 300, 200},
+		{math.MaxUint32 - 99, 100, 200},
+		{math.MaxUint64 - 99, 100, 200},
+		{5, 5, 0},
+	}
+	for _, tt := range tests {
+		if got := counterDelta(tt.prev, tt.cur); got != tt.want {
+			t.Errorf("counterDelta(%d, %d) = %d, ожидалось %d", tt.prev, tt.cur, got, tt.want)
+		}
+	}
+}
+
+func TestCounterRatesSkipsNewAndZeroInterval(t *testing.T) {
+	before := map[string]ioCounters{"eth0": {in: 0, out: 0}}
+	after := map[string]ioCounters{"eth0": {in: 1024, out: 0}, "eth1": {in: 1 << 20, out: 0}}
+	if got := counterRates(before, after, time.Second); len(got) != 1 || got[0].name != "eth0" {
+		t.Errorf("скорости %+v, ожидался только eth0", got)
+	}
+	if got := counterRates(before, after, 0); got != nil {
+		t.Errorf("для нулевого интервала получено %+v", got)
+	}
+}
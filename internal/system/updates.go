@@ -0,0 +1,161 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// UpdateSummary содержит результат проверки доступных обновлений
+type UpdateSummary struct {
+	Manager   string
+	Available int
+	Security  int
+	Packages  []string
+}
+
+// securityChecks содержит команды подсчета обновлений безопасности для менеджеров,
+// у которых эта информация не входит в общий список обновлений
+var securityChecks = map[string]string{
+	"dnf": "dnf -q updateinfo list --security",
+	"yum": "yum -q updateinfo list security",
+}
+
+// CheckUpdates проверяет наличие обновлений без их установки.
+// Метаданные обновляются только там, где это безопасно и не запускает обновление пакетов.
+func CheckUpdates(pm *PackageManager) (*UpdateSummary, error) {
+	return checkUpdates(pm, true)
+}
+
+// CheckCachedUpdates проверяет обновления по уже загруженным метаданным, не обращаясь к зеркалам.
+// Подходит для дашборда, который должен отрисовываться быстро.
+func CheckCachedUpdates(pm *PackageManager) (*UpdateSummary, error) {
+	return checkUpdates(pm, false)
+}
+
+func checkUpdates(pm *PackageManager, refresh bool) (*UpdateSummary, error) {
+	if refresh && os.Geteuid() == 0 {
+		switch pm.Name {
+		case "apt", "zypper", "apk":
+			// Ошибку обновления метаданных не считаем фатальной: проверим по кешу
+			_ = shell(pm.Update)
+		}
+	}
+
+	output, err := runCheckCommand(pm.Check)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения обновлений: %w", err)
+	}
+
+	summary := parseUpdateList(pm.Name, output)
+
+	if cmd, ok := securityChecks[pm.Name]; ok {
+		if out, err := runCheckCommand(cmd); err == nil {
+			summary.Security = countSecurityAdvisories(out)
+		}
+	}
+
+	return summary, nil
+}
+
+// runCheckCommand выполняет команду проверки обновлений.
+// dnf и yum check-update возвращают код 100, если обновления есть, - это не ошибка.
+func runCheckCommand(cmd string) (string, error) {
+	output, err := shellOutput(cmd)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 100 {
+		return string(output), nil
+	}
+	return string(output), err
+}
+
+// parseUpdateList разбирает вывод команды проверки обновлений конкретного менеджера
+func parseUpdateList(manager, output string) *UpdateSummary {
+	summary := &UpdateSummary{Manager: manager}
+
+	seen := make(map[string]bool)
+	obsoleting := false
+	for _, raw := range strings.Split(output, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		// Секция "Obsoleting Packages" dnf/yum идет после списка обновлений: пакет, заменяющий
+		// устаревший, - тоже обновление, а строки с отступом под ним - заменяемые пакеты
+		if manager == "dnf" || manager == "yum" {
+			if strings.HasPrefix(line, "Obsoleting Packages") {
+				obsoleting = true
+				continue
+			}
+			if obsoleting && raw != strings.TrimLeft(raw, " \t") {
+				continue
+			}
+		}
+
+		var name string
+		switch manager {
+		case "apt":
+			// vim/jammy-updates,jammy-security 2:8.2 amd64 [upgradable from: 2:8.1]
+			if !strings.Contains(line, "[upgradable from") {
+				continue
+			}
+			name = strings.SplitN(line, "/", 2)[0]
+			if strings.Contains(strings.Fields(line)[0], "-security") {
+				summary.Security++
+			}
+		case "dnf", "yum":
+			// vim-enhanced.x86_64  2:9.0.2120-1.fc39  updates
+			fields := strings.Fields(line)
+			if len(fields) != 3 || !strings.Contains(fields[0], ".") || strings.HasSuffix(line, ":") {
+				continue
+			}
+			name = fields[0][:strings.LastIndex(fields[0], ".")]
+		case "pacman":
+			// linux 6.1.1-1 -> 6.1.2-1
+			if !strings.Contains(line, " -> ") {
+				continue
+			}
+			name = strings.Fields(line)[0]
+		case "zypper":
+			// v | repo | name | current | available | arch
+			fields := strings.Split(line, "|")
+			if len(fields) < 5 || strings.TrimSpace(fields[0]) != "v" {
+				continue
+			}
+			name = strings.TrimSpace(fields[2])
+		case "apk":
+			// busybox-1.36.0-r0 < 1.36.1-r0
+			if !strings.Contains(line, " < ") {
+				continue
+			}
+			name = strings.Fields(line)[0]
+		default:
+			name = line
+		}
+
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		summary.Packages = append(summary.Packages, name)
+	}
+
+	summary.Available = len(summary.Packages)
+	return summary
+}
+
+// countSecurityAdvisories считает пакеты в выводе updateinfo
+func countSecurityAdvisories(output string) int {
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		seen[fields[len(fields)-1]] = true
+	}
+	return len(seen)
+}
@@ -0,0 +1,116 @@
+package system
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+const aptUpgradable = `Listing... Done
+vim/jammy-updates,jammy-security 2:8.2.3995-1ubuntu2.15 amd64 [upgradable from: 2:8.2.3995-1ubuntu2.13]
+libssl3/jammy-security 3.0.2-0ubuntu1.15 amd64 [upgradable from: 3.0.2-0ubuntu1.14]
+curl/jammy-updates 7.81.0-1ubuntu1.16 amd64 [upgradable from: 7.81.0-1ubuntu1.15]
+curl/jammy-updates 7.81.0-1ubuntu1.16 i386 [upgradable from: 7.81.0-1ubuntu1.15]
+`
+
+const dnfCheckUpdate = `Last metadata expiration check: 0:12:31 ago on Mon 01 Jan 2024 10:00:00 AM UTC.
+
+kernel.x86_64                          6.6.8-200.fc39                updates
+vim-enhanced.x86_64                    2:9.0.2120-1.fc39             updates
+openssl-libs.x86_64                    1:3.1.1-4.fc39                updates
+Obsoleting Packages
+grub2-tools.x86_64                     1:2.06-110.fc39               updates
+    grub2-tools.x86_64                 1:2.06-100.fc39               @updates
+`
+
+func TestParseUpdateListApt(t *testing.T) {
+	summary := parseUpdateList("apt", aptUpgradable)
+	want := &UpdateSummary{Manager: "apt", Available: 3, Security: 2, Packages: []string{"vim", "libssl3", "curl"}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Fatalf("parseUpdateList = %+v, ожидалось %+v", summary, want)
+	}
+}
+
+func TestParseUpdateListDnf(t *testing.T) {
+	summary := parseUpdateList("dnf", dnfCheckUpdate)
+	want := &UpdateSummary{Manager: "dnf", Available: 4, Packages: []string{"kernel", "vim-enhanced", "openssl-libs", "grub2-tools"}}
+	if !reflect.DeepEqual(summary, want) {
+		t.Fatalf("parseUpdateList = %+v, ожидалось %+v", summary, want)
+	}
+}
+
+func TestParseUpdateListEmpty(t *testing.T) {
+	for manager, output := range map[string]string{
+		"apt": "Listing... Done\n",
+		"dnf": "Last metadata expiration check: 0:01:00 ago on Mon 01 Jan 2024 10:00:00 AM UTC.\n",
+	} {
+		if summary := parseUpdateList(manager, output); summary.Available != 0 || len(summary.Packages) != 0 {
+			t.Errorf("%s: %+v, ожидалось отсутствие обновлений", manager, summary)
+		}
+	}
+}
+
+func TestCountSecurityAdvisories(t *testing.T) {
+	output := `FEDORA-2024-1a2b3c4d5e Important/Sec. openssl-libs-1:3.1.1-4.fc39.x86_64
+FEDORA-2024-1a2b3c4d5e Important/Sec. openssl-1:3.1.1-4.fc39.x86_64
+FEDORA-2024-9f8e7d6c5b Moderate/Sec.  openssl-libs-1:3.1.1-4.fc39.x86_64
+`
+	if got := countSecurityAdvisories(output); got != 2 {
+		t.Fatalf("countSecurityAdvisories = %d, ожидалось 2", got)
+	}
+}
+
+// exitStatus возвращает настоящую *exec.ExitError с кодом code
+func exitStatus(t *testing.T, code string) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", "exit "+code).Run()
+	if err == nil {
+		t.Fatal("ожидалась ошибка завершения")
+	}
+	return err
+}
+
+func TestCheckCachedUpdatesDnfExit100(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("sh -c dnf check-update", dnfCheckUpdate, exitStatus(t, "100")).
+		On("sh -c dnf -q updateinfo list --security",
+			"FEDORA-2024-1a2b3c4d5e Important/Sec. openssl-libs-1:3.1.1-4.fc39.x86_64\n", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	summary, err := CheckCachedUpdates(&PackageManager{Name: "dnf", Check: "dnf check-update"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Available != 4 || summary.Security != 1 {
+		t.Fatalf("summary = %+v, ожидалось 4 обновления и 1 исправление безопасности", summary)
+	}
+	want := []string{"sh -c dnf check-update", "sh -c dnf -q updateinfo list --security"}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, want) {
+		t.Fatalf("команды %q, ожидалось только чтение %q", commands, want)
+	}
+}
+
+func TestCheckCachedUpdatesFailure(t *testing.T) {
+	fake := runner.NewFakeRunner().On("sh -c dnf check-update", "", exitStatus(t, "1"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	if _, err := CheckCachedUpdates(&PackageManager{Name: "dnf", Check: "dnf check-update"}); err == nil {
+		t.Fatal("ожидалась ошибка при коде завершения 1")
+	}
+}
+
+func TestGetAvailableUpdatesDnf(t *testing.T) {
+	fake := runner.NewFakeRunner().On("sh -c dnf check-update", "\n"+dnfCheckUpdate+"\n\n", exitStatus(t, "100"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	updates, err := GetAvailableUpdates(&PackageManager{Name: "dnf", Check: "dnf check-update"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"kernel", "vim-enhanced", "openssl-libs", "grub2-tools"}
+	if !reflect.DeepEqual(updates, want) {
+		t.Fatalf("GetAvailableUpdates = %q, ожидалось %q", updates, want)
+	}
+}
@@ -0,0 +1,164 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/ui"
+)
+
+// UpdateReport содержит результат обновления системы
+type UpdateReport struct {
+	// Refreshed - список пакетов успешно обновлен перед обновлением
+	Refreshed bool
+	Upgraded  []string
+	// HeldBack - пакеты, которые менеджер отказался обновлять (apt kept back)
+	HeldBack       []string
+	RebootRequired bool
+	Duration       time.Duration
+}
+
+// UpdateSystemWithResult обновляет систему и возвращает отчет об обновленных пакетах
+func UpdateSystemWithResult(pm *PackageManager) (*UpdateReport, error) {
+	if isOSTree() {
+		return nil, fmt.Errorf("%w: обновите систему командой rpm-ostree upgrade", ErrReadOnlyFilesystem)
+	}
+
+	start := time.Now()
+	report := &UpdateReport{}
+
+	// Обновляем список пакетов
+	s := ui.NewSpinner("Обновление списка пакетов...")
+	s.Start()
+
+	// Недоступное зеркало не должно прерывать весь запуск: повторяем временные ошибки
+	err := refreshPackageLists(pm)
+	s.Stop()
+	if err != nil {
+		return report, err
+	}
+	report.Refreshed = true
+
+	// Сообщаем, сколько обновлений будет установлено
+	if summary, err := checkUpdates(pm, false); err == nil {
+		fmt.Printf("Доступно обновлений: %d (безопасности: %d)\n", summary.Available, summary.Security)
+	}
+
+	// Обновляем пакеты
+	s = ui.NewSpinner("Обновление пакетов...")
+	s.Start()
+
+	// Вывод разбирается по английским сообщениям менеджеров
+	output, err := cmdRunner.CombinedOutput("env", cLocale("sh", "-c", pm.Upgrade)...)
+	s.Stop()
+	report.Duration = time.Since(start)
+	if err != nil {
+		return report, fmt.Errorf("ошибка обновления пакетов: %w", err)
+	}
+
+	report.Upgraded, report.HeldBack = parseUpgradeOutput(pm.Name, string(output))
+	report.RebootRequired = rebootRequired(pm.Name)
+	return report, nil
+}
+
+// parseUpgradeOutput извлекает обновленные и отложенные пакеты из вывода обновления
+func parseUpgradeOutput(manager, output string) (upgraded, heldBack []string) {
+	lines := strings.Split(output, "\n")
+
+	switch manager {
+	case "apt":
+		upgraded = aptSection(lines, "The following packages will be upgraded:")
+		heldBack = aptSection(lines, "The following packages have been kept back:")
+	case "dnf", "yum":
+		for _, nevra := range aptSection(lines, "Upgraded:") {
+			upgraded = append(upgraded, rpmName(nevra))
+		}
+	case "pacman":
+		// (1/3) upgrading linux    [######] 100%
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && fields[1] == "upgrading" {
+				upgraded = append(upgraded, strings.TrimSuffix(fields[2], "..."))
+			}
+		}
+	case "zypper":
+		for i, line := range lines {
+			if strings.Contains(line, "going to be upgraded:") {
+				upgraded = aptSection(lines[i:], strings.TrimSpace(line))
+				break
+			}
+		}
+	case "apk":
+		// (1/3) Upgrading busybox (1.36.0-r0 -> 1.36.1-r0)
+		for _, line := range lines {
+			fields := strings.Fields(line)
+			if len(fields) >= 3 && fields[1] == "Upgrading" {
+				upgraded = append(upgraded, fields[2])
+			}
+		}
+	}
+	return upgraded, heldBack
+}
+
+// aptSection возвращает имена из блока с отступом, следующего за строкой header
+func aptSection(lines []string, header string) []string {
+	var names []string
+	inside := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == header {
+			inside = true
+			continue
+		}
+		if !inside {
+			continue
+		}
+		if line == "" || (line[0] != ' ' && line[0] != '\t') {
+			break
+		}
+		names = append(names, strings.Fields(line)...)
+	}
+	return names
+}
+
+// rpmName отбрасывает версию, релиз и архитектуру из NEVRA: vim-enhanced-2:9.0-1.fc39.x86_64 -> vim-enhanced
+func rpmName(nevra string) string {
+	name := nevra
+	for i := 0; i < 2; i++ {
+		idx := strings.LastIndex(name, "-")
+		if idx <= 0 {
+			return nevra
+		}
+		name = name[:idx]
+	}
+	return name
+}
+
+// rebootRequired проверяет, требуется ли перезагрузка после обновления
+func rebootRequired(manager string) bool {
+	// Debian и Ubuntu создают файл-флаг при обновлении ядра и базовых библиотек
+	if _, err := os.Stat("/var/run/reboot-required"); err == nil {
+		return true
+	}
+
+	switch manager {
+	case "dnf", "yum":
+		if commandExists("needs-restarting") {
+			err := cmdRunner.Run("needs-restarting", "-r")
+			var exitErr *exec.ExitError
+			return errors.As(err, &exitErr) && exitErr.ExitCode() == 1
+		}
+	case "pacman", "apk":
+		// Модули работающего ядра удаляются при его обновлении
+		release, err := cmdRunner.Output("uname", "-r")
+		if err != nil {
+			return false
+		}
+		_, err = os.Stat("/lib/modules/" + strings.TrimSpace(string(release)))
+		return errors.Is(err, os.ErrNotExist)
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package system
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const aptUpgradeOutput = `Reading package lists...
+Building dependency tree...
+Reading state information...
+Calculating upgrade...
+The following packages have been kept back:
+  linux-image-generic linux-headers-generic
+The following NEW packages will be installed:
+  linux-image-6.8.0-45-generic
+The following packages will be upgraded:
+  curl libcurl4 openssl
+  libssl3
+4 upgraded, 1 newly installed, 0 to remove and 2 not upgraded.
+Need to get 5,123 kB of archives.
+After this operation, 1,024 kB of additional disk space will be used.
+Setting up curl (8.5.0-2ubuntu10.4) ...
+`
+
+// aptSummaryCounts разбирает итоговую строку apt "N upgraded, M newly installed, ..."
+func aptSummaryCounts(t *testing.T, output string) (upgraded, installed, removed, notUpgraded int) {
+	t.Helper()
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.Contains(line, " upgraded, ") {
+			continue
+		}
+		if _, err := fmt.Sscanf(line, "%d upgraded, %d newly installed, %d to remove and %d not upgraded.",
+			&upgraded, &installed, &removed, &notUpgraded); err != nil {
+			t.Fatalf("итоговая строка %q: %v", line, err)
+		}
+		return
+	}
+	t.Fatal("в выводе нет итоговой строки apt")
+	return
+}
+
+func TestParseUpgradeOutputApt(t *testing.T) {
+	upgraded, heldBack := parseUpgradeOutput("apt", aptUpgradeOutput)
+
+	wantUpgraded := []string{"curl", "libcurl4", "openssl", "libssl3"}
+	wantHeld := []string{"linux-image-generic", "linux-headers-generic"}
+	if !reflect.DeepEqual(upgraded, wantUpgraded) {
+		t.Errorf("обновлены %q, ожидалось %q", upgraded, wantUpgraded)
+	}
+	if !reflect.DeepEqual(heldBack, wantHeld) {
+		t.Errorf("отложены %q, ожидалось %q", heldBack, wantHeld)
+	}
+
+	// Списки согласуются с итоговой строкой и не захватывают ее
+	n, _, _, notUpgraded := aptSummaryCounts(t, aptUpgradeOutput)
+	if len(upgraded) != n || len(heldBack) != notUpgraded {
+		t.Errorf("обновлено %d и отложено %d, итоговая строка: %d и %d", len(upgraded), len(heldBack), n, notUpgraded)
+	}
+}
+
+func TestParseUpgradeOutputAptNothingToDo(t *testing.T) {
+	output := "Reading package lists...\nCalculating upgrade...\n0 upgraded, 0 newly installed, 0 to remove and 0 not upgraded.\n"
+	upgraded, heldBack := parseUpgradeOutput("apt", output)
+	if len(upgraded) != 0 || len(heldBack) != 0 {
+		t.Errorf("без обновлений получено %q и %q", upgraded, heldBack)
+	}
+}
+
+func TestParseUpgradeOutputManagers(t *testing.T) {
+	tests := []struct {
+		manager string
+		output  string
+		want    []string
+	}{
+		{"dnf", `Upgrading:
+ vim-enhanced  x86_64  2:9.1.0-1.fc40  updates  2.0 M
+
+Upgraded:
+  vim-enhanced-2:9.1.0-1.fc40.x86_64    vim-common-2:9.1.0-1.fc40.x86_64
+  openssl-libs-1:3.2.1-2.fc40.x86_64
+
+Complete!
+`, []string{"vim-enhanced", "vim-common", "openssl-libs"}},
+		{"pacman", `:: Processing package changes...
+(1/2) upgrading linux                              [######################] 100%
+(2/2) upgrading systemd...                         [######################] 100%
+`, []string{"linux", "systemd"}},
+		{"zypper", `The following 2 packages are going to be upgraded:
+  curl libcurl4
+
+2 packages to upgrade.
+`, []string{"curl", "libcurl4"}},
+		{"apk", `(1/2) Upgrading busybox (1.36.0-r0 -> 1.36.1-r0)
+(2/2) Upgrading musl (1.2.4-r1 -> 1.2.4-r2)
+OK: 8 MiB in 15 packages
+`, []string{"busybox", "musl"}},
+	}
+	for _, tt := range tests {
+		upgraded, heldBack := parseUpgradeOutput(tt.manager, tt.output)
+		if !reflect.DeepEqual(upgraded, tt.want) {
+			t.Errorf("%s: обновлены %q, ожидалось %q", tt.manager, upgraded, tt.want)
+		}
+		if len(heldBack) != 0 {
+			t.Errorf("%s: отложены %q, ожидалось пусто", tt.manager, heldBack)
+		}
+	}
+}
+
+func TestRpmName(t *testing.T) {
+	tests := map[string]string{
+		"vim-enhanced-2:9.0-1.fc39.x86_64": "vim-enhanced",
+		"bash-5.2.26-3.fc40.x86_64":        "bash",
+		"noversion":                        "noversion",
+	}
+	for nevra, want := range tests {
+		if got := rpmName(nevra); got != want {
+			t.Errorf("rpmName(%q) = %q, ожидалось %q", nevra, got, want)
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package system
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
-	"time"
 
-	"github.com/briandowns/spinner"
+	appconfig "github.com/13winged/go-to-run/internal/config"
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/ui"
 )
 
 // SystemInfo содержит информацию о системе
@@ -22,10 +25,21 @@ type SystemInfo struct {
 	IPAddress   string
 	Processes   int
 	LoadAverage string
+	// DiskWarnings содержит предупреждения о нехватке inode
+	DiskWarnings []string
+	// Firewall - состояние фаервола; nil, если его не удалось определить
+	Firewall *FirewallInfo
+	// RequiresRoot перечисляет проверки, пропущенные без прав root
+	RequiresRoot []string
 }
 
 // SystemUtils предоставляет утилиты для работы с системой
-type SystemUtils struct{}
+type SystemUtils struct {
+	// InodeThreshold - порог занятых inode в процентах; ноль означает DefaultInodeThreshold
+	InodeThreshold float64
+	// Disk задает фильтры файловых систем в отчетах о дисках
+	Disk appconfig.DiskConfig
+}
 
 // GetSystemInfo собирает информацию о системе
 func (su *SystemUtils) GetSystemInfo() (*SystemInfo, error) {
@@ -38,17 +52,17 @@ func (su *SystemUtils) GetSystemInfo() (*SystemInfo, error) {
 	}
 
 	// Получаем информацию о ядре
-	if kernel, err := exec.Command("uname", "-r").Output(); err == nil {
+	if kernel, err := cmdRunner.Output("uname", "-r"); err == nil {
 		info.Kernel = strings.TrimSpace(string(kernel))
 	}
 
 	// Получаем время работы
-	if uptime, err := exec.Command("uptime", "-p").Output(); err == nil {
+	if uptime, err := cmdRunner.Output("uptime", "-p"); err == nil {
 		info.Uptime = strings.TrimSpace(strings.TrimPrefix(string(uptime), "up "))
 	}
 
 	// Получаем информацию о памяти
-	if memory, err := exec.Command("free", "-h").Output(); err == nil {
+	if memory, err := cmdRunner.Output("free", "-h"); err == nil {
 		lines := strings.Split(string(memory), "\n")
 		if len(lines) > 1 {
 			parts := strings.Fields(lines[1])
@@ -59,11 +73,17 @@ func (su *SystemUtils) GetSystemInfo() (*SystemInfo, error) {
 	}
 
 	// Получаем информацию о дисках
-	if disk, err := exec.Command("df", "-h", "--output=source,size,used,avail,pcent,target").Output(); err == nil {
+	if disk, err := cmdRunner.Output("df", "-h", "--output=source,fstype,size,used,avail,pcent,target"); err == nil {
 		lines := strings.Split(string(disk), "\n")
 		var diskInfo []string
 		for i, line := range lines {
-			if i > 0 && len(line) > 0 {
+			fields := strings.Fields(line)
+			if i == 0 || len(fields) < 7 {
+				continue
+			}
+			// Те же фильтры, что и в GetDiskUsage: snap и overlay не показываются
+			entry := mountEntry{Source: fields[0], FSType: fields[1], Mount: strings.Join(fields[6:], " ")}
+			if showMount(entry, su.Disk) {
 				diskInfo = append(diskInfo, line)
 			}
 		}
@@ -71,9 +91,12 @@ func (su *SystemUtils) GetSystemInfo() (*SystemInfo, error) {
 			info.Disk = strings.Join(diskInfo[:min(3, len(diskInfo))], "; ")
 		}
 	}
+	if usages, err := su.GetDiskUsage(); err == nil {
+		info.DiskWarnings = su.InodeWarnings(usages)
+	}
 
 	// Получаем информацию о CPU
-	if cpu, err := exec.Command("lscpu").Output(); err == nil {
+	if cpu, err := cmdRunner.Output("lscpu"); err == nil {
 		lines := strings.Split(string(cpu), "\n")
 		for _, line := range lines {
 			if strings.Contains(line, "Model name:") {
@@ -84,35 +107,41 @@ func (su *SystemUtils) GetSystemInfo() (*SystemInfo, error) {
 	}
 
 	// Получаем IP адрес
-	if ip, err := exec.Command("hostname", "-I").Output(); err == nil {
+	if ip, err := cmdRunner.Output("hostname", "-I"); err == nil {
 		info.IPAddress = strings.TrimSpace(string(ip))
 	}
 
 	// Получаем количество процессов
-	if procs, err := exec.Command("ps", "-e", "--no-headers").Output(); err == nil {
+	if procs, err := cmdRunner.Output("ps", "-e", "--no-headers"); err == nil {
 		info.Processes = len(strings.Split(strings.TrimSpace(string(procs)), "\n"))
 	}
 
 	// Получаем среднюю загрузку
-	if load, err := exec.Command("uptime").Output(); err == nil {
+	if load, err := cmdRunner.Output("uptime"); err == nil {
 		parts := strings.Split(string(load), "load average:")
 		if len(parts) > 1 {
 			info.LoadAverage = strings.TrimSpace(parts[1])
 		}
 	}
 
+	// Фаервол запрашивается только от root: без прав проверка помечается, а не дает ошибку доступа
+	if firewall, err := (&SecurityManager{}).FirewallStatus(); errors.Is(err, ErrRequiresRoot) {
+		info.RequiresRoot = append(info.RequiresRoot, "firewall")
+	} else if err == nil {
+		info.Firewall = firewall
+	}
+
 	return info, nil
 }
 
 // SetupTimezone настраивает часовой пояс
 func (su *SystemUtils) SetupTimezone(timezone string) error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = fmt.Sprintf(" Настройка часового пояса: %s", timezone)
+	s := ui.NewSpinner(fmt.Sprintf("Настройка часового пояса: %s", timezone))
 	s.Start()
 	defer s.Stop()
 
 	if commandExists("timedatectl") {
-		if err := exec.Command("timedatectl", "set-timezone", timezone).Run(); err != nil {
+		if err := cmdRunner.Run("timedatectl", "set-timezone", timezone); err != nil {
 			// Альтернативный метод
 			return su.setTimezoneFile(timezone)
 		}
@@ -128,6 +157,10 @@ func (su *SystemUtils) setTimezoneFile(timezone string) error {
 		return fmt.Errorf("часовой пояс не найден: %s", timezone)
 	}
 
+	if err := ensureWritable("/etc/localtime"); err != nil {
+		return err
+	}
+
 	// Удаляем старый симлинк
 	os.Remove("/etc/localtime")
 
@@ -137,13 +170,21 @@ func (su *SystemUtils) setTimezoneFile(timezone string) error {
 	}
 
 	// Записываем в /etc/timezone
-	return os.WriteFile("/etc/timezone", []byte(timezone+"\n"), 0644)
+	return writeFileMode("/etc/timezone", []byte(timezone+"\n"), systemFileMode)
+}
+
+// AvailableLocales возвращает локали, сгенерированные на хосте (locale -a)
+func AvailableLocales() ([]string, error) {
+	output, err := cmdRunner.Output("locale", "-a")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка локалей: %w", err)
+	}
+	return strings.Fields(string(output)), nil
 }
 
 // SetupLocale настраивает локаль
 func (su *SystemUtils) SetupLocale(locale string) error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = fmt.Sprintf(" Настройка локали: %s", locale)
+	s := ui.NewSpinner(fmt.Sprintf("Настройка локали: %s", locale))
 	s.Start()
 	defer s.Stop()
 
@@ -153,26 +194,32 @@ func (su *SystemUtils) SetupLocale(locale string) error {
 
 	// Генерируем локаль
 	cmd := fmt.Sprintf("locale-gen %s", locale)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
+	if err := shell(cmd); err != nil {
 		return fmt.Errorf("ошибка генерации локали: %v", err)
 	}
 
 	// Обновляем настройки локали
 	cmd = fmt.Sprintf("update-locale LANG=%s LC_ALL=%s", locale, locale)
-	return exec.Command("sh", "-c", cmd).Run()
+	return shell(cmd)
 }
 
 // SetupSwap настраивает swap
 func (su *SystemUtils) SetupSwap(swapSize string) error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Настройка swap..."
+	s := ui.NewSpinner("Настройка swap...")
 	s.Start()
 	defer s.Stop()
 
-	// Проверяем существующий swap
-	if swapInfo, err := exec.Command("swapon", "--show").Output(); err == nil {
-		if strings.TrimSpace(string(swapInfo)) != "" {
-			return fmt.Errorf("swap уже настроен")
+	// Другие swap устройства не мешают; повторная настройка нашего файла - no-op
+	if active, err := isActiveSwap(procSwapsPath, ManagedSwapFile); err == nil && active {
+		s.Stop()
+		fmt.Printf("Swap %s уже подключен\n", ManagedSwapFile)
+		return nil
+	}
+
+	// Проверяем возможность записи до создания swap файла
+	for _, path := range []string{ManagedSwapFile, "/etc/fstab", "/etc/sysctl.d/99-swappiness.conf"} {
+		if err := ensureWritable(path); err != nil {
+			return err
 		}
 	}
 
@@ -186,88 +233,85 @@ func (su *SystemUtils) SetupSwap(swapSize string) error {
 	}
 
 	// Создаем swap файл
-	swapFile := "/swapfile"
+	swapFile := ManagedSwapFile
 	if err := su.createSwapFile(swapFile, swapSize); err != nil {
 		return err
 	}
 
+	// При ошибке на следующих шагах отключаем swap и удаляем файл
+	rb := &Rollback{}
+	rb.Add("удаление "+swapFile, func() error {
+		return os.Remove(swapFile)
+	})
+
 	// Настраиваем swap
-	if err := su.configureSwap(swapFile); err != nil {
+	if err := su.configureSwap(swapFile, rb); err != nil {
+		rb.Run()
 		return err
 	}
 
 	// Настраиваем swappiness
-	return su.configureSwappiness()
+	if err := su.configureSwappiness(); err != nil {
+		rb.Run()
+		return err
+	}
+	return nil
 }
 
 func (su *SystemUtils) calculateSwapSize() (string, error) {
-	memInfo, err := exec.Command("free", "-b").Output()
+	// Учитываем лимит cgroup: в контейнере память хоста не отражает доступную
+	memory, err := su.GetMemoryInfo()
 	if err != nil {
 		return "2G", nil // Значение по умолчанию
 	}
+	memBytes := memory.Total
 
-	lines := strings.Split(string(memInfo), "\n")
-	if len(lines) > 1 {
-		parts := strings.Fields(lines[1])
-		if len(parts) >= 2 {
-			memBytes, err := parseBytes(parts[1])
-			if err != nil {
-				return "2G", nil
-			}
-
-			// Рекомендуемый размер swap:
-			// - RAM < 2GB: 2x RAM
-			// - RAM 2-8GB: 1x RAM
-			// - RAM > 8GB: 0.5x RAM
-			var swapBytes uint64
-			if memBytes < 2*1024*1024*1024 {
-				swapBytes = memBytes * 2
-			} else if memBytes <= 8*1024*1024*1024 {
-				swapBytes = memBytes
-			} else {
-				swapBytes = memBytes / 2
-			}
-
-			return fmt.Sprintf("%dM", swapBytes/(1024*1024)), nil
-		}
+	// Рекомендуемый размер swap:
+	// - RAM < 2GB: 2x RAM
+	// - RAM 2-8GB: 1x RAM
+	// - RAM > 8GB: 0.5x RAM
+	var swapBytes uint64
+	if memBytes < 2*1024*1024*1024 {
+		swapBytes = memBytes * 2
+	} else if memBytes <= 8*1024*1024*1024 {
+		swapBytes = memBytes
+	} else {
+		swapBytes = memBytes / 2
 	}
 
-	return "2G", nil
+	return fmt.Sprintf("%dM", swapBytes/(1024*1024)), nil
 }
 
 func (su *SystemUtils) createSwapFile(swapFile, size string) error {
-	// Удаляем старый файл если существует
-	os.Remove(swapFile)
-
-	// Создаем файл с помощью fallocate
-	cmd := fmt.Sprintf("fallocate -l %s %s", size, swapFile)
-	if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
-		// fallocate может не работать, используем dd
-		cmd = fmt.Sprintf("dd if=/dev/zero of=%s bs=1M count=%s status=progress",
-			swapFile, strings.TrimSuffix(size, "G"))
-		if err := exec.Command("sh", "-c", cmd).Run(); err != nil {
-			return fmt.Errorf("ошибка создания swap файла: %v", err)
-		}
+	bytes, err := ParseSwapSize(size)
+	if err != nil {
+		return err
+	}
+	if err := allocateSwapFile(swapFile, int64(bytes)); err != nil {
+		return err
 	}
 
 	// Устанавливаем права
-	return exec.Command("chmod", "600", swapFile).Run()
+	return cmdRunner.Run("chmod", "600", swapFile)
 }
 
-func (su *SystemUtils) configureSwap(swapFile string) error {
+func (su *SystemUtils) configureSwap(swapFile string, rb *Rollback) error {
 	// Форматируем как swap
-	if err := exec.Command("mkswap", swapFile).Run(); err != nil {
+	if err := cmdRunner.Run("mkswap", swapFile); err != nil {
 		return fmt.Errorf("ошибка форматирования swap: %v", err)
 	}
 
 	// Включаем swap
-	if err := exec.Command("swapon", swapFile).Run(); err != nil {
+	if err := cmdRunner.Run("swapon", swapFile); err != nil {
 		return fmt.Errorf("ошибка включения swap: %v", err)
 	}
+	rb.Add("отключение swap "+swapFile, func() error {
+		return cmdRunner.Run("swapoff", swapFile)
+	})
 
 	// Добавляем в fstab
 	fstabEntry := fmt.Sprintf("%s none swap sw 0 0\n", swapFile)
-	f, err := os.OpenFile("/etc/fstab", os.O_APPEND|os.O_WRONLY, 0644)
+	f, err := os.OpenFile("/etc/fstab", os.O_APPEND|os.O_WRONLY, systemFileMode)
 	if err != nil {
 		return fmt.Errorf("ошибка открытия fstab: %v", err)
 	}
@@ -276,25 +320,45 @@ func (su *SystemUtils) configureSwap(swapFile string) error {
 	if _, err := f.WriteString(fstabEntry); err != nil {
 		return fmt.Errorf("ошибка записи в fstab: %v", err)
 	}
+	rb.Add("удаление записи swap из fstab", func() error {
+		return removeFstabEntry(fstabEntry)
+	})
 
 	return nil
 }
 
+// removeFstabEntry удаляет из /etc/fstab строку, добавленную при настройке swap
+func removeFstabEntry(entry string) error {
+	content, err := os.ReadFile("/etc/fstab")
+	if err != nil {
+		return fmt.Errorf("ошибка чтения fstab: %w", err)
+	}
+	updated := strings.Replace(string(content), entry, "", 1)
+	return writeFileMode("/etc/fstab", []byte(updated), systemFileMode)
+}
+
 func (su *SystemUtils) configureSwappiness() error {
 	config := "vm.swappiness=10\nvm.vfs_cache_pressure=50\n"
 	configFile := "/etc/sysctl.d/99-swappiness.conf"
 
-	if err := os.WriteFile(configFile, []byte(config), 0644); err != nil {
+	if err := writeFileMode(configFile, []byte(config), systemFileMode); err != nil {
 		return fmt.Errorf("ошибка записи конфигурации swappiness: %v", err)
 	}
 
-	return exec.Command("sysctl", "-p", configFile).Run()
+	return cmdRunner.Run("sysctl", "-p", configFile)
 }
 
-// CleanSystem очищает систему
+// CleanSystem очищает систему с настройками по умолчанию
 func (su *SystemUtils) CleanSystem() error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Очистка системы..."
+	return su.CleanSystemWithConfig(appconfig.DefaultConfig().Clean)
+}
+
+// CleanSystemWithConfig очищает систему, сокращая журнал systemd по заданному сроку и/или размеру
+func (su *SystemUtils) CleanSystemWithConfig(cfg appconfig.CleanConfig) error {
+	// Показываем, какие пакеты удалит autoremove, до начала очистки
+	su.showOrphans()
+
+	s := ui.NewSpinner("Очистка системы...")
 	s.Start()
 	defer s.Stop()
 
@@ -307,48 +371,68 @@ func (su *SystemUtils) CleanSystem() error {
 	// Очищаем логи
 	su.cleanLogs()
 
-	// Очищаем кеш systemd
-	su.cleanSystemdCache()
+	// Очищаем журнал systemd
+	freed, err := su.VacuumJournal(cfg)
+	s.Stop()
+	if err != nil {
+		return err
+	}
+	if freed > 0 {
+		fmt.Printf("Журнал systemd уменьшен на %s\n", formatSize(freed))
+	}
 
 	return nil
 }
 
 func (su *SystemUtils) cleanTempFiles() {
-	exec.Command("sh", "-c", "rm -rf /tmp/* 2>/dev/null || true").Run()
-	exec.Command("sh", "-c", "rm -rf /var/tmp/* 2>/dev/null || true").Run()
+	shell("rm -rf /tmp/* 2>/dev/null || true")
+	shell("rm -rf /var/tmp/* 2>/dev/null || true")
 }
 
 func (su *SystemUtils) cleanPackageCache() {
-	pm, err := (&PackageManagerDetector{}).Detect()
-	if err == nil {
-		exec.Command("sh", "-c", pm.Clean).Run()
+	pm, err := DetectPackageManager()
+	if err != nil {
+		notifyNoPackageManager()
+		return
 	}
+	shell(pm.Clean)
 }
 
-func (su *SystemUtils) cleanLogs() {
-	exec.Command("sh", "-c", "find /var/log -type f -name '*.gz' -delete 2>/dev/null || true").Run()
-	exec.Command("sh", "-c", "find /var/log -type f -name '*.1' -delete 2>/dev/null || true").Run()
-}
+// showOrphans выводит пакеты, которые удалит очистка кеша менеджера пакетов
+func (su *SystemUtils) showOrphans() {
+	pm, err := DetectPackageManager()
+	if err != nil {
+		notifyNoPackageManager()
+		return
+	}
+	if !strings.Contains(pm.Clean, "autoremove") {
+		return
+	}
+	report, err := GetOrphanReport(pm)
+	if err != nil || len(report.Packages) == 0 {
+		return
+	}
 
-func (su *SystemUtils) cleanSystemdCache() {
-	if commandExists("journalctl") {
-		exec.Command("sh", "-c", "journalctl --vacuum-time=3d").Run()
+	fmt.Printf("Будут удалены неиспользуемые пакеты (%d): %s\n", len(report.Packages), strings.Join(report.Packages, ", "))
+	if report.FreedBytes > 0 {
+		fmt.Printf("Освободится примерно %s\n", formatSize(report.FreedBytes))
 	}
 }
 
+func (su *SystemUtils) cleanLogs() {
+	shell("find /var/log -type f -name '*.gz' -delete 2>/dev/null || true")
+	shell("find /var/log -type f -name '*.1' -delete 2>/dev/null || true")
+}
+
 // RunCommand выполняет команду с выводом
 func (su *SystemUtils) RunCommand(name string, args ...string) error {
-	cmd := exec.Command(name, args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
-	return cmd.Run()
+	streams := runner.Streams{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	return cmdRunner.RunIO(context.Background(), streams, name, args...)
 }
 
 // RunCommandOutput выполняет команду и возвращает вывод
 func (su *SystemUtils) RunCommandOutput(name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
-	output, err := cmd.Output()
+	output, err := cmdRunner.Output(name, args...)
 	if err != nil {
 		var stderr []byte
 		if exitErr, ok := err.(*exec.ExitError); ok {
@@ -362,33 +446,14 @@ func (su *SystemUtils) RunCommandOutput(name string, args ...string) (string, er
 // Helper функции
 
 func detectDistro() (string, string, error) {
-	if _, err := os.Stat("/etc/os-release"); err == nil {
-		content, err := os.ReadFile("/etc/os-release")
-		if err != nil {
-			return "", "", err
-		}
-
-		lines := strings.Split(string(content), "\n")
-		var id, versionID, prettyName string
-
-		for _, line := range lines {
-			if strings.HasPrefix(line, "ID=") {
-				id = strings.Trim(strings.TrimPrefix(line, "ID="), "\"")
-			}
-			if strings.HasPrefix(line, "VERSION_ID=") {
-				versionID = strings.Trim(strings.TrimPrefix(line, "VERSION_ID="), "\"")
-			}
-			if strings.HasPrefix(line, "PRETTY_NAME=") {
-				prettyName = strings.Trim(strings.TrimPrefix(line, "PRETTY_NAME="), "\"")
-			}
-		}
-
-		if prettyName != "" {
-			return id, prettyName, nil
-		}
-		return id, versionID, nil
+	release, err := ParseOSRelease()
+	if err != nil {
+		return "unknown", "unknown", nil
+	}
+	if release.PrettyName != "" {
+		return release.ID, release.PrettyName, nil
 	}
-	return "unknown", "unknown", nil
+	return release.ID, release.VersionID, nil
 }
 
 func parseBytes(s string) (uint64, error) {
@@ -0,0 +1,59 @@
+package system
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// useEUID подменяет эффективный UID процесса на время теста
+func useEUID(t *testing.T, uid int) {
+	t.Helper()
+	prev := geteuid
+	geteuid = func() int { return uid }
+	t.Cleanup(func() { geteuid = prev })
+}
+
+func TestGetSystemInfoNonRootSkipsFirewall(t *testing.T) {
+	useEUID(t, 1000)
+	fake := runner.NewFakeRunner().On("ufw", "", errors.New("ERROR: You need to be root to run this script"))
+	t.Cleanup(SetCommandRunner(fake))
+
+	info, err := (&SystemUtils{}).GetSystemInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(info.RequiresRoot, []string{"firewall"}) {
+		t.Errorf("RequiresRoot = %v, ожидалось [firewall]", info.RequiresRoot)
+	}
+	if info.Firewall != nil {
+		t.Errorf("Firewall = %+v, ожидался nil", info.Firewall)
+	}
+	for _, command := range fake.Commands() {
+		for _, binary := range []string{"ufw", "firewall-cmd", "nft"} {
+			if strings.HasPrefix(command, binary+" ") {
+				t.Errorf("без root запущена проверка фаервола: %q", command)
+			}
+		}
+	}
+}
+
+func TestGetSystemInfoRootReportsFirewall(t *testing.T) {
+	useEUID(t, 0)
+	fake := runner.NewFakeRunner().On("ufw status verbose", "Status: active\nDefault: deny (incoming), allow (outgoing)\n", nil)
+	t.Cleanup(SetCommandRunner(fake))
+
+	info, err := (&SystemUtils{}).GetSystemInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.RequiresRoot) != 0 {
+		t.Errorf("RequiresRoot = %v для root", info.RequiresRoot)
+	}
+	if info.Firewall == nil || info.Firewall.Backend != "ufw" || !info.Firewall.Active {
+		t.Errorf("Firewall = %+v, ожидался активный ufw", info.Firewall)
+	}
+}
@@ -0,0 +1,75 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// worldWritableRoots возвращает директории, в которых файлы не должны быть доступны на запись всем
+func worldWritableRoots() []string {
+	roots := []string{"/etc", "/usr/local/bin", "/usr/local/sbin", "/root"}
+	if homes, err := filepath.Glob("/home/*"); err == nil {
+		roots = append(roots, homes...)
+	}
+	return roots
+}
+
+// scanWorldWritable ищет файлы и директории с битом записи для остальных (0002).
+// Директории со sticky-битом (как /tmp) считаются допустимыми, символические ссылки пропускаются.
+// Недоступные для чтения директории пропускаются, отсутствующие корни игнорируются.
+func scanWorldWritable(paths []string) ([]string, error) {
+	var found []string
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+					if d != nil && d.IsDir() {
+						return fs.SkipDir
+					}
+					return nil
+				}
+				return err
+			}
+			if d.Type()&fs.ModeSymlink != 0 {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			mode := info.Mode()
+			if mode.Perm()&0002 == 0 {
+				return nil
+			}
+			if mode.IsDir() && mode&fs.ModeSticky != 0 {
+				return nil
+			}
+			found = append(found, path)
+			return nil
+		})
+		if err != nil {
+			return found, fmt.Errorf("ошибка обхода %s: %w", root, err)
+		}
+	}
+	return found, nil
+}
+
+// fixWorldWritable снимает бит записи для остальных с найденных файлов
+func fixWorldWritable(paths []string) error {
+	var errs []error
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := os.Chmod(path, info.Mode().Perm()&^0002|info.Mode()&(fs.ModeSetuid|fs.ModeSetgid|fs.ModeSticky)); err != nil {
+			errs = append(errs, fmt.Errorf("ошибка изменения прав %s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,103 @@
+package system
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// plantWorldWritable создает дерево с файлом и директорией, доступными на запись всем,
+// sticky-директорией и ссылкой на доступный всем файл
+func plantWorldWritable(t *testing.T) (root string, want []string) {
+	t.Helper()
+	root = t.TempDir()
+	outside := filepath.Join(t.TempDir(), "outside")
+	files := map[string]fs.FileMode{
+		"etc/passwd":        0644,
+		"etc/cron.d/job":    0666,
+		"bin/tool":          0777,
+		"tmp/.keep":         0644,
+		"share/open/readme": 0644,
+	}
+	for name, mode := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		// Права задаются явно, чтобы не зависеть от umask
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(root, "tmp"), 0777|fs.ModeSticky); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(root, "share/open"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(outside, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(outside, 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "etc/link")); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		filepath.Join(root, "bin/tool"),
+		filepath.Join(root, "etc/cron.d/job"),
+		filepath.Join(root, "share/open"),
+	}
+	return root, want
+}
+
+func TestScanWorldWritable(t *testing.T) {
+	root, want := plantWorldWritable(t)
+
+	found, err := scanWorldWritable([]string{root, filepath.Join(root, "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("найдено %q, ожидалось %q", found, want)
+	}
+}
+
+func TestFixWorldWritable(t *testing.T) {
+	root, want := plantWorldWritable(t)
+	if err := os.Chmod(filepath.Join(root, "bin/tool"), 0777|fs.ModeSetuid); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fixWorldWritable(want); err != nil {
+		t.Fatal(err)
+	}
+	found, err := scanWorldWritable([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 0 {
+		t.Errorf("после исправления остались %q", found)
+	}
+
+	info, err := os.Stat(filepath.Join(root, "bin/tool"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0775 || info.Mode()&fs.ModeSetuid == 0 {
+		t.Errorf("права %v, ожидалось 0775 с setuid", info.Mode())
+	}
+	info, err = os.Stat(filepath.Join(root, "tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0777 {
+		t.Errorf("права sticky-директории изменены: %v", info.Mode())
+	}
+}
@@ -4,25 +4,57 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/briandowns/spinner"
 	"github.com/schollz/progressbar/v3"
 )
 
 // ProgressManager управляет прогресс-индикаторами
 type ProgressManager struct{}
 
-// NewSpinner создает новый спиннер
-func (pm *ProgressManager) NewSpinner(message string) *spinner.Spinner {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " " + message
-	return s
+// NewSpinner создает новый спиннер; при выводе не в терминал - строку статуса
+func (pm *ProgressManager) NewSpinner(message string) Spinner {
+	return NewSpinner(message)
 }
 
 // NewProgressBar создает новый прогресс-бар
 func (pm *ProgressManager) NewProgressBar(total int, description string) *progressbar.ProgressBar {
+	return NewProgressBar(total, description)
+}
+
+// NewProgressBar создает прогресс-бар для stdout.
+// При выводе не в терминал бар скрыт, а описание печатается одной строкой.
+func NewProgressBar(total int, description string) *progressbar.ProgressBar {
+	visible := ProgressEnabled()
+	if !visible {
+		fmt.Println(description)
+	}
 	return progressbar.NewOptions(total,
+		progressbar.OptionSetVisibility(visible),
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionShowCount(),
+		progressbar.OptionClearOnFinish(),
+		progressbar.OptionEnableColorCodes(true),
+		progressbar.OptionSetTheme(progressbar.Theme{
+			Saucer:        "[green]=[reset]",
+			SaucerHead:    "[green]>[reset]",
+			SaucerPadding: " ",
+			BarStart:      "[",
+			BarEnd:        "]",
+		}))
+}
+
+// NewBytesProgressBar создает прогресс-бар для объема данных: позиция и скорость
+// показываются в байтах. Отрицательный total дает бар без известного конца.
+func NewBytesProgressBar(total int64, description string) *progressbar.ProgressBar {
+	visible := ProgressEnabled()
+	if !visible {
+		fmt.Println(description)
+	}
+	return progressbar.NewOptions64(total,
+		progressbar.OptionSetVisibility(visible),
 		progressbar.OptionSetDescription(description),
 		progressbar.OptionSetWidth(40),
+		progressbar.OptionShowBytes(true),
 		progressbar.OptionShowCount(),
 		progressbar.OptionClearOnFinish(),
 		progressbar.OptionEnableColorCodes(true),
@@ -69,22 +101,21 @@ func (pm *ProgressManager) ShowProgressWithBar(items []string, processItem func(
 
 // MultiProgress управляет несколькими прогресс-индикаторами
 type MultiProgress struct {
-	spinners []*spinner.Spinner
+	spinners []Spinner
 	bars     []*progressbar.ProgressBar
 }
 
 // NewMultiProgress создает новый MultiProgress
 func NewMultiProgress() *MultiProgress {
 	return &MultiProgress{
-		spinners: make([]*spinner.Spinner, 0),
+		spinners: make([]Spinner, 0),
 		bars:     make([]*progressbar.ProgressBar, 0),
 	}
 }
 
 // AddSpinner добавляет спиннер
-func (mp *MultiProgress) AddSpinner(message string) *spinner.Spinner {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " " + message
+func (mp *MultiProgress) AddSpinner(message string) Spinner {
+	s := NewSpinner(message)
 	mp.spinners = append(mp.spinners, s)
 	return s
 }
@@ -92,6 +123,7 @@ func (mp *MultiProgress) AddSpinner(message string) *spinner.Spinner {
 // AddProgressBar добавляет прогресс-бар
 func (mp *MultiProgress) AddProgressBar(total int, description string) *progressbar.ProgressBar {
 	bar := progressbar.NewOptions(total,
+		progressbar.OptionSetVisibility(ProgressEnabled()),
 		progressbar.OptionSetDescription(description),
 		progressbar.OptionSetWidth(30),
 		progressbar.OptionShowCount(),
@@ -167,6 +199,7 @@ func (pm *ProgressManager) ColorProgressBar(total int, description, color string
 	}
 
 	return progressbar.NewOptions(total,
+		progressbar.OptionSetVisibility(ProgressEnabled()),
 		progressbar.OptionSetDescription(description),
 		progressbar.OptionSetWidth(40),
 		progressbar.OptionShowCount(),
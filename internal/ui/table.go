@@ -22,7 +22,40 @@ type ServiceInfo struct {
 }
 
 // TableManager управляет таблицами
-type TableManager struct{}
+type TableManager struct {
+	// Descriptions дополняет или переопределяет описания категорий пакетов,
+	// например для пользовательских категорий из конфигурации
+	Descriptions map[string]string
+}
+
+// defaultCategoryDescriptions содержит описания встроенных категорий пакетов
+var defaultCategoryDescriptions = map[string]string{
+	"basic":       "Основные утилиты системы",
+	"archive":     "Инструменты для работы с архивами",
+	"network":     "Сетевые утилиты и инструменты",
+	"monitoring":  "Мониторинг системы",
+	"development": "Инструменты разработки",
+	"security":    "Безопасность системы",
+	"system":      "Системные утилиты",
+	"database":    "Базы данных",
+	"web":         "Веб-серверы и инструменты",
+}
+
+// CategoryDescription возвращает описание категории с учетом пользовательских описаний
+func (tm *TableManager) CategoryDescription(category string) string {
+	if desc := tm.Descriptions[category]; desc != "" {
+		return desc
+	}
+	if desc := defaultCategoryDescriptions[category]; desc != "" {
+		return desc
+	}
+	return "Без описания"
+}
+
+// categoryTitle переводит первую букву каждого слова в верхний регистр с учетом Unicode
+func categoryTitle(category string) string {
+	return cases.Title(language.Und).String(category)
+}
 
 // NewTable создает новую таблицу
 func (tm *TableManager) NewTable(headers []string) *tablewriter.Table {
@@ -130,28 +163,11 @@ func (tm *TableManager) DisplayCategories(categories map[string][]string) {
 		},
 	)
 
-	descriptions := map[string]string{
-		"basic":       "Основные утилиты системы",
-		"archive":     "Инструменты для работы с архивами",
-		"network":     "Сетевые утилиты и инструменты",
-		"monitoring":  "Мониторинг системы",
-		"development": "Инструменты разработки",
-		"security":    "Безопасность системы",
-		"system":      "Системные утилиты",
-		"database":    "Базы данных",
-		"web":         "Веб-серверы и инструменты",
-	}
-
 	for category, packages := range categories {
-		desc := descriptions[category]
-		if desc == "" {
-			desc = "Без описания"
-		}
-		caser := cases.Title(language.Russian)
 		table.Append([]string{
-			caser.String(category),
+			categoryTitle(category),
 			strconv.Itoa(len(packages)),
-			desc,
+			tm.CategoryDescription(category),
 		})
 	}
 
@@ -199,3 +215,32 @@ func (tm *TableManager) DisplayServices(services []ServiceInfo) {
 	fmt.Println("\nСистемные службы:")
 	table.Render()
 }
+
+// DisplayPackageChanges отображает план изменения пакетов: установку, обновление и удаление
+func (tm *TableManager) DisplayPackageChanges(install, upgrade, remove []string) {
+	if len(install)+len(upgrade)+len(remove) == 0 {
+		fmt.Println("Изменений пакетов не будет")
+		return
+	}
+
+	table := tm.NewBorderedTable([]string{"#", "Пакет", "Действие"})
+	table.SetHeaderColor(
+		tablewriter.Colors{tablewriter.Bold, tablewriter.BgBlueColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiWhiteColor},
+		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiCyanColor},
+	)
+
+	row := 0
+	appendRows := func(packages []string, action string) {
+		for _, pkg := range packages {
+			row++
+			table.Append([]string{strconv.Itoa(row), pkg, action})
+		}
+	}
+	appendRows(install, "установка")
+	appendRows(upgrade, "обновление")
+	appendRows(remove, "удаление")
+
+	fmt.Println("\nИзменения пакетов:")
+	table.Render()
+}
@@ -0,0 +1,43 @@
+package ui
+
+import "testing"
+
+func TestCategoryTitle(t *testing.T) {
+	tests := map[string]string{
+		"basic":         "Basic",
+		"базы данных":   "Базы Данных",
+		"ёлка":          "Ёлка",
+		"élan":          "Élan",
+		"straße":        "Straße",
+		"ǆungla":        "ǅungla",
+		"web-серверы":   "Web-Серверы",
+		"":              "",
+		"ΑΘΗΝΑ servers": "Αθηνα Servers",
+	}
+	for input, want := range tests {
+		if got := categoryTitle(input); got != want {
+			t.Errorf("categoryTitle(%q) = %q, ожидалось %q", input, got, want)
+		}
+	}
+}
+
+func TestCategoryDescription(t *testing.T) {
+	tm := &TableManager{Descriptions: map[string]string{
+		"игры": "Игры и развлечения",
+		"web":  "Обратный прокси",
+	}}
+	tests := map[string]string{
+		"игры":     "Игры и развлечения",
+		"web":      "Обратный прокси",
+		"database": "Базы данных",
+		"unknown":  "Без описания",
+	}
+	for category, want := range tests {
+		if got := tm.CategoryDescription(category); got != want {
+			t.Errorf("CategoryDescription(%q) = %q, ожидалось %q", category, got, want)
+		}
+	}
+	if got := (&TableManager{}).CategoryDescription("basic"); got != "Основные утилиты системы" {
+		t.Errorf("описание по умолчанию: %q", got)
+	}
+}
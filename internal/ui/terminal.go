@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"golang.org/x/term"
+)
+
+// ProgressMode задает вид индикаторов прогресса
+type ProgressMode int32
+
+const (
+	// ProgressAuto выбирает вид по возможностям терминала
+	ProgressAuto ProgressMode = iota
+	// ProgressAnimated всегда показывает спиннеры и прогресс-бары
+	ProgressAnimated
+	// ProgressPlain печатает вместо анимации обычные строки статуса
+	ProgressPlain
+)
+
+// progressModeEnv переопределяет вид индикаторов: auto, animated или plain
+const progressModeEnv = "GO_TO_RUN_PROGRESS"
+
+// plainProgressInterval - период строк "выполняется" в текстовом режиме
+var plainProgressInterval = 10 * time.Second
+
+// progressMode - вид индикаторов, заданный SetProgressMode
+var progressMode atomic.Int32
+
+// SetProgressMode задает вид индикаторов прогресса для всего процесса.
+// ProgressAuto возвращает автоматический выбор (с учетом GO_TO_RUN_PROGRESS).
+func SetProgressMode(mode ProgressMode) {
+	progressMode.Store(int32(mode))
+}
+
+// SetForceProgress включает спиннеры и прогресс-бары при выводе в канал или файл.
+// По умолчанию вместо них печатаются обычные строки статуса, чтобы не засорять логи.
+func SetForceProgress(force bool) {
+	if force {
+		SetProgressMode(ProgressAnimated)
+	} else {
+		SetProgressMode(ProgressAuto)
+	}
+}
+
+// currentProgressMode возвращает вид, заданный SetProgressMode или переменной окружения
+func currentProgressMode() ProgressMode {
+	if mode := ProgressMode(progressMode.Load()); mode != ProgressAuto {
+		return mode
+	}
+	switch strings.ToLower(os.Getenv(progressModeEnv)) {
+	case "animated":
+		return ProgressAnimated
+	case "plain":
+		return ProgressPlain
+	}
+	return ProgressAuto
+}
+
+// ProgressEnabled сообщает, нужно ли показывать анимированные индикаторы
+func ProgressEnabled() bool {
+	return canAnimate(os.Stdout)
+}
+
+// canAnimate проверяет, отобразит ли w управляющие последовательности индикаторов.
+// Без терминала, с TERM=dumb или пустым TERM и в CI анимация превращается
+// в мусор вида [2K, поэтому используются строки статуса.
+func canAnimate(w io.Writer) bool {
+	switch currentProgressMode() {
+	case ProgressAnimated:
+		return true
+	case ProgressPlain:
+		return false
+	}
+	return isTerminal(w) && terminalSupportsEscapes()
+}
+
+// terminalSupportsEscapes проверяет окружение на поддержку управляющих последовательностей
+func terminalSupportsEscapes() bool {
+	if t := os.Getenv("TERM"); t == "" || t == "dumb" {
+		return false
+	}
+	return os.Getenv("CI") == ""
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// Spinner - индикатор длительной операции
+type Spinner interface {
+	Start()
+	Stop()
+}
+
+// NewSpinner создает спиннер для stdout: анимированный в терминале
+// и однострочный статус при перенаправленном выводе
+func NewSpinner(message string) Spinner {
+	return newSpinnerTo(os.Stdout, message)
+}
+
+func newSpinnerTo(w io.Writer, message string) Spinner {
+	if !canAnimate(w) {
+		return &statusLine{w: w, message: message, interval: plainProgressInterval}
+	}
+
+	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond, spinner.WithWriter(w))
+	s.Suffix = " " + message
+	// Библиотека запускает анимацию, только если WriterFile - терминал.
+	// При принудительном режиме проверяем терминал stderr, а пишем в исходный поток.
+	if !isTerminal(w) {
+		if !isTerminal(os.Stderr) {
+			return &statusLine{w: w, message: message, interval: plainProgressInterval}
+		}
+		s.WriterFile = os.Stderr
+	}
+	return s
+}
+
+// statusLine заменяет спиннер, когда анимация невозможна: сообщение печатается
+// при запуске, а затем раз в interval - строка о том, что операция еще выполняется
+type statusLine struct {
+	w        io.Writer
+	message  string
+	interval time.Duration
+
+	mu      sync.Mutex
+	started bool
+	done    chan struct{}
+	exited  chan struct{}
+}
+
+// Start печатает строку статуса и запускает периодические напоминания
+func (sl *statusLine) Start() {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if sl.started {
+		return
+	}
+	sl.started = true
+	message := strings.TrimSpace(sl.message)
+	fmt.Fprintln(sl.w, message)
+
+	if sl.interval <= 0 {
+		return
+	}
+	sl.done = make(chan struct{})
+	sl.exited = make(chan struct{})
+	go func(done <-chan struct{}, exited chan<- struct{}) {
+		defer close(exited)
+		start := time.Now()
+		ticker := time.NewTicker(sl.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fmt.Fprintf(sl.w, "... выполняется: %s (%s)\n", message, time.Since(start).Round(time.Second))
+			}
+		}
+	}(sl.done, sl.exited)
+}
+
+// Stop прекращает напоминания; результат операции печатает вызывающий код
+func (sl *statusLine) Stop() {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	if sl.done != nil {
+		close(sl.done)
+		<-sl.exited
+		sl.done = nil
+	}
+}
+
+// Confirm задает вопрос да/нет и читает ответ из stdin.
+// Без терминала на stdin подтверждение получить нельзя, и ответом считается "нет",
+// чтобы запуск из скрипта не выполнял изменения без явного согласия.
+func Confirm(prompt string) bool {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false
+	}
+	return confirmFrom(os.Stdin, os.Stdout, prompt)
+}
+
+func confirmFrom(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N]: ", prompt)
+	var answer string
+	if _, err := fmt.Fscanln(in, &answer); err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes", "д", "да":
+		return true
+	}
+	return false
+}
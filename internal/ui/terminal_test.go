@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useProgressMode задает вид индикаторов на время теста
+func useProgressMode(t *testing.T, mode ProgressMode) {
+	t.Helper()
+	prev := ProgressMode(progressMode.Load())
+	SetProgressMode(mode)
+	t.Cleanup(func() { SetProgressMode(prev) })
+}
+
+func TestSpinnerToBufferHasNoEscapes(t *testing.T) {
+	useProgressMode(t, ProgressAuto)
+	t.Setenv(progressModeEnv, "")
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("CI", "")
+
+	var buf bytes.Buffer
+	s := newSpinnerTo(&buf, "Установка пакетов...")
+	if _, ok := s.(*statusLine); !ok {
+		t.Fatalf("для bytes.Buffer создан %T, ожидалась строка статуса", s)
+	}
+	s.(*statusLine).interval = 5 * time.Millisecond
+	s.Start()
+	time.Sleep(30 * time.Millisecond)
+	s.Stop()
+
+	output := buf.String()
+	if strings.ContainsAny(output, "\x1b\r\b") {
+		t.Errorf("вывод содержит управляющие символы: %q", output)
+	}
+	if !strings.HasPrefix(output, "Установка пакетов...\n") {
+		t.Errorf("вывод начинается не с сообщения: %q", output)
+	}
+	if !strings.Contains(output, "... выполняется: Установка пакетов...") {
+		t.Errorf("нет строки о продолжении операции: %q", output)
+	}
+}
+
+func TestStatusLineStopsReminders(t *testing.T) {
+	var buf bytes.Buffer
+	sl := &statusLine{w: &buf, message: "Загрузка", interval: time.Millisecond}
+	sl.Start()
+	sl.Start()
+	sl.Stop()
+	sl.Stop()
+
+	stopped := buf.Len()
+	time.Sleep(10 * time.Millisecond)
+	if buf.Len() != stopped {
+		t.Errorf("после Stop напечатано: %q", buf.String()[stopped:])
+	}
+	if strings.Count(buf.String(), "Загрузка\n") != 1 {
+		t.Errorf("сообщение напечатано не один раз: %q", buf.String())
+	}
+}
+
+func TestCanAnimate(t *testing.T) {
+	var buf bytes.Buffer
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("CI", "")
+
+	tests := []struct {
+		name string
+		mode ProgressMode
+		env  string
+		want bool
+	}{
+		{"auto без терминала", ProgressAuto, "", false},
+		{"force-progress", ProgressAnimated, "", true},
+		{"plain", ProgressPlain, "animated", false},
+		{"переменная animated", ProgressAuto, "animated", true},
+		{"переменная plain", ProgressAuto, "plain", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useProgressMode(t, tt.mode)
+			t.Setenv(progressModeEnv, tt.env)
+			if got := canAnimate(&buf); got != tt.want {
+				t.Errorf("canAnimate() = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetForceProgress(t *testing.T) {
+	useProgressMode(t, ProgressAuto)
+	SetForceProgress(true)
+	if got := currentProgressMode(); got != ProgressAnimated {
+		t.Errorf("после SetForceProgress(true) вид %v, ожидался ProgressAnimated", got)
+	}
+	SetForceProgress(false)
+	t.Setenv(progressModeEnv, "")
+	if got := currentProgressMode(); got != ProgressAuto {
+		t.Errorf("после SetForceProgress(false) вид %v, ожидался ProgressAuto", got)
+	}
+}
+
+func TestTerminalSupportsEscapes(t *testing.T) {
+	tests := []struct {
+		term, ci string
+		want     bool
+	}{
+		{"xterm-256color", "", true},
+		{"dumb", "", false},
+		{"", "", false},
+		{"xterm", "true", false},
+	}
+	for _, tt := range tests {
+		t.Setenv("TERM", tt.term)
+		t.Setenv("CI", tt.ci)
+		if got := terminalSupportsEscapes(); got != tt.want {
+			t.Errorf("TERM=%q CI=%q: %v, ожидалось %v", tt.term, tt.ci, got, tt.want)
+		}
+	}
+}
+
+func TestDumbTerminalUsesPlainProgress(t *testing.T) {
+	useProgressMode(t, ProgressAuto)
+	t.Setenv(progressModeEnv, "")
+	t.Setenv("TERM", "dumb")
+	t.Setenv("CI", "")
+
+	if ProgressEnabled() {
+		t.Error("при TERM=dumb анимация включена")
+	}
+	if s := NewSpinner("Очистка"); s == nil {
+		t.Fatal("NewSpinner вернул nil")
+	} else if _, ok := s.(*statusLine); !ok {
+		t.Errorf("при TERM=dumb создан %T, ожидалась строка статуса", s)
+	}
+
+	// Автоматический выбор переопределяется явно
+	t.Setenv(progressModeEnv, "animated")
+	if !ProgressEnabled() {
+		t.Error("GO_TO_RUN_PROGRESS=animated не включает анимацию")
+	}
+}
@@ -0,0 +1,154 @@
+package archive
+
+import (
+	"bufio"
+	"crypto/md5" //nolint:gosec // md5 нужен для сверки с опубликованными контрольными суммами
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrChecksumMismatch - общая причина ChecksumMismatchError для errors.Is
+var ErrChecksumMismatch = errors.New("контрольная сумма не совпадает")
+
+// ChecksumMismatchError возвращается, если контрольная сумма файла отличается от ожидаемой
+type ChecksumMismatchError struct {
+	Path      string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("контрольная сумма %s файла %s не совпадает: ожидалась %s, вычислена %s",
+		e.Algorithm, e.Path, e.Expected, e.Actual)
+}
+
+// Unwrap позволяет проверять ошибку через errors.Is(err, ErrChecksumMismatch)
+func (e *ChecksumMismatchError) Unwrap() error {
+	return ErrChecksumMismatch
+}
+
+// checksumAlgorithms сопоставляет алгоритм с конструктором хеша и длиной hex-суммы
+var checksumAlgorithms = map[string]struct {
+	newHash func() hash.Hash
+	hexLen  int
+}{
+	"md5":    {md5.New, 32},
+	"sha256": {sha256.New, 64},
+	"sha512": {sha512.New, 128},
+}
+
+// sidecarExtensions - расширения файлов с контрольной суммой рядом с архивом,
+// в порядке предпочтения
+var sidecarExtensions = []string{".sha512", ".sha256", ".md5"}
+
+// VerifyChecksum вычисляет контрольную сумму файла алгоритмом algo (sha256, sha512, md5)
+// и сравнивает ее с expected без учета регистра. Пустой algo определяется по длине
+// expected. При несовпадении возвращает false и *ChecksumMismatchError с обеими суммами.
+func VerifyChecksum(filePath, expected, algo string) (bool, error) {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if algo == "" {
+		algo = algorithmByLength(expected)
+		if algo == "" {
+			return false, fmt.Errorf("не удалось определить алгоритм по контрольной сумме длиной %d", len(expected))
+		}
+	}
+	algo = strings.ToLower(algo)
+	spec, ok := checksumAlgorithms[algo]
+	if !ok {
+		return false, fmt.Errorf("неподдерживаемый алгоритм контрольной суммы: %s", algo)
+	}
+	if len(expected) != spec.hexLen {
+		return false, fmt.Errorf("некорректная контрольная сумма %s: ожидается %d шестнадцатеричных символов", algo, spec.hexLen)
+	}
+	if _, err := hex.DecodeString(expected); err != nil {
+		return false, fmt.Errorf("некорректная контрольная сумма %s: %w", algo, err)
+	}
+
+	f, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return false, fmt.Errorf("ошибка открытия файла: %w", err)
+	}
+	defer f.Close()
+
+	h := spec.newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, fmt.Errorf("ошибка чтения %s: %w", filePath, err)
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if actual != expected {
+		return false, &ChecksumMismatchError{Path: filePath, Algorithm: algo, Expected: expected, Actual: actual}
+	}
+	return true, nil
+}
+
+// algorithmByLength определяет алгоритм по длине hex-суммы
+func algorithmByLength(digest string) string {
+	for algo, spec := range checksumAlgorithms {
+		if len(digest) == spec.hexLen {
+			return algo
+		}
+	}
+	return ""
+}
+
+// verifyArchive проверяет архив по ExpectedChecksum, а без нее - по файлу
+// archive.sha512, archive.sha256 или archive.md5 рядом с архивом, если он есть
+func (em *ExtractManager) verifyArchive(archivePath string) error {
+	expected, algo := em.ExpectedChecksum, ""
+	if name, digest, ok := strings.Cut(expected, ":"); ok {
+		algo, expected = name, digest
+	}
+	if expected == "" {
+		var err error
+		if expected, algo, err = sidecarChecksum(archivePath); err != nil || expected == "" {
+			return err
+		}
+	}
+	_, err := VerifyChecksum(archivePath, expected, algo)
+	return err
+}
+
+// sidecarChecksum читает сумму архива из файла рядом с ним в формате sha256sum:
+// "<сумма>  <имя файла>" или только сумма. Пустая сумма означает, что файла нет.
+func sidecarChecksum(archivePath string) (digest, algo string, err error) {
+	for _, ext := range sidecarExtensions {
+		path := archivePath + ext
+		data, err := os.ReadFile(filepath.Clean(path))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", "", fmt.Errorf("ошибка чтения %s: %w", path, err)
+		}
+		digest := findDigest(string(data), filepath.Base(archivePath))
+		if digest == "" {
+			return "", "", fmt.Errorf("в %s нет контрольной суммы %s", path, filepath.Base(archivePath))
+		}
+		return digest, strings.TrimPrefix(ext, "."), nil
+	}
+	return "", "", nil
+}
+
+// findDigest ищет сумму файла name в выводе sha256sum; строка без имени подходит к любому файлу
+func findDigest(content, name string) string {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		switch {
+		case len(fields) == 1:
+			return fields[0]
+		case len(fields) >= 2 && strings.TrimPrefix(fields[1], "*") == name:
+			return fields[0]
+		}
+	}
+	return ""
+}
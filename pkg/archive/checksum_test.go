@@ -0,0 +1,138 @@
+package archive
+
+import (
+	"crypto/md5" //nolint:gosec // md5 нужен для проверки поддержки опубликованных сумм
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// fileDigests возвращает суммы файла path по всем поддерживаемым алгоритмам
+func fileDigests(t *testing.T, path string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s256 := sha256.Sum256(data)
+	s512 := sha512.Sum512(data)
+	m5 := md5.Sum(data) //nolint:gosec // см. импорт
+	return map[string]string{
+		"sha256": hex.EncodeToString(s256[:]),
+		"sha512": hex.EncodeToString(s512[:]),
+		"md5":    hex.EncodeToString(m5[:]),
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	path := tarFixture(t)
+	for algo, digest := range fileDigests(t, path) {
+		t.Run(algo, func(t *testing.T) {
+			for _, tt := range []struct{ expected, algo string }{
+				{digest, algo},
+				{strings.ToUpper(digest), strings.ToUpper(algo)},
+				// Без алгоритма он определяется по длине суммы
+				{" " + digest + "\n", ""},
+			} {
+				ok, err := VerifyChecksum(path, tt.expected, tt.algo)
+				if !ok || err != nil {
+					t.Errorf("VerifyChecksum(%q, %q) = %v, %v", tt.expected, tt.algo, ok, err)
+				}
+			}
+
+			wrong := strings.Repeat("0", len(digest))
+			ok, err := VerifyChecksum(path, wrong, algo)
+			var mismatch *ChecksumMismatchError
+			if ok || !errors.As(err, &mismatch) || !errors.Is(err, ErrChecksumMismatch) {
+				t.Fatalf("несовпадение: %v, %v", ok, err)
+			}
+			if mismatch.Algorithm != algo || mismatch.Expected != wrong || mismatch.Actual != digest {
+				t.Errorf("ошибка несовпадения: %+v", mismatch)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksumInvalidArguments(t *testing.T) {
+	path := tarFixture(t)
+	sum := fileDigests(t, path)["sha256"]
+
+	tests := []struct {
+		name, expected, algo string
+	}{
+		{"неизвестная длина", "abc", ""},
+		{"неизвестный алгоритм", sum, "sha1"},
+		{"длина не по алгоритму", sum, "sha512"},
+		{"не hex", strings.Repeat("z", 64), "sha256"},
+	}
+	for _, tt := range tests {
+		ok, err := VerifyChecksum(path, tt.expected, tt.algo)
+		if ok || err == nil || errors.Is(err, ErrChecksumMismatch) {
+			t.Errorf("%s: %v, %v", tt.name, ok, err)
+		}
+	}
+	if _, err := VerifyChecksum(filepath.Join(t.TempDir(), "missing"), sum, "sha256"); err == nil {
+		t.Error("для отсутствующего файла ожидалась ошибка")
+	}
+}
+
+func TestExtractExpectedChecksum(t *testing.T) {
+	path := tarFixture(t)
+	digests := fileDigests(t, path)
+
+	for _, expected := range []string{digests["sha256"], "sha512:" + digests["sha512"], "MD5:" + digests["md5"]} {
+		em := &ExtractManager{PreferNative: true, ExpectedChecksum: expected}
+		outputDir := t.TempDir()
+		if err := em.ExtractWithOptions(path, outputDir, DefaultExtractOptions()); err != nil {
+			t.Fatalf("%s: %v", expected, err)
+		}
+		checkFiles(t, outputDir, map[string]string{"file.txt": "data"})
+	}
+}
+
+func TestExtractChecksumMismatchWritesNothing(t *testing.T) {
+	path := tarFixture(t)
+	wrong := "sha256:" + strings.Repeat("0", 64)
+
+	// Сумма из файла рядом с архивом проверяется так же, как ExpectedChecksum
+	sidecar := tarFixture(t)
+	line := strings.Repeat("1", 64) + "  " + filepath.Base(sidecar) + "\n"
+	if err := os.WriteFile(sidecar+".sha256", []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, path, expected string
+		native               bool
+	}{
+		{"expected", path, wrong, false},
+		{"expected/native", path, wrong, true},
+		{"sidecar", sidecar, "", false},
+		{"sidecar/native", sidecar, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := runner.NewFakeRunner()
+			em := &ExtractManager{Runner: fake, PreferNative: tt.native, ExpectedChecksum: tt.expected}
+			outputDir := filepath.Join(t.TempDir(), "out")
+
+			err := em.ExtractWithOptions(tt.path, outputDir, DefaultExtractOptions())
+			if !errors.Is(err, ErrChecksumMismatch) {
+				t.Fatalf("ошибка %v, ожидалось несовпадение суммы", err)
+			}
+			if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+				t.Errorf("при несовпадении суммы создана выходная директория: %v", err)
+			}
+			if cmds := fake.Commands(); len(cmds) != 0 {
+				t.Errorf("при несовпадении суммы запущены команды %q", cmds)
+			}
+		})
+	}
+}
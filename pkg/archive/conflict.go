@@ -0,0 +1,221 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConflictPolicy определяет, что делать с записью архива, путь которой уже занят
+type ConflictPolicy string
+
+// Политики разрешения конфликтов при извлечении
+const (
+	// ConflictOverwrite заменяет существующий файл; файл и директория не заменяют друг друга
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictSkip оставляет существующий файл, запись архива пропускается
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictRename сохраняет запись архива под свободным именем: file.1.txt
+	ConflictRename ConflictPolicy = "rename"
+	// ConflictError прерывает извлечение, ничего не изменяя в выходной директории
+	ConflictError ConflictPolicy = "error"
+)
+
+// ErrConflict возвращается политикой ConflictError, если путь записи уже занят,
+// и политикой ConflictOverwrite, если он занят записью другого вида
+var ErrConflict = errors.New("файл уже существует")
+
+// ExtractResult содержит итог извлечения с политикой конфликтов
+type ExtractResult struct {
+	Overwritten int
+	Skipped     int
+	Renamed     int
+	// Files - пути записанных файлов и ссылок относительно выходной директории, по алфавиту.
+	// Пропущенные политикой ConflictSkip файлы в список не входят, переименованные
+	// указаны под новым именем. Для внешних утилит список строится по записям архива,
+	// найденным в выходной директории; без списка записей (нет 7z или unrar) он пуст
+	Files []string
+}
+
+// validConflictPolicy проверяет значение ExtractOptions.OnConflict
+func validConflictPolicy(policy ConflictPolicy) bool {
+	switch policy {
+	case "", ConflictOverwrite, ConflictSkip, ConflictRename, ConflictError:
+		return true
+	}
+	return false
+}
+
+// extractStaged извлекает архив функцией extract во временную директорию внутри
+// outputDir (или opts.TempDir) и переносит результат в outputDir по политике opts.OnConflict.
+// Так политика одинаково применяется и к встроенному извлечению, и к внешним утилитам,
+// у которых нет общего флага для пропуска или переименования файлов.
+func extractStaged(ctx context.Context, outputDir string, opts ExtractOptions, extract func(dir string) error) (*ExtractResult, error) {
+	staging, err := os.MkdirTemp(stagingParent(outputDir, opts.TempDir), ".go-to-run-extract-*")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания временной директории: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if opts.MaxRetries > 0 {
+		cleanup := func() error {
+			if err := os.RemoveAll(staging); err != nil {
+				return err
+			}
+			return os.Mkdir(staging, 0750)
+		}
+		err = retryTransient(ctx, opts.MaxRetries, retryDelay, cleanup, func() error {
+			return extract(staging)
+		})
+	} else {
+		err = extract(staging)
+	}
+	// Пропущенные политикой UnsafeSkip записи не мешают перенести остальное содержимое
+	var skipped *SkippedEntriesError
+	if errors.As(err, &skipped) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.needsFixup() {
+		if err := fixupTree(staging, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := opts.OnConflict
+	if policy == "" {
+		policy = ConflictOverwrite
+	}
+	// Все конфликты проверяются заранее, чтобы не оставить частично извлеченный архив.
+	// Перезапись заменяет только пути того же вида: файл архива не удаляет директорию целиком
+	switch policy {
+	case ConflictError:
+		if conflict, err := findConflict(staging, outputDir, false); err != nil {
+			return nil, err
+		} else if conflict != "" {
+			return nil, fmt.Errorf("%w: %s", ErrConflict, conflict)
+		}
+	case ConflictOverwrite:
+		if conflict, err := findConflict(staging, outputDir, true); err != nil {
+			return nil, err
+		} else if conflict != "" {
+			return nil, kindConflict(conflict)
+		}
+	}
+
+	result := &ExtractResult{}
+	recorder := newFileRecorder(outputDir)
+	err = mergeTree(staging, outputDir, policy, result, recorder)
+	result.Files = recorder.files()
+	if err != nil {
+		return result, err
+	}
+	if skipped != nil {
+		return result, skipped
+	}
+	return result, nil
+}
+
+// mergeTree переносит содержимое src в dst, объединяя существующие директории.
+// Занятые пути обрабатываются по политике policy, итог записывается в result,
+// перенесенные файлы - в recorder.
+func mergeTree(src, dst string, policy ConflictPolicy, result *ExtractResult, recorder *fileRecorder) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", src, err)
+	}
+	for _, entry := range entries {
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+
+		target, err := os.Lstat(to)
+		if err == nil && target.IsDir() && entry.IsDir() {
+			if err := mergeTree(from, to, policy, result, recorder); err != nil {
+				return err
+			}
+			continue
+		}
+		if err == nil {
+			switch policy {
+			case ConflictSkip:
+				result.Skipped++
+				continue
+			case ConflictRename:
+				if to, err = freeName(to); err != nil {
+					return err
+				}
+				result.Renamed++
+			case ConflictError:
+				return fmt.Errorf("%w: %s", ErrConflict, to)
+			default:
+				if target.IsDir() != entry.IsDir() {
+					return kindConflict(to)
+				}
+				if err := os.RemoveAll(to); err != nil {
+					return fmt.Errorf("ошибка замены %s: %w", to, err)
+				}
+				result.Overwritten++
+			}
+		}
+		if err := moveEntry(from, to); err != nil {
+			return fmt.Errorf("ошибка переноса %s: %w", to, err)
+		}
+		recorder.addTree(to)
+	}
+	return nil
+}
+
+// findConflict возвращает первый путь в dst, который заняла бы запись из src.
+// С kindOnly учитываются только пути, занятые записью другого вида: файл вместо директории или наоборот
+func findConflict(src, dst string, kindOnly bool) (string, error) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения %s: %w", src, err)
+	}
+	for _, entry := range entries {
+		to := filepath.Join(dst, entry.Name())
+		target, err := os.Lstat(to)
+		if err != nil {
+			continue
+		}
+		if !target.IsDir() || !entry.IsDir() {
+			if !kindOnly || target.IsDir() != entry.IsDir() {
+				return to, nil
+			}
+			continue
+		}
+		if conflict, err := findConflict(filepath.Join(src, entry.Name()), to, kindOnly); err != nil || conflict != "" {
+			return conflict, err
+		}
+	}
+	return "", nil
+}
+
+// kindConflict сообщает, что запись архива и существующий путь разного вида
+func kindConflict(path string) error {
+	return fmt.Errorf("%w: %s (файл и директория не заменяют друг друга)", ErrConflict, path)
+}
+
+// freeName подбирает свободное имя рядом с path: config.json -> config.1.json
+func freeName(path string) (string, error) {
+	dir, base := filepath.Split(path)
+	ext := filepath.Ext(base)
+	// Скрытые файлы без расширения (.bashrc) нумеруются в конце имени
+	if ext == base {
+		ext = ""
+	}
+	stem := strings.TrimSuffix(base, ext)
+	for i := 1; i < 10000; i++ {
+		candidate := filepath.Join(dir, stem+"."+strconv.Itoa(i)+ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("не удалось подобрать свободное имя для %s", path)
+}
@@ -0,0 +1,213 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// conflictArchives возвращает tar и zip с одинаковым содержимым:
+// a.txt и dir/b.txt конфликтуют с файлами populateOutput, dir/c.txt - новый
+func conflictArchives(t *testing.T) map[string]string {
+	t.Helper()
+	tarPath := filepath.Join(t.TempDir(), "conflict.tar")
+	data := buildTar(t, []tarEntry{
+		{name: "a.txt", typeflag: tar.TypeReg, body: "new a"},
+		{name: "dir/", typeflag: tar.TypeDir},
+		{name: "dir/b.txt", typeflag: tar.TypeReg, body: "new b"},
+		{name: "dir/c.txt", typeflag: tar.TypeReg, body: "new c"},
+	})
+	if err := os.WriteFile(tarPath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	zipPath := buildZip(t, []string{"a.txt", "dir/b.txt", "dir/c.txt"}, map[string]string{
+		"a.txt": "new a", "dir/b.txt": "new b", "dir/c.txt": "new c",
+	})
+	return map[string]string{"tar": tarPath, "zip": zipPath}
+}
+
+// populateOutput создает выходную директорию с файлами, которые заняты записями архива
+func populateOutput(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "dir"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.txt": "old a", "dir/b.txt": "old b"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExtractConflictPolicies(t *testing.T) {
+	tests := []struct {
+		policy ConflictPolicy
+		files  map[string]string
+		want   ExtractResult
+	}{
+		{
+			policy: ConflictOverwrite,
+			files:  map[string]string{"a.txt": "new a", "dir/b.txt": "new b", "dir/c.txt": "new c"},
+			want:   ExtractResult{Overwritten: 2, Files: []string{"a.txt", "dir/b.txt", "dir/c.txt"}},
+		},
+		{
+			policy: ConflictSkip,
+			files:  map[string]string{"a.txt": "old a", "dir/b.txt": "old b", "dir/c.txt": "new c"},
+			want:   ExtractResult{Skipped: 2, Files: []string{"dir/c.txt"}},
+		},
+		{
+			policy: ConflictRename,
+			files: map[string]string{
+				"a.txt": "old a", "a.1.txt": "new a",
+				"dir/b.txt": "old b", "dir/b.1.txt": "new b", "dir/c.txt": "new c",
+			},
+			want: ExtractResult{Renamed: 2, Files: []string{"a.1.txt", "dir/b.1.txt", "dir/c.txt"}},
+		},
+	}
+
+	for format, archivePath := range conflictArchives(t) {
+		for _, native := range []bool{false, true} {
+			if !native && format == "tar" {
+				if _, err := exec.LookPath("tar"); err != nil {
+					continue
+				}
+			}
+			if !native && format == "zip" {
+				if _, err := exec.LookPath("unzip"); err != nil {
+					continue
+				}
+			}
+			em := &ExtractManager{PreferNative: native}
+			suffix := ""
+			if native {
+				suffix = "/native"
+			}
+			for _, tt := range tests {
+				t.Run(format+"/"+string(tt.policy)+suffix, func(t *testing.T) {
+					outputDir := populateOutput(t)
+					result, err := em.ExtractWithResult(archivePath, outputDir, ExtractOptions{OnConflict: tt.policy})
+					if err != nil {
+						t.Fatal(err)
+					}
+					if !reflect.DeepEqual(*result, tt.want) {
+						t.Errorf("результат %+v, ожидалось %+v", *result, tt.want)
+					}
+					checkFiles(t, outputDir, tt.files)
+				})
+			}
+
+			t.Run(format+"/error"+suffix, func(t *testing.T) {
+				outputDir := populateOutput(t)
+				_, err := em.ExtractWithResult(archivePath, outputDir, ExtractOptions{OnConflict: ConflictError})
+				if !errors.Is(err, ErrConflict) {
+					t.Fatalf("ожидалась ErrConflict, получено %v", err)
+				}
+				// Конфликт обнаруживается до переноса: новые файлы не появляются
+				checkFiles(t, outputDir, map[string]string{"a.txt": "old a", "dir/b.txt": "old b"})
+				if _, err := os.Stat(filepath.Join(outputDir, "dir", "c.txt")); !os.IsNotExist(err) {
+					t.Errorf("при ошибке конфликта записан dir/c.txt: %v", err)
+				}
+				entries, _ := os.ReadDir(outputDir)
+				if len(entries) != 2 {
+					t.Errorf("в выходной директории осталось %d записей, временная директория не удалена?", len(entries))
+				}
+			})
+		}
+	}
+}
+
+func TestExtractOverwriteKeepsDirectoryOfOtherKind(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "kind.tar")
+	data := buildTar(t, []tarEntry{
+		{name: "new.txt", typeflag: tar.TypeReg, body: "new"},
+		{name: "data", typeflag: tar.TypeReg, body: "file instead of directory"},
+		{name: "file.txt/", typeflag: tar.TypeDir},
+		{name: "file.txt/x", typeflag: tar.TypeReg, body: "x"},
+	})
+	if err := os.WriteFile(archivePath, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	existing := map[string]string{"data/keep.txt": "keep", "file.txt": "old"}
+
+	tests := map[string]ExtractOptions{
+		"overwrite": {OnConflict: ConflictOverwrite},
+		// Перезапись по умолчанию применяется и при извлечении через временную директорию
+		"retries": {MaxRetries: 1},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			outputDir := t.TempDir()
+			for path, content := range existing {
+				if err := os.MkdirAll(filepath.Dir(filepath.Join(outputDir, path)), 0750); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(outputDir, path), []byte(content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := (&ExtractManager{PreferNative: true}).ExtractWithResult(archivePath, outputDir, opts)
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("ожидалась ErrConflict, получено %v", err)
+			}
+			// Конфликт обнаруживается до переноса: ничего не удалено и не записано
+			checkFiles(t, outputDir, existing)
+			if _, err := os.Stat(filepath.Join(outputDir, "new.txt")); !os.IsNotExist(err) {
+				t.Errorf("при конфликте записан new.txt: %v", err)
+			}
+		})
+	}
+}
+
+func TestMergeTreeKeepsDirectoryOfOtherKind(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, "data"), []byte("file"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(dst, "data"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, "data", "keep.txt"), []byte("keep"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := mergeTree(src, dst, ConflictOverwrite, &ExtractResult{}, newFileRecorder(dst))
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("ожидалась ErrConflict, получено %v", err)
+	}
+	checkFiles(t, dst, map[string]string{"data/keep.txt": "keep"})
+}
+
+func TestExtractUnknownConflictPolicy(t *testing.T) {
+	archivePath := conflictArchives(t)["zip"]
+	em := &ExtractManager{PreferNative: true}
+	if err := em.ExtractWithOptions(archivePath, t.TempDir(), ExtractOptions{OnConflict: "merge"}); err == nil {
+		t.Error("неизвестная политика должна давать ошибку")
+	}
+}
+
+func TestFreeName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"config.json", "config.1.json", ".bashrc"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]string{
+		"config.json": "config.2.json",
+		".bashrc":     ".bashrc.1",
+		"README":      "README.1",
+	}
+	for name, want := range tests {
+		got, err := freeName(filepath.Join(dir, name))
+		if err != nil || got != filepath.Join(dir, want) {
+			t.Errorf("freeName(%s) = %q, %v, ожидалось %s", name, got, err, want)
+		}
+	}
+}
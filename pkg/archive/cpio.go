@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// openCpio открывает cpio-архив, при gzipped распаковывая его встроенным gzip
+func (em *ExtractManager) openCpio(archivePath string, gzipped bool) (io.ReadCloser, error) {
+	if err := em.lookCpio(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	if !gzipped {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("ошибка чтения gzip: %w", err)
+	}
+	return &gzipFile{Reader: gz, file: f}, nil
+}
+
+// lookCpio проверяет наличие утилиты cpio
+func (em *ExtractManager) lookCpio() error {
+	if _, err := em.runner().LookPath("cpio"); err != nil {
+		return fmt.Errorf("команда cpio не найдена: %w", err)
+	}
+	return nil
+}
+
+// gzipFile закрывает gzip-поток вместе с файлом
+type gzipFile struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (g *gzipFile) Close() error {
+	_ = g.Reader.Close()
+	return g.file.Close()
+}
+
+// extractCpio извлекает cpio или cpio.gz. cpio извлекает файлы относительно
+// текущей директории, поэтому команда запускается в outputDir, а архив подается в stdin.
+// Абсолютные пути записей превращаются в относительные (--no-absolute-filenames).
+func (em *ExtractManager) extractCpio(ctx context.Context, archivePath, outputDir string, gzipped bool) error {
+	r, err := em.openCpio(archivePath, gzipped)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return em.runStreams(ctx, runner.Streams{Dir: outputDir, Stdin: r},
+		"cpio", "-idm", "--quiet", "--no-absolute-filenames")
+}
+
+// listCpio возвращает записи cpio-архива (cpio -t)
+func (em *ExtractManager) listCpio(archivePath string, gzipped bool) ([]string, error) {
+	r, err := em.openCpio(archivePath, gzipped)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var stdout bytes.Buffer
+	err = em.runStreams(context.Background(), runner.Streams{Stdin: r, Stdout: &stdout}, "cpio", "-t", "--quiet")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSpace(stdout.String()), "\n"), nil
+}
+
+// createCpio создает cpio-архив формата newc (как initramfs) из файлов и директорий.
+// cpio -o читает список путей из stdin, поэтому директории обходятся заранее.
+func (em *ExtractManager) createCpio(files []string, outputPath string, gzipped bool, opts CreateOptions) error {
+	if err := em.lookCpio(); err != nil {
+		return err
+	}
+
+	var list bytes.Buffer
+	err := walkFilesMode(files, opts.symlinks(), func(path, _ string, _ os.FileInfo) error {
+		list.WriteString(path + "\n")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(filepath.Clean(outputPath), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("ошибка создания архива: %w", err)
+	}
+
+	var (
+		w  io.Writer = out
+		gz *gzip.Writer
+	)
+	if gzipped {
+		level := opts.CompressionLevel
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		if gz, err = gzip.NewWriterLevel(out, level); err != nil {
+			_ = out.Close()
+			return fmt.Errorf("ошибка настройки сжатия: %w", err)
+		}
+		w = gz
+	}
+
+	args := []string{"-o", "-H", "newc", "--quiet"}
+	if opts.symlinks() == symlinksFollow {
+		args = append(args, "-L")
+	}
+	err = em.runStreams(context.Background(), runner.Streams{Stdin: &list, Stdout: w}, "cpio", args...)
+	if gz != nil {
+		if closeErr := gz.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("ошибка записи архива: %w", closeErr)
+		}
+	}
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("ошибка записи архива: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(outputPath)
+	}
+	return err
+}
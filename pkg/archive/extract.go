@@ -3,18 +3,43 @@
 package archive
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
-	"time"
+	"sync"
 
-	"github.com/briandowns/spinner"
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/13winged/go-to-run/internal/ui"
 )
 
 // ExtractManager управляет извлечением архивов
-type ExtractManager struct{}
+type ExtractManager struct {
+	// Runner запускает внешние утилиты; nil - runner.Default
+	Runner runner.CommandRunner
+	// Workers задает число горутин, записывающих файлы при встроенном извлечении tar.
+	// Нулевое значение означает число CPU, 1 - последовательную запись.
+	Workers int
+	// PreferNative обрабатывает форматы из nativeFormats встроенными средствами Go,
+	// даже если внешние утилиты установлены
+	PreferNative bool
+	// UnsafeEntries - политика для записей за пределами выходной директории при встроенном
+	// извлечении; пустое значение равно UnsafeAbort
+	UnsafeEntries UnsafeEntryPolicy
+	// Progress задает индикатор при ShowProgress: ProgressSpinner (по умолчанию)
+	// или ProgressBar с оценкой оставшегося объема
+	Progress ProgressStyle
+	// ExpectedChecksum - ожидаемая контрольная сумма архива в hex (см. VerifyChecksum);
+	// без нее архив проверяется по файлу .sha256 и т.п. рядом с ним, если он есть
+	ExpectedChecksum string
+}
 
 // Info содержит информацию об архиве
 type Info struct {
@@ -23,6 +48,16 @@ type Info struct {
 	Type     string
 	IsValid  bool
 	Contents []string
+	// UncompressedSize - размер содержимого после распаковки для gz, xz, zst (и tar с ними)
+	// и zip; 0, если его нельзя узнать без распаковки
+	UncompressedSize int64
+	// DetectedByMagic сообщает, что расширение незнакомо и Type определен
+	// по сигнатуре содержимого
+	DetectedByMagic bool
+	// Encrypted сообщает, что в zip есть зашифрованные записи и для извлечения нужен пароль
+	Encrypted bool
+	// Err содержит ошибку проверки архива (файл недоступен или поврежден)
+	Err error
 }
 
 // SupportedFormats возвращает поддерживаемые форматы архивов
@@ -30,61 +65,246 @@ func (em *ExtractManager) SupportedFormats() []string {
 	return []string{
 		".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz",
 		".tar", ".gz", ".bz2", ".xz", ".zip", ".rar", ".7z",
-		".lz4", ".zst", ".lzop", ".tar.zst", ".tar.lz4",
+		".lz4", ".zst", ".lzop", ".tar.zst", ".tzst", ".tar.lz4",
+		".cpio", ".cpio.gz",
 	}
 }
 
-// GetArchiveInfo возвращает информацию об архиве
+// GetArchiveInfo возвращает информацию об архиве; недоступный файл возвращает ошибку,
+// а поврежденный архив - Info с IsValid false и Err
 func (em *ExtractManager) GetArchiveInfo(filePath string) (*Info, error) {
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка доступа к архиву: %w", err)
+	}
 	info := &Info{
 		Path: filePath,
+		Size: stat.Size(),
 	}
 
-	// Получаем размер файла
-	if stat, err := os.Stat(filePath); err == nil {
-		info.Size = stat.Size()
+	// Определяем тип архива: по расширению, а если оно незнакомо - по сигнатуре
+	info.Type, info.DetectedByMagic = em.detectFormat(filePath)
+	info.UncompressedSize = em.uncompressedSize(filePath, info.Type)
+	if info.Type == "zip" {
+		info.Encrypted, _, _ = zipEncryption(filePath)
 	}
 
-	// Определяем тип архива
-	info.Type = em.detectArchiveType(filePath)
-
 	// Проверяем валидность архива
 	info.IsValid = em.checkArchiveValidity(filePath)
 
 	// Получаем список содержимого (если возможно)
 	if info.IsValid {
 		info.Contents = em.listArchiveContents(filePath)
+	} else {
+		info.Err = fmt.Errorf("архив поврежден: %s", filePath)
 	}
 
 	return info, nil
 }
 
+// GetArchiveInfoBatch проверяет несколько архивов параллельно пулом из concurrency горутин.
+// Результаты возвращаются в порядке путей, ошибка каждого архива сохраняется в его Info.Err,
+// а общая ошибка лишь сообщает, сколько архивов не прошло проверку.
+func (em *ExtractManager) GetArchiveInfoBatch(paths []string, concurrency int) ([]*Info, error) {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	infos := make([]*Info, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency && w < len(paths); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				info, err := em.GetArchiveInfo(paths[i])
+				if err != nil {
+					info = &Info{Path: paths[i], Err: err}
+				}
+				infos[i] = info
+			}
+		}()
+	}
+
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	failed := 0
+	for _, info := range infos {
+		if info.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return infos, fmt.Errorf("ошибки в %d из %d архивов", failed, len(paths))
+	}
+	return infos, nil
+}
+
+// ExtractOptions задает параметры извлечения архива
+type ExtractOptions struct {
+	// OutputDir - выходная директория, если она не передана аргументом; пустая означает
+	// директорию по умолчанию рядом с архивом
+	OutputDir    string
+	ShowProgress bool
+	// SmartStrip при выходной директории по умолчанию проверяет записи архива:
+	// если все они лежат в одной директории верхнего уровня, архив извлекается
+	// рядом с ним без дополнительной директории-обертки
+	SmartStrip bool
+	// StripComponents удаляет из путей записей указанное число ведущих компонентов,
+	// как tar --strip-components. Поддерживается для tar-архивов и zip
+	StripComponents int
+	// Verbose выводит сообщения о преобразовании путей записей при встроенном извлечении
+	Verbose bool
+	// MaxRetries - число повторов при временных ошибках ввода-вывода (EIO).
+	// При ненулевом значении архив извлекается во временную директорию,
+	// которая удаляется перед каждым повтором
+	MaxRetries int
+	// OnConflict задает политику для записей, пути которых уже заняты в выходной директории.
+	// Пустое значение заменяет файлы без подсчета, как раньше; с любой политикой архив
+	// сначала извлекается во временную директорию, а итог возвращает ExtractWithResult
+	OnConflict ConflictPolicy
+	// Chown задает владельца всех извлеченных записей (см. LookupOwnership);
+	// nil оставляет владельца, с которым записи создала утилита извлечения
+	Chown *Ownership
+	// ChmodDir и ChmodFile задают права извлеченных директорий и файлов; 0 - без изменений.
+	// Как и Chown, применяются только к записям архива: архив извлекается во временную
+	// директорию, и уже существующие в выходной директории файлы не затрагиваются
+	ChmodDir  os.FileMode
+	ChmodFile os.FileMode
+	// TempDir - директория для временных файлов извлечения; по умолчанию они создаются
+	// внутри выходной директории, а поток ExtractStream - в os.TempDir()
+	TempDir string
+	// Password - пароль зашифрованного zip или 7z; в аргументы команд не попадает (см. run7z)
+	Password string
+	// DiscardPermissions ограничивает права записей из архива umask, как у tar без root.
+	// По умолчанию права восстанавливаются точно, без umask (tar -p);
+	// ChmodDir и ChmodFile применяются поверх
+	DiscardPermissions bool
+	// PreserveOwnership восстанавливает UID и GID записей tar (tar --same-owner), только от root
+	PreserveOwnership bool
+
+	// progress получает распакованные байты встроенного извлечения для ProgressBar
+	progress io.Writer
+	// members - имена записей, которые извлекает ExtractMatching; nil - все записи
+	members map[string]bool
+	// recorder собирает пути записанных файлов для ExtractResult.Files
+	recorder *fileRecorder
+}
+
+// DefaultExtractOptions возвращает параметры извлечения по умолчанию: права записей
+// сохраняются, владелец - нет, что безопасно и при запуске без root. Они совпадают
+// с нулевым значением ExtractOptions
+func DefaultExtractOptions() ExtractOptions {
+	return ExtractOptions{}
+}
+
 // Extract извлекает архив
 func (em *ExtractManager) Extract(archivePath, outputDir string, showProgress bool) error {
+	return em.ExtractContext(context.Background(), archivePath, outputDir, showProgress)
+}
+
+// ExtractContext извлекает архив, прерывая извлечение при отмене ctx: внешняя утилита
+// завершается, встроенное извлечение останавливается на следующем чтении архива,
+// индикатор прогресса убирается. Уже извлеченные файлы остаются на месте.
+func (em *ExtractManager) ExtractContext(ctx context.Context, archivePath, outputDir string, showProgress bool) error {
+	opts := DefaultExtractOptions()
+	opts.ShowProgress = showProgress
+	_, err := em.extractWithResult(ctx, archivePath, outputDir, opts)
+	return err
+}
+
+// ExtractWithOptions извлекает архив с заданными параметрами
+func (em *ExtractManager) ExtractWithOptions(archivePath, outputDir string, opts ExtractOptions) error {
+	_, err := em.ExtractWithResult(archivePath, outputDir, opts)
+	return err
+}
+
+// ExtractWithResult извлекает архив с заданными параметрами и возвращает список
+// записанных файлов и число перезаписанных, пропущенных и переименованных. Без
+// opts.OnConflict, opts.MaxRetries и смены владельца или прав счетчики нулевые:
+// файлы заменяются напрямую, без подсчета.
+func (em *ExtractManager) ExtractWithResult(archivePath, outputDir string, opts ExtractOptions) (*ExtractResult, error) {
+	return em.extractWithResult(context.Background(), archivePath, outputDir, opts)
+}
+
+func (em *ExtractManager) extractWithResult(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) (*ExtractResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if !em.isArchive(archivePath) {
-		return fmt.Errorf("неподдерживаемый формат архива: %s", archivePath)
+		return nil, fmt.Errorf("неподдерживаемый формат архива: %s", archivePath)
+	}
+	if opts.StripComponents < 0 {
+		return nil, fmt.Errorf("некорректное число удаляемых компонентов пути: %d", opts.StripComponents)
+	}
+	if !validConflictPolicy(opts.OnConflict) {
+		return nil, fmt.Errorf("неизвестная политика конфликтов: %s", opts.OnConflict)
+	}
+	if !validUnsafePolicy(em.UnsafeEntries) {
+		return nil, fmt.Errorf("неизвестная политика небезопасных записей: %s", em.UnsafeEntries)
+	}
+	switch em.Progress {
+	case "", ProgressSpinner, ProgressBar:
+	default:
+		return nil, fmt.Errorf("неизвестный вид индикатора: %s", em.Progress)
+	}
+	if err := em.verifyArchive(archivePath); err != nil {
+		return nil, err
 	}
 
 	// Создаем директорию для извлечения если не существует
+	if outputDir == "" {
+		outputDir = opts.OutputDir
+	}
 	if outputDir == "" {
 		outputDir = em.getDefaultOutputDir(archivePath)
+		if opts.SmartStrip && opts.StripComponents == 0 {
+			if _, ok := topLevelDir(em.archiveEntries(archivePath)); ok {
+				outputDir = filepath.Dir(archivePath)
+			}
+		}
 	}
 
 	if err := os.MkdirAll(outputDir, 0750); err != nil {
-		return fmt.Errorf("ошибка создания директории: %w", err)
+		return nil, fmt.Errorf("ошибка создания директории: %w", err)
 	}
 
-	if showProgress {
-		return em.extractWithProgress(archivePath, outputDir)
+	extract := func(dir string) error {
+		if opts.ShowProgress {
+			return em.extractWithProgress(ctx, archivePath, dir, opts)
+		}
+		return em.extractWithoutProgress(ctx, archivePath, dir, opts)
+	}
+	if opts.MaxRetries > 0 || opts.OnConflict != "" || opts.needsFixup() {
+		if opts.TempDir != "" {
+			if err := checkFreeSpace(opts.TempDir, em.estimateExtractedSize(archivePath)); err != nil {
+				return nil, err
+			}
+		}
+		return extractStaged(ctx, outputDir, opts, extract)
 	}
-	return em.extractWithoutProgress(archivePath, outputDir)
+	// Без временной директории записанные файлы отмечаются при извлечении
+	opts.recorder = newFileRecorder(outputDir)
+	err := extract(outputDir)
+	return &ExtractResult{Files: opts.recorder.files()}, err
 }
 
 // ExtractAll извлекает несколько архивов
 func (em *ExtractManager) ExtractAll(archives []string, outputDir string, showProgress bool) error {
+	return em.ExtractAllContext(context.Background(), archives, outputDir, showProgress)
+}
+
+// ExtractAllContext извлекает несколько архивов, прерываясь при отмене ctx
+// (см. ExtractContext); оставшиеся архивы не извлекаются
+func (em *ExtractManager) ExtractAllContext(ctx context.Context, archives []string, outputDir string, showProgress bool) error {
 	if showProgress {
-		s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-		s.Suffix = fmt.Sprintf(" Извлечение %d архивов...", len(archives))
+		s := ui.NewSpinner(fmt.Sprintf("Извлечение %d архивов...", len(archives)))
 		s.Start()
 		defer s.Stop()
 	}
@@ -94,8 +314,7 @@ func (em *ExtractManager) ExtractAll(archives []string, outputDir string, showPr
 			fmt.Printf("Извлечение %d/%d: %s\n", i+1, len(archives), filepath.Base(archive))
 		}
 
-		subDir := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(archive), filepath.Ext(archive)))
-		if err := em.Extract(archive, subDir, false); err != nil {
+		if err := em.ExtractContext(ctx, archive, archiveSubDir(outputDir, archive), false); err != nil {
 			return fmt.Errorf("ошибка извлечения %s: %w", archive, err)
 		}
 	}
@@ -103,42 +322,162 @@ func (em *ExtractManager) ExtractAll(archives []string, outputDir string, showPr
 	return nil
 }
 
-// CreateArchive создает архив
+// ExtractAllConcurrent извлекает несколько архивов параллельно пулом из workers горутин
+// (по умолчанию по числу ядер). Ошибка одного архива не останавливает остальные:
+// общая ошибка перечисляет все неудачные архивы в порядке списка.
+func (em *ExtractManager) ExtractAllConcurrent(archives []string, outputDir string, workers int) error {
+	return em.ExtractAllConcurrentContext(context.Background(), archives, outputDir, workers)
+}
+
+// ExtractAllConcurrentContext работает как ExtractAllConcurrent; после отмены ctx
+// новые архивы не запускаются, а начатые прерываются (см. ExtractContext)
+func (em *ExtractManager) ExtractAllConcurrentContext(ctx context.Context, archives []string, outputDir string, workers int) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	errs := make([]error, len(archives))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers && w < len(archives); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := em.ExtractContext(ctx, archives[i], archiveSubDir(outputDir, archives[i]), false); err != nil {
+					errs[i] = fmt.Errorf("ошибка извлечения %s: %w", archives[i], err)
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range archives {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	failed := make([]error, 0, len(errs))
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("извлечение прервано: %w", errors.Join(append(failed, err)...))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("ошибки в %d из %d архивов:\n%w", len(failed), len(archives), errors.Join(failed...))
+	}
+	return nil
+}
+
+// archiveSubDir возвращает директорию архива внутри outputDir для ExtractAll
+func archiveSubDir(outputDir, archive string) string {
+	return filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(archive), filepath.Ext(archive)))
+}
+
+// CreateOptions задает параметры создания архива
+type CreateOptions struct {
+	// CompressionLevel - уровень сжатия; 0 означает уровень утилиты по умолчанию
+	CompressionLevel int
+	// Threads - число потоков для многопоточных компрессоров (pigz, pbzip2, xz, zstd, 7z).
+	// Для tar.gz и tar.bz2 больше одного потока требует установленных pigz или pbzip2
+	Threads int
+	// FollowSymlinks сохраняет вместо ссылок то, на что они указывают (tar -h, cpio -L)
+	FollowSymlinks bool
+	// IncludeSymlinks сохраняет ссылки как ссылки (для zip - zip -y). Если не задан ни
+	// FollowSymlinks, ни IncludeSymlinks, ссылки в архив не попадают. Для 7z обработку
+	// ссылок определяет утилита, проверяется только отсутствие циклов.
+	IncludeSymlinks bool
+	// TempDir - директория временных файлов: списка файлов tar и результата Recompress.
+	// По умолчанию список создается в os.TempDir() (TMPDIR), а Recompress пишет рядом с архивом
+	TempDir string
+}
+
+// compressionLevels содержит допустимые диапазоны уровня сжатия по форматам
+var compressionLevels = map[string][2]int{
+	"tar.gz":  {1, 9},
+	"zip":     {1, 9},
+	"tar.xz":  {1, 9},
+	"tar.bz2": {1, 9},
+	// 7z -mx=0 только упаковывает без сжатия; 0 здесь означает уровень по умолчанию
+	"7z": {1, 9},
+	// Уровни zstd выше 19 требуют --ultra и большого объема памяти
+	"tar.zst": {1, 19},
+	"tar.lz4": {1, 12},
+	"zst":     {1, 19},
+	"cpio.gz": {1, 9},
+}
+
+// CreateArchive создает архив; символические ссылки сохраняются как ссылки
 func (em *ExtractManager) CreateArchive(files []string, outputPath string, format string) error {
+	return em.CreateArchiveWithOptions(files, outputPath, format, CreateOptions{IncludeSymlinks: true})
+}
+
+// CreateArchiveWithOptions создает архив с заданным уровнем сжатия, числом потоков
+// и обработкой символических ссылок
+func (em *ExtractManager) CreateArchiveWithOptions(files []string, outputPath, format string, opts CreateOptions) error {
+	if err := validateCreateOptions(format, opts); err != nil {
+		return err
+	}
+	outputPath, files = argPath(outputPath), argPaths(files)
+
 	switch format {
+	case "tar":
+		return em.createTar(files, outputPath, opts)
 	case "tar.gz":
-		return em.createTarGz(files, outputPath)
+		return em.createTarGz(files, outputPath, opts)
 	case "zip":
-		return em.createZip(files, outputPath)
+		return em.createZip(files, outputPath, opts)
 	case "tar.bz2":
-		return em.createTarBz2(files, outputPath)
+		return em.createTarBz2(files, outputPath, opts)
 	case "tar.xz":
-		return em.createTarXz(files, outputPath)
+		return em.createTarXz(files, outputPath, opts)
+	case "tar.zst":
+		return em.createTarZst(files, outputPath, opts)
+	case "tar.lz4":
+		return em.createTarLz4(files, outputPath, opts)
+	case "zst":
+		return em.createZst(files, outputPath, opts)
 	case "7z":
-		return em.create7z(files, outputPath)
+		return em.create7z(files, outputPath, opts)
+	case "cpio", "cpio.gz":
+		return em.createCpio(files, outputPath, format == "cpio.gz", opts)
 	default:
 		return fmt.Errorf("неподдерживаемый формат: %s", format)
 	}
 }
 
-// CheckTools проверяет наличие необходимых инструментов
-func (em *ExtractManager) CheckTools() map[string]bool {
-	tools := map[string]string{
-		"tar":    "tar",
-		"gzip":   "gzip",
-		"bzip2":  "bzip2",
-		"xz":     "xz",
-		"unzip":  "unzip",
-		"unrar":  "unrar",
-		"7z":     "7z",
-		"lz4":    "lz4",
-		"zstd":   "zstd",
-		"lzop":   "lzop",
-		"gunzip": "gunzip",
+func validateCreateOptions(format string, opts CreateOptions) error {
+	if opts.Threads < 0 {
+		return fmt.Errorf("некорректное число потоков: %d", opts.Threads)
+	}
+	if opts.CompressionLevel == 0 {
+		return nil
+	}
+	bounds, ok := compressionLevels[format]
+	if !ok {
+		return fmt.Errorf("формат %s не поддерживает выбор уровня сжатия", format)
+	}
+	if opts.CompressionLevel < bounds[0] || opts.CompressionLevel > bounds[1] {
+		return fmt.Errorf("уровень сжатия %d вне диапазона %d-%d для формата %s",
+			opts.CompressionLevel, bounds[0], bounds[1], format)
 	}
+	return nil
+}
 
+// CheckTools проверяет наличие необходимых инструментов.
+// Какие форматы при этом извлекаются встроенными средствами, сообщает CheckFormats.
+func (em *ExtractManager) CheckTools() map[string]bool {
 	result := make(map[string]bool)
-	for name, cmd := range tools {
+	for name, cmd := range archiveTools {
 		result[name] = em.commandExists(cmd)
 	}
 
@@ -148,16 +487,30 @@ func (em *ExtractManager) CheckTools() map[string]bool {
 // Helper методы
 
 func (em *ExtractManager) isArchive(filePath string) bool {
-	filename := strings.ToLower(filePath)
-	for _, format := range em.SupportedFormats() {
-		if strings.HasSuffix(filename, format) {
-			return true
-		}
-	}
-	return false
+	return em.detectArchiveType(filePath) != "unknown"
 }
 
+// detectArchiveType определяет формат по расширению, а для незнакомого расширения -
+// по сигнатуре содержимого
 func (em *ExtractManager) detectArchiveType(filePath string) string {
+	format, _ := em.detectFormat(filePath)
+	return format
+}
+
+// detectFormat определяет формат архива; byMagic сообщает, что формат определен
+// по сигнатуре, а не по расширению
+func (em *ExtractManager) detectFormat(filePath string) (format string, byMagic bool) {
+	if format := detectByExtension(filePath); format != "unknown" {
+		return format, false
+	}
+	if format := detectByMagic(filePath); format != "" {
+		return format, true
+	}
+	return "unknown", false
+}
+
+// detectByExtension определяет формат архива по расширению имени файла
+func detectByExtension(filePath string) string {
 	filename := strings.ToLower(filePath)
 
 	switch {
@@ -167,12 +520,16 @@ func (em *ExtractManager) detectArchiveType(filePath string) string {
 		return "tar.bz2"
 	case strings.HasSuffix(filename, ".tar.xz") || strings.HasSuffix(filename, ".txz"):
 		return "tar.xz"
-	case strings.HasSuffix(filename, ".tar.zst"):
+	case strings.HasSuffix(filename, ".tar.zst") || strings.HasSuffix(filename, ".tzst"):
 		return "tar.zst"
 	case strings.HasSuffix(filename, ".tar.lz4"):
 		return "tar.lz4"
 	case strings.HasSuffix(filename, ".tar"):
 		return "tar"
+	case strings.HasSuffix(filename, ".cpio.gz"):
+		return "cpio.gz"
+	case strings.HasSuffix(filename, ".cpio"):
+		return "cpio"
 	case strings.HasSuffix(filename, ".gz"):
 		return "gz"
 	case strings.HasSuffix(filename, ".bz2"):
@@ -198,23 +555,30 @@ func (em *ExtractManager) detectArchiveType(filePath string) string {
 
 func (em *ExtractManager) checkArchiveValidity(filePath string) bool {
 	archiveType := em.detectArchiveType(filePath)
+	if em.PreferNative && nativeFormats[archiveType] {
+		return checkNative(filePath, archiveType) == nil
+	}
+	filePath = argPath(filePath)
 
 	switch archiveType {
-	case "tar.gz", "tgz", "tar.bz2", "tbz2", "tar.xz", "txz", "tar":
-		cmd := exec.Command("tar", "-tf", filePath)
-		return cmd.Run() == nil
+	case "tar.gz", "tgz", "tar.bz2", "tbz2", "tar.xz", "txz", "tar.zst", "tar.lz4", "tar":
+		return em.runner().Run("tar", em.tarListArgs(archiveType, filePath)...) == nil
 	case "gz":
-		cmd := exec.Command("gunzip", "-t", filePath)
-		return cmd.Run() == nil
+		return em.runner().Run("gunzip", "-t", filePath) == nil
 	case "zip":
-		cmd := exec.Command("unzip", "-t", filePath)
-		return cmd.Run() == nil
+		// unzip -t запрашивает пароль зашифрованного архива; достаточно прочитать каталог
+		if encrypted, _, err := zipEncryption(filePath); err != nil || encrypted {
+			return err == nil
+		}
+		return em.runner().Run("unzip", "-t", filePath) == nil
 	case "rar":
 		if em.commandExists("unrar") {
-			cmd := exec.Command("unrar", "t", filePath)
-			return cmd.Run() == nil
+			return em.runner().Run("unrar", "t", filePath) == nil
 		}
 		return true // Предполагаем валидным если нет unrar
+	case "cpio", "cpio.gz":
+		_, err := em.listCpio(filePath, archiveType == "cpio.gz")
+		return err == nil
 	default:
 		return true // Для остальных форматов считаем валидным
 	}
@@ -222,21 +586,35 @@ func (em *ExtractManager) checkArchiveValidity(filePath string) bool {
 
 func (em *ExtractManager) listArchiveContents(filePath string) []string {
 	archiveType := em.detectArchiveType(filePath)
+	if em.PreferNative && archiveType != "gz" && nativeFormats[archiveType] {
+		manifest, err := em.Inspect(filePath)
+		if err != nil {
+			return []string{}
+		}
+		names := make([]string, len(manifest.Entries))
+		for i, entry := range manifest.Entries {
+			names[i] = entry.Name
+		}
+		return names
+	}
+	filePath = argPath(filePath)
 
 	switch archiveType {
-	case "tar.gz", "tgz", "tar.bz2", "tbz2", "tar.xz", "txz", "tar":
-		cmd := exec.Command("tar", "-tf", filePath)
-		if output, err := cmd.Output(); err == nil {
+	case "tar.gz", "tgz", "tar.bz2", "tbz2", "tar.xz", "txz", "tar.zst", "tar.lz4", "tar":
+		if output, err := em.runner().Output("tar", em.tarListArgs(archiveType, filePath)...); err == nil {
 			return strings.Split(strings.TrimSpace(string(output)), "\n")
 		}
 	case "zip":
-		cmd := exec.Command("unzip", "-l", filePath)
-		if output, err := cmd.Output(); err == nil {
+		if output, err := em.runner().Output("unzip", "-l", filePath); err == nil {
 			lines := strings.Split(string(output), "\n")
 			if len(lines) > 3 {
 				return lines[3 : len(lines)-3]
 			}
 		}
+	case "cpio", "cpio.gz":
+		if entries, err := em.listCpio(filePath, archiveType == "cpio.gz"); err == nil {
+			return entries
+		}
 	}
 
 	return []string{}
@@ -248,59 +626,89 @@ func (em *ExtractManager) getDefaultOutputDir(archivePath string) string {
 	return filepath.Join(filepath.Dir(archivePath), baseName)
 }
 
-func (em *ExtractManager) extractWithProgress(archivePath, outputDir string) error {
-	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = " Извлечение архива..."
+func (em *ExtractManager) extractWithProgress(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	if em.Progress == ProgressBar {
+		return em.extractWithBar(ctx, archivePath, outputDir, opts)
+	}
+	return em.extractWithSpinner(ctx, archivePath, outputDir, opts)
+}
+
+func (em *ExtractManager) extractWithSpinner(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	s := ui.NewSpinner("Извлечение архива...")
 	s.Start()
 	defer s.Stop()
 
-	return em.extractArchive(archivePath, outputDir)
+	return em.extractArchive(ctx, archivePath, outputDir, opts)
 }
 
-func (em *ExtractManager) extractWithoutProgress(archivePath, outputDir string) error {
-	return em.extractArchive(archivePath, outputDir)
+func (em *ExtractManager) extractWithoutProgress(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	return em.extractArchive(ctx, archivePath, outputDir, opts)
 }
 
-func (em *ExtractManager) extractArchive(archivePath, outputDir string) error {
+func (em *ExtractManager) extractArchive(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
 	archiveType := em.detectArchiveType(archivePath)
+	archivePath, outputDir = argPath(archivePath), argPath(outputDir)
+
+	if opts.StripComponents > 0 && !supportsStrip(archiveType) {
+		return fmt.Errorf("формат %s не поддерживает удаление компонентов пути", archiveType)
+	}
+
+	if em.useNative(archiveType) {
+		return em.extractNative(ctx, archiveType, archivePath, outputDir, opts)
+	}
+	if err := em.extractExternal(ctx, archiveType, archivePath, outputDir, opts); err != nil {
+		return err
+	}
+	em.recordExternal(archiveType, archivePath, outputDir, opts)
+	return nil
+}
 
+// extractExternal извлекает архив внешней утилитой формата; встроенное извлечение
+// используется, только если утилите не хватает возможностей (zip со StripComponents)
+func (em *ExtractManager) extractExternal(ctx context.Context, archiveType, archivePath, outputDir string, opts ExtractOptions) error {
 	switch archiveType {
 	case "tar.gz", "tgz":
-		return em.extractTarGz(archivePath, outputDir)
+		return em.extractTarGz(ctx, archivePath, outputDir, opts)
 	case "tar.bz2", "tbz2":
-		return em.extractTarBz2(archivePath, outputDir)
+		return em.safeExecContext(ctx, "tar", tarExtractArgs("-xjf", archivePath, outputDir, opts)...)
 	case "tar.xz", "txz":
-		return em.extractTarXz(archivePath, outputDir)
+		return em.safeExecContext(ctx, "tar", tarExtractArgs("-xJf", archivePath, outputDir, opts)...)
 	case "tar":
-		return em.extractTar(archivePath, outputDir)
+		return em.extractTar(ctx, archivePath, outputDir, opts)
 	case "gz":
-		return em.extractGz(archivePath, outputDir)
+		return em.extractGz(ctx, archivePath, outputDir)
 	case "bz2":
-		return em.extractBz2(archivePath, outputDir)
+		return em.extractBz2(ctx, archivePath, outputDir)
 	case "xz":
-		return em.extractXz(archivePath, outputDir)
+		return em.extractXz(ctx, archivePath, outputDir)
 	case "zip":
-		return em.extractZip(archivePath, outputDir)
+		// unzip разбирает имена записей как шаблоны, поэтому выборка идет встроенно
+		if opts.StripComponents > 0 || opts.members != nil {
+			return extractZipNative(ctx, archivePath, outputDir, opts, em.newGuard())
+		}
+		return em.extractZip(ctx, archivePath, outputDir, opts)
 	case "rar":
-		return em.extractRar(archivePath, outputDir)
+		return em.extractRar(ctx, archivePath, outputDir)
 	case "7z":
-		return safeExecCommand("7z", "x", archivePath, "-o"+outputDir)
+		return em.extract7z(ctx, archivePath, outputDir, opts.Password)
+	case "cpio", "cpio.gz":
+		return em.extractCpio(ctx, archivePath, outputDir, archiveType == "cpio.gz")
 	case "lz4":
 		filename := filepath.Base(archivePath)
 		outputFile := filepath.Join(outputDir, strings.TrimSuffix(filename, ".lz4"))
-		return safeExecCommand("lz4", "-d", archivePath, outputFile)
+		return em.safeExecContext(ctx, "lz4", "-d", archivePath, outputFile)
 	case "zst":
 		filename := filepath.Base(archivePath)
 		outputFile := filepath.Join(outputDir, strings.TrimSuffix(filename, ".zst"))
-		return safeExecCommand("zstd", "-d", archivePath, "-o", outputFile)
+		return em.safeExecContext(ctx, "zstd", "-d", archivePath, "-o", outputFile)
 	case "lzop":
 		filename := filepath.Base(archivePath)
 		outputFile := filepath.Join(outputDir, strings.TrimSuffix(filename, ".lzop"))
-		return safeExecCommand("lzop", "-d", archivePath, "-o", outputFile)
+		return em.safeExecContext(ctx, "lzop", "-d", archivePath, "-o", outputFile)
 	case "tar.zst":
-		return safeExecCommand("tar", "--zstd", "-xf", archivePath, "-C", outputDir)
+		return em.extractTarCompressed(ctx, archivePath, outputDir, "--zstd", "zstd", opts)
 	case "tar.lz4":
-		return safeExecCommand("tar", "--lz4", "-xf", archivePath, "-C", outputDir)
+		return em.extractTarCompressed(ctx, archivePath, outputDir, "--lz4", "lz4", opts)
 	default:
 		return fmt.Errorf("неподдерживаемый формат архива: %s", archiveType)
 	}
@@ -308,32 +716,60 @@ func (em *ExtractManager) extractArchive(archivePath, outputDir string) error {
 
 // Методы извлечения для разных форматов
 
-func (em *ExtractManager) extractTarGz(archivePath, outputDir string) error {
-	cmd := exec.Command("tar", "-xzf", archivePath, "-C", outputDir)
-	return cmd.Run()
+func (em *ExtractManager) extractTarGz(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	return em.safeExecContext(ctx, "tar", tarExtractArgs("-xzf", archivePath, outputDir, opts)...)
 }
 
-func (em *ExtractManager) extractTarBz2(archivePath, outputDir string) error {
-	cmd := exec.Command("tar", "-xjf", archivePath, "-C", outputDir)
-	return cmd.Run()
+// extractTarCompressed извлекает tar со сжатием, для которого у tar есть флаг flag.
+// Если tar не поддерживает флаг, распаковка идет через program, а без нее tar.zst
+// извлекается встроенным декодером zstd
+func (em *ExtractManager) extractTarCompressed(ctx context.Context, archivePath, outputDir, flag, program string, opts ExtractOptions) error {
+	compress, err := em.tarCompressArgs(flag, program)
+	if err != nil {
+		if flag == "--zstd" {
+			return em.extractTarZstNative(ctx, archivePath, outputDir, opts)
+		}
+		return err
+	}
+	return em.safeExecContext(ctx, "tar", append(compress, tarExtractArgs("-xf", archivePath, outputDir, opts)...)...)
 }
 
-func (em *ExtractManager) extractTarXz(archivePath, outputDir string) error {
-	cmd := exec.Command("tar", "-xJf", archivePath, "-C", outputDir)
-	return cmd.Run()
+func (em *ExtractManager) extractTar(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	return em.safeExecContext(ctx, "tar", tarExtractArgs("-xf", archivePath, outputDir, opts)...)
 }
 
-func (em *ExtractManager) extractTar(archivePath, outputDir string) error {
-	cmd := exec.Command("tar", "-xf", archivePath, "-C", outputDir)
-	return cmd.Run()
+// tarListArgs возвращает аргументы tar -tf. Сжатие tar распознает сам, кроме lz4:
+// GNU tar не знает этот формат, и программа распаковки передается явно
+func (em *ExtractManager) tarListArgs(archiveType, filePath string) []string {
+	args := []string{"-tf", filePath}
+	if archiveType == "tar.lz4" {
+		if compress, err := em.tarCompressArgs("--lz4", "lz4"); err == nil {
+			args = append(compress, args...)
+		}
+	}
+	return args
 }
 
-func (em *ExtractManager) extractGz(archivePath, outputDir string) error {
+// tarExtractArgs формирует аргументы извлечения tar с учетом --strip-components
+// и списка извлекаемых записей ExtractMatching
+func tarExtractArgs(flag, archivePath, outputDir string, opts ExtractOptions) []string {
+	args := []string{flag, archivePath, "-C", outputDir}
+	if opts.StripComponents > 0 {
+		args = append(args, "--strip-components="+strconv.Itoa(opts.StripComponents))
+	}
+	args = append(args, tarAttrArgs(opts)...)
+	if opts.members != nil {
+		args = append(args, "--")
+		args = append(args, opts.memberNames()...)
+	}
+	return args
+}
+
+func (em *ExtractManager) extractGz(ctx context.Context, archivePath, outputDir string) error {
 	filename := filepath.Base(archivePath)
 	outputFile := filepath.Join(outputDir, strings.TrimSuffix(filename, ".gz"))
 
-	cmd := exec.Command("gunzip", "-c", archivePath)
-	output, err := cmd.Output()
+	output, err := em.runner().OutputContext(ctx, "gunzip", "-c", archivePath)
 	if err != nil {
 		return err
 	}
@@ -341,12 +777,11 @@ func (em *ExtractManager) extractGz(archivePath, outputDir string) error {
 	return os.WriteFile(outputFile, output, 0600)
 }
 
-func (em *ExtractManager) extractBz2(archivePath, outputDir string) error {
+func (em *ExtractManager) extractBz2(ctx context.Context, archivePath, outputDir string) error {
 	filename := filepath.Base(archivePath)
 	outputFile := filepath.Join(outputDir, strings.TrimSuffix(filename, ".bz2"))
 
-	cmd := exec.Command("bunzip2", "-c", archivePath)
-	output, err := cmd.Output()
+	output, err := em.runner().OutputContext(ctx, "bunzip2", "-c", archivePath)
 	if err != nil {
 		return err
 	}
@@ -354,12 +789,11 @@ func (em *ExtractManager) extractBz2(archivePath, outputDir string) error {
 	return os.WriteFile(outputFile, output, 0600)
 }
 
-func (em *ExtractManager) extractXz(archivePath, outputDir string) error {
+func (em *ExtractManager) extractXz(ctx context.Context, archivePath, outputDir string) error {
 	filename := filepath.Base(archivePath)
 	outputFile := filepath.Join(outputDir, strings.TrimSuffix(filename, ".xz"))
 
-	cmd := exec.Command("xz", "-d", "-c", archivePath)
-	output, err := cmd.Output()
+	output, err := em.runner().OutputContext(ctx, "xz", "-d", "-c", archivePath)
 	if err != nil {
 		return err
 	}
@@ -367,63 +801,379 @@ func (em *ExtractManager) extractXz(archivePath, outputDir string) error {
 	return os.WriteFile(outputFile, output, 0600)
 }
 
-func (em *ExtractManager) extractZip(archivePath, outputDir string) error {
-	cmd := exec.Command("unzip", "-o", archivePath, "-d", outputDir)
-	return cmd.Run()
+// extractZip извлекает zip утилитой unzip. Зашифрованный архив извлекается встроенно,
+// а с шифрованием AES - через 7z: пароль не передается в аргументах командной строки
+func (em *ExtractManager) extractZip(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	encrypted, aes, err := zipEncryption(archivePath)
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		if opts.Password == "" {
+			return fmt.Errorf("%w: %s", ErrPasswordRequired, archivePath)
+		}
+		if aes {
+			return em.extract7z(ctx, archivePath, outputDir, opts.Password)
+		}
+		return extractZipNative(ctx, archivePath, outputDir, opts, em.newGuard())
+	}
+	return em.safeExecContext(ctx, "unzip", "-o", archivePath, "-d", outputDir)
+}
+
+// extract7z извлекает 7z; неподходящий пароль возвращает ErrWrongPassword,
+// а зашифрованный архив без пароля - ErrPasswordRequired
+func (em *ExtractManager) extract7z(ctx context.Context, archivePath, outputDir, password string) error {
+	_, err := em.run7z(ctx, password, "x", "-y", archivePath, "-o"+outputDir)
+	if err != nil && strings.Contains(err.Error(), "Wrong password") {
+		return passwordError(err, password)
+	}
+	return err
 }
 
-func (em *ExtractManager) extractRar(archivePath, outputDir string) error {
-	cmd := exec.Command("unrar", "x", archivePath, outputDir)
-	return cmd.Run()
+// run7z запускает 7z, передавая пароль в stdin, а не в аргументах (-p): аргументы видны
+// всем пользователям в ps и /proc. Без пароля stdin пуст, и запрос пароля получает EOF.
+// Пароль по-прежнему виден root через память процесса, а сборки p7zip, читающие пароль
+// из управляющего терминала, при наличии терминала запросят его там.
+func (em *ExtractManager) run7z(ctx context.Context, password string, args ...string) (string, error) {
+	if _, err := em.runner().LookPath("7z"); err != nil {
+		return "", fmt.Errorf("команда 7z не найдена: %w", err)
+	}
+	stdin := ""
+	if password != "" {
+		stdin = password + "\n"
+	}
+	var output bytes.Buffer
+	err := em.runner().RunIO(ctx, runner.Streams{Stdin: strings.NewReader(stdin), Stdout: &output, Stderr: &output}, "7z", args...)
+	if err != nil {
+		if msg := strings.TrimSpace(output.String()); msg != "" {
+			return output.String(), fmt.Errorf("7z: %w: %s", err, msg)
+		}
+		return output.String(), fmt.Errorf("7z: %w", err)
+	}
+	return output.String(), nil
+}
+
+func (em *ExtractManager) extractRar(ctx context.Context, archivePath, outputDir string) error {
+	// Без завершающего разделителя unrar считает последний аргумент маской файлов
+	dir := outputDir
+	if !strings.HasSuffix(dir, string(os.PathSeparator)) {
+		dir += string(os.PathSeparator)
+	}
+	return em.safeExecContext(ctx, "unrar", "x", archivePath, dir)
 }
 
 // Методы создания архивов
 
-func (em *ExtractManager) createTarGz(files []string, outputPath string) error {
+func (em *ExtractManager) createTar(files []string, outputPath string, opts CreateOptions) error {
+	if !em.commandExists("tar") {
+		return createTarNative(files, outputPath, "", opts)
+	}
+	return em.runTarCreate([]string{"-cf", outputPath}, files, opts)
+}
+
+func (em *ExtractManager) createTarGz(files []string, outputPath string, opts CreateOptions) error {
+	program, err := em.gzipProgram(opts)
+	if err != nil {
+		return err
+	}
+	// Без утилиты tar создаем архив встроенными средствами
+	if !em.commandExists("tar") {
+		return createTarNative(files, outputPath, "gzip", opts)
+	}
 	args := []string{"-czf", outputPath}
-	args = append(args, files...)
-	return safeExecCommand("tar", args...)
+	if program != "" {
+		args = []string{"--use-compress-program=" + program, "-cf", outputPath}
+	}
+	return em.runTarCreate(args, files, opts)
 }
 
-func (em *ExtractManager) createZip(files []string, outputPath string) error {
-	args := []string{outputPath}
+// runTarCreate запускает tar с аргументами args и входными файлами с учетом обработки ссылок
+func (em *ExtractManager) runTarCreate(args, files []string, opts CreateOptions) error {
+	input, cleanup, err := tarInputArgs(files, opts)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return em.safeExecCommand("tar", append(args, input...)...)
+}
+
+// errNoParallelCompressor возвращается, если задан Threads больше 1, а многопоточного
+// компрессора нет: gzip и bzip2 сжимают в один поток, и Threads молча не учитывался бы
+var errNoParallelCompressor = errors.New("многопоточное сжатие требует утилиту, которая не установлена")
+
+// gzipProgram возвращает команду сжатия для tar или пустую строку для настроек по умолчанию
+func (em *ExtractManager) gzipProgram(opts CreateOptions) (string, error) {
+	program := ""
+	switch {
+	case opts.Threads > 0 && em.commandExists("pigz"):
+		program = "pigz -p " + strconv.Itoa(opts.Threads)
+	case opts.Threads > 1:
+		return "", fmt.Errorf("%w: pigz", errNoParallelCompressor)
+	case opts.CompressionLevel > 0:
+		program = "gzip"
+	default:
+		return "", nil
+	}
+	if opts.CompressionLevel > 0 {
+		program += " -" + strconv.Itoa(opts.CompressionLevel)
+	}
+	return program, nil
+}
+
+func (em *ExtractManager) createZip(files []string, outputPath string, opts CreateOptions) error {
+	// Утилита zip не умеет пропускать ссылки - в этом случае архив создается встроенными средствами
+	if !em.commandExists("zip") || opts.symlinks() == symlinksSkip {
+		return createZipNative(files, outputPath, opts.CompressionLevel, opts.symlinks())
+	}
+	// -r: директории упаковываются рекурсивно, как в tar и встроенной реализации
+	args := []string{"-r", "-q"}
+	if opts.CompressionLevel > 0 {
+		args = append(args, "-"+strconv.Itoa(opts.CompressionLevel))
+	}
+	switch opts.symlinks() {
+	case symlinksStore:
+		args = append(args, "-y")
+	case symlinksFollow:
+		// zip переходит по ссылкам по умолчанию
+		if err := checkSymlinkLoops(files); err != nil {
+			return err
+		}
+	}
+	args = append(args, outputPath)
 	args = append(args, files...)
-	return safeExecCommand("zip", args...)
+	return em.safeExecCommand("zip", args...)
 }
 
-func (em *ExtractManager) createTarBz2(files []string, outputPath string) error {
+func (em *ExtractManager) createTarBz2(files []string, outputPath string, opts CreateOptions) error {
+	program, err := em.bzip2Program(opts)
+	if err != nil {
+		return err
+	}
 	args := []string{"-cjf", outputPath}
-	args = append(args, files...)
-	return safeExecCommand("tar", args...)
+	if program != "" {
+		args = []string{"--use-compress-program=" + program, "-cf", outputPath}
+	}
+	return em.runTarCreate(args, files, opts)
 }
 
-func (em *ExtractManager) createTarXz(files []string, outputPath string) error {
+// bzip2Program возвращает команду сжатия bzip2 для tar (pbzip2 при Threads, если установлен)
+// или пустую строку для настроек по умолчанию
+func (em *ExtractManager) bzip2Program(opts CreateOptions) (string, error) {
+	program := ""
+	switch {
+	case opts.Threads > 0 && em.commandExists("pbzip2"):
+		program = "pbzip2 -p" + strconv.Itoa(opts.Threads)
+	case opts.Threads > 1:
+		return "", fmt.Errorf("%w: pbzip2", errNoParallelCompressor)
+	case opts.CompressionLevel > 0:
+		program = "bzip2"
+	default:
+		return "", nil
+	}
+	if opts.CompressionLevel > 0 {
+		program += " -" + strconv.Itoa(opts.CompressionLevel)
+	}
+	return program, nil
+}
+
+func (em *ExtractManager) createTarXz(files []string, outputPath string, opts CreateOptions) error {
 	args := []string{"-cJf", outputPath}
-	args = append(args, files...)
-	return safeExecCommand("tar", args...)
+	if opts.CompressionLevel > 0 || opts.Threads > 0 {
+		program := "xz"
+		if opts.CompressionLevel > 0 {
+			program += " -" + strconv.Itoa(opts.CompressionLevel)
+		}
+		if opts.Threads > 0 {
+			program += " -T" + strconv.Itoa(opts.Threads)
+		}
+		args = []string{"--use-compress-program=" + program, "-cf", outputPath}
+	}
+	return em.runTarCreate(args, files, opts)
+}
+
+func (em *ExtractManager) createTarZst(files []string, outputPath string, opts CreateOptions) error {
+	// Без tar или zstd архив сжимается встроенным кодеком zstd
+	if !em.commandExists("tar") || !em.commandExists("zstd") {
+		return createTarNative(files, outputPath, "zstd", opts)
+	}
+	compress := []string{"--use-compress-program=" + zstdProgram(opts)}
+	if zstdProgram(opts) == "zstd" {
+		var err error
+		if compress, err = em.tarCompressArgs("--zstd", "zstd"); err != nil {
+			return err
+		}
+	}
+	return em.runTarCreate(append(compress, "-cf", outputPath), files, opts)
 }
 
-func (em *ExtractManager) create7z(files []string, outputPath string) error {
-	args := []string{"a", outputPath}
+// createTarLz4 создает tar.lz4: tar --lz4, а с уровнем сжатия - lz4 -N через
+// --use-compress-program. lz4 однопоточный, Threads не учитывается
+func (em *ExtractManager) createTarLz4(files []string, outputPath string, opts CreateOptions) error {
+	compress, err := em.tarCompressArgs("--lz4", "lz4")
+	if err != nil {
+		return err
+	}
+	if opts.CompressionLevel > 0 {
+		compress = []string{"--use-compress-program=lz4 -" + strconv.Itoa(opts.CompressionLevel)}
+	}
+	return em.runTarCreate(append(compress, "-cf", outputPath), files, opts)
+}
+
+// createZst сжимает один файл в .zst без упаковки в tar
+func (em *ExtractManager) createZst(files []string, outputPath string, opts CreateOptions) error {
+	if len(files) != 1 {
+		return fmt.Errorf("формат zst сжимает ровно один файл, передано: %d", len(files))
+	}
+	if info, err := os.Stat(files[0]); err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", files[0], err)
+	} else if info.IsDir() {
+		return fmt.Errorf("%s является директорией, используйте формат tar.zst", files[0])
+	}
+
+	if !em.commandExists("zstd") {
+		return createZstNative(files[0], outputPath, opts)
+	}
+	args := strings.Fields(zstdProgram(opts))[1:]
+	args = append(args, "-q", "-f", files[0], "-o", outputPath)
+	return em.safeExecCommand("zstd", args...)
+}
+
+// createZstNative сжимает файл в .zst встроенным кодеком, когда утилиты zstd нет
+func createZstNative(file, outputPath string, opts CreateOptions) error {
+	in, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", file, err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(filepath.Clean(outputPath), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("ошибка создания архива: %w", err)
+	}
+	zw, err := nativeCompressWriter("zstd", out, opts)
+	if err != nil {
+		_ = out.Close()
+		return fmt.Errorf("ошибка настройки сжатия: %w", err)
+	}
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	return nil
+}
+
+// zstdProgram возвращает команду zstd с уровнем сжатия и числом потоков
+func zstdProgram(opts CreateOptions) string {
+	program := "zstd"
+	if opts.CompressionLevel > 0 {
+		program += " -" + strconv.Itoa(opts.CompressionLevel)
+	}
+	if opts.Threads > 0 {
+		program += " -T" + strconv.Itoa(opts.Threads)
+	}
+	return program
+}
+
+func (em *ExtractManager) create7z(files []string, outputPath string, opts CreateOptions) error {
+	if opts.FollowSymlinks {
+		if err := checkSymlinkLoops(files); err != nil {
+			return err
+		}
+	}
+	args := []string{"a"}
+	if opts.CompressionLevel > 0 {
+		args = append(args, "-mx="+strconv.Itoa(opts.CompressionLevel))
+	}
+	if opts.Threads > 0 {
+		args = append(args, "-mmt="+strconv.Itoa(opts.Threads))
+	}
+	args = append(args, outputPath)
 	args = append(args, files...)
-	return safeExecCommand("7z", args...)
+	return em.safeExecCommand("7z", args...)
 }
 
 func (em *ExtractManager) commandExists(cmd string) bool {
-	_, err := exec.LookPath(cmd)
+	_, err := em.runner().LookPath(cmd)
 	return err == nil
 }
 
-// safeExecCommand безопасно выполняет команду с проверкой аргументов
-func safeExecCommand(name string, arg ...string) error {
+// safeExecCommand безопасно выполняет команду с проверкой аргументов.
+// stderr сохраняется в ошибке: по нему отличаются временные сбои ввода-вывода
+func (em *ExtractManager) safeExecCommand(name string, arg ...string) error {
+	return em.safeExecContext(context.Background(), name, arg...)
+}
+
+// safeExecContext выполняет команду как safeExecCommand, завершая ее при отмене ctx
+func (em *ExtractManager) safeExecContext(ctx context.Context, name string, arg ...string) error {
+	r := em.runner()
 	// Проверяем наличие команды
-	if _, err := exec.LookPath(name); err != nil {
+	if _, err := r.LookPath(name); err != nil {
 		return fmt.Errorf("команда %s не найдена: %w", name, err)
 	}
 
-	// Создаем команду с явными аргументами
-	cmd := exec.Command(name, arg...)
-	return cmd.Run()
+	// Запускаем команду с явными аргументами, без оболочки
+	_, err := r.OutputContext(ctx, name, arg...)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return fmt.Errorf("%s: %w", name, ctxErr)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// runner возвращает средство запуска команд: em.Runner или runner.Default
+func (em *ExtractManager) runner() runner.CommandRunner {
+	return runner.Or(em.Runner)
+}
+
+// runStreams выполняет команду с потоками streams, сохраняя stderr в ошибке:
+// по нему отличаются временные сбои ввода-вывода
+func (em *ExtractManager) runStreams(ctx context.Context, streams runner.Streams, name string, args ...string) error {
+	var stderr bytes.Buffer
+	streams.Stderr = &stderr
+	if err := em.runner().RunIO(ctx, streams, name, args...); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%s: %w: %s", name, err, msg)
+		}
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// argPath защищает путь, передаваемый внешней утилите отдельным аргументом.
+// Относительные пути, начинающиеся с "-", иначе будут приняты за опцию,
+// а "host:file" GNU tar считает удаленным архивом, поэтому к ним добавляется "./".
+// Пробелы, кавычки и юникод не требуют экранирования: команды запускаются без оболочки.
+func argPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	colon := strings.Index(path, ":")
+	slash := strings.Index(path, "/")
+	if strings.HasPrefix(path, "-") || (colon >= 0 && (slash < 0 || colon < slash)) {
+		return "./" + path
+	}
+	return path
+}
+
+func argPaths(paths []string) []string {
+	result := make([]string, len(paths))
+	for i, path := range paths {
+		result[i] = argPath(path)
+	}
+	return result
 }
 
 // ExtractFunction предоставляет функцию извлечения для использования в скриптах
@@ -433,3 +1183,13 @@ func ExtractFunction() func(string) error {
 		return em.Extract(archivePath, "", true)
 	}
 }
+
+// ExtractFunctionWith предоставляет функцию извлечения с заданными один раз параметрами:
+// без opts.ShowProgress спиннер не выводится, что удобно для неинтерактивных скриптов.
+// Пустой opts.OutputDir означает директорию по умолчанию рядом с каждым архивом
+func ExtractFunctionWith(opts ExtractOptions) func(string) error {
+	return func(archivePath string) error {
+		em := &ExtractManager{}
+		return em.ExtractWithOptions(archivePath, "", opts)
+	}
+}
@@ -0,0 +1,813 @@
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCreateArchiveCompressionFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		opts    CreateOptions
+		missing []string
+		want    string
+	}{
+		{"pigz", "tar.gz", CreateOptions{Threads: 4, CompressionLevel: 6}, nil,
+			"tar --use-compress-program=pigz -p 4 -6 -cf out file"},
+		{"gzip без pigz", "tar.gz", CreateOptions{Threads: 1, CompressionLevel: 9}, []string{"pigz"},
+			"tar --use-compress-program=gzip -9 -cf out file"},
+		{"gzip по умолчанию", "tar.gz", CreateOptions{}, nil, "tar -czf out file"},
+		{"pbzip2", "tar.bz2", CreateOptions{Threads: 2}, nil,
+			"tar --use-compress-program=pbzip2 -p2 -cf out file"},
+		{"xz", "tar.xz", CreateOptions{Threads: 2, CompressionLevel: 3}, nil,
+			"tar --use-compress-program=xz -3 -T2 -cf out file"},
+		{"zstd", "tar.zst", CreateOptions{CompressionLevel: 19}, nil,
+			"tar --use-compress-program=zstd -19 -cf out file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := runner.NewFakeRunner()
+			for _, name := range tt.missing {
+				fake.Missing[name] = true
+			}
+			em := &ExtractManager{Runner: fake}
+			tt.opts.IncludeSymlinks = true
+			if err := em.CreateArchiveWithOptions([]string{"file"}, "out", tt.format, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if commands := fake.Commands(); !reflect.DeepEqual(commands, []string{tt.want}) {
+				t.Errorf("команды %q, ожидалось %q", commands, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateArchiveThreadsWithoutParallelCompressor(t *testing.T) {
+	for format, tool := range map[string]string{"tar.gz": "pigz", "tar.bz2": "pbzip2"} {
+		fake := runner.NewFakeRunner()
+		fake.Missing[tool] = true
+		em := &ExtractManager{Runner: fake}
+
+		err := em.CreateArchiveWithOptions([]string{"file"}, "out", format, CreateOptions{Threads: 4, IncludeSymlinks: true})
+		if !errors.Is(err, errNoParallelCompressor) || !strings.Contains(err.Error(), tool) {
+			t.Errorf("%s: ошибка %v должна называть %s", format, err, tool)
+		}
+		if commands := fake.Commands(); len(commands) != 0 {
+			t.Errorf("%s: запущены команды %q", format, commands)
+		}
+	}
+}
+
+func TestCreateArchiveValidatesLevel(t *testing.T) {
+	em := &ExtractManager{Runner: runner.NewFakeRunner()}
+	for format, level := range map[string]int{"tar.gz": 10, "tar.zst": 20, "tar": 1} {
+		if err := em.CreateArchiveWithOptions([]string{"file"}, "out", format, CreateOptions{CompressionLevel: level}); err == nil {
+			t.Errorf("%s: уровень %d должен отклоняться", format, level)
+		}
+	}
+}
+
+func TestCreateTarNativeHonorsLevel(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	var data strings.Builder
+	for i := 0; i < 20000; i++ {
+		data.WriteString(strings.Repeat("go-to-run ", i%7+1))
+		data.WriteByte(byte('a' + i%26))
+	}
+	if err := os.WriteFile(file, []byte(data.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	size := func(level int) int64 {
+		out := filepath.Join(dir, "level.tar.gz")
+		if err := createTarNative([]string{file}, out, "gzip", CreateOptions{CompressionLevel: level, IncludeSymlinks: true}); err != nil {
+			t.Fatal(err)
+		}
+		info, err := os.Stat(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return info.Size()
+	}
+	if fast, best := size(1), size(9); fast <= best {
+		t.Errorf("уровень 1 дал %d байт, уровень 9 - %d: уровень не учитывается", fast, best)
+	}
+}
+
+func TestGetArchiveInfoMissingFile(t *testing.T) {
+	em := &ExtractManager{PreferNative: true}
+	info, err := em.GetArchiveInfo(filepath.Join(t.TempDir(), "missing.tar"))
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ожидалась ошибка отсутствующего файла, получено %v", err)
+	}
+	if info != nil {
+		t.Errorf("info = %+v, ожидался nil", info)
+	}
+}
+
+func TestGetArchiveInfoBatch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(file, []byte("go-to-run"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	em := &ExtractManager{PreferNative: true}
+	var paths []string
+	for i := 0; i < 6; i++ {
+		for _, format := range []string{"tar.gz", "zip"} {
+			path := filepath.Join(dir, fmt.Sprintf("valid%d.%s", i, format))
+			if err := em.CreateArchive([]string{file}, path, format); err != nil {
+				t.Fatal(err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	corrupt := filepath.Join(dir, "corrupt.tar.gz")
+	if err := os.WriteFile(corrupt, []byte("не архив"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.zip")
+	paths = append(paths, corrupt, missing)
+
+	infos, err := em.GetArchiveInfoBatch(paths, 4)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("2 из %d", len(paths))) {
+		t.Errorf("общая ошибка %v должна сообщать о 2 архивах", err)
+	}
+	if len(infos) != len(paths) {
+		t.Fatalf("получено %d результатов, ожидалось %d", len(infos), len(paths))
+	}
+	for i, info := range infos {
+		if info.Path != paths[i] {
+			t.Errorf("результат %d относится к %s, ожидался %s", i, info.Path, paths[i])
+		}
+		switch info.Path {
+		case corrupt:
+			if info.IsValid || info.Err == nil {
+				t.Errorf("%s: поврежденный архив не отмечен: %+v", info.Path, info)
+			}
+		case missing:
+			if !errors.Is(info.Err, os.ErrNotExist) {
+				t.Errorf("%s: Err = %v", info.Path, info.Err)
+			}
+		default:
+			if !info.IsValid || info.Err != nil || len(info.Contents) == 0 {
+				t.Errorf("%s: %+v", info.Path, info)
+			}
+		}
+	}
+}
+
+func TestCreateTarZstRoundTrip(t *testing.T) {
+	parent := t.TempDir()
+	src := filepath.Join(parent, "tree")
+	sampleTree(t, src, 20)
+	// Относительный путь: tar и встроенная реализация сохраняют его одинаково
+	t.Chdir(parent)
+	_, tarErr := exec.LookPath("tar")
+	_, zstdErr := exec.LookPath("zstd")
+	externalZstd := tarErr == nil && zstdErr == nil
+
+	tests := []struct {
+		name    string
+		runner  runner.CommandRunner
+		archive string
+	}{
+		{"zstd", nil, "tree.tar.zst"},
+		{"tzst", nil, "tree.tzst"},
+		{"без zstd", missingRunner("zstd"), "native.tar.zst"},
+		{"без tar", missingRunner("tar"), "native.tzst"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.runner == nil && !externalZstd {
+				t.Skip("tar или zstd не установлены")
+			}
+			archivePath := filepath.Join(t.TempDir(), tt.archive)
+			em := &ExtractManager{Runner: tt.runner}
+			if got := em.detectArchiveType(archivePath); got != "tar.zst" {
+				t.Fatalf("тип %s = %q, ожидался tar.zst", tt.archive, got)
+			}
+			if err := em.CreateArchiveWithOptions([]string{"tree"}, archivePath, "tar.zst", CreateOptions{CompressionLevel: 3, IncludeSymlinks: true}); err != nil {
+				t.Fatal(err)
+			}
+
+			// Архив читается и встроенным кодеком, и tar --zstd, если утилиты есть
+			extractors := []*ExtractManager{{PreferNative: true}}
+			if externalZstd {
+				extractors = append(extractors, &ExtractManager{})
+			}
+			for _, extractor := range extractors {
+				outputDir := t.TempDir()
+				if err := extractor.ExtractWithOptions(archivePath, outputDir, DefaultExtractOptions()); err != nil {
+					t.Fatalf("PreferNative=%v: %v", extractor.PreferNative, err)
+				}
+				if got, want := treeSnapshot(t, filepath.Join(outputDir, "tree")), treeSnapshot(t, src); !reflect.DeepEqual(got, want) {
+					t.Errorf("PreferNative=%v: содержимое после распаковки отличается:\n%v\n%v", extractor.PreferNative, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateTarLz4RoundTrip(t *testing.T) {
+	for _, tool := range []string{"tar", "lz4"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			t.Skipf("%s не установлен", tool)
+		}
+	}
+	parent := t.TempDir()
+	src := filepath.Join(parent, "tree")
+	sampleTree(t, src, 20)
+	t.Chdir(parent)
+
+	// Без уровня используется tar --lz4, с уровнем - lz4 -N через --use-compress-program
+	for _, level := range []int{0, 9} {
+		t.Run(fmt.Sprintf("level=%d", level), func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "tree.tar.lz4")
+			em := &ExtractManager{}
+			if err := em.CreateArchiveWithOptions([]string{"tree"}, archivePath, "tar.lz4", CreateOptions{CompressionLevel: level, IncludeSymlinks: true}); err != nil {
+				t.Fatal(err)
+			}
+			if got := em.detectArchiveType(archivePath); got != "tar.lz4" {
+				t.Fatalf("тип архива %q, ожидался tar.lz4", got)
+			}
+
+			outputDir := t.TempDir()
+			if err := em.ExtractWithOptions(archivePath, outputDir, DefaultExtractOptions()); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := treeSnapshot(t, filepath.Join(outputDir, "tree")), treeSnapshot(t, src); !reflect.DeepEqual(got, want) {
+				t.Errorf("содержимое после распаковки отличается:\n%v\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestCreateZstWithoutZstd(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	content := strings.Repeat("go-to-run\n", 1000)
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fake := missingRunner("zstd")
+	em := &ExtractManager{Runner: fake}
+	archivePath := filepath.Join(dir, "data.txt.zst")
+	if err := em.CreateArchiveWithOptions([]string{file}, archivePath, "zst", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("запущены команды %q", commands)
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil || string(data) != content {
+		t.Errorf("распаковано %d байт, %v", len(data), err)
+	}
+}
+
+// missingRunner возвращает FakeRunner, в PATH которого нет перечисленных команд
+func missingRunner(names ...string) *runner.FakeRunner {
+	fake := runner.NewFakeRunner()
+	for _, name := range names {
+		fake.Missing[name] = true
+	}
+	return fake
+}
+
+func TestCreateArchiveNativeExtractsWithBothExtractors(t *testing.T) {
+	parent := t.TempDir()
+	src := filepath.Join(parent, "tree")
+	sampleTree(t, src, 30)
+	t.Chdir(parent)
+	want := treeSnapshot(t, src)
+
+	tools := map[string]string{"tar": "tar", "tar.gz": "tar", "zip": "unzip"}
+	for _, format := range []string{"tar", "tar.gz", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			fake := missingRunner("tar", "zip", "gzip", "pigz")
+			archivePath := filepath.Join(t.TempDir(), "tree."+format)
+			if err := (&ExtractManager{Runner: fake}).CreateArchive([]string{"tree"}, archivePath, format); err != nil {
+				t.Fatal(err)
+			}
+			if commands := fake.Commands(); len(commands) != 0 {
+				t.Fatalf("без утилит запущены команды %q", commands)
+			}
+
+			extractors := map[string]*ExtractManager{"native": {PreferNative: true}}
+			if _, err := exec.LookPath(tools[format]); err == nil {
+				extractors["external"] = &ExtractManager{}
+			}
+			for name, em := range extractors {
+				outputDir := t.TempDir()
+				if err := em.ExtractWithOptions(archivePath, outputDir, DefaultExtractOptions()); err != nil {
+					t.Fatalf("%s: %v", name, err)
+				}
+				got, expected := treeSnapshot(t, filepath.Join(outputDir, "tree")), want
+				if format == "zip" && name == "native" {
+					// Встроенное извлечение zip восстанавливает только файлы и директории
+					expected = withoutLinks(want)
+				}
+				if !reflect.DeepEqual(got, expected) {
+					t.Errorf("%s: содержимое отличается:\n%v\n%v", name, got, expected)
+				}
+			}
+		})
+	}
+}
+
+// withoutLinks возвращает снимок дерева без символических ссылок
+func withoutLinks(snapshot map[string]string) map[string]string {
+	result := make(map[string]string, len(snapshot))
+	for path, entry := range snapshot {
+		if !strings.HasPrefix(entry, "link:") {
+			result[path] = entry
+		}
+	}
+	return result
+}
+
+func TestArgPath(t *testing.T) {
+	tests := map[string]string{
+		"":                     "",
+		"/abs/path with space": "/abs/path with space",
+		"archive.tar":          "archive.tar",
+		"-rf.tar":              "./-rf.tar",
+		"host:backup.tar":      "./host:backup.tar",
+		"dir/file:1.tar":       "dir/file:1.tar",
+		"архив «копия».zip":    "архив «копия».zip",
+	}
+	for path, want := range tests {
+		if got := argPath(path); got != want {
+			t.Errorf("argPath(%q) = %q, ожидалось %q", path, got, want)
+		}
+	}
+}
+
+// awkwardTree создает дерево, имена в котором содержат пробелы, кавычки, юникод и метасимволы оболочки
+func awkwardTree(t *testing.T, root string) {
+	t.Helper()
+	files := map[string]string{
+		"my docs/отчёт 2024.txt":        "отчёт",
+		"my docs/it's \"quoted\".txt":   "quoted",
+		"my docs/$(touch pwned); x.txt": "metachar",
+		"日本語/ファイル.txt":                  "unicode",
+		"-leading dash.txt":             "dash",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestArchivePathsWithSpacesAndUnicode(t *testing.T) {
+	parent := filepath.Join(t.TempDir(), "рабочая папка")
+	src := filepath.Join(parent, "исходные данные")
+	awkwardTree(t, src)
+	want := treeSnapshot(t, src)
+	t.Chdir(parent)
+
+	tools := map[string][]string{
+		"tar":     {"tar"},
+		"tar.gz":  {"tar", "gzip"},
+		"tar.xz":  {"tar", "xz"},
+		"tar.zst": {"tar", "zstd"},
+		"zip":     {"zip", "unzip"},
+	}
+	for format, required := range tools {
+		for _, native := range []bool{false, true} {
+			name := format + "/external"
+			if native {
+				if !nativeFormats[format] {
+					continue
+				}
+				name = format + "/native"
+			}
+			t.Run(name, func(t *testing.T) {
+				em := &ExtractManager{PreferNative: native}
+				if native {
+					em.Runner = missingRunner(required...)
+				} else {
+					for _, tool := range required {
+						if _, err := exec.LookPath(tool); err != nil {
+							t.Skipf("%s не установлен", tool)
+						}
+					}
+				}
+				archivePath := filepath.Join(parent, "архив с пробелами '"+name[len(format)+1:]+"'."+format)
+				if err := em.CreateArchive([]string{"исходные данные"}, archivePath, format); err != nil {
+					t.Fatal(err)
+				}
+				outputDir := filepath.Join(parent, "вывод "+strings.ReplaceAll(name, "/", " "))
+				if err := em.ExtractWithOptions(archivePath, outputDir, DefaultExtractOptions()); err != nil {
+					t.Fatal(err)
+				}
+				if got := treeSnapshot(t, filepath.Join(outputDir, "исходные данные")); !reflect.DeepEqual(got, want) {
+					t.Errorf("содержимое отличается:\n%v\n%v", got, want)
+				}
+				if _, err := os.Stat(filepath.Join(parent, "pwned")); err == nil {
+					t.Fatal("имя файла выполнено оболочкой")
+				}
+			})
+		}
+	}
+}
+
+func TestExtract7zPassesPathsAsArguments(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	em := &ExtractManager{Runner: fake}
+	archivePath := "/tmp/мой архив; rm -rf ~.7z"
+	outputDir := "/tmp/папка с пробелами"
+	if err := em.extract7z(context.Background(), archivePath, outputDir, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.Calls) != 1 {
+		t.Fatalf("вызовы %q", fake.Commands())
+	}
+	call := fake.Calls[0]
+	want := []string{"x", "-y", archivePath, "-o" + outputDir}
+	if call.Name != "7z" || !reflect.DeepEqual(call.Args, want) {
+		t.Fatalf("7z %q, ожидалось %q", call.Args, want)
+	}
+}
+
+func TestCpioRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("cpio"); err != nil {
+		t.Skip("cpio не установлен")
+	}
+	parent := t.TempDir()
+	src := filepath.Join(parent, "tree")
+	sampleTree(t, src, 20)
+	// cpio сохраняет пути в том виде, в каком они переданы в списке
+	t.Chdir(parent)
+
+	for _, format := range []string{"cpio", "cpio.gz"} {
+		t.Run(format, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "tree."+format)
+			em := &ExtractManager{}
+			if got := em.detectArchiveType(archivePath); got != format {
+				t.Fatalf("тип %s = %q", archivePath, got)
+			}
+			if err := em.CreateArchiveWithOptions([]string{"tree"}, archivePath, format, CreateOptions{IncludeSymlinks: true}); err != nil {
+				t.Fatal(err)
+			}
+			if entries, err := em.listCpio(archivePath, format == "cpio.gz"); err != nil || len(entries) == 0 {
+				t.Fatalf("cpio -t: %q, %v", entries, err)
+			}
+
+			outputDir := t.TempDir()
+			if err := em.ExtractWithOptions(archivePath, outputDir, DefaultExtractOptions()); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := treeSnapshot(t, filepath.Join(outputDir, "tree")), treeSnapshot(t, src); !reflect.DeepEqual(got, want) {
+				t.Errorf("содержимое после распаковки отличается:\n%v\n%v", got, want)
+			}
+		})
+	}
+}
+
+func TestCpioGzipRoundTripUsesRunner(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "init")
+	if err := os.WriteFile(file, []byte("#!/bin/sh\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Вывод cpio -o подменяется; встроенный gzip должен вернуть его cpio без изменений
+	payload := "070701" + strings.Repeat("0", 104) + "init\x00TRAILER!!!"
+	fake := runner.NewFakeRunner().
+		On("cpio -o -H newc --quiet", payload, nil).
+		On("cpio -t --quiet", "init\n", nil)
+	em := &ExtractManager{Runner: fake}
+
+	archivePath := filepath.Join(dir, "initrd.cpio.gz")
+	if err := em.CreateArchiveWithOptions([]string{file}, archivePath, "cpio.gz", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := em.listCpio(archivePath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(entries, []string{"init"}) {
+		t.Errorf("записи %q", entries)
+	}
+	outputDir := t.TempDir()
+	if err := em.extractCpio(context.Background(), archivePath, outputDir, true); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"cpio -o -H newc --quiet",
+		"cpio -t --quiet",
+		"cpio -idm --quiet --no-absolute-filenames",
+	}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, want) {
+		t.Fatalf("команды %q, ожидались %q", commands, want)
+	}
+	if stdin := fake.Calls[0].Stdin; stdin != file+"\n" {
+		t.Errorf("cpio -o получил список %q", stdin)
+	}
+	for _, call := range fake.Calls[1:] {
+		if call.Stdin != payload {
+			t.Errorf("%s получил %q вместо распакованного архива", call, call.Stdin)
+		}
+	}
+}
+
+func TestCpioWithoutTool(t *testing.T) {
+	dir := t.TempDir()
+	em := &ExtractManager{Runner: missingRunner("cpio")}
+	if err := em.CreateArchive([]string{dir}, filepath.Join(dir, "out.cpio"), "cpio"); err == nil {
+		t.Error("без cpio создание архива должно завершаться ошибкой")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out.cpio")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("оставлен архив: %v", err)
+	}
+}
+
+// hangingRunner - исполнитель, команды которого не завершаются до отмены ctx
+type hangingRunner struct {
+	*runner.FakeRunner
+}
+
+func (r hangingRunner) OutputContext(ctx context.Context, name string, args ...string) ([]byte, error) {
+	_, _ = r.FakeRunner.OutputContext(ctx, name, args...)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (r hangingRunner) RunIO(ctx context.Context, streams runner.Streams, name string, args ...string) error {
+	_ = r.FakeRunner.RunIO(ctx, streams, name, args...)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// cancelOnWrite отменяет извлечение при первой записи распакованных данных
+type cancelOnWrite struct {
+	cancel context.CancelFunc
+}
+
+func (c cancelOnWrite) Write(p []byte) (int, error) {
+	c.cancel()
+	return len(p), nil
+}
+
+func TestExtractContextCancelled(t *testing.T) {
+	archivePath := tarFixture(t)
+	outputDir := filepath.Join(t.TempDir(), "out")
+	fake := runner.NewFakeRunner()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := (&ExtractManager{Runner: fake}).ExtractContext(ctx, archivePath, outputDir, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ошибка %v, ожидалась context.Canceled", err)
+	}
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Errorf("отмененное извлечение создало выходную директорию: %v", err)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("отмененное извлечение выполнило команды %q", commands)
+	}
+}
+
+func TestExtractContextStopsExternalTool(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "data.tar.bz2")
+	if err := os.WriteFile(archivePath, []byte("BZh9"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	em := &ExtractManager{Runner: hangingRunner{runner.NewFakeRunner()}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- em.ExtractContext(ctx, archivePath, t.TempDir(), false) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ошибка %v, ожидалась context.DeadlineExceeded", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("извлечение не прервано по истечении ctx")
+	}
+}
+
+func TestExtractContextStopsNativeExtraction(t *testing.T) {
+	var entries []tarEntry
+	for i := 0; i < 20; i++ {
+		entries = append(entries, tarEntry{name: fmt.Sprintf("f%02d.txt", i), typeflag: tar.TypeReg, body: strings.Repeat("x", 4096)})
+	}
+	archivePath := filepath.Join(t.TempDir(), "data.tar")
+	if err := os.WriteFile(archivePath, buildTar(t, entries), 0600); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts := DefaultExtractOptions()
+	opts.progress = cancelOnWrite{cancel}
+	_, err := (&ExtractManager{PreferNative: true, Workers: 1}).extractWithResult(ctx, archivePath, outputDir, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ошибка %v, ожидалась context.Canceled", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "f19.txt")); !os.IsNotExist(err) {
+		t.Errorf("после отмены извлечен последний файл: %v", err)
+	}
+}
+
+// concurrentArchives записывает в dir n корректных tar-архивов data<i>.tar
+func concurrentArchives(t *testing.T, dir string, n int) []string {
+	t.Helper()
+	var archives []string
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("data%d.tar", i))
+		data := buildTar(t, []tarEntry{{name: "file.txt", typeflag: tar.TypeReg, body: fmt.Sprint(i)}})
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		archives = append(archives, path)
+	}
+	return archives
+}
+
+func TestExtractAllConcurrentCollectsErrors(t *testing.T) {
+	dir := t.TempDir()
+	archives := concurrentArchives(t, dir, 4)
+	corrupt := filepath.Join(dir, "corrupt.tar.gz")
+	if err := os.WriteFile(corrupt, []byte("not gzip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.tar")
+	// Неудачные архивы перемежаются с корректными
+	archives = []string{archives[0], corrupt, archives[1], archives[2], missing, archives[3]}
+
+	outputDir := t.TempDir()
+	err := (&ExtractManager{PreferNative: true}).ExtractAllConcurrent(archives, outputDir, 3)
+	if err == nil {
+		t.Fatal("ожидалась ошибка для поврежденного и отсутствующего архивов")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "ошибки в 2 из 6 архивов") {
+		t.Errorf("ошибка не сообщает число неудачных архивов: %v", err)
+	}
+	if i, j := strings.Index(msg, corrupt), strings.Index(msg, missing); i < 0 || j < 0 || i > j {
+		t.Errorf("ошибки перечислены не в порядке списка: %v", err)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("ошибка отсутствующего архива не сохранена: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		checkFiles(t, filepath.Join(outputDir, fmt.Sprintf("data%d", i)), map[string]string{"file.txt": fmt.Sprint(i)})
+	}
+}
+
+func TestExtractAllConcurrentDefaultWorkers(t *testing.T) {
+	for _, workers := range []int{0, -1} {
+		t.Run(fmt.Sprint(workers), func(t *testing.T) {
+			archives := concurrentArchives(t, t.TempDir(), 5)
+			outputDir := t.TempDir()
+			if err := (&ExtractManager{PreferNative: true}).ExtractAllConcurrent(archives, outputDir, workers); err != nil {
+				t.Fatal(err)
+			}
+			for i := range archives {
+				checkFiles(t, filepath.Join(outputDir, fmt.Sprintf("data%d", i)), map[string]string{"file.txt": fmt.Sprint(i)})
+			}
+		})
+	}
+	if err := (&ExtractManager{}).ExtractAllConcurrent(nil, t.TempDir(), 0); err != nil {
+		t.Errorf("пустой список: %v", err)
+	}
+}
+
+func TestExtractAllConcurrentContextCancelled(t *testing.T) {
+	archives := concurrentArchives(t, t.TempDir(), 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	outputDir := t.TempDir()
+	err := (&ExtractManager{PreferNative: true}).ExtractAllConcurrentContext(ctx, archives, outputDir, 2)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ошибка %v, ожидалась context.Canceled", err)
+	}
+	if entries, _ := os.ReadDir(outputDir); len(entries) != 0 {
+		t.Errorf("после отмены извлечены архивы: %v", entries)
+	}
+}
+
+func TestExtractAllConcurrentContextStopsRunning(t *testing.T) {
+	dir := t.TempDir()
+	var archives []string
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("data%d.tar.bz2", i))
+		if err := os.WriteFile(path, []byte("BZh9"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		archives = append(archives, path)
+	}
+	em := &ExtractManager{Runner: hangingRunner{runner.NewFakeRunner()}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- em.ExtractAllConcurrentContext(ctx, archives, t.TempDir(), 2) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ошибка %v, ожидалась context.DeadlineExceeded", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("параллельное извлечение не прервано по истечении ctx")
+	}
+}
+
+// captureStdout возвращает вывод fn в stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	fn()
+	_ = w.Close()
+	return <-done
+}
+
+func TestExtractFunctionWith(t *testing.T) {
+	archivePath := tarFixture(t)
+	outputDir := filepath.Join(t.TempDir(), "out")
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "file.txt"), []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Параметры задаются один раз: директория, индикатор и политика конфликтов
+	var err error
+	extract := ExtractFunctionWith(ExtractOptions{OutputDir: outputDir, ShowProgress: true, OnConflict: ConflictSkip})
+	out := captureStdout(t, func() { err = extract(archivePath) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "Извлечение архива") {
+		t.Errorf("при ShowProgress нет индикатора в выводе %q", out)
+	}
+	checkFiles(t, outputDir, map[string]string{"file.txt": "old"})
+
+	extract = ExtractFunctionWith(ExtractOptions{OutputDir: outputDir, OnConflict: ConflictOverwrite})
+	out = captureStdout(t, func() { err = extract(archivePath) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("без ShowProgress выведено %q", out)
+	}
+	checkFiles(t, outputDir, map[string]string{"file.txt": "data"})
+
+	// Без OutputDir архив извлекается в директорию по умолчанию рядом с ним
+	if err := ExtractFunctionWith(ExtractOptions{})(archivePath); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, filepath.Join(filepath.Dir(archivePath), "data"), map[string]string{"file.txt": "data"})
+}
@@ -0,0 +1,145 @@
+package archive
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileRecorder собирает пути файлов и ссылок, записанных при извлечении в root.
+// Воркеры встроенного извлечения пишут в него параллельно; nil ничего не записывает.
+type fileRecorder struct {
+	mu    sync.Mutex
+	root  string
+	paths map[string]bool
+}
+
+func newFileRecorder(root string) *fileRecorder {
+	return &fileRecorder{root: root, paths: make(map[string]bool)}
+}
+
+// add запоминает путь внутри root
+func (r *fileRecorder) add(path string) {
+	if r == nil {
+		return
+	}
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paths[rel] = true
+}
+
+// addTree запоминает все файлы и ссылки внутри path; сам path, если это не директория
+func (r *fileRecorder) addTree(path string) {
+	if r == nil {
+		return
+	}
+	_ = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			r.add(p)
+		}
+		return nil
+	})
+}
+
+// files возвращает записанные пути в алфавитном порядке
+func (r *fileRecorder) files() []string {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	files := make([]string, 0, len(r.paths))
+	for path := range r.paths {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// recordExternal определяет, какие файлы записала внешняя утилита: имена записей
+// архива сопоставляются с тем, что оказалось в outputDir. Для форматов без списка
+// записей (утилита списка недоступна) ничего не записывается.
+func (em *ExtractManager) recordExternal(archiveType, archivePath, outputDir string, opts ExtractOptions) {
+	if opts.recorder == nil {
+		return
+	}
+	var names []string
+	switch archiveType {
+	case "gz", "bz2", "xz", "lz4", "zst", "lzop":
+		names = []string{strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath))}
+	case "cpio", "cpio.gz":
+		names, _ = em.listCpio(archivePath, archiveType == "cpio.gz")
+	case "7z":
+		names = em.list7z(archivePath, opts.Password)
+	case "rar":
+		if output, err := em.runner().Output("unrar", "lb", archivePath); err == nil {
+			names = strings.Split(strings.TrimSpace(string(output)), "\n")
+		}
+	default:
+		names = em.archiveEntries(archivePath)
+	}
+
+	for _, name := range names {
+		if name == "" || !opts.wantsEntry(name) {
+			continue
+		}
+		relative, _ := sanitizeEntry(name)
+		var ok bool
+		if archiveType == "zip" {
+			relative, ok = stripPath(relative, opts.StripComponents)
+		} else {
+			relative, ok = tarStripPath(relative, opts.StripComponents)
+		}
+		if !ok || relative == "" {
+			continue
+		}
+		target, err := safeJoin(outputDir, relative)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Lstat(target); err == nil && !info.IsDir() {
+			opts.recorder.add(target)
+		}
+	}
+}
+
+// list7z возвращает пути записей из технического вывода 7z l -slt
+func (em *ExtractManager) list7z(archivePath, password string) []string {
+	output, err := em.run7z(context.Background(), password, "l", "-slt", archivePath)
+	if err != nil {
+		return nil
+	}
+	// Записи идут после разделителя "----------"; до него - свойства самого архива
+	_, entries, found := strings.Cut(output, "\n----------\n")
+	if !found {
+		return nil
+	}
+	var names []string
+	for _, line := range strings.Split(entries, "\n") {
+		if path, ok := strings.CutPrefix(line, "Path = "); ok {
+			names = append(names, strings.TrimSpace(path))
+		}
+	}
+	return names
+}
+
+// tarStripPath повторяет --strip-components утилиты tar: в отличие от stripPath
+// ведущий "./" считается отдельным компонентом
+func tarStripPath(name string, strip int) (string, bool) {
+	if strip == 0 {
+		return name, true
+	}
+	parts := strings.Split(strings.Trim(name, "/"), "/")
+	if len(parts) <= strip {
+		return "", false
+	}
+	return strings.Join(parts[strip:], "/"), true
+}
@@ -0,0 +1,248 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// EntryType - вид записи архива
+type EntryType string
+
+// Виды записей в манифесте архива
+const (
+	EntryFile     EntryType = "file"
+	EntryDir      EntryType = "dir"
+	EntrySymlink  EntryType = "symlink"
+	EntryHardlink EntryType = "hardlink"
+	EntryOther    EntryType = "other"
+)
+
+// ManifestEntry описывает одну запись архива
+type ManifestEntry struct {
+	Name    string
+	Size    int64
+	Mode    os.FileMode
+	ModTime time.Time
+	Type    EntryType
+	// LinkTarget - цель символической или жесткой ссылки
+	LinkTarget string
+	// Unsafe отмечает запись, которая при наивном извлечении попала бы за пределы
+	// директории: абсолютный путь, "..", ссылка наружу. UnsafeReason объясняет причину
+	Unsafe       bool
+	UnsafeReason string
+}
+
+// ArchiveManifest - содержимое архива без извлечения
+type ArchiveManifest struct {
+	Format  string
+	Entries []ManifestEntry
+	// TotalSize - суммарный размер файлов после извлечения
+	TotalSize int64
+}
+
+// Unsafe возвращает записи, отмеченные как небезопасные
+func (m *ArchiveManifest) Unsafe() []ManifestEntry {
+	var unsafe []ManifestEntry
+	for _, entry := range m.Entries {
+		if entry.Unsafe {
+			unsafe = append(unsafe, entry)
+		}
+	}
+	return unsafe
+}
+
+// Inspect читает заголовки записей архива встроенными средствами, ничего не записывая на диск:
+// имя, размер, права, время изменения, вид записи и цель ссылки. Записи, выходящие за
+// пределы директории извлечения, отмечаются как небезопасные. Поддерживаются tar, tar.gz,
+// tar.bz2, tar.zst и zip.
+func (em *ExtractManager) Inspect(archivePath string) (*ArchiveManifest, error) {
+	format := em.detectArchiveType(archivePath)
+	manifest := &ArchiveManifest{Format: format}
+
+	var err error
+	switch format {
+	case "zip":
+		err = inspectZip(archivePath, manifest)
+	case "tar", "tar.gz", "tgz", "tar.bz2", "tbz2", "tar.zst":
+		err = inspectTarFile(archivePath, format, manifest)
+	default:
+		return nil, fmt.Errorf("формат %s не поддерживается для просмотра", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range manifest.Entries {
+		entry := &manifest.Entries[i]
+		entry.UnsafeReason = unsafeReason(entry)
+		entry.Unsafe = entry.UnsafeReason != ""
+		if entry.Type == EntryFile {
+			manifest.TotalSize += entry.Size
+		}
+	}
+	return manifest, nil
+}
+
+// inspectTarFile читает заголовки tar, распаковывая поток встроенным декодером формата
+func inspectTarFile(archivePath, format string, manifest *ArchiveManifest) error {
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch format {
+	case "tar.gz", "tgz":
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	case "tar.bz2", "tbz2":
+		r = bzip2.NewReader(f)
+	case "tar.zst":
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения zstd: %w", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ошибка чтения архива: %w", err)
+		}
+
+		entry := ManifestEntry{
+			Name:       hdr.Name,
+			Size:       hdr.Size,
+			Mode:       hdr.FileInfo().Mode(),
+			ModTime:    hdr.ModTime,
+			LinkTarget: hdr.Linkname,
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			entry.Type = EntryFile
+		case tar.TypeDir:
+			entry.Type = EntryDir
+		case tar.TypeSymlink:
+			entry.Type = EntrySymlink
+		case tar.TypeLink:
+			entry.Type = EntryHardlink
+		default:
+			entry.Type = EntryOther
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+}
+
+// inspectZip читает центральный каталог zip; цель символической ссылки хранится
+// в содержимом записи и читается только для ссылок
+func inspectZip(archivePath string, manifest *ArchiveManifest) error {
+	zr, err := zip.OpenReader(filepath.Clean(archivePath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		mode := f.Mode()
+		entry := ManifestEntry{
+			Name:    f.Name,
+			Size:    int64(f.UncompressedSize64),
+			Mode:    mode,
+			ModTime: f.Modified,
+		}
+		switch {
+		case mode.IsDir():
+			entry.Type = EntryDir
+		case mode&os.ModeSymlink != 0:
+			entry.Type = EntrySymlink
+			if entry.LinkTarget, err = readZipLink(f); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			entry.Type = EntryFile
+		default:
+			entry.Type = EntryOther
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	return nil
+}
+
+// maxZipLinkSize ограничивает чтение цели ссылки: длиннее PATH_MAX цель быть не может
+const maxZipLinkSize = 4096
+
+func readZipLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipLinkSize))
+	if err != nil {
+		return "", fmt.Errorf("ошибка чтения %s: %w", f.Name, err)
+	}
+	return string(data), nil
+}
+
+// unsafeReason возвращает причину, по которой запись небезопасна, или пустую строку
+func unsafeReason(entry *ManifestEntry) string {
+	name := slashPath(entry.Name)
+	if _, stripped := sanitizeEntry(entry.Name); stripped {
+		return "абсолютный путь"
+	}
+	if escapesRoot(name) {
+		return "выход за пределы директории через .."
+	}
+
+	switch entry.Type {
+	case EntrySymlink:
+		target := slashPath(entry.LinkTarget)
+		if path.IsAbs(target) {
+			return "ссылка на абсолютный путь " + entry.LinkTarget
+		}
+		if escapesRoot(path.Join(path.Dir(name), target)) {
+			return "ссылка за пределы директории: " + entry.LinkTarget
+		}
+	case EntryHardlink:
+		// Цель жесткой ссылки задается от корня архива, а не от директории записи
+		target := slashPath(entry.LinkTarget)
+		if path.IsAbs(target) || escapesRoot(target) {
+			return "жесткая ссылка за пределы директории: " + entry.LinkTarget
+		}
+	}
+	return ""
+}
+
+// slashPath приводит разделители Windows в имени записи к /
+func slashPath(name string) string {
+	return strings.ReplaceAll(name, `\`, "/")
+}
+
+// escapesRoot проверяет, выходит ли относительный путь за пределы корня
+func escapesRoot(name string) bool {
+	clean := path.Clean(name)
+	return clean == ".." || strings.HasPrefix(clean, "../")
+}
@@ -0,0 +1,172 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// craftedEntries - записи tar с безопасными и небезопасными путями и ссылками
+var craftedEntries = []tarEntry{
+	{name: "docs/", typeflag: tar.TypeDir},
+	{name: "docs/readme.txt", typeflag: tar.TypeReg, body: "hello"},
+	{name: "docs/latest", typeflag: tar.TypeSymlink, linkname: "readme.txt"},
+	{name: "docs/copy", typeflag: tar.TypeLink, linkname: "docs/readme.txt"},
+	{name: "../evil.sh", typeflag: tar.TypeReg, body: "rm -rf"},
+	{name: "/etc/cron.d/job", typeflag: tar.TypeReg, body: "* * * * *"},
+	{name: "docs/passwd", typeflag: tar.TypeSymlink, linkname: "/etc/passwd"},
+	{name: "docs/up", typeflag: tar.TypeSymlink, linkname: "../../outside"},
+	{name: "stolen", typeflag: tar.TypeLink, linkname: "../secret"},
+}
+
+// manifestEntry описывает ожидаемую запись манифеста без времени изменения
+type manifestEntry struct {
+	typ    EntryType
+	size   int64
+	target string
+	unsafe bool
+}
+
+var craftedManifest = map[string]manifestEntry{
+	"docs/":           {typ: EntryDir},
+	"docs/readme.txt": {typ: EntryFile, size: 5},
+	"docs/latest":     {typ: EntrySymlink, target: "readme.txt"},
+	"docs/copy":       {typ: EntryHardlink, target: "docs/readme.txt"},
+	"../evil.sh":      {typ: EntryFile, size: 6, unsafe: true},
+	"/etc/cron.d/job": {typ: EntryFile, size: 9, unsafe: true},
+	"docs/passwd":     {typ: EntrySymlink, target: "/etc/passwd", unsafe: true},
+	"docs/up":         {typ: EntrySymlink, target: "../../outside", unsafe: true},
+	"stolen":          {typ: EntryHardlink, target: "../secret", unsafe: true},
+}
+
+func checkManifest(t *testing.T, manifest *ArchiveManifest, want map[string]manifestEntry) {
+	t.Helper()
+	if len(manifest.Entries) != len(want) {
+		t.Fatalf("записей %d, ожидалось %d: %+v", len(manifest.Entries), len(want), manifest.Entries)
+	}
+	for _, entry := range manifest.Entries {
+		w, ok := want[entry.Name]
+		if !ok {
+			t.Errorf("неожиданная запись %q", entry.Name)
+			continue
+		}
+		got := manifestEntry{typ: entry.Type, size: entry.Size, target: entry.LinkTarget, unsafe: entry.Unsafe}
+		if got != w {
+			t.Errorf("%s: %+v, ожидалось %+v", entry.Name, got, w)
+		}
+		if entry.Unsafe && entry.UnsafeReason == "" {
+			t.Errorf("%s: небезопасная запись без причины", entry.Name)
+		}
+	}
+}
+
+func TestInspectTar(t *testing.T) {
+	data := buildTar(t, craftedEntries)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range map[string][]byte{"crafted.tar": data, "crafted.tar.gz": gz.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			archivePath := filepath.Join(dir, name)
+			if err := os.WriteFile(archivePath, content, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			manifest, err := (&ExtractManager{}).Inspect(archivePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkManifest(t, manifest, craftedManifest)
+			if len(manifest.Unsafe()) != 5 {
+				t.Errorf("небезопасных записей %d, ожидалось 5", len(manifest.Unsafe()))
+			}
+			// Учитываются только обычные файлы: readme.txt, evil.sh и job
+			if manifest.TotalSize != 20 {
+				t.Errorf("TotalSize = %d, ожидалось 20", manifest.TotalSize)
+			}
+
+			// Просмотр ничего не записывает рядом с архивом или за его пределами
+			entries, _ := os.ReadDir(dir)
+			if len(entries) != 1 {
+				t.Errorf("в директории архива %d записей, ожидался только архив", len(entries))
+			}
+			if _, err := os.Lstat(filepath.Join(filepath.Dir(dir), "evil.sh")); !os.IsNotExist(err) {
+				t.Errorf("запись ../evil.sh создана при просмотре: %v", err)
+			}
+		})
+	}
+}
+
+func TestInspectZip(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "crafted.zip")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, e := range []struct {
+		name string
+		mode os.FileMode
+		body string
+	}{
+		{"docs/", os.ModeDir | 0755, ""},
+		{"docs/readme.txt", 0644, "hello"},
+		{"docs/latest", os.ModeSymlink | 0777, "readme.txt"},
+		{"docs/passwd", os.ModeSymlink | 0777, "/etc/passwd"},
+		{"../evil.sh", 0755, "rm -rf"},
+	} {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		hdr.SetMode(e.mode)
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	manifest, err := (&ExtractManager{}).Inspect(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkManifest(t, manifest, map[string]manifestEntry{
+		"docs/":           {typ: EntryDir},
+		"docs/readme.txt": {typ: EntryFile, size: 5},
+		"docs/latest":     {typ: EntrySymlink, size: 10, target: "readme.txt"},
+		"docs/passwd":     {typ: EntrySymlink, size: 11, target: "/etc/passwd", unsafe: true},
+		"../evil.sh":      {typ: EntryFile, size: 6, unsafe: true},
+	})
+	for _, entry := range manifest.Entries {
+		if entry.Name == "../evil.sh" && entry.Mode.Perm() != 0755 {
+			t.Errorf("права ../evil.sh %v, ожидалось 0755", entry.Mode.Perm())
+		}
+	}
+}
+
+func TestInspectUnsupportedFormat(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "data.rar")
+	if err := os.WriteFile(archivePath, []byte("Rar!"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&ExtractManager{}).Inspect(archivePath); err == nil {
+		t.Error("ожидалась ошибка для формата без встроенного чтения")
+	}
+}
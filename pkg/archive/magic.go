@@ -0,0 +1,89 @@
+package archive
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Сигнатуры форматов, которые распознаются только по началу файла
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	xzMagic       = []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a}
+	bzip2Magic    = []byte("BZh")
+	sevenZipMagic = []byte{0x37, 0x7a, 0xbc, 0xaf, 0x27, 0x1c}
+)
+
+// tarHeaderSize - размер заголовка записи tar, в котором находится tarMagic
+const tarHeaderSize = 512
+
+// detectByMagic определяет формат файла по сигнатуре: gzip, zip, xz, bzip2, zstd, 7z
+// и tar без сжатия. Для gzip, bzip2 и zstd начало распакованного потока проверяется
+// на заголовок tar, чтобы отличить tar.gz от одиночного сжатого файла. xz встроенно
+// не распаковывается и всегда считается одиночным файлом. Пустая строка означает,
+// что формат не распознан или файл не читается.
+func detectByMagic(filePath string) string {
+	f, err := os.Open(filepath.Clean(filePath))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, tarHeaderSize)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return compressedFormat(f, "gz", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) })
+	case bytes.HasPrefix(head, zstdMagic):
+		return compressedFormat(f, "zst", func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) })
+	case bytes.HasPrefix(head, bzip2Magic):
+		return compressedFormat(f, "bz2", func(r io.Reader) (io.Reader, error) { return bzip2.NewReader(r), nil })
+	case bytes.HasPrefix(head, xzMagic):
+		return "xz"
+	case bytes.HasPrefix(head, zipMagic):
+		return "zip"
+	case bytes.HasPrefix(head, sevenZipMagic):
+		return "7z"
+	case isTarHeader(head):
+		return "tar"
+	}
+	return ""
+}
+
+// compressedFormat возвращает tar.<format>, если распакованный поток начинается
+// с заголовка tar, иначе format
+func compressedFormat(f *os.File, format string, decoder func(io.Reader) (io.Reader, error)) string {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return format
+	}
+	r, err := decoder(f)
+	if err != nil {
+		return format
+	}
+	switch closer := r.(type) {
+	case io.Closer:
+		defer closer.Close()
+	case interface{ Close() }:
+		defer closer.Close()
+	}
+
+	head := make([]byte, tarHeaderSize)
+	n, _ := io.ReadFull(r, head)
+	if isTarHeader(head[:n]) {
+		return "tar." + format
+	}
+	return format
+}
+
+// isTarHeader проверяет наличие сигнатуры ustar в заголовке первой записи tar
+func isTarHeader(head []byte) bool {
+	return len(head) >= tarMagicOffset+len(tarMagic) &&
+		bytes.Equal(head[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic)
+}
@@ -0,0 +1,106 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdBytes сжимает data zstd в памяти
+func zstdBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// zipBytes собирает zip с одним файлом в памяти
+func zipBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectByMagic(t *testing.T) {
+	tarData := buildTar(t, []tarEntry{{name: "a.txt", typeflag: tar.TypeReg, body: "a"}})
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"archive.txt", gzipBytes(t, tarData), "tar.gz"},
+		{"photo.jpg", gzipBytes(t, []byte("plain text")), "gz"},
+		{"backup.bin", zstdBytes(t, tarData), "tar.zst"},
+		{"data.dat", zstdBytes(t, []byte("plain text")), "zst"},
+		{"report.pdf", zipBytes(t), "zip"},
+		{"image.png", append([]byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}, bytes.Repeat([]byte{0}, 32)...), "xz"},
+		// Поврежденный поток после сигнатуры bzip2 считается одиночным сжатым файлом
+		{"notes.doc", []byte("BZh91AY&SY broken stream"), "bz2"},
+		{"setup.exe", []byte{0x37, 0x7a, 0xbc, 0xaf, 0x27, 0x1c, 0x00, 0x04}, "7z"},
+		{"plain.log", tarData, "tar"},
+		{"readme.md", []byte("# not an archive\n"), ""},
+		{"empty", nil, ""},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+			if got := detectByMagic(path); got != tt.want {
+				t.Errorf("detectByMagic(%s) = %q, ожидалось %q", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	if got := detectByMagic(filepath.Join(dir, "missing")); got != "" {
+		t.Errorf("для отсутствующего файла формат %q", got)
+	}
+}
+
+func TestDetectArchiveTypeFallsBackToMagic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "download.tmp")
+	if err := os.WriteFile(path, zipBytes(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+	format, byMagic := (&ExtractManager{}).detectFormat(path)
+	if format != "zip" || !byMagic {
+		t.Errorf("detectFormat() = %q, %v, ожидалось zip по сигнатуре", format, byMagic)
+	}
+
+	// Известное расширение определяет формат без чтения файла
+	path = filepath.Join(dir, "real.tar.gz")
+	if err := os.WriteFile(path, zipBytes(t), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if format, byMagic := (&ExtractManager{}).detectFormat(path); format != "tar.gz" || byMagic {
+		t.Errorf("detectFormat() = %q, %v, ожидалось tar.gz по расширению", format, byMagic)
+	}
+}
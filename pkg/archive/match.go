@@ -0,0 +1,102 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrNoMatches возвращается ExtractMatching, если ни одна запись не подошла под шаблоны
+var ErrNoMatches = errors.New("ни одна запись архива не подходит под шаблоны")
+
+// ExtractMatching извлекает только записи, путь которых подходит хотя бы под один
+// шаблон filepath.Match ("etc/*.conf", "*/README*"). Шаблон сравнивается с именем
+// записи без ведущего "./"; директории создаются по мере извлечения файлов.
+// Поддерживаются tar-архивы и zip. tar получает список подходящих записей,
+// встроенное извлечение и zip пропускают остальные записи сами.
+func (em *ExtractManager) ExtractMatching(archivePath, outputDir string, patterns []string) error {
+	if len(patterns) == 0 {
+		return fmt.Errorf("не заданы шаблоны записей")
+	}
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("некорректный шаблон %q: %w", pattern, err)
+		}
+	}
+
+	format := em.detectArchiveType(archivePath)
+	if format == "unknown" {
+		return fmt.Errorf("неподдерживаемый формат архива: %s", archivePath)
+	}
+	if !supportsStrip(format) {
+		return fmt.Errorf("формат %s не поддерживает выборочное извлечение", format)
+	}
+
+	entries, err := em.entryNames(archivePath, format)
+	if err != nil {
+		return err
+	}
+	members := make(map[string]bool)
+	for _, name := range entries {
+		// Директории не передаются tar: он извлек бы их целиком
+		if strings.HasSuffix(name, "/") {
+			continue
+		}
+		if matchesAny(normalizeEntry(name), patterns) {
+			members[name] = true
+		}
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("%w: %s", ErrNoMatches, strings.Join(patterns, ", "))
+	}
+
+	opts := DefaultExtractOptions()
+	opts.members = members
+	_, err = em.extractWithResult(context.Background(), archivePath, outputDir, opts)
+	return err
+}
+
+// entryNames возвращает имена записей архива так, как они записаны в архиве. Просмотр
+// встроенными средствами не зависит от наличия tar; остальные tar-архивы читает tar -tf
+func (em *ExtractManager) entryNames(archivePath, format string) ([]string, error) {
+	if manifest, err := em.Inspect(archivePath); err == nil {
+		names := make([]string, 0, len(manifest.Entries))
+		for _, entry := range manifest.Entries {
+			names = append(names, entry.Name)
+		}
+		return names, nil
+	}
+	entries := em.archiveEntries(archivePath)
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("не удалось получить список записей архива %s (%s)", archivePath, format)
+	}
+	return entries, nil
+}
+
+// matchesAny проверяет имя по шаблонам; шаблоны уже проверены на корректность
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsEntry сообщает, нужно ли извлекать запись: без ExtractMatching извлекаются все
+func (opts ExtractOptions) wantsEntry(name string) bool {
+	return opts.members == nil || opts.members[name]
+}
+
+// memberNames возвращает имена выбранных записей в стабильном порядке
+func (opts ExtractOptions) memberNames() []string {
+	names := make([]string, 0, len(opts.members))
+	for name := range opts.members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,126 @@
+package archive
+
+import (
+	"archive/tar"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// matchContents - содержимое архивов для выборочного извлечения
+var matchContents = map[string]string{
+	"etc/a.conf":        "a",
+	"etc/b.conf":        "b",
+	"etc/readme.txt":    "readme",
+	"docs/README.md":    "docs",
+	"other/x.conf":      "x",
+	"other/deep/y.conf": "y",
+}
+
+// matchArchives возвращает tar и zip с содержимым matchContents
+func matchArchives(t *testing.T) map[string]string {
+	t.Helper()
+	names := make([]string, 0, len(matchContents))
+	for name := range matchContents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries []tarEntry
+	for _, name := range names {
+		entries = append(entries, tarEntry{name: name, typeflag: tar.TypeReg, body: matchContents[name]})
+	}
+	tarPath := filepath.Join(t.TempDir(), "match.tar")
+	if err := os.WriteFile(tarPath, buildTar(t, entries), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{"tar": tarPath, "zip": buildZip(t, names, matchContents)}
+}
+
+// extractedFiles возвращает пути файлов внутри dir относительно него
+func extractedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestExtractMatching(t *testing.T) {
+	tests := []struct {
+		patterns []string
+		want     []string
+	}{
+		{[]string{"etc/*.conf"}, []string{"etc/a.conf", "etc/b.conf"}},
+		{[]string{"*/README*", "other/x.conf"}, []string{"docs/README.md", "other/x.conf"}},
+		// * не проходит через "/": вложенная директория не выбирается
+		{[]string{"other/*"}, []string{"other/x.conf"}},
+	}
+
+	for format, archivePath := range matchArchives(t) {
+		for _, native := range []bool{false, true} {
+			tool := map[string]string{"tar": "tar", "zip": "unzip"}[format]
+			if _, err := exec.LookPath(tool); !native && err != nil {
+				continue
+			}
+			em := &ExtractManager{PreferNative: native}
+			name := format
+			if native {
+				name += "/native"
+			}
+			t.Run(name, func(t *testing.T) {
+				for _, tt := range tests {
+					outputDir := t.TempDir()
+					if err := em.ExtractMatching(archivePath, outputDir, tt.patterns); err != nil {
+						t.Fatalf("%q: %v", tt.patterns, err)
+					}
+					if got := extractedFiles(t, outputDir); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+						t.Errorf("%q: извлечены %q, ожидалось %q", tt.patterns, got, tt.want)
+					}
+				}
+
+				outputDir := t.TempDir()
+				err := em.ExtractMatching(archivePath, outputDir, []string{"missing/*", "*.exe"})
+				if !errors.Is(err, ErrNoMatches) {
+					t.Fatalf("ошибка %v, ожидалась ErrNoMatches", err)
+				}
+				if files := extractedFiles(t, outputDir); len(files) != 0 {
+					t.Errorf("без совпадений извлечены %q", files)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractMatchingInvalidArguments(t *testing.T) {
+	archivePath := matchArchives(t)["tar"]
+	em := &ExtractManager{PreferNative: true}
+
+	if err := em.ExtractMatching(archivePath, t.TempDir(), nil); err == nil {
+		t.Error("без шаблонов ожидалась ошибка")
+	}
+	if err := em.ExtractMatching(archivePath, t.TempDir(), []string{"etc/[a"}); err == nil || errors.Is(err, ErrNoMatches) {
+		t.Errorf("некорректный шаблон: %v", err)
+	}
+	rarPath := filepath.Join(t.TempDir(), "data.rar")
+	if err := os.WriteFile(rarPath, []byte("Rar!"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := em.ExtractMatching(rarPath, t.TempDir(), []string{"*"}); err == nil {
+		t.Error("для rar ожидалась ошибка выборочного извлечения")
+	}
+}
@@ -0,0 +1,751 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// inlineWriteThreshold - файлы крупнее этого размера пишутся сразу из читающей горутины,
+// чтобы не держать их содержимое в памяти целиком
+const inlineWriteThreshold = 1 << 20
+
+// nativeFormats перечисляет форматы, которые извлекаются встроенными средствами Go
+var nativeFormats = map[string]bool{
+	"tar":     true,
+	"tar.gz":  true,
+	"tar.zst": true,
+	"gz":      true,
+	"zip":     true,
+}
+
+// useNative сообщает, извлекать ли формат встроенными средствами: при PreferNative
+// или если утилиты, которой формат извлекается обычно, нет
+func (em *ExtractManager) useNative(format string) bool {
+	if !nativeFormats[format] {
+		return false
+	}
+	return em.PreferNative || !em.commandExists(formatTools[format])
+}
+
+// extractNative извлекает архив формата из nativeFormats без внешних утилит
+func (em *ExtractManager) extractNative(ctx context.Context, format, archivePath, outputDir string, opts ExtractOptions) error {
+	switch format {
+	case "tar":
+		return em.extractTarFileNative(ctx, archivePath, outputDir, false, opts)
+	case "tar.gz":
+		return em.extractTarFileNative(ctx, archivePath, outputDir, true, opts)
+	case "tar.zst":
+		return em.extractTarZstNative(ctx, archivePath, outputDir, opts)
+	case "gz":
+		return extractGzNative(ctx, archivePath, outputDir, opts)
+	case "zip":
+		return extractZipNative(ctx, archivePath, outputDir, opts, em.newGuard())
+	default:
+		return fmt.Errorf("формат %s не извлекается встроенными средствами", format)
+	}
+}
+
+// checkNative проверяет целостность архива формата из nativeFormats без внешних утилит:
+// gz распаковывается целиком, чтобы сверить контрольную сумму, у остальных читаются заголовки
+func checkNative(archivePath, format string) error {
+	if format != "gz" {
+		_, err := (&ExtractManager{}).Inspect(archivePath)
+		return err
+	}
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения gzip: %w", err)
+	}
+	defer gz.Close()
+	if _, err := io.Copy(io.Discard, gz); err != nil {
+		return fmt.Errorf("ошибка чтения gzip: %w", err)
+	}
+	return nil
+}
+
+// extractGzNative распаковывает одиночный файл .gz встроенным gzip
+func extractGzNative(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения gzip: %w", err)
+	}
+	defer gz.Close()
+
+	outputFile := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(archivePath), ".gz"))
+	if err := writeFileFrom(outputFile, opts.withProgress(contextReader{ctx, gz}), 0600); err != nil {
+		return err
+	}
+	opts.recorder.add(outputFile)
+	return nil
+}
+
+// writeJob описывает отложенную запись файла пулом воркеров
+type writeJob struct {
+	path string
+	mode os.FileMode
+	data []byte
+	// uid и gid записи для PreserveOwnership
+	uid, gid int
+}
+
+// extractTarFileNative извлекает tar или tar.gz без внешней утилиты tar
+func (em *ExtractManager) extractTarFileNative(ctx context.Context, archivePath, outputDir string, gzipped bool, opts ExtractOptions) error {
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = contextReader{ctx, f}
+	if gzipped {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения gzip: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return extractTarNative(opts.withProgress(r), outputDir, em.workers(), opts, em.newGuard())
+}
+
+// extractTarZstNative извлекает tar.zst встроенным декодером zstd
+func (em *ExtractManager) extractTarZstNative(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer f.Close()
+
+	zr, err := zstd.NewReader(contextReader{ctx, f})
+	if err != nil {
+		return fmt.Errorf("ошибка чтения zstd: %w", err)
+	}
+	defer zr.Close()
+
+	return extractTarNative(opts.withProgress(zr), outputDir, em.workers(), opts, em.newGuard())
+}
+
+func (em *ExtractManager) workers() int {
+	if em.Workers > 0 {
+		return em.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// extractTarNative извлекает tar-поток средствами Go.
+// Поток читается последовательно в одной горутине: директории создаются сразу,
+// а содержимое небольших файлов передается пулу из workers горутин на запись.
+// Из имен записей и целей жестких ссылок удаляются абсолютные префиксы
+// и opts.StripComponents ведущих компонентов. Записи и ссылки за пределами outputDir
+// обрабатываются по политике guard.
+func extractTarNative(r io.Reader, outputDir string, workers int, opts ExtractOptions, guard *entryGuard) error {
+	var (
+		mu       sync.Mutex
+		firstErr error
+		pending  sync.WaitGroup
+		pool     sync.WaitGroup
+	)
+
+	setErr := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	failed := func() error {
+		mu.Lock()
+		defer mu.Unlock()
+		return firstErr
+	}
+
+	var jobs chan writeJob
+	if workers > 1 {
+		jobs = make(chan writeJob, workers)
+		for i := 0; i < workers; i++ {
+			pool.Add(1)
+			go func() {
+				defer pool.Done()
+				for job := range jobs {
+					if err := os.WriteFile(job.path, job.data, job.mode); err != nil {
+						setErr(fmt.Errorf("ошибка записи %s: %w", job.path, err))
+					} else if err := restoreAttrs(job.path, job.mode, job.uid, job.gid, opts); err != nil {
+						setErr(err)
+					} else {
+						opts.recorder.add(job.path)
+					}
+					pending.Done()
+				}
+			}()
+		}
+	}
+
+	// queued - пути файлов, переданных воркерам. Повторная запись того же пути
+	// дожидается воркеров, чтобы, как в tar, побеждала последняя запись архива
+	queued := make(map[string]bool)
+	drain := func() {
+		pending.Wait()
+		clear(queued)
+	}
+
+	// Права и владелец директорий восстанавливаются после извлечения их содержимого:
+	// директория без права записи не позволила бы создать в ней файлы
+	var dirs []writeJob
+
+	tr := tar.NewReader(r)
+	for failed() == nil {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			setErr(fmt.Errorf("ошибка чтения tar: %w", err))
+			break
+		}
+		if !opts.wantsEntry(hdr.Name) {
+			continue
+		}
+
+		target, ok, err := entryTarget(outputDir, hdr.Name, opts)
+		if err != nil {
+			if err := guard.reject(hdr.Name, err); err != nil {
+				setErr(err)
+				break
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		// Путь проверяется и после разрешения уже созданных ссылок: после a/b -> ..
+		// и a/b/c -> .. запись a/b/c/x лексически внутри outputDir, а на деле - снаружи.
+		// Сама ссылка при создании заменяется, а не открывается, поэтому для ссылок
+		// проверяется директория
+		checkPath := target
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+			checkPath = filepath.Dir(target)
+		}
+		if err := resolveInside(outputDir, checkPath); err != nil {
+			if err := guard.reject(hdr.Name, err); err != nil {
+				setErr(err)
+				break
+			}
+			continue
+		}
+		mode := hdr.FileInfo().Mode().Perm()
+		if queued[target] {
+			drain()
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			// Директории создаются до отправки дочерних файлов воркерам
+			if err := os.MkdirAll(target, 0750); err != nil {
+				setErr(fmt.Errorf("ошибка создания директории: %w", err))
+			}
+			dirs = append(dirs, writeJob{path: target, mode: mode, uid: hdr.Uid, gid: hdr.Gid})
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+				setErr(fmt.Errorf("ошибка создания директории: %w", err))
+				break
+			}
+			if jobs == nil || hdr.Size > inlineWriteThreshold {
+				if err := writeFileFrom(target, tr, mode); err != nil {
+					setErr(err)
+				} else if err := restoreAttrs(target, mode, hdr.Uid, hdr.Gid, opts); err != nil {
+					setErr(err)
+				} else {
+					opts.recorder.add(target)
+				}
+				break
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				setErr(fmt.Errorf("ошибка чтения %s: %w", hdr.Name, err))
+				break
+			}
+			pending.Add(1)
+			queued[target] = true
+			jobs <- writeJob{path: target, mode: mode, data: data, uid: hdr.Uid, gid: hdr.Gid}
+		case tar.TypeSymlink:
+			// Ссылка может заменить пустую директорию, в которую воркер еще пишет файл
+			drain()
+			if err := createSymlink(outputDir, target, hdr.Linkname); err != nil {
+				if err := guard.reject(hdr.Name, err); err != nil {
+					setErr(err)
+				}
+			} else if err := restoreAttrs(target, os.ModeSymlink, hdr.Uid, hdr.Gid, opts); err != nil {
+				setErr(err)
+			} else {
+				opts.recorder.add(target)
+			}
+		case tar.TypeLink:
+			// Жесткая ссылка может указывать на файл, который еще пишется воркером
+			drain()
+			source, ok, err := entryTarget(outputDir, hdr.Linkname, opts)
+			if err != nil {
+				if err := guard.reject(hdr.Name, err); err != nil {
+					setErr(err)
+				}
+				break
+			}
+			if !ok {
+				break
+			}
+			if err := resolveInside(outputDir, source); err != nil {
+				if err := guard.reject(hdr.Name, err); err != nil {
+					setErr(err)
+				}
+				break
+			}
+			_ = os.Remove(target)
+			if err := os.Link(source, target); err != nil {
+				setErr(fmt.Errorf("ошибка создания ссылки %s: %w", hdr.Name, err))
+			} else {
+				opts.recorder.add(target)
+			}
+		}
+	}
+
+	if jobs != nil {
+		close(jobs)
+		pool.Wait()
+	}
+	for i := len(dirs) - 1; i >= 0 && failed() == nil; i-- {
+		if err := restoreAttrs(dirs[i].path, dirs[i].mode, dirs[i].uid, dirs[i].gid, opts); err != nil {
+			setErr(err)
+		}
+	}
+
+	if err := failed(); err != nil {
+		return err
+	}
+	return guard.err()
+}
+
+// extractZipNative извлекает zip средствами Go, удаляя абсолютные префиксы
+// и opts.StripComponents ведущих компонентов путей. Записи за пределами outputDir
+// обрабатываются по политике guard.
+func extractZipNative(ctx context.Context, archivePath, outputDir string, opts ExtractOptions, guard *entryGuard) error {
+	zr, err := zip.OpenReader(filepath.Clean(archivePath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !opts.wantsEntry(f.Name) {
+			continue
+		}
+		target, ok, err := entryTarget(outputDir, f.Name, opts)
+		if err != nil {
+			if err := guard.reject(f.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if err := resolveInside(outputDir, target); err != nil {
+			if err := guard.reject(f.Name, err); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0750); err != nil {
+				return fmt.Errorf("ошибка создания директории: %w", err)
+			}
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+			return fmt.Errorf("ошибка создания директории: %w", err)
+		}
+		rc, err := openZipEntry(f, opts.Password)
+		if err != nil {
+			return err
+		}
+		err = writeFileFrom(target, opts.withProgress(contextReader{ctx, rc}), f.Mode().Perm())
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		// zip не хранит владельца: восстанавливаются только права
+		if !opts.DiscardPermissions {
+			if err := os.Chmod(target, f.Mode().Perm()); err != nil {
+				return fmt.Errorf("ошибка смены прав %s: %w", target, err)
+			}
+		}
+		opts.recorder.add(target)
+	}
+	return guard.err()
+}
+
+// contextReader прерывает чтение при отмене ctx: встроенное извлечение
+// останавливается на следующем блоке архива
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+// writeFileFrom записывает содержимое reader в файл
+func writeFileFrom(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла %s: %w", path, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("ошибка записи %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// createSymlink создает символическую ссылку, не позволяя ей указывать за пределы outputDir
+func createSymlink(outputDir, target, linkname string) error {
+	if filepath.IsAbs(linkname) {
+		return fmt.Errorf("%w: ссылка %s -> %s", ErrUnsafeEntry, target, linkname)
+	}
+	if _, err := safeJoin(outputDir, filepath.Join(relDir(outputDir, target), linkname)); err != nil {
+		return fmt.Errorf("%w: ссылка %s -> %s", ErrUnsafeEntry, target, linkname)
+	}
+	// Цель разрешается от фактической директории ссылки: путь к ней может проходить
+	// через ссылки, созданные ранее, и лексическая проверка выше этого не видит
+	if err := resolveInside(outputDir, filepath.Dir(target)+string(os.PathSeparator)+linkname); err != nil {
+		return fmt.Errorf("%w: ссылка %s -> %s", ErrUnsafeEntry, target, linkname)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0750); err != nil {
+		return fmt.Errorf("ошибка создания директории: %w", err)
+	}
+	_ = os.Remove(target)
+	return os.Symlink(linkname, target)
+}
+
+// relDir возвращает директорию target относительно outputDir
+func relDir(outputDir, target string) string {
+	rel, err := filepath.Rel(outputDir, filepath.Dir(target))
+	if err != nil {
+		return "."
+	}
+	return rel
+}
+
+// entryTarget возвращает путь извлечения записи name внутри outputDir.
+// Абсолютный префикс удаляется, чтобы запись вида /etc/passwd попала в outputDir/etc/passwd;
+// в режиме Verbose об этом выводится сообщение. false означает, что запись пропускается.
+func entryTarget(outputDir, name string, opts ExtractOptions) (string, bool, error) {
+	relative, stripped := sanitizeEntry(name)
+	if stripped && opts.Verbose {
+		fmt.Printf("Удален абсолютный префикс пути: %s -> %s\n", name, relative)
+	}
+	relative, ok := stripPath(relative, opts.StripComponents)
+	if !ok || relative == "" {
+		return "", false, nil
+	}
+	target, err := safeJoin(outputDir, relative)
+	if err != nil {
+		return "", false, err
+	}
+	return target, true, nil
+}
+
+// safeJoin объединяет outputDir и имя записи, отклоняя выход за пределы outputDir
+func safeJoin(outputDir, name string) (string, error) {
+	target := filepath.Join(outputDir, name)
+	base := filepath.Clean(outputDir)
+	if target != base && !strings.HasPrefix(target, base+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafeEntry, name)
+	}
+	return target, nil
+}
+
+// maxSymlinkHops - предел переходов по ссылкам при разрешении пути, как MAXSYMLINKS в Linux
+const maxSymlinkHops = 40
+
+// resolveInside проверяет, что path после разрешения символических ссылок
+// остается внутри outputDir
+func resolveInside(outputDir, path string) error {
+	root, err := resolvePath(outputDir)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrUnsafeEntry, path, err)
+	}
+	if resolved != root && !strings.HasPrefix(resolved, root+string(os.PathSeparator)) {
+		return fmt.Errorf("%w: %s ведет в %s", ErrUnsafeEntry, path, resolved)
+	}
+	return nil
+}
+
+// resolvePath разрешает символические ссылки в path по компонентам, как ядро при открытии
+// файла: ".." после ссылки ведет к родителю ее цели. В отличие от filepath.EvalSymlinks
+// несуществующие компоненты допускаются и остаются как есть. path не очищается заранее:
+// filepath.Clean сократил бы "ссылка/.." лексически
+func resolvePath(path string) (string, error) {
+	sep := string(os.PathSeparator)
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("ошибка определения текущей директории: %w", err)
+		}
+		path = wd + sep + path
+	}
+
+	resolved := sep
+	pending := strings.Split(path, sep)
+	for hops := 0; len(pending) > 0; {
+		name := pending[0]
+		pending = pending[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, name)
+		info, err := os.Lstat(next)
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			// Несуществующий компонент не может быть ссылкой
+			resolved = next
+			continue
+		}
+		if hops++; hops > maxSymlinkHops {
+			return "", fmt.Errorf("слишком много символических ссылок: %s", path)
+		}
+		link, err := os.Readlink(next)
+		if err != nil {
+			return "", fmt.Errorf("ошибка чтения ссылки %s: %w", next, err)
+		}
+		if filepath.IsAbs(link) {
+			resolved = sep
+		}
+		pending = append(strings.Split(link, sep), pending...)
+	}
+	return resolved, nil
+}
+
+// createTarNative создает tar средствами Go, записывая архив потоком в файл
+// и сжимая его встроенным кодеком codec (см. nativeCodecs)
+func createTarNative(files []string, outputPath, codec string, opts CreateOptions) error {
+	out, err := os.OpenFile(filepath.Clean(outputPath), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("ошибка создания архива: %w", err)
+	}
+
+	cw, err := nativeCompressWriter(codec, out, opts)
+	if err != nil {
+		_ = out.Close()
+		return fmt.Errorf("ошибка настройки сжатия: %w", err)
+	}
+	tw := tar.NewWriter(cw)
+
+	err = walkFilesMode(files, opts.symlinks(), func(path, name string, info os.FileInfo) error {
+		return addTarEntry(tw, path, name, info)
+	})
+	if err == nil {
+		err = tw.Close()
+	}
+	if cerr := cw.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("ошибка сжатия архива: %w", cerr)
+	}
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("ошибка записи архива: %w", cerr)
+	}
+	return err
+}
+
+func addTarEntry(tw *tar.Writer, path, name string, info os.FileInfo) error {
+	var link string
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения ссылки %s: %w", path, err)
+		}
+		link = target
+	}
+
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return fmt.Errorf("ошибка заголовка %s: %w", path, err)
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("ошибка записи заголовка %s: %w", path, err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	return copyFileTo(tw, path)
+}
+
+// createZipNative создает zip-архив средствами Go
+func createZipNative(files []string, outputPath string, level int, links symlinkMode) error {
+	out, err := os.OpenFile(filepath.Clean(outputPath), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("ошибка создания архива: %w", err)
+	}
+	zw := zip.NewWriter(out)
+	if level > 0 {
+		zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		})
+	}
+
+	err = walkFilesMode(files, links, func(path, name string, info os.FileInfo) error {
+		return addZipEntry(zw, path, name, info)
+	})
+	if err == nil {
+		err = zw.Close()
+	}
+	if cerr := out.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("ошибка записи архива: %w", cerr)
+	}
+	return err
+}
+
+func addZipEntry(zw *zip.Writer, path, name string, info os.FileInfo) error {
+	// Ссылка сохраняется как zip -y: запись с режимом ссылки, содержимое - путь назначения
+	if info.Mode()&os.ModeSymlink != 0 {
+		return addZipSymlink(zw, path, name, info)
+	}
+	if !info.IsDir() && !info.Mode().IsRegular() {
+		return nil
+	}
+
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("ошибка заголовка %s: %w", path, err)
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	} else {
+		hdr.Method = zip.Deflate
+	}
+
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return fmt.Errorf("ошибка записи заголовка %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return nil
+	}
+	return copyFileTo(w, path)
+}
+
+func addZipSymlink(zw *zip.Writer, path, name string, info os.FileInfo) error {
+	target, err := os.Readlink(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения ссылки %s: %w", path, err)
+	}
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("ошибка заголовка %s: %w", path, err)
+	}
+	hdr.Name = name
+	hdr.Method = zip.Store
+	w, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return fmt.Errorf("ошибка записи заголовка %s: %w", path, err)
+	}
+	if _, err := io.WriteString(w, target); err != nil {
+		return fmt.Errorf("ошибка записи %s в архив: %w", path, err)
+	}
+	return nil
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(w, f); err != nil {
+		return fmt.Errorf("ошибка записи %s в архив: %w", path, err)
+	}
+	return nil
+}
+
+// walkFiles обходит файлы и директории без перехода по символическим ссылкам.
+// Имена записей повторяют переданные пути без ведущих "/" и "../", как это делает tar.
+func walkFiles(files []string, fn func(path, name string, info os.FileInfo) error) error {
+	for _, root := range files {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return fmt.Errorf("ошибка чтения %s: %w", path, err)
+			}
+			name := entryName(path)
+			if name == "" {
+				return nil
+			}
+			return fn(path, name, info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryName преобразует путь файла в имя записи архива
+func entryName(path string) string {
+	name := filepath.ToSlash(filepath.Clean(path))
+	for {
+		switch {
+		case strings.HasPrefix(name, "/"):
+			name = strings.TrimPrefix(name, "/")
+		case strings.HasPrefix(name, "../"):
+			name = strings.TrimPrefix(name, "../")
+		case name == "." || name == "..":
+			return ""
+		default:
+			return name
+		}
+	}
+}
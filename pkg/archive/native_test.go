@@ -0,0 +1,532 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// tarEntry - запись тестового tar-архива
+type tarEntry struct {
+	name     string
+	typeflag byte
+	body     string
+	linkname string
+}
+
+// buildTar собирает tar-архив в памяти
+func buildTar(t testing.TB, entries []tarEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Linkname: e.linkname, Mode: 0644}
+		switch e.typeflag {
+		case tar.TypeDir:
+			hdr.Mode = 0755
+		case tar.TypeReg:
+			hdr.Size = int64(len(e.body))
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractTarNativeSymlinkChainEscape(t *testing.T) {
+	archive := buildTar(t, []tarEntry{
+		{name: "a/", typeflag: tar.TypeDir},
+		{name: "a/b", typeflag: tar.TypeSymlink, linkname: ".."},
+		{name: "a/b/c", typeflag: tar.TypeSymlink, linkname: ".."},
+		{name: "a/b/c/x", typeflag: tar.TypeReg, body: "escaped"},
+	})
+
+	for _, skip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("skip=%v", skip), func(t *testing.T) {
+			parent := t.TempDir()
+			outputDir := filepath.Join(parent, "out")
+			if err := os.Mkdir(outputDir, 0750); err != nil {
+				t.Fatal(err)
+			}
+
+			err := extractTarNative(bytes.NewReader(archive), outputDir, 1, ExtractOptions{}, &entryGuard{skip: skip})
+			if !errors.Is(err, ErrUnsafeEntry) {
+				t.Fatalf("ожидалась ErrUnsafeEntry, получено %v", err)
+			}
+			if _, err := os.Lstat(filepath.Join(parent, "x")); err == nil {
+				t.Fatal("файл записан за пределами директории извлечения")
+			}
+			if _, err := os.Lstat(filepath.Join(parent, "c")); err == nil {
+				t.Fatal("ссылка создана за пределами директории извлечения")
+			}
+			var skipped *SkippedEntriesError
+			if skip && (!errors.As(err, &skipped) || !reflect.DeepEqual(skipped.Entries, []string{"a/b/c"})) {
+				t.Errorf("пропущены %v, ожидалось [a/b/c]", err)
+			}
+		})
+	}
+}
+
+func TestExtractTarNativeDuplicateEntriesLastWins(t *testing.T) {
+	large := strings.Repeat("L", inlineWriteThreshold+1)
+	tests := map[string][]tarEntry{
+		// Небольшие записи уходят воркерам и могут завершиться в любом порядке
+		"small": {
+			{name: "f", typeflag: tar.TypeReg, body: "first"},
+			{name: "f", typeflag: tar.TypeReg, body: "second"},
+			{name: "f", typeflag: tar.TypeReg, body: "last"},
+		},
+		// Крупная запись пишется сразу и не должна быть перезаписана очередью
+		"large": {
+			{name: "f", typeflag: tar.TypeReg, body: "queued"},
+			{name: "f", typeflag: tar.TypeReg, body: large},
+		},
+		"large then small": {
+			{name: "f", typeflag: tar.TypeReg, body: large},
+			{name: "f", typeflag: tar.TypeReg, body: "queued"},
+			{name: "f", typeflag: tar.TypeReg, body: large + "!"},
+		},
+	}
+	for name, entries := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Другие записи занимают воркеров, чтобы запись f задержалась в очереди
+			var archiveEntries []tarEntry
+			for i := 0; i < 16; i++ {
+				archiveEntries = append(archiveEntries, tarEntry{name: fmt.Sprintf("other%d", i), typeflag: tar.TypeReg, body: "x"})
+				if i < len(entries) {
+					archiveEntries = append(archiveEntries, entries[i])
+				}
+			}
+			archive := buildTar(t, archiveEntries)
+			want := entries[len(entries)-1].body
+
+			for run := 0; run < 30; run++ {
+				outputDir := t.TempDir()
+				if err := extractTarNative(bytes.NewReader(archive), outputDir, 8, ExtractOptions{}, nil); err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(filepath.Join(outputDir, "f"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(data) != want {
+					t.Fatalf("запуск %d: записано %d байт %.10q, ожидалась последняя запись архива (%d байт)",
+						run, len(data), data, len(want))
+				}
+			}
+		})
+	}
+}
+
+func TestExtractTarNativeRejectsExistingSymlinkOutside(t *testing.T) {
+	parent := t.TempDir()
+	outside := filepath.Join(parent, "outside")
+	outputDir := filepath.Join(parent, "out")
+	for _, dir := range []string{outside, outputDir} {
+		if err := os.Mkdir(dir, 0750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(outputDir, "evil")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []tarEntry{
+		{name: "evil/x", typeflag: tar.TypeReg, body: "escaped"},
+		{name: "h", typeflag: tar.TypeLink, linkname: "evil/secret"},
+	}
+	for _, entry := range tests {
+		t.Run(entry.name, func(t *testing.T) {
+			archive := buildTar(t, []tarEntry{entry})
+			err := extractTarNative(bytes.NewReader(archive), outputDir, 1, ExtractOptions{}, nil)
+			if !errors.Is(err, ErrUnsafeEntry) {
+				t.Fatalf("ожидалась ErrUnsafeEntry, получено %v", err)
+			}
+		})
+	}
+	if _, err := os.Lstat(filepath.Join(outside, "x")); err == nil {
+		t.Error("файл записан через существующую ссылку наружу")
+	}
+	if _, err := os.Lstat(filepath.Join(outputDir, "h")); err == nil {
+		t.Error("создана жесткая ссылка на файл за пределами директории")
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "d", "e"), 0750); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"up": "..", "d/e/top": "../..", "loop": "loop"} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path, want string
+	}{
+		{root + "/d/e/top/d", real + "/d"},
+		{root + "/up", filepath.Dir(real)},
+		{root + "/d/e/top/missing/../d", real + "/d"},
+		{root + "/d/e/top/..", filepath.Dir(real)},
+	}
+	for _, tt := range tests {
+		got, err := resolvePath(tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("resolvePath(%q) = %q, %v; ожидалось %q", tt.path, got, err, tt.want)
+		}
+	}
+	if _, err := resolvePath(root + "/loop/x"); err == nil {
+		t.Error("цикл ссылок должен давать ошибку")
+	}
+}
+
+// sampleTree создает дерево с директориями, файлами и ссылками
+func sampleTree(t testing.TB, root string, files int) {
+	t.Helper()
+	for i := 0; i < files; i++ {
+		path := filepath.Join(root, fmt.Sprintf("dir%d", i%10), fmt.Sprintf("sub%d", i%3), fmt.Sprintf("file%d.txt", i))
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, bytes.Repeat([]byte{byte(i)}, 100+i), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("dir0/sub0/file0.txt", filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("../dir1", filepath.Join(root, "dir0", "sibling")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(root, "dir0", "sub0", "file0.txt"), filepath.Join(root, "hard")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// treeSnapshot описывает дерево: тип и права записей, содержимое файлов и цели ссылок
+func treeSnapshot(t *testing.T, root string) map[string]string {
+	t.Helper()
+	snapshot := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			snapshot[rel] = "link:" + target
+		case info.IsDir():
+			snapshot[rel] = "dir"
+		default:
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			snapshot[rel] = fmt.Sprintf("file:%v:%x", info.Mode().Perm(), data)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+func TestExtractTarNativeMatchesTar(t *testing.T) {
+	if _, err := exec.LookPath("tar"); err != nil {
+		t.Skip("tar не установлен")
+	}
+	src := t.TempDir()
+	sampleTree(t, src, 60)
+	archivePath := filepath.Join(t.TempDir(), "tree.tar")
+	if output, err := exec.Command("tar", "-cf", archivePath, "-C", src, ".").CombinedOutput(); err != nil {
+		t.Fatalf("tar: %v: %s", err, output)
+	}
+
+	byTar := t.TempDir()
+	if output, err := exec.Command("tar", "-xf", archivePath, "-C", byTar).CombinedOutput(); err != nil {
+		t.Fatalf("tar: %v: %s", err, output)
+	}
+	for _, workers := range []int{1, 8} {
+		native := t.TempDir()
+		f, err := os.Open(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = extractTarNative(f, native, workers, ExtractOptions{}, nil)
+		_ = f.Close()
+		if err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		if got, want := treeSnapshot(t, native), treeSnapshot(t, byTar); !reflect.DeepEqual(got, want) {
+			t.Errorf("workers=%d: результат отличается от tar:\n%v\n%v", workers, got, want)
+		}
+	}
+}
+
+func BenchmarkExtractTarNative(b *testing.B) {
+	src := b.TempDir()
+	sampleTree(b, src, 500)
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.AddFS(os.DirFS(src)); err != nil {
+		b.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		b.Fatal(err)
+	}
+	archive := buf.Bytes()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		outputDir := filepath.Join(b.TempDir(), "out")
+		b.StartTimer()
+		if err := extractTarNative(bytes.NewReader(archive), outputDir, 4, ExtractOptions{}, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// buildZip записывает zip-архив с файлами entries (имя -> содержимое) в порядке names
+func buildZip(t *testing.T, names []string, entries map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for _, name := range names {
+		// Заголовок создается вручную: zip.Writer.Create не проверяет имена записей
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entries[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSanitizeEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		want     string
+		stripped bool
+	}{
+		{"etc/passwd", "etc/passwd", false},
+		{"/etc/passwd", "etc/passwd", true},
+		{"///usr/local/bin/foo", "usr/local/bin/foo", true},
+		{`C:\Windows\win.ini`, `Windows\win.ini`, true},
+		{"c:/temp/x", "temp/x", true},
+		{"C:relative", "relative", true},
+		{`\\server\share\dir\file`, `dir\file`, true},
+		{`\\server`, "", true},
+		{"./dir/file", "./dir/file", false},
+	}
+	for _, tt := range tests {
+		got, stripped := sanitizeEntry(tt.name)
+		if got != tt.want || stripped != tt.stripped {
+			t.Errorf("sanitizeEntry(%q) = %q, %v, ожидалось %q, %v", tt.name, got, stripped, tt.want, tt.stripped)
+		}
+	}
+}
+
+// checkAbsoluteEntryExtracted проверяет, что запись /etc/passwd попала в outputDir,
+// а настоящий /etc/passwd не изменился
+func checkAbsoluteEntryExtracted(t *testing.T, outputDir string, before []byte) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(outputDir, "etc", "passwd"))
+	if err != nil || string(data) != "root:x:0:0::/root:/bin/sh\n" {
+		t.Fatalf("outputDir/etc/passwd = %q, %v", data, err)
+	}
+	after, _ := os.ReadFile("/etc/passwd")
+	if !bytes.Equal(before, after) {
+		t.Fatal("извлечение изменило настоящий /etc/passwd")
+	}
+}
+
+func TestExtractTarNativeAbsolutePath(t *testing.T) {
+	before, _ := os.ReadFile("/etc/passwd")
+	archive := buildTar(t, []tarEntry{
+		{name: "/etc/", typeflag: tar.TypeDir},
+		{name: "/etc/passwd", typeflag: tar.TypeReg, body: "root:x:0:0::/root:/bin/sh\n"},
+		{name: "/usr/local/bin/foo", typeflag: tar.TypeReg, body: "foo"},
+	})
+
+	outputDir := t.TempDir()
+	em := &ExtractManager{Runner: runner.NewFakeRunner()}
+	if err := em.ExtractStream(bytes.NewReader(archive), "tar", outputDir, ExtractOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	checkAbsoluteEntryExtracted(t, outputDir, before)
+	if _, err := os.Stat(filepath.Join(outputDir, "usr", "local", "bin", "foo")); err != nil {
+		t.Errorf("запись /usr/local/bin/foo не извлечена в outputDir: %v", err)
+	}
+}
+
+func TestExtractTarNativeAbsolutePathWithTraversal(t *testing.T) {
+	archive := buildTar(t, []tarEntry{
+		{name: "/../../escaped", typeflag: tar.TypeReg, body: "escaped"},
+	})
+	parent := t.TempDir()
+	outputDir := filepath.Join(parent, "a", "b")
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	err := extractTarNative(bytes.NewReader(archive), outputDir, 1, ExtractOptions{}, nil)
+	if !errors.Is(err, ErrUnsafeEntry) {
+		t.Fatalf("ожидалась ErrUnsafeEntry, получено %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(parent, "escaped")); err == nil {
+		t.Fatal("файл записан за пределами директории извлечения")
+	}
+}
+
+func TestExtractZipNativeAbsolutePath(t *testing.T) {
+	before, _ := os.ReadFile("/etc/passwd")
+	archivePath := buildZip(t, []string{"/etc/passwd", `C:\temp\x.txt`}, map[string]string{
+		"/etc/passwd":   "root:x:0:0::/root:/bin/sh\n",
+		`C:\temp\x.txt`: "x",
+	})
+
+	outputDir := t.TempDir()
+	if err := extractZipNative(context.Background(), archivePath, outputDir, ExtractOptions{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkAbsoluteEntryExtracted(t, outputDir, before)
+	if _, err := os.Stat(filepath.Join(outputDir, `temp\x.txt`)); err != nil {
+		t.Errorf("префикс диска не удален: %v", err)
+	}
+}
+
+// unsafeArchives создает tar.gz и zip с записями, выходящими за пределы директории извлечения
+func unsafeArchives(t *testing.T) map[string]string {
+	t.Helper()
+	names := []string{"good.txt", "../evil.txt", "dir/../../evil2.txt"}
+	contents := map[string]string{"good.txt": "good", "../evil.txt": "evil", "dir/../../evil2.txt": "evil"}
+
+	var entries []tarEntry
+	for _, name := range names {
+		entries = append(entries, tarEntry{name: name, typeflag: tar.TypeReg, body: contents[name]})
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(buildTar(t, entries)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	tgz := filepath.Join(t.TempDir(), "unsafe.tar.gz")
+	if err := os.WriteFile(tgz, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{"tar.gz": tgz, "zip": buildZip(t, names, contents)}
+}
+
+func TestExtractUnsafeEntryPolicy(t *testing.T) {
+	for format, archivePath := range unsafeArchives(t) {
+		for _, policy := range []UnsafeEntryPolicy{"", UnsafeAbort, UnsafeSkip} {
+			t.Run(fmt.Sprintf("%s/%s", format, policy), func(t *testing.T) {
+				parent := t.TempDir()
+				outputDir := filepath.Join(parent, "a", "out")
+				em := &ExtractManager{Runner: runner.NewFakeRunner(), PreferNative: true, UnsafeEntries: policy}
+
+				err := em.ExtractWithOptions(archivePath, outputDir, ExtractOptions{})
+				if !errors.Is(err, ErrUnsafeEntry) {
+					t.Fatalf("ожидалась ErrUnsafeEntry, получено %v", err)
+				}
+				for _, escaped := range []string{filepath.Join(parent, "a", "evil.txt"), filepath.Join(parent, "a", "evil2.txt")} {
+					if _, err := os.Lstat(escaped); err == nil {
+						t.Fatalf("файл %s записан за пределами директории извлечения", escaped)
+					}
+				}
+
+				var skipped *SkippedEntriesError
+				if policy != UnsafeSkip {
+					if errors.As(err, &skipped) {
+						t.Fatalf("политика %q пропустила записи вместо прерывания: %v", policy, err)
+					}
+					return
+				}
+				if !errors.As(err, &skipped) || !reflect.DeepEqual(skipped.Entries, []string{"../evil.txt", "dir/../../evil2.txt"}) {
+					t.Fatalf("пропущены %v, ожидалось [../evil.txt dir/../../evil2.txt]", err)
+				}
+				if data, err := os.ReadFile(filepath.Join(outputDir, "good.txt")); err != nil || string(data) != "good" {
+					t.Errorf("безопасная запись не извлечена: %q, %v", data, err)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractUnsafeEntrySkipWithConflictPolicy(t *testing.T) {
+	archivePath := unsafeArchives(t)["tar.gz"]
+	parent := t.TempDir()
+	outputDir := filepath.Join(parent, "out")
+	em := &ExtractManager{Runner: runner.NewFakeRunner(), PreferNative: true, UnsafeEntries: UnsafeSkip}
+
+	err := em.ExtractWithOptions(archivePath, outputDir, ExtractOptions{OnConflict: ConflictOverwrite})
+	var skipped *SkippedEntriesError
+	if !errors.As(err, &skipped) {
+		t.Fatalf("ожидалась SkippedEntriesError, получено %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(outputDir, "good.txt")); err != nil || string(data) != "good" {
+		t.Errorf("безопасная запись не перенесена из временной директории: %q, %v", data, err)
+	}
+}
+
+func TestExtractRejectsUnknownUnsafePolicy(t *testing.T) {
+	em := &ExtractManager{Runner: runner.NewFakeRunner(), PreferNative: true, UnsafeEntries: "ignore"}
+	err := em.ExtractWithOptions(unsafeArchives(t)["zip"], t.TempDir(), ExtractOptions{})
+	if err == nil || !strings.Contains(err.Error(), "ignore") {
+		t.Fatalf("ошибка = %v, ожидалось упоминание неизвестной политики", err)
+	}
+}
@@ -0,0 +1,171 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+)
+
+// Ownership задает владельца извлеченных файлов; -1 оставляет UID или GID без изменений
+type Ownership struct {
+	UID int
+	GID int
+}
+
+// LookupOwnership определяет UID и GID по имени пользователя и группы (или их номерам).
+// Пустое имя пользователя оставляет владельца без изменений; пустая группа означает
+// основную группу пользователя.
+func LookupOwnership(username, group string) (*Ownership, error) {
+	owner := &Ownership{UID: -1, GID: -1}
+	if username != "" {
+		u, err := lookupUser(username)
+		if err != nil {
+			return nil, err
+		}
+		if owner.UID, err = strconv.Atoi(u.Uid); err != nil {
+			return nil, fmt.Errorf("некорректный UID пользователя %s: %s", username, u.Uid)
+		}
+		if group == "" {
+			if owner.GID, err = strconv.Atoi(u.Gid); err != nil {
+				return nil, fmt.Errorf("некорректный GID пользователя %s: %s", username, u.Gid)
+			}
+		}
+	}
+	if group != "" {
+		g, err := lookupGroup(group)
+		if err != nil {
+			return nil, err
+		}
+		if owner.GID, err = strconv.Atoi(g.Gid); err != nil {
+			return nil, fmt.Errorf("некорректный GID группы %s: %s", group, g.Gid)
+		}
+	}
+	return owner, nil
+}
+
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		if u, err := user.LookupId(name); err == nil {
+			return u, nil
+		}
+		// Пользователя может не быть в /etc/passwd (контейнер) - числовой UID допустим и так
+		return &user.User{Uid: name, Gid: "-1"}, nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("пользователь не найден: %s", name)
+	}
+	return u, nil
+}
+
+func lookupGroup(name string) (*user.Group, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		return &user.Group{Gid: name}, nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return nil, fmt.Errorf("группа не найдена: %s", name)
+	}
+	return g, nil
+}
+
+// geteuid позволяет проверить поведение PreserveOwnership без запуска от root
+var geteuid = os.Geteuid
+
+// restoresOwner сообщает, восстанавливать ли владельца из архива: только при
+// PreserveOwnership от root и без явного Chown
+func (o ExtractOptions) restoresOwner() bool {
+	return o.PreserveOwnership && o.Chown == nil && geteuid() == 0
+}
+
+// tarAttrArgs возвращает флаги tar для DiscardPermissions и PreserveOwnership.
+// tar от root по умолчанию сохраняет и права, и владельца, поэтому для root
+// отключенное сохранение передается явно
+func tarAttrArgs(opts ExtractOptions) []string {
+	var args []string
+	root := geteuid() == 0
+	switch {
+	case !opts.DiscardPermissions:
+		args = append(args, "-p")
+	case root:
+		args = append(args, "--no-same-permissions")
+	}
+	switch {
+	case opts.restoresOwner():
+		args = append(args, "--same-owner")
+	case root:
+		args = append(args, "--no-same-owner")
+	}
+	return args
+}
+
+// restoreAttrs применяет к извлеченной записи права mode без DiscardPermissions
+// и владельца uid:gid при restoresOwner. Права ссылок не меняются
+func restoreAttrs(path string, mode os.FileMode, uid, gid int, opts ExtractOptions) error {
+	if opts.restoresOwner() {
+		if err := os.Lchown(path, uid, gid); err != nil {
+			if errors.Is(err, os.ErrPermission) {
+				return fmt.Errorf("ошибка смены владельца %s на %d:%d: %w (владелец из архива "+
+					"восстанавливается только при наличии CAP_CHOWN; без него отключите PreserveOwnership, "+
+					"и файлы будут принадлежать текущему пользователю)", path, uid, gid, err)
+			}
+			return fmt.Errorf("ошибка смены владельца %s: %w", path, err)
+		}
+	}
+	if !opts.DiscardPermissions && mode.Type()&os.ModeSymlink == 0 {
+		if err := os.Chmod(path, mode.Perm()); err != nil {
+			return fmt.Errorf("ошибка смены прав %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// needsFixup сообщает, нужно ли менять владельца или права извлеченных файлов
+func (o ExtractOptions) needsFixup() bool {
+	return o.Chown != nil || o.ChmodDir != 0 || o.ChmodFile != 0
+}
+
+// fixupTree применяет владельца и права из opts ко всем записям внутри root (кроме самого root).
+// Права ссылок не меняются, владелец ссылки меняется без перехода по ней.
+// Права директорий меняются после обхода их содержимого, чтобы не потерять к нему доступ.
+func fixupTree(root string, opts ExtractOptions) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root {
+			return nil
+		}
+		if opts.Chown != nil {
+			if err := os.Lchown(path, opts.Chown.UID, opts.Chown.GID); err != nil {
+				return fmt.Errorf("ошибка смены владельца %s: %w", path, err)
+			}
+		}
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, path)
+		case d.Type().IsRegular() && opts.ChmodFile != 0:
+			if err := os.Chmod(path, opts.ChmodFile); err != nil {
+				return fmt.Errorf("ошибка смены прав %s: %w", path, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if opts.ChmodDir == 0 {
+		return nil
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i], opts.ChmodDir); err != nil {
+			return fmt.Errorf("ошибка смены прав %s: %w", dirs[i], err)
+		}
+	}
+	return nil
+}
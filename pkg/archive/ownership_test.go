@@ -0,0 +1,187 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+// ownerOf возвращает UID и GID записи, не переходя по ссылкам
+func ownerOf(t *testing.T, path string) (int, int) {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	return int(stat.Uid), int(stat.Gid)
+}
+
+func TestExtractChownAndChmod(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("смена владельца требует запуска от root")
+	}
+
+	tools := map[string]string{"tar": "tar", "zip": "unzip"}
+	for format, archivePath := range conflictArchives(t) {
+		for _, native := range []bool{false, true} {
+			if _, err := exec.LookPath(tools[format]); err != nil && !native {
+				continue
+			}
+			name := format
+			if native {
+				name += "/native"
+			}
+			t.Run(name, func(t *testing.T) {
+				outputDir := t.TempDir()
+				existing := filepath.Join(outputDir, "existing.txt")
+				if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				em := &ExtractManager{PreferNative: native}
+				opts := ExtractOptions{
+					Chown:    &Ownership{UID: 1234, GID: 2345},
+					ChmodDir: 0750, ChmodFile: 0600,
+					// Явный Chown важнее владельца и прав из архива
+					PreserveOwnership: true,
+				}
+				if _, err := em.ExtractWithResult(archivePath, outputDir, opts); err != nil {
+					t.Fatal(err)
+				}
+
+				for _, entry := range []string{"a.txt", "dir", "dir/b.txt", "dir/c.txt"} {
+					path := filepath.Join(outputDir, entry)
+					if uid, gid := ownerOf(t, path); uid != 1234 || gid != 2345 {
+						t.Errorf("%s: владелец %d:%d, ожидалось 1234:2345", entry, uid, gid)
+					}
+					info, _ := os.Lstat(path)
+					want := os.FileMode(0600)
+					if info.IsDir() {
+						want = 0750
+					}
+					if info.Mode().Perm() != want {
+						t.Errorf("%s: права %v, ожидалось %v", entry, info.Mode().Perm(), want)
+					}
+				}
+
+				// Файлы, которых нет в архиве, не затрагиваются
+				if uid, gid := ownerOf(t, existing); uid != 0 || gid != 0 {
+					t.Errorf("existing.txt: владелец изменен на %d:%d", uid, gid)
+				}
+				if info, _ := os.Stat(existing); info.Mode().Perm() != 0644 {
+					t.Errorf("existing.txt: права изменены на %v", info.Mode().Perm())
+				}
+			})
+		}
+	}
+}
+
+func TestLookupOwnership(t *testing.T) {
+	tests := []struct {
+		user, group string
+		want        Ownership
+	}{
+		{"", "", Ownership{UID: -1, GID: -1}},
+		{"root", "", Ownership{UID: 0, GID: 0}},
+		// Числовой UID допустим, даже если пользователя нет в /etc/passwd
+		{"54321", "", Ownership{UID: 54321, GID: -1}},
+		{"54321", "4321", Ownership{UID: 54321, GID: 4321}},
+		{"", "4321", Ownership{UID: -1, GID: 4321}},
+	}
+	for _, tt := range tests {
+		got, err := LookupOwnership(tt.user, tt.group)
+		if err != nil {
+			t.Errorf("LookupOwnership(%q, %q) error = %v", tt.user, tt.group, err)
+			continue
+		}
+		if *got != tt.want {
+			t.Errorf("LookupOwnership(%q, %q) = %+v, ожидалось %+v", tt.user, tt.group, *got, tt.want)
+		}
+	}
+
+	if _, err := LookupOwnership("no-such-user-go-to-run", ""); err == nil {
+		t.Error("ожидалась ошибка для несуществующего пользователя")
+	}
+	if _, err := LookupOwnership("", "no-such-group-go-to-run"); err == nil {
+		t.Error("ожидалась ошибка для несуществующей группы")
+	}
+}
+
+func TestTarAttrArgsWithChown(t *testing.T) {
+	prev := geteuid
+	geteuid = func() int { return 0 }
+	t.Cleanup(func() { geteuid = prev })
+
+	tests := []struct {
+		opts ExtractOptions
+		want []string
+	}{
+		{ExtractOptions{PreserveOwnership: true, DiscardPermissions: true}, []string{"--no-same-permissions", "--same-owner"}},
+		// Владелец из архива не восстанавливается, если задан Chown
+		{ExtractOptions{PreserveOwnership: true, Chown: &Ownership{UID: 1000, GID: 1000}},
+			[]string{"-p", "--no-same-owner"}},
+		// Нулевое значение сохраняет права, как DefaultExtractOptions
+		{ExtractOptions{}, []string{"-p", "--no-same-owner"}},
+		{ExtractOptions{DiscardPermissions: true}, []string{"--no-same-permissions", "--no-same-owner"}},
+	}
+	for _, tt := range tests {
+		if got := tarAttrArgs(tt.opts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tarAttrArgs(%+v) = %q, ожидалось %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestExtractZeroOptionsPreservePermissions(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "run.sh", Typeflag: tar.TypeReg, Mode: 0755, Size: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte("#!")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(t.TempDir(), "perm.tar")
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Строгая umask отличает права из архива от прав, ограниченных umask
+	prev := syscall.Umask(0077)
+	t.Cleanup(func() { syscall.Umask(prev) })
+
+	tests := []struct {
+		opts ExtractOptions
+		want os.FileMode
+	}{
+		{ExtractOptions{}, 0755},
+		{DefaultExtractOptions(), 0755},
+		{ExtractOptions{DiscardPermissions: true}, 0700},
+	}
+	for _, native := range []bool{false, true} {
+		if _, err := exec.LookPath("tar"); !native && err != nil {
+			continue
+		}
+		for _, tt := range tests {
+			outputDir := t.TempDir()
+			if err := (&ExtractManager{PreferNative: native}).ExtractWithOptions(archivePath, outputDir, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(filepath.Join(outputDir, "run.sh"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.want {
+				t.Errorf("native=%v, %+v: права %v, ожидалось %v", native, tt.opts, info.Mode().Perm(), tt.want)
+			}
+		}
+	}
+}
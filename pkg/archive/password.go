@@ -0,0 +1,166 @@
+package archive
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"path/filepath"
+)
+
+var (
+	// ErrPasswordRequired возвращается для зашифрованного архива без ExtractOptions.Password
+	ErrPasswordRequired = errors.New("архив зашифрован, требуется пароль")
+	// ErrWrongPassword возвращается, если пароль не подходит к архиву
+	ErrWrongPassword = errors.New("неверный пароль архива")
+)
+
+// zipEncryptedFlag - бит 0 общего флага записи zip: запись зашифрована
+const zipEncryptedFlag = 0x1
+
+// zipMethodAES - метод сжатия, которым WinZip помечает записи с шифрованием AES
+const zipMethodAES = 99
+
+// zipEncryption сообщает, есть ли в zip зашифрованные записи и зашифрована ли какая-то из них AES
+func zipEncryption(archivePath string) (encrypted, aes bool, err error) {
+	zr, err := zip.OpenReader(filepath.Clean(archivePath))
+	if err != nil {
+		return false, false, fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Flags&zipEncryptedFlag != 0 {
+			encrypted = true
+			aes = aes || f.Method == zipMethodAES
+		}
+	}
+	return encrypted, aes, nil
+}
+
+// passwordError переводит ошибку утилиты о пароле в ErrPasswordRequired или ErrWrongPassword
+func passwordError(err error, password string) error {
+	if password == "" {
+		return fmt.Errorf("%w: %w", ErrPasswordRequired, err)
+	}
+	return fmt.Errorf("%w: %w", ErrWrongPassword, err)
+}
+
+// openZipEntry открывает запись zip для чтения распакованного содержимого.
+// Записи с традиционным шифрованием PKWARE (ZipCrypto) расшифровываются паролем;
+// неверный пароль обнаруживается по проверочному байту заголовка и по CRC в конце записи.
+// Шифрование AES встроенным извлечением не поддерживается.
+func openZipEntry(f *zip.File, password string) (io.ReadCloser, error) {
+	if f.Flags&zipEncryptedFlag == 0 {
+		return f.Open()
+	}
+	if password == "" {
+		return nil, fmt.Errorf("%w: %s", ErrPasswordRequired, f.Name)
+	}
+	if f.Method == zipMethodAES {
+		return nil, fmt.Errorf("запись %s зашифрована AES, встроенное извлечение поддерживает только ZipCrypto; используйте 7z", f.Name)
+	}
+
+	raw, err := f.OpenRaw()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", f.Name, err)
+	}
+	keys := newZipCryptoKeys(password)
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(raw, header); err != nil {
+		return nil, fmt.Errorf("ошибка чтения %s: %w", f.Name, err)
+	}
+	keys.decrypt(header)
+	// Проверочный байт - старший байт CRC, а при дескрипторе данных (бит 3) - времени изменения
+	check := byte(f.CRC32 >> 24)
+	if f.Flags&0x8 != 0 {
+		check = byte(f.ModifiedTime >> 8)
+	}
+	if header[11] != check {
+		return nil, fmt.Errorf("%w: %s", ErrWrongPassword, f.Name)
+	}
+
+	var body io.Reader = &zipCryptoReader{r: raw, keys: keys}
+	var closer io.Closer
+	switch f.Method {
+	case zip.Store:
+	case zip.Deflate:
+		fr := flate.NewReader(body)
+		body, closer = fr, fr
+	default:
+		return nil, fmt.Errorf("метод сжатия %d записи %s не поддерживается", f.Method, f.Name)
+	}
+	return &crcCheckReader{r: body, closer: closer, want: f.CRC32, hash: crc32.NewIEEE(), name: f.Name}, nil
+}
+
+// zipCryptoKeys - состояние традиционного шифрования PKWARE (APPNOTE 6.1)
+type zipCryptoKeys [3]uint32
+
+func newZipCryptoKeys(password string) *zipCryptoKeys {
+	keys := &zipCryptoKeys{0x12345678, 0x23456789, 0x34567890}
+	for i := 0; i < len(password); i++ {
+		keys.update(password[i])
+	}
+	return keys
+}
+
+func (k *zipCryptoKeys) update(b byte) {
+	k[0] = crc32.IEEETable[byte(k[0])^b] ^ (k[0] >> 8)
+	k[1] = (k[1]+(k[0]&0xff))*134775813 + 1
+	k[2] = crc32.IEEETable[byte(k[2])^byte(k[1]>>24)] ^ (k[2] >> 8)
+}
+
+// decrypt расшифровывает buf на месте
+func (k *zipCryptoKeys) decrypt(buf []byte) {
+	for i, c := range buf {
+		temp := k[2] | 2
+		plain := c ^ byte((temp*(temp^1))>>8)
+		k.update(plain)
+		buf[i] = plain
+	}
+}
+
+// zipCryptoReader расшифровывает поток записи ZipCrypto
+type zipCryptoReader struct {
+	r    io.Reader
+	keys *zipCryptoKeys
+}
+
+func (z *zipCryptoReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	z.keys.decrypt(p[:n])
+	return n, err
+}
+
+// crcCheckReader сверяет CRC32 распакованного содержимого в конце записи:
+// проверочный байт заголовка совпадает и у неверного пароля с вероятностью 1/256
+type crcCheckReader struct {
+	r      io.Reader
+	closer io.Closer
+	want   uint32
+	hash   hash.Hash32
+	name   string
+}
+
+func (c *crcCheckReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) && c.hash.Sum32() != c.want {
+		return n, fmt.Errorf("%w: %s", ErrWrongPassword, c.name)
+	}
+	var corrupt flate.CorruptInputError
+	if errors.As(err, &corrupt) {
+		// Мусор после расшифровки неверным паролем не разбирается как deflate
+		return n, fmt.Errorf("%w: %s: %w", ErrWrongPassword, c.name, err)
+	}
+	return n, err
+}
+
+func (c *crcCheckReader) Close() error {
+	if c.closer == nil {
+		return nil
+	}
+	return c.closer.Close()
+}
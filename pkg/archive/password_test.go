@@ -0,0 +1,98 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// encryptedZip создает zip с шифрованием ZipCrypto утилитой zip
+func encryptedZip(t *testing.T, password string) string {
+	t.Helper()
+	if _, err := exec.LookPath("zip"); err != nil {
+		t.Skip("zip не установлен")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("содержимое"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	archivePath := filepath.Join(dir, "secret.zip")
+	cmd := exec.Command("zip", "-q", "-P", password, archivePath, "secret.txt")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("zip: %v: %s", err, output)
+	}
+	return archivePath
+}
+
+func TestExtractEncryptedZipKeepsPasswordOffArgv(t *testing.T) {
+	archivePath := encryptedZip(t, "s3cret")
+	fake := runner.NewFakeRunner()
+	em := &ExtractManager{Runner: fake}
+	outputDir := t.TempDir()
+
+	if err := em.extractZip(context.Background(), archivePath, outputDir, ExtractOptions{Password: "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "secret.txt"))
+	if err != nil || string(data) != "содержимое" {
+		t.Fatalf("извлечено %q, %v", data, err)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("зашифрованный zip должен извлекаться встроенно, запущено: %q", commands)
+	}
+}
+
+func TestExtractEncryptedZipPasswordErrors(t *testing.T) {
+	archivePath := encryptedZip(t, "s3cret")
+	em := &ExtractManager{Runner: runner.NewFakeRunner()}
+
+	err := em.extractZip(context.Background(), archivePath, t.TempDir(), ExtractOptions{})
+	if !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("без пароля: %v", err)
+	}
+	err = em.extractZip(context.Background(), archivePath, t.TempDir(), ExtractOptions{Password: "wrong"})
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("неверный пароль: %v", err)
+	}
+}
+
+func TestExtract7zPassesPasswordOnStdin(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	em := &ExtractManager{Runner: fake}
+
+	if err := em.extract7z(context.Background(), "/tmp/a.7z", "/tmp/out", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	call := fake.Calls[0]
+	if strings.Contains(call.String(), "s3cret") {
+		t.Errorf("пароль в аргументах: %q", call.String())
+	}
+	if call.Stdin != "s3cret\n" {
+		t.Errorf("stdin = %q", call.Stdin)
+	}
+}
+
+func TestExtract7zPasswordErrors(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("7z", "ERROR: Wrong password : a.txt\n", errors.New("exit status 2"))
+	em := &ExtractManager{Runner: fake}
+
+	err := em.extract7z(context.Background(), "/tmp/a.7z", "/tmp/out", "")
+	if !errors.Is(err, ErrPasswordRequired) {
+		t.Errorf("без пароля: %v", err)
+	}
+	if fake.Calls[0].Stdin != "" {
+		t.Errorf("без пароля stdin должен быть пустым, получено %q", fake.Calls[0].Stdin)
+	}
+	err = em.extract7z(context.Background(), "/tmp/a.7z", "/tmp/out", "wrong")
+	if !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("неверный пароль: %v", err)
+	}
+}
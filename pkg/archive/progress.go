@@ -0,0 +1,116 @@
+package archive
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/ui"
+)
+
+// ProgressStyle задает вид индикатора извлечения при ShowProgress
+type ProgressStyle string
+
+// Виды индикатора извлечения
+const (
+	// ProgressSpinner показывает спиннер без оценки оставшегося объема
+	ProgressSpinner ProgressStyle = "spinner"
+	// ProgressBar показывает прогресс-бар: при встроенном извлечении по распакованным
+	// байтам, при извлечении утилитой - по числу появившихся записей
+	ProgressBar ProgressStyle = "bar"
+)
+
+// entryPollInterval - как часто пересчитываются записи, извлеченные внешней утилитой
+var entryPollInterval = 250 * time.Millisecond
+
+// extractWithBar извлекает архив с прогресс-баром. Встроенное извлечение считает
+// распакованные байты относительно uncompressedSize; для внешних утилит бар
+// отслеживает число записей в outputDir относительно списка содержимого архива.
+func (em *ExtractManager) extractWithBar(ctx context.Context, archivePath, outputDir string, opts ExtractOptions) error {
+	format := em.detectArchiveType(archivePath)
+	if em.useNative(format) {
+		return em.extractNativeWithBar(ctx, format, archivePath, outputDir, opts)
+	}
+
+	total := len(em.listArchiveContents(archivePath))
+	if total == 0 {
+		// Число записей неизвестно - без него бар ничего не добавит к спиннеру
+		return em.extractWithSpinner(ctx, archivePath, outputDir, opts)
+	}
+
+	bar := ui.NewProgressBar(total, "Извлечение архива")
+	baseline := countEntries(outputDir)
+	done := make(chan struct{})
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		ticker := time.NewTicker(entryPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = bar.Set(min(countEntries(outputDir)-baseline, total))
+			}
+		}
+	}()
+
+	err := em.extractArchive(ctx, archivePath, outputDir, opts)
+	close(done)
+	<-watched
+	return finishBar(bar, err)
+}
+
+// extractNativeWithBar извлекает архив встроенными средствами, передавая распакованные
+// байты бару. Без известного размера бар показывает объем без конечного значения.
+func (em *ExtractManager) extractNativeWithBar(ctx context.Context, format, archivePath, outputDir string, opts ExtractOptions) error {
+	total := em.uncompressedSize(archivePath, format)
+	if format == "tar" {
+		if info, err := os.Stat(archivePath); err == nil {
+			total = info.Size()
+		}
+	}
+	if total <= 0 {
+		total = -1
+	}
+
+	bar := ui.NewBytesProgressBar(total, "Извлечение архива")
+	opts.progress = bar
+	return finishBar(bar, em.extractArchive(ctx, archivePath, outputDir, opts))
+}
+
+// finishBar доводит бар до конца после успешного извлечения и убирает его при ошибке
+func finishBar(bar interface {
+	Finish() error
+	Exit() error
+}, err error) error {
+	if err != nil {
+		_ = bar.Exit()
+		return err
+	}
+	_ = bar.Finish()
+	return nil
+}
+
+// withProgress возвращает r, который сообщает прочитанные байты opts.progress
+func (opts ExtractOptions) withProgress(r io.Reader) io.Reader {
+	if opts.progress == nil {
+		return r
+	}
+	return io.TeeReader(r, opts.progress)
+}
+
+// countEntries считает файлы, ссылки и директории внутри dir
+func countEntries(dir string) int {
+	count := 0
+	_ = filepath.WalkDir(dir, func(path string, _ os.DirEntry, err error) error {
+		if err == nil && path != dir {
+			count++
+		}
+		return nil
+	})
+	return count
+}
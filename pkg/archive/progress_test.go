@@ -0,0 +1,77 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// byteCounter считает байты, переданные индикатору прогресса
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+func TestNativeProgressCountsDecompressedBytes(t *testing.T) {
+	dir := t.TempDir()
+	tarData := buildTar(t, []tarEntry{
+		{name: "dir/", typeflag: tar.TypeDir},
+		{name: "dir/a.txt", typeflag: tar.TypeReg, body: strings.Repeat("a", 10000)},
+		{name: "dir/b.txt", typeflag: tar.TypeReg, body: strings.Repeat("b", 3000)},
+	})
+	plain := bytes.Repeat([]byte("go-to-run\n"), 5000)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int64
+	}{
+		{"data.tar", tarData, int64(len(tarData))},
+		{"data.tar.gz", gzipBytes(t, tarData), int64(len(tarData))},
+		{"data.tar.zst", zstdBytes(t, tarData), int64(len(tarData))},
+		{"notes.txt.gz", gzipBytes(t, plain), int64(len(plain))},
+		{"data.zip", nil, 13000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if tt.data == nil {
+				path = buildZip(t, []string{"dir/a.txt", "dir/b.txt"}, map[string]string{
+					"dir/a.txt": strings.Repeat("a", 10000), "dir/b.txt": strings.Repeat("b", 3000),
+				})
+			} else if err := os.WriteFile(path, tt.data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			em := &ExtractManager{PreferNative: true}
+			counter := &byteCounter{}
+			opts := DefaultExtractOptions()
+			opts.progress = counter
+			if err := em.extractArchive(context.Background(), path, t.TempDir(), opts); err != nil {
+				t.Fatal(err)
+			}
+			if counter.n != tt.want {
+				t.Errorf("индикатор получил %d байт, ожидалось %d", counter.n, tt.want)
+			}
+			// Для сжатых форматов полный бар совпадает с размером из заголовков архива
+			if size := em.uncompressedSize(path, em.detectArchiveType(path)); size > 0 && size != counter.n {
+				t.Errorf("размер для бара %d, получено байт %d", size, counter.n)
+			}
+		})
+	}
+}
+
+func TestWithProgressWithoutIndicator(t *testing.T) {
+	r := strings.NewReader("data")
+	if got := (ExtractOptions{}).withProgress(r); got != r {
+		t.Error("без индикатора поток должен передаваться без обертки")
+	}
+}
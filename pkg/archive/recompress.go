@@ -0,0 +1,310 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/13winged/go-to-run/internal/runner"
+	"github.com/klauspost/compress/zstd"
+)
+
+// tarCodecs сопоставляет форматы семейства tar с кодеком сжатия; пустая строка - без сжатия
+var tarCodecs = map[string]string{
+	"tar":     "",
+	"tar.gz":  "gzip",
+	"tgz":     "gzip",
+	"tar.bz2": "bzip2",
+	"tbz2":    "bzip2",
+	"tar.xz":  "xz",
+	"txz":     "xz",
+	"tar.zst": "zstd",
+	"tzst":    "zstd",
+	"tar.lz4": "lz4",
+}
+
+// tarSuffixes - расширения архивов tar; длинные проверяются раньше коротких
+var tarSuffixes = []string{
+	".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz",
+	".tar.zst", ".tzst", ".tar.lz4", ".tar",
+}
+
+// Recompress пересжимает tar-архив srcPath в формат dstFormat (tar.zst, tar.gz, tar.xz...)
+// за один проход: поток распаковывается и сразу сжимается заново, без извлечения на диск.
+// gzip, bzip2 (распаковка) и zstd обрабатываются встроенными кодеками, остальные - утилитами.
+// Результат сохраняется рядом с исходным архивом с новым расширением.
+func (em *ExtractManager) Recompress(srcPath, dstFormat string, opts CreateOptions) error {
+	srcFormat := em.detectArchiveType(srcPath)
+	srcCodec, ok := tarCodecs[srcFormat]
+	if !ok {
+		return fmt.Errorf("пересжатие поддерживается только для tar-архивов, формат %s требует переупаковки", srcFormat)
+	}
+	dstFormat = strings.TrimPrefix(strings.ToLower(dstFormat), ".")
+	if alias, ok := streamFormatAliases[dstFormat]; ok {
+		dstFormat = alias
+	}
+	dstCodec, ok := tarCodecs[dstFormat]
+	if !ok {
+		return fmt.Errorf("пересжатие поддерживается только в tar-форматы, указан %s", dstFormat)
+	}
+	if err := validateCreateOptions(dstFormat, opts); err != nil {
+		return err
+	}
+
+	dstPath := trimTarSuffix(srcPath) + "." + dstFormat
+	if filepath.Clean(dstPath) == filepath.Clean(srcPath) {
+		return fmt.Errorf("архив %s уже в формате %s", srcPath, dstFormat)
+	}
+
+	src, err := os.Open(filepath.Clean(srcPath))
+	if err != nil {
+		return fmt.Errorf("ошибка открытия архива: %w", err)
+	}
+	defer src.Close()
+
+	decoded, err := em.decompressReader(srcCodec, src)
+	if err != nil {
+		return err
+	}
+	defer decoded.Close()
+
+	// Проверяем, что внутри действительно tar, до создания результата
+	br := bufio.NewReaderSize(decoded, 64*1024)
+	head, _ := br.Peek(tarMagicOffset + len(tarMagic))
+	if len(head) < tarMagicOffset+len(tarMagic) || !bytes.Equal(head[tarMagicOffset:], tarMagic) {
+		return fmt.Errorf("содержимое %s не является tar-архивом", srcPath)
+	}
+
+	tmpDir := filepath.Dir(dstPath)
+	if opts.TempDir != "" {
+		tmpDir = opts.TempDir
+		// Результат пересжатия редко больше исходного архива
+		if info, err := src.Stat(); err == nil {
+			if err := checkFreeSpace(tmpDir, info.Size()); err != nil {
+				return err
+			}
+		}
+	}
+	tmp, err := os.CreateTemp(tmpDir, ".go-to-run-recompress-*")
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	err = em.recompressTo(tmp, br, dstCodec, opts)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка пересжатия %s: %w", srcPath, err)
+	}
+	if err := moveFile(tmp.Name(), dstPath); err != nil {
+		return fmt.Errorf("ошибка сохранения %s: %w", dstPath, err)
+	}
+
+	if srcInfo, err := src.Stat(); err == nil {
+		if dstInfo, err := os.Stat(dstPath); err == nil {
+			fmt.Printf("%s -> %s: %s\n", filepath.Base(srcPath), filepath.Base(dstPath),
+				sizeDelta(srcInfo.Size(), dstInfo.Size()))
+		}
+	}
+	return nil
+}
+
+// recompressTo сжимает поток r кодеком codec в файл out
+func (em *ExtractManager) recompressTo(out *os.File, r io.Reader, codec string, opts CreateOptions) error {
+	w, err := em.compressWriter(codec, out, opts)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// decompressReader возвращает распакованный поток архива
+func (em *ExtractManager) decompressReader(codec string, r io.Reader) (io.ReadCloser, error) {
+	switch codec {
+	case "":
+		return io.NopCloser(r), nil
+	case "gzip":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения gzip: %w", err)
+		}
+		return gz, nil
+	case "bzip2":
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case "zstd":
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения zstd: %w", err)
+		}
+		return zr.IOReadCloser(), nil
+	default:
+		return em.filterReader(r, codec, "-d", "-c")
+	}
+}
+
+// compressWriter возвращает поток, сжимающий данные кодеком codec в w
+func (em *ExtractManager) compressWriter(codec string, w io.Writer, opts CreateOptions) (io.WriteCloser, error) {
+	if nativeCodecs[codec] {
+		return nativeCompressWriter(codec, w, opts)
+	}
+	args := []string{"-c"}
+	if opts.CompressionLevel > 0 {
+		args = append(args, "-"+strconv.Itoa(opts.CompressionLevel))
+	}
+	if opts.Threads > 0 && codec == "xz" {
+		args = append(args, "-T"+strconv.Itoa(opts.Threads))
+	}
+	return em.filterWriter(w, codec, args...)
+}
+
+// nativeCodecs - кодеки, которые сжимаются встроенными средствами Go
+var nativeCodecs = map[string]bool{"": true, "gzip": true, "zstd": true}
+
+// nativeCompressWriter сжимает данные встроенным кодеком (см. nativeCodecs)
+func nativeCompressWriter(codec string, w io.Writer, opts CreateOptions) (io.WriteCloser, error) {
+	switch codec {
+	case "":
+		return nopWriteCloser{w}, nil
+	case "gzip":
+		level := gzip.DefaultCompression
+		if opts.CompressionLevel > 0 {
+			level = opts.CompressionLevel
+		}
+		return gzip.NewWriterLevel(w, level)
+	case "zstd":
+		zopts := []zstd.EOption{}
+		if opts.CompressionLevel > 0 {
+			zopts = append(zopts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(opts.CompressionLevel)))
+		}
+		if opts.Threads > 0 {
+			zopts = append(zopts, zstd.WithEncoderConcurrency(opts.Threads))
+		}
+		return zstd.NewWriter(w, zopts...)
+	default:
+		return nil, fmt.Errorf("кодек %s не поддерживается встроенным сжатием", codec)
+	}
+}
+
+// filterReader пропускает r через внешнюю утилиту и возвращает ее вывод
+func (em *ExtractManager) filterReader(r io.Reader, program string, args ...string) (io.ReadCloser, error) {
+	if _, err := em.runner().LookPath(program); err != nil {
+		return nil, fmt.Errorf("команда %s не найдена: %w", program, err)
+	}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := em.runStreams(context.Background(), runner.Streams{Stdin: r, Stdout: pw}, program, args...)
+		// Читатель получает ошибку утилиты вместо преждевременного EOF
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	return &filterProcess{ReadCloser: pr, done: done}, nil
+}
+
+// filterWriter возвращает поток, передающий данные внешней утилите, вывод которой пишется в w
+func (em *ExtractManager) filterWriter(w io.Writer, program string, args ...string) (io.WriteCloser, error) {
+	if _, err := em.runner().LookPath(program); err != nil {
+		return nil, fmt.Errorf("команда %s не найдена: %w", program, err)
+	}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := em.runStreams(context.Background(), runner.Streams{Stdin: pr, Stdout: w}, program, args...)
+		// Если утилита завершилась раньше, запись в канал не должна блокироваться
+		closeErr := err
+		if closeErr == nil {
+			closeErr = fmt.Errorf("%s завершилась до конца входных данных", program)
+		}
+		pr.CloseWithError(closeErr)
+		done <- err
+	}()
+	return &filterProcess{WriteCloser: pw, done: done}, nil
+}
+
+// filterProcess - внешняя утилита-фильтр; Close закрывает канал и дожидается завершения
+type filterProcess struct {
+	io.ReadCloser
+	io.WriteCloser
+	done chan error
+}
+
+func (p *filterProcess) Close() error {
+	if p.WriteCloser != nil {
+		_ = p.WriteCloser.Close()
+	}
+	if p.ReadCloser != nil {
+		// Дочитываем остаток, чтобы утилита не заблокировалась на записи
+		_, _ = io.Copy(io.Discard, p.ReadCloser)
+	}
+	return <-p.done
+}
+
+func (p *filterProcess) Read(b []byte) (int, error) {
+	if p.ReadCloser == nil {
+		return 0, errors.New("фильтр открыт только на запись")
+	}
+	return p.ReadCloser.Read(b)
+}
+
+func (p *filterProcess) Write(b []byte) (int, error) {
+	if p.WriteCloser == nil {
+		return 0, errors.New("фильтр открыт только на чтение")
+	}
+	return p.WriteCloser.Write(b)
+}
+
+// nopWriteCloser добавляет к io.Writer пустой Close
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// trimTarSuffix удаляет расширение tar-архива из пути
+func trimTarSuffix(path string) string {
+	lower := strings.ToLower(path)
+	for _, suffix := range tarSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return path[:len(path)-len(suffix)]
+		}
+	}
+	return path
+}
+
+// sizeDelta описывает изменение размера: "12.0 MB -> 9.5 MB (-20.8%)"
+func sizeDelta(before, after int64) string {
+	change := 0.0
+	if before > 0 {
+		change = float64(after-before) * 100 / float64(before)
+	}
+	return fmt.Sprintf("%s -> %s (%+.1f%%)", humanSize(before), humanSize(after), change)
+}
+
+// humanSize форматирует размер в байтах
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
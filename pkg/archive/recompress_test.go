@@ -0,0 +1,150 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+func TestFilterReaderUsesRunner(t *testing.T) {
+	fake := runner.NewFakeRunner().On("xz -d -c", "распаковано", nil)
+	em := &ExtractManager{Runner: fake}
+
+	r, err := em.decompressReader("xz", strings.NewReader("сжато"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "распаковано" {
+		t.Errorf("вывод %q", data)
+	}
+	if commands := fake.Commands(); len(commands) != 1 || commands[0] != "xz -d -c" {
+		t.Errorf("команды %q", commands)
+	}
+}
+
+func TestFilterWriterUsesRunner(t *testing.T) {
+	fake := runner.NewFakeRunner().On("xz -c -9 -T2", "сжато", nil)
+	em := &ExtractManager{Runner: fake}
+
+	var out bytes.Buffer
+	w, err := em.compressWriter("xz", &out, CreateOptions{CompressionLevel: 9, Threads: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("данные")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "сжато" {
+		t.Errorf("вывод %q", out.String())
+	}
+}
+
+func TestFilterReaderReportsFailure(t *testing.T) {
+	fake := runner.NewFakeRunner().On("lz4", "", errors.New("exit status 1"))
+	em := &ExtractManager{Runner: fake}
+
+	r, err := em.decompressReader("lz4", strings.NewReader("мусор"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("чтение должно вернуть ошибку утилиты")
+	}
+	if err := r.Close(); err == nil || !strings.Contains(err.Error(), "lz4") {
+		t.Errorf("Close: %v", err)
+	}
+
+	fake.Missing["lz4"] = true
+	if _, err := em.decompressReader("lz4", strings.NewReader("")); err == nil {
+		t.Error("отсутствующая утилита должна давать ошибку")
+	}
+}
+
+func TestRecompressThroughXZ(t *testing.T) {
+	if _, err := exec.LookPath("xz"); err != nil {
+		t.Skip("xz не установлен")
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data.txt")
+	if err := os.WriteFile(file, []byte(strings.Repeat("go-to-run\n", 100)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	em := &ExtractManager{PreferNative: true}
+	src := filepath.Join(dir, "data.tar.gz")
+	if err := em.CreateArchive([]string{file}, src, "tar.gz"); err != nil {
+		t.Fatal(err)
+	}
+	if err := em.Recompress(src, "tar.xz", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Обратно через xz -d, чтобы проверить оба направления фильтра
+	if err := em.Recompress(filepath.Join(dir, "data.tar.xz"), "tar", CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "data.tar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("go-to-run\n")) {
+		t.Error("содержимое потеряно при пересжатии")
+	}
+}
+
+func TestRecompressTarGzToZst(t *testing.T) {
+	parent := t.TempDir()
+	src := filepath.Join(parent, "tree")
+	sampleTree(t, src, 20)
+	t.Chdir(parent)
+
+	// Без внешних утилит: gzip и zstd обрабатываются встроенными кодеками
+	fake := missingRunner("tar", "gzip", "zstd")
+	em := &ExtractManager{Runner: fake, PreferNative: true}
+	archivePath := filepath.Join(t.TempDir(), "tree.tar.gz")
+	if err := em.CreateArchiveWithOptions([]string{"tree"}, archivePath, "tar.gz", CreateOptions{IncludeSymlinks: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := em.Recompress(archivePath, "tzst", CreateOptions{CompressionLevel: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if commands := fake.Commands(); len(commands) != 0 {
+		t.Errorf("запущены команды %q", commands)
+	}
+
+	converted := strings.TrimSuffix(archivePath, ".tar.gz") + ".tar.zst"
+	if got := em.detectArchiveType(converted); got != "tar.zst" {
+		t.Fatalf("тип результата %q", got)
+	}
+	outputDir := t.TempDir()
+	if err := em.ExtractWithOptions(converted, outputDir, DefaultExtractOptions()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := treeSnapshot(t, filepath.Join(outputDir, "tree")), treeSnapshot(t, src); !reflect.DeepEqual(got, want) {
+		t.Errorf("содержимое после пересжатия отличается:\n%v\n%v", got, want)
+	}
+
+	// zip нельзя пересжать без переупаковки, а результат не может совпадать с исходным
+	if err := em.Recompress(converted, "tar.zst", CreateOptions{}); err == nil {
+		t.Error("пересжатие в тот же формат должно давать ошибку")
+	}
+	if err := em.Recompress(buildZip(t, []string{"a"}, map[string]string{"a": "a"}), "tar.zst", CreateOptions{}); err == nil {
+		t.Error("zip не пересжимается в tar.zst")
+	}
+}
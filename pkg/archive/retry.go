@@ -0,0 +1,58 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// retryDelay - пауза перед повторным извлечением после временной ошибки
+var retryDelay = 2 * time.Second
+
+// transientMessages - сообщения утилит о временных сбоях ввода-вывода
+var transientMessages = []string{
+	"Input/output error",
+	"Stale file handle",
+}
+
+// isTransientError отличает временные сбои ввода-вывода от ошибок самого архива
+func isTransientError(err error) bool {
+	if errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ESTALE) {
+		return true
+	}
+	for _, msg := range transientMessages {
+		if strings.Contains(err.Error(), msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryTransient выполняет attempt, повторяя его до maxRetries раз при временных ошибках.
+// Перед каждым повтором вызывается cleanup для удаления частичного результата.
+// Отмена ctx прерывает паузу между повторами.
+func retryTransient(ctx context.Context, maxRetries int, delay time.Duration, cleanup func() error, attempt func() error) error {
+	var err error
+	for i := 0; i <= maxRetries; i++ {
+		if i > 0 {
+			if cerr := cleanup(); cerr != nil {
+				return fmt.Errorf("ошибка очистки перед повтором: %w", cerr)
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		err = attempt()
+		if err == nil || !isTransientError(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("временная ошибка не устранена после %d повторов: %w", maxRetries, err)
+}
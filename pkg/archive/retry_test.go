@@ -0,0 +1,182 @@
+package archive
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// noRetryDelay убирает паузу между повторами на время теста
+func noRetryDelay(t *testing.T) {
+	t.Helper()
+	prev := retryDelay
+	retryDelay = 0
+	t.Cleanup(func() { retryDelay = prev })
+}
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&fs.PathError{Op: "write", Path: "/mnt/nfs/x", Err: syscall.EIO}, true},
+		{fmt.Errorf("ошибка: %w", syscall.ESTALE), true},
+		{errors.New("tar: out/x: Cannot write: Input/output error"), true},
+		{errors.New("unzip: Stale file handle"), true},
+		{errors.New("gzip: stdin: not in gzip format"), false},
+		{errors.New("tar: This does not look like a tar archive"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransientError(tt.err); got != tt.want {
+			t.Errorf("isTransientError(%q) = %v, ожидалось %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryTransient(t *testing.T) {
+	transient := errors.New("read: Input/output error")
+	tests := []struct {
+		name     string
+		failures []error
+		retries  int
+		attempts int
+		cleanups int
+		wantErr  bool
+	}{
+		{"успех после временных ошибок", []error{transient, transient}, 3, 3, 2, false},
+		{"ошибка архива не повторяется", []error{errors.New("unexpected EOF")}, 3, 1, 0, true},
+		{"повторы исчерпаны", []error{transient, transient, transient}, 2, 3, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, cleanups := 0, 0
+			err := retryTransient(context.Background(), tt.retries, 0, func() error {
+				cleanups++
+				return nil
+			}, func() error {
+				attempts++
+				if attempts <= len(tt.failures) {
+					return tt.failures[attempts-1]
+				}
+				return nil
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if attempts != tt.attempts || cleanups != tt.cleanups {
+				t.Errorf("попыток %d и очисток %d, ожидалось %d и %d", attempts, cleanups, tt.attempts, tt.cleanups)
+			}
+		})
+	}
+}
+
+func TestRetryTransientCancelledDuringDelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	start := time.Now()
+	err := retryTransient(ctx, 3, time.Hour, func() error { return nil }, func() error {
+		attempts++
+		// Извлечение отменяется после первой неудачной попытки
+		cancel()
+		return errors.New("read: Input/output error")
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ошибка %v, ожидалась context.Canceled", err)
+	}
+	if attempts != 1 {
+		t.Errorf("попыток %d, ожидалась 1", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Minute {
+		t.Errorf("отмена не прервала паузу: %v", elapsed)
+	}
+}
+
+// tarFixture записывает во временную директорию простой tar-архив
+func tarFixture(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.tar")
+	data := buildTar(t, []tarEntry{{name: "file.txt", typeflag: tar.TypeReg, body: "data"}})
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractRetriesTransientToolFailure(t *testing.T) {
+	noRetryDelay(t)
+	fake := runner.NewFakeRunner().
+		On("tar", "", errors.New("tar: file.txt: Cannot write: Input/output error")).
+		On("tar", "", nil)
+	em := &ExtractManager{Runner: fake}
+
+	if err := em.ExtractWithOptions(tarFixture(t), t.TempDir(), ExtractOptions{MaxRetries: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if calls := extractCalls(fake); calls != 2 {
+		t.Errorf("tar запущен %d раз, ожидалось 2: %q", calls, fake.Commands())
+	}
+}
+
+func TestExtractDoesNotRetryCorruptArchive(t *testing.T) {
+	noRetryDelay(t)
+	fake := runner.NewFakeRunner().On("tar", "", errors.New("tar: This does not look like a tar archive"))
+	em := &ExtractManager{Runner: fake}
+
+	err := em.ExtractWithOptions(tarFixture(t), t.TempDir(), ExtractOptions{MaxRetries: 2})
+	if err == nil || !strings.Contains(err.Error(), "does not look like a tar archive") {
+		t.Fatalf("ошибка = %v, ожидалась ошибка архива", err)
+	}
+	if calls := extractCalls(fake); calls != 1 {
+		t.Errorf("tar запущен %d раз, ожидался один запуск: %q", calls, fake.Commands())
+	}
+}
+
+// extractCalls считает запуски tar -xf
+func extractCalls(fake *runner.FakeRunner) int {
+	calls := 0
+	for _, command := range fake.Commands() {
+		if strings.HasPrefix(command, "tar -xf") {
+			calls++
+		}
+	}
+	return calls
+}
+
+func TestExtractStagedCleansPartialOutputBeforeRetry(t *testing.T) {
+	noRetryDelay(t)
+	outputDir := t.TempDir()
+	attempts := 0
+	extract := func(dir string) error {
+		attempts++
+		if attempts == 1 {
+			if err := os.WriteFile(filepath.Join(dir, "partial"), []byte("x"), 0600); err != nil {
+				return err
+			}
+			return &fs.PathError{Op: "write", Path: filepath.Join(dir, "partial"), Err: syscall.EIO}
+		}
+		if _, err := os.Stat(filepath.Join(dir, "partial")); err == nil {
+			return errors.New("повтор начат с частичным результатом")
+		}
+		return os.WriteFile(filepath.Join(dir, "complete"), []byte("ok"), 0600)
+	}
+
+	if _, err := extractStaged(context.Background(), outputDir, ExtractOptions{MaxRetries: 1}, extract); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "partial")); err == nil {
+		t.Error("частичный результат неудачной попытки перенесен в директорию извлечения")
+	}
+	if data, err := os.ReadFile(filepath.Join(outputDir, "complete")); err != nil || string(data) != "ok" {
+		t.Errorf("результат повтора: %q, %v", data, err)
+	}
+}
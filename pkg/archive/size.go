@@ -0,0 +1,118 @@
+package archive
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// zstdSizePattern - размер после распаковки в выводе zstd -lv: "Decompressed Size: 3.00 KiB (3072 B)"
+var zstdSizePattern = regexp.MustCompile(`Decompressed Size:.*\((\d+) B\)`)
+
+// uncompressedSize возвращает размер содержимого после распаковки, если его можно
+// узнать без распаковки: из ISIZE gzip, xz --robot --list, заголовков zstd или центрального
+// каталога zip. 0 означает, что размер неизвестен.
+func (em *ExtractManager) uncompressedSize(archivePath, format string) int64 {
+	switch format {
+	case "gz", "tar.gz":
+		return gzipISize(archivePath)
+	case "xz", "tar.xz":
+		return em.xzListSize(archivePath)
+	case "zst", "tar.zst":
+		if size := em.zstdListSize(archivePath); size > 0 {
+			return size
+		}
+		return zstdFrameSize(archivePath)
+	case "zip":
+		if manifest, err := em.Inspect(archivePath); err == nil {
+			return manifest.TotalSize
+		}
+	}
+	return 0
+}
+
+// gzipISize читает поле ISIZE - последние 4 байта gzip: размер исходных данных
+// по модулю 2^32. Для содержимого больше 4 ГиБ значение неверно; такой размер
+// обычно заметно меньше сжатого, чего без переполнения не бывает, и считается неизвестным.
+func gzipISize(archivePath string) int64 {
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.Size() < 18 {
+		return 0
+	}
+	var tail [4]byte
+	if _, err := f.ReadAt(tail[:], info.Size()-4); err != nil {
+		return 0
+	}
+	size := int64(binary.LittleEndian.Uint32(tail[:]))
+	// Несжимаемые данные deflate увеличивает лишь на доли процента и заголовок
+	if size+size/1000+64 < info.Size() {
+		return 0
+	}
+	return size
+}
+
+// xzListSize разбирает строку totals вывода xz --robot --list:
+// "totals	1	1	300080	300000	1.000	CRC64	0	1", пятое поле - размер после распаковки
+func (em *ExtractManager) xzListSize(archivePath string) int64 {
+	output, err := em.runner().Output("xz", "--robot", "--list", argPath(archivePath))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 5 || fields[0] != "totals" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return size
+	}
+	return 0
+}
+
+// zstdListSize извлекает размер после распаковки из вывода zstd -lv; строки нет,
+// если размер не записан в заголовках кадров
+func (em *ExtractManager) zstdListSize(archivePath string) int64 {
+	output, err := em.runner().CombinedOutput("zstd", "-lv", argPath(archivePath))
+	if err != nil {
+		return 0
+	}
+	match := zstdSizePattern.FindSubmatch(output)
+	if match == nil {
+		return 0
+	}
+	size, _ := strconv.ParseInt(string(match[1]), 10, 64)
+	return size
+}
+
+// zstdFrameSize читает размер из заголовка первого кадра zstd. Используется без утилиты
+// zstd; для файла из нескольких кадров (zstd -T) это лишь нижняя граница.
+func zstdFrameSize(archivePath string) int64 {
+	f, err := os.Open(filepath.Clean(archivePath))
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	head := make([]byte, zstd.HeaderMaxSize)
+	n, _ := io.ReadFull(f, head)
+	var hdr zstd.Header
+	if err := hdr.Decode(head[:n]); err != nil || !hdr.HasFCS {
+		return 0
+	}
+	return int64(hdr.FrameContentSize)
+}
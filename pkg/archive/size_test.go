@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+func TestGzipISize(t *testing.T) {
+	dir := t.TempDir()
+	data := bytes.Repeat([]byte("go-to-run "), 12345)
+	path := filepath.Join(dir, "data.gz")
+	if err := os.WriteFile(path, gzipBytes(t, data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if size := gzipISize(path); size != int64(len(data)) {
+		t.Errorf("gzipISize() = %d, ожидалось %d", size, len(data))
+	}
+
+	info, err := (&ExtractManager{Runner: runner.NewFakeRunner()}).GetArchiveInfo(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.UncompressedSize != int64(len(data)) {
+		t.Errorf("UncompressedSize = %d, ожидалось %d", info.UncompressedSize, len(data))
+	}
+}
+
+func TestGzipISizeUnknown(t *testing.T) {
+	dir := t.TempDir()
+
+	// Файл короче минимального gzip (заголовок и ISIZE) не читается
+	short := filepath.Join(dir, "short.gz")
+	if err := os.WriteFile(short, []byte{0x1f, 0x8b, 0x08, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if size := gzipISize(short); size != 0 {
+		t.Errorf("для короткого файла размер %d, ожидалось 0", size)
+	}
+
+	// ISIZE меньше сжатого размера бывает только после переполнения (содержимое больше 4 ГиБ)
+	random := make([]byte, 64*1024)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatal(err)
+	}
+	wrapped := gzipBytes(t, random)
+	copy(wrapped[len(wrapped)-4:], []byte{0x10, 0, 0, 0})
+	overflow := filepath.Join(dir, "overflow.gz")
+	if err := os.WriteFile(overflow, wrapped, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if size := gzipISize(overflow); size != 0 {
+		t.Errorf("для переполненного ISIZE размер %d, ожидалось 0", size)
+	}
+
+	if size := gzipISize(filepath.Join(dir, "missing.gz")); size != 0 {
+		t.Errorf("для отсутствующего файла размер %d", size)
+	}
+}
+
+func TestUncompressedSizeFromTools(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.xz")
+	if err := os.WriteFile(path, []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	fake := runner.NewFakeRunner().
+		On("xz", "name\tdata.xz\ntotals\t1\t1\t300080\t300000\t1.000\tCRC64\t0\t1\n", nil).
+		On("zstd", "Decompressed Size: 2.93 KiB (3000 B)\n", nil)
+	em := &ExtractManager{Runner: fake}
+
+	if size := em.uncompressedSize(path, "tar.xz"); size != 300000 {
+		t.Errorf("xz: размер %d, ожидалось 300000", size)
+	}
+	if size := em.uncompressedSize(path, "zst"); size != 3000 {
+		t.Errorf("zstd: размер %d, ожидалось 3000", size)
+	}
+}
+
+func TestZstdFrameSize(t *testing.T) {
+	data := bytes.Repeat([]byte("x"), 5000)
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// EncodeAll записывает размер содержимого в заголовок кадра
+	frame := enc.EncodeAll(data, nil)
+	_ = enc.Close()
+
+	path := filepath.Join(t.TempDir(), "data.zst")
+	if err := os.WriteFile(path, frame, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if size := zstdFrameSize(path); size != int64(len(data)) {
+		t.Errorf("zstdFrameSize() = %d, ожидалось %d", size, len(data))
+	}
+	// Без утилиты zstd размер читается из заголовка кадра
+	em := &ExtractManager{Runner: missingRunner("zstd")}
+	if size := em.uncompressedSize(path, "zst"); size != int64(len(data)) {
+		t.Errorf("uncompressedSize() = %d, ожидалось %d", size, len(data))
+	}
+}
@@ -0,0 +1,166 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/13winged/go-to-run/internal/ui"
+)
+
+// Сигнатуры форматов, распознаваемых по началу потока
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	// tarMagic находится по смещению 257 в заголовке первой записи
+	tarMagic       = []byte("ustar")
+	tarMagicOffset = 257
+)
+
+// streamFormatAliases приводит короткие имена форматов к каноническим
+var streamFormatAliases = map[string]string{
+	"tgz":  "tar.gz",
+	"tzst": "tar.zst",
+	"tbz2": "tar.bz2",
+	"txz":  "tar.xz",
+}
+
+// ExtractStream извлекает архив из потока r в outputDir, а без него - в opts.OutputDir.
+// Пустой format определяется по сигнатуре начала потока (tar, tar.gz, tar.zst).
+// Форматы tar, tar.gz и tar.zst извлекаются встроенными средствами без временного файла,
+// остальные сначала сохраняются во временный файл и передаются внешним утилитам.
+func (em *ExtractManager) ExtractStream(r io.Reader, format, outputDir string, opts ExtractOptions) error {
+	if outputDir == "" {
+		outputDir = opts.OutputDir
+	}
+	if outputDir == "" {
+		return fmt.Errorf("не указана директория для извлечения")
+	}
+	if opts.StripComponents < 0 {
+		return fmt.Errorf("некорректное число удаляемых компонентов пути: %d", opts.StripComponents)
+	}
+	if !validConflictPolicy(opts.OnConflict) {
+		return fmt.Errorf("неизвестная политика конфликтов: %s", opts.OnConflict)
+	}
+	if !validUnsafePolicy(em.UnsafeEntries) {
+		return fmt.Errorf("неизвестная политика небезопасных записей: %s", em.UnsafeEntries)
+	}
+
+	br := bufio.NewReader(r)
+	format = strings.TrimPrefix(strings.ToLower(format), ".")
+	if alias, ok := streamFormatAliases[format]; ok {
+		format = alias
+	}
+	if format == "" {
+		var err error
+		if format, err = detectStreamFormat(br); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(outputDir, 0750); err != nil {
+		return fmt.Errorf("ошибка создания директории: %w", err)
+	}
+
+	if opts.ShowProgress {
+		s := ui.NewSpinner("Извлечение архива...")
+		s.Start()
+		defer s.Stop()
+	}
+
+	if (opts.OnConflict != "" || opts.needsFixup()) && isNativeStreamFormat(format) {
+		// Поток нельзя прочитать повторно, поэтому MaxRetries к нему не применяется
+		staged := ExtractOptions{
+			OnConflict: opts.OnConflict,
+			Chown:      opts.Chown,
+			ChmodDir:   opts.ChmodDir,
+			ChmodFile:  opts.ChmodFile,
+			TempDir:    opts.TempDir,
+		}
+		_, err := extractStaged(context.Background(), outputDir, staged, func(dir string) error {
+			return em.extractStreamNative(br, format, dir, opts)
+		})
+		return err
+	}
+	if isNativeStreamFormat(format) {
+		return em.extractStreamNative(br, format, outputDir, opts)
+	}
+	return em.extractSpooled(br, format, outputDir, opts)
+}
+
+// isNativeStreamFormat сообщает, извлекается ли формат из потока без временного файла
+func isNativeStreamFormat(format string) bool {
+	return format == "tar" || format == "tar.gz" || format == "tar.zst"
+}
+
+// extractStreamNative извлекает tar, tar.gz или tar.zst из потока встроенными средствами
+func (em *ExtractManager) extractStreamNative(br *bufio.Reader, format, outputDir string, opts ExtractOptions) error {
+	switch format {
+	case "tar":
+		return extractTarNative(br, outputDir, em.workers(), opts, em.newGuard())
+	case "tar.gz":
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения gzip: %w", err)
+		}
+		defer gz.Close()
+		return extractTarNative(gz, outputDir, em.workers(), opts, em.newGuard())
+	case "tar.zst":
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения zstd: %w", err)
+		}
+		defer zr.Close()
+		return extractTarNative(zr, outputDir, em.workers(), opts, em.newGuard())
+	default:
+		return fmt.Errorf("формат %s не извлекается из потока", format)
+	}
+}
+
+// detectStreamFormat определяет формат потока по сигнатуре, не извлекая данные из буфера
+func detectStreamFormat(br *bufio.Reader) (string, error) {
+	head, err := br.Peek(tarMagicOffset + len(tarMagic))
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", fmt.Errorf("ошибка чтения потока: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return "tar.gz", nil
+	case bytes.HasPrefix(head, zstdMagic):
+		return "tar.zst", nil
+	case isTarHeader(head):
+		return "tar", nil
+	default:
+		return "", fmt.Errorf("не удалось определить формат потока, укажите его явно")
+	}
+}
+
+// extractSpooled сохраняет поток во временный файл и извлекает его обычным путем.
+// Нужен форматам, которые поддерживаются только внешними утилитами.
+func (em *ExtractManager) extractSpooled(r io.Reader, format, outputDir string, opts ExtractOptions) error {
+	tmp, err := os.CreateTemp(spoolDir(opts.TempDir), "go-to-run-stream-*."+format)
+	if err != nil {
+		return fmt.Errorf("ошибка создания временного файла: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("ошибка сохранения потока: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("ошибка сохранения потока: %w", err)
+	}
+
+	// Индикатор уже показан вызывающей стороной
+	opts.ShowProgress = false
+	return em.ExtractWithOptions(tmp.Name(), outputDir, opts)
+}
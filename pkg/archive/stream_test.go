@@ -0,0 +1,117 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// streamEntries - содержимое тестового архива для потокового извлечения
+var streamEntries = []tarEntry{
+	{name: "app/", typeflag: tar.TypeDir},
+	{name: "app/config.yaml", typeflag: tar.TypeReg, body: "port: 8080\n"},
+	{name: "app/bin/run.sh", typeflag: tar.TypeReg, body: "#!/bin/sh\n"},
+}
+
+// gzipBytes сжимает data gzip в памяти
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractStreamTarGzFromReader(t *testing.T) {
+	archive := gzipBytes(t, buildTar(t, streamEntries))
+	for _, format := range []string{"tar.gz", ".TGZ", ""} {
+		t.Run("format="+format, func(t *testing.T) {
+			outputDir := filepath.Join(t.TempDir(), "out")
+			// Внешние утилиты не нужны: поток извлекается встроенными средствами
+			em := &ExtractManager{Runner: missingRunner("tar", "gzip", "gunzip")}
+
+			if err := em.ExtractStream(bytes.NewReader(archive), format, outputDir, ExtractOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			checkFiles(t, outputDir, map[string]string{"app/config.yaml": "port: 8080\n", "app/bin/run.sh": "#!/bin/sh\n"})
+		})
+	}
+}
+
+func TestExtractStreamDetectsZstd(t *testing.T) {
+	var buf bytes.Buffer
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := zw.Write(buildTar(t, streamEntries)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	outputDir := t.TempDir()
+	em := &ExtractManager{Runner: missingRunner("tar", "zstd")}
+	if err := em.ExtractStream(&buf, "", outputDir, ExtractOptions{StripComponents: 1}); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, outputDir, map[string]string{"config.yaml": "port: 8080\n", "bin/run.sh": "#!/bin/sh\n"})
+}
+
+func TestExtractStreamUnknownFormat(t *testing.T) {
+	em := &ExtractManager{Runner: runner.NewFakeRunner()}
+	err := em.ExtractStream(strings.NewReader("plain text, not an archive"), "", t.TempDir(), ExtractOptions{})
+	if err == nil || !strings.Contains(err.Error(), "укажите его явно") {
+		t.Errorf("ошибка %v, ожидалась просьба указать формат", err)
+	}
+	if err := em.ExtractStream(strings.NewReader(""), "tar.gz", "", ExtractOptions{}); err == nil {
+		t.Error("ожидалась ошибка без директории извлечения")
+	}
+}
+
+func TestExtractStreamUsesOptionsOutputDir(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "out")
+	em := &ExtractManager{Runner: missingRunner("tar")}
+	if err := em.ExtractStream(bytes.NewReader(buildTar(t, streamEntries)), "tar", "", ExtractOptions{OutputDir: outputDir}); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, outputDir, map[string]string{"app/config.yaml": "port: 8080\n"})
+}
+
+func TestExtractStreamSpoolsExternalFormats(t *testing.T) {
+	fake := runner.NewFakeRunner()
+	em := &ExtractManager{Runner: fake}
+	outputDir := t.TempDir()
+	spool := t.TempDir()
+
+	err := em.ExtractStream(strings.NewReader("BZh91AY&SY"), "tbz2", outputDir, ExtractOptions{TempDir: spool})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var extract []string
+	for _, call := range fake.Calls {
+		if call.Name == "tar" && len(call.Args) > 0 && call.Args[0] == "-xjf" {
+			extract = call.Args
+		}
+	}
+	if len(extract) < 4 || filepath.Dir(extract[1]) != spool || extract[3] != outputDir {
+		t.Fatalf("tar вызван не для временного файла в %s: %q", spool, fake.Commands())
+	}
+	if _, err := os.Stat(extract[1]); !os.IsNotExist(err) {
+		t.Errorf("временный файл %s не удален: %v", extract[1], err)
+	}
+}
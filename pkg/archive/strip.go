@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"archive/zip"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// supportsStrip сообщает, поддерживает ли формат удаление ведущих компонентов путей
+func supportsStrip(archiveType string) bool {
+	switch archiveType {
+	case "tar.gz", "tgz", "tar.bz2", "tbz2", "tar.xz", "txz", "tar", "tar.zst", "tar.lz4", "zip":
+		return true
+	default:
+		return false
+	}
+}
+
+// stripPath удаляет strip ведущих компонентов из имени записи архива.
+// Возвращает false, если после удаления от имени ничего не осталось.
+func stripPath(name string, strip int) (string, bool) {
+	if strip == 0 {
+		return name, true
+	}
+	parts := strings.Split(strings.Trim(normalizeEntry(name), "/"), "/")
+	if len(parts) <= strip {
+		return "", false
+	}
+	return strings.Join(parts[strip:], "/"), true
+}
+
+// normalizeEntry убирает ведущие "./" из имени записи
+func normalizeEntry(name string) string {
+	for strings.HasPrefix(name, "./") {
+		name = strings.TrimPrefix(name, "./")
+	}
+	return name
+}
+
+// drivePrefix - буква диска Windows в начале имени записи: C: или C:\
+var drivePrefix = regexp.MustCompile(`^[A-Za-z]:[\\/]?`)
+
+// sanitizeEntry убирает из имени записи абсолютный префикс: UNC-префикс (\\server\share\),
+// букву диска (C:\) и ведущие слеши. Возвращает true, если префикс был удален.
+func sanitizeEntry(name string) (string, bool) {
+	result := name
+	if strings.HasPrefix(result, `\\`) {
+		// \\server\share\path: сервер и общий ресурс не входят в путь
+		parts := strings.SplitN(strings.TrimLeft(result, `\`), `\`, 3)
+		result = ""
+		if len(parts) == 3 {
+			result = parts[2]
+		}
+	}
+	result = drivePrefix.ReplaceAllString(result, "")
+	result = strings.TrimLeft(result, "/")
+	return result, result != name
+}
+
+// topLevelDir проверяет, лежат ли все записи архива в одной директории верхнего уровня,
+// и возвращает ее имя. Файл в корне архива или несколько директорий дают false.
+func topLevelDir(entries []string) (string, bool) {
+	var top string
+	for _, entry := range entries {
+		entry = normalizeEntry(entry)
+		if entry == "" || entry == "." {
+			continue
+		}
+		first, _, isDir := strings.Cut(entry, "/")
+		if !isDir || first == ".." {
+			return "", false
+		}
+		if top == "" {
+			top = first
+		} else if first != top {
+			return "", false
+		}
+	}
+	return top, top != ""
+}
+
+// archiveEntries возвращает имена записей tar- и zip-архивов без извлечения.
+// Для остальных форматов возвращается nil.
+func (em *ExtractManager) archiveEntries(archivePath string) []string {
+	archiveType := em.detectArchiveType(archivePath)
+	switch archiveType {
+	case "zip":
+		zr, err := zip.OpenReader(filepath.Clean(archivePath))
+		if err != nil {
+			return nil
+		}
+		defer zr.Close()
+		entries := make([]string, 0, len(zr.File))
+		for _, f := range zr.File {
+			entries = append(entries, f.Name)
+		}
+		return entries
+	default:
+		if !supportsStrip(archiveType) {
+			return nil
+		}
+		output, err := em.runner().Output("tar", em.tarListArgs(archiveType, argPath(archivePath))...)
+		if err != nil {
+			return nil
+		}
+		return strings.Split(strings.TrimSpace(string(output)), "\n")
+	}
+}
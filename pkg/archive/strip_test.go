@@ -0,0 +1,134 @@
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTopLevelDir(t *testing.T) {
+	tests := []struct {
+		entries []string
+		want    string
+		ok      bool
+	}{
+		{[]string{"project/", "project/README", "project/src/main.go"}, "project", true},
+		{[]string{"./project/", "./project/README"}, "project", true},
+		{[]string{"./", "./project/a", "./project/b"}, "project", true},
+		{[]string{"project/a", "other/b"}, "", false},
+		{[]string{"project/a", "README"}, "", false},
+		{[]string{"README"}, "", false},
+		{[]string{"../project/a"}, "", false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := topLevelDir(tt.entries)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("topLevelDir(%q) = %q, %v, ожидалось %q, %v", tt.entries, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestStripPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		strip int
+		want  string
+		ok    bool
+	}{
+		{"project/src/main.go", 0, "project/src/main.go", true},
+		{"project/src/main.go", 1, "src/main.go", true},
+		{"./project/src/main.go", 2, "main.go", true},
+		{"project/src/", 1, "src", true},
+		{"project/", 1, "", false},
+		{"project/src/main.go", 3, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := stripPath(tt.name, tt.strip)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("stripPath(%q, %d) = %q, %v, ожидалось %q, %v", tt.name, tt.strip, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// nestedArchives создает tar и zip, все записи которых лежат в project/
+func nestedArchives(t *testing.T) map[string]string {
+	t.Helper()
+	names := []string{"project/README", "project/src/main.go"}
+	contents := map[string]string{"project/README": "readme", "project/src/main.go": "package main"}
+
+	entries := []tarEntry{{name: "project/", typeflag: tar.TypeDir}, {name: "project/src/", typeflag: tar.TypeDir}}
+	for _, name := range names {
+		entries = append(entries, tarEntry{name: name, typeflag: tar.TypeReg, body: contents[name]})
+	}
+	tarPath := filepath.Join(t.TempDir(), "project.tar")
+	if err := os.WriteFile(tarPath, buildTar(t, entries), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]string{"tar": tarPath, "zip": buildZip(t, names, contents)}
+}
+
+// checkFiles проверяет содержимое файлов относительно dir
+func checkFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	for name, content := range want {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s: %q, %v, ожидалось %q", name, data, err, content)
+		}
+	}
+}
+
+func TestExtractStripComponents(t *testing.T) {
+	for format, archivePath := range nestedArchives(t) {
+		for _, native := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/native=%v", format, native), func(t *testing.T) {
+				outputDir := t.TempDir()
+				em := &ExtractManager{PreferNative: native}
+
+				if err := em.ExtractWithOptions(archivePath, outputDir, ExtractOptions{StripComponents: 1}); err != nil {
+					t.Fatal(err)
+				}
+				checkFiles(t, outputDir, map[string]string{"README": "readme", "src/main.go": "package main"})
+				if _, err := os.Stat(filepath.Join(outputDir, "project")); err == nil {
+					t.Error("ведущий компонент project не удален")
+				}
+			})
+		}
+	}
+}
+
+func TestExtractRejectsNegativeStripComponents(t *testing.T) {
+	em := &ExtractManager{PreferNative: true}
+	err := em.ExtractWithOptions(nestedArchives(t)["tar"], t.TempDir(), ExtractOptions{StripComponents: -1})
+	if err == nil {
+		t.Fatal("ожидалась ошибка для отрицательного StripComponents")
+	}
+}
+
+func TestExtractSmartStrip(t *testing.T) {
+	for format, archivePath := range nestedArchives(t) {
+		t.Run(format, func(t *testing.T) {
+			em := &ExtractManager{}
+			if err := em.ExtractWithOptions(archivePath, "", ExtractOptions{SmartStrip: true}); err != nil {
+				t.Fatal(err)
+			}
+			dir := filepath.Dir(archivePath)
+			checkFiles(t, dir, map[string]string{"project/README": "readme", "project/src/main.go": "package main"})
+			if _, err := os.Stat(filepath.Join(dir, "project", "project")); err == nil {
+				t.Error("директория project вложена дважды")
+			}
+		})
+	}
+}
+
+func TestExtractSmartStripKeepsWrapperForFlatArchive(t *testing.T) {
+	archivePath := buildZip(t, []string{"README", "main.go"}, map[string]string{"README": "readme", "main.go": "package main"})
+	em := &ExtractManager{}
+	if err := em.ExtractWithOptions(archivePath, "", ExtractOptions{SmartStrip: true}); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, filepath.Join(filepath.Dir(archivePath), "test"), map[string]string{"README": "readme", "main.go": "package main"})
+}
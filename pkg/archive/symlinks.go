@@ -0,0 +1,133 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrSymlinkLoop возвращается, если при переходе по ссылкам директория содержит саму себя
+var ErrSymlinkLoop = errors.New("обнаружен цикл символических ссылок")
+
+// symlinkMode определяет, как символические ссылки попадают в создаваемый архив
+type symlinkMode int
+
+const (
+	// symlinksSkip - ссылки не сохраняются
+	symlinksSkip symlinkMode = iota
+	// symlinksStore - ссылки сохраняются как ссылки
+	symlinksStore
+	// symlinksFollow - сохраняется содержимое, на которое указывает ссылка
+	symlinksFollow
+)
+
+// symlinks возвращает режим обработки ссылок; FollowSymlinks важнее IncludeSymlinks
+func (o CreateOptions) symlinks() symlinkMode {
+	switch {
+	case o.FollowSymlinks:
+		return symlinksFollow
+	case o.IncludeSymlinks:
+		return symlinksStore
+	default:
+		return symlinksSkip
+	}
+}
+
+// walkFilesMode обходит файлы и директории с заданной обработкой ссылок.
+// Имена записей повторяют переданные пути, как в walkFiles.
+func walkFilesMode(files []string, mode symlinkMode, fn func(path, name string, info os.FileInfo) error) error {
+	if mode == symlinksFollow {
+		for _, root := range files {
+			info, err := os.Stat(root)
+			if err != nil {
+				return fmt.Errorf("ошибка чтения %s: %w", root, err)
+			}
+			if err := walkFollow(root, info, nil, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walkFiles(files, func(path, name string, info os.FileInfo) error {
+		if mode == symlinksSkip && info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+		return fn(path, name, info)
+	})
+}
+
+// walkFollow рекурсивно обходит path, переходя по ссылкам.
+// ancestors - директории на пути от корня: встреча одной из них означает цикл.
+func walkFollow(path string, info os.FileInfo, ancestors []os.FileInfo, fn func(path, name string, info os.FileInfo) error) error {
+	if name := entryName(path); name != "" {
+		if err := fn(path, name, info); err != nil {
+			return err
+		}
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	for _, ancestor := range ancestors {
+		if os.SameFile(ancestor, info) {
+			return fmt.Errorf("%w: %s", ErrSymlinkLoop, path)
+		}
+	}
+	ancestors = append(ancestors, info)
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return fmt.Errorf("ошибка чтения %s: %w", path, err)
+	}
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childInfo, err := os.Stat(child)
+		if err != nil {
+			return fmt.Errorf("ошибка чтения %s: %w", child, err)
+		}
+		if err := walkFollow(child, childInfo, ancestors, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSymlinkLoops проверяет перед запуском внешней утилиты, что переход по ссылкам конечен
+func checkSymlinkLoops(files []string) error {
+	return walkFilesMode(files, symlinksFollow, func(string, string, os.FileInfo) error { return nil })
+}
+
+// tarInputArgs возвращает аргументы tar, задающие входные файлы с учетом обработки ссылок:
+// -h для перехода по ссылкам, а для пропуска ссылок - заранее составленный список файлов.
+// cleanup удаляет временный список и должен вызываться после завершения tar.
+func tarInputArgs(files []string, opts CreateOptions) (args []string, cleanup func(), err error) {
+	cleanup = func() {}
+	switch opts.symlinks() {
+	case symlinksFollow:
+		if err := checkSymlinkLoops(files); err != nil {
+			return nil, cleanup, err
+		}
+		return append([]string{"-h"}, files...), cleanup, nil
+	case symlinksStore:
+		return files, cleanup, nil
+	}
+
+	list, err := os.CreateTemp(spoolDir(opts.TempDir), "go-to-run-files-*")
+	if err != nil {
+		return nil, cleanup, fmt.Errorf("ошибка создания списка файлов: %w", err)
+	}
+	cleanup = func() { _ = os.Remove(list.Name()) }
+	// Имена разделяются нулевым байтом: так tar не воспринимает строки списка как параметры
+	err = walkFilesMode(files, symlinksSkip, func(path, _ string, _ os.FileInfo) error {
+		_, err := list.WriteString(path + "\x00")
+		return err
+	})
+	if closeErr := list.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return nil, func() {}, fmt.Errorf("ошибка создания списка файлов: %w", err)
+	}
+	return []string{"--null", "--no-recursion", "-T", list.Name()}, cleanup, nil
+}
@@ -0,0 +1,160 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// storedEntry описывает, как запись попала в архив
+type storedEntry struct {
+	symlink bool
+	// content - содержимое файла или цель ссылки
+	content string
+}
+
+// symlinkTree создает в текущей директории tree/data.txt и ссылку tree/link на него
+func symlinkTree(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	if err := os.MkdirAll("tree", 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("tree", "data.txt"), []byte("payload"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("data.txt", filepath.Join("tree", "link")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readStoredEntries возвращает файлы и ссылки tar- или zip-архива по именам
+func readStoredEntries(t *testing.T, archivePath string) map[string]storedEntry {
+	t.Helper()
+	entries := make(map[string]storedEntry)
+	if filepath.Ext(archivePath) == ".zip" {
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			entries[f.Name] = storedEntry{symlink: f.Mode()&os.ModeSymlink != 0, content: string(data)}
+		}
+		return entries
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	tr := tar.NewReader(file)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeSymlink:
+			entries[hdr.Name] = storedEntry{symlink: true, content: hdr.Linkname}
+		case tar.TypeReg:
+			data, _ := io.ReadAll(tr)
+			entries[hdr.Name] = storedEntry{content: string(data)}
+		case tar.TypeLink:
+			// tar -h сохраняет повторно встреченный файл жесткой ссылкой на первую запись
+			entries[hdr.Name] = storedEntry{content: entries[hdr.Linkname].content}
+		}
+	}
+}
+
+func TestCreateArchiveSymlinkHandling(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CreateOptions
+		// want - как сохранена tree/link; nil - ссылка не попала в архив
+		want *storedEntry
+	}{
+		{"follow", CreateOptions{FollowSymlinks: true}, &storedEntry{content: "payload"}},
+		{"include", CreateOptions{IncludeSymlinks: true}, &storedEntry{symlink: true, content: "data.txt"}},
+		{"skip", CreateOptions{}, nil},
+	}
+	tools := map[string]string{"tar": "tar", "zip": "zip"}
+	for format, tool := range tools {
+		for _, native := range []bool{false, true} {
+			var r runner.CommandRunner
+			if native {
+				r = missingRunner(tool)
+			} else if _, err := exec.LookPath(tool); err != nil {
+				continue
+			}
+			em := &ExtractManager{Runner: r}
+			for _, tt := range tests {
+				name := format + "/" + tt.name
+				if native {
+					name += "/native"
+				}
+				t.Run(name, func(t *testing.T) {
+					symlinkTree(t)
+					archivePath := filepath.Join(t.TempDir(), "tree."+format)
+					if err := em.CreateArchiveWithOptions([]string{"tree"}, archivePath, format, tt.opts); err != nil {
+						t.Fatal(err)
+					}
+					entries := readStoredEntries(t, archivePath)
+					if got := entries["tree/data.txt"]; got != (storedEntry{content: "payload"}) {
+						t.Errorf("tree/data.txt: %+v", got)
+					}
+					got, ok := entries["tree/link"]
+					switch {
+					case tt.want == nil && ok:
+						t.Errorf("ссылка сохранена: %+v", got)
+					case tt.want != nil && got != *tt.want:
+						t.Errorf("tree/link: %+v (есть: %v), ожидалось %+v", got, ok, *tt.want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestCreateArchiveSymlinkLoop(t *testing.T) {
+	symlinkTree(t)
+	// Ссылка на родительскую директорию при переходе по ссылкам дает бесконечное дерево
+	if err := os.Symlink("..", filepath.Join("tree", "parent")); err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"tar", "zip", "tar.gz"} {
+		for _, r := range []runner.CommandRunner{nil, missingRunner("tar", "zip")} {
+			em := &ExtractManager{Runner: r}
+			archivePath := filepath.Join(t.TempDir(), "loop."+format)
+			err := em.CreateArchiveWithOptions([]string{"tree"}, archivePath, format, CreateOptions{FollowSymlinks: true})
+			if !errors.Is(err, ErrSymlinkLoop) {
+				t.Errorf("%s (native=%v): ожидалась ErrSymlinkLoop, получено %v", format, r != nil, err)
+			}
+		}
+	}
+	// Без перехода по ссылкам цикла нет
+	em := &ExtractManager{Runner: missingRunner("tar")}
+	if err := em.CreateArchiveWithOptions([]string{"tree"}, filepath.Join(t.TempDir(), "ok.tar"), "tar", CreateOptions{IncludeSymlinks: true}); err != nil {
+		t.Errorf("сохранение ссылок как ссылок: %v", err)
+	}
+}
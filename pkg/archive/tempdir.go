@@ -0,0 +1,175 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrInsufficientSpace возвращается, если во временной директории не хватит места для операции
+var ErrInsufficientSpace = errors.New("недостаточно места во временной директории")
+
+// rename переименовывает файл или директорию; подменяется в тестах, чтобы проверить перенос
+// между файловыми системами
+var rename = os.Rename
+
+// stagingParent возвращает директорию, в которой создается временная директория извлечения.
+// По умолчанию это сама outputDir: результат переносится в нее атомарным переименованием.
+func stagingParent(outputDir, tempDir string) string {
+	if tempDir != "" {
+		return tempDir
+	}
+	return outputDir
+}
+
+// spoolDir возвращает директорию временных файлов: tempDir или os.TempDir(), учитывающий TMPDIR
+func spoolDir(tempDir string) string {
+	if tempDir != "" {
+		return tempDir
+	}
+	return os.TempDir()
+}
+
+// checkFreeSpace проверяет, что в dir доступно для записи не меньше need байт
+func checkFreeSpace(dir string, need int64) error {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return fmt.Errorf("ошибка проверки свободного места в %s: %w", dir, err)
+	}
+	free := int64(st.Bavail) * int64(st.Bsize)
+	if free < need {
+		return fmt.Errorf("%w: %s: нужно %s, свободно %s", ErrInsufficientSpace, dir, humanSize(need), humanSize(free))
+	}
+	return nil
+}
+
+// estimateExtractedSize оценивает место, которое займет извлеченный архив.
+// Если размер после распаковки нельзя узнать дешево (см. uncompressedSize),
+// берется размер самого архива - нижняя граница.
+func (em *ExtractManager) estimateExtractedSize(archivePath string) int64 {
+	if size := em.uncompressedSize(archivePath, em.detectArchiveType(archivePath)); size > 0 {
+		return size
+	}
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// moveEntry переносит файл, ссылку или директорию. Временная директория на другой
+// файловой системе не переименовывается (EXDEV), тогда содержимое копируется и удаляется.
+func moveEntry(from, to string) error {
+	err := rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if err := copyTree(from, to); err != nil {
+		_ = os.RemoveAll(to)
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+// moveFile переносит файл в dst, оставаясь атомарным и между файловыми системами:
+// копия сначала пишется во временный файл рядом с dst и затем переименовывается
+func moveFile(src, dst string) error {
+	err := rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".go-to-run-move-*")
+	if err != nil {
+		return err
+	}
+	_ = tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := copyTree(src, tmp.Name()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyTree копирует файл, ссылку или директорию с сохранением прав, времени изменения
+// и владельца, которые могли быть заданы Chown/ChmodDir/ChmodFile во временной директории
+func copyTree(src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		_ = os.Remove(dst)
+		if err := os.Symlink(target, dst); err != nil {
+			return err
+		}
+	case info.IsDir():
+		if err := os.MkdirAll(dst, 0700); err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+				return err
+			}
+		}
+		// Права директории применяются после заполнения: директория без записи не заполнится
+		if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+	case info.Mode().IsRegular():
+		if err := copyRegular(src, dst, info.Mode().Perm()); err != nil {
+			return err
+		}
+	default:
+		// Устройства и каналы утилиты извлечения не создают без прав root; пропускаем
+		return nil
+	}
+
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := os.Lchown(dst, int(st.Uid), int(st.Gid)); err != nil && !errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("ошибка смены владельца %s: %w", dst, err)
+		}
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		_ = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return nil
+}
+
+// copyRegular копирует содержимое обычного файла
+func copyRegular(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(filepath.Clean(src))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(filepath.Clean(dst), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// OpenFile не меняет права существующего файла, а umask мог их сузить
+	return os.Chmod(dst, mode)
+}
@@ -0,0 +1,228 @@
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// crossDeviceRename подменяет rename так, будто источник и назначение на разных файловых системах
+func crossDeviceRename(t *testing.T) *int {
+	t.Helper()
+	calls := 0
+	prev := rename
+	rename = func(from, to string) error {
+		calls++
+		return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EXDEV}
+	}
+	t.Cleanup(func() { rename = prev })
+	return &calls
+}
+
+func TestExtractStagingUsesTempDir(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "data.tar")
+	if err := os.WriteFile(archivePath, buildTar(t, []tarEntry{
+		{name: "a.txt", typeflag: tar.TypeReg, body: "a"},
+	}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+	tempDir := t.TempDir()
+
+	fake := runner.NewFakeRunner()
+	em := &ExtractManager{Runner: fake}
+	if _, err := em.ExtractWithResult(archivePath, outputDir, ExtractOptions{OnConflict: ConflictOverwrite, TempDir: tempDir}); err != nil {
+		t.Fatal(err)
+	}
+
+	var staging string
+	for _, call := range fake.Calls {
+		for i, arg := range call.Args {
+			if arg == "-C" && i+1 < len(call.Args) {
+				staging = call.Args[i+1]
+			}
+		}
+	}
+	if filepath.Dir(staging) != tempDir {
+		t.Fatalf("архив извлечен в %q, ожидалась временная директория внутри %s: %q", staging, tempDir, fake.Commands())
+	}
+	if !strings.HasPrefix(filepath.Base(staging), ".go-to-run-extract-") {
+		t.Errorf("неожиданное имя временной директории %q", staging)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("временная директория не удалена: %v", entries)
+	}
+}
+
+func TestExtractStreamStagingUsesTempDir(t *testing.T) {
+	archive := buildTar(t, []tarEntry{{name: "a.txt", typeflag: tar.TypeReg, body: "a"}})
+	outputDir := t.TempDir()
+	tempDir := t.TempDir()
+
+	var moved []string
+	prev := rename
+	rename = func(from, to string) error {
+		moved = append(moved, from)
+		return prev(from, to)
+	}
+	t.Cleanup(func() { rename = prev })
+
+	em := &ExtractManager{}
+	opts := ExtractOptions{OnConflict: ConflictOverwrite, TempDir: tempDir}
+	if err := em.ExtractStream(bytes.NewReader(archive), "tar", outputDir, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(moved) != 1 || !strings.HasPrefix(moved[0], filepath.Join(tempDir, ".go-to-run-extract-")) {
+		t.Fatalf("перенесены %q, ожидалась временная директория внутри %s", moved, tempDir)
+	}
+	checkFiles(t, outputDir, map[string]string{"a.txt": "a"})
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("временная директория не удалена: %v", entries)
+	}
+
+	// Отсутствующая временная директория не подменяется выходной
+	opts.TempDir = filepath.Join(t.TempDir(), "missing")
+	if err := em.ExtractStream(bytes.NewReader(archive), "tar", outputDir, opts); err == nil {
+		t.Error("извлечение потока с отсутствующей временной директорией должно завершиться ошибкой")
+	}
+}
+
+func TestExtractStagingDefaultsToOutputDir(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "data.tar")
+	if err := os.WriteFile(archivePath, buildTar(t, nil), 0600); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+
+	fake := runner.NewFakeRunner()
+	em := &ExtractManager{Runner: fake}
+	if _, err := em.ExtractWithResult(archivePath, outputDir, ExtractOptions{OnConflict: ConflictSkip}); err != nil {
+		t.Fatal(err)
+	}
+	if commands := strings.Join(fake.Commands(), "\n"); !strings.Contains(commands, "-C "+outputDir+"/.go-to-run-extract-") {
+		t.Errorf("без TempDir архив должен извлекаться внутри выходной директории: %s", commands)
+	}
+}
+
+func TestExtractAcrossFilesystemsFallsBackToCopy(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "data.tar")
+	if err := os.WriteFile(archivePath, buildTar(t, []tarEntry{
+		{name: "a.txt", typeflag: tar.TypeReg, body: "new a"},
+		{name: "dir/", typeflag: tar.TypeDir},
+		{name: "dir/c.txt", typeflag: tar.TypeReg, body: "new c"},
+		{name: "dir/link", typeflag: tar.TypeSymlink, linkname: "c.txt"},
+	}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	outputDir := t.TempDir()
+	tempDir := t.TempDir()
+	calls := crossDeviceRename(t)
+
+	em := &ExtractManager{PreferNative: true}
+	result, err := em.ExtractWithResult(archivePath, outputDir, ExtractOptions{OnConflict: ConflictOverwrite, TempDir: tempDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *calls == 0 {
+		t.Fatal("перенос не пытался переименовать записи")
+	}
+	if len(result.Files) != 3 {
+		t.Errorf("перенесено %q, ожидалось 3 записи", result.Files)
+	}
+	checkFiles(t, outputDir, map[string]string{"a.txt": "new a", "dir/c.txt": "new c"})
+	if target, err := os.Readlink(filepath.Join(outputDir, "dir", "link")); err != nil || target != "c.txt" {
+		t.Errorf("ссылка dir/link -> %q, %v", target, err)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("после копирования временная директория не удалена: %v", entries)
+	}
+}
+
+func TestMoveFileAcrossFilesystems(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "result.tar.zst")
+	if err := os.WriteFile(src, []byte("payload"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	dstDir := t.TempDir()
+	dst := filepath.Join(dstDir, "result.tar.zst")
+	crossDeviceRename(t)
+
+	if err := moveFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	checkFiles(t, dstDir, map[string]string{"result.tar.zst": "payload"})
+	if info, err := os.Stat(dst); err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("права результата: %v, %v", info, err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Errorf("исходный файл не удален: %v", err)
+	}
+	if entries, _ := os.ReadDir(dstDir); len(entries) != 1 {
+		t.Errorf("рядом с результатом остались временные файлы: %v", entries)
+	}
+}
+
+func TestMoveEntryOtherRenameErrors(t *testing.T) {
+	prev := rename
+	rename = func(string, string) error { return os.ErrPermission }
+	t.Cleanup(func() { rename = prev })
+
+	src := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(src, []byte("a"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := moveEntry(src, filepath.Join(t.TempDir(), "a.txt")); !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("ошибка %v, ожидалась ошибка переименования без копирования", err)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("при ошибке переименования источник удален: %v", err)
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	if err := checkFreeSpace(dir, 1); err != nil {
+		t.Fatalf("checkFreeSpace(1 байт) = %v", err)
+	}
+	if err := checkFreeSpace(dir, 1<<62); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("ошибка %v, ожидалась ErrInsufficientSpace", err)
+	}
+	if err := checkFreeSpace(filepath.Join(dir, "missing"), 1); err == nil || errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("для несуществующей директории ожидалась ошибка Statfs, получено %v", err)
+	}
+}
+
+func TestSpoolDirHonorsTMPDIR(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	if got := spoolDir(""); got != tmp {
+		t.Errorf("spoolDir(\"\") = %q, ожидался TMPDIR %q", got, tmp)
+	}
+	if got := spoolDir("/srv/spool"); got != "/srv/spool" {
+		t.Errorf("spoolDir = %q, ожидалась явная директория", got)
+	}
+}
+
+func TestExtractRejectsTempDirWithoutSpace(t *testing.T) {
+	// Размер оценивается по архиву; в несуществующей временной директории проверка не проходит
+	archivePath := filepath.Join(t.TempDir(), "data.tar")
+	if err := os.WriteFile(archivePath, buildTar(t, nil), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fake := runner.NewFakeRunner()
+	em := &ExtractManager{Runner: fake}
+	_, err := em.ExtractWithResult(archivePath, t.TempDir(), ExtractOptions{OnConflict: ConflictOverwrite, TempDir: "/nonexistent/go-to-run"})
+	if err == nil {
+		t.Fatal("ожидалась ошибка проверки временной директории")
+	}
+	if len(fake.Calls) != 0 {
+		t.Errorf("извлечение запущено до проверки места: %q", fake.Commands())
+	}
+}
@@ -0,0 +1,214 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+// archiveTools сопоставляет имена инструментов с исполняемыми файлами
+var archiveTools = map[string]string{
+	"tar":    "tar",
+	"gzip":   "gzip",
+	"bzip2":  "bzip2",
+	"xz":     "xz",
+	"unzip":  "unzip",
+	"unrar":  "unrar",
+	"7z":     "7z",
+	"lz4":    "lz4",
+	"zstd":   "zstd",
+	"lzop":   "lzop",
+	"gunzip": "gunzip",
+	"cpio":   "cpio",
+}
+
+// formatTools сопоставляет форматы архивов с утилитами, которыми они извлекаются
+var formatTools = map[string]string{
+	"tar":     "tar",
+	"tar.gz":  "tar",
+	"tar.bz2": "tar",
+	"tar.xz":  "tar",
+	"tar.zst": "tar",
+	"tar.lz4": "tar",
+	"gz":      "gunzip",
+	"bz2":     "bunzip2",
+	"xz":      "xz",
+	"zip":     "unzip",
+	"rar":     "unrar",
+	"7z":      "7z",
+	"lz4":     "lz4",
+	"zst":     "zstd",
+	"lzop":    "lzop",
+	"cpio":    "cpio",
+	"cpio.gz": "cpio",
+}
+
+// FormatSource сообщает, чем извлекается формат архива
+type FormatSource string
+
+// Способы извлечения формата
+const (
+	// FormatNative - встроенными средствами Go
+	FormatNative FormatSource = "native"
+	// FormatExternal - внешней утилитой
+	FormatExternal FormatSource = "external"
+	// FormatUnavailable - нужной утилиты нет, формат не извлекается
+	FormatUnavailable FormatSource = "unavailable"
+)
+
+// CheckFormats сообщает для каждого формата, извлекается ли он встроенными средствами,
+// внешней утилитой или недоступен. Учитывает PreferNative: без него встроенные средства
+// используются только при отсутствии утилиты.
+func (em *ExtractManager) CheckFormats() map[string]FormatSource {
+	result := make(map[string]FormatSource, len(formatTools))
+	for format, tool := range formatTools {
+		switch {
+		case em.useNative(format):
+			result[format] = FormatNative
+		case em.commandExists(tool):
+			result[format] = FormatExternal
+		default:
+			result[format] = FormatUnavailable
+		}
+	}
+	return result
+}
+
+// versionArgs содержит аргументы запроса версии для утилит без --version.
+// unrar и 7z печатают версию в баннере при запуске без аргументов
+var versionArgs = map[string][]string{
+	"unzip": {"-v"},
+	"unrar": {},
+	"7z":    {},
+}
+
+// versionTimeout ограничивает время запроса версии утилиты
+const versionTimeout = 5 * time.Second
+
+// versionPattern - номер версии вида 1.34, v1.5.5 или 16.02
+var versionPattern = regexp.MustCompile(`\bv?(\d+(?:\.\d+)+)`)
+
+// tarFlagMinVersion - минимальная версия GNU tar для флагов сжатия.
+// Более старые версии получают программу распаковки через --use-compress-program.
+// Флага --lz4 в GNU tar нет (он есть только в bsdtar), поэтому его здесь нет
+var tarFlagMinVersion = map[string]string{
+	"--zstd": "1.31",
+}
+
+// ToolInfo содержит сведения об установленном инструменте
+type ToolInfo struct {
+	Present bool
+	Version string
+	Path    string
+}
+
+// CheckToolsDetailed проверяет наличие инструментов и определяет их версии
+func (em *ExtractManager) CheckToolsDetailed() map[string]ToolInfo {
+	result := make(map[string]ToolInfo, len(archiveTools))
+	for name, cmd := range archiveTools {
+		result[name] = em.toolInfo(cmd)
+	}
+	return result
+}
+
+// RequireTool проверяет, что инструмент установлен и его версия не ниже minVersion.
+// Пустая minVersion проверяет только наличие.
+func (em *ExtractManager) RequireTool(name, minVersion string) error {
+	cmd, ok := archiveTools[name]
+	if !ok {
+		cmd = name
+	}
+	info := em.toolInfo(cmd)
+	if !info.Present {
+		return fmt.Errorf("команда %s не найдена", name)
+	}
+	if minVersion == "" {
+		return nil
+	}
+	if info.Version == "" {
+		return fmt.Errorf("не удалось определить версию %s, требуется %s", name, minVersion)
+	}
+	if compareVersions(info.Version, minVersion) < 0 {
+		return fmt.Errorf("версия %s %s ниже требуемой %s", name, info.Version, minVersion)
+	}
+	return nil
+}
+
+// toolInfo находит инструмент в PATH и запрашивает его версию
+func (em *ExtractManager) toolInfo(cmd string) ToolInfo {
+	path, err := em.runner().LookPath(cmd)
+	if err != nil {
+		return ToolInfo{}
+	}
+	output, _ := em.toolVersionOutput(path, cmd)
+	return ToolInfo{Present: true, Path: path, Version: parseToolVersion(output)}
+}
+
+// toolVersionOutput возвращает вывод запроса версии; многие утилиты пишут его в stderr
+// или завершаются с ненулевым кодом, поэтому ошибка запуска не отбрасывает вывод
+func (em *ExtractManager) toolVersionOutput(path, cmd string) (string, error) {
+	args, ok := versionArgs[cmd]
+	if !ok {
+		args = []string{"--version"}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+	var output bytes.Buffer
+	err := em.runner().RunIO(ctx, runner.Streams{Stdout: &output, Stderr: &output}, path, args...)
+	return output.String(), err
+}
+
+// parseToolVersion извлекает номер версии из первой строки вывода, где он встречается:
+// "tar (GNU tar) 1.34", "*** Zstandard CLI (64-bit) v1.5.5, by Yann Collet ***",
+// "bzip2, a block-sorting file compressor.  Version 1.0.8, 13-Jul-2019.", "7-Zip [64] 16.02"
+func parseToolVersion(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if match := versionPattern.FindStringSubmatch(line); match != nil {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// compareVersions сравнивает версии покомпонентно: -1, если a < b, 0 при равенстве, 1, если a > b
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+// tarCompressArgs возвращает аргументы tar для сжатия flag (--zstd, --lz4).
+// GNU tar старше минимальной версии не знает флаг, и программа сжатия
+// передается через --use-compress-program; bsdtar поддерживает оба флага.
+func (em *ExtractManager) tarCompressArgs(flag, program string) ([]string, error) {
+	output, _ := em.toolVersionOutput("tar", "tar")
+	minVersion, known := tarFlagMinVersion[flag]
+	if !strings.Contains(output, "GNU tar") || known && compareVersions(parseToolVersion(output), minVersion) >= 0 {
+		return []string{flag}, nil
+	}
+	if _, err := em.runner().LookPath(program); err != nil {
+		return nil, fmt.Errorf("tar %s не поддерживает %s, а команда %s не найдена",
+			parseToolVersion(output), flag, program)
+	}
+	return []string{"--use-compress-program=" + program}, nil
+}
@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/13winged/go-to-run/internal/runner"
+)
+
+func TestRequireToolUsesRunner(t *testing.T) {
+	fake := runner.NewFakeRunner().On("/usr/bin/zstd --version", "*** Zstandard CLI (64-bit) v1.5.5, by Yann Collet ***\n", nil)
+	fake.Missing["7z"] = true
+	em := &ExtractManager{Runner: fake}
+
+	if err := em.RequireTool("zstd", "1.4"); err != nil {
+		t.Errorf("zstd 1.5.5 >= 1.4: %v", err)
+	}
+	if err := em.RequireTool("zstd", "1.6"); err == nil {
+		t.Error("zstd 1.5.5 < 1.6 должна быть ошибка")
+	}
+	if err := em.RequireTool("7z", ""); err == nil {
+		t.Error("отсутствующий 7z должен давать ошибку")
+	}
+	if info := em.CheckToolsDetailed()["zstd"]; !info.Present || info.Version != "1.5.5" {
+		t.Errorf("zstd: %+v", info)
+	}
+}
+
+func TestListCpioUsesRunner(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "initrd.cpio")
+	if err := os.WriteFile(archivePath, []byte("070701"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fake := runner.NewFakeRunner().On("cpio -t --quiet", "init\nbin/sh\n", nil)
+	em := &ExtractManager{Runner: fake}
+
+	entries, err := em.listCpio(archivePath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"init", "bin/sh"}; !reflect.DeepEqual(entries, want) {
+		t.Errorf("записи %q, ожидалось %q", entries, want)
+	}
+	if commands := fake.Commands(); !reflect.DeepEqual(commands, []string{"cpio -t --quiet"}) {
+		t.Errorf("команды %q", commands)
+	}
+}
+
+func TestParseToolVersion(t *testing.T) {
+	tests := map[string]string{
+		"tar (GNU tar) 1.34\nCopyright (C) 2021 Free Software Foundation, Inc.\n":                      "1.34",
+		"bsdtar 3.7.2 - libarchive 3.7.2 zlib/1.3 liblzma/5.4.5 bz2lib/1.0.8\n":                        "3.7.2",
+		"*** Zstandard CLI (64-bit) v1.5.5, by Yann Collet ***\n":                                      "1.5.5",
+		"bzip2, a block-sorting file compressor.  Version 1.0.8, 13-Jul-2019.\n":                       "1.0.8",
+		"xz (XZ Utils) 5.4.5\nliblzma 5.4.5\n":                                                         "5.4.5",
+		"*** LZ4 command line interface 64-bits v1.9.4, by Yann Collet ***\n":                          "1.9.4",
+		"UnZip 6.00 of 20 April 2009, by Debian. Original by Info-ZIP.\n":                              "6.00",
+		"\n7-Zip [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov : 2016-05-21\np7zip Version 16.02\n": "16.02",
+		"\nUNRAR 6.24 freeware      Copyright (c) 1993-2023 Alexander Roshal\n":                        "6.24",
+		"cpio (GNU cpio) 2.15\n":  "2.15",
+		"usage: tool [options]\n": "",
+	}
+	for output, want := range tests {
+		if got := parseToolVersion(output); got != want {
+			t.Errorf("parseToolVersion(%q) = %q, ожидалось %q", output, got, want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.34", "1.31", 1},
+		{"1.30", "1.31", -1},
+		{"1.31", "1.31", 0},
+		{"1.31.0", "1.31", 0},
+		{"1.9", "1.10", -1},
+		{"16.02", "9.20", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, ожидалось %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestToolVersionArgs(t *testing.T) {
+	fake := runner.NewFakeRunner().
+		On("/usr/bin/unzip -v", "UnZip 6.00 of 20 April 2009, by Debian.\n", nil).
+		On("/usr/bin/7z", "\n7-Zip [64] 16.02 : Copyright (c) 1999-2016 Igor Pavlov\n", nil)
+	em := &ExtractManager{Runner: fake}
+
+	tools := em.CheckToolsDetailed()
+	if info := tools["unzip"]; info.Version != "6.00" || info.Path != "/usr/bin/unzip" {
+		t.Errorf("unzip: %+v", info)
+	}
+	if info := tools["7z"]; info.Version != "16.02" {
+		t.Errorf("7z: %+v", info)
+	}
+}
+
+func TestTarCompressArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		missing []string
+		want    []string
+		wantErr bool
+	}{
+		{"новый GNU tar", "tar (GNU tar) 1.34\n", nil, []string{"--zstd"}, false},
+		{"старый GNU tar", "tar (GNU tar) 1.30\n", nil, []string{"--use-compress-program=zstd"}, false},
+		{"bsdtar", "bsdtar 3.7.2 - libarchive 3.7.2\n", nil, []string{"--zstd"}, false},
+		{"старый GNU tar без zstd", "tar (GNU tar) 1.30\n", []string{"zstd"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := missingRunner(tt.missing...).On("tar --version", tt.version, nil)
+			em := &ExtractManager{Runner: fake}
+
+			got, err := em.tarCompressArgs("--zstd", "zstd")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ошибка = %v, ожидалась ошибка: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("аргументы %q, ожидалось %q", got, tt.want)
+			}
+		})
+	}
+
+	// Для --lz4 у GNU tar нет минимальной версии: программа передается всегда
+	fake := runner.NewFakeRunner().On("tar --version", "tar (GNU tar) 1.35\n", nil)
+	if got, _ := (&ExtractManager{Runner: fake}).tarCompressArgs("--lz4", "lz4"); !reflect.DeepEqual(got, []string{"--use-compress-program=lz4"}) {
+		t.Errorf("--lz4 с GNU tar: %q", got)
+	}
+}
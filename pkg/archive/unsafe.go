@@ -0,0 +1,86 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// UnsafeEntryPolicy определяет, что делать с записью архива, которая после объединения
+// с выходной директорией оказывается за ее пределами (zip-slip: ../../etc/cron.d/evil)
+type UnsafeEntryPolicy string
+
+// Политики обработки небезопасных записей при встроенном извлечении
+const (
+	// UnsafeAbort прерывает извлечение на первой небезопасной записи
+	UnsafeAbort UnsafeEntryPolicy = "abort"
+	// UnsafeSkip пропускает небезопасные записи и извлекает остальные; имена
+	// пропущенных записей возвращаются в ошибке после завершения извлечения
+	UnsafeSkip UnsafeEntryPolicy = "skip"
+)
+
+// ErrUnsafeEntry возвращается, если запись архива выходит за пределы директории извлечения
+var ErrUnsafeEntry = errors.New("запись выходит за пределы директории извлечения")
+
+// SkippedEntriesError возвращается политикой UnsafeSkip, если небезопасные записи были
+// пропущены; остальное содержимое архива при этом извлечено
+type SkippedEntriesError struct {
+	Entries []string
+}
+
+func (e *SkippedEntriesError) Error() string {
+	return fmt.Sprintf("%s: пропущено %d: %s", ErrUnsafeEntry, len(e.Entries), strings.Join(e.Entries, ", "))
+}
+
+func (e *SkippedEntriesError) Unwrap() error {
+	return ErrUnsafeEntry
+}
+
+// validUnsafePolicy проверяет значение ExtractManager.UnsafeEntries
+func validUnsafePolicy(policy UnsafeEntryPolicy) bool {
+	switch policy {
+	case "", UnsafeAbort, UnsafeSkip:
+		return true
+	}
+	return false
+}
+
+// entryGuard применяет политику UnsafeEntries к записям одного извлечения.
+// Записи tar разбираются в одной горутине, но мьютекс делает guard безопасным
+// и для параллельных извлечений с общим ExtractManager.
+type entryGuard struct {
+	skip    bool
+	mu      sync.Mutex
+	skipped []string
+}
+
+func (em *ExtractManager) newGuard() *entryGuard {
+	return &entryGuard{skip: em.UnsafeEntries == UnsafeSkip}
+}
+
+// reject решает судьбу записи name, отклоненной с ошибкой err: при UnsafeSkip
+// небезопасная запись запоминается и nil означает, что извлечение продолжается.
+// Прочие ошибки возвращаются без изменений.
+func (g *entryGuard) reject(name string, err error) error {
+	if g == nil || !g.skip || !errors.Is(err, ErrUnsafeEntry) {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.skipped = append(g.skipped, name)
+	return nil
+}
+
+// err возвращает SkippedEntriesError с именами пропущенных записей или nil
+func (g *entryGuard) err() error {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if len(g.skipped) == 0 {
+		return nil
+	}
+	return &SkippedEntriesError{Entries: append([]string(nil), g.skipped...)}
+}